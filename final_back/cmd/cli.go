@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/service"
+	"go.uber.org/zap"
+)
+
+const usage = `usage: %s [command]
+
+Without a command, the server is started. Commands:
+
+  import [-format csv|ndjson] FILE
+	Import movies from FILE, or from standard input if FILE is -, and print
+	the report. Exits with status 1 if any row failed.
+
+  import-imdb [-min-votes N] [-types movie,...] [-checkpoint FILE] DIR
+	Import movies, their genres and their directors from the IMDb dataset
+	dump in DIR: title.basics.tsv.gz, title.ratings.tsv.gz, name.basics.tsv.gz
+	and optionally title.crew.tsv.gz. An interrupted import carries on from
+	its checkpoint, DIR/imdb-import.checkpoint.json by default, when run
+	again with the same options.
+
+  export [-format csv|ndjson|jsonld] [-o FILE]
+	Export all movies to FILE, or to standard output. The format defaults
+	to the extension of FILE, or to csv.
+`
+
+// runCommand runs a command given on the command line against st and
+// returns the exit status.
+func runCommand(log *zap.Logger, st *storage, args []string) int {
+	switch args[0] {
+	case "import":
+		return runImport(log, st, args[1:])
+	case "import-imdb":
+		return runIMDbImport(log, st, args[1:])
+	case "export":
+		return runExport(log, st, args[1:])
+	}
+	fmt.Fprintf(os.Stderr, usage, filepath.Base(os.Args[0]))
+	return 2
+}
+
+func runImport(log *zap.Logger, st *storage, args []string) int {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := flags.String("format", "", "csv or ndjson; by default told from the file extension")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, usage, filepath.Base(os.Args[0]))
+		return 2
+	}
+
+	path := flags.Arg(0)
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		in = f
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(path), ".")
+		if *format == "jsonl" {
+			*format = models.ImportNDJSON
+		}
+	}
+
+	importSvc := service.NewImportService(log, st.movieRepo, st.personRepo, st.genreRepo, st.userRepo, st.search, st.uow)
+	job, err := importSvc.RunImport(models.NilID, *format, in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(job); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if job.Failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+func runIMDbImport(log *zap.Logger, st *storage, args []string) int {
+	flags := flag.NewFlagSet("import-imdb", flag.ContinueOnError)
+	minVotes := flags.Int("min-votes", 0, "leave out titles with fewer IMDb votes")
+	types := flags.String("types", "movie", "comma-separated title types to import")
+	checkpoint := flags.String("checkpoint", "", "checkpoint file; by default in DIR")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, usage, filepath.Base(os.Args[0]))
+		return 2
+	}
+
+	dir := flags.Arg(0)
+	if *checkpoint == "" {
+		*checkpoint = filepath.Join(dir, "imdb-import.checkpoint.json")
+	}
+	opts := models.IMDbOptions{TitleTypes: strings.Split(*types, ","), MinVotes: *minVotes}
+
+	importSvc := service.NewImportService(log, st.movieRepo, st.personRepo, st.genreRepo, st.userRepo, st.search, st.uow)
+	report, err := importSvc.RunIMDbImport(models.NilID, os.DirFS(dir), *checkpoint, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if report.Failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+func runExport(log *zap.Logger, st *storage, args []string) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := flags.String("format", "", "csv, ndjson or jsonld; by default told from the file extension")
+	path := flags.String("o", "", "file to write to instead of standard output")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		fmt.Fprintf(os.Stderr, usage, filepath.Base(os.Args[0]))
+		return 2
+	}
+
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(*path), ".")
+		switch *format {
+		case "":
+			*format = models.ExportCSV
+		case "jsonl":
+			*format = models.ExportNDJSON
+		}
+	}
+	if _, ok := models.ExportContentTypes[*format]; !ok {
+		fmt.Fprintf(os.Stderr, "%v: unknown format %q\n", models.ErrInvalidExport, *format)
+		return 2
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	if *path != "" {
+		f, err := os.Create(*path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		out = bufio.NewWriter(f)
+	}
+
+	movieSvc := service.NewMovieService(log, st.movieRepo, st.userRepo, st.personRepo, st.genreRepo, st.creditRepo, st.tagRepo, st.releaseRepo, st.revisionRepo, st.search, st.facets, st.uow)
+	if err := movieSvc.ExportMovies(*format, out); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := out.Flush(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"os"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,33 +15,75 @@ import (
 	"go.uber.org/zap"
 )
 
+// storage holds the repositories of the configured backend.
+type storage struct {
+	userRepo        repository.UserRepo
+	movieRepo       repository.MovieRepo
+	reviewRepo      repository.ReviewRepo
+	personRepo      repository.PersonRepo
+	genreRepo       repository.GenreRepo
+	creditRepo      repository.CreditRepo
+	revisionRepo    repository.RevisionRepo
+	suggestionRepo  repository.SuggestionRepo
+	translationRepo repository.TranslationRepo
+	tagRepo         repository.TagRepo
+	releaseRepo     repository.ReleaseRepo
+	watchlistRepo   repository.WatchlistRepo
+	uow             repository.UnitOfWork
+	search          repository.SearchIndex
+	facets          repository.FacetCounter
+	blobs           repository.BlobStore
+	closers         []func()
+}
+
+func (st *storage) close() {
+	for _, close := range st.closers {
+		close()
+	}
+	st.closers = nil
+}
+
+// main runs the server, or the command named by its first argument.
 func main() {
 	log := logger.New()
 	defer log.Sync()
 
 	cfg := config.New(log)
 
-	mongoDB, mongoClient, collectionNames := db.New(log, cfg.MongoURI, cfg.MongoDB)
-	defer func() {
-		if err := mongoClient.Disconnect(context.TODO()); err != nil {
-			log.Fatal(err.Error())
-		}
-	}()
+	st := openStorage(log, cfg)
+	defer st.close()
+
+	if len(os.Args) > 1 {
+		code := runCommand(log, st, os.Args[1:])
+		st.close()
+		log.Sync()
+		os.Exit(code)
+	}
 
 	router := gin.Default()
 
 	jwtSvc := service.NewJWTService(cfg.JWTSecret, time.Duration(cfg.JWTDurationInMinutes*int(time.Minute)), time.Duration(cfg.JWTRefreshDurationInMinutes*int(time.Minute)))
 
-	userRepo := repository.NewUserRepo(log, collectionNames, mongoDB)
-	userSvc := service.NewUserService(log, userRepo, jwtSvc)
-
-	movieRepo := repository.NewMovieRepo(log, collectionNames, mongoDB)
-	movieSvc := service.NewMovieService(log, movieRepo, userRepo)
+	userSvc := service.NewUserService(log, st.userRepo, st.movieRepo, jwtSvc, st.uow, st.search)
+	movieSvc := service.NewMovieService(log, st.movieRepo, st.userRepo, st.personRepo, st.genreRepo, st.creditRepo, st.tagRepo, st.releaseRepo, st.revisionRepo, st.search, st.facets, st.uow)
+	reviewSvc := service.NewReviewService(log, st.reviewRepo, st.userRepo, st.movieRepo, st.uow, st.search)
+	personSvc := service.NewPersonService(log, st.personRepo, st.movieRepo, st.creditRepo, st.userRepo)
+	genreSvc := service.NewGenreService(log, st.genreRepo, st.movieRepo, st.userRepo)
+	creditSvc := service.NewCreditService(log, st.creditRepo, st.movieRepo, st.personRepo, st.userRepo)
+	maxPosterSize := int64(cfg.PosterMaxSizeInKB) * 1024
+	mediaSvc := service.NewMediaService(log, st.blobs, st.movieRepo, st.userRepo, st.uow, maxPosterSize)
+	importSvc := service.NewImportService(log, st.movieRepo, st.personRepo, st.genreRepo, st.userRepo, st.search, st.uow)
+	suggestionSvc := service.NewSuggestionService(log, st.suggestionRepo, st.movieRepo, st.userRepo, st.personRepo, st.genreRepo, st.uow, st.search)
+	translationSvc := service.NewTranslationService(log, st.translationRepo, st.movieRepo, st.reviewRepo, st.userRepo)
+	tagSvc := service.NewTagService(log, st.tagRepo, st.movieRepo, st.userRepo, st.uow)
+	releaseSvc := service.NewReleaseService(log, st.releaseRepo, st.movieRepo, st.userRepo)
+	watchlistSvc := service.NewWatchlistService(log, st.watchlistRepo, st.movieRepo, st.userRepo)
+	calendarSvc := service.NewCalendarService(log, st.releaseRepo, st.movieRepo, st.watchlistRepo, st.userRepo, translationSvc)
 
-	reviewRepo := repository.NewReviewRepo(log, collectionNames, mongoDB)
-	reviewSvc := service.NewReviewService(log, reviewRepo, userRepo)
+	purgeSvc := service.NewPurgeService(log, st.uow, time.Duration(cfg.TrashRetentionInHours)*time.Hour)
+	go purgeSvc.Run(context.Background(), time.Duration(cfg.PurgeIntervalInMinutes)*time.Minute)
 
-	ctrl := controller.New(router, log, userSvc, movieSvc, reviewSvc, jwtSvc)
+	ctrl := controller.New(router, log, userSvc, movieSvc, reviewSvc, personSvc, genreSvc, creditSvc, mediaSvc, importSvc, suggestionSvc, translationSvc, tagSvc, releaseSvc, watchlistSvc, calendarSvc, jwtSvc, maxPosterSize)
 	ctrl.Bind()
 
 	log.Info("Starting server", zap.String("port", cfg.Port))
@@ -48,3 +91,96 @@ func main() {
 		log.Fatal("Failed to start server", zap.Error(err))
 	}
 }
+
+// openStorage connects to the storage backend selected in cfg.
+func openStorage(log *zap.Logger, cfg *config.Config) *storage {
+	st := &storage{}
+
+	switch cfg.Storage {
+	case config.StorageMemory:
+		log.Warn("Using in-memory storage, data will be lost on restart")
+		st.userRepo = repository.NewMemoryUserRepo()
+		st.movieRepo = repository.NewMemoryMovieRepo()
+		st.reviewRepo = repository.NewMemoryReviewRepo()
+		st.personRepo = repository.NewMemoryPersonRepo()
+		st.genreRepo = repository.NewMemoryGenreRepo()
+		st.creditRepo = repository.NewMemoryCreditRepo()
+		st.revisionRepo = repository.NewMemoryRevisionRepo()
+		st.suggestionRepo = repository.NewMemorySuggestionRepo()
+		st.translationRepo = repository.NewMemoryTranslationRepo()
+		st.tagRepo = repository.NewMemoryTagRepo()
+		st.releaseRepo = repository.NewMemoryReleaseRepo()
+		st.watchlistRepo = repository.NewMemoryWatchlistRepo()
+		st.uow = repository.NewMemoryUnitOfWork(repository.Repos{Users: st.userRepo, Movies: st.movieRepo, Reviews: st.reviewRepo, Credits: st.creditRepo, Revisions: st.revisionRepo, Translations: st.translationRepo, Tags: st.tagRepo, Releases: st.releaseRepo, Watchlists: st.watchlistRepo, Suggestions: st.suggestionRepo})
+		st.search = repository.NewMemorySearchIndex()
+		st.facets = repository.NewMemoryFacetCounter(st.movieRepo, st.tagRepo)
+		st.blobs = repository.NewS3BlobStore(repository.NewMemoryS3Client(), "media")
+	case config.StoragePostgres, config.StorageSQLite:
+		dialect, dsn := sqlDatabase(cfg)
+		sqlDB := db.NewSQL(log, dialect, dsn)
+		st.closers = append(st.closers, func() { sqlDB.Close() })
+
+		st.userRepo = repository.NewSQLUserRepo(sqlDB)
+		st.movieRepo = repository.NewSQLMovieRepo(sqlDB)
+		st.reviewRepo = repository.NewSQLReviewRepo(log, sqlDB)
+		st.personRepo = repository.NewSQLPersonRepo(sqlDB)
+		st.genreRepo = repository.NewSQLGenreRepo(sqlDB)
+		st.creditRepo = repository.NewSQLCreditRepo(sqlDB)
+		st.revisionRepo = repository.NewSQLRevisionRepo(sqlDB)
+		st.suggestionRepo = repository.NewSQLSuggestionRepo(sqlDB)
+		st.translationRepo = repository.NewSQLTranslationRepo(sqlDB)
+		st.tagRepo = repository.NewSQLTagRepo(sqlDB)
+		st.releaseRepo = repository.NewSQLReleaseRepo(sqlDB)
+		st.watchlistRepo = repository.NewSQLWatchlistRepo(sqlDB)
+		st.uow = repository.NewSQLUnitOfWork(sqlDB)
+		// The search index lives in this process and only sees the writes
+		// this process makes, so the SQL backends must run as a single
+		// instance. Another instance writing to the same database would
+		// leave this index stale until restart.
+		st.search = repository.NewMemorySearchIndex()
+		if err := repository.IndexMovies(st.search, st.movieRepo); err != nil {
+			log.Fatal("couldn't build search index", zap.Error(err))
+		}
+		st.facets = repository.NewSQLFacetCounter(sqlDB)
+	default:
+		mongoDB, mongoClient, collectionNames := db.New(log, cfg.MongoURI, cfg.MongoDB)
+		st.closers = append(st.closers, func() {
+			if err := mongoClient.Disconnect(context.TODO()); err != nil {
+				log.Fatal(err.Error())
+			}
+		})
+
+		st.userRepo = repository.NewUserRepo(log, collectionNames, mongoDB)
+		st.movieRepo = repository.NewMovieRepo(log, collectionNames, mongoDB)
+		st.reviewRepo = repository.NewReviewRepo(log, collectionNames, mongoDB)
+		st.personRepo = repository.NewPersonRepo(log, collectionNames, mongoDB)
+		st.genreRepo = repository.NewGenreRepo(log, collectionNames, mongoDB)
+		st.creditRepo = repository.NewCreditRepo(log, collectionNames, mongoDB)
+		st.revisionRepo = repository.NewRevisionRepo(log, collectionNames, mongoDB)
+		st.suggestionRepo = repository.NewSuggestionRepo(log, collectionNames, mongoDB)
+		st.translationRepo = repository.NewTranslationRepo(log, collectionNames, mongoDB)
+		st.tagRepo = repository.NewTagRepo(log, collectionNames, mongoDB)
+		st.releaseRepo = repository.NewReleaseRepo(log, collectionNames, mongoDB)
+		st.watchlistRepo = repository.NewWatchlistRepo(log, collectionNames, mongoDB)
+		st.uow = repository.NewMongoUnitOfWork(mongoDB)
+		st.search = repository.NewMongoSearchIndex(log, mongoDB)
+		st.facets = repository.NewMongoFacetCounter(mongoDB)
+	}
+
+	if st.blobs == nil {
+		var err error
+		if st.blobs, err = repository.NewFSBlobStore(cfg.MediaDir); err != nil {
+			log.Fatal("couldn't open media store", zap.Error(err))
+		}
+	}
+	return st
+}
+
+// sqlDatabase returns the dialect and data source name for the SQL storage
+// backend selected in cfg.
+func sqlDatabase(cfg *config.Config) (string, string) {
+	if cfg.Storage == config.StorageSQLite {
+		return db.DialectSQLite, cfg.SQLitePath
+	}
+	return db.DialectPostgres, cfg.PostgresDSN
+}
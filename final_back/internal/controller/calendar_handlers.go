@@ -0,0 +1,82 @@
+package controller
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.uber.org/zap"
+)
+
+// calendarExt ends the paths of calendar feeds, which some calendar apps
+// look for.
+const calendarExt = ".ics"
+
+// ReleaseCalendar serves releases as an iCalendar feed. Like
+// UpcomingReleases it takes country and type, but the country defaults to
+// every country, since calendar apps rarely send Accept-Language.
+func (ctrl *controller) ReleaseCalendar(c *gin.Context) {
+	releaseType, ok := calendarReleaseType(c)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	err := ctrl.calendarSvc.ReleaseCalendar(&buf, c.Query("country"), releaseType, acceptLanguages(c))
+	if err != nil {
+		if err == models.ErrInvalidCountry {
+			c.JSON(400, gin.H{"error": "Invalid country"})
+			return
+		}
+		ctrl.log.Error("failed to write release calendar", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to get calendar"})
+		return
+	}
+	c.Data(200, models.CalendarContentType, buf.Bytes())
+}
+
+// WatchlistCalendar serves the releases of the movies on a user's watchlist
+// as an iCalendar feed. It takes no access token: the secret token in the
+// path stands in for one, since calendar apps cannot send headers.
+func (ctrl *controller) WatchlistCalendar(c *gin.Context) {
+	token, ok := strings.CutSuffix(c.Param("file"), calendarExt)
+	if !ok || token == "" {
+		c.JSON(404, gin.H{"error": "Calendar not found"})
+		return
+	}
+	releaseType, ok := calendarReleaseType(c)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	err := ctrl.calendarSvc.WatchlistCalendar(&buf, token, c.Query("country"), releaseType, acceptLanguages(c))
+	if err != nil {
+		switch err {
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Calendar not found"})
+		case models.ErrInvalidCountry:
+			c.JSON(400, gin.H{"error": "Invalid country"})
+		default:
+			ctrl.log.Error("failed to write watchlist calendar", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to get calendar"})
+		}
+		return
+	}
+	// The address is a secret, so the feed is kept out of shared caches.
+	c.Header("Cache-Control", "private")
+	c.Data(200, models.CalendarContentType, buf.Bytes())
+}
+
+// calendarReleaseType reads the type query of a calendar request,
+// answering 400 and returning false if it is not a release type.
+func calendarReleaseType(c *gin.Context) (models.ReleaseType, bool) {
+	releaseType := models.ReleaseType(c.Query("type"))
+	if releaseType != "" && releaseType != models.ReleaseTheatrical && releaseType != models.ReleaseStreaming {
+		c.JSON(400, gin.H{"error": "Invalid release type"})
+		return "", false
+	}
+	return releaseType, true
+}
@@ -12,17 +12,46 @@ type controller struct {
 	router    *gin.Engine
 	movieSvc  service.MovieService
 	reviewSvc service.ReviewService
+	personSvc service.PersonService
+	genreSvc  service.GenreService
+	creditSvc service.CreditService
+	mediaSvc  service.MediaService
+	importSvc service.ImportService
 	jwtSvc    service.JWTService
+
+	suggestionSvc  service.SuggestionService
+	translationSvc service.TranslationService
+	tagSvc         service.TagService
+	releaseSvc     service.ReleaseService
+	watchlistSvc   service.WatchlistService
+	calendarSvc    service.CalendarService
+
+	// maxPosterSize lets oversized uploads be turned away before they are
+	// read into memory.
+	maxPosterSize int64
 }
 
-func New(router *gin.Engine, logger *zap.Logger, usersvc service.UserService, movieSvc service.MovieService, reviewSvc service.ReviewService, jwtSvc service.JWTService) *controller {
+func New(router *gin.Engine, logger *zap.Logger, usersvc service.UserService, movieSvc service.MovieService, reviewSvc service.ReviewService, personSvc service.PersonService, genreSvc service.GenreService, creditSvc service.CreditService, mediaSvc service.MediaService, importSvc service.ImportService, suggestionSvc service.SuggestionService, translationSvc service.TranslationService, tagSvc service.TagService, releaseSvc service.ReleaseService, watchlistSvc service.WatchlistService, calendarSvc service.CalendarService, jwtSvc service.JWTService, maxPosterSize int64) *controller {
 	return &controller{
 		log:       logger,
 		usersvc:   usersvc,
 		router:    router,
 		movieSvc:  movieSvc,
 		reviewSvc: reviewSvc,
+		personSvc: personSvc,
+		genreSvc:  genreSvc,
+		creditSvc: creditSvc,
+		mediaSvc:  mediaSvc,
+		importSvc: importSvc,
 		jwtSvc:    jwtSvc,
+
+		suggestionSvc:  suggestionSvc,
+		translationSvc: translationSvc,
+		tagSvc:         tagSvc,
+		releaseSvc:     releaseSvc,
+		watchlistSvc:   watchlistSvc,
+		calendarSvc:    calendarSvc,
+		maxPosterSize:  maxPosterSize,
 	}
 }
 
@@ -40,23 +69,119 @@ func (c *controller) Bind() {
 		users.GET("/me", c.GetMe)
 		users.PUT("/me", c.UpdateMe)
 		users.DELETE("/me", c.DeleteMe)
+		users.POST("/me/avatar", c.UploadAvatar)
+		users.GET("/me/watchlist", c.ListWatchlist)
+		users.POST("/me/watchlist", c.AddToWatchlist)
+		users.DELETE("/me/watchlist/:movieId", c.RemoveFromWatchlist)
+		users.GET("/me/calendar", c.GetCalendarFeed)
+		users.POST("/me/calendar/reset", c.ResetCalendarFeed)
 		users.GET("/:id", c.GetUser)
 
 		// for moderators and admin
 		users.PUT("/:id", c.UpdateUser)
 		users.DELETE("/:id", c.DeleteUser)
+		users.GET("/trash", c.ListDeletedUsers)
+		users.POST("/:id/restore", c.RestoreUser)
 	}
 
 	movies := c.router.Group("/movies")
 	{
 		// 	// common
 		movies.GET("/", c.ListMovies)
+		movies.GET("/search", c.SearchMovies)
+		movies.GET("/export", c.ExportMovies)
 		movies.GET("/:id", c.GetMovie)
+		movies.GET("/:id/credits", c.ListMovieCredits)
+		movies.GET("/:id/history", c.ListMovieHistory)
+		movies.GET("/:id/translations", c.ListMovieTranslations)
+		movies.GET("/:id/tags", c.ListMovieTags)
+		movies.GET("/:id/releases", c.ListMovieReleases)
+
+		// 	// users suggest, moderators and admin tag directly
+		movies.POST("/:id/tags", c.TagMovie)
 
 		// 	// for moderators and admin
 		movies.POST("/", c.CreateMovie)
 		movies.PUT("/:id", c.UpdateMovie)
 		movies.DELETE("/:id", c.DeleteMovie)
+		movies.GET("/trash", c.ListDeletedMovies)
+		movies.GET("/duplicates", c.ListDuplicateMovies)
+		movies.POST("/:id/restore", c.RestoreMovie)
+		movies.POST("/:id/history/:version/revert", c.RevertMovie)
+		movies.POST("/:id/merge", c.MergeMovies)
+		movies.POST("/:id/credits", c.CreateCredit)
+		movies.PUT("/:id/credits/:creditId", c.UpdateCredit)
+		movies.DELETE("/:id/credits/:creditId", c.DeleteCredit)
+		movies.DELETE("/:id/tags/:tagId", c.UntagMovie)
+		movies.POST("/:id/releases", c.CreateRelease)
+		movies.PUT("/:id/releases/:releaseId", c.UpdateRelease)
+		movies.DELETE("/:id/releases/:releaseId", c.DeleteRelease)
+		movies.POST("/:id/poster", c.UploadPoster)
+		movies.POST("/import", c.ImportMovies)
+		movies.GET("/import/:jobId", c.GetImport)
+
+		// 	// for admin
+		movies.PUT("/:id/translations/:locale", c.PutMovieTranslation)
+		movies.DELETE("/:id/translations/:locale", c.DeleteMovieTranslation)
+	}
+
+	c.router.GET("/media/:key", c.GetMedia)
+	c.router.GET("/releases/upcoming", c.UpcomingReleases)
+	c.router.GET("/calendar/releases.ics", c.ReleaseCalendar)
+	c.router.GET("/calendar/watchlist/:file", c.WatchlistCalendar)
+
+	people := c.router.Group("/people")
+	{
+		// common
+		people.GET("/", c.ListPeople)
+		people.GET("/:id", c.GetPerson)
+		people.GET("/:id/filmography", c.GetFilmography)
+
+		// for moderators and admin
+		people.POST("/", c.CreatePerson)
+		people.PUT("/:id", c.UpdatePerson)
+		people.DELETE("/:id", c.DeletePerson)
+	}
+
+	genres := c.router.Group("/genres")
+	{
+		// common
+		genres.GET("/", c.ListGenres)
+		genres.GET("/:id", c.GetGenre)
+
+		// for moderators and admin
+		genres.POST("/", c.CreateGenre)
+		genres.PUT("/:id", c.UpdateGenre)
+		genres.DELETE("/:id", c.DeleteGenre)
+	}
+
+	tags := c.router.Group("/tags")
+	{
+		// common
+		tags.GET("/", c.ListTags)
+		tags.GET("/:id", c.GetTag)
+
+		// users own, all of them for moderators and admin
+		tags.GET("/suggestions", c.ListTagSuggestions)
+
+		// for moderators and admin
+		tags.POST("/", c.CreateTag)
+		tags.PUT("/:id", c.UpdateTag)
+		tags.DELETE("/:id", c.DeleteTag)
+		tags.POST("/suggestions/:id/approve", c.ApproveTagSuggestion)
+		tags.POST("/suggestions/:id/reject", c.RejectTagSuggestion)
+	}
+
+	suggestions := c.router.Group("/suggestions")
+	{
+		// users own, all of them for moderators and admin
+		suggestions.GET("/", c.ListSuggestions)
+		suggestions.GET("/:id", c.GetSuggestion)
+		suggestions.POST("/", c.CreateSuggestion)
+
+		// for moderators and admin
+		suggestions.POST("/:id/approve", c.ApproveSuggestion)
+		suggestions.POST("/:id/reject", c.RejectSuggestion)
 	}
 
 	reviews := c.router.Group("/reviews")
@@ -64,6 +189,7 @@ func (c *controller) Bind() {
 		// common
 		reviews.GET("/:movieId", c.ListReviewsByMovieID)
 		reviews.GET("/categories", c.ListReviewCategories)
+		reviews.GET("/categories/:id/translations", c.ListReviewCategoryTranslations)
 		reviews.PUT("/:id", c.UpdateReview)
 
 		// users own
@@ -72,5 +198,16 @@ func (c *controller) Bind() {
 
 		// 	// for moderators and admin
 		reviews.DELETE("/:id", c.DeleteReview)
+		reviews.GET("/trash", c.ListDeletedReviews)
+		reviews.POST("/:id/restore", c.RestoreReview)
+		reviews.GET("/categories/all", c.ListAllReviewCategories)
+		reviews.POST("/categories", c.CreateReviewCategory)
+		reviews.PUT("/categories/:id", c.UpdateReviewCategory)
+		reviews.POST("/categories/:id/archive", c.ArchiveReviewCategory)
+		reviews.POST("/categories/:id/unarchive", c.UnarchiveReviewCategory)
+
+		// 	// for admin
+		reviews.PUT("/categories/:id/translations/:locale", c.PutReviewCategoryTranslation)
+		reviews.DELETE("/categories/:id/translations/:locale", c.DeleteReviewCategoryTranslation)
 	}
 }
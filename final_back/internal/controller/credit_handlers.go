@@ -0,0 +1,191 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.uber.org/zap"
+)
+
+func (ctrl *controller) ListMovieCredits(c *gin.Context) {
+	movieID, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	credits, err := ctrl.creditSvc.ListMovieCredits(movieID)
+	if err != nil {
+		if err == errs.NotFound {
+			c.JSON(404, gin.H{"error": "Movie not found"})
+			return
+		}
+		ctrl.log.Error("failed to list credits", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to list credits"})
+		return
+	}
+
+	c.JSON(200, models.Page[*models.Credit]{Items: credits})
+}
+
+func (ctrl *controller) CreateCredit(c *gin.Context) {
+	movieID, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.CreateCreditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.log.Error("failed to bind request", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	id, err := ctrl.creditSvc.CreateCredit(actorID.(models.ID), movieID, &req)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Movie not found"})
+		case errs.InvalidReference:
+			c.JSON(422, gin.H{"error": "Unknown person"})
+		default:
+			ctrl.log.Error("failed to create credit", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to create credit"})
+		}
+		return
+	}
+	c.JSON(201, id)
+}
+
+func (ctrl *controller) UpdateCredit(c *gin.Context) {
+	movieID, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+	id, err := models.ParseID(c.Param("creditId"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	version, ok := ifMatch(c)
+	if !ok {
+		ctrl.log.Error("If-Match header is missing")
+		c.JSON(428, gin.H{"error": "If-Match header is required"})
+		return
+	}
+
+	var req models.UpdateCreditRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.log.Error("failed to bind request", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	credit, err := ctrl.creditSvc.UpdateCredit(actorID.(models.ID), movieID, id, version, &req)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Credit not found"})
+		case errs.Conflict:
+			current, err := ctrl.creditSvc.GetCredit(movieID, id)
+			if err != nil {
+				ctrl.log.Error("failed to get credit", zap.Error(err))
+				c.JSON(500, gin.H{"error": "Failed to get credit"})
+				return
+			}
+			setETag(c, current.Version)
+			c.JSON(412, gin.H{"error": "Credit was modified", "current": current})
+		default:
+			ctrl.log.Error("failed to update credit", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to update credit"})
+		}
+		return
+	}
+
+	setETag(c, credit.Version)
+	c.JSON(200, credit)
+}
+
+func (ctrl *controller) DeleteCredit(c *gin.Context) {
+	movieID, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+	id, err := models.ParseID(c.Param("creditId"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	err = ctrl.creditSvc.DeleteCredit(actorID.(models.ID), movieID, id)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Credit not found"})
+		default:
+			ctrl.log.Error("failed to delete credit", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to delete credit"})
+		}
+		return
+	}
+	c.JSON(200, gin.H{"message": "Credit deleted successfully"})
+}
+
+func (ctrl *controller) GetFilmography(c *gin.Context) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	credits, err := ctrl.creditSvc.Filmography(id)
+	if err != nil {
+		if err == errs.NotFound {
+			c.JSON(404, gin.H{"error": "Person not found"})
+			return
+		}
+		ctrl.log.Error("failed to get filmography", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to get filmography"})
+		return
+	}
+
+	c.JSON(200, models.Page[*models.Credit]{Items: credits})
+}
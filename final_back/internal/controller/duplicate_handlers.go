@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.uber.org/zap"
+)
+
+func (ctrl *controller) ListDuplicateMovies(c *gin.Context) {
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	duplicates, err := ctrl.movieSvc.FindDuplicates(actorID.(models.ID))
+	if err != nil {
+		if err == errs.Forbidden {
+			ctrl.log.Error("user does not have permission to list duplicate movies", zap.Error(err))
+			c.JSON(403, gin.H{"error": "Forbidden"})
+			return
+		}
+		ctrl.log.Error("failed to find duplicate movies", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to find duplicate movies"})
+		return
+	}
+	c.JSON(200, duplicates)
+}
+
+func (ctrl *controller) MergeMovies(c *gin.Context) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.MergeMovieRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.log.Error("failed to bind request", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	movie, err := ctrl.movieSvc.MergeMovies(actorID.(models.ID), id, *req.DuplicateID)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			ctrl.log.Error("user does not have permission to merge movies", zap.Error(err))
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Movie not found"})
+		case models.ErrInvalidMerge:
+			c.JSON(400, gin.H{"error": "Cannot merge a movie into itself"})
+		case errs.InvalidReference:
+			c.JSON(422, gin.H{"error": "Duplicate movie not found"})
+		default:
+			ctrl.log.Error("failed to merge movies", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to merge movies"})
+		}
+		return
+	}
+
+	setETag(c, movie.Version)
+	c.JSON(200, movie)
+}
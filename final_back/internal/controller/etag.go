@@ -0,0 +1,28 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag exposes a stored version as a strong entity tag so clients can send
+// it back in If-Match on their next PUT.
+func setETag(c *gin.Context, version int64) {
+	c.Header("ETag", `"`+strconv.FormatInt(version, 10)+`"`)
+}
+
+// ifMatch returns the version in the If-Match header. Both strong and weak
+// tags are accepted; false means the header is missing or not one of ours.
+func ifMatch(c *gin.Context) (int64, bool) {
+	tag := strings.TrimPrefix(strings.TrimSpace(c.GetHeader("If-Match")), "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+	version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return version, true
+}
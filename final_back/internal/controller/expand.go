@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errInvalidExpand = errors.New("invalid expand")
+	errInvalidFacets = errors.New("invalid facets")
+)
+
+// expandOptions reads the comma-separated references a request asks to have
+// expanded, such as ?expand=director,genre, and checks them against allowed.
+func expandOptions(c *gin.Context, allowed ...string) ([]string, error) {
+	return queryNames(c, "expand", allowed, errInvalidExpand)
+}
+
+// facetOptions reads the facets a request asks to have counted, such as
+// ?facets=genre,decade, and checks them against allowed.
+func facetOptions(c *gin.Context, allowed ...string) ([]string, error) {
+	return queryNames(c, "facets", allowed, errInvalidFacets)
+}
+
+// queryNames reads the distinct comma-separated names in the query
+// parameter key, failing with invalid on one not in allowed.
+func queryNames(c *gin.Context, key string, allowed []string, invalid error) ([]string, error) {
+	names := []string{}
+	for _, value := range c.QueryArray(key) {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if !slices.Contains(allowed, name) {
+				return nil, invalid
+			}
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
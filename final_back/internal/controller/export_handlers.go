@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.uber.org/zap"
+)
+
+// ExportMovies streams the whole catalogue in the format given by ?format=,
+// CSV by default. Once the first page is sent the status can no longer
+// change, so a later failure cuts the response short.
+func (ctrl *controller) ExportMovies(c *gin.Context) {
+	format := c.DefaultQuery("format", models.ExportCSV)
+	contentType, ok := models.ExportContentTypes[format]
+	if !ok {
+		c.JSON(400, gin.H{"error": "Invalid format"})
+		return
+	}
+
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="movies.`+format+`"`)
+	c.Status(200)
+	if err := ctrl.movieSvc.ExportMovies(format, c.Writer); err != nil {
+		ctrl.log.Error("failed to export movies", zap.Error(err))
+		c.Abort()
+	}
+}
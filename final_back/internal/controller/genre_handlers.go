@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.uber.org/zap"
+)
+
+func (ctrl *controller) ListGenres(c *gin.Context) {
+	opts, err := listOptions(c, models.GenreListFields)
+	if err != nil {
+		ctrl.log.Error("failed to parse list options", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid list options"})
+		return
+	}
+
+	genres, err := ctrl.genreSvc.ListGenres(opts)
+	if err != nil {
+		ctrl.log.Error("failed to list genres", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to list genres"})
+		return
+	}
+
+	c.JSON(200, genres)
+}
+
+func (ctrl *controller) GetGenre(c *gin.Context) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	genre, err := ctrl.genreSvc.GetGenre(id)
+	if err != nil {
+		if err == errs.NotFound {
+			c.JSON(404, gin.H{"error": "Genre not found"})
+			return
+		}
+		ctrl.log.Error("failed to get genre", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to get genre"})
+		return
+	}
+
+	setETag(c, genre.Version)
+	c.JSON(200, genre)
+}
+
+func (ctrl *controller) CreateGenre(c *gin.Context) {
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.CreateGenreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.log.Error("failed to bind request", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	id, err := ctrl.genreSvc.CreateGenre(actorID.(models.ID), &req)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+			return
+		case errs.AlreadyExists:
+			c.JSON(409, gin.H{"error": "Genre already exists"})
+			return
+		}
+		ctrl.log.Error("failed to create genre", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to create genre"})
+		return
+	}
+	c.JSON(201, id)
+}
+
+func (ctrl *controller) UpdateGenre(c *gin.Context) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	version, ok := ifMatch(c)
+	if !ok {
+		ctrl.log.Error("If-Match header is missing")
+		c.JSON(428, gin.H{"error": "If-Match header is required"})
+		return
+	}
+
+	var req models.UpdateGenreRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.log.Error("failed to bind request", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	genre, err := ctrl.genreSvc.UpdateGenre(actorID.(models.ID), id, version, &req)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Genre not found"})
+		case errs.AlreadyExists:
+			c.JSON(409, gin.H{"error": "Genre already exists"})
+		case errs.Conflict:
+			current, err := ctrl.genreSvc.GetGenre(id)
+			if err != nil {
+				ctrl.log.Error("failed to get genre", zap.Error(err))
+				c.JSON(500, gin.H{"error": "Failed to get genre"})
+				return
+			}
+			setETag(c, current.Version)
+			c.JSON(412, gin.H{"error": "Genre was modified", "current": current})
+		default:
+			ctrl.log.Error("failed to update genre", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to update genre"})
+		}
+		return
+	}
+
+	setETag(c, genre.Version)
+	c.JSON(200, genre)
+}
+
+func (ctrl *controller) DeleteGenre(c *gin.Context) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	err = ctrl.genreSvc.DeleteGenre(actorID.(models.ID), id)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Genre not found"})
+		case errs.InUse:
+			c.JSON(409, gin.H{"error": "Genre is still used by movies"})
+		default:
+			ctrl.log.Error("failed to delete genre", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to delete genre"})
+		}
+		return
+	}
+	c.JSON(200, gin.H{"message": "Genre deleted successfully"})
+}
@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.uber.org/zap"
+)
+
+// maxImportSize bounds the body of an import, which is read whole before the
+// import starts in the background.
+const maxImportSize = 32 << 20
+
+// importFormats maps the content types an import may be sent as to formats.
+var importFormats = map[string]string{
+	"text/csv":             models.ImportCSV,
+	"application/x-ndjson": models.ImportNDJSON,
+	"application/ndjson":   models.ImportNDJSON,
+}
+
+// ImportMovies starts an import of the request body, whose format is given
+// by ?format= or else by its content type.
+func (ctrl *controller) ImportMovies(c *gin.Context) {
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		contentType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		format = importFormats[contentType]
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(413, gin.H{"error": "Import is too large"})
+		return
+	}
+	if err != nil {
+		ctrl.log.Error("failed to read import", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	job, err := ctrl.importSvc.StartImport(actorID.(models.ID), format, data)
+	if err != nil {
+		if err == errs.Forbidden {
+			c.JSON(403, gin.H{"error": "Forbidden"})
+			return
+		}
+		if errors.Is(err, models.ErrInvalidImport) {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		ctrl.log.Error("failed to start import", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to start import"})
+		return
+	}
+
+	c.Header("Location", "/movies/import/"+job.ID.String())
+	c.JSON(202, job)
+}
+
+func (ctrl *controller) GetImport(c *gin.Context) {
+	id, err := models.ParseID(c.Param("jobId"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	job, err := ctrl.importSvc.GetImport(actorID.(models.ID), id)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Import not found"})
+		default:
+			ctrl.log.Error("failed to get import", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to get import"})
+		}
+		return
+	}
+	c.JSON(200, job)
+}
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+// timeRangeParams are shorthands for filters on the audit timestamps. After
+// bounds are inclusive and Before bounds exclusive.
+var timeRangeParams = map[string]struct {
+	field string
+	op    models.Op
+}{
+	"createdAfter":  {models.SortCreatedAt, models.OpGte},
+	"createdBefore": {models.SortCreatedAt, models.OpLt},
+	"updatedAfter":  {models.SortUpdatedAt, models.OpGte},
+	"updatedBefore": {models.SortUpdatedAt, models.OpLt},
+}
+
+// listOptions reads the list query of a request for a resource with the
+// given list fields: sort, limit, cursor and field filters such as
+// year>=2000. Parameters that are not list fields are left to the handler.
+func listOptions(c *gin.Context, fields models.ListFields) (models.ListOptions, error) {
+	opts := models.ListOptions{
+		Sort:   c.Query("sort"),
+		Cursor: c.Query("cursor"),
+	}
+	limit, err := listLimit(c)
+	if err != nil {
+		return opts, err
+	}
+	opts.Limit = limit
+
+	query := c.Request.URL.Query()
+	for _, key := range slices.Sorted(maps.Keys(query)) {
+		for _, value := range query[key] {
+			if param, ok := timeRangeParams[key]; ok {
+				t, err := models.FieldTime.Parse(value)
+				if err != nil {
+					return opts, models.ErrInvalidFilter
+				}
+				opts.Filters = append(opts.Filters, models.Filter{Field: param.field, Op: param.op, Value: t})
+				continue
+			}
+
+			filter, ok, err := fields.ParseFilter(key, value)
+			if err != nil {
+				return opts, err
+			}
+			if ok {
+				opts.Filters = append(opts.Filters, filter)
+			}
+		}
+	}
+	return opts, opts.Validate(fields)
+}
+
+// listLimit reads the page size a request asks for, which defaults to
+// models.DefaultListLimit.
+func listLimit(c *gin.Context) (int, error) {
+	limit := c.Query("limit")
+	if limit == "" {
+		return models.DefaultListLimit, nil
+	}
+	n, err := strconv.Atoi(limit)
+	if err != nil || n < 1 || n > models.MaxListLimit {
+		return 0, models.ErrInvalidLimit
+	}
+	return n, nil
+}
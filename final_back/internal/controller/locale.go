@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// acceptLanguages returns the locales of the Accept-Language header in
+// canonical form, most preferred first. A malformed header counts as none.
+// Responses that depend on it are marked as varying by it.
+func acceptLanguages(c *gin.Context) []string {
+	c.Header("Vary", "Accept-Language")
+
+	tags, _, err := language.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	if err != nil {
+		return nil
+	}
+	locales := []string{}
+	for _, tag := range tags {
+		// "*" parses as mul, which names no language to translate into.
+		if tag != language.Und && tag.String() != "mul" {
+			locales = append(locales, tag.String())
+		}
+	}
+	return locales
+}
+
+// acceptRegion returns the country of the most preferred locale of the
+// Accept-Language header that names one, such as "US" for en-US, or "" if
+// none does.
+func acceptRegion(c *gin.Context) string {
+	for _, locale := range acceptLanguages(c) {
+		region, confidence := language.Make(locale).Region()
+		if confidence == language.Exact && region.IsCountry() {
+			return region.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.uber.org/zap"
+)
+
+// UploadPoster takes a poster image as the "poster" field of a multipart
+// form. Like any other movie write it needs the movie's version in If-Match.
+func (ctrl *controller) UploadPoster(c *gin.Context) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	version, ok := ifMatch(c)
+	if !ok {
+		ctrl.log.Error("If-Match header is missing")
+		c.JSON(428, gin.H{"error": "If-Match header is required"})
+		return
+	}
+
+	data, ok := ctrl.formImage(c, "poster", "Poster")
+	if !ok {
+		return
+	}
+
+	movie, err := ctrl.mediaSvc.UploadPoster(actorID.(models.ID), id, version, data)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Movie not found"})
+		case models.ErrImageTooLarge:
+			c.JSON(413, gin.H{"error": "Poster is too large"})
+		case models.ErrUnsupportedImage:
+			c.JSON(415, gin.H{"error": "Poster must be a JPEG, PNG or GIF image"})
+		case errs.Conflict:
+			current, err := ctrl.movieSvc.GetMovie(id)
+			if err != nil {
+				ctrl.log.Error("failed to get movie", zap.Error(err))
+				c.JSON(500, gin.H{"error": "Failed to get movie"})
+				return
+			}
+			setETag(c, current.Version)
+			c.JSON(412, gin.H{"error": "Movie was modified", "current": current})
+		default:
+			ctrl.log.Error("failed to upload poster", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to upload poster"})
+		}
+		return
+	}
+
+	setETag(c, movie.Version)
+	c.JSON(200, movie)
+}
+
+// UploadAvatar takes the caller's avatar image as the "avatar" field of a
+// multipart form. Like other updates of the caller it needs their version in
+// If-Match.
+func (ctrl *controller) UploadAvatar(c *gin.Context) {
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	version, ok := ifMatch(c)
+	if !ok {
+		ctrl.log.Error("If-Match header is missing")
+		c.JSON(428, gin.H{"error": "If-Match header is required"})
+		return
+	}
+
+	data, ok := ctrl.formImage(c, "avatar", "Avatar")
+	if !ok {
+		return
+	}
+
+	user, err := ctrl.mediaSvc.UploadAvatar(actorID.(models.ID), version, data)
+	if err != nil {
+		switch err {
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "User not found"})
+		case models.ErrImageTooLarge:
+			c.JSON(413, gin.H{"error": "Avatar is too large"})
+		case models.ErrUnsupportedImage:
+			c.JSON(415, gin.H{"error": "Avatar must be a JPEG, PNG or GIF image"})
+		case errs.Conflict:
+			ctrl.userPreconditionFailed(c, actorID.(models.ID))
+		default:
+			ctrl.log.Error("failed to upload avatar", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to upload avatar"})
+		}
+		return
+	}
+
+	setETag(c, user.Version)
+	c.JSON(200, user)
+}
+
+// formImage reads the image uploaded as field of a multipart form, turning
+// away anything larger than posters may be. It writes the error response
+// itself and reports whether it succeeded; name is how messages refer to
+// the image.
+func (ctrl *controller) formImage(c *gin.Context, field, name string) ([]byte, bool) {
+	// Leave room for the rest of the form around the image itself.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, ctrl.maxPosterSize+64*1024)
+	header, err := c.FormFile(field)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(413, gin.H{"error": name + " is too large"})
+		return nil, false
+	}
+	if err != nil {
+		ctrl.log.Error("failed to read "+field, zap.Error(err))
+		c.JSON(400, gin.H{"error": "Missing " + field})
+		return nil, false
+	}
+	if header.Size > ctrl.maxPosterSize {
+		c.JSON(413, gin.H{"error": name + " is too large"})
+		return nil, false
+	}
+	file, err := header.Open()
+	if err != nil {
+		ctrl.log.Error("failed to open "+field, zap.Error(err))
+		c.JSON(400, gin.H{"error": "Missing " + field})
+		return nil, false
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		ctrl.log.Error("failed to read "+field, zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid " + field})
+		return nil, false
+	}
+	return data, true
+}
+
+// GetMedia serves a stored blob. Blobs are content-addressed and never
+// change, so clients and proxies may cache them for good.
+func (ctrl *controller) GetMedia(c *gin.Context) {
+	key := c.Param("key")
+	etag := `"` + key + `"`
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	blob, err := ctrl.mediaSvc.OpenMedia(key)
+	if err != nil {
+		c.Header("Cache-Control", "no-store")
+		c.Header("ETag", "")
+		if err == errs.NotFound {
+			c.JSON(404, gin.H{"error": "Media not found"})
+			return
+		}
+		ctrl.log.Error("failed to open media", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to get media"})
+		return
+	}
+	defer blob.Close()
+
+	c.DataFromReader(200, blob.Size, blob.ContentType, blob, nil)
+}
@@ -14,13 +14,15 @@ func (ctrl *controller) AuthenticateMiddleware() gin.HandlerFunc {
 		}
 
 		userID, roles, err := ctrl.jwtSvc.ParseJWT(token)
-		if userID != nil {
-			c.Set("userID", *userID)
-		}
-		if roles != nil {
-			c.Set("roles", roles)
+		if err != nil {
+			ctrl.log.Warn("JWT token parsing error", zap.Error(err))
+			c.Next()
+			return
 		}
-		ctrl.log.Warn("JWT token parsing error", zap.Error(err))
+
+		// Handlers read these back with c.Get("userID").(models.ID).
+		c.Set("userID", userID)
+		c.Set("roles", roles)
 		c.Next()
 	}
 }
@@ -1,39 +1,158 @@
 package controller
 
 import (
+	"strings"
+
 	"github.com/gin-gonic/gin"
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
 	"github.com/kakimnsnv/ios_final_back/internal/models"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 )
 
 func (ctrl *controller) ListMovies(c *gin.Context) {
-	movies, err := ctrl.movieSvc.ListMovies()
+	opts, err := listOptions(c, models.MovieListFields)
+	if err != nil {
+		ctrl.log.Error("failed to parse list options", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid list options"})
+		return
+	}
+	for i, filter := range opts.Filters {
+		// releasedIn= on its own means the caller's own region.
+		if filter.Field == models.FilterReleasedIn && filter.Value == "" {
+			opts.Filters[i].Value = acceptRegion(c)
+		}
+	}
+
+	expand, err := expandOptions(c, models.ExpandDirector, models.ExpandGenre)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid expand"})
+		return
+	}
+
+	facets, err := facetOptions(c, models.FacetGenre, models.FacetDecade, models.FacetTag, models.FacetRating)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid facets"})
+		return
+	}
+
+	movies, err := ctrl.movieSvc.ListMovies(opts)
+	if err == models.ErrInvalidFilter {
+		c.JSON(400, gin.H{"error": "Invalid list options"})
+		return
+	}
 	if err != nil {
 		ctrl.log.Error("failed to list movies", zap.Error(err))
 		c.JSON(500, gin.H{"error": "Failed to list movies"})
 		return
 	}
 
-	c.JSON(200, movies)
+	if err := ctrl.movieSvc.ExpandMovies(movies.Items, expand); err != nil {
+		ctrl.log.Error("failed to expand movies", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to list movies"})
+		return
+	}
+	if err := ctrl.translationSvc.LocalizeMovies(movies.Items, acceptLanguages(c)); err != nil {
+		ctrl.log.Error("failed to localize movies", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to list movies"})
+		return
+	}
+
+	if len(facets) == 0 {
+		c.JSON(200, movies)
+		return
+	}
+	counts, err := ctrl.movieSvc.ListMovieFacets(opts, facets)
+	if err != nil {
+		ctrl.log.Error("failed to count movie facets", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to list movies"})
+		return
+	}
+	res := gin.H{"items": movies.Items, "facets": counts}
+	if movies.NextCursor != "" {
+		res["nextCursor"] = movies.NextCursor
+	}
+	c.JSON(200, res)
+}
+
+func (ctrl *controller) SearchMovies(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(400, gin.H{"error": "Missing search query"})
+		return
+	}
+
+	limit, err := listLimit(c)
+	if err != nil {
+		ctrl.log.Error("failed to parse limit", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid limit"})
+		return
+	}
+
+	expand, err := expandOptions(c, models.ExpandDirector, models.ExpandGenre)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid expand"})
+		return
+	}
+
+	results, err := ctrl.movieSvc.SearchMovies(query, limit)
+	if err != nil {
+		ctrl.log.Error("failed to search movies", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to search movies"})
+		return
+	}
+
+	movies := make([]*models.Movie, len(results))
+	for i, result := range results {
+		movies[i] = result.Movie
+	}
+	if err := ctrl.movieSvc.ExpandMovies(movies, expand); err != nil {
+		ctrl.log.Error("failed to expand movies", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to search movies"})
+		return
+	}
+	if err := ctrl.translationSvc.LocalizeMovies(movies, acceptLanguages(c)); err != nil {
+		ctrl.log.Error("failed to localize movies", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to search movies"})
+		return
+	}
+
+	c.JSON(200, models.Page[*models.MovieSearchResult]{Items: results})
 }
 
 func (ctrl *controller) GetMovie(c *gin.Context) {
 	id := c.Param("id")
-	idObj, err := primitive.ObjectIDFromHex(id)
+	idObj, err := models.ParseID(id)
 	if err != nil {
-		ctrl.log.Error("failed to convert id to objectID", zap.Error(err))
+		ctrl.log.Error("failed to parse id", zap.Error(err))
 		c.JSON(400, gin.H{"error": "Invalid id"})
 		return
 	}
 
-	movie, err := ctrl.movieSvc.GetMovie(&idObj)
+	expand, err := expandOptions(c, models.ExpandDirector, models.ExpandGenre)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "Invalid expand"})
+		return
+	}
+
+	movie, err := ctrl.movieSvc.GetMovie(idObj)
 	if err != nil {
 		ctrl.log.Error("failed to get movie", zap.Error(err))
 		c.JSON(500, gin.H{"error": "Failed to get movie"})
 		return
 	}
 
+	if err := ctrl.movieSvc.ExpandMovies([]*models.Movie{movie}, expand); err != nil {
+		ctrl.log.Error("failed to expand movie", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to get movie"})
+		return
+	}
+	if err := ctrl.translationSvc.LocalizeMovies([]*models.Movie{movie}, acceptLanguages(c)); err != nil {
+		ctrl.log.Error("failed to localize movie", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to get movie"})
+		return
+	}
+
+	setETag(c, movie.Version)
 	c.JSON(200, movie)
 }
 
@@ -52,8 +171,16 @@ func (ctrl *controller) CreateMovie(c *gin.Context) {
 		return
 	}
 
-	res, err := ctrl.movieSvc.CreateMovie(actorID.(*primitive.ObjectID), &movie)
+	res, err := ctrl.movieSvc.CreateMovie(actorID.(models.ID), &movie)
 	if err != nil {
+		if err == errs.InvalidReference {
+			c.JSON(422, gin.H{"error": "Unknown director or genre"})
+			return
+		}
+		if err == models.ErrInvalidLocale {
+			c.JSON(400, gin.H{"error": "Invalid original language"})
+			return
+		}
 		ctrl.log.Error("failed to create movie", zap.Error(err))
 		c.JSON(500, gin.H{"error": "Failed to create movie"})
 		return
@@ -63,9 +190,9 @@ func (ctrl *controller) CreateMovie(c *gin.Context) {
 
 func (ctrl *controller) UpdateMovie(c *gin.Context) {
 	id := c.Param("id")
-	idObj, err := primitive.ObjectIDFromHex(id)
+	idObj, err := models.ParseID(id)
 	if err != nil {
-		ctrl.log.Error("failed to convert id to objectID", zap.Error(err))
+		ctrl.log.Error("failed to parse id", zap.Error(err))
 		c.JSON(400, gin.H{"error": "Invalid id"})
 		return
 	}
@@ -77,6 +204,13 @@ func (ctrl *controller) UpdateMovie(c *gin.Context) {
 		return
 	}
 
+	version, ok := ifMatch(c)
+	if !ok {
+		ctrl.log.Error("If-Match header is missing")
+		c.JSON(428, gin.H{"error": "If-Match header is required"})
+		return
+	}
+
 	var movie models.UpdateMovieRequest
 	if err := c.ShouldBindJSON(&movie); err != nil {
 		ctrl.log.Error("failed to bind request", zap.Error(err))
@@ -84,20 +218,40 @@ func (ctrl *controller) UpdateMovie(c *gin.Context) {
 		return
 	}
 
-	res, err := ctrl.movieSvc.UpdateMovie(actorID.(*primitive.ObjectID), &idObj, &movie)
+	res, err := ctrl.movieSvc.UpdateMovie(actorID.(models.ID), idObj, version, &movie)
 	if err != nil {
+		if err == errs.InvalidReference {
+			c.JSON(422, gin.H{"error": "Unknown director or genre"})
+			return
+		}
+		if err == models.ErrInvalidLocale {
+			c.JSON(400, gin.H{"error": "Invalid original language"})
+			return
+		}
+		if err == errs.Conflict {
+			current, err := ctrl.movieSvc.GetMovie(idObj)
+			if err != nil {
+				ctrl.log.Error("failed to get movie", zap.Error(err))
+				c.JSON(500, gin.H{"error": "Failed to get movie"})
+				return
+			}
+			setETag(c, current.Version)
+			c.JSON(412, gin.H{"error": "Movie was modified", "current": current})
+			return
+		}
 		ctrl.log.Error("failed to update movie", zap.Error(err))
 		c.JSON(500, gin.H{"error": "Failed to update movie"})
 		return
 	}
+	setETag(c, res.Version)
 	c.JSON(200, res)
 }
 
 func (ctrl *controller) DeleteMovie(c *gin.Context) {
 	id := c.Param("id")
-	idObj, err := primitive.ObjectIDFromHex(id)
+	idObj, err := models.ParseID(id)
 	if err != nil {
-		ctrl.log.Error("failed to convert id to objectID", zap.Error(err))
+		ctrl.log.Error("failed to parse id", zap.Error(err))
 		c.JSON(400, gin.H{"error": "Invalid id"})
 		return
 	}
@@ -109,7 +263,7 @@ func (ctrl *controller) DeleteMovie(c *gin.Context) {
 		return
 	}
 
-	err = ctrl.movieSvc.DeleteMovie(actorID.(*primitive.ObjectID), &idObj)
+	err = ctrl.movieSvc.DeleteMovie(actorID.(models.ID), idObj)
 	if err != nil {
 		ctrl.log.Error("failed to delete movie", zap.Error(err))
 		c.JSON(500, gin.H{"error": "Failed to delete movie"})
@@ -117,3 +271,59 @@ func (ctrl *controller) DeleteMovie(c *gin.Context) {
 	}
 	c.JSON(200, gin.H{"message": "Movie deleted successfully"})
 }
+
+func (ctrl *controller) ListDeletedMovies(c *gin.Context) {
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	movies, err := ctrl.movieSvc.ListDeletedMovies(actorID.(models.ID))
+	if err != nil {
+		if err == errs.Forbidden {
+			ctrl.log.Error("user does not have permission to list deleted movies", zap.Error(err))
+			c.JSON(403, gin.H{"error": "Forbidden"})
+			return
+		}
+		ctrl.log.Error("failed to list deleted movies", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to list deleted movies"})
+		return
+	}
+	c.JSON(200, movies)
+}
+
+func (ctrl *controller) RestoreMovie(c *gin.Context) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	movie, err := ctrl.movieSvc.RestoreMovie(actorID.(models.ID), id)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			ctrl.log.Error("user does not have permission to restore movie", zap.Error(err))
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Movie is not in the trash"})
+		default:
+			ctrl.log.Error("failed to restore movie", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to restore movie"})
+		}
+		return
+	}
+
+	setETag(c, movie.Version)
+	c.JSON(200, movie)
+}
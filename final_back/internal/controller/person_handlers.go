@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.uber.org/zap"
+)
+
+func (ctrl *controller) ListPeople(c *gin.Context) {
+	opts, err := listOptions(c, models.PersonListFields)
+	if err != nil {
+		ctrl.log.Error("failed to parse list options", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid list options"})
+		return
+	}
+
+	people, err := ctrl.personSvc.ListPeople(opts)
+	if err != nil {
+		ctrl.log.Error("failed to list people", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to list people"})
+		return
+	}
+
+	c.JSON(200, people)
+}
+
+func (ctrl *controller) GetPerson(c *gin.Context) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	person, err := ctrl.personSvc.GetPerson(id)
+	if err != nil {
+		if err == errs.NotFound {
+			c.JSON(404, gin.H{"error": "Person not found"})
+			return
+		}
+		ctrl.log.Error("failed to get person", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to get person"})
+		return
+	}
+
+	setETag(c, person.Version)
+	c.JSON(200, person)
+}
+
+func (ctrl *controller) CreatePerson(c *gin.Context) {
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.CreatePersonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.log.Error("failed to bind request", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	id, err := ctrl.personSvc.CreatePerson(actorID.(models.ID), &req)
+	if err != nil {
+		if err == errs.Forbidden {
+			c.JSON(403, gin.H{"error": "Forbidden"})
+			return
+		}
+		ctrl.log.Error("failed to create person", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to create person"})
+		return
+	}
+	c.JSON(201, id)
+}
+
+func (ctrl *controller) UpdatePerson(c *gin.Context) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	version, ok := ifMatch(c)
+	if !ok {
+		ctrl.log.Error("If-Match header is missing")
+		c.JSON(428, gin.H{"error": "If-Match header is required"})
+		return
+	}
+
+	var req models.UpdatePersonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.log.Error("failed to bind request", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	person, err := ctrl.personSvc.UpdatePerson(actorID.(models.ID), id, version, &req)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Person not found"})
+		case errs.Conflict:
+			current, err := ctrl.personSvc.GetPerson(id)
+			if err != nil {
+				ctrl.log.Error("failed to get person", zap.Error(err))
+				c.JSON(500, gin.H{"error": "Failed to get person"})
+				return
+			}
+			setETag(c, current.Version)
+			c.JSON(412, gin.H{"error": "Person was modified", "current": current})
+		default:
+			ctrl.log.Error("failed to update person", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to update person"})
+		}
+		return
+	}
+
+	setETag(c, person.Version)
+	c.JSON(200, person)
+}
+
+func (ctrl *controller) DeletePerson(c *gin.Context) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	err = ctrl.personSvc.DeletePerson(actorID.(models.ID), id)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Person not found"})
+		case errs.InUse:
+			c.JSON(409, gin.H{"error": "Person is still credited in movies"})
+		default:
+			ctrl.log.Error("failed to delete person", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to delete person"})
+		}
+		return
+	}
+	c.JSON(200, gin.H{"message": "Person deleted successfully"})
+}
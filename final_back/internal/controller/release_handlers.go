@@ -0,0 +1,219 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.uber.org/zap"
+)
+
+func (ctrl *controller) ListMovieReleases(c *gin.Context) {
+	movieID, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	releases, err := ctrl.releaseSvc.ListMovieReleases(movieID)
+	if err != nil {
+		if err == errs.NotFound {
+			c.JSON(404, gin.H{"error": "Movie not found"})
+			return
+		}
+		ctrl.log.Error("failed to list releases", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to list releases"})
+		return
+	}
+
+	c.JSON(200, models.Page[*models.Release]{Items: releases})
+}
+
+func (ctrl *controller) CreateRelease(c *gin.Context) {
+	movieID, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.CreateReleaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.log.Error("failed to bind request", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	id, err := ctrl.releaseSvc.CreateRelease(actorID.(models.ID), movieID, &req)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Movie not found"})
+		case errs.AlreadyExists:
+			c.JSON(409, gin.H{"error": "Movie already has a release of this type in this country"})
+		case models.ErrInvalidCountry:
+			c.JSON(400, gin.H{"error": "Invalid country"})
+		default:
+			ctrl.log.Error("failed to create release", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to create release"})
+		}
+		return
+	}
+	c.JSON(201, id)
+}
+
+func (ctrl *controller) UpdateRelease(c *gin.Context) {
+	movieID, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+	id, err := models.ParseID(c.Param("releaseId"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	version, ok := ifMatch(c)
+	if !ok {
+		ctrl.log.Error("If-Match header is missing")
+		c.JSON(428, gin.H{"error": "If-Match header is required"})
+		return
+	}
+
+	var req models.UpdateReleaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.log.Error("failed to bind request", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	release, err := ctrl.releaseSvc.UpdateRelease(actorID.(models.ID), movieID, id, version, &req)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Release not found"})
+		case errs.Conflict:
+			c.JSON(412, gin.H{"error": "Release was modified"})
+		default:
+			ctrl.log.Error("failed to update release", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to update release"})
+		}
+		return
+	}
+
+	setETag(c, release.Version)
+	c.JSON(200, release)
+}
+
+func (ctrl *controller) DeleteRelease(c *gin.Context) {
+	movieID, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+	id, err := models.ParseID(c.Param("releaseId"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	err = ctrl.releaseSvc.DeleteRelease(actorID.(models.ID), movieID, id)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Release not found"})
+		default:
+			ctrl.log.Error("failed to delete release", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to delete release"})
+		}
+		return
+	}
+	c.JSON(200, gin.H{"message": "Release deleted successfully"})
+}
+
+// UpcomingReleases lists releases from today on in the country and of the
+// type the query asks for. The country defaults to the caller's region
+// from Accept-Language; country=all lists every country.
+func (ctrl *controller) UpcomingReleases(c *gin.Context) {
+	limit, err := listLimit(c)
+	if err != nil {
+		ctrl.log.Error("failed to parse limit", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid limit"})
+		return
+	}
+	opts := models.ListOptions{Sort: "date", Cursor: c.Query("cursor"), Limit: limit}
+	if err := opts.Validate(models.ReleaseListFields); err != nil {
+		ctrl.log.Error("failed to parse list options", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid list options"})
+		return
+	}
+
+	country := c.Query("country")
+	switch country {
+	case "":
+		country = acceptRegion(c)
+	case "all":
+		country = ""
+	}
+	releaseType := models.ReleaseType(c.Query("type"))
+	if releaseType != "" && releaseType != models.ReleaseTheatrical && releaseType != models.ReleaseStreaming {
+		c.JSON(400, gin.H{"error": "Invalid release type"})
+		return
+	}
+
+	releases, err := ctrl.releaseSvc.UpcomingReleases(country, releaseType, opts)
+	if err != nil {
+		switch err {
+		case models.ErrInvalidCountry:
+			c.JSON(400, gin.H{"error": "Invalid country"})
+		default:
+			ctrl.log.Error("failed to list upcoming releases", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to list upcoming releases"})
+		}
+		return
+	}
+
+	movies := []*models.Movie{}
+	for _, release := range releases.Items {
+		movies = append(movies, release.Movie)
+	}
+	if err := ctrl.translationSvc.LocalizeMovies(movies, acceptLanguages(c)); err != nil {
+		ctrl.log.Error("failed to localize movies", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to list upcoming releases"})
+		return
+	}
+
+	c.JSON(200, releases)
+}
@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.uber.org/zap"
+)
+
+func (ctrl *controller) ListAllReviewCategories(c *gin.Context) {
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	categories, err := ctrl.reviewSvc.ListAllReviewCategories(actorID.(models.ID))
+	if err != nil {
+		if err == errs.Forbidden {
+			c.JSON(403, gin.H{"error": "Forbidden"})
+			return
+		}
+		ctrl.log.Error("failed to list review categories", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to list review categories"})
+		return
+	}
+	c.JSON(200, categories)
+}
+
+func (ctrl *controller) CreateReviewCategory(c *gin.Context) {
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.CreateReviewCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.log.Error("failed to bind request", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	id, err := ctrl.reviewSvc.CreateReviewCategory(actorID.(models.ID), &req)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+			return
+		case errs.AlreadyExists:
+			c.JSON(409, gin.H{"error": "Review category already exists"})
+			return
+		}
+		ctrl.log.Error("failed to create review category", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to create review category"})
+		return
+	}
+	c.JSON(201, id)
+}
+
+func (ctrl *controller) UpdateReviewCategory(c *gin.Context) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	version, ok := ifMatch(c)
+	if !ok {
+		ctrl.log.Error("If-Match header is missing")
+		c.JSON(428, gin.H{"error": "If-Match header is required"})
+		return
+	}
+
+	var req models.UpdateReviewCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.log.Error("failed to bind request", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	category, err := ctrl.reviewSvc.UpdateReviewCategory(actorID.(models.ID), id, version, &req)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Review category not found"})
+		case errs.AlreadyExists:
+			c.JSON(409, gin.H{"error": "Review category already exists"})
+		case errs.Conflict:
+			current, err := ctrl.reviewSvc.GetReviewCategory(id)
+			if err != nil {
+				ctrl.log.Error("failed to get review category", zap.Error(err))
+				c.JSON(500, gin.H{"error": "Failed to get review category"})
+				return
+			}
+			setETag(c, current.Version)
+			c.JSON(412, gin.H{"error": "Review category was modified", "current": current})
+		default:
+			ctrl.log.Error("failed to update review category", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to update review category"})
+		}
+		return
+	}
+
+	setETag(c, category.Version)
+	c.JSON(200, category)
+}
+
+func (ctrl *controller) ArchiveReviewCategory(c *gin.Context) {
+	ctrl.archiveReviewCategory(c, true)
+}
+
+func (ctrl *controller) UnarchiveReviewCategory(c *gin.Context) {
+	ctrl.archiveReviewCategory(c, false)
+}
+
+func (ctrl *controller) archiveReviewCategory(c *gin.Context, archived bool) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	category, err := ctrl.reviewSvc.ArchiveReviewCategory(actorID.(models.ID), id, archived)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Review category not found"})
+		default:
+			ctrl.log.Error("failed to archive review category", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to archive review category"})
+		}
+		return
+	}
+
+	setETag(c, category.Version)
+	c.JSON(200, category)
+}
@@ -2,16 +2,16 @@ package controller
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
 	"github.com/kakimnsnv/ios_final_back/internal/models"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 )
 
 func (ctrl *controller) ListReviewsByMovieID(c *gin.Context) {
 	movieIDStr := c.Param("movieId")
-	movieID, err := primitive.ObjectIDFromHex(movieIDStr)
+	movieID, err := models.ParseID(movieIDStr)
 	if err != nil {
-		ctrl.log.Error("failed to convert id to objectID", zap.Error(err))
+		ctrl.log.Error("failed to parse id", zap.Error(err))
 		c.JSON(400, gin.H{"error": "Invalid id"})
 		return
 	}
@@ -23,13 +23,20 @@ func (ctrl *controller) ListReviewsByMovieID(c *gin.Context) {
 		return
 	}
 
-	ownReviews, reviews, err := ctrl.reviewSvc.ListReviewsByMovieID(actorID.(*primitive.ObjectID), &movieID)
+	opts, err := listOptions(c, models.ReviewListFields)
+	if err != nil {
+		ctrl.log.Error("failed to parse list options", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid list options"})
+		return
+	}
+
+	ownReviews, reviews, err := ctrl.reviewSvc.ListReviewsByMovieID(actorID.(models.ID), movieID, opts)
 	if err != nil {
 		ctrl.log.Error("failed to list reviews by movie ID", zap.Error(err))
 		c.JSON(500, gin.H{"error": "Failed to list reviews by movie ID"})
 		return
 	}
-	c.JSON(200, gin.H{"ownReviews": ownReviews, "reviews": reviews})
+	c.JSON(200, gin.H{"ownReviews": ownReviews, "reviews": reviews.Items, "nextCursor": reviews.NextCursor})
 }
 
 func (ctrl *controller) ListMyReviews(c *gin.Context) {
@@ -40,7 +47,14 @@ func (ctrl *controller) ListMyReviews(c *gin.Context) {
 		return
 	}
 
-	reviews, err := ctrl.reviewSvc.ListMyReviews(actorID.(*primitive.ObjectID))
+	opts, err := listOptions(c, models.ReviewListFields)
+	if err != nil {
+		ctrl.log.Error("failed to parse list options", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid list options"})
+		return
+	}
+
+	reviews, err := ctrl.reviewSvc.ListMyReviews(actorID.(models.ID), opts)
 	if err != nil {
 		ctrl.log.Error("failed to list my reviews", zap.Error(err))
 		c.JSON(500, gin.H{"error": "Failed to list my reviews"})
@@ -58,13 +72,20 @@ func (ctrl *controller) UpdateReview(c *gin.Context) {
 	}
 
 	reviewIDStr := c.Param("id")
-	reviewID, err := primitive.ObjectIDFromHex(reviewIDStr)
+	reviewID, err := models.ParseID(reviewIDStr)
 	if err != nil {
-		ctrl.log.Error("failed to convert id to objectID", zap.Error(err))
+		ctrl.log.Error("failed to parse id", zap.Error(err))
 		c.JSON(400, gin.H{"error": "Invalid id"})
 		return
 	}
 
+	version, ok := ifMatch(c)
+	if !ok {
+		ctrl.log.Error("If-Match header is missing")
+		c.JSON(428, gin.H{"error": "If-Match header is required"})
+		return
+	}
+
 	var review models.UpdateReviewRequest
 	if err := c.ShouldBindJSON(&review); err != nil {
 		ctrl.log.Error("failed to bind request", zap.Error(err))
@@ -72,12 +93,29 @@ func (ctrl *controller) UpdateReview(c *gin.Context) {
 		return
 	}
 
-	if err := ctrl.reviewSvc.UpdateReview(actorID.(*primitive.ObjectID), &reviewID, &review); err != nil {
+	res, err := ctrl.reviewSvc.UpdateReview(actorID.(models.ID), reviewID, version, &review)
+	if err != nil {
+		if err == errs.InvalidReference {
+			c.JSON(422, gin.H{"error": "Review category does not exist or is archived"})
+			return
+		}
+		if err == errs.Conflict {
+			current, err := ctrl.reviewSvc.GetReview(actorID.(models.ID), reviewID)
+			if err != nil {
+				ctrl.log.Error("failed to get review", zap.Error(err))
+				c.JSON(500, gin.H{"error": "Failed to get review"})
+				return
+			}
+			setETag(c, current.Version)
+			c.JSON(412, gin.H{"error": "Review was modified", "current": current})
+			return
+		}
 		ctrl.log.Error("failed to update my review", zap.Error(err))
 		c.JSON(500, gin.H{"error": "Failed to update my review"})
 		return
 	}
-	c.Status(200)
+	setETag(c, res.Version)
+	c.JSON(200, res)
 }
 
 func (ctrl *controller) ListReviewCategories(c *gin.Context) {
@@ -87,6 +125,11 @@ func (ctrl *controller) ListReviewCategories(c *gin.Context) {
 		c.JSON(500, gin.H{"error": "Failed to list review categories"})
 		return
 	}
+	if err := ctrl.translationSvc.LocalizeReviewCategories(categories, acceptLanguages(c)); err != nil {
+		ctrl.log.Error("failed to localize review categories", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to list review categories"})
+		return
+	}
 	c.JSON(200, categories)
 }
 
@@ -105,8 +148,12 @@ func (ctrl *controller) CreateReview(c *gin.Context) {
 		return
 	}
 
-	id, err := ctrl.reviewSvc.CreateReview(actorID.(*primitive.ObjectID), &review)
+	id, err := ctrl.reviewSvc.CreateReview(actorID.(models.ID), &review)
 	if err != nil {
+		if err == errs.InvalidReference {
+			c.JSON(422, gin.H{"error": "Movie or review category does not exist or is archived"})
+			return
+		}
 		ctrl.log.Error("failed to create review", zap.Error(err))
 		c.JSON(500, gin.H{"error": "Failed to create review"})
 		return
@@ -123,17 +170,82 @@ func (ctrl *controller) DeleteReview(c *gin.Context) {
 	}
 
 	reviewIDStr := c.Param("id")
-	reviewID, err := primitive.ObjectIDFromHex(reviewIDStr)
+	reviewID, err := models.ParseID(reviewIDStr)
 	if err != nil {
-		ctrl.log.Error("failed to convert id to objectID", zap.Error(err))
+		ctrl.log.Error("failed to parse id", zap.Error(err))
 		c.JSON(400, gin.H{"error": "Invalid id"})
 		return
 	}
 
-	if err := ctrl.reviewSvc.DeleteReview(actorID.(*primitive.ObjectID), &reviewID); err != nil {
-		ctrl.log.Error("failed to delete review", zap.Error(err))
-		c.JSON(500, gin.H{"error": "Failed to delete review"})
+	if err := ctrl.reviewSvc.DeleteReview(actorID.(models.ID), reviewID); err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Review not found"})
+		case errs.Conflict:
+			c.JSON(409, gin.H{"error": "Review was modified concurrently"})
+		default:
+			ctrl.log.Error("failed to delete review", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to delete review"})
+		}
 		return
 	}
 	c.Status(200)
 }
+
+func (ctrl *controller) ListDeletedReviews(c *gin.Context) {
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	reviews, err := ctrl.reviewSvc.ListDeletedReviews(actorID.(models.ID))
+	if err != nil {
+		if err == errs.Forbidden {
+			ctrl.log.Error("user does not have permission to list deleted reviews", zap.Error(err))
+			c.JSON(403, gin.H{"error": "Forbidden"})
+			return
+		}
+		ctrl.log.Error("failed to list deleted reviews", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to list deleted reviews"})
+		return
+	}
+	c.JSON(200, reviews)
+}
+
+func (ctrl *controller) RestoreReview(c *gin.Context) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	review, err := ctrl.reviewSvc.RestoreReview(actorID.(models.ID), id)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			ctrl.log.Error("user does not have permission to restore review", zap.Error(err))
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Review is not in the trash"})
+		default:
+			ctrl.log.Error("failed to restore review", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to restore review"})
+		}
+		return
+	}
+
+	setETag(c, review.Version)
+	c.JSON(200, review)
+}
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.uber.org/zap"
+)
+
+func (ctrl *controller) ListMovieHistory(c *gin.Context) {
+	movieID, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	opts, err := listOptions(c, models.RevisionListFields)
+	if err != nil {
+		ctrl.log.Error("failed to parse list options", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid list options"})
+		return
+	}
+
+	revisions, err := ctrl.movieSvc.ListMovieHistory(movieID, opts)
+	if err != nil {
+		switch err {
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Movie not found"})
+		case models.ErrInvalidFilter:
+			c.JSON(400, gin.H{"error": "Invalid list options"})
+		default:
+			ctrl.log.Error("failed to list movie history", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to list movie history"})
+		}
+		return
+	}
+
+	c.JSON(200, revisions)
+}
+
+func (ctrl *controller) RevertMovie(c *gin.Context) {
+	movieID, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	toVersion, err := strconv.ParseInt(c.Param("version"), 10, 64)
+	if err != nil {
+		ctrl.log.Error("failed to parse version", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid version"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	version, ok := ifMatch(c)
+	if !ok {
+		ctrl.log.Error("If-Match header is missing")
+		c.JSON(428, gin.H{"error": "If-Match header is required"})
+		return
+	}
+
+	movie, err := ctrl.movieSvc.RevertMovie(actorID.(models.ID), movieID, version, toVersion)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			ctrl.log.Error("user does not have permission to revert movie", zap.Error(err))
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Movie or revision not found"})
+		case models.ErrInvalidRevert:
+			c.JSON(400, gin.H{"error": "Can only revert to an earlier version"})
+		case errs.InvalidReference:
+			c.JSON(422, gin.H{"error": "Director or genre of that version no longer exists"})
+		case errs.Conflict:
+			current, err := ctrl.movieSvc.GetMovie(movieID)
+			if err != nil {
+				ctrl.log.Error("failed to get movie", zap.Error(err))
+				c.JSON(500, gin.H{"error": "Failed to get movie"})
+				return
+			}
+			setETag(c, current.Version)
+			c.JSON(412, gin.H{"error": "Movie was modified", "current": current})
+		default:
+			ctrl.log.Error("failed to revert movie", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to revert movie"})
+		}
+		return
+	}
+
+	setETag(c, movie.Version)
+	c.JSON(200, movie)
+}
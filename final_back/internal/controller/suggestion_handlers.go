@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.uber.org/zap"
+)
+
+func (ctrl *controller) ListSuggestions(c *gin.Context) {
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	opts, err := listOptions(c, models.SuggestionListFields)
+	if err != nil {
+		ctrl.log.Error("failed to parse list options", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid list options"})
+		return
+	}
+
+	suggestions, err := ctrl.suggestionSvc.ListSuggestions(actorID.(models.ID), opts)
+	if err == models.ErrInvalidFilter {
+		c.JSON(400, gin.H{"error": "Invalid list options"})
+		return
+	}
+	if err != nil {
+		ctrl.log.Error("failed to list suggestions", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to list suggestions"})
+		return
+	}
+
+	c.JSON(200, suggestions)
+}
+
+func (ctrl *controller) GetSuggestion(c *gin.Context) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	suggestion, err := ctrl.suggestionSvc.GetSuggestion(actorID.(models.ID), id)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Suggestion not found"})
+		default:
+			ctrl.log.Error("failed to get suggestion", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to get suggestion"})
+		}
+		return
+	}
+
+	c.JSON(200, suggestion)
+}
+
+func (ctrl *controller) CreateSuggestion(c *gin.Context) {
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.CreateSuggestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.log.Error("failed to bind request", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	id, err := ctrl.suggestionSvc.CreateSuggestion(actorID.(models.ID), &req)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case models.ErrInvalidSuggestion:
+			c.JSON(400, gin.H{"error": "A suggestion must change the movie, and a new movie needs a title, year, director, genre and image URL"})
+		case errs.InvalidReference:
+			c.JSON(422, gin.H{"error": "Unknown movie, director or genre"})
+		default:
+			ctrl.log.Error("failed to create suggestion", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to create suggestion"})
+		}
+		return
+	}
+	c.JSON(201, id)
+}
+
+func (ctrl *controller) ApproveSuggestion(c *gin.Context) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	suggestion, err := ctrl.suggestionSvc.ApproveSuggestion(actorID.(models.ID), id)
+	if err != nil {
+		ctrl.suggestionError(c, "failed to approve suggestion", err)
+		return
+	}
+
+	c.JSON(200, suggestion)
+}
+
+func (ctrl *controller) RejectSuggestion(c *gin.Context) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.RejectSuggestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.log.Error("failed to bind request", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	suggestion, err := ctrl.suggestionSvc.RejectSuggestion(actorID.(models.ID), id, req.Reason)
+	if err != nil {
+		ctrl.suggestionError(c, "failed to reject suggestion", err)
+		return
+	}
+
+	c.JSON(200, suggestion)
+}
+
+// suggestionError responds to an error from approving or rejecting a
+// suggestion.
+func (ctrl *controller) suggestionError(c *gin.Context, msg string, err error) {
+	switch err {
+	case errs.Forbidden:
+		c.JSON(403, gin.H{"error": "Forbidden"})
+	case errs.NotFound:
+		c.JSON(404, gin.H{"error": "Suggestion not found"})
+	case models.ErrSuggestionResolved:
+		c.JSON(409, gin.H{"error": "Suggestion was already resolved"})
+	case errs.Conflict:
+		c.JSON(409, gin.H{"error": "Movie was modified since the suggestion was made"})
+	case errs.InvalidReference:
+		c.JSON(422, gin.H{"error": "Movie, director or genre no longer exists"})
+	case models.ErrInvalidSuggestion:
+		c.JSON(422, gin.H{"error": "A new movie needs a title, year, director, genre and image URL"})
+	default:
+		ctrl.log.Error(msg, zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to resolve suggestion"})
+	}
+}
@@ -0,0 +1,379 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.uber.org/zap"
+)
+
+func (ctrl *controller) ListTags(c *gin.Context) {
+	opts, err := listOptions(c, models.TagListFields)
+	if err != nil {
+		ctrl.log.Error("failed to parse list options", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid list options"})
+		return
+	}
+
+	tags, err := ctrl.tagSvc.ListTags(opts)
+	if err != nil {
+		ctrl.log.Error("failed to list tags", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to list tags"})
+		return
+	}
+
+	c.JSON(200, tags)
+}
+
+func (ctrl *controller) GetTag(c *gin.Context) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	tag, err := ctrl.tagSvc.GetTag(id)
+	if err != nil {
+		if err == errs.NotFound {
+			c.JSON(404, gin.H{"error": "Tag not found"})
+			return
+		}
+		ctrl.log.Error("failed to get tag", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to get tag"})
+		return
+	}
+
+	setETag(c, tag.Version)
+	c.JSON(200, tag)
+}
+
+func (ctrl *controller) CreateTag(c *gin.Context) {
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.CreateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.log.Error("failed to bind request", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	id, err := ctrl.tagSvc.CreateTag(actorID.(models.ID), &req)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case models.ErrInvalidTag:
+			c.JSON(400, gin.H{"error": "Tag name must not be blank"})
+		case errs.AlreadyExists:
+			c.JSON(409, gin.H{"error": "Tag already exists"})
+		default:
+			ctrl.log.Error("failed to create tag", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to create tag"})
+		}
+		return
+	}
+	c.JSON(201, id)
+}
+
+func (ctrl *controller) UpdateTag(c *gin.Context) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	version, ok := ifMatch(c)
+	if !ok {
+		ctrl.log.Error("If-Match header is missing")
+		c.JSON(428, gin.H{"error": "If-Match header is required"})
+		return
+	}
+
+	var req models.UpdateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.log.Error("failed to bind request", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	tag, err := ctrl.tagSvc.UpdateTag(actorID.(models.ID), id, version, &req)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case models.ErrInvalidTag:
+			c.JSON(400, gin.H{"error": "Tag name must not be blank"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Tag not found"})
+		case errs.AlreadyExists:
+			c.JSON(409, gin.H{"error": "Tag already exists"})
+		case errs.Conflict:
+			current, err := ctrl.tagSvc.GetTag(id)
+			if err != nil {
+				ctrl.log.Error("failed to get tag", zap.Error(err))
+				c.JSON(500, gin.H{"error": "Failed to get tag"})
+				return
+			}
+			setETag(c, current.Version)
+			c.JSON(412, gin.H{"error": "Tag was modified", "current": current})
+		default:
+			ctrl.log.Error("failed to update tag", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to update tag"})
+		}
+		return
+	}
+
+	setETag(c, tag.Version)
+	c.JSON(200, tag)
+}
+
+func (ctrl *controller) DeleteTag(c *gin.Context) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	err = ctrl.tagSvc.DeleteTag(actorID.(models.ID), id)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Tag not found"})
+		default:
+			ctrl.log.Error("failed to delete tag", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to delete tag"})
+		}
+		return
+	}
+	c.JSON(200, gin.H{"message": "Tag deleted successfully"})
+}
+
+func (ctrl *controller) ListMovieTags(c *gin.Context) {
+	movieID, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	tags, err := ctrl.tagSvc.ListMovieTags(movieID)
+	if err != nil {
+		if err == errs.NotFound {
+			c.JSON(404, gin.H{"error": "Movie not found"})
+			return
+		}
+		ctrl.log.Error("failed to list movie tags", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to list movie tags"})
+		return
+	}
+
+	c.JSON(200, tags)
+}
+
+// TagMovie responds with the movie tag, which is pending when the user may
+// only suggest tags.
+func (ctrl *controller) TagMovie(c *gin.Context) {
+	movieID, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.TagMovieRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.log.Error("failed to bind request", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	movieTag, err := ctrl.tagSvc.TagMovie(actorID.(models.ID), movieID, &req)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case models.ErrInvalidTag:
+			c.JSON(400, gin.H{"error": "Either tagId or a non-blank name is required"})
+		case errs.AlreadyExists:
+			c.JSON(409, gin.H{"error": "Movie already has or was suggested this tag"})
+		case errs.InvalidReference:
+			c.JSON(422, gin.H{"error": "Unknown movie or tag"})
+		default:
+			ctrl.log.Error("failed to tag movie", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to tag movie"})
+		}
+		return
+	}
+	c.JSON(201, movieTag)
+}
+
+func (ctrl *controller) UntagMovie(c *gin.Context) {
+	movieID, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	tagID, err := models.ParseID(c.Param("tagId"))
+	if err != nil {
+		ctrl.log.Error("failed to parse tag id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid tag id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	err = ctrl.tagSvc.UntagMovie(actorID.(models.ID), movieID, tagID)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Movie tag not found"})
+		default:
+			ctrl.log.Error("failed to untag movie", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to untag movie"})
+		}
+		return
+	}
+	c.JSON(200, gin.H{"message": "Movie tag deleted successfully"})
+}
+
+func (ctrl *controller) ListTagSuggestions(c *gin.Context) {
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	opts, err := listOptions(c, models.MovieTagListFields)
+	if err != nil {
+		ctrl.log.Error("failed to parse list options", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid list options"})
+		return
+	}
+
+	suggestions, err := ctrl.tagSvc.ListTagSuggestions(actorID.(models.ID), opts)
+	if err == models.ErrInvalidFilter {
+		c.JSON(400, gin.H{"error": "Invalid list options"})
+		return
+	}
+	if err != nil {
+		ctrl.log.Error("failed to list tag suggestions", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to list tag suggestions"})
+		return
+	}
+
+	c.JSON(200, suggestions)
+}
+
+func (ctrl *controller) ApproveTagSuggestion(c *gin.Context) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	suggestion, err := ctrl.tagSvc.ApproveTagSuggestion(actorID.(models.ID), id)
+	if err != nil {
+		ctrl.tagSuggestionError(c, "failed to approve tag suggestion", err)
+		return
+	}
+
+	c.JSON(200, suggestion)
+}
+
+func (ctrl *controller) RejectTagSuggestion(c *gin.Context) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.RejectSuggestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.log.Error("failed to bind request", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	suggestion, err := ctrl.tagSvc.RejectTagSuggestion(actorID.(models.ID), id, req.Reason)
+	if err != nil {
+		ctrl.tagSuggestionError(c, "failed to reject tag suggestion", err)
+		return
+	}
+
+	c.JSON(200, suggestion)
+}
+
+// tagSuggestionError responds to an error from approving or rejecting a tag
+// suggestion.
+func (ctrl *controller) tagSuggestionError(c *gin.Context, msg string, err error) {
+	switch err {
+	case errs.Forbidden:
+		c.JSON(403, gin.H{"error": "Forbidden"})
+	case errs.NotFound:
+		c.JSON(404, gin.H{"error": "Tag suggestion not found"})
+	case models.ErrSuggestionResolved:
+		c.JSON(409, gin.H{"error": "Tag suggestion was already resolved"})
+	case errs.AlreadyExists:
+		c.JSON(409, gin.H{"error": "Movie already has this tag"})
+	default:
+		ctrl.log.Error(msg, zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to resolve tag suggestion"})
+	}
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.uber.org/zap"
+)
+
+func (ctrl *controller) ListMovieTranslations(c *gin.Context) {
+	ctrl.listTranslations(c, models.TranslationMovie)
+}
+
+func (ctrl *controller) PutMovieTranslation(c *gin.Context) {
+	ctrl.putTranslation(c, models.TranslationMovie)
+}
+
+func (ctrl *controller) DeleteMovieTranslation(c *gin.Context) {
+	ctrl.deleteTranslation(c, models.TranslationMovie)
+}
+
+func (ctrl *controller) ListReviewCategoryTranslations(c *gin.Context) {
+	ctrl.listTranslations(c, models.TranslationReviewCategory)
+}
+
+func (ctrl *controller) PutReviewCategoryTranslation(c *gin.Context) {
+	ctrl.putTranslation(c, models.TranslationReviewCategory)
+}
+
+func (ctrl *controller) DeleteReviewCategoryTranslation(c *gin.Context) {
+	ctrl.deleteTranslation(c, models.TranslationReviewCategory)
+}
+
+func (ctrl *controller) listTranslations(c *gin.Context, kind models.TranslationKind) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	translations, err := ctrl.translationSvc.ListTranslations(kind, id)
+	if err != nil {
+		if err == errs.NotFound {
+			c.JSON(404, gin.H{"error": "Not found"})
+			return
+		}
+		ctrl.log.Error("failed to list translations", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to list translations"})
+		return
+	}
+	c.JSON(200, translations)
+}
+
+func (ctrl *controller) putTranslation(c *gin.Context, kind models.TranslationKind) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.PutTranslationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.log.Error("failed to bind request", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	translation, err := ctrl.translationSvc.PutTranslation(actorID.(models.ID), kind, id, c.Param("locale"), req.Fields)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			ctrl.log.Error("user does not have permission to translate", zap.Error(err))
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Not found"})
+		case models.ErrInvalidLocale:
+			c.JSON(400, gin.H{"error": "Invalid locale"})
+		case models.ErrInvalidTranslation:
+			c.JSON(400, gin.H{"error": "Only these fields can be translated", "fields": models.TranslatableFields[kind]})
+		case errs.Conflict, errs.AlreadyExists:
+			c.JSON(409, gin.H{"error": "Translation was modified concurrently"})
+		default:
+			ctrl.log.Error("failed to put translation", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to put translation"})
+		}
+		return
+	}
+	c.JSON(200, translation)
+}
+
+func (ctrl *controller) deleteTranslation(c *gin.Context, kind models.TranslationKind) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	err = ctrl.translationSvc.DeleteTranslation(actorID.(models.ID), kind, id, c.Param("locale"))
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			ctrl.log.Error("user does not have permission to delete translations", zap.Error(err))
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "Translation not found"})
+		case models.ErrInvalidLocale:
+			c.JSON(400, gin.H{"error": "Invalid locale"})
+		default:
+			ctrl.log.Error("failed to delete translation", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to delete translation"})
+		}
+		return
+	}
+	c.JSON(200, gin.H{"message": "Translation deleted successfully"})
+}
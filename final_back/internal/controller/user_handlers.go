@@ -4,7 +4,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/kakimnsnv/ios_final_back/internal/errs"
 	"github.com/kakimnsnv/ios_final_back/internal/models"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 )
 
@@ -79,13 +78,14 @@ func (ctrl *controller) GetMe(c *gin.Context) {
 		return
 	}
 
-	user, err := ctrl.usersvc.GetUserByID(userID.(*primitive.ObjectID))
+	user, err := ctrl.usersvc.GetUserByID(userID.(models.ID))
 	if err != nil {
 		ctrl.log.Error("failed to get user by ID", zap.Error(err))
 		c.JSON(500, gin.H{"error": "Failed to get user"})
 		return
 	}
 
+	setETag(c, user.Version)
 	c.JSON(200, user)
 }
 
@@ -97,6 +97,13 @@ func (ctrl *controller) UpdateMe(c *gin.Context) {
 		return
 	}
 
+	version, ok := ifMatch(c)
+	if !ok {
+		ctrl.log.Error("If-Match header is missing")
+		c.JSON(428, gin.H{"error": "If-Match header is required"})
+		return
+	}
+
 	var req models.UpdateMeRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		ctrl.log.Error("failed to bind request", zap.Error(err))
@@ -104,13 +111,18 @@ func (ctrl *controller) UpdateMe(c *gin.Context) {
 		return
 	}
 
-	user, err := ctrl.usersvc.UpdateMe(userID.(*primitive.ObjectID), req)
+	user, err := ctrl.usersvc.UpdateMe(userID.(models.ID), version, req)
 	if err != nil {
+		if err == errs.Conflict {
+			ctrl.userPreconditionFailed(c, userID.(models.ID))
+			return
+		}
 		ctrl.log.Error("failed to update user", zap.Error(err))
 		c.JSON(500, gin.H{"error": "Failed to update user"})
 		return
 	}
 
+	setETag(c, user.Version)
 	c.JSON(200, user)
 }
 
@@ -122,7 +134,7 @@ func (ctrl *controller) DeleteMe(c *gin.Context) {
 		return
 	}
 
-	err := ctrl.usersvc.DeleteMe(userID.(*primitive.ObjectID))
+	err := ctrl.usersvc.DeleteMe(userID.(models.ID))
 	if err != nil {
 		ctrl.log.Error("failed to delete user", zap.Error(err))
 		c.JSON(500, gin.H{"error": "Failed to delete user"})
@@ -133,25 +145,40 @@ func (ctrl *controller) DeleteMe(c *gin.Context) {
 }
 
 func (ctrl *controller) GetUser(c *gin.Context) {
-	userID, ok := c.Get("userID")
-	if !ok {
+	id := c.Param("id")
+	targetID, err := models.ParseID(id)
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	if _, ok := c.Get("userID"); !ok {
 		ctrl.log.Error("userID is nil")
 		c.JSON(401, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	user, err := ctrl.usersvc.GetUserByID(userID.(*primitive.ObjectID))
+	user, err := ctrl.usersvc.GetUserByID(targetID)
 	if err != nil {
 		ctrl.log.Error("failed to get user by ID", zap.Error(err))
 		c.JSON(500, gin.H{"error": "Failed to get user"})
 		return
 	}
 
+	setETag(c, user.Version)
 	c.JSON(200, user)
 }
 
 func (ctrl *controller) ListUsers(c *gin.Context) {
-	users, err := ctrl.usersvc.ListUsers()
+	opts, err := listOptions(c, models.UserListFields)
+	if err != nil {
+		ctrl.log.Error("failed to parse list options", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid list options"})
+		return
+	}
+
+	users, err := ctrl.usersvc.ListUsers(opts)
 	if err != nil {
 		ctrl.log.Error("failed to list users", zap.Error(err))
 		c.JSON(500, gin.H{"error": "Failed to list users"})
@@ -163,9 +190,9 @@ func (ctrl *controller) ListUsers(c *gin.Context) {
 
 func (ctrl *controller) UpdateUser(c *gin.Context) {
 	id := c.Param("id")
-	targetID, err := primitive.ObjectIDFromHex(id)
+	targetID, err := models.ParseID(id)
 	if err != nil {
-		ctrl.log.Error("failed to convert id to objectID", zap.Error(err))
+		ctrl.log.Error("failed to parse id", zap.Error(err))
 		c.JSON(400, gin.H{"error": "Invalid id"})
 		return
 	}
@@ -177,6 +204,13 @@ func (ctrl *controller) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	version, ok := ifMatch(c)
+	if !ok {
+		ctrl.log.Error("If-Match header is missing")
+		c.JSON(428, gin.H{"error": "If-Match header is required"})
+		return
+	}
+
 	var req models.UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		ctrl.log.Error("failed to bind request", zap.Error(err))
@@ -184,8 +218,12 @@ func (ctrl *controller) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	user, err := ctrl.usersvc.UpdateUser(actorID.(*primitive.ObjectID), &targetID, req)
+	user, err := ctrl.usersvc.UpdateUser(actorID.(models.ID), targetID, version, req)
 	if err != nil {
+		if err == errs.Conflict {
+			ctrl.userPreconditionFailed(c, targetID)
+			return
+		}
 		if err == errs.Forbidden {
 			ctrl.log.Error("user does not have permission to update user", zap.Error(err))
 			c.JSON(403, gin.H{"error": "Forbidden"})
@@ -196,14 +234,15 @@ func (ctrl *controller) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	setETag(c, user.Version)
 	c.JSON(200, user)
 }
 
 func (ctrl *controller) DeleteUser(c *gin.Context) {
 	id := c.Param("id")
-	targetID, err := primitive.ObjectIDFromHex(id)
+	targetID, err := models.ParseID(id)
 	if err != nil {
-		ctrl.log.Error("failed to convert id to objectID", zap.Error(err))
+		ctrl.log.Error("failed to parse id", zap.Error(err))
 		c.JSON(400, gin.H{"error": "Invalid id"})
 		return
 	}
@@ -215,7 +254,7 @@ func (ctrl *controller) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	if err := ctrl.usersvc.DeleteUser(actorID.(*primitive.ObjectID), &targetID); err != nil {
+	if err := ctrl.usersvc.DeleteUser(actorID.(models.ID), targetID); err != nil {
 		if err == errs.Forbidden {
 			ctrl.log.Error("user does not have permission to update user", zap.Error(err))
 			c.JSON(403, gin.H{"error": "Forbidden"})
@@ -228,3 +267,73 @@ func (ctrl *controller) DeleteUser(c *gin.Context) {
 
 	c.Status(200)
 }
+
+// userPreconditionFailed answers a PUT whose If-Match no longer matches with
+// the user as it is now stored.
+func (ctrl *controller) userPreconditionFailed(c *gin.Context, id models.ID) {
+	current, err := ctrl.usersvc.GetUserByID(id)
+	if err != nil {
+		ctrl.log.Error("failed to get user by ID", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to get user"})
+		return
+	}
+
+	setETag(c, current.Version)
+	c.JSON(412, gin.H{"error": "User was modified", "current": current})
+}
+
+func (ctrl *controller) ListDeletedUsers(c *gin.Context) {
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	users, err := ctrl.usersvc.ListDeletedUsers(actorID.(models.ID))
+	if err != nil {
+		if err == errs.Forbidden {
+			ctrl.log.Error("user does not have permission to list deleted users", zap.Error(err))
+			c.JSON(403, gin.H{"error": "Forbidden"})
+			return
+		}
+		ctrl.log.Error("failed to list deleted users", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to list deleted users"})
+		return
+	}
+	c.JSON(200, users)
+}
+
+func (ctrl *controller) RestoreUser(c *gin.Context) {
+	id, err := models.ParseID(c.Param("id"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	actorID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	user, err := ctrl.usersvc.RestoreUser(actorID.(models.ID), id)
+	if err != nil {
+		switch err {
+		case errs.Forbidden:
+			ctrl.log.Error("user does not have permission to restore user", zap.Error(err))
+			c.JSON(403, gin.H{"error": "Forbidden"})
+		case errs.NotFound:
+			c.JSON(404, gin.H{"error": "User is not in the trash"})
+		default:
+			ctrl.log.Error("failed to restore user", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to restore user"})
+		}
+		return
+	}
+
+	setETag(c, user.Version)
+	c.JSON(200, user)
+}
@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.uber.org/zap"
+)
+
+func (ctrl *controller) ListWatchlist(c *gin.Context) {
+	userID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	opts, err := listOptions(c, models.WatchlistListFields)
+	if err != nil {
+		ctrl.log.Error("failed to parse list options", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid list options"})
+		return
+	}
+
+	entries, err := ctrl.watchlistSvc.ListWatchlist(userID.(models.ID), opts)
+	if err != nil {
+		ctrl.log.Error("failed to list watchlist", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to list watchlist"})
+		return
+	}
+
+	movies := []*models.Movie{}
+	for _, entry := range entries.Items {
+		movies = append(movies, entry.Movie)
+	}
+	if err := ctrl.translationSvc.LocalizeMovies(movies, acceptLanguages(c)); err != nil {
+		ctrl.log.Error("failed to localize movies", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to list watchlist"})
+		return
+	}
+
+	c.JSON(200, entries)
+}
+
+func (ctrl *controller) AddToWatchlist(c *gin.Context) {
+	userID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req models.AddToWatchlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ctrl.log.Error("failed to bind request", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid request"})
+		return
+	}
+
+	id, err := ctrl.watchlistSvc.AddToWatchlist(userID.(models.ID), *req.MovieID)
+	if err != nil {
+		switch err {
+		case errs.InvalidReference:
+			c.JSON(422, gin.H{"error": "Unknown movie"})
+		case errs.AlreadyExists:
+			c.JSON(409, gin.H{"error": "Movie is already on the watchlist"})
+		default:
+			ctrl.log.Error("failed to add to watchlist", zap.Error(err))
+			c.JSON(500, gin.H{"error": "Failed to add to watchlist"})
+		}
+		return
+	}
+	c.JSON(201, id)
+}
+
+func (ctrl *controller) RemoveFromWatchlist(c *gin.Context) {
+	movieID, err := models.ParseID(c.Param("movieId"))
+	if err != nil {
+		ctrl.log.Error("failed to parse id", zap.Error(err))
+		c.JSON(400, gin.H{"error": "Invalid id"})
+		return
+	}
+
+	userID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	err = ctrl.watchlistSvc.RemoveFromWatchlist(userID.(models.ID), movieID)
+	if err != nil {
+		if err == errs.NotFound {
+			c.JSON(404, gin.H{"error": "Movie is not on the watchlist"})
+			return
+		}
+		ctrl.log.Error("failed to remove from watchlist", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to remove from watchlist"})
+		return
+	}
+	c.JSON(200, gin.H{"message": "Movie removed from watchlist successfully"})
+}
+
+// GetCalendarFeed returns the secret address of the user's watchlist
+// calendar.
+func (ctrl *controller) GetCalendarFeed(c *gin.Context) {
+	userID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	token, err := ctrl.watchlistSvc.CalendarToken(userID.(models.ID))
+	if err != nil {
+		ctrl.log.Error("failed to get calendar token", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to get calendar feed"})
+		return
+	}
+	c.JSON(200, calendarFeed(token))
+}
+
+// ResetCalendarFeed gives the user's watchlist calendar a new secret
+// address, for when the old one has leaked.
+func (ctrl *controller) ResetCalendarFeed(c *gin.Context) {
+	userID, ok := c.Get("userID")
+	if !ok {
+		ctrl.log.Error("userID is nil")
+		c.JSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	token, err := ctrl.watchlistSvc.ResetCalendarToken(userID.(models.ID))
+	if err != nil {
+		ctrl.log.Error("failed to reset calendar token", zap.Error(err))
+		c.JSON(500, gin.H{"error": "Failed to reset calendar feed"})
+		return
+	}
+	c.JSON(200, calendarFeed(token))
+}
+
+func calendarFeed(token string) models.CalendarFeed {
+	return models.CalendarFeed{Token: token, URL: "/calendar/watchlist/" + token + calendarExt}
+}
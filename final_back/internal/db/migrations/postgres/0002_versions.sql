@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN version BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE movies ADD COLUMN version BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE reviews ADD COLUMN version BIGINT NOT NULL DEFAULT 0;
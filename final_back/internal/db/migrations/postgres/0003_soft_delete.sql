@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN deleted TIMESTAMPTZ;
+ALTER TABLE movies ADD COLUMN deleted TIMESTAMPTZ;
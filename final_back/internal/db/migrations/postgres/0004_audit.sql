@@ -0,0 +1,14 @@
+ALTER TABLE users ADD COLUMN created_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN updated_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN created_by CHAR(24);
+ALTER TABLE users ADD COLUMN updated_by CHAR(24);
+
+ALTER TABLE movies ADD COLUMN created_at TIMESTAMPTZ;
+ALTER TABLE movies ADD COLUMN updated_at TIMESTAMPTZ;
+ALTER TABLE movies ADD COLUMN created_by CHAR(24);
+ALTER TABLE movies ADD COLUMN updated_by CHAR(24);
+
+ALTER TABLE reviews RENAME COLUMN created TO created_at;
+ALTER TABLE reviews RENAME COLUMN updated TO updated_at;
+ALTER TABLE reviews ADD COLUMN created_by CHAR(24);
+ALTER TABLE reviews ADD COLUMN updated_by CHAR(24);
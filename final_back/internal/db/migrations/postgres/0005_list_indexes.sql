@@ -0,0 +1,21 @@
+CREATE INDEX users_created_at_idx ON users (created_at, id);
+CREATE INDEX users_updated_at_idx ON users (updated_at, id);
+
+CREATE INDEX movies_title_idx ON movies (title, id);
+CREATE INDEX movies_year_idx ON movies (year, id);
+CREATE INDEX movies_rating_idx ON movies (rating, id);
+CREATE INDEX movies_created_at_idx ON movies (created_at, id);
+CREATE INDEX movies_updated_at_idx ON movies (updated_at, id);
+CREATE INDEX movies_director_id_idx ON movies (director_id);
+CREATE INDEX movies_genre_id_idx ON movies (genre_id);
+
+DROP INDEX reviews_movie_id_idx;
+DROP INDEX reviews_owner_id_idx;
+CREATE INDEX reviews_movie_id_idx ON reviews (movie_id, id);
+CREATE INDEX reviews_movie_id_rating_idx ON reviews (movie_id, rating, id);
+CREATE INDEX reviews_movie_id_created_at_idx ON reviews (movie_id, created_at, id);
+CREATE INDEX reviews_movie_id_updated_at_idx ON reviews (movie_id, updated_at, id);
+CREATE INDEX reviews_owner_id_idx ON reviews (owner_id, id);
+CREATE INDEX reviews_owner_id_rating_idx ON reviews (owner_id, rating, id);
+CREATE INDEX reviews_owner_id_created_at_idx ON reviews (owner_id, created_at, id);
+CREATE INDEX reviews_owner_id_updated_at_idx ON reviews (owner_id, updated_at, id);
@@ -0,0 +1,16 @@
+CREATE TABLE credits (
+    id             CHAR(24)    PRIMARY KEY,
+    movie_id       CHAR(24)    NOT NULL,
+    person_id      CHAR(24)    NOT NULL,
+    role           TEXT        NOT NULL,
+    character_name TEXT        NOT NULL DEFAULT '',
+    billing_order  INTEGER     NOT NULL DEFAULT 0,
+    version        INTEGER     NOT NULL DEFAULT 0,
+    created_at     TIMESTAMPTZ,
+    updated_at     TIMESTAMPTZ,
+    created_by     CHAR(24),
+    updated_by     CHAR(24)
+);
+
+CREATE INDEX credits_movie_id_idx ON credits (movie_id, billing_order, id);
+CREATE INDEX credits_person_id_idx ON credits (person_id);
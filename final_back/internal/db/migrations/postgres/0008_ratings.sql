@@ -0,0 +1,38 @@
+ALTER TABLE movies ADD COLUMN rating_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE movies ADD COLUMN rating_sum INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE movies ADD COLUMN rating_mean DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE movies ADD COLUMN rating_1 INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE movies ADD COLUMN rating_2 INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE movies ADD COLUMN rating_3 INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE movies ADD COLUMN rating_4 INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE movies ADD COLUMN rating_5 INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE movies ADD COLUMN rating_6 INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE movies ADD COLUMN rating_7 INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE movies ADD COLUMN rating_8 INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE movies ADD COLUMN rating_9 INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE movies ADD COLUMN rating_10 INTEGER NOT NULL DEFAULT 0;
+
+UPDATE movies SET
+    rating_1 = (SELECT COUNT(*) FROM reviews WHERE movie_id = movies.id AND deleted IS NULL AND is_private = FALSE AND rating = 1),
+    rating_2 = (SELECT COUNT(*) FROM reviews WHERE movie_id = movies.id AND deleted IS NULL AND is_private = FALSE AND rating = 2),
+    rating_3 = (SELECT COUNT(*) FROM reviews WHERE movie_id = movies.id AND deleted IS NULL AND is_private = FALSE AND rating = 3),
+    rating_4 = (SELECT COUNT(*) FROM reviews WHERE movie_id = movies.id AND deleted IS NULL AND is_private = FALSE AND rating = 4),
+    rating_5 = (SELECT COUNT(*) FROM reviews WHERE movie_id = movies.id AND deleted IS NULL AND is_private = FALSE AND rating = 5),
+    rating_6 = (SELECT COUNT(*) FROM reviews WHERE movie_id = movies.id AND deleted IS NULL AND is_private = FALSE AND rating = 6),
+    rating_7 = (SELECT COUNT(*) FROM reviews WHERE movie_id = movies.id AND deleted IS NULL AND is_private = FALSE AND rating = 7),
+    rating_8 = (SELECT COUNT(*) FROM reviews WHERE movie_id = movies.id AND deleted IS NULL AND is_private = FALSE AND rating = 8),
+    rating_9 = (SELECT COUNT(*) FROM reviews WHERE movie_id = movies.id AND deleted IS NULL AND is_private = FALSE AND rating = 9),
+    rating_10 = (SELECT COUNT(*) FROM reviews WHERE movie_id = movies.id AND deleted IS NULL AND is_private = FALSE AND rating = 10);
+
+UPDATE movies SET
+    rating_count = rating_1 + rating_2 + rating_3 + rating_4 + rating_5 + rating_6 + rating_7 + rating_8 + rating_9 + rating_10,
+    rating_sum = rating_1 + 2 * rating_2 + 3 * rating_3 + 4 * rating_4 + 5 * rating_5 + 6 * rating_6 + 7 * rating_7 + 8 * rating_8 + 9 * rating_9 + 10 * rating_10;
+
+-- The prior of the Bayesian score is models.RatingPriorMean and
+-- models.RatingPriorCount.
+UPDATE movies SET
+    rating_mean = CASE WHEN rating_count > 0 THEN rating_sum * 1.0 / rating_count ELSE 0 END,
+    rating = (60 + rating_sum) * 1.0 / (10 + rating_count);
+
+CREATE INDEX movies_rating_mean_idx ON movies (rating_mean, id);
+CREATE INDEX movies_rating_count_idx ON movies (rating_count, id);
@@ -0,0 +1,6 @@
+ALTER TABLE movies ADD COLUMN poster_key TEXT;
+ALTER TABLE movies ADD COLUMN poster_url TEXT;
+ALTER TABLE movies ADD COLUMN poster_content_type TEXT;
+ALTER TABLE movies ADD COLUMN poster_size BIGINT;
+ALTER TABLE movies ADD COLUMN poster_width INTEGER;
+ALTER TABLE movies ADD COLUMN poster_height INTEGER;
@@ -0,0 +1,3 @@
+ALTER TABLE movies ADD COLUMN poster_variants TEXT;
+ALTER TABLE movies ADD COLUMN poster_blur_hash TEXT;
+ALTER TABLE movies ADD COLUMN poster_dominant_color TEXT;
@@ -0,0 +1,3 @@
+ALTER TABLE people ADD COLUMN external_id TEXT;
+
+CREATE UNIQUE INDEX people_external_id_idx ON people (external_id);
@@ -0,0 +1,14 @@
+CREATE TABLE movie_revisions (
+    id          CHAR(24)    PRIMARY KEY,
+    movie_id    CHAR(24)    NOT NULL,
+    version     INTEGER     NOT NULL,
+    action      TEXT        NOT NULL,
+    old_fields  TEXT        NOT NULL,
+    new_fields  TEXT        NOT NULL,
+    reverted_to INTEGER,
+    created_by  CHAR(24),
+    created_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX movie_revisions_movie_id_idx ON movie_revisions (movie_id, id);
+CREATE INDEX movie_revisions_version_idx ON movie_revisions (movie_id, version, id);
@@ -0,0 +1,10 @@
+ALTER TABLE review_categories ADD COLUMN sort_order INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE review_categories ADD COLUMN archived TIMESTAMPTZ;
+ALTER TABLE review_categories ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE review_categories ADD COLUMN created_at TIMESTAMPTZ;
+ALTER TABLE review_categories ADD COLUMN updated_at TIMESTAMPTZ;
+ALTER TABLE review_categories ADD COLUMN created_by CHAR(24);
+ALTER TABLE review_categories ADD COLUMN updated_by CHAR(24);
+
+CREATE UNIQUE INDEX review_categories_name_idx ON review_categories (name);
+CREATE INDEX reviews_review_category_id_idx ON reviews (review_category_id);
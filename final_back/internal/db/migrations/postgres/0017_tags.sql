@@ -0,0 +1,33 @@
+CREATE TABLE tags (
+    id         CHAR(24)    PRIMARY KEY,
+    name       TEXT        NOT NULL UNIQUE,
+    curated    BOOLEAN     NOT NULL DEFAULT FALSE,
+    version    INTEGER     NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    created_by CHAR(24),
+    updated_by CHAR(24)
+);
+
+CREATE INDEX tags_created_at_idx ON tags (created_at, id);
+CREATE INDEX tags_updated_at_idx ON tags (updated_at, id);
+
+CREATE TABLE movie_tags (
+    id         CHAR(24)    PRIMARY KEY,
+    movie_id   CHAR(24)    NOT NULL,
+    tag_id     CHAR(24),
+    name       TEXT        NOT NULL DEFAULT '',
+    status     TEXT        NOT NULL,
+    reason     TEXT        NOT NULL DEFAULT '',
+    version    INTEGER     NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    created_by CHAR(24),
+    updated_by CHAR(24),
+    UNIQUE (movie_id, tag_id)
+);
+
+CREATE INDEX movie_tags_tag_id_idx ON movie_tags (tag_id);
+CREATE INDEX movie_tags_status_idx ON movie_tags (status, id);
+CREATE INDEX movie_tags_created_at_idx ON movie_tags (created_at, id);
+CREATE INDEX movie_tags_updated_at_idx ON movie_tags (updated_at, id);
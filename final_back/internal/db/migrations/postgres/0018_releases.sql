@@ -0,0 +1,26 @@
+ALTER TABLE movies ADD COLUMN runtime INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE movies ADD COLUMN original_language TEXT NOT NULL DEFAULT '';
+ALTER TABLE movies ADD COLUMN synopsis TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX movies_runtime_idx ON movies (runtime, id);
+CREATE INDEX movies_original_language_idx ON movies (original_language);
+
+CREATE TABLE releases (
+    id            CHAR(24)    PRIMARY KEY,
+    movie_id      CHAR(24)    NOT NULL,
+    country       TEXT        NOT NULL,
+    date          TEXT        NOT NULL,
+    type          TEXT        NOT NULL,
+    certification TEXT        NOT NULL DEFAULT '',
+    version       INTEGER     NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ,
+    updated_at    TIMESTAMPTZ,
+    created_by    CHAR(24),
+    updated_by    CHAR(24),
+    UNIQUE (movie_id, country, type)
+);
+
+CREATE INDEX releases_country_date_idx ON releases (country, date, id);
+CREATE INDEX releases_date_idx ON releases (date, id);
+CREATE INDEX releases_created_at_idx ON releases (created_at, id);
+CREATE INDEX releases_updated_at_idx ON releases (updated_at, id);
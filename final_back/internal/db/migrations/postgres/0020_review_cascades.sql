@@ -0,0 +1,7 @@
+ALTER TABLE reviews ADD COLUMN movie_trashed BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE reviews ADD COLUMN owner_trashed BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Users already in the trash keep their reviews visible and counted until
+-- they are purged, since hiding them here would leave the rating
+-- aggregates counting them.
+UPDATE reviews SET movie_trashed = TRUE WHERE movie_id IN (SELECT id FROM movies WHERE deleted IS NOT NULL);
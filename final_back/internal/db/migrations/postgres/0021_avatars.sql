@@ -0,0 +1,9 @@
+ALTER TABLE users ADD COLUMN avatar_key TEXT;
+ALTER TABLE users ADD COLUMN avatar_url TEXT;
+ALTER TABLE users ADD COLUMN avatar_content_type TEXT;
+ALTER TABLE users ADD COLUMN avatar_size BIGINT;
+ALTER TABLE users ADD COLUMN avatar_width INTEGER;
+ALTER TABLE users ADD COLUMN avatar_height INTEGER;
+ALTER TABLE users ADD COLUMN avatar_variants TEXT;
+ALTER TABLE users ADD COLUMN avatar_blur_hash TEXT;
+ALTER TABLE users ADD COLUMN avatar_dominant_color TEXT;
@@ -0,0 +1,44 @@
+CREATE TABLE users (
+    id            CHAR(24) PRIMARY KEY,
+    username      TEXT     NOT NULL UNIQUE,
+    password_hash BLOB,
+    email         TEXT     NOT NULL DEFAULT ''
+);
+
+CREATE TABLE user_roles (
+    user_id  CHAR(24) NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    position INTEGER  NOT NULL,
+    role     TEXT     NOT NULL,
+    PRIMARY KEY (user_id, position)
+);
+
+CREATE TABLE movies (
+    id          CHAR(24) PRIMARY KEY,
+    title       TEXT     NOT NULL DEFAULT '',
+    year        INTEGER  NOT NULL DEFAULT 0,
+    director_id CHAR(24),
+    genre_id    CHAR(24),
+    rating      REAL     NOT NULL DEFAULT 0,
+    image_url   TEXT     NOT NULL DEFAULT ''
+);
+
+CREATE TABLE review_categories (
+    id   CHAR(24) PRIMARY KEY,
+    name TEXT     NOT NULL
+);
+
+CREATE TABLE reviews (
+    id                 CHAR(24) PRIMARY KEY,
+    movie_id           CHAR(24) NOT NULL,
+    owner_id           CHAR(24) NOT NULL,
+    review_category_id CHAR(24) NOT NULL,
+    rating             INTEGER  NOT NULL,
+    content            TEXT,
+    created            DATETIME,
+    updated            DATETIME,
+    deleted            DATETIME,
+    is_private         BOOLEAN  NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX reviews_movie_id_idx ON reviews (movie_id);
+CREATE INDEX reviews_owner_id_idx ON reviews (owner_id);
@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE movies ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE reviews ADD COLUMN version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN deleted DATETIME;
+ALTER TABLE movies ADD COLUMN deleted DATETIME;
@@ -0,0 +1,30 @@
+CREATE TABLE people (
+    id         CHAR(24) PRIMARY KEY,
+    name       TEXT     NOT NULL,
+    birth_year INTEGER,
+    bio        TEXT     NOT NULL DEFAULT '',
+    image_url  TEXT     NOT NULL DEFAULT '',
+    version    INTEGER  NOT NULL DEFAULT 0,
+    created_at DATETIME,
+    updated_at DATETIME,
+    created_by CHAR(24),
+    updated_by CHAR(24)
+);
+
+CREATE INDEX people_name_idx ON people (name, id);
+CREATE INDEX people_birth_year_idx ON people (birth_year, id);
+CREATE INDEX people_created_at_idx ON people (created_at, id);
+CREATE INDEX people_updated_at_idx ON people (updated_at, id);
+
+CREATE TABLE genres (
+    id         CHAR(24) PRIMARY KEY,
+    name       TEXT     NOT NULL UNIQUE,
+    version    INTEGER  NOT NULL DEFAULT 0,
+    created_at DATETIME,
+    updated_at DATETIME,
+    created_by CHAR(24),
+    updated_by CHAR(24)
+);
+
+CREATE INDEX genres_created_at_idx ON genres (created_at, id);
+CREATE INDEX genres_updated_at_idx ON genres (updated_at, id);
@@ -0,0 +1,3 @@
+ALTER TABLE movies ADD COLUMN external_id TEXT;
+
+CREATE UNIQUE INDEX movies_external_id_idx ON movies (external_id);
@@ -0,0 +1,19 @@
+CREATE TABLE suggestions (
+    id            CHAR(24) PRIMARY KEY,
+    movie_id      CHAR(24),
+    movie_version INTEGER  NOT NULL DEFAULT 0,
+    title         TEXT,
+    year          INTEGER,
+    image_url     TEXT,
+    status        TEXT     NOT NULL,
+    reason        TEXT     NOT NULL DEFAULT '',
+    version       INTEGER  NOT NULL DEFAULT 0,
+    created_at    DATETIME,
+    updated_at    DATETIME,
+    created_by    CHAR(24),
+    updated_by    CHAR(24)
+);
+
+CREATE INDEX suggestions_status_idx ON suggestions (status, id);
+CREATE INDEX suggestions_created_at_idx ON suggestions (created_at, id);
+CREATE INDEX suggestions_updated_at_idx ON suggestions (updated_at, id);
@@ -0,0 +1,13 @@
+CREATE TABLE translations (
+    id         CHAR(24) PRIMARY KEY,
+    kind       TEXT     NOT NULL,
+    record_id  CHAR(24) NOT NULL,
+    locale     TEXT     NOT NULL,
+    fields     TEXT     NOT NULL,
+    version    INTEGER  NOT NULL DEFAULT 0,
+    created_at DATETIME,
+    updated_at DATETIME,
+    created_by CHAR(24),
+    updated_by CHAR(24),
+    UNIQUE (kind, record_id, locale)
+);
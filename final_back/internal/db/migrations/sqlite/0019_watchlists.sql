@@ -0,0 +1,19 @@
+CREATE TABLE watchlist_entries (
+    id         CHAR(24) PRIMARY KEY,
+    user_id    CHAR(24) NOT NULL,
+    movie_id   CHAR(24) NOT NULL,
+    created_at DATETIME,
+    updated_at DATETIME,
+    created_by CHAR(24),
+    updated_by CHAR(24),
+    UNIQUE (user_id, movie_id)
+);
+
+CREATE INDEX watchlist_entries_movie_id_idx ON watchlist_entries (movie_id);
+CREATE INDEX watchlist_entries_created_at_idx ON watchlist_entries (created_at, id);
+CREATE INDEX watchlist_entries_updated_at_idx ON watchlist_entries (updated_at, id);
+
+CREATE TABLE calendar_tokens (
+    user_id CHAR(24) PRIMARY KEY,
+    token   TEXT     NOT NULL UNIQUE
+);
@@ -0,0 +1,2 @@
+ALTER TABLE suggestions ADD COLUMN director_id CHAR(24);
+ALTER TABLE suggestions ADD COLUMN genre_id CHAR(24);
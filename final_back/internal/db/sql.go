@@ -0,0 +1,113 @@
+package db
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"go.uber.org/zap"
+	_ "modernc.org/sqlite"
+)
+
+// SQL dialects understood by NewSQL. Each one has its own directory of
+// migrations under migrations/.
+const (
+	DialectPostgres = "postgres"
+	DialectSQLite   = "sqlite"
+)
+
+var drivers = map[string]string{
+	DialectPostgres: "pgx",
+	DialectSQLite:   "sqlite",
+}
+
+//go:embed migrations
+var migrations embed.FS
+
+func NewSQL(log *zap.Logger, dialect, dsn string) *sql.DB {
+	sqlDB, err := OpenSQL(dialect, dsn)
+	if err != nil {
+		log.Fatal("couldn't connect to sql database", zap.String("dialect", dialect), zap.Error(err))
+	}
+	return sqlDB
+}
+
+// OpenSQL connects to dsn using the driver for dialect and brings the schema
+// up to date.
+func OpenSQL(dialect, dsn string) (*sql.DB, error) {
+	driver, ok := drivers[dialect]
+	if !ok {
+		return nil, fmt.Errorf("unknown sql dialect %q", dialect)
+	}
+
+	sqlDB, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, err
+	}
+	if dialect == DialectSQLite {
+		// SQLite allows a single writer; sharing one connection also keeps
+		// in-memory databases from being split across the pool.
+		sqlDB.SetMaxOpenConns(1)
+	}
+
+	if err := sqlDB.Ping(); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+	if err := migrate(sqlDB, dialect); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+	return sqlDB, nil
+}
+
+// migrate applies, in file name order, every migration of dialect that is not
+// yet recorded in schema_migrations. Each file runs in its own transaction.
+func migrate(sqlDB *sql.DB, dialect string) error {
+	if _, err := sqlDB.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version TEXT PRIMARY KEY)`); err != nil {
+		return err
+	}
+
+	dir := path.Join("migrations", dialect)
+	entries, err := fs.ReadDir(migrations, dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		version := entry.Name()
+
+		var applied int
+		if err := sqlDB.QueryRow(`SELECT COUNT(*) FROM schema_migrations WHERE version = $1`, version).Scan(&applied); err != nil {
+			return err
+		}
+		if applied > 0 {
+			continue
+		}
+
+		script, err := fs.ReadFile(migrations, path.Join(dir, version))
+		if err != nil {
+			return err
+		}
+
+		tx, err := sqlDB.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(string(script)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %s: %w", version, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES ($1)`, version); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -7,4 +7,7 @@ var (
 	NotFound           = errors.New("not found")
 	InvalidCredentials = errors.New("invalid credentials")
 	Forbidden          = errors.New("forbidden")
+	Conflict           = errors.New("version conflict")
+	InvalidReference   = errors.New("invalid reference")
+	InUse              = errors.New("still referenced")
 )
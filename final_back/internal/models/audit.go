@@ -0,0 +1,13 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Audit records when and by whom a record was created and last changed. It
+// is stamped by the repository layer on every write; UpdatedBy is left empty
+// for changes made by the system rather than a user.
+type Audit struct {
+	CreatedAt *primitive.DateTime `json:"createdAt,omitempty" bson:"createdAt,omitempty"`
+	UpdatedAt *primitive.DateTime `json:"updatedAt,omitempty" bson:"updatedAt,omitempty"`
+	CreatedBy *ID                 `json:"createdBy,omitempty" bson:"createdBy,omitempty"`
+	UpdatedBy *ID                 `json:"updatedBy,omitempty" bson:"updatedBy,omitempty"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// CalendarContentType is the content type iCalendar feeds are served as.
+const CalendarContentType = "text/calendar; charset=utf-8"
+
+// CalendarFeed is the secret address of a user's watchlist calendar. Anyone
+// who has it can read the feed, so it is only shown to its user, who can
+// replace it with a new one.
+type CalendarFeed struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
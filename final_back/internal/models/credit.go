@@ -0,0 +1,46 @@
+package models
+
+// CreditRole is the part a person plays in making a movie.
+type CreditRole string
+
+const (
+	CreditActor           CreditRole = "actor"
+	CreditDirector        CreditRole = "director"
+	CreditWriter          CreditRole = "writer"
+	CreditProducer        CreditRole = "producer"
+	CreditComposer        CreditRole = "composer"
+	CreditCinematographer CreditRole = "cinematographer"
+	CreditEditor          CreditRole = "editor"
+)
+
+// Credit links a person to a movie they worked on. Actors also carry the
+// character they play. Order is the billing order among the movie's
+// credits, lowest first.
+type Credit struct {
+	ID        ID         `json:"id,omitzero" bson:"_id,omitempty"`
+	MovieID   ID         `json:"movieId" bson:"movieId"`
+	PersonID  ID         `json:"personId" bson:"personId"`
+	Role      CreditRole `json:"role" bson:"role"`
+	Character string     `json:"character,omitempty" bson:"character,omitempty"`
+	Order     int        `json:"order" bson:"order"`
+	Version   int64      `json:"version" bson:"version"`
+	Audit     `bson:",inline"`
+
+	// Person and Movie are filled in for the credit lists of a movie and of
+	// a person respectively, and are never stored.
+	Person *Person `json:"person,omitempty" bson:"-"`
+	Movie  *Movie  `json:"movie,omitempty" bson:"-"`
+}
+
+type CreateCreditRequest struct {
+	PersonID  *ID        `json:"personId" binding:"required"`
+	Role      CreditRole `json:"role" binding:"required,oneof=actor director writer producer composer cinematographer editor"`
+	Character string     `json:"character,omitempty"`
+	Order     int        `json:"order" binding:"min=0"`
+}
+
+type UpdateCreditRequest struct {
+	Role      *CreditRole `json:"role,omitempty" binding:"omitempty,oneof=actor director writer producer composer cinematographer editor"`
+	Character *string     `json:"character,omitempty"`
+	Order     *int        `json:"order,omitempty" binding:"omitempty,min=0"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "errors"
+
+// ErrInvalidMerge is returned for a merge of a movie into itself.
+var ErrInvalidMerge = errors.New("cannot merge a movie into itself")
+
+// Duplicate is a pair of live movies that look like the same film. Movie is
+// the older of the two and the one to keep; Score, between 0 and 1, is how
+// sure the match is.
+type Duplicate struct {
+	Movie     *Movie  `json:"movie"`
+	Duplicate *Movie  `json:"duplicate"`
+	Score     float64 `json:"score"`
+}
+
+// MergeMovieRequest names the movie to merge into the one in the URL.
+type MergeMovieRequest struct {
+	DuplicateID *ID `json:"duplicateId" binding:"required"`
+}
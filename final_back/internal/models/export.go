@@ -0,0 +1,24 @@
+package models
+
+import "errors"
+
+var ErrInvalidExport = errors.New("invalid export")
+
+// Formats the catalogue can be exported in.
+const (
+	ExportCSV    = "csv"
+	ExportNDJSON = "ndjson"
+	ExportJSONLD = "jsonld"
+)
+
+// ExportContentTypes maps export formats to the content type they are
+// served as.
+var ExportContentTypes = map[string]string{
+	ExportCSV:    "text/csv; charset=utf-8",
+	ExportNDJSON: "application/x-ndjson",
+	ExportJSONLD: "application/ld+json",
+}
+
+// ExportColumns are the columns of a CSV export. Director and genre hold the
+// names of the referenced records next to their IDs.
+var ExportColumns = []string{"id", "externalId", "title", "year", "directorId", "director", "genreId", "genre", "imageURL", "rating", "ratingMean", "ratingCount", "createdAt", "updatedAt"}
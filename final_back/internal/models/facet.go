@@ -0,0 +1,22 @@
+package models
+
+// Facets of a movie list that ?facets= can ask for.
+const (
+	FacetGenre  = "genre"
+	FacetDecade = "decade"
+	FacetTag    = "tag"
+	FacetRating = "rating"
+)
+
+// FacetCount is the number of listed movies that share a value: a genre or
+// tag ID, the first year of a decade, or the whole part of a rating score.
+// Name is the name of the genre or tag.
+type FacetCount struct {
+	Value string `json:"value"`
+	Name  string `json:"name,omitempty"`
+	Count int    `json:"count"`
+}
+
+// MovieFacets maps the facets a client asked for to their counts, largest
+// first.
+type MovieFacets map[string][]FacetCount
@@ -0,0 +1,32 @@
+package models
+
+type Genre struct {
+	ID      ID     `json:"id,omitzero" bson:"_id,omitempty"`
+	Name    string `json:"name" bson:"name"`
+	Version int64  `json:"version" bson:"version"`
+	Audit   `bson:",inline"`
+}
+
+type CreateGenreRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+type UpdateGenreRequest struct {
+	Name *string `json:"name,omitempty" binding:"omitempty,min=1"`
+}
+
+var GenreListFields = withAudit(ListFields{
+	"name": {Type: FieldString, Sortable: true},
+})
+
+func (g *Genre) ListID() ID { return g.ID }
+
+func (g *Genre) ListValue(field string) any {
+	if v, ok := g.Audit.listValue(field); ok {
+		return v
+	}
+	if field == "name" {
+		return g.Name
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ID identifies a stored entity independently of the storage backend. It is
+// a 12 byte value laid out like a Mongo ObjectID, so that existing documents
+// keep their identifiers, and is written as 24 hex characters everywhere
+// else (JSON, SQL, URLs, tokens).
+type ID [12]byte
+
+// NilID is the zero ID; it never identifies a stored entity.
+var NilID ID
+
+var ErrInvalidID = errors.New("invalid id")
+
+// NewID returns a new, unique ID. IDs generated later compare greater.
+func NewID() ID {
+	return ID(primitive.NewObjectID())
+}
+
+// ParseID parses the 24 character hex form of an ID.
+func ParseID(s string) (ID, error) {
+	var id ID
+	if len(s) != 2*len(id) {
+		return NilID, fmt.Errorf("%w: %q", ErrInvalidID, s)
+	}
+	if _, err := hex.Decode(id[:], []byte(s)); err != nil {
+		return NilID, fmt.Errorf("%w: %q", ErrInvalidID, s)
+	}
+	return id, nil
+}
+
+func (id ID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func (id ID) IsZero() bool {
+	return id == NilID
+}
+
+// Compare orders IDs by creation time.
+func (id ID) Compare(other ID) int {
+	return bytes.Compare(id[:], other[:])
+}
+
+func (id ID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+func (id *ID) UnmarshalText(text []byte) error {
+	parsed, err := ParseID(string(text))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+// MarshalBSONValue stores the ID as a native ObjectID.
+func (id ID) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bsontype.ObjectID, id[:], nil
+}
+
+// UnmarshalBSONValue accepts ObjectIDs as well as their hex string form.
+func (id *ID) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	switch t {
+	case bsontype.ObjectID:
+		if len(data) != len(id) {
+			return fmt.Errorf("%w: %d bytes", ErrInvalidID, len(data))
+		}
+		copy(id[:], data)
+		return nil
+	case bsontype.String:
+		// A BSON string is an int32 length followed by the bytes and a NUL.
+		if len(data) < 5 {
+			return fmt.Errorf("%w: truncated string", ErrInvalidID)
+		}
+		return id.UnmarshalText(data[4 : len(data)-1])
+	case bsontype.Null:
+		*id = NilID
+		return nil
+	}
+	return fmt.Errorf("%w: cannot decode %s", ErrInvalidID, t)
+}
+
+// Value stores the ID in SQL databases as its hex form.
+func (id ID) Value() (driver.Value, error) {
+	return id.String(), nil
+}
+
+func (id *ID) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		return id.UnmarshalText([]byte(v))
+	case []byte:
+		return id.UnmarshalText(v)
+	}
+	return fmt.Errorf("%w: cannot scan %T", ErrInvalidID, src)
+}
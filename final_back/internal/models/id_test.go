@@ -0,0 +1,98 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseID(t *testing.T) {
+	id := NewID()
+
+	parsed, err := ParseID(id.String())
+	require.NoError(t, err)
+	assert.Equal(t, id, parsed)
+
+	for _, invalid := range []string{"", "xyz", "0123456789abcdef0123456", "zz23456789abcdef01234567"} {
+		_, err := ParseID(invalid)
+		assert.ErrorIs(t, err, ErrInvalidID, invalid)
+	}
+}
+
+func TestIDJSON(t *testing.T) {
+	type doc struct {
+		ID       ID  `json:"id,omitzero"`
+		Optional *ID `json:"optional,omitempty"`
+	}
+
+	id := NewID()
+	data, err := json.Marshal(doc{ID: id, Optional: &id})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"`+id.String()+`","optional":"`+id.String()+`"}`, string(data))
+
+	var decoded doc
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, id, decoded.ID)
+	assert.Equal(t, &id, decoded.Optional)
+
+	data, err = json.Marshal(doc{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(data))
+
+	assert.Error(t, json.Unmarshal([]byte(`{"id":"nope"}`), &decoded))
+}
+
+func TestIDBSON(t *testing.T) {
+	type doc struct {
+		ID       ID  `bson:"_id,omitempty"`
+		Optional *ID `bson:"optional,omitempty"`
+	}
+
+	id := NewID()
+	data, err := bson.Marshal(doc{ID: id, Optional: &id})
+	require.NoError(t, err)
+
+	// Stored as native ObjectIDs so existing documents keep matching.
+	var raw struct {
+		ID       primitive.ObjectID `bson:"_id"`
+		Optional primitive.ObjectID `bson:"optional"`
+	}
+	require.NoError(t, bson.Unmarshal(data, &raw))
+	assert.Equal(t, primitive.ObjectID(id), raw.ID)
+	assert.Equal(t, primitive.ObjectID(id), raw.Optional)
+
+	var decoded doc
+	require.NoError(t, bson.Unmarshal(data, &decoded))
+	assert.Equal(t, id, decoded.ID)
+	assert.Equal(t, &id, decoded.Optional)
+
+	data, err = bson.Marshal(doc{})
+	require.NoError(t, err)
+	var empty bson.M
+	require.NoError(t, bson.Unmarshal(data, &empty))
+	assert.Empty(t, empty)
+
+	data, err = bson.Marshal(bson.M{"_id": id.String()})
+	require.NoError(t, err)
+	require.NoError(t, bson.Unmarshal(data, &decoded))
+	assert.Equal(t, id, decoded.ID)
+}
+
+func TestIDSQL(t *testing.T) {
+	id := NewID()
+
+	value, err := id.Value()
+	require.NoError(t, err)
+	assert.Equal(t, id.String(), value)
+
+	var scanned ID
+	require.NoError(t, scanned.Scan(value))
+	assert.Equal(t, id, scanned)
+	require.NoError(t, scanned.Scan([]byte(id.String())))
+	assert.Equal(t, id, scanned)
+	assert.Error(t, scanned.Scan(42))
+}
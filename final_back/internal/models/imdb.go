@@ -0,0 +1,57 @@
+package models
+
+import "errors"
+
+var ErrInvalidIMDbDump = errors.New("invalid IMDb dump")
+
+// Files of an IMDb dataset dump, as published. The crew file is optional:
+// without it, directors are told from the titles people are known for.
+const (
+	IMDbTitleBasics  = "title.basics.tsv.gz"
+	IMDbTitleRatings = "title.ratings.tsv.gz"
+	IMDbTitleCrew    = "title.crew.tsv.gz"
+	IMDbNameBasics   = "name.basics.tsv.gz"
+)
+
+// IMDbOptions choose which titles of a dump become movies. Adult titles are
+// always left out.
+type IMDbOptions struct {
+	// TitleTypes are the title types to import; by default only "movie".
+	TitleTypes []string `json:"titleTypes"`
+	// MinVotes leaves out titles with fewer IMDb votes.
+	MinVotes int `json:"minVotes"`
+}
+
+// IMDbReport counts what an IMDb import read and did. Movies and people
+// keep their IMDb IDs (tconst and nconst) as external IDs, so running the
+// import again updates them instead of adding duplicates.
+type IMDbReport struct {
+	TitlesRead  int `json:"titlesRead"`
+	RatingsRead int `json:"ratingsRead"`
+	CrewRead    int `json:"crewRead"`
+	NamesRead   int `json:"namesRead"`
+
+	MoviesCreated   int `json:"moviesCreated"`
+	MoviesUpdated   int `json:"moviesUpdated"`
+	MoviesUnchanged int `json:"moviesUnchanged"`
+	GenresCreated   int `json:"genresCreated"`
+	PeopleCreated   int `json:"peopleCreated"`
+	PeopleUpdated   int `json:"peopleUpdated"`
+	PeopleUnchanged int `json:"peopleUnchanged"`
+	DirectorsLinked int `json:"directorsLinked"`
+
+	Failed int           `json:"failed"`
+	Errors []ImportError `json:"errors"`
+	// Resumed is set when the import carried on from a checkpoint.
+	Resumed bool `json:"resumed"`
+}
+
+// IMDbCheckpoint records how far an IMDb import got, so that an interrupted
+// import can carry on where it stopped. Line is the last line of Phase that
+// was written.
+type IMDbCheckpoint struct {
+	Options IMDbOptions `json:"options"`
+	Phase   string      `json:"phase"`
+	Line    int         `json:"line"`
+	Report  IMDbReport  `json:"report"`
+}
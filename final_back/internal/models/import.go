@@ -0,0 +1,54 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrInvalidImport = errors.New("invalid import")
+
+// Formats movies can be imported from.
+const (
+	ImportCSV    = "csv"
+	ImportNDJSON = "ndjson"
+)
+
+// ImportColumns are the CSV columns an import understands, named after the
+// JSON fields of CreateMovieRequest. Only title and year are needed in the
+// header; missing columns leave their field empty.
+var ImportColumns = []string{"externalId", "title", "year", "directorId", "genreId", "imageURL", "runtime", "originalLanguage", "synopsis"}
+
+type ImportStatus string
+
+const (
+	ImportQueued  ImportStatus = "queued"
+	ImportRunning ImportStatus = "running"
+	ImportDone    ImportStatus = "done"
+)
+
+// ImportJob tracks a bulk movie import. Rows with an external ID that is
+// already in the catalogue update that movie; all others create one. A row
+// that fails is reported in Errors and does not stop the rest.
+type ImportJob struct {
+	ID         ID            `json:"id"`
+	Format     string        `json:"format"`
+	Status     ImportStatus  `json:"status"`
+	Total      int           `json:"total"`
+	Processed  int           `json:"processed"`
+	Created    int           `json:"created"`
+	Updated    int           `json:"updated"`
+	Failed     int           `json:"failed"`
+	Errors     []ImportError `json:"errors"`
+	CreatedBy  ID            `json:"createdBy,omitzero"`
+	CreatedAt  time.Time     `json:"createdAt"`
+	FinishedAt *time.Time    `json:"finishedAt,omitempty"`
+}
+
+// ImportError reports why the row on Line of the input was not imported.
+type ImportError struct {
+	// File is only set by imports that read several files.
+	File       string `json:"file,omitempty"`
+	Line       int    `json:"line"`
+	ExternalID string `json:"externalId,omitempty"`
+	Error      string `json:"error"`
+}
@@ -0,0 +1,310 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fields every audited resource can be listed by. A "-" prefix on a sort
+// field sorts in descending order.
+const (
+	SortCreatedAt = "createdAt"
+	SortUpdatedAt = "updatedAt"
+)
+
+// Page sizes used when a client does not ask for one, and the most it can
+// ask for.
+const (
+	DefaultListLimit = 20
+	MaxListLimit     = 100
+)
+
+var (
+	ErrInvalidSort   = errors.New("invalid sort field")
+	ErrInvalidFilter = errors.New("invalid filter")
+	ErrInvalidLimit  = errors.New("invalid limit")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Op is the comparison a Filter applies.
+type Op string
+
+const (
+	OpEq  Op = "="
+	OpGt  Op = ">"
+	OpGte Op = ">="
+	OpLt  Op = "<"
+	OpLte Op = "<="
+	// OpIn keeps the records whose field equals one of the values in a
+	// []any. Clients cannot filter with it; services use it to look up the
+	// records of many others at once.
+	OpIn Op = "IN"
+)
+
+// FieldType is the type of the values of a list field. Filter and cursor
+// values are string, int64, float64, time.Time and ID respectively.
+type FieldType int
+
+const (
+	FieldString FieldType = iota
+	FieldInt
+	FieldFloat
+	FieldTime
+	FieldID
+)
+
+// Parse converts the text form of a value to the type's Go representation.
+// Times are RFC 3339.
+func (t FieldType) Parse(s string) (any, error) {
+	switch t {
+	case FieldInt:
+		return strconv.ParseInt(s, 10, 64)
+	case FieldFloat:
+		return strconv.ParseFloat(s, 64)
+	case FieldTime:
+		v, err := time.Parse(time.RFC3339Nano, s)
+		return v.UTC(), err
+	case FieldID:
+		return ParseID(s)
+	}
+	return s, nil
+}
+
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case ID:
+		return v.String()
+	case string:
+		return v
+	}
+	return ""
+}
+
+// ListField describes a field a list can be filtered by and, if Sortable,
+// sorted by.
+type ListField struct {
+	Type     FieldType
+	Sortable bool
+}
+
+// ListFields maps the JSON names of a resource's list fields to their
+// descriptions.
+type ListFields map[string]ListField
+
+var auditListFields = ListFields{
+	SortCreatedAt: {Type: FieldTime, Sortable: true},
+	SortUpdatedAt: {Type: FieldTime, Sortable: true},
+	"createdBy":   {Type: FieldID},
+	"updatedBy":   {Type: FieldID},
+}
+
+func withAudit(fields ListFields) ListFields {
+	maps.Copy(fields, auditListFields)
+	return fields
+}
+
+var (
+	UserListFields = withAudit(ListFields{
+		"username": {Type: FieldString, Sortable: true},
+	})
+	MovieListFields = withAudit(ListFields{
+		"title":       {Type: FieldString, Sortable: true},
+		"year":        {Type: FieldInt, Sortable: true},
+		"rating":      {Type: FieldFloat, Sortable: true},
+		"ratingMean":  {Type: FieldFloat, Sortable: true},
+		"ratingCount": {Type: FieldInt, Sortable: true},
+		"runtime":     {Type: FieldInt, Sortable: true},
+		"directorId":  {Type: FieldID},
+		"genreId":     {Type: FieldID},
+		// originalLanguage is a canonical BCP 47 tag such as "ko" or "pt-BR".
+		"originalLanguage": {Type: FieldString},
+		// FilterCastID, FilterTagID and FilterReleasedIn are not stored on
+		// movies: MovieService turns them into the IDs of the movies the
+		// person is credited in, of those that carry the tag and of those
+		// released in the country.
+		FilterCastID:     {Type: FieldID},
+		FilterTagID:      {Type: FieldID},
+		FilterReleasedIn: {Type: FieldString},
+	})
+	ReviewListFields = withAudit(ListFields{
+		"rating":           {Type: FieldInt, Sortable: true},
+		"reviewCategoryId": {Type: FieldID},
+	})
+)
+
+// ParseFilter parses a query parameter such as "genreId=...", "year>=2000"
+// (which arrives as the key "year>" with the value "2000") or "rating>8"
+// (the key "rating>8" with no value). ok is false if the parameter does not
+// name one of the fields.
+func (fields ListFields) ParseFilter(key, value string) (filter Filter, ok bool, err error) {
+	filter = Filter{Field: key, Op: OpEq}
+	if i := strings.IndexAny(key, "<>"); i >= 0 && i == len(key)-1 {
+		filter.Field, filter.Op = key[:i], Op(key[i:]+"=")
+	} else if i >= 0 && value == "" {
+		filter.Field, filter.Op, value = key[:i], Op(key[i:i+1]), key[i+1:]
+	}
+
+	field, ok := fields[filter.Field]
+	if !ok {
+		return filter, false, nil
+	}
+	if filter.Value, err = field.Type.Parse(value); err != nil {
+		return filter, true, ErrInvalidFilter
+	}
+	return filter, true, nil
+}
+
+// Listable is implemented by the records list queries return. ListValue
+// returns the value of a list field in its FieldType's representation, or
+// nil if the record does not have it.
+type Listable interface {
+	ListID() ID
+	ListValue(field string) any
+}
+
+// Filter keeps the records whose Field compares to Value with Op.
+type Filter struct {
+	Field string
+	Op    Op
+	Value any
+}
+
+// ListOptions narrows down, orders and pages the result of a list query. The
+// zero value lists everything in ID order.
+type ListOptions struct {
+	Sort    string
+	Filters []Filter
+	// IDs, unless nil, keeps only the records with one of these IDs.
+	IDs []ID
+
+	// Limit caps the size of the page; zero means no limit.
+	Limit int
+	// Cursor is the NextCursor of the previous page.
+	Cursor string
+}
+
+// SortField returns the field to sort by and whether the order is
+// descending. An empty field means ID order.
+func (o ListOptions) SortField() (string, bool) {
+	if field, ok := strings.CutPrefix(o.Sort, "-"); ok {
+		return field, true
+	}
+	return o.Sort, false
+}
+
+// Validate checks o against the list fields of the listed resource.
+func (o ListOptions) Validate(fields ListFields) error {
+	if field, _ := o.SortField(); field != "" && !fields[field].Sortable {
+		return ErrInvalidSort
+	}
+	for _, filter := range o.Filters {
+		if _, ok := fields[filter.Field]; !ok {
+			return ErrInvalidFilter
+		}
+		switch filter.Op {
+		case OpEq, OpGt, OpGte, OpLt, OpLte:
+		default:
+			return ErrInvalidFilter
+		}
+	}
+	if o.Limit < 0 {
+		return ErrInvalidLimit
+	}
+	_, err := o.ParseCursor(fields)
+	return err
+}
+
+// Page is one page of a list and the cursor of the page after it, which is
+// empty on the last page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// Cursor is the position of the last item of a page: its value of the sort
+// field, nil if it has none, and its ID, which breaks ties.
+type Cursor struct {
+	Value any
+	ID    ID
+}
+
+type cursorJSON struct {
+	Sort  string  `json:"s,omitempty"`
+	Value *string `json:"v,omitempty"`
+	ID    ID      `json:"id"`
+}
+
+// NextCursor returns the opaque cursor of the page that follows last.
+func (o ListOptions) NextCursor(last Listable) string {
+	c := cursorJSON{Sort: o.Sort, ID: last.ListID()}
+	if field, _ := o.SortField(); field != "" {
+		if v := last.ListValue(field); v != nil {
+			text := formatValue(v)
+			c.Value = &text
+		}
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ParseCursor decodes o.Cursor, which must have been issued for the same
+// sort order. It returns nil if there is no cursor.
+func (o ListOptions) ParseCursor(fields ListFields) (*Cursor, error) {
+	if o.Cursor == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(o.Cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c cursorJSON
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != o.Sort {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := &Cursor{ID: c.ID}
+	if field, _ := o.SortField(); field != "" && c.Value != nil {
+		if cursor.Value, err = fields[field].Type.Parse(*c.Value); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return cursor, nil
+}
+
+// listValue returns the value of an audit list field; ok is false if field
+// is not one.
+func (a Audit) listValue(field string) (v any, ok bool) {
+	switch field {
+	case SortCreatedAt:
+		if a.CreatedAt != nil {
+			return a.CreatedAt.Time().UTC(), true
+		}
+	case SortUpdatedAt:
+		if a.UpdatedAt != nil {
+			return a.UpdatedAt.Time().UTC(), true
+		}
+	case "createdBy":
+		if a.CreatedBy != nil {
+			return *a.CreatedBy, true
+		}
+	case "updatedBy":
+		if a.UpdatedBy != nil {
+			return *a.UpdatedBy, true
+		}
+	default:
+		return nil, false
+	}
+	return nil, true
+}
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilter(t *testing.T) {
+	for _, tc := range []struct {
+		key, value string
+		want       Filter
+	}{
+		{"title", "Heat", Filter{Field: "title", Op: OpEq, Value: "Heat"}},
+		{"year>", "2000", Filter{Field: "year", Op: OpGte, Value: int64(2000)}},
+		{"year<", "2000", Filter{Field: "year", Op: OpLte, Value: int64(2000)}},
+		{"rating>8.5", "", Filter{Field: "rating", Op: OpGt, Value: 8.5}},
+		{"rating<8", "", Filter{Field: "rating", Op: OpLt, Value: float64(8)}},
+	} {
+		filter, ok, err := MovieListFields.ParseFilter(tc.key, tc.value)
+		require.NoError(t, err, tc.key)
+		assert.True(t, ok, tc.key)
+		assert.Equal(t, tc.want, filter, tc.key)
+	}
+
+	_, ok, err := MovieListFields.ParseFilter("expand", "director")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = MovieListFields.ParseFilter("year>", "soon")
+	assert.ErrorIs(t, err, ErrInvalidFilter)
+	assert.True(t, ok)
+}
+
+func TestListOptionsValidate(t *testing.T) {
+	assert.NoError(t, ListOptions{Sort: "-year"}.Validate(MovieListFields))
+	assert.NoError(t, ListOptions{Sort: SortCreatedAt}.Validate(ReviewListFields))
+	assert.ErrorIs(t, ListOptions{Sort: "genreId"}.Validate(MovieListFields), ErrInvalidSort)
+	assert.ErrorIs(t, ListOptions{Sort: "title"}.Validate(UserListFields), ErrInvalidSort)
+	assert.ErrorIs(t, ListOptions{Filters: []Filter{{Field: "title", Op: "~"}}}.Validate(MovieListFields), ErrInvalidFilter)
+	assert.ErrorIs(t, ListOptions{Limit: -1}.Validate(MovieListFields), ErrInvalidLimit)
+	assert.ErrorIs(t, ListOptions{Cursor: "!"}.Validate(MovieListFields), ErrInvalidCursor)
+}
+
+func TestCursor(t *testing.T) {
+	movie := &Movie{ID: NewID(), Ratings: RatingStats{Score: 7.25}}
+	opts := ListOptions{Sort: "-rating"}
+
+	opts.Cursor = opts.NextCursor(movie)
+	cursor, err := opts.ParseCursor(MovieListFields)
+	require.NoError(t, err)
+	assert.Equal(t, &Cursor{Value: 7.25, ID: movie.ID}, cursor)
+
+	// A cursor only continues the order it was issued for.
+	opts.Sort = "rating"
+	_, err = opts.ParseCursor(MovieListFields)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	// Records without a value of the sort field get a cursor without one.
+	user := &User{ID: NewID()}
+	opts = ListOptions{Sort: SortUpdatedAt}
+	opts.Cursor = opts.NextCursor(user)
+	cursor, err = opts.ParseCursor(UserListFields)
+	require.NoError(t, err)
+	assert.Equal(t, &Cursor{ID: user.ID}, cursor)
+}
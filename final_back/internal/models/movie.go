@@ -3,29 +3,100 @@ package models
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
 type Movie struct {
-	ID         *primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	Title      string              `json:"title,omitempty" bson:"title,omitempty"`
-	Year       int                 `json:"year,omitempty" bson:"year,omitempty"`
-	DirectorID *primitive.ObjectID `json:"directorId,omitempty" bson:"directorId,omitempty"`
-	GenreID    *primitive.ObjectID `json:"genreId,omitempty" bson:"genreId,omitempty"`
-	Rating     float64             `json:"rating,omitempty" bson:"rating,omitempty"`
-	ImageURL   string              `json:"imageURL,omitempty" bson:"imageURL,omitempty"`
+	ID         ID      `json:"id,omitzero" bson:"_id,omitempty"`
+	ExternalID string  `json:"externalId,omitempty" bson:"externalId,omitempty"`
+	Title      string  `json:"title,omitempty" bson:"title"`
+	Year       int     `json:"year,omitempty" bson:"year"`
+	DirectorID *ID     `json:"directorId,omitempty" bson:"directorId,omitempty"`
+	GenreID    *ID     `json:"genreId,omitempty" bson:"genreId,omitempty"`
+	ImageURL   string  `json:"imageURL,omitempty" bson:"imageURL,omitempty"`
+	Poster     *Poster `json:"poster,omitempty" bson:"poster,omitempty"`
+	// Runtime is in minutes. OriginalLanguage is a canonical BCP 47 tag.
+	// The fields movies are sorted and filtered by are stored even when
+	// empty, so that Mongo orders them like their zero values elsewhere.
+	Runtime          int                 `json:"runtime,omitempty" bson:"runtime"`
+	OriginalLanguage string              `json:"originalLanguage,omitempty" bson:"originalLanguage"`
+	Synopsis         string              `json:"synopsis,omitempty" bson:"synopsis,omitempty"`
+	Deleted          *primitive.DateTime `json:"deleted,omitempty" bson:"deleted,omitempty"`
+	Version          int64               `json:"version" bson:"version"`
+	Ratings          RatingStats         `json:"ratings" bson:",inline"`
+	Audit            `bson:",inline"`
+
+	// Director and Genre are only filled in when a client asks to expand
+	// them, and are never stored.
+	Director *Person `json:"director,omitempty" bson:"-"`
+	Genre    *Genre  `json:"genre,omitempty" bson:"-"`
 }
 
+// FilterCastID lists the movies a person is credited in.
+const FilterCastID = "castId"
+
+// FilterReleasedIn lists the movies already released in a country.
+const FilterReleasedIn = "releasedIn"
+
+// References of a movie that ?expand= can ask for.
+const (
+	ExpandDirector = "director"
+	ExpandGenre    = "genre"
+)
+
 type CreateMovieRequest struct {
-	Title      string              `json:"title" binding:"required"`
-	Year       int                 `json:"year" binding:"required"`
-	DirectorID *primitive.ObjectID `json:"directorId" binding:"required"`
-	GenreID    *primitive.ObjectID `json:"genreId" binding:"required"`
-	Rating     float64             `json:"rating" binding:"required"`
-	ImageURL   string              `json:"imageURL" binding:"required"`
+	ExternalID string `json:"externalId" binding:"omitempty,max=100"`
+	Title      string `json:"title" binding:"required"`
+	Year       int    `json:"year" binding:"required"`
+	DirectorID *ID    `json:"directorId" binding:"required"`
+	GenreID    *ID    `json:"genreId" binding:"required"`
+	ImageURL   string `json:"imageURL" binding:"required"`
+
+	Runtime          int    `json:"runtime,omitempty" binding:"omitempty,min=1,max=1000"`
+	OriginalLanguage string `json:"originalLanguage,omitempty" binding:"omitempty,max=35"`
+	Synopsis         string `json:"synopsis,omitempty" binding:"omitempty,max=5000"`
 }
 
 type UpdateMovieRequest struct {
-	Title      *string             `json:"title,omitempty" binding:"omitempty,required"`
-	Year       *int                `json:"year,omitempty" binding:"omitempty,required"`
-	DirectorID *primitive.ObjectID `json:"directorId,omitempty" binding:"omitempty,required"`
-	GenreID    *primitive.ObjectID `json:"genreId,omitempty" binding:"omitempty,required"`
-	Rating     *float64            `json:"rating,omitempty" binding:"omitempty,required"`
-	ImageURL   *string             `json:"imageURL,omitempty" binding:"omitempty,required"`
+	Title      *string `json:"title,omitempty" binding:"omitempty,required"`
+	Year       *int    `json:"year,omitempty" binding:"omitempty,required"`
+	DirectorID *ID     `json:"directorId,omitempty" binding:"omitempty,required"`
+	GenreID    *ID     `json:"genreId,omitempty" binding:"omitempty,required"`
+	ImageURL   *string `json:"imageURL,omitempty" binding:"omitempty,required"`
+
+	Runtime          *int    `json:"runtime,omitempty" binding:"omitempty,min=1,max=1000"`
+	OriginalLanguage *string `json:"originalLanguage,omitempty" binding:"omitempty,max=35"`
+	Synopsis         *string `json:"synopsis,omitempty" binding:"omitempty,max=5000"`
+
+	// Poster is set by poster uploads and never bound from a request.
+	Poster *Poster `json:"-"`
+}
+
+func (m *Movie) ListID() ID { return m.ID }
+
+func (m *Movie) ListValue(field string) any {
+	if v, ok := m.Audit.listValue(field); ok {
+		return v
+	}
+	switch field {
+	case "title":
+		return m.Title
+	case "year":
+		return int64(m.Year)
+	case "rating":
+		return m.Ratings.Score
+	case "ratingMean":
+		return m.Ratings.Mean
+	case "ratingCount":
+		return int64(m.Ratings.Count)
+	case "runtime":
+		return int64(m.Runtime)
+	case "originalLanguage":
+		return m.OriginalLanguage
+	case "directorId":
+		if m.DirectorID != nil {
+			return *m.DirectorID
+		}
+	case "genreId":
+		if m.GenreID != nil {
+			return *m.GenreID
+		}
+	}
+	return nil
 }
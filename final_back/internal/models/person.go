@@ -0,0 +1,50 @@
+package models
+
+// Person is anyone who works on movies, such as a movie's director.
+type Person struct {
+	ID         ID     `json:"id,omitzero" bson:"_id,omitempty"`
+	ExternalID string `json:"externalId,omitempty" bson:"externalId,omitempty"`
+	Name       string `json:"name" bson:"name"`
+	BirthYear  *int   `json:"birthYear,omitempty" bson:"birthYear,omitempty"`
+	Bio        string `json:"bio,omitempty" bson:"bio,omitempty"`
+	ImageURL   string `json:"imageURL,omitempty" bson:"imageURL,omitempty"`
+	Version    int64  `json:"version" bson:"version"`
+	Audit      `bson:",inline"`
+}
+
+type CreatePersonRequest struct {
+	ExternalID string `json:"externalId,omitempty" binding:"omitempty,max=100"`
+	Name       string `json:"name" binding:"required"`
+	BirthYear  *int   `json:"birthYear,omitempty"`
+	Bio        string `json:"bio,omitempty"`
+	ImageURL   string `json:"imageURL,omitempty"`
+}
+
+type UpdatePersonRequest struct {
+	Name      *string `json:"name,omitempty" binding:"omitempty,min=1"`
+	BirthYear *int    `json:"birthYear,omitempty"`
+	Bio       *string `json:"bio,omitempty"`
+	ImageURL  *string `json:"imageURL,omitempty"`
+}
+
+var PersonListFields = withAudit(ListFields{
+	"name":      {Type: FieldString, Sortable: true},
+	"birthYear": {Type: FieldInt, Sortable: true},
+})
+
+func (p *Person) ListID() ID { return p.ID }
+
+func (p *Person) ListValue(field string) any {
+	if v, ok := p.Audit.listValue(field); ok {
+		return v
+	}
+	switch field {
+	case "name":
+		return p.Name
+	case "birthYear":
+		if p.BirthYear != nil {
+			return int64(*p.BirthYear)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package models
+
+import "errors"
+
+var (
+	ErrImageTooLarge    = errors.New("image too large")
+	ErrUnsupportedImage = errors.New("unsupported image")
+)
+
+// PosterTypes maps the content types posters and avatars may be uploaded
+// as to the extension of the blob they are stored in.
+var PosterTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// ImageVariants are the scaled-down copies made of every uploaded image,
+// by name and width. Images no wider than a variant are used as it as is.
+var ImageVariants = []struct {
+	Name  string
+	Width int
+}{
+	{VariantThumbnail, 160},
+	{VariantMedium, 480},
+	{VariantLarge, 1080},
+}
+
+const (
+	VariantThumbnail = "thumbnail"
+	VariantMedium    = "medium"
+	VariantLarge     = "large"
+)
+
+// Poster describes an uploaded poster or avatar image, stored as the blob
+// Key and served at URL. BlurHash and DominantColor let clients paint a placeholder
+// of the right size and colour before any of the image has loaded.
+type Poster struct {
+	Key           string         `json:"key" bson:"key"`
+	URL           string         `json:"url" bson:"url"`
+	ContentType   string         `json:"contentType" bson:"contentType"`
+	Size          int64          `json:"size" bson:"size"`
+	Width         int            `json:"width" bson:"width"`
+	Height        int            `json:"height" bson:"height"`
+	Variants      []ImageVariant `json:"variants,omitempty" bson:"variants,omitempty"`
+	BlurHash      string         `json:"blurHash,omitempty" bson:"blurHash,omitempty"`
+	DominantColor string         `json:"dominantColor,omitempty" bson:"dominantColor,omitempty"`
+}
+
+// ImageVariant is one of the ImageVariants of an image.
+type ImageVariant struct {
+	Name        string `json:"name" bson:"name"`
+	Key         string `json:"key" bson:"key"`
+	URL         string `json:"url" bson:"url"`
+	ContentType string `json:"contentType" bson:"contentType"`
+	Size        int64  `json:"size" bson:"size"`
+	Width       int    `json:"width" bson:"width"`
+	Height      int    `json:"height" bson:"height"`
+}
@@ -0,0 +1,68 @@
+package models
+
+// Reviews rate movies from MinRating to MaxRating.
+const (
+	MinRating = 1
+	MaxRating = 10
+)
+
+// The Bayesian score of a movie is its mean rating pulled towards
+// RatingPriorMean as if it had RatingPriorCount more ratings of that value,
+// so that a handful of enthusiastic reviews does not put a movie on top.
+const (
+	RatingPriorMean  = 6.0
+	RatingPriorCount = 10
+)
+
+// RatingStats aggregates the ratings of a movie's public, live reviews.
+// Histogram[i] counts the ratings of i+1. The score is stored as the
+// movie's rating, which is what sorting by rating orders by.
+type RatingStats struct {
+	Score     float64        `json:"score" bson:"rating"`
+	Mean      float64        `json:"mean" bson:"ratingMean"`
+	Count     int            `json:"count" bson:"ratingCount"`
+	Sum       int            `json:"-" bson:"ratingSum"`
+	Histogram [MaxRating]int `json:"histogram" bson:"ratingHistogram"`
+}
+
+// NewRatingStats returns the aggregate of a movie nobody has rated yet.
+func NewRatingStats() RatingStats {
+	return RatingStats{Score: RatingPriorMean}
+}
+
+// Adjust takes the rating remove out of the aggregate and puts add into it.
+// Either may be 0, which stands for no rating.
+func (s RatingStats) Adjust(remove, add int) RatingStats {
+	if ValidRating(remove) {
+		s.Count--
+		s.Sum -= remove
+		s.Histogram[remove-1]--
+	}
+	if ValidRating(add) {
+		s.Count++
+		s.Sum += add
+		s.Histogram[add-1]++
+	}
+
+	s.Mean = 0
+	if s.Count > 0 {
+		s.Mean = float64(s.Sum) / float64(s.Count)
+	}
+	s.Score = (RatingPriorMean*RatingPriorCount + float64(s.Sum)) / float64(RatingPriorCount+s.Count)
+	return s
+}
+
+func ValidRating(rating int) bool {
+	return rating >= MinRating && rating <= MaxRating
+}
+
+// CountedRating returns the rating a review contributes to its movie's
+// aggregate, or 0 if it contributes none because it is private, deleted or
+// its owner is in the trash. A movie's own trashing leaves its aggregate
+// alone, since its reviews come back with it.
+func CountedRating(review *Review) int {
+	if review.IsPrivate || review.Deleted != nil || review.OwnerTrashed || !ValidRating(review.Rating) {
+		return 0
+	}
+	return review.Rating
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+// ErrInvalidCountry is returned for a country that is not an ISO 3166-1
+// alpha-2 code.
+var ErrInvalidCountry = errors.New("invalid country")
+
+// ReleaseType is how a movie was released in a country.
+type ReleaseType string
+
+const (
+	ReleaseTheatrical ReleaseType = "theatrical"
+	ReleaseStreaming  ReleaseType = "streaming"
+)
+
+// ReleaseDateLayout is the layout of release dates. Dates are local to the
+// country of the release and sort as strings.
+const ReleaseDateLayout = time.DateOnly
+
+// Release is when and how a movie came out in a country, along with the
+// age certification it got there, such as "PG-13" or "16+". Country is an
+// upper-case ISO 3166-1 alpha-2 code. A movie has at most one release of
+// each type per country.
+type Release struct {
+	ID            ID          `json:"id,omitzero" bson:"_id,omitempty"`
+	MovieID       ID          `json:"movieId" bson:"movieId"`
+	Country       string      `json:"country" bson:"country"`
+	Date          string      `json:"date" bson:"date"`
+	Type          ReleaseType `json:"type" bson:"type"`
+	Certification string      `json:"certification,omitempty" bson:"certification,omitempty"`
+	Version       int64       `json:"version" bson:"version"`
+	Audit         `bson:",inline"`
+
+	// Movie is filled in for lists of upcoming releases and is never
+	// stored.
+	Movie *Movie `json:"movie,omitempty" bson:"-"`
+}
+
+type CreateReleaseRequest struct {
+	Country       string      `json:"country" binding:"required,len=2"`
+	Date          string      `json:"date" binding:"required,datetime=2006-01-02"`
+	Type          ReleaseType `json:"type" binding:"required,oneof=theatrical streaming"`
+	Certification string      `json:"certification,omitempty" binding:"omitempty,max=20"`
+}
+
+type UpdateReleaseRequest struct {
+	Date          *string `json:"date,omitempty" binding:"omitempty,datetime=2006-01-02"`
+	Certification *string `json:"certification,omitempty" binding:"omitempty,max=20"`
+}
+
+// ParseCountry returns the upper-case ISO 3166-1 alpha-2 code of a country
+// such as "us" or "US", with deprecated codes such as "UK" replaced.
+func ParseCountry(s string) (string, error) {
+	if len(s) != 2 {
+		return "", ErrInvalidCountry
+	}
+	region, err := language.ParseRegion(s)
+	if err != nil || !region.IsCountry() {
+		return "", ErrInvalidCountry
+	}
+	return region.Canonicalize().String(), nil
+}
+
+var ReleaseListFields = withAudit(ListFields{
+	"movieId": {Type: FieldID},
+	"country": {Type: FieldString},
+	"type":    {Type: FieldString},
+	"date":    {Type: FieldString, Sortable: true},
+})
+
+func (r *Release) ListID() ID { return r.ID }
+
+func (r *Release) ListValue(field string) any {
+	if v, ok := r.Audit.listValue(field); ok {
+		return v
+	}
+	switch field {
+	case "movieId":
+		return r.MovieID
+	case "country":
+		return r.Country
+	case "type":
+		return string(r.Type)
+	case "date":
+		return r.Date
+	}
+	return nil
+}
@@ -3,34 +3,75 @@ package models
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
 type Review struct {
-	ID               *primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	MovieID          primitive.ObjectID  `json:"movieId" bson:"movieId"`
-	OwnerID          primitive.ObjectID  `json:"ownerId" bson:"ownerId"`
-	ReviewCategoryID primitive.ObjectID  `json:"reviewCategoryId" bson:"reviewCategoryId"`
+	ID               ID                  `json:"id,omitzero" bson:"_id,omitempty"`
+	MovieID          ID                  `json:"movieId" bson:"movieId"`
+	OwnerID          ID                  `json:"ownerId,omitzero" bson:"ownerId"`
+	ReviewCategoryID ID                  `json:"reviewCategoryId" bson:"reviewCategoryId"`
 	Rating           int                 `json:"rating" bson:"rating"`
 	Content          *string             `json:"content,omitempty" bson:"content,omitempty"`
-	Created          *primitive.DateTime `json:"created,omitempty" bson:"created,omitempty"`
-	Updated          *primitive.DateTime `json:"updated,omitempty" bson:"updated,omitempty"`
 	Deleted          *primitive.DateTime `json:"deleted,omitempty" bson:"deleted,omitempty"`
 	IsPrivate        bool                `json:"isPrivate" bson:"isPrivate"`
+	Version          int64               `json:"version" bson:"version"`
+	Audit            `bson:",inline"`
+
+	// MovieTrashed and OwnerTrashed hide the review while its movie or its
+	// owner is in the trash, apart from whether the review itself is.
+	MovieTrashed bool `json:"-" bson:"movieTrashed,omitempty"`
+	OwnerTrashed bool `json:"-" bson:"ownerTrashed,omitempty"`
 }
 
 type CreateReviewRequest struct {
-	MovieID          primitive.ObjectID `json:"movieId"`
-	ReviewCategoryID primitive.ObjectID `json:"reviewCategoryId" bson:"reviewCategoryId"`
-	Rating           int                `json:"rating" binding:"required,min=1,max=10"`
-	Content          *string            `json:"content,omitempty" binding:"omitempty,max=500"`
-	IsPrivate        bool               `json:"isPrivate"`
+	MovieID          ID      `json:"movieId"`
+	ReviewCategoryID ID      `json:"reviewCategoryId" bson:"reviewCategoryId"`
+	Rating           int     `json:"rating" binding:"required,min=1,max=10"`
+	Content          *string `json:"content,omitempty" binding:"omitempty,max=500"`
+	IsPrivate        bool    `json:"isPrivate"`
 }
 
 type UpdateReviewRequest struct {
-	ReviewCategoryID primitive.ObjectID `json:"reviewCategoryId" bson:"reviewCategoryId"`
-	Rating           int                `json:"rating" binding:"omitempty,min=1,max=10"`
-	Content          *string            `json:"content,omitempty" binding:"omitempty,max=500"`
-	IsPrivate        bool               `json:"isPrivate"`
+	ReviewCategoryID ID      `json:"reviewCategoryId" bson:"reviewCategoryId"`
+	Rating           int     `json:"rating" binding:"omitempty,min=1,max=10"`
+	Content          *string `json:"content,omitempty" binding:"omitempty,max=500"`
+	IsPrivate        bool    `json:"isPrivate"`
 }
 
+// ReviewCategory is a label reviewers pick for their review. Categories are
+// listed by Order; archived ones are kept for the reviews that have them but
+// cannot be picked anymore.
 type ReviewCategory struct {
-	ID   primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	Name string             `json:"name" bson:"name"`
+	ID       ID                  `json:"id,omitzero" bson:"_id,omitempty"`
+	Name     string              `json:"name" bson:"name"`
+	Order    int                 `json:"order" bson:"order"`
+	Archived *primitive.DateTime `json:"archived,omitempty" bson:"archived,omitempty"`
+	Version  int64               `json:"version" bson:"version"`
+	Audit    `bson:",inline"`
+
+	// Usage is the number of live reviews in the category. It is only
+	// filled in for moderators.
+	Usage *int `json:"usage,omitempty" bson:"-"`
+}
+
+type CreateReviewCategoryRequest struct {
+	Name  string `json:"name" binding:"required,max=100"`
+	Order int    `json:"order" binding:"min=0"`
+}
+
+type UpdateReviewCategoryRequest struct {
+	Name  *string `json:"name,omitempty" binding:"omitempty,min=1,max=100"`
+	Order *int    `json:"order,omitempty" binding:"omitempty,min=0"`
+}
+
+func (r *Review) ListID() ID { return r.ID }
+
+func (r *Review) ListValue(field string) any {
+	if v, ok := r.Audit.listValue(field); ok {
+		return v
+	}
+	switch field {
+	case "rating":
+		return int64(r.Rating)
+	case "reviewCategoryId":
+		return r.ReviewCategoryID
+	}
+	return nil
 }
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrInvalidRevert is returned for a revert to a version that is not
+// earlier than the current one.
+var ErrInvalidRevert = errors.New("can only revert to an earlier version")
+
+// RevisionAction is the kind of change a revision records.
+type RevisionAction string
+
+const (
+	RevisionCreated  RevisionAction = "created"
+	RevisionUpdated  RevisionAction = "updated"
+	RevisionDeleted  RevisionAction = "deleted"
+	RevisionRestored RevisionAction = "restored"
+	RevisionReverted RevisionAction = "reverted"
+)
+
+// MovieFields holds the editable fields of a movie that a revision
+// changed; the fields it left alone are nil.
+type MovieFields struct {
+	Title      *string `json:"title,omitempty" bson:"title,omitempty"`
+	Year       *int    `json:"year,omitempty" bson:"year,omitempty"`
+	DirectorID *ID     `json:"directorId,omitempty" bson:"directorId,omitempty"`
+	GenreID    *ID     `json:"genreId,omitempty" bson:"genreId,omitempty"`
+	ImageURL   *string `json:"imageURL,omitempty" bson:"imageURL,omitempty"`
+	Poster     *Poster `json:"poster,omitempty" bson:"poster,omitempty"`
+
+	Runtime          *int    `json:"runtime,omitempty" bson:"runtime,omitempty"`
+	OriginalLanguage *string `json:"originalLanguage,omitempty" bson:"originalLanguage,omitempty"`
+	Synopsis         *string `json:"synopsis,omitempty" bson:"synopsis,omitempty"`
+}
+
+// MovieRevision records one change to a movie: who made it, when, and the
+// values of the changed fields before and after it. Version is the version
+// of the movie the change produced. A revert also records the version it
+// went back to.
+type MovieRevision struct {
+	ID         ID                 `json:"id" bson:"_id"`
+	MovieID    ID                 `json:"movieId" bson:"movieId"`
+	Version    int64              `json:"version" bson:"version"`
+	Action     RevisionAction     `json:"action" bson:"action"`
+	Before     MovieFields        `json:"before" bson:"before"`
+	After      MovieFields        `json:"after" bson:"after"`
+	RevertedTo *int64             `json:"revertedTo,omitempty" bson:"revertedTo,omitempty"`
+	CreatedBy  *ID                `json:"createdBy,omitempty" bson:"createdBy,omitempty"`
+	CreatedAt  primitive.DateTime `json:"createdAt" bson:"createdAt"`
+}
+
+var RevisionListFields = ListFields{
+	"version": {Type: FieldInt, Sortable: true},
+	"action":  {Type: FieldString},
+}
+
+func (r *MovieRevision) ListID() ID { return r.ID }
+
+func (r *MovieRevision) ListValue(field string) any {
+	switch field {
+	case "version":
+		return r.Version
+	case "action":
+		return string(r.Action)
+	}
+	return nil
+}
+
+// DiffMovie returns the editable fields that differ between before and
+// after, with their values on either side. A nil before stands for a movie
+// that did not exist yet.
+func DiffMovie(before, after *Movie) (MovieFields, MovieFields) {
+	if before == nil {
+		before = &Movie{}
+	}
+	var old, new MovieFields
+	if before.Title != after.Title {
+		old.Title, new.Title = nonZero(before.Title), nonZero(after.Title)
+	}
+	if before.Year != after.Year {
+		old.Year, new.Year = nonZero(before.Year), nonZero(after.Year)
+	}
+	if !equalIDs(before.DirectorID, after.DirectorID) {
+		old.DirectorID, new.DirectorID = before.DirectorID, after.DirectorID
+	}
+	if !equalIDs(before.GenreID, after.GenreID) {
+		old.GenreID, new.GenreID = before.GenreID, after.GenreID
+	}
+	if before.ImageURL != after.ImageURL {
+		old.ImageURL, new.ImageURL = nonZero(before.ImageURL), nonZero(after.ImageURL)
+	}
+	if posterKey(before.Poster) != posterKey(after.Poster) {
+		old.Poster, new.Poster = before.Poster, after.Poster
+	}
+	if before.Runtime != after.Runtime {
+		old.Runtime, new.Runtime = nonZero(before.Runtime), nonZero(after.Runtime)
+	}
+	if before.OriginalLanguage != after.OriginalLanguage {
+		old.OriginalLanguage, new.OriginalLanguage = nonZero(before.OriginalLanguage), nonZero(after.OriginalLanguage)
+	}
+	if before.Synopsis != after.Synopsis {
+		old.Synopsis, new.Synopsis = nonZero(before.Synopsis), nonZero(after.Synopsis)
+	}
+	return old, new
+}
+
+// IsZero tells whether f holds no fields at all.
+func (f MovieFields) IsZero() bool {
+	return f.Title == nil && f.Year == nil && f.DirectorID == nil && f.GenreID == nil && f.ImageURL == nil && f.Poster == nil &&
+		f.Runtime == nil && f.OriginalLanguage == nil && f.Synopsis == nil
+}
+
+func nonZero[T comparable](v T) *T {
+	var zero T
+	if v == zero {
+		return nil
+	}
+	return &v
+}
+
+func equalIDs(a, b *ID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// Posters are content-addressed, so two posters are the same image exactly
+// when their keys are equal.
+func posterKey(p *Poster) string {
+	if p == nil {
+		return ""
+	}
+	return p.Key
+}
@@ -0,0 +1,12 @@
+package models
+
+// SearchHit is a movie matching a search query and how well it matches.
+type SearchHit struct {
+	ID    ID
+	Score float64
+}
+
+type MovieSearchResult struct {
+	Movie *Movie  `json:"movie"`
+	Score float64 `json:"score"`
+}
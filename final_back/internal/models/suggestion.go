@@ -0,0 +1,74 @@
+package models
+
+import "errors"
+
+var (
+	// ErrInvalidSuggestion is returned for a suggestion that changes nothing,
+	// or a new movie without every field CreateMovieRequest requires.
+	ErrInvalidSuggestion = errors.New("invalid suggestion")
+	// ErrSuggestionResolved is returned for a suggestion that has already
+	// been approved or rejected.
+	ErrSuggestionResolved = errors.New("suggestion already resolved")
+)
+
+type SuggestionStatus string
+
+const (
+	SuggestionPending  SuggestionStatus = "pending"
+	SuggestionApproved SuggestionStatus = "approved"
+	SuggestionRejected SuggestionStatus = "rejected"
+)
+
+// Suggestion is a change to the catalogue proposed by a user, waiting for a
+// moderator. Without a MovieID it proposes a new movie; with one it proposes
+// corrections to the movie as it was at MovieVersion. Once a new movie is
+// approved, MovieID is the movie it became.
+type Suggestion struct {
+	ID           ID               `json:"id,omitzero" bson:"_id,omitempty"`
+	MovieID      *ID              `json:"movieId,omitempty" bson:"movieId,omitempty"`
+	MovieVersion int64            `json:"movieVersion,omitempty" bson:"movieVersion,omitempty"`
+	Title        *string          `json:"title,omitempty" bson:"title,omitempty"`
+	Year         *int             `json:"year,omitempty" bson:"year,omitempty"`
+	DirectorID   *ID              `json:"directorId,omitempty" bson:"directorId,omitempty"`
+	GenreID      *ID              `json:"genreId,omitempty" bson:"genreId,omitempty"`
+	ImageURL     *string          `json:"imageURL,omitempty" bson:"imageURL,omitempty"`
+	Status       SuggestionStatus `json:"status" bson:"status"`
+	Reason       string           `json:"reason,omitempty" bson:"reason,omitempty"`
+	Version      int64            `json:"version" bson:"version"`
+	Audit        `bson:",inline"`
+}
+
+type CreateSuggestionRequest struct {
+	MovieID    *ID     `json:"movieId,omitempty"`
+	Title      *string `json:"title,omitempty" binding:"omitempty,min=1"`
+	Year       *int    `json:"year,omitempty" binding:"omitempty,min=1"`
+	DirectorID *ID     `json:"directorId,omitempty"`
+	GenreID    *ID     `json:"genreId,omitempty"`
+	ImageURL   *string `json:"imageURL,omitempty" binding:"omitempty,url"`
+}
+
+type RejectSuggestionRequest struct {
+	Reason string `json:"reason" binding:"required,max=1000"`
+}
+
+var SuggestionListFields = withAudit(ListFields{
+	"status":  {Type: FieldString},
+	"movieId": {Type: FieldID},
+})
+
+func (s *Suggestion) ListID() ID { return s.ID }
+
+func (s *Suggestion) ListValue(field string) any {
+	if v, ok := s.Audit.listValue(field); ok {
+		return v
+	}
+	switch field {
+	case "status":
+		return string(s.Status)
+	case "movieId":
+		if s.MovieID != nil {
+			return *s.MovieID
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidTag is returned for a request to tag a movie that names neither
+// an existing tag nor a new one, or both.
+var ErrInvalidTag = errors.New("invalid tag")
+
+// Tag is a label such as "time travel" or "heist" that movies can carry
+// besides their genre. Curated tags are made by moderators; the others come
+// from suggestions by users. Names are normalized with NormalizeTagName.
+type Tag struct {
+	ID      ID     `json:"id,omitzero" bson:"_id,omitempty"`
+	Name    string `json:"name" bson:"name"`
+	Curated bool   `json:"curated" bson:"curated"`
+	Version int64  `json:"version" bson:"version"`
+	Audit   `bson:",inline"`
+}
+
+type CreateTagRequest struct {
+	Name string `json:"name" binding:"required,max=50"`
+}
+
+type UpdateTagRequest struct {
+	Name    *string `json:"name,omitempty" binding:"omitempty,min=1,max=50"`
+	Curated *bool   `json:"curated,omitempty"`
+}
+
+// NormalizeTagName lowercases a tag name and collapses its whitespace, so
+// that "Time  Travel" and "time travel" are one tag.
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// MovieTag puts a tag on a movie. Moderators' tags are approved at once;
+// users' wait for a moderator like suggestions do. A suggested tag that does
+// not exist yet has a Name and no TagID until it is approved.
+type MovieTag struct {
+	ID      ID               `json:"id,omitzero" bson:"_id,omitempty"`
+	MovieID ID               `json:"movieId" bson:"movieId"`
+	TagID   *ID              `json:"tagId,omitempty" bson:"tagId,omitempty"`
+	Name    string           `json:"name,omitempty" bson:"name,omitempty"`
+	Status  SuggestionStatus `json:"status" bson:"status"`
+	Reason  string           `json:"reason,omitempty" bson:"reason,omitempty"`
+	Version int64            `json:"version" bson:"version"`
+	Audit   `bson:",inline"`
+}
+
+// TagMovieRequest names either an existing tag or a new one.
+type TagMovieRequest struct {
+	TagID *ID     `json:"tagId,omitempty"`
+	Name  *string `json:"name,omitempty" binding:"omitempty,min=1,max=50"`
+}
+
+// FilterTagID lists the movies that carry a tag.
+const FilterTagID = "tagId"
+
+var (
+	TagListFields = withAudit(ListFields{
+		"name": {Type: FieldString, Sortable: true},
+	})
+	MovieTagListFields = withAudit(ListFields{
+		"movieId": {Type: FieldID},
+		"tagId":   {Type: FieldID},
+		"status":  {Type: FieldString},
+	})
+)
+
+func (t *Tag) ListID() ID { return t.ID }
+
+func (t *Tag) ListValue(field string) any {
+	if v, ok := t.Audit.listValue(field); ok {
+		return v
+	}
+	if field == "name" {
+		return t.Name
+	}
+	return nil
+}
+
+func (t *MovieTag) ListID() ID { return t.ID }
+
+func (t *MovieTag) ListValue(field string) any {
+	if v, ok := t.Audit.listValue(field); ok {
+		return v
+	}
+	switch field {
+	case "movieId":
+		return t.MovieID
+	case "tagId":
+		if t.TagID != nil {
+			return *t.TagID
+		}
+	case "status":
+		return string(t.Status)
+	}
+	return nil
+}
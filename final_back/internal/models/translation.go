@@ -0,0 +1,60 @@
+package models
+
+import (
+	"errors"
+
+	"golang.org/x/text/language"
+)
+
+var (
+	// ErrInvalidLocale is returned for a locale that is not a well-formed
+	// BCP 47 language tag.
+	ErrInvalidLocale = errors.New("invalid locale")
+	// ErrInvalidTranslation is returned for a translation of a field that
+	// cannot be translated, or of no field at all.
+	ErrInvalidTranslation = errors.New("invalid translation")
+)
+
+// TranslationKind is the kind of record a translation belongs to.
+type TranslationKind string
+
+const (
+	TranslationMovie          TranslationKind = "movie"
+	TranslationReviewCategory TranslationKind = "reviewCategory"
+)
+
+// TranslatableFields are the fields each kind of record can be translated
+// in, by their JSON names.
+var TranslatableFields = map[TranslationKind][]string{
+	TranslationMovie:          {"title", "synopsis"},
+	TranslationReviewCategory: {"name"},
+}
+
+// Translation holds the fields of a record in another locale, keyed by
+// their JSON names. Locale is a canonical BCP 47 tag such as "en" or
+// "pt-BR". A record has at most one translation per locale.
+type Translation struct {
+	ID       ID                `json:"id,omitzero" bson:"_id,omitempty"`
+	Kind     TranslationKind   `json:"kind" bson:"kind"`
+	RecordID ID                `json:"recordId" bson:"recordId"`
+	Locale   string            `json:"locale" bson:"locale"`
+	Fields   map[string]string `json:"fields" bson:"fields"`
+	Version  int64             `json:"version" bson:"version"`
+	Audit    `bson:",inline"`
+}
+
+// PutTranslationRequest replaces the translation of a record into one
+// locale.
+type PutTranslationRequest struct {
+	Fields map[string]string `json:"fields" binding:"required,min=1,dive,keys,required,endkeys,required,max=500"`
+}
+
+// ParseLocale returns the canonical form of a BCP 47 language tag, such as
+// "pt-BR" for "pt_br".
+func ParseLocale(s string) (string, error) {
+	tag, err := language.Parse(s)
+	if err != nil || tag == language.Und {
+		return "", ErrInvalidLocale
+	}
+	return tag.String(), nil
+}
@@ -3,11 +3,19 @@ package models
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
 type User struct {
-	ID           *primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	ID           ID                  `json:"id,omitzero" bson:"_id,omitempty"`
 	Username     string              `json:"username,omitempty" bson:"username,omitempty"`
 	PasswordHash []byte              `json:"passwordHash,omitempty" bson:"passwordHash,omitempty"`
 	Email        string              `json:"email,omitempty" bson:"email,omitempty"`
 	Roles        []Role              `json:"roles" bson:"roles"`
+	Deleted      *primitive.DateTime `json:"deleted,omitempty" bson:"deleted,omitempty"`
+	Version      int64               `json:"version" bson:"version"`
+	Audit        `bson:",inline"`
+
+	// AvatarKey is the blob key of the user's uploaded avatar, which Avatar
+	// describes along with its variants. Both are set by avatar uploads only.
+	AvatarKey string  `json:"avatarKey,omitempty" bson:"avatarKey,omitempty"`
+	Avatar    *Poster `json:"avatar,omitempty" bson:"avatar,omitempty"`
 }
 
 type CreateUserRequest struct {
@@ -36,8 +44,20 @@ type UpdateMeRequest struct {
 }
 
 type UpdateUserRequest struct {
-	ID       *primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	Username *string             `json:"username,omitempty" bson:"username,omitempty"`
-	Email    string              `json:"email,omitempty" bson:"email,omitempty"`
-	Roles    []Role              `json:"roles" bson:"roles"`
+	ID       ID      `json:"id,omitzero" bson:"_id,omitempty"`
+	Username *string `json:"username,omitempty" bson:"username,omitempty"`
+	Email    string  `json:"email,omitempty" bson:"email,omitempty"`
+	Roles    []Role  `json:"roles" bson:"roles"`
+}
+
+func (u *User) ListID() ID { return u.ID }
+
+func (u *User) ListValue(field string) any {
+	if v, ok := u.Audit.listValue(field); ok {
+		return v
+	}
+	if field == "username" {
+		return u.Username
+	}
+	return nil
 }
@@ -0,0 +1,36 @@
+package models
+
+// WatchlistEntry puts a movie on a user's watchlist.
+type WatchlistEntry struct {
+	ID      ID `json:"id,omitzero" bson:"_id,omitempty"`
+	UserID  ID `json:"userId" bson:"userId"`
+	MovieID ID `json:"movieId" bson:"movieId"`
+	Audit   `bson:",inline"`
+
+	// Movie is filled in for a user's watchlist and is never stored.
+	Movie *Movie `json:"movie,omitempty" bson:"-"`
+}
+
+type AddToWatchlistRequest struct {
+	MovieID *ID `json:"movieId" binding:"required"`
+}
+
+var WatchlistListFields = withAudit(ListFields{
+	"userId":  {Type: FieldID},
+	"movieId": {Type: FieldID},
+})
+
+func (e *WatchlistEntry) ListID() ID { return e.ID }
+
+func (e *WatchlistEntry) ListValue(field string) any {
+	if v, ok := e.Audit.listValue(field); ok {
+		return v
+	}
+	switch field {
+	case "userId":
+		return e.UserID
+	case "movieId":
+		return e.MovieID
+	}
+	return nil
+}
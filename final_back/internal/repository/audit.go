@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// timestamp returns the current time at the millisecond precision every
+// backend can store.
+func timestamp() primitive.DateTime {
+	return primitive.NewDateTimeFromTime(time.Now())
+}
+
+// newAudit returns the audit fields of a record created by actorID now.
+func newAudit(actorID models.ID) models.Audit {
+	created, updated := timestamp(), timestamp()
+	updated = created
+	audit := models.Audit{CreatedAt: &created, UpdatedAt: &updated}
+	if !actorID.IsZero() {
+		createdBy, updatedBy := actorID, actorID
+		audit.CreatedBy = &createdBy
+		audit.UpdatedBy = &updatedBy
+	}
+	return audit
+}
+
+// touch stamps audit with a change made by actorID now. A zero actorID
+// stands for the system.
+func touch(audit models.Audit, actorID models.ID) models.Audit {
+	updated := timestamp()
+	audit.UpdatedAt = &updated
+	audit.UpdatedBy = nil
+	if !actorID.IsZero() {
+		updatedBy := actorID
+		audit.UpdatedBy = &updatedBy
+	}
+	return audit
+}
+
+func cloneAudit(audit models.Audit) models.Audit {
+	clone := audit
+	if audit.CreatedAt != nil {
+		createdAt := *audit.CreatedAt
+		clone.CreatedAt = &createdAt
+	}
+	if audit.UpdatedAt != nil {
+		updatedAt := *audit.UpdatedAt
+		clone.UpdatedAt = &updatedAt
+	}
+	if audit.CreatedBy != nil {
+		createdBy := *audit.CreatedBy
+		clone.CreatedBy = &createdBy
+	}
+	if audit.UpdatedBy != nil {
+		updatedBy := *audit.UpdatedBy
+		clone.UpdatedBy = &updatedBy
+	}
+	return clone
+}
+
+// mongoTouch adds the stamps of a change made by actorID to a Mongo update.
+func mongoTouch(update bson.M, actorID models.ID) bson.M {
+	set, ok := update["$set"].(bson.M)
+	if !ok {
+		set = bson.M{}
+		update["$set"] = set
+	}
+	set["updatedAt"] = timestamp()
+
+	if !actorID.IsZero() {
+		set["updatedBy"] = actorID
+		return update
+	}
+	unset, ok := update["$unset"].(bson.M)
+	if !ok {
+		unset = bson.M{}
+		update["$unset"] = unset
+	}
+	unset["updatedBy"] = ""
+	return update
+}
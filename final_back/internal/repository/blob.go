@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"mime"
+	"path"
+	"regexp"
+)
+
+// BlobStore keeps immutable blobs, such as uploaded images, under keys
+// derived from their content. A blob never changes once stored, so storing
+// the same content twice is a no-op and blobs can be cached forever.
+type BlobStore interface {
+	// PutBlob stores data under key, which must come from BlobKey.
+	PutBlob(key string, data []byte) error
+	// OpenBlob returns errs.NotFound for keys that were never stored.
+	OpenBlob(key string) (*Blob, error)
+}
+
+// Blob is an open stored blob. The caller closes it.
+type Blob struct {
+	io.ReadCloser
+	Size        int64
+	ContentType string
+}
+
+// BlobKey returns the content address of data: its SHA-256 followed by ext,
+// which decides the content type the blob is served with.
+func BlobKey(data []byte, ext string) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]) + ext
+}
+
+var blobKeyPattern = regexp.MustCompile(`^[0-9a-f]{64}\.[a-z0-9]+$`)
+
+// validBlobKey guards the stores against keys that did not come from
+// BlobKey, which could otherwise escape a directory or bucket prefix.
+func validBlobKey(key string) bool {
+	return blobKeyPattern.MatchString(key)
+}
+
+func blobContentType(key string) string {
+	if contentType := mime.TypeByExtension(path.Ext(key)); contentType != "" {
+		return contentType
+	}
+	return "application/octet-stream"
+}
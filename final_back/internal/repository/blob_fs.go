@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+)
+
+type fsBlobStore struct {
+	dir string
+}
+
+// NewFSBlobStore returns a BlobStore that keeps blobs as files under dir,
+// fanned out into subdirectories by the first two characters of their key.
+func NewFSBlobStore(dir string) (BlobStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &fsBlobStore{dir: dir}, nil
+}
+
+func (s *fsBlobStore) path(key string) string {
+	return filepath.Join(s.dir, key[:2], key)
+}
+
+func (s *fsBlobStore) PutBlob(key string, data []byte) error {
+	if !validBlobKey(key) {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	target := s.path(key)
+	if _, err := os.Stat(target); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so that a crash never leaves a
+	// truncated blob under a valid key.
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+func (s *fsBlobStore) OpenBlob(key string) (*Blob, error) {
+	if !validBlobKey(key) {
+		return nil, errs.NotFound
+	}
+	f, err := os.Open(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errs.NotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Blob{ReadCloser: f, Size: info.Size(), ContentType: blobContentType(key)}, nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+)
+
+// ErrNoSuchKey is what an S3Client returns for objects that do not exist,
+// after the S3 error code of the same name.
+var ErrNoSuchKey = errors.New("NoSuchKey")
+
+// S3Client is the part of the S3 object API that s3BlobStore needs. Any
+// S3-compatible service can stand behind it through a thin adapter, and
+// NewMemoryS3Client stands in for one locally.
+type S3Client interface {
+	HeadObject(bucket, key string) error
+	PutObject(bucket, key string, body io.Reader, size int64, contentType string) error
+	GetObject(bucket, key string) (io.ReadCloser, int64, error)
+}
+
+type s3BlobStore struct {
+	client S3Client
+	bucket string
+}
+
+// NewS3BlobStore returns a BlobStore that keeps blobs as objects in bucket.
+func NewS3BlobStore(client S3Client, bucket string) BlobStore {
+	return &s3BlobStore{client: client, bucket: bucket}
+}
+
+func (s *s3BlobStore) PutBlob(key string, data []byte) error {
+	if !validBlobKey(key) {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	err := s.client.HeadObject(s.bucket, key)
+	if err == nil {
+		return nil
+	}
+	if err != ErrNoSuchKey {
+		return err
+	}
+	return s.client.PutObject(s.bucket, key, bytes.NewReader(data), int64(len(data)), blobContentType(key))
+}
+
+func (s *s3BlobStore) OpenBlob(key string) (*Blob, error) {
+	if !validBlobKey(key) {
+		return nil, errs.NotFound
+	}
+	body, size, err := s.client.GetObject(s.bucket, key)
+	if err == ErrNoSuchKey {
+		return nil, errs.NotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Blob{ReadCloser: body, Size: size, ContentType: blobContentType(key)}, nil
+}
+
+type memoryS3Client struct {
+	mu      sync.RWMutex
+	objects map[string][]byte
+}
+
+// NewMemoryS3Client returns an S3Client that keeps objects in memory, for
+// development and tests.
+func NewMemoryS3Client() S3Client {
+	return &memoryS3Client{objects: map[string][]byte{}}
+}
+
+func (c *memoryS3Client) HeadObject(bucket, key string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if _, ok := c.objects[bucket+"/"+key]; !ok {
+		return ErrNoSuchKey
+	}
+	return nil
+}
+
+func (c *memoryS3Client) PutObject(bucket, key string, body io.Reader, size int64, contentType string) error {
+	data, err := io.ReadAll(io.LimitReader(body, size))
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.objects[bucket+"/"+key] = data
+	return nil
+}
+
+func (c *memoryS3Client) GetObject(bucket, key string) (io.ReadCloser, int64, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	data, ok := c.objects[bucket+"/"+key]
+	if !ok {
+		return nil, 0, ErrNoSuchKey
+	}
+	return io.NopCloser(bytes.NewReader(data)), int64(len(data)), nil
+}
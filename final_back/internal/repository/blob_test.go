@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFSBlobStore(t *testing.T) {
+	testBlobStore(t, func(t *testing.T) BlobStore {
+		store, err := NewFSBlobStore(t.TempDir())
+		require.NoError(t, err)
+		return store
+	})
+}
+
+func TestS3BlobStore(t *testing.T) {
+	testBlobStore(t, func(t *testing.T) BlobStore {
+		return NewS3BlobStore(NewMemoryS3Client(), "media")
+	})
+}
@@ -0,0 +1,2529 @@
+package repository
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The functions in this file describe the behaviour every repository
+// implementation must have. Each backend's test file runs them against a
+// fresh, empty store per subtest.
+
+// actor is recorded as the author of the writes the tests make.
+var actor = models.NewID()
+
+func testUserRepo(t *testing.T, newRepo func(t *testing.T) UserRepo) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, err := repo.CreateUser(actor, &models.User{
+			Username:     "neo",
+			PasswordHash: []byte("hash"),
+			Email:        "neo@example.com",
+			Roles:        []models.Role{"user"},
+		})
+		require.NoError(t, err)
+		require.False(t, id.IsZero())
+
+		byID, err := repo.GetUserByID(id)
+		require.NoError(t, err)
+		assert.Equal(t, id, byID.ID)
+		assert.Equal(t, "neo", byID.Username)
+		assert.Equal(t, []byte("hash"), byID.PasswordHash)
+		assert.Equal(t, "neo@example.com", byID.Email)
+		assert.Equal(t, []models.Role{"user"}, byID.Roles)
+
+		byName, err := repo.GetUserByUsername("neo")
+		require.NoError(t, err)
+		assert.Equal(t, byID, byName)
+	})
+
+	t.Run("DuplicateUsername", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.CreateUser(actor, &models.User{Username: "trinity"})
+		require.NoError(t, err)
+		_, err = repo.CreateUser(actor, &models.User{Username: "trinity"})
+		assert.ErrorIs(t, err, errs.AlreadyExists)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+		missing := models.NewID()
+
+		_, err := repo.GetUserByID(missing)
+		assert.ErrorIs(t, err, errs.NotFound)
+		_, err = repo.GetUserByUsername("nobody")
+		assert.ErrorIs(t, err, errs.NotFound)
+		_, err = repo.UpdateUser(actor, missing, 1, &models.User{Username: "nobody"})
+		assert.ErrorIs(t, err, errs.NotFound)
+		assert.ErrorIs(t, repo.DeleteUser(actor, missing), errs.NotFound)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, err := repo.CreateUser(actor, &models.User{
+			Username:     "morpheus",
+			PasswordHash: []byte("hash"),
+			Email:        "morpheus@example.com",
+			Roles:        []models.Role{"user"},
+		})
+		require.NoError(t, err)
+
+		updated, err := repo.UpdateUser(actor, id, 1, &models.User{
+			Email: "captain@example.com",
+			Roles: []models.Role{"user", "moderator"},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "morpheus", updated.Username)
+		assert.Equal(t, []byte("hash"), updated.PasswordHash)
+		assert.Equal(t, "captain@example.com", updated.Email)
+		assert.Equal(t, []models.Role{"user", "moderator"}, updated.Roles)
+
+		stored, err := repo.GetUserByID(id)
+		require.NoError(t, err)
+		assert.Equal(t, updated, stored)
+	})
+
+	t.Run("Avatar", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, err := repo.CreateUser(actor, &models.User{Username: "trinity", Roles: []models.Role{"user"}})
+		require.NoError(t, err)
+		created, err := repo.GetUserByID(id)
+		require.NoError(t, err)
+		assert.Nil(t, created.Avatar)
+		assert.Empty(t, created.AvatarKey)
+
+		avatar := &models.Poster{
+			Key: "abc.png", URL: "/media/abc.png", ContentType: "image/png", Size: 1234, Width: 400, Height: 400,
+			Variants: []models.ImageVariant{
+				{Name: models.VariantThumbnail, Key: "def.jpg", URL: "/media/def.jpg", ContentType: "image/jpeg", Size: 99, Width: 160, Height: 160},
+				{Name: models.VariantLarge, Key: "abc.png", URL: "/media/abc.png", ContentType: "image/png", Size: 1234, Width: 400, Height: 400},
+			},
+			BlurHash:      "LEHV6nWB2yk8pyo0adR*.7kCMdnj",
+			DominantColor: "#c81e1e",
+		}
+		updated, err := repo.UpdateUser(actor, id, 1, &models.User{Roles: created.Roles, Avatar: avatar})
+		require.NoError(t, err)
+		assert.Equal(t, avatar, updated.Avatar)
+		assert.Equal(t, "abc.png", updated.AvatarKey)
+
+		_, err = repo.UpdateUser(actor, id, 2, &models.User{Email: "trinity@example.com", Roles: created.Roles})
+		require.NoError(t, err)
+		stored, err := repo.GetUserByID(id)
+		require.NoError(t, err)
+		assert.Equal(t, avatar, stored.Avatar, "other updates keep the avatar")
+		assert.Equal(t, "abc.png", stored.AvatarKey)
+	})
+
+	t.Run("UpdateToTakenUsername", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.CreateUser(actor, &models.User{Username: "smith"})
+		require.NoError(t, err)
+		id, err := repo.CreateUser(actor, &models.User{Username: "jones"})
+		require.NoError(t, err)
+
+		_, err = repo.UpdateUser(actor, id, 1, &models.User{Username: "smith"})
+		assert.ErrorIs(t, err, errs.AlreadyExists)
+	})
+
+	t.Run("StaleVersion", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, err := repo.CreateUser(actor, &models.User{Username: "tank", Roles: []models.Role{"user"}})
+		require.NoError(t, err)
+		created, err := repo.GetUserByID(id)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), created.Version)
+
+		updated, err := repo.UpdateUser(actor, id, 1, &models.User{Email: "tank@example.com", Roles: created.Roles})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), updated.Version)
+
+		_, err = repo.UpdateUser(actor, id, 1, &models.User{Email: "dozer@example.com", Roles: created.Roles})
+		assert.ErrorIs(t, err, errs.Conflict)
+
+		stored, err := repo.GetUserByID(id)
+		require.NoError(t, err)
+		assert.Equal(t, updated, stored)
+	})
+
+	t.Run("DeleteAndList", func(t *testing.T) {
+		repo := newRepo(t)
+
+		users, err := items(repo.ListUsers(models.ListOptions{}))
+		require.NoError(t, err)
+		assert.NotNil(t, users)
+		assert.Empty(t, users)
+
+		first, err := repo.CreateUser(actor, &models.User{Username: "first"})
+		require.NoError(t, err)
+		second, err := repo.CreateUser(actor, &models.User{Username: "second"})
+		require.NoError(t, err)
+
+		users, err = items(repo.ListUsers(models.ListOptions{}))
+		require.NoError(t, err)
+		require.Len(t, users, 2)
+		assert.Equal(t, first, users[0].ID)
+		assert.Equal(t, second, users[1].ID)
+
+		require.NoError(t, repo.DeleteUser(actor, first))
+		_, err = repo.GetUserByID(first)
+		assert.ErrorIs(t, err, errs.NotFound)
+
+		users, err = items(repo.ListUsers(models.ListOptions{}))
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, second, users[0].ID)
+	})
+
+	t.Run("Audit", func(t *testing.T) {
+		repo := newRepo(t)
+		editor := models.NewID()
+
+		id, err := repo.CreateUser(actor, &models.User{Username: "oracle", Roles: []models.Role{"user"}})
+		require.NoError(t, err)
+		created, err := repo.GetUserByID(id)
+		require.NoError(t, err)
+		assertCreatedBy(t, created.Audit, actor)
+
+		time.Sleep(2 * time.Millisecond)
+		updated, err := repo.UpdateUser(editor, id, 1, &models.User{Email: "oracle@example.com", Roles: created.Roles})
+		require.NoError(t, err)
+		assertUpdatedBy(t, created.Audit, updated.Audit, editor)
+
+		stored, err := repo.GetUserByID(id)
+		require.NoError(t, err)
+		assert.Equal(t, updated.Audit, stored.Audit)
+
+		require.NoError(t, repo.DeleteUser(editor, id))
+		restored, err := repo.RestoreUser(actor, id)
+		require.NoError(t, err)
+		assertUpdatedBy(t, created.Audit, restored.Audit, actor)
+	})
+
+	t.Run("SortAndFilter", func(t *testing.T) {
+		repo := newRepo(t)
+		editor := models.NewID()
+
+		var ids []models.ID
+		for _, username := range []string{"first", "second", "third"} {
+			id, err := repo.CreateUser(actor, &models.User{Username: username})
+			require.NoError(t, err)
+			ids = append(ids, id)
+			time.Sleep(2 * time.Millisecond)
+		}
+		_, err := repo.UpdateUser(editor, ids[0], 1, &models.User{Email: "first@example.com"})
+		require.NoError(t, err)
+
+		users, err := items(repo.ListUsers(models.ListOptions{Sort: "-" + models.SortCreatedAt}))
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{ids[2], ids[1], ids[0]}, userIDs(users))
+
+		users, err = items(repo.ListUsers(models.ListOptions{Sort: models.SortUpdatedAt}))
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{ids[1], ids[2], ids[0]}, userIDs(users))
+
+		users, err = items(repo.ListUsers(models.ListOptions{Filters: []models.Filter{{Field: "updatedBy", Op: models.OpEq, Value: editor}}}))
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{ids[0]}, userIDs(users))
+		users, err = items(repo.ListUsers(models.ListOptions{Filters: []models.Filter{{Field: "createdBy", Op: models.OpEq, Value: editor}}}))
+		require.NoError(t, err)
+		assert.Empty(t, users)
+
+		second, err := repo.GetUserByID(ids[1])
+		require.NoError(t, err)
+		createdAt := second.CreatedAt.Time().UTC()
+		users, err = items(repo.ListUsers(models.ListOptions{Filters: []models.Filter{{Field: models.SortCreatedAt, Op: models.OpGte, Value: createdAt}}}))
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{ids[1], ids[2]}, userIDs(users))
+		users, err = items(repo.ListUsers(models.ListOptions{Filters: []models.Filter{{Field: models.SortCreatedAt, Op: models.OpLt, Value: createdAt}}}))
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{ids[0]}, userIDs(users))
+	})
+
+	t.Run("Trash", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, err := repo.CreateUser(actor, &models.User{Username: "mouse", Roles: []models.Role{"user"}})
+		require.NoError(t, err)
+		require.NoError(t, repo.DeleteUser(actor, id))
+		assert.ErrorIs(t, repo.DeleteUser(actor, id), errs.NotFound)
+
+		_, err = repo.GetUserByUsername("mouse")
+		assert.ErrorIs(t, err, errs.NotFound)
+		_, err = repo.UpdateUser(actor, id, 2, &models.User{Email: "mouse@example.com"})
+		assert.ErrorIs(t, err, errs.NotFound)
+		_, err = repo.CreateUser(actor, &models.User{Username: "mouse"})
+		assert.ErrorIs(t, err, errs.AlreadyExists, "a trashed user keeps their username")
+
+		users, err := items(repo.ListUsers(models.ListOptions{}))
+		require.NoError(t, err)
+		assert.Empty(t, users)
+		trash, err := repo.ListDeletedUsers()
+		require.NoError(t, err)
+		require.Len(t, trash, 1)
+		assert.Equal(t, id, trash[0].ID)
+		assert.NotNil(t, trash[0].Deleted)
+		assert.Equal(t, []models.Role{"user"}, trash[0].Roles)
+
+		restored, err := repo.RestoreUser(actor, id)
+		require.NoError(t, err)
+		assert.Nil(t, restored.Deleted)
+		assert.Equal(t, int64(3), restored.Version)
+		assert.Equal(t, []models.Role{"user"}, restored.Roles)
+		_, err = repo.RestoreUser(actor, id)
+		assert.ErrorIs(t, err, errs.NotFound)
+		assert.ErrorIs(t, repo.PurgeUser(id), errs.NotFound, "only trashed users can be purged")
+
+		require.NoError(t, repo.DeleteUser(actor, id))
+		require.NoError(t, repo.PurgeUser(id))
+		trash, err = repo.ListDeletedUsers()
+		require.NoError(t, err)
+		assert.Empty(t, trash)
+		_, err = repo.CreateUser(actor, &models.User{Username: "mouse"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("ReturnedValuesAreCopies", func(t *testing.T) {
+		repo := newRepo(t)
+
+		req := &models.User{Username: "oracle", Roles: []models.Role{"user"}}
+		id, err := repo.CreateUser(actor, req)
+		require.NoError(t, err)
+		req.Roles[0] = "admin"
+
+		user, err := repo.GetUserByID(id)
+		require.NoError(t, err)
+		user.Roles[0] = "admin"
+
+		stored, err := repo.GetUserByID(id)
+		require.NoError(t, err)
+		assert.Equal(t, []models.Role{"user"}, stored.Roles)
+	})
+
+	t.Run("ConcurrentCreate", func(t *testing.T) {
+		repo := newRepo(t)
+
+		const workers = 16
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := repo.CreateUser(actor, &models.User{Username: models.NewID().String()})
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+
+		users, err := items(repo.ListUsers(models.ListOptions{}))
+		require.NoError(t, err)
+		assert.Len(t, users, workers)
+	})
+}
+
+func testMovieRepo(t *testing.T, newRepo func(t *testing.T) MovieRepo) {
+	directorID := models.NewID()
+	genreID := models.NewID()
+	newRequest := func(title string) *models.CreateMovieRequest {
+		return &models.CreateMovieRequest{
+			Title:      title,
+			Year:       1999,
+			DirectorID: &directorID,
+			GenreID:    &genreID,
+			ImageURL:   "https://example.com/" + title + ".jpg",
+		}
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, err := repo.CreateMovie(actor, newRequest("matrix"))
+		require.NoError(t, err)
+		require.False(t, id.IsZero())
+
+		movie, err := repo.GetMovie(id)
+		require.NoError(t, err)
+		assert.Equal(t, &models.Movie{
+			ID:         id,
+			Version:    1,
+			Title:      "matrix",
+			Year:       1999,
+			DirectorID: &directorID,
+			GenreID:    &genreID,
+			ImageURL:   "https://example.com/matrix.jpg",
+			Ratings:    models.NewRatingStats(),
+			Audit:      movie.Audit, // checked by the Audit subtest
+		}, movie)
+	})
+
+	t.Run("Audit", func(t *testing.T) {
+		repo := newRepo(t)
+		editor := models.NewID()
+
+		id, err := repo.CreateMovie(actor, newRequest("matrix"))
+		require.NoError(t, err)
+		created, err := repo.GetMovie(id)
+		require.NoError(t, err)
+		assertCreatedBy(t, created.Audit, actor)
+
+		time.Sleep(2 * time.Millisecond)
+		title := "reloaded"
+		updated, err := repo.UpdateMovie(editor, id, 1, &models.UpdateMovieRequest{Title: &title})
+		require.NoError(t, err)
+		assertUpdatedBy(t, created.Audit, updated.Audit, editor)
+
+		stored, err := repo.GetMovie(id)
+		require.NoError(t, err)
+		assert.Equal(t, updated.Audit, stored.Audit)
+
+		movies, err := items(repo.ListMovies(models.ListOptions{Filters: []models.Filter{{Field: "updatedBy", Op: models.OpEq, Value: editor}}, Sort: "-" + models.SortUpdatedAt}))
+		require.NoError(t, err)
+		require.Len(t, movies, 1)
+		assert.Equal(t, id, movies[0].ID)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+		missing := models.NewID()
+		title := "missing"
+
+		_, err := repo.GetMovie(missing)
+		assert.ErrorIs(t, err, errs.NotFound)
+		_, err = repo.UpdateMovie(actor, missing, 1, &models.UpdateMovieRequest{Title: &title})
+		assert.ErrorIs(t, err, errs.NotFound)
+		assert.ErrorIs(t, repo.DeleteMovie(actor, missing), errs.NotFound)
+	})
+
+	t.Run("PartialUpdate", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, err := repo.CreateMovie(actor, newRequest("matrix"))
+		require.NoError(t, err)
+
+		title := "The Matrix"
+		otherGenre := models.NewID()
+		updated, err := repo.UpdateMovie(actor, id, 1, &models.UpdateMovieRequest{
+			Title:   &title,
+			GenreID: &otherGenre,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "The Matrix", updated.Title)
+		assert.Equal(t, 1999, updated.Year)
+		assert.Equal(t, &directorID, updated.DirectorID)
+		assert.Equal(t, &otherGenre, updated.GenreID)
+		assert.Equal(t, int64(2), updated.Version)
+
+		unchanged, err := repo.UpdateMovie(actor, id, 2, &models.UpdateMovieRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), unchanged.Version)
+		unchanged.Version = updated.Version
+		unchanged.UpdatedAt = updated.UpdatedAt // an empty update still counts as one
+		assert.Equal(t, updated, unchanged)
+
+		stored, err := repo.GetMovie(id)
+		require.NoError(t, err)
+		assert.Equal(t, "The Matrix", stored.Title)
+		assert.Equal(t, int64(3), stored.Version)
+	})
+
+	t.Run("Details", func(t *testing.T) {
+		repo := newRepo(t)
+
+		req := newRequest("matrix")
+		req.Runtime, req.OriginalLanguage, req.Synopsis = 136, "en", "A hacker learns the truth."
+		id, err := repo.CreateMovie(actor, req)
+		require.NoError(t, err)
+		_, err = repo.CreateMovie(actor, newRequest("primer"))
+		require.NoError(t, err)
+
+		movie, err := repo.GetMovie(id)
+		require.NoError(t, err)
+		assert.Equal(t, 136, movie.Runtime)
+		assert.Equal(t, "en", movie.OriginalLanguage)
+		assert.Equal(t, "A hacker learns the truth.", movie.Synopsis)
+
+		runtime, language := 138, "ja"
+		updated, err := repo.UpdateMovie(actor, id, 1, &models.UpdateMovieRequest{Runtime: &runtime, OriginalLanguage: &language})
+		require.NoError(t, err)
+		assert.Equal(t, 138, updated.Runtime)
+		assert.Equal(t, "ja", updated.OriginalLanguage)
+		assert.Equal(t, "A hacker learns the truth.", updated.Synopsis)
+
+		long := listAll(t, repo.ListMovies, models.ListOptions{Filters: []models.Filter{{Field: "runtime", Op: models.OpGte, Value: int64(120)}}, Limit: 10})
+		require.Len(t, long, 1)
+		assert.Equal(t, id, long[0].ID)
+		japanese := listAll(t, repo.ListMovies, models.ListOptions{Filters: []models.Filter{{Field: "originalLanguage", Op: models.OpEq, Value: "ja"}}, Limit: 10})
+		require.Len(t, japanese, 1)
+		assert.Equal(t, id, japanese[0].ID)
+
+		// Movies without a runtime page like any other value.
+		_, err = repo.CreateMovie(actor, newRequest("pi"))
+		require.NoError(t, err)
+		byRuntime := listAll(t, repo.ListMovies, models.ListOptions{Sort: "-runtime", Limit: 1})
+		require.Len(t, byRuntime, 3)
+		assert.Equal(t, id, byRuntime[0].ID)
+	})
+
+	t.Run("StaleVersion", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, err := repo.CreateMovie(actor, newRequest("matrix"))
+		require.NoError(t, err)
+
+		first, second := "Reloaded", "Revolutions"
+		updated, err := repo.UpdateMovie(actor, id, 1, &models.UpdateMovieRequest{Title: &first})
+		require.NoError(t, err)
+		_, err = repo.UpdateMovie(actor, id, 1, &models.UpdateMovieRequest{Title: &second})
+		assert.ErrorIs(t, err, errs.Conflict)
+
+		stored, err := repo.GetMovie(id)
+		require.NoError(t, err)
+		assert.Equal(t, updated, stored)
+	})
+
+	t.Run("DeleteAndList", func(t *testing.T) {
+		repo := newRepo(t)
+
+		movies, err := items(repo.ListMovies(models.ListOptions{}))
+		require.NoError(t, err)
+		assert.NotNil(t, movies)
+		assert.Empty(t, movies)
+
+		first, err := repo.CreateMovie(actor, newRequest("first"))
+		require.NoError(t, err)
+		second, err := repo.CreateMovie(actor, newRequest("second"))
+		require.NoError(t, err)
+
+		movies, err = items(repo.ListMovies(models.ListOptions{}))
+		require.NoError(t, err)
+		require.Len(t, movies, 2)
+		assert.Equal(t, first, movies[0].ID)
+		assert.Equal(t, second, movies[1].ID)
+
+		require.NoError(t, repo.DeleteMovie(actor, first))
+		_, err = repo.GetMovie(first)
+		assert.ErrorIs(t, err, errs.NotFound)
+
+		movies, err = items(repo.ListMovies(models.ListOptions{}))
+		require.NoError(t, err)
+		require.Len(t, movies, 1)
+		assert.Equal(t, second, movies[0].ID)
+	})
+
+	t.Run("Pagination", func(t *testing.T) {
+		repo := newRepo(t)
+
+		var ids []models.ID
+		for _, movie := range []struct {
+			title string
+			year  int
+		}{{"d", 2003}, {"b", 1999}, {"e", 2010}, {"a", 1999}, {"c", 2003}} {
+			req := newRequest(movie.title)
+			req.Year = movie.year
+			id, err := repo.CreateMovie(actor, req)
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+
+		movies := listAll(t, repo.ListMovies, models.ListOptions{Limit: 2})
+		assert.Equal(t, ids, movieIDs(movies))
+
+		// Movies of the same year are ordered by ID.
+		byYear := []models.ID{ids[1], ids[3], ids[0], ids[4], ids[2]}
+		if ids[3].Compare(ids[1]) < 0 {
+			byYear[0], byYear[1] = ids[3], ids[1]
+		}
+		if ids[4].Compare(ids[0]) < 0 {
+			byYear[2], byYear[3] = ids[4], ids[0]
+		}
+		movies = listAll(t, repo.ListMovies, models.ListOptions{Sort: "year", Limit: 2})
+		assert.Equal(t, byYear, movieIDs(movies))
+
+		movies = listAll(t, repo.ListMovies, models.ListOptions{
+			Sort:    "-title",
+			Filters: []models.Filter{{Field: "year", Op: models.OpGte, Value: int64(2003)}},
+			Limit:   1,
+		})
+		assert.Equal(t, []models.ID{ids[2], ids[0], ids[4]}, movieIDs(movies))
+
+		movies = listAll(t, repo.ListMovies, models.ListOptions{Sort: "title", IDs: []models.ID{ids[0], ids[3], models.NewID()}, Limit: 1})
+		assert.Equal(t, []models.ID{ids[3], ids[0]}, movieIDs(movies))
+		page, err := repo.ListMovies(models.ListOptions{IDs: []models.ID{}})
+		require.NoError(t, err)
+		assert.Empty(t, page.Items, "an empty ID list matches nothing")
+
+		first, err := repo.ListMovies(models.ListOptions{Sort: "title", Limit: 2})
+		require.NoError(t, err)
+		require.NotEmpty(t, first.NextCursor)
+		_, err = repo.ListMovies(models.ListOptions{Sort: "year", Limit: 2, Cursor: first.NextCursor})
+		assert.ErrorIs(t, err, models.ErrInvalidCursor)
+	})
+
+	t.Run("Trash", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, err := repo.CreateMovie(actor, newRequest("matrix"))
+		require.NoError(t, err)
+		require.NoError(t, repo.DeleteMovie(actor, id))
+		assert.ErrorIs(t, repo.DeleteMovie(actor, id), errs.NotFound)
+
+		title := "The Matrix"
+		_, err = repo.UpdateMovie(actor, id, 2, &models.UpdateMovieRequest{Title: &title})
+		assert.ErrorIs(t, err, errs.NotFound)
+
+		trash, err := repo.ListDeletedMovies()
+		require.NoError(t, err)
+		require.Len(t, trash, 1)
+		assert.Equal(t, id, trash[0].ID)
+		assert.NotNil(t, trash[0].Deleted)
+
+		restored, err := repo.RestoreMovie(actor, id)
+		require.NoError(t, err)
+		assert.Nil(t, restored.Deleted)
+		assert.Equal(t, "matrix", restored.Title)
+		_, err = repo.RestoreMovie(actor, id)
+		assert.ErrorIs(t, err, errs.NotFound)
+		assert.ErrorIs(t, repo.PurgeMovie(id), errs.NotFound)
+
+		require.NoError(t, repo.DeleteMovie(actor, id))
+		require.NoError(t, repo.PurgeMovie(id))
+		trash, err = repo.ListDeletedMovies()
+		require.NoError(t, err)
+		assert.Empty(t, trash)
+		_, err = repo.RestoreMovie(actor, id)
+		assert.ErrorIs(t, err, errs.NotFound)
+	})
+
+	t.Run("ExternalID", func(t *testing.T) {
+		repo := newRepo(t)
+
+		req := newRequest("matrix")
+		req.ExternalID = "tt0133093"
+		id, err := repo.CreateMovie(actor, req)
+		require.NoError(t, err)
+		_, err = repo.CreateMovie(actor, newRequest("no external id"))
+		require.NoError(t, err)
+		_, err = repo.CreateMovie(actor, newRequest("no external id either"))
+		require.NoError(t, err)
+
+		movie, err := repo.GetMovieByExternalID("tt0133093")
+		require.NoError(t, err)
+		assert.Equal(t, id, movie.ID)
+		assert.Equal(t, "tt0133093", movie.ExternalID)
+		_, err = repo.GetMovieByExternalID("tt0234215")
+		assert.ErrorIs(t, err, errs.NotFound)
+
+		_, err = repo.CreateMovie(actor, req)
+		assert.ErrorIs(t, err, errs.AlreadyExists)
+
+		require.NoError(t, repo.DeleteMovie(actor, id))
+		_, err = repo.GetMovieByExternalID("tt0133093")
+		assert.ErrorIs(t, err, errs.NotFound)
+		_, err = repo.CreateMovie(actor, req)
+		assert.ErrorIs(t, err, errs.AlreadyExists, "trashed movies keep their external IDs")
+	})
+
+	t.Run("Poster", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, err := repo.CreateMovie(actor, newRequest("matrix"))
+		require.NoError(t, err)
+		created, err := repo.GetMovie(id)
+		require.NoError(t, err)
+		assert.Nil(t, created.Poster)
+
+		poster := &models.Poster{
+			Key: "abc.png", URL: "/media/abc.png", ContentType: "image/png", Size: 1234, Width: 600, Height: 900,
+			Variants: []models.ImageVariant{
+				{Name: models.VariantThumbnail, Key: "def.jpg", URL: "/media/def.jpg", ContentType: "image/jpeg", Size: 99, Width: 160, Height: 240},
+				{Name: models.VariantLarge, Key: "abc.png", URL: "/media/abc.png", ContentType: "image/png", Size: 1234, Width: 600, Height: 900},
+			},
+			BlurHash:      "LEHV6nWB2yk8pyo0adR*.7kCMdnj",
+			DominantColor: "#c81e1e",
+		}
+		updated, err := repo.UpdateMovie(actor, id, 1, &models.UpdateMovieRequest{ImageURL: &poster.URL, Poster: poster})
+		require.NoError(t, err)
+		assert.Equal(t, poster, updated.Poster)
+		assert.Equal(t, "/media/abc.png", updated.ImageURL)
+
+		title := "The Matrix"
+		_, err = repo.UpdateMovie(actor, id, 2, &models.UpdateMovieRequest{Title: &title})
+		require.NoError(t, err)
+		stored, err := repo.GetMovie(id)
+		require.NoError(t, err)
+		assert.Equal(t, poster, stored.Poster, "other updates keep the poster")
+	})
+
+	t.Run("Ratings", func(t *testing.T) {
+		repo := newRepo(t)
+
+		rated, err := repo.CreateMovie(actor, newRequest("rated"))
+		require.NoError(t, err)
+		unrated, err := repo.CreateMovie(actor, newRequest("unrated"))
+		require.NoError(t, err)
+
+		require.NoError(t, repo.UpdateRatings(rated, 0, 9))
+		require.NoError(t, repo.UpdateRatings(rated, 0, 10))
+		require.NoError(t, repo.UpdateRatings(rated, 0, 4))
+		require.NoError(t, repo.UpdateRatings(rated, 4, 8))
+		require.NoError(t, repo.UpdateRatings(rated, 0, 0), "no rating either way is a no-op")
+		require.NoError(t, repo.UpdateRatings(models.NewID(), 0, 5), "an unknown movie is not an error")
+
+		movie, err := repo.GetMovie(rated)
+		require.NoError(t, err)
+		assert.Equal(t, 3, movie.Ratings.Count)
+		assert.InDelta(t, 9.0, movie.Ratings.Mean, 1e-9)
+		assert.InDelta(t, (models.RatingPriorMean*models.RatingPriorCount+27)/(models.RatingPriorCount+3), movie.Ratings.Score, 1e-9)
+		assert.Equal(t, [models.MaxRating]int{7: 1, 8: 1, 9: 1}, movie.Ratings.Histogram)
+		assert.Equal(t, int64(1), movie.Version, "ratings are not an edit of the movie")
+
+		require.NoError(t, repo.UpdateRatings(rated, 9, 0))
+		require.NoError(t, repo.UpdateRatings(rated, 10, 0))
+		require.NoError(t, repo.UpdateRatings(rated, 8, 0))
+		movie, err = repo.GetMovie(rated)
+		require.NoError(t, err)
+		assert.Equal(t, models.NewRatingStats(), movie.Ratings)
+
+		require.NoError(t, repo.UpdateRatings(rated, 0, 3))
+		for _, sort := range []string{"rating", "ratingMean", "ratingCount"} {
+			movies, err := items(repo.ListMovies(models.ListOptions{Sort: "-" + sort}))
+			require.NoError(t, err)
+			if sort == "rating" {
+				assert.Equal(t, []models.ID{unrated, rated}, movieIDs(movies), "a low rating scores below the prior")
+			} else {
+				assert.Equal(t, []models.ID{rated, unrated}, movieIDs(movies), sort)
+			}
+		}
+	})
+}
+
+func testReviewRepo(t *testing.T, newRepo func(t *testing.T) ReviewRepo) {
+	movieID := models.NewID()
+	otherMovieID := models.NewID()
+	alice := models.NewID()
+	bob := models.NewID()
+	content := "great"
+
+	newReview := func(owner, movie models.ID, private bool) *models.Review {
+		return &models.Review{
+			MovieID:          movie,
+			OwnerID:          owner,
+			ReviewCategoryID: models.NewID(),
+			Rating:           7,
+			Content:          &content,
+			IsPrivate:        private,
+		}
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+
+		review := newReview(alice, movieID, false)
+		id, err := repo.CreateReview(actor, review)
+		require.NoError(t, err)
+		require.False(t, id.IsZero())
+
+		stored, err := repo.GetReviewByID(id)
+		require.NoError(t, err)
+		review.ID = id
+		review.Version = 1
+		review.Audit = stored.Audit // checked by the Audit subtest
+		assert.Equal(t, review, stored)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		repo := newRepo(t)
+		missing := models.NewID()
+
+		_, err := repo.GetReviewByID(missing)
+		assert.ErrorIs(t, err, errs.NotFound)
+		_, err = repo.UpdateReview(actor, missing, 1, newReview(alice, movieID, false))
+		assert.ErrorIs(t, err, errs.NotFound)
+		assert.ErrorIs(t, repo.DeleteReview(actor, missing, 1), errs.NotFound)
+	})
+
+	t.Run("UpdateReplaces", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, err := repo.CreateReview(actor, newReview(alice, movieID, false))
+		require.NoError(t, err)
+
+		replacement := newReview(alice, movieID, true)
+		replacement.Rating = 3
+		replacement.Content = nil
+		updated, err := repo.UpdateReview(actor, id, 1, replacement)
+		require.NoError(t, err)
+		assert.Equal(t, id, updated.ID)
+		assert.Equal(t, int64(2), updated.Version)
+		assert.Equal(t, 3, updated.Rating)
+		assert.Nil(t, updated.Content)
+		assert.True(t, updated.IsPrivate)
+
+		stored, err := repo.GetReviewByID(id)
+		require.NoError(t, err)
+		assert.Equal(t, updated, stored)
+
+		_, err = repo.UpdateReview(actor, id, 1, newReview(alice, movieID, false))
+		assert.ErrorIs(t, err, errs.Conflict)
+	})
+
+	t.Run("Audit", func(t *testing.T) {
+		repo := newRepo(t)
+		editor := models.NewID()
+
+		id, err := repo.CreateReview(alice, newReview(alice, movieID, false))
+		require.NoError(t, err)
+		created, err := repo.GetReviewByID(id)
+		require.NoError(t, err)
+		assertCreatedBy(t, created.Audit, alice)
+
+		// The stamps of a replacement are ignored.
+		time.Sleep(2 * time.Millisecond)
+		replacement := newReview(alice, movieID, false)
+		replacement.Audit = models.Audit{CreatedBy: &editor}
+		updated, err := repo.UpdateReview(editor, id, 1, replacement)
+		require.NoError(t, err)
+		assertUpdatedBy(t, created.Audit, updated.Audit, editor)
+
+		stored, err := repo.GetReviewByID(id)
+		require.NoError(t, err)
+		assert.Equal(t, updated.Audit, stored.Audit)
+
+		other, err := repo.CreateReview(alice, newReview(alice, movieID, false))
+		require.NoError(t, err)
+		mine, err := items(repo.ListMyReviews(alice, models.ListOptions{Sort: "-" + models.SortCreatedAt}))
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{other, id}, reviewIDs(mine))
+		mine, err = items(repo.ListMyReviews(alice, models.ListOptions{Filters: []models.Filter{{Field: "updatedBy", Op: models.OpEq, Value: editor}}}))
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{id}, reviewIDs(mine))
+	})
+
+	t.Run("Listing", func(t *testing.T) {
+		repo := newRepo(t)
+
+		alicePublic, err := repo.CreateReview(actor, newReview(alice, movieID, false))
+		require.NoError(t, err)
+		alicePrivate, err := repo.CreateReview(actor, newReview(alice, movieID, true))
+		require.NoError(t, err)
+		bobPublic, err := repo.CreateReview(actor, newReview(bob, movieID, false))
+		require.NoError(t, err)
+		bobPrivate, err := repo.CreateReview(actor, newReview(bob, movieID, true))
+		require.NoError(t, err)
+		aliceOther, err := repo.CreateReview(actor, newReview(alice, otherMovieID, false))
+		require.NoError(t, err)
+
+		visible, err := items(repo.ListReviewsByMovieID(alice, movieID, models.ListOptions{}))
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{alicePublic, alicePrivate, bobPublic}, reviewIDs(visible))
+
+		visible, err = items(repo.ListReviewsByMovieID(bob, movieID, models.ListOptions{}))
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{alicePublic, bobPublic, bobPrivate}, reviewIDs(visible))
+
+		own, err := repo.ListOwnReviewsByMovieID(alice, movieID)
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{alicePublic, alicePrivate}, reviewIDs(own))
+
+		mine, err := items(repo.ListMyReviews(alice, models.ListOptions{}))
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{alicePublic, alicePrivate, aliceOther}, reviewIDs(mine))
+
+		nobody := models.NewID()
+		mine, err = items(repo.ListMyReviews(nobody, models.ListOptions{}))
+		require.NoError(t, err)
+		assert.NotNil(t, mine)
+		assert.Empty(t, mine)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, err := repo.CreateReview(actor, newReview(alice, movieID, false))
+		require.NoError(t, err)
+		_, err = repo.UpdateReview(actor, id, 1, newReview(alice, movieID, false))
+		require.NoError(t, err)
+		assert.ErrorIs(t, repo.DeleteReview(actor, id, 1), errs.Conflict, "stale version")
+		require.NoError(t, repo.DeleteReview(actor, id, 2))
+
+		_, err = repo.GetReviewByID(id)
+		assert.ErrorIs(t, err, errs.NotFound)
+		mine, err := items(repo.ListMyReviews(alice, models.ListOptions{}))
+		require.NoError(t, err)
+		assert.Empty(t, mine)
+	})
+
+	t.Run("Trash", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, err := repo.CreateReview(actor, newReview(alice, movieID, false))
+		require.NoError(t, err)
+		require.NoError(t, repo.DeleteReview(actor, id, 1))
+		assert.ErrorIs(t, repo.DeleteReview(actor, id, 2), errs.NotFound)
+
+		_, err = repo.UpdateReview(actor, id, 2, newReview(alice, movieID, false))
+		assert.ErrorIs(t, err, errs.NotFound)
+		visible, err := items(repo.ListReviewsByMovieID(alice, movieID, models.ListOptions{}))
+		require.NoError(t, err)
+		assert.Empty(t, visible)
+		own, err := repo.ListOwnReviewsByMovieID(alice, movieID)
+		require.NoError(t, err)
+		assert.Empty(t, own)
+
+		trash, err := repo.ListDeletedReviews()
+		require.NoError(t, err)
+		require.Len(t, trash, 1)
+		assert.Equal(t, id, trash[0].ID)
+		assert.NotNil(t, trash[0].Deleted)
+
+		restored, err := repo.RestoreReview(actor, id)
+		require.NoError(t, err)
+		assert.Nil(t, restored.Deleted)
+		mine, err := items(repo.ListMyReviews(alice, models.ListOptions{}))
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{id}, reviewIDs(mine))
+		assert.ErrorIs(t, repo.PurgeReview(id), errs.NotFound)
+
+		require.NoError(t, repo.DeleteReview(actor, id, restored.Version))
+		require.NoError(t, repo.PurgeReview(id))
+		_, err = repo.RestoreReview(actor, id)
+		assert.ErrorIs(t, err, errs.NotFound)
+	})
+
+	t.Run("DeleteByMovie", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.CreateReview(actor, newReview(alice, movieID, false))
+		require.NoError(t, err)
+		_, err = repo.CreateReview(actor, newReview(bob, movieID, true))
+		require.NoError(t, err)
+		other, err := repo.CreateReview(actor, newReview(alice, otherMovieID, false))
+		require.NoError(t, err)
+
+		require.NoError(t, repo.DeleteReviewsByMovieID(movieID))
+
+		visible, err := items(repo.ListReviewsByMovieID(bob, movieID, models.ListOptions{}))
+		require.NoError(t, err)
+		assert.Empty(t, visible)
+		mine, err := items(repo.ListMyReviews(alice, models.ListOptions{}))
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{other}, reviewIDs(mine))
+	})
+
+	t.Run("Move", func(t *testing.T) {
+		repo := newRepo(t)
+		editor := models.NewID()
+
+		public, err := repo.CreateReview(actor, newReview(alice, movieID, false))
+		require.NoError(t, err)
+		trashed, err := repo.CreateReview(actor, newReview(bob, movieID, false))
+		require.NoError(t, err)
+		require.NoError(t, repo.DeleteReview(actor, trashed, 1))
+		other, err := repo.CreateReview(actor, newReview(bob, otherMovieID, false))
+		require.NoError(t, err)
+
+		moved, err := repo.MoveReviews(editor, movieID, otherMovieID)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []models.ID{public, trashed}, reviewIDs(moved))
+		for _, review := range moved {
+			assert.Equal(t, otherMovieID, review.MovieID)
+			assert.Equal(t, &editor, review.UpdatedBy)
+		}
+
+		visible, err := items(repo.ListReviewsByMovieID(alice, otherMovieID, models.ListOptions{}))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []models.ID{public, other}, reviewIDs(visible))
+		stored, err := repo.GetReviewByID(public)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), stored.Version)
+		assert.Equal(t, &editor, stored.UpdatedBy)
+		visible, err = items(repo.ListReviewsByMovieID(alice, movieID, models.ListOptions{}))
+		require.NoError(t, err)
+		assert.Empty(t, visible)
+		trash, err := repo.ListDeletedReviews()
+		require.NoError(t, err)
+		require.Len(t, trash, 1)
+		assert.Equal(t, otherMovieID, trash[0].MovieID)
+	})
+
+	t.Run("Anonymize", func(t *testing.T) {
+		repo := newRepo(t)
+
+		public, err := repo.CreateReview(actor, newReview(alice, movieID, false))
+		require.NoError(t, err)
+		private, err := repo.CreateReview(actor, newReview(alice, movieID, true))
+		require.NoError(t, err)
+		bobs, err := repo.CreateReview(actor, newReview(bob, movieID, false))
+		require.NoError(t, err)
+
+		shown, err := repo.AnonymizeReviews(alice)
+		require.NoError(t, err)
+		assert.Empty(t, shown, "none of the reviews were hidden with their owner")
+
+		mine, err := items(repo.ListMyReviews(alice, models.ListOptions{}))
+		require.NoError(t, err)
+		assert.Empty(t, mine)
+		_, err = repo.GetReviewByID(private)
+		assert.ErrorIs(t, err, errs.NotFound)
+
+		anonymized, err := repo.GetReviewByID(public)
+		require.NoError(t, err)
+		assert.True(t, anonymized.OwnerID.IsZero())
+		assert.Equal(t, int64(2), anonymized.Version)
+		assert.NotNil(t, anonymized.CreatedAt)
+		assert.Nil(t, anonymized.CreatedBy)
+		assert.Nil(t, anonymized.UpdatedBy)
+
+		visible, err := items(repo.ListReviewsByMovieID(bob, movieID, models.ListOptions{}))
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{public, bobs}, reviewIDs(visible))
+
+		_, err = repo.TrashReviewsWithOwner(bob, true)
+		require.NoError(t, err)
+		shown, err = repo.AnonymizeReviews(bob)
+		require.NoError(t, err)
+		require.Equal(t, []models.ID{bobs}, reviewIDs(shown))
+		assert.True(t, shown[0].OwnerID.IsZero())
+		assert.False(t, shown[0].OwnerTrashed)
+		stored, err := repo.GetReviewByID(bobs)
+		require.NoError(t, err, "anonymized reviews are shown again")
+		assert.Equal(t, shown[0].Version, stored.Version)
+	})
+
+	t.Run("TrashWithMovieAndOwner", func(t *testing.T) {
+		repo := newRepo(t)
+
+		alices, err := repo.CreateReview(actor, newReview(alice, movieID, false))
+		require.NoError(t, err)
+		bobs, err := repo.CreateReview(actor, newReview(bob, movieID, false))
+		require.NoError(t, err)
+		trashed, err := repo.CreateReview(actor, newReview(bob, movieID, false))
+		require.NoError(t, err)
+		require.NoError(t, repo.DeleteReview(actor, trashed, 1))
+		elsewhere, err := repo.CreateReview(actor, newReview(alice, otherMovieID, false))
+		require.NoError(t, err)
+
+		require.NoError(t, repo.TrashReviewsWithMovie(movieID, true))
+		visible, err := items(repo.ListReviewsByMovieID(alice, movieID, models.ListOptions{}))
+		require.NoError(t, err)
+		assert.Empty(t, visible)
+		_, err = repo.GetReviewByID(bobs)
+		assert.ErrorIs(t, err, errs.NotFound)
+		mine, err := items(repo.ListMyReviews(alice, models.ListOptions{}))
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{elsewhere}, reviewIDs(mine))
+		trash, err := repo.ListDeletedReviews()
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{trashed}, reviewIDs(trash), "hidden reviews are not in the review trash")
+
+		require.NoError(t, repo.TrashReviewsWithMovie(movieID, false))
+		visible, err = items(repo.ListReviewsByMovieID(alice, movieID, models.ListOptions{}))
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{alices, bobs}, reviewIDs(visible))
+		_, err = repo.GetReviewByID(trashed)
+		assert.ErrorIs(t, err, errs.NotFound, "a review trashed on its own stays in the trash")
+
+		changed, err := repo.TrashReviewsWithOwner(alice, true)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []models.ID{alices, elsewhere}, reviewIDs(changed))
+		for _, review := range changed {
+			assert.True(t, review.OwnerTrashed)
+		}
+		changed, err = repo.TrashReviewsWithOwner(alice, true)
+		require.NoError(t, err)
+		assert.Empty(t, changed, "reviews already hidden are left alone")
+		visible, err = items(repo.ListReviewsByMovieID(bob, movieID, models.ListOptions{}))
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{bobs}, reviewIDs(visible))
+		counts, err := repo.CountReviewsByCategory()
+		require.NoError(t, err)
+		assert.Len(t, counts, 1)
+
+		changed, err = repo.TrashReviewsWithOwner(alice, false)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []models.ID{alices, elsewhere}, reviewIDs(changed))
+		for _, review := range changed {
+			assert.False(t, review.OwnerTrashed)
+		}
+		mine, err = items(repo.ListMyReviews(alice, models.ListOptions{}))
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []models.ID{alices, elsewhere}, reviewIDs(mine))
+	})
+
+	t.Run("DefaultCategories", func(t *testing.T) {
+		repo := newRepo(t)
+
+		categories, err := repo.ListReviewCategories(false)
+		require.NoError(t, err)
+		require.Len(t, categories, len(defaultReviewCategories))
+		for i, category := range categories {
+			assert.False(t, category.ID.IsZero())
+			assert.Equal(t, defaultReviewCategories[i], category.Name)
+			assert.Equal(t, i, category.Order)
+		}
+	})
+
+	t.Run("Categories", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, err := repo.CreateReviewCategory(actor, &models.CreateReviewCategoryRequest{Name: "Classic"})
+		require.NoError(t, err)
+		_, err = repo.CreateReviewCategory(actor, &models.CreateReviewCategoryRequest{Name: "Classic"})
+		assert.ErrorIs(t, err, errs.AlreadyExists)
+
+		category, err := repo.GetReviewCategory(id)
+		require.NoError(t, err)
+		assert.Equal(t, "Classic", category.Name)
+		assert.Equal(t, int64(1), category.Version)
+		assertCreatedBy(t, category.Audit, actor)
+
+		categories, err := repo.ListReviewCategories(false)
+		require.NoError(t, err)
+		require.Len(t, categories, len(defaultReviewCategories)+1)
+		assert.Equal(t, id, categories[1].ID, "ties in order go by creation")
+
+		name, order := "Cult classic", 10
+		updated, err := repo.UpdateReviewCategory(actor, id, 1, &models.UpdateReviewCategoryRequest{Name: &name, Order: &order})
+		require.NoError(t, err)
+		assert.Equal(t, name, updated.Name)
+		assert.Equal(t, order, updated.Order)
+		assert.Equal(t, int64(2), updated.Version)
+		_, err = repo.UpdateReviewCategory(actor, id, 1, &models.UpdateReviewCategoryRequest{Name: &name})
+		assert.ErrorIs(t, err, errs.Conflict)
+		taken := defaultReviewCategories[0]
+		_, err = repo.UpdateReviewCategory(actor, id, 2, &models.UpdateReviewCategoryRequest{Name: &taken})
+		assert.ErrorIs(t, err, errs.AlreadyExists)
+
+		categories, err = repo.ListReviewCategories(false)
+		require.NoError(t, err)
+		assert.Equal(t, id, categories[len(categories)-1].ID)
+
+		archived, err := repo.ArchiveReviewCategory(actor, id, true)
+		require.NoError(t, err)
+		assert.NotNil(t, archived.Archived)
+		assert.Equal(t, int64(3), archived.Version)
+		again, err := repo.ArchiveReviewCategory(actor, id, true)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), again.Version, "archiving twice is a no-op")
+
+		categories, err = repo.ListReviewCategories(false)
+		require.NoError(t, err)
+		assert.Len(t, categories, len(defaultReviewCategories))
+		categories, err = repo.ListReviewCategories(true)
+		require.NoError(t, err)
+		assert.Len(t, categories, len(defaultReviewCategories)+1)
+
+		restored, err := repo.ArchiveReviewCategory(actor, id, false)
+		require.NoError(t, err)
+		assert.Nil(t, restored.Archived)
+		assert.Equal(t, int64(4), restored.Version)
+
+		_, err = repo.GetReviewCategory(models.NewID())
+		assert.ErrorIs(t, err, errs.NotFound)
+		_, err = repo.ArchiveReviewCategory(actor, models.NewID(), true)
+		assert.ErrorIs(t, err, errs.NotFound)
+	})
+
+	t.Run("CountByCategory", func(t *testing.T) {
+		repo := newRepo(t)
+
+		first, second := models.NewID(), models.NewID()
+		for _, category := range []models.ID{first, first, second, models.NilID} {
+			review := newReview(alice, movieID, false)
+			review.ReviewCategoryID = category
+			_, err := repo.CreateReview(actor, review)
+			require.NoError(t, err)
+		}
+		review := newReview(bob, movieID, true)
+		review.ReviewCategoryID = second
+		deleted, err := repo.CreateReview(actor, review)
+		require.NoError(t, err)
+		require.NoError(t, repo.DeleteReview(actor, deleted, 1))
+
+		counts, err := repo.CountReviewsByCategory()
+		require.NoError(t, err)
+		assert.Equal(t, map[models.ID]int{first: 2, second: 1}, counts, "trashed and uncategorized reviews are not counted")
+	})
+}
+
+// testUnitOfWork checks that writes made through a UnitOfWork become visible
+// to the store's ordinary repositories on success and are discarded on error.
+func testUnitOfWork(t *testing.T, newStore func(t *testing.T) (Repos, UnitOfWork)) {
+	t.Run("Commit", func(t *testing.T) {
+		repos, uow := newStore(t)
+
+		var userID, movieID models.ID
+		err := uow.Do(func(tx Repos) error {
+			var err error
+			if userID, err = tx.Users.CreateUser(actor, &models.User{Username: "switch"}); err != nil {
+				return err
+			}
+			movieID, err = tx.Movies.CreateMovie(actor, &models.CreateMovieRequest{Title: "matrix"})
+			return err
+		})
+		require.NoError(t, err)
+
+		_, err = repos.Users.GetUserByID(userID)
+		assert.NoError(t, err)
+		_, err = repos.Movies.GetMovie(movieID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		repos, uow := newStore(t)
+
+		movieID, err := repos.Movies.CreateMovie(actor, &models.CreateMovieRequest{Title: "matrix"})
+		require.NoError(t, err)
+		reviewID, err := repos.Reviews.CreateReview(actor, &models.Review{MovieID: movieID, OwnerID: models.NewID(), Rating: 9})
+		require.NoError(t, err)
+
+		failure := errors.New("abort")
+		err = uow.Do(func(tx Repos) error {
+			if err := tx.Reviews.DeleteReviewsByMovieID(movieID); err != nil {
+				return err
+			}
+			if err := tx.Movies.DeleteMovie(actor, movieID); err != nil {
+				return err
+			}
+			if _, err := tx.Users.CreateUser(actor, &models.User{Username: "cypher"}); err != nil {
+				return err
+			}
+			return failure
+		})
+		assert.ErrorIs(t, err, failure)
+
+		_, err = repos.Movies.GetMovie(movieID)
+		assert.NoError(t, err)
+		_, err = repos.Reviews.GetReviewByID(reviewID)
+		assert.NoError(t, err)
+		_, err = repos.Users.GetUserByUsername("cypher")
+		assert.ErrorIs(t, err, errs.NotFound)
+	})
+}
+
+func testPersonRepo(t *testing.T, newRepo func(t *testing.T) PersonRepo) {
+	birthYear := 1942
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, err := repo.CreatePerson(actor, &models.CreatePersonRequest{Name: "Martin Scorsese", BirthYear: &birthYear, Bio: "Director"})
+		require.NoError(t, err)
+		require.False(t, id.IsZero())
+
+		person, err := repo.GetPerson(id)
+		require.NoError(t, err)
+		assertCreatedBy(t, person.Audit, actor)
+		assert.Equal(t, &models.Person{
+			ID:        id,
+			Name:      "Martin Scorsese",
+			BirthYear: &birthYear,
+			Bio:       "Director",
+			Version:   1,
+			Audit:     person.Audit,
+		}, person)
+
+		_, err = repo.GetPerson(models.NewID())
+		assert.ErrorIs(t, err, errs.NotFound)
+	})
+
+	t.Run("GetPeople", func(t *testing.T) {
+		repo := newRepo(t)
+
+		first, err := repo.CreatePerson(actor, &models.CreatePersonRequest{Name: "Lana Wachowski"})
+		require.NoError(t, err)
+		second, err := repo.CreatePerson(actor, &models.CreatePersonRequest{Name: "Lilly Wachowski"})
+		require.NoError(t, err)
+
+		people, err := repo.GetPeople([]models.ID{second, models.NewID(), first})
+		require.NoError(t, err)
+		ids := []models.ID{}
+		for _, person := range people {
+			ids = append(ids, person.ID)
+		}
+		assert.ElementsMatch(t, []models.ID{first, second}, ids)
+
+		people, err = repo.GetPeople(nil)
+		require.NoError(t, err)
+		assert.Empty(t, people)
+	})
+
+	t.Run("ExternalID", func(t *testing.T) {
+		repo := newRepo(t)
+
+		req := &models.CreatePersonRequest{ExternalID: "nm0905154", Name: "Lana Wachowski"}
+		id, err := repo.CreatePerson(actor, req)
+		require.NoError(t, err)
+		_, err = repo.CreatePerson(actor, &models.CreatePersonRequest{Name: "No External ID"})
+		require.NoError(t, err)
+		_, err = repo.CreatePerson(actor, &models.CreatePersonRequest{Name: "No External ID Either"})
+		require.NoError(t, err)
+
+		person, err := repo.GetPersonByExternalID("nm0905154")
+		require.NoError(t, err)
+		assert.Equal(t, id, person.ID)
+		assert.Equal(t, "nm0905154", person.ExternalID)
+		_, err = repo.GetPersonByExternalID("nm0905152")
+		assert.ErrorIs(t, err, errs.NotFound)
+
+		_, err = repo.CreatePerson(actor, req)
+		assert.ErrorIs(t, err, errs.AlreadyExists)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		editor := models.NewID()
+
+		id, err := repo.CreatePerson(actor, &models.CreatePersonRequest{Name: "Michael Mann"})
+		require.NoError(t, err)
+		created, err := repo.GetPerson(id)
+		require.NoError(t, err)
+
+		time.Sleep(2 * time.Millisecond)
+		bio := "Director of Heat"
+		updated, err := repo.UpdatePerson(editor, id, 1, &models.UpdatePersonRequest{Bio: &bio, BirthYear: &birthYear})
+		require.NoError(t, err)
+		assert.Equal(t, "Michael Mann", updated.Name)
+		assert.Equal(t, bio, updated.Bio)
+		assert.Equal(t, &birthYear, updated.BirthYear)
+		assert.Equal(t, int64(2), updated.Version)
+		assertUpdatedBy(t, created.Audit, updated.Audit, editor)
+
+		stored, err := repo.GetPerson(id)
+		require.NoError(t, err)
+		assert.Equal(t, updated, stored)
+
+		_, err = repo.UpdatePerson(editor, id, 1, &models.UpdatePersonRequest{Bio: &bio})
+		assert.ErrorIs(t, err, errs.Conflict)
+		_, err = repo.UpdatePerson(editor, models.NewID(), 1, &models.UpdatePersonRequest{Bio: &bio})
+		assert.ErrorIs(t, err, errs.NotFound)
+	})
+
+	t.Run("DeleteAndList", func(t *testing.T) {
+		repo := newRepo(t)
+
+		var ids []models.ID
+		for _, name := range []string{"Kubrick", "Bergman", "Fellini"} {
+			id, err := repo.CreatePerson(actor, &models.CreatePersonRequest{Name: name})
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+
+		people := listAll(t, repo.ListPeople, models.ListOptions{Sort: "name", Limit: 2})
+		names := []string{}
+		for _, person := range people {
+			names = append(names, person.Name)
+		}
+		assert.Equal(t, []string{"Bergman", "Fellini", "Kubrick"}, names)
+
+		require.NoError(t, repo.DeletePerson(ids[1]))
+		assert.ErrorIs(t, repo.DeletePerson(ids[1]), errs.NotFound)
+		_, err := repo.GetPerson(ids[1])
+		assert.ErrorIs(t, err, errs.NotFound)
+
+		page, err := repo.ListPeople(models.ListOptions{})
+		require.NoError(t, err)
+		assert.Len(t, page.Items, 2)
+	})
+}
+
+func testGenreRepo(t *testing.T, newRepo func(t *testing.T) GenreRepo) {
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, err := repo.CreateGenre(actor, &models.CreateGenreRequest{Name: "Crime"})
+		require.NoError(t, err)
+
+		genre, err := repo.GetGenre(id)
+		require.NoError(t, err)
+		assertCreatedBy(t, genre.Audit, actor)
+		assert.Equal(t, &models.Genre{ID: id, Name: "Crime", Version: 1, Audit: genre.Audit}, genre)
+
+		_, err = repo.CreateGenre(actor, &models.CreateGenreRequest{Name: "Crime"})
+		assert.ErrorIs(t, err, errs.AlreadyExists)
+		_, err = repo.GetGenre(models.NewID())
+		assert.ErrorIs(t, err, errs.NotFound)
+
+		genres, err := repo.GetGenres([]models.ID{id, models.NewID()})
+		require.NoError(t, err)
+		require.Len(t, genres, 1)
+		assert.Equal(t, genre, genres[0])
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		editor := models.NewID()
+
+		id, err := repo.CreateGenre(actor, &models.CreateGenreRequest{Name: "Scifi"})
+		require.NoError(t, err)
+		_, err = repo.CreateGenre(actor, &models.CreateGenreRequest{Name: "Drama"})
+		require.NoError(t, err)
+
+		name := "Science Fiction"
+		updated, err := repo.UpdateGenre(editor, id, 1, &models.UpdateGenreRequest{Name: &name})
+		require.NoError(t, err)
+		assert.Equal(t, name, updated.Name)
+		assert.Equal(t, int64(2), updated.Version)
+		assert.Equal(t, &editor, updated.UpdatedBy)
+
+		stored, err := repo.GetGenre(id)
+		require.NoError(t, err)
+		assert.Equal(t, updated, stored)
+
+		_, err = repo.UpdateGenre(editor, id, 1, &models.UpdateGenreRequest{Name: &name})
+		assert.ErrorIs(t, err, errs.Conflict)
+		taken := "Drama"
+		_, err = repo.UpdateGenre(editor, id, 2, &models.UpdateGenreRequest{Name: &taken})
+		assert.ErrorIs(t, err, errs.AlreadyExists)
+	})
+
+	t.Run("DeleteAndList", func(t *testing.T) {
+		repo := newRepo(t)
+
+		var ids []models.ID
+		for _, name := range []string{"Western", "Horror", "Comedy"} {
+			id, err := repo.CreateGenre(actor, &models.CreateGenreRequest{Name: name})
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+
+		genres := listAll(t, repo.ListGenres, models.ListOptions{Sort: "-name", Limit: 2})
+		names := []string{}
+		for _, genre := range genres {
+			names = append(names, genre.Name)
+		}
+		assert.Equal(t, []string{"Western", "Horror", "Comedy"}, names)
+
+		require.NoError(t, repo.DeleteGenre(ids[0]))
+		assert.ErrorIs(t, repo.DeleteGenre(ids[0]), errs.NotFound)
+		_, err := repo.CreateGenre(actor, &models.CreateGenreRequest{Name: "Western"})
+		assert.NoError(t, err, "the name of a deleted genre is free again")
+	})
+}
+
+func testCreditRepo(t *testing.T, newRepo func(t *testing.T) CreditRepo) {
+	movieID := models.NewID()
+	personID := models.NewID()
+	newRequest := func(role models.CreditRole, character string, order int) *models.CreateCreditRequest {
+		return &models.CreateCreditRequest{PersonID: &personID, Role: role, Character: character, Order: order}
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, err := repo.CreateCredit(actor, movieID, newRequest(models.CreditActor, "Neo", 0))
+		require.NoError(t, err)
+
+		credit, err := repo.GetCredit(id)
+		require.NoError(t, err)
+		assertCreatedBy(t, credit.Audit, actor)
+		assert.Equal(t, &models.Credit{
+			ID:        id,
+			MovieID:   movieID,
+			PersonID:  personID,
+			Role:      models.CreditActor,
+			Character: "Neo",
+			Version:   1,
+			Audit:     credit.Audit,
+		}, credit)
+
+		_, err = repo.GetCredit(models.NewID())
+		assert.ErrorIs(t, err, errs.NotFound)
+	})
+
+	t.Run("Listing", func(t *testing.T) {
+		repo := newRepo(t)
+		otherMovieID := models.NewID()
+
+		third, err := repo.CreateCredit(actor, movieID, newRequest(models.CreditComposer, "", 2))
+		require.NoError(t, err)
+		first, err := repo.CreateCredit(actor, movieID, newRequest(models.CreditActor, "Neo", 0))
+		require.NoError(t, err)
+		second, err := repo.CreateCredit(actor, movieID, newRequest(models.CreditActor, "Thomas Anderson", 1))
+		require.NoError(t, err)
+		other, err := repo.CreateCredit(actor, otherMovieID, newRequest(models.CreditActor, "John Wick", 0))
+		require.NoError(t, err)
+
+		credits, err := repo.ListMovieCredits(movieID)
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{first, second, third}, creditIDs(credits))
+
+		credits, err = repo.ListPersonCredits(personID)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []models.ID{first, second, third, other}, creditIDs(credits))
+		credits, err = repo.ListPersonCredits(models.NewID())
+		require.NoError(t, err)
+		assert.Empty(t, credits)
+
+		require.NoError(t, repo.DeleteCreditsByMovieID(movieID))
+		credits, err = repo.ListPersonCredits(personID)
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{other}, creditIDs(credits))
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		editor := models.NewID()
+
+		id, err := repo.CreateCredit(actor, movieID, newRequest(models.CreditActor, "Neo", 0))
+		require.NoError(t, err)
+		created, err := repo.GetCredit(id)
+		require.NoError(t, err)
+
+		time.Sleep(2 * time.Millisecond)
+		order := 3
+		updated, err := repo.UpdateCredit(editor, id, 1, &models.UpdateCreditRequest{Order: &order})
+		require.NoError(t, err)
+		assert.Equal(t, "Neo", updated.Character)
+		assert.Equal(t, 3, updated.Order)
+		assert.Equal(t, int64(2), updated.Version)
+		assertUpdatedBy(t, created.Audit, updated.Audit, editor)
+
+		stored, err := repo.GetCredit(id)
+		require.NoError(t, err)
+		assert.Equal(t, updated, stored)
+
+		_, err = repo.UpdateCredit(editor, id, 1, &models.UpdateCreditRequest{Order: &order})
+		assert.ErrorIs(t, err, errs.Conflict)
+		_, err = repo.UpdateCredit(editor, models.NewID(), 1, &models.UpdateCreditRequest{Order: &order})
+		assert.ErrorIs(t, err, errs.NotFound)
+	})
+
+	t.Run("Move", func(t *testing.T) {
+		repo := newRepo(t)
+		editor := models.NewID()
+		otherMovieID := models.NewID()
+
+		moved, err := repo.CreateCredit(actor, movieID, newRequest(models.CreditActor, "Neo", 1))
+		require.NoError(t, err)
+		kept, err := repo.CreateCredit(actor, otherMovieID, newRequest(models.CreditDirector, "", 0))
+		require.NoError(t, err)
+
+		require.NoError(t, repo.MoveCredits(editor, movieID, otherMovieID))
+		credits, err := repo.ListMovieCredits(otherMovieID)
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{kept, moved}, creditIDs(credits))
+		assert.Equal(t, int64(2), credits[1].Version)
+		assert.Equal(t, &editor, credits[1].UpdatedBy)
+		credits, err = repo.ListMovieCredits(movieID)
+		require.NoError(t, err)
+		assert.Empty(t, credits)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, err := repo.CreateCredit(actor, movieID, newRequest(models.CreditWriter, "", 0))
+		require.NoError(t, err)
+		require.NoError(t, repo.DeleteCredit(id))
+		assert.ErrorIs(t, repo.DeleteCredit(id), errs.NotFound)
+		_, err = repo.GetCredit(id)
+		assert.ErrorIs(t, err, errs.NotFound)
+	})
+}
+
+func testSearchIndex(t *testing.T, newIndex func(t *testing.T) (MovieRepo, SearchIndex)) {
+	type fixture struct {
+		movies MovieRepo
+		index  SearchIndex
+		ids    map[string]models.ID
+	}
+	newFixture := func(t *testing.T) fixture {
+		movies, index := newIndex(t)
+		f := fixture{movies: movies, index: index, ids: map[string]models.ID{}}
+		for _, movie := range []struct {
+			models.CreateMovieRequest
+			rating int
+		}{
+			{models.CreateMovieRequest{Title: "The Matrix", Year: 1999}, 9},
+			{models.CreateMovieRequest{Title: "The Matrix Reloaded", Year: 2003}, 7},
+			{models.CreateMovieRequest{Title: "The Matrix Revolutions", Year: 2003}, 6},
+			{models.CreateMovieRequest{Title: "Heat", Year: 1995}, 8},
+			{models.CreateMovieRequest{Title: "Amélie", Year: 2001}, 8},
+		} {
+			id, err := movies.CreateMovie(actor, &movie.CreateMovieRequest)
+			require.NoError(t, err)
+			require.NoError(t, movies.UpdateRatings(id, 0, movie.rating))
+			created, err := movies.GetMovie(id)
+			require.NoError(t, err)
+			require.NoError(t, index.IndexMovie(created))
+			f.ids[movie.Title] = id
+		}
+		return f
+	}
+	search := func(t *testing.T, f fixture, query string) []string {
+		t.Helper()
+		hits, err := f.index.SearchMovies(query, 10)
+		require.NoError(t, err)
+		titles := []string{}
+		for _, hit := range hits {
+			for title, id := range f.ids {
+				if id == hit.ID {
+					titles = append(titles, title)
+				}
+			}
+		}
+		return titles
+	}
+
+	t.Run("Ranking", func(t *testing.T) {
+		f := newFixture(t)
+
+		assert.Equal(t, []string{"The Matrix", "The Matrix Reloaded", "The Matrix Revolutions"}, search(t, f, "matrix"))
+		assert.Equal(t, []string{"The Matrix", "The Matrix Reloaded", "The Matrix Revolutions"}, search(t, f, "The Matrix"))
+		assert.Equal(t, []string{"The Matrix Revolutions"}, search(t, f, "matrix revolutions"))
+		assert.Equal(t, []string{"The Matrix Reloaded", "The Matrix Revolutions"}, search(t, f, "matrix 2003"))
+		assert.Equal(t, []string{"Heat"}, search(t, f, "heat"))
+	})
+
+	t.Run("PrefixAndTypos", func(t *testing.T) {
+		f := newFixture(t)
+
+		assert.Equal(t, []string{"The Matrix", "The Matrix Reloaded", "The Matrix Revolutions"}, search(t, f, "matr"))
+		assert.Equal(t, []string{"The Matrix Reloaded"}, search(t, f, "matrix rel"))
+		assert.Equal(t, []string{"The Matrix", "The Matrix Reloaded", "The Matrix Revolutions"}, search(t, f, "matirx"))
+		assert.Equal(t, []string{"The Matrix Revolutions"}, search(t, f, "revolutoins"))
+		assert.Equal(t, []string{"Amélie"}, search(t, f, "amelie"))
+		assert.Equal(t, []string{"Amélie"}, search(t, f, "ame"), "prefixes match the folded title")
+		assert.Empty(t, search(t, f, "hat"), "short words must match exactly or as a prefix")
+	})
+
+	t.Run("NoMatch", func(t *testing.T) {
+		f := newFixture(t)
+
+		hits, err := f.index.SearchMovies("zardoz", 10)
+		require.NoError(t, err)
+		assert.NotNil(t, hits)
+		assert.Empty(t, hits)
+
+		hits, err = f.index.SearchMovies("  ", 10)
+		require.NoError(t, err)
+		assert.Empty(t, hits)
+	})
+
+	t.Run("Limit", func(t *testing.T) {
+		f := newFixture(t)
+
+		hits, err := f.index.SearchMovies("matrix", 2)
+		require.NoError(t, err)
+		require.Len(t, hits, 2)
+		assert.Equal(t, f.ids["The Matrix"], hits[0].ID)
+		assert.Greater(t, hits[0].Score, hits[1].Score)
+	})
+
+	t.Run("UpdateAndRemove", func(t *testing.T) {
+		f := newFixture(t)
+
+		title := "Heat 2"
+		updated, err := f.movies.UpdateMovie(actor, f.ids["Heat"], 1, &models.UpdateMovieRequest{Title: &title})
+		require.NoError(t, err)
+		require.NoError(t, f.index.IndexMovie(updated))
+		hits, err := f.index.SearchMovies("heat 2", 10)
+		require.NoError(t, err)
+		require.Len(t, hits, 1)
+		assert.Equal(t, f.ids["Heat"], hits[0].ID)
+
+		require.NoError(t, f.movies.DeleteMovie(actor, f.ids["Heat"]))
+		require.NoError(t, f.index.RemoveMovie(f.ids["Heat"]))
+		require.NoError(t, f.index.RemoveMovie(f.ids["Heat"]))
+		assert.Empty(t, search(t, f, "heat"))
+	})
+}
+
+func testFacetCounter(t *testing.T, newCounter func(t *testing.T) (MovieRepo, TagRepo, FacetCounter)) {
+	movies, tags, counter := newCounter(t)
+	allFacets := []string{models.FacetGenre, models.FacetDecade, models.FacetRating, models.FacetTag}
+
+	drama, comedy := models.NewID(), models.NewID()
+	create := func(req models.CreateMovieRequest, ratings ...int) models.ID {
+		t.Helper()
+		id, err := movies.CreateMovie(actor, &req)
+		require.NoError(t, err)
+		for _, rating := range ratings {
+			require.NoError(t, movies.UpdateRatings(id, 0, rating))
+		}
+		return id
+	}
+	heat := create(models.CreateMovieRequest{Title: "Heat", Year: 1995, GenreID: &drama}, 8, 9)
+	matrix := create(models.CreateMovieRequest{Title: "The Matrix", Year: 1999, GenreID: &drama}, 10)
+	amelie := create(models.CreateMovieRequest{Title: "Amélie", Year: 2001, GenreID: &comedy})
+	undated := create(models.CreateMovieRequest{Title: "Untitled"})
+	trashed := create(models.CreateMovieRequest{Title: "Trashed", Year: 2010, GenreID: &comedy}, 5)
+	require.NoError(t, movies.DeleteMovie(actor, trashed))
+
+	heist, err := tags.CreateTag(actor, &models.Tag{Name: "heist"})
+	require.NoError(t, err)
+	paris, err := tags.CreateTag(actor, &models.Tag{Name: "paris"})
+	require.NoError(t, err)
+	for _, mt := range []models.MovieTag{
+		{MovieID: heat, TagID: &heist, Status: models.SuggestionApproved},
+		{MovieID: amelie, TagID: &paris, Status: models.SuggestionApproved},
+		{MovieID: undated, TagID: &paris, Status: models.SuggestionApproved},
+		{MovieID: matrix, TagID: &heist, Status: models.SuggestionPending},
+		{MovieID: matrix, Name: "cyberpunk", Status: models.SuggestionApproved},
+		{MovieID: trashed, TagID: &heist, Status: models.SuggestionApproved},
+	} {
+		_, err := tags.CreateMovieTag(actor, &mt)
+		require.NoError(t, err)
+	}
+
+	scores := map[int]int{}
+	for _, id := range []models.ID{heat, matrix} {
+		movie, err := movies.GetMovie(id)
+		require.NoError(t, err)
+		scores[ratingFacet(movie.Ratings.Score)]++
+	}
+
+	t.Run("All", func(t *testing.T) {
+		counts, err := counter.CountMovieFacets(models.ListOptions{}, allFacets)
+		require.NoError(t, err)
+		assert.Equal(t, map[models.ID]int{drama: 2, comedy: 1}, counts.Genres)
+		assert.Equal(t, map[int]int{1990: 2, 2000: 1}, counts.Decades, "movies without a year are left out")
+		assert.Equal(t, scores, counts.Ratings, "movies nobody rated are left out")
+		assert.Equal(t, map[models.ID]int{heist: 1, paris: 2}, counts.Tags, "only approved tags of live movies count")
+	})
+
+	t.Run("Filtered", func(t *testing.T) {
+		counts, err := counter.CountMovieFacets(models.ListOptions{
+			Filters: []models.Filter{{Field: "year", Op: models.OpGte, Value: int64(1999)}},
+			Sort:    "-year",
+			Limit:   1,
+		}, allFacets)
+		require.NoError(t, err)
+		assert.Equal(t, map[models.ID]int{drama: 1, comedy: 1}, counts.Genres, "every page is counted")
+		assert.Equal(t, map[int]int{1990: 1, 2000: 1}, counts.Decades)
+		assert.Equal(t, map[models.ID]int{paris: 1}, counts.Tags)
+
+		counts, err = counter.CountMovieFacets(models.ListOptions{IDs: []models.ID{heat, undated}}, allFacets)
+		require.NoError(t, err)
+		assert.Equal(t, map[models.ID]int{drama: 1}, counts.Genres)
+		assert.Equal(t, map[models.ID]int{heist: 1, paris: 1}, counts.Tags)
+	})
+
+	t.Run("OnlyAskedFor", func(t *testing.T) {
+		counts, err := counter.CountMovieFacets(models.ListOptions{}, []string{models.FacetDecade})
+		require.NoError(t, err)
+		assert.Equal(t, map[int]int{1990: 2, 2000: 1}, counts.Decades)
+		assert.Empty(t, counts.Genres)
+		assert.Empty(t, counts.Ratings)
+		assert.Empty(t, counts.Tags)
+	})
+}
+
+// assertCreatedBy checks the stamps of a record actorID has just created.
+func assertCreatedBy(t *testing.T, audit models.Audit, actorID models.ID) {
+	t.Helper()
+	require.NotNil(t, audit.CreatedAt)
+	assert.Equal(t, audit.CreatedAt, audit.UpdatedAt)
+	assert.Equal(t, &actorID, audit.CreatedBy)
+	assert.Equal(t, &actorID, audit.UpdatedBy)
+}
+
+// assertUpdatedBy checks that audit records a later change by actorID to a
+// record that was stamped with created.
+func assertUpdatedBy(t *testing.T, created, audit models.Audit, actorID models.ID) {
+	t.Helper()
+	assert.Equal(t, created.CreatedAt, audit.CreatedAt)
+	assert.Equal(t, created.CreatedBy, audit.CreatedBy)
+	require.NotNil(t, audit.UpdatedAt)
+	assert.Greater(t, *audit.UpdatedAt, *created.UpdatedAt)
+	assert.Equal(t, &actorID, audit.UpdatedBy)
+}
+
+// items adapts a list call for tests that only look at the first page.
+func items[T any](page models.Page[T], err error) ([]T, error) {
+	return page.Items, err
+}
+
+// listAll follows the cursors of a paged list to its end.
+func listAll[T any](t *testing.T, list func(models.ListOptions) (models.Page[T], error), opts models.ListOptions) []T {
+	t.Helper()
+	var all []T
+	for {
+		page, err := list(opts)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(page.Items), opts.Limit)
+		all = append(all, page.Items...)
+		if page.NextCursor == "" {
+			return all
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+func userIDs(users []*models.User) []models.ID {
+	ids := make([]models.ID, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return ids
+}
+
+func movieIDs(movies []*models.Movie) []models.ID {
+	ids := make([]models.ID, 0, len(movies))
+	for _, movie := range movies {
+		ids = append(ids, movie.ID)
+	}
+	return ids
+}
+
+func creditIDs(credits []*models.Credit) []models.ID {
+	ids := []models.ID{}
+	for _, credit := range credits {
+		ids = append(ids, credit.ID)
+	}
+	return ids
+}
+
+func reviewIDs(reviews []*models.Review) []models.ID {
+	ids := make([]models.ID, 0, len(reviews))
+	for _, review := range reviews {
+		ids = append(ids, review.ID)
+	}
+	return ids
+}
+
+func testBlobStore(t *testing.T, newStore func(t *testing.T) BlobStore) {
+	t.Run("PutAndOpen", func(t *testing.T) {
+		store := newStore(t)
+		data := []byte("not really a png")
+		key := BlobKey(data, ".png")
+
+		_, err := store.OpenBlob(key)
+		assert.ErrorIs(t, err, errs.NotFound)
+
+		require.NoError(t, store.PutBlob(key, data))
+		require.NoError(t, store.PutBlob(key, data), "storing a blob twice is a no-op")
+
+		blob, err := store.OpenBlob(key)
+		require.NoError(t, err)
+		defer blob.Close()
+		assert.Equal(t, int64(len(data)), blob.Size)
+		assert.Equal(t, "image/png", blob.ContentType)
+		read, err := io.ReadAll(blob)
+		require.NoError(t, err)
+		assert.Equal(t, data, read)
+	})
+
+	t.Run("InvalidKeys", func(t *testing.T) {
+		store := newStore(t)
+
+		assert.Error(t, store.PutBlob("../escape.png", []byte("x")))
+		for _, key := range []string{"", "../escape.png", "abc.png", BlobKey([]byte("x"), "")} {
+			_, err := store.OpenBlob(key)
+			assert.ErrorIs(t, err, errs.NotFound, key)
+		}
+	})
+}
+
+func testRevisionRepo(t *testing.T, newRepo func(t *testing.T) RevisionRepo) {
+	title, year := "The Matrix", 1999
+	directorID := models.NewID()
+	poster := &models.Poster{Key: "ab.jpg", URL: "/media/ab.jpg", Variants: []models.ImageVariant{{Name: models.VariantThumbnail, Key: "cd.jpg"}}}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		movieID := models.NewID()
+
+		id, err := repo.CreateMovieRevision(actor, &models.MovieRevision{
+			MovieID: movieID,
+			Version: 1,
+			Action:  models.RevisionCreated,
+			After:   models.MovieFields{Title: &title, Year: &year, DirectorID: &directorID, Poster: poster},
+		})
+		require.NoError(t, err)
+		revertedTo := int64(1)
+		_, err = repo.CreateMovieRevision(models.NilID, &models.MovieRevision{
+			MovieID:    movieID,
+			Version:    2,
+			Action:     models.RevisionReverted,
+			Before:     models.MovieFields{Title: &title},
+			RevertedTo: &revertedTo,
+		})
+		require.NoError(t, err)
+
+		rev, err := repo.GetMovieRevision(movieID, 1)
+		require.NoError(t, err)
+		assert.Equal(t, id, rev.ID)
+		assert.Equal(t, &actor, rev.CreatedBy)
+		assert.WithinDuration(t, time.Now(), rev.CreatedAt.Time(), time.Minute)
+		assert.Equal(t, models.RevisionCreated, rev.Action)
+		assert.Equal(t, models.MovieFields{Title: &title, Year: &year, DirectorID: &directorID, Poster: poster}, rev.After)
+		assert.True(t, rev.Before.IsZero())
+		assert.Nil(t, rev.RevertedTo)
+
+		rev, err = repo.GetMovieRevision(movieID, 2)
+		require.NoError(t, err)
+		assert.Nil(t, rev.CreatedBy, "the system made the change")
+		assert.Equal(t, &revertedTo, rev.RevertedTo)
+		assert.Equal(t, &title, rev.Before.Title)
+		assert.True(t, rev.After.IsZero())
+
+		_, err = repo.GetMovieRevision(movieID, 3)
+		assert.ErrorIs(t, err, errs.NotFound)
+	})
+
+	t.Run("ListAndDelete", func(t *testing.T) {
+		repo := newRepo(t)
+		movieID, otherID := models.NewID(), models.NewID()
+
+		for version := int64(1); version <= 3; version++ {
+			_, err := repo.CreateMovieRevision(actor, &models.MovieRevision{MovieID: movieID, Version: version, Action: models.RevisionUpdated})
+			require.NoError(t, err)
+		}
+		_, err := repo.CreateMovieRevision(actor, &models.MovieRevision{MovieID: otherID, Version: 1, Action: models.RevisionCreated})
+		require.NoError(t, err)
+
+		versions := func(page models.Page[*models.MovieRevision]) []int64 {
+			versions := []int64{}
+			for _, rev := range page.Items {
+				versions = append(versions, rev.Version)
+			}
+			return versions
+		}
+		page, err := repo.ListMovieRevisions(movieID, models.ListOptions{Sort: "-version", Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{3, 2}, versions(page))
+		page, err = repo.ListMovieRevisions(movieID, models.ListOptions{Sort: "-version", Limit: 2, Cursor: page.NextCursor})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{1}, versions(page))
+		assert.Empty(t, page.NextCursor)
+
+		page, err = repo.ListMovieRevisions(movieID, models.ListOptions{Sort: "version", Filters: []models.Filter{{Field: "version", Op: models.OpGt, Value: int64(1)}}})
+		require.NoError(t, err)
+		assert.Equal(t, []int64{2, 3}, versions(page))
+
+		require.NoError(t, repo.DeleteMovieRevisions(movieID))
+		page, err = repo.ListMovieRevisions(movieID, models.ListOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, page.Items)
+		page, err = repo.ListMovieRevisions(otherID, models.ListOptions{})
+		require.NoError(t, err)
+		assert.Len(t, page.Items, 1)
+	})
+}
+
+func testSuggestionRepo(t *testing.T, newRepo func(t *testing.T) SuggestionRepo) {
+	title, year, imageURL := "The Matrix", 1999, "https://example.com/matrix.jpg"
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+		movieID := models.NewID()
+
+		id, err := repo.CreateSuggestion(actor, &models.Suggestion{MovieID: &movieID, MovieVersion: 3, Title: &title, ImageURL: &imageURL})
+		require.NoError(t, err)
+
+		s, err := repo.GetSuggestion(id)
+		require.NoError(t, err)
+		assertCreatedBy(t, s.Audit, actor)
+		assert.Equal(t, &models.Suggestion{
+			ID:           id,
+			MovieID:      &movieID,
+			MovieVersion: 3,
+			Title:        &title,
+			ImageURL:     &imageURL,
+			Status:       models.SuggestionPending,
+			Version:      1,
+			Audit:        s.Audit,
+		}, s)
+
+		directorID, genreID := models.NewID(), models.NewID()
+		id, err = repo.CreateSuggestion(actor, &models.Suggestion{Title: &title, Year: &year, DirectorID: &directorID, GenreID: &genreID})
+		require.NoError(t, err)
+		s, err = repo.GetSuggestion(id)
+		require.NoError(t, err)
+		assert.Nil(t, s.MovieID)
+		assert.Equal(t, &year, s.Year)
+		assert.Equal(t, &directorID, s.DirectorID)
+		assert.Equal(t, &genreID, s.GenreID)
+		assert.Nil(t, s.ImageURL)
+
+		_, err = repo.GetSuggestion(models.NewID())
+		assert.ErrorIs(t, err, errs.NotFound)
+	})
+
+	t.Run("Resolve", func(t *testing.T) {
+		repo := newRepo(t)
+		moderator := models.NewID()
+
+		id, err := repo.CreateSuggestion(actor, &models.Suggestion{Title: &title, Year: &year})
+		require.NoError(t, err)
+		movieID := models.NewID()
+		approved, err := repo.ResolveSuggestion(moderator, id, 1, models.SuggestionApproved, "", &movieID)
+		require.NoError(t, err)
+		assert.Equal(t, models.SuggestionApproved, approved.Status)
+		assert.Equal(t, &movieID, approved.MovieID)
+		assert.Equal(t, int64(2), approved.Version)
+		assert.Equal(t, &moderator, approved.UpdatedBy)
+
+		stored, err := repo.GetSuggestion(id)
+		require.NoError(t, err)
+		assert.Equal(t, approved, stored)
+
+		_, err = repo.ResolveSuggestion(moderator, id, 1, models.SuggestionRejected, "late", nil)
+		assert.ErrorIs(t, err, errs.Conflict)
+		_, err = repo.ResolveSuggestion(moderator, models.NewID(), 1, models.SuggestionRejected, "late", nil)
+		assert.ErrorIs(t, err, errs.NotFound)
+
+		id, err = repo.CreateSuggestion(actor, &models.Suggestion{MovieID: &movieID, MovieVersion: 1, Year: &year})
+		require.NoError(t, err)
+		rejected, err := repo.ResolveSuggestion(moderator, id, 1, models.SuggestionRejected, "wrong year", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "wrong year", rejected.Reason)
+		assert.Equal(t, &movieID, rejected.MovieID)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		repo := newRepo(t)
+		movieID := models.NewID()
+
+		var ids []models.ID
+		for i := 0; i < 3; i++ {
+			id, err := repo.CreateSuggestion(actor, &models.Suggestion{MovieID: &movieID, MovieVersion: 1, Title: &title})
+			require.NoError(t, err)
+			ids = append(ids, id)
+		}
+		other := models.NewID()
+		_, err := repo.CreateSuggestion(other, &models.Suggestion{Title: &title, Year: &year})
+		require.NoError(t, err)
+		_, err = repo.ResolveSuggestion(actor, ids[0], 1, models.SuggestionRejected, "duplicate", nil)
+		require.NoError(t, err)
+
+		pending := listAll(t, repo.ListSuggestions, models.ListOptions{
+			Filters: []models.Filter{{Field: "status", Op: models.OpEq, Value: string(models.SuggestionPending)}},
+			Limit:   2,
+		})
+		assert.Len(t, pending, 3)
+		forMovie := listAll(t, repo.ListSuggestions, models.ListOptions{Filters: []models.Filter{{Field: "movieId", Op: models.OpEq, Value: movieID}}, Limit: 2})
+		assert.Len(t, forMovie, 3)
+		mine := listAll(t, repo.ListSuggestions, models.ListOptions{Filters: []models.Filter{{Field: "createdBy", Op: models.OpEq, Value: other}}, Limit: 2})
+		require.Len(t, mine, 1)
+		assert.Nil(t, mine[0].MovieID)
+	})
+}
+
+func testTranslationRepo(t *testing.T, newRepo func(t *testing.T) TranslationRepo) {
+	movieID, otherID := models.NewID(), models.NewID()
+	newTranslation := func(recordID models.ID, locale, title string) *models.Translation {
+		return &models.Translation{Kind: models.TranslationMovie, RecordID: recordID, Locale: locale, Fields: map[string]string{"title": title}}
+	}
+
+	t.Run("PutAndList", func(t *testing.T) {
+		repo := newRepo(t)
+		editor := models.NewID()
+
+		created, err := repo.PutTranslation(actor, newTranslation(movieID, "ru", "Матрица"))
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), created.Version)
+		assertCreatedBy(t, created.Audit, actor)
+		_, err = repo.PutTranslation(actor, newTranslation(movieID, "de", "Matrix"))
+		require.NoError(t, err)
+		_, err = repo.PutTranslation(actor, newTranslation(otherID, "ru", "Начало"))
+		require.NoError(t, err)
+		_, err = repo.PutTranslation(actor, &models.Translation{Kind: models.TranslationReviewCategory, RecordID: movieID, Locale: "ru", Fields: map[string]string{"name": "Пересмотреть"}})
+		require.NoError(t, err)
+
+		time.Sleep(2 * time.Millisecond)
+		replaced, err := repo.PutTranslation(editor, newTranslation(movieID, "ru", "Матрица (1999)"))
+		require.NoError(t, err)
+		assert.Equal(t, created.ID, replaced.ID)
+		assert.Equal(t, int64(2), replaced.Version)
+		assertUpdatedBy(t, created.Audit, replaced.Audit, editor)
+
+		translations, err := repo.ListTranslations(models.TranslationMovie, []models.ID{movieID})
+		require.NoError(t, err)
+		require.Len(t, translations, 2)
+		assert.Equal(t, "de", translations[0].Locale)
+		assert.Equal(t, replaced, translations[1])
+		assert.Equal(t, map[string]string{"title": "Матрица (1999)"}, translations[1].Fields)
+
+		translations, err = repo.ListTranslations(models.TranslationMovie, []models.ID{otherID, movieID})
+		require.NoError(t, err)
+		assert.Len(t, translations, 3)
+		translations, err = repo.ListTranslations(models.TranslationMovie, nil)
+		require.NoError(t, err)
+		assert.Empty(t, translations)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.PutTranslation(actor, newTranslation(movieID, "ru", "Матрица"))
+		require.NoError(t, err)
+		_, err = repo.PutTranslation(actor, newTranslation(movieID, "de", "Matrix"))
+		require.NoError(t, err)
+		_, err = repo.PutTranslation(actor, newTranslation(otherID, "ru", "Начало"))
+		require.NoError(t, err)
+
+		require.NoError(t, repo.DeleteTranslation(models.TranslationMovie, movieID, "de"))
+		assert.ErrorIs(t, repo.DeleteTranslation(models.TranslationMovie, movieID, "de"), errs.NotFound)
+		assert.ErrorIs(t, repo.DeleteTranslation(models.TranslationReviewCategory, movieID, "ru"), errs.NotFound)
+		translations, err := repo.ListTranslations(models.TranslationMovie, []models.ID{movieID})
+		require.NoError(t, err)
+		require.Len(t, translations, 1)
+		assert.Equal(t, "ru", translations[0].Locale)
+
+		require.NoError(t, repo.DeleteTranslations(models.TranslationMovie, movieID))
+		translations, err = repo.ListTranslations(models.TranslationMovie, []models.ID{movieID, otherID})
+		require.NoError(t, err)
+		require.Len(t, translations, 1)
+		assert.Equal(t, otherID, translations[0].RecordID)
+	})
+}
+
+func testTagRepo(t *testing.T, newRepo func(t *testing.T) TagRepo) {
+	movieID, otherID := models.NewID(), models.NewID()
+	movieTags := func(t *testing.T, repo TagRepo, movieID models.ID) []*models.MovieTag {
+		t.Helper()
+		return listAll(t, repo.ListMovieTags, models.ListOptions{Filters: []models.Filter{{Field: "movieId", Op: models.OpEq, Value: movieID}}, Limit: 10})
+	}
+
+	t.Run("Tags", func(t *testing.T) {
+		repo := newRepo(t)
+		editor := models.NewID()
+
+		id, err := repo.CreateTag(actor, &models.Tag{Name: "time travel", Curated: true})
+		require.NoError(t, err)
+		_, err = repo.CreateTag(actor, &models.Tag{Name: "time travel"})
+		assert.Equal(t, errs.AlreadyExists, err)
+		otherTagID, err := repo.CreateTag(actor, &models.Tag{Name: "heist"})
+		require.NoError(t, err)
+
+		tag, err := repo.GetTag(id)
+		require.NoError(t, err)
+		assert.Equal(t, "time travel", tag.Name)
+		assert.True(t, tag.Curated)
+		assert.Equal(t, int64(1), tag.Version)
+		assertCreatedBy(t, tag.Audit, actor)
+
+		byName, err := repo.GetTagByName("heist")
+		require.NoError(t, err)
+		assert.Equal(t, otherTagID, byName.ID)
+		_, err = repo.GetTagByName("noir")
+		assert.Equal(t, errs.NotFound, err)
+
+		tags, err := repo.GetTags([]models.ID{id, models.NewID()})
+		require.NoError(t, err)
+		require.Len(t, tags, 1)
+		assert.Equal(t, id, tags[0].ID)
+
+		sorted := listAll(t, repo.ListTags, models.ListOptions{Sort: "name", Limit: 1})
+		require.Len(t, sorted, 2)
+		assert.Equal(t, "heist", sorted[0].Name)
+
+		time.Sleep(2 * time.Millisecond)
+		name := "time loop"
+		updated, err := repo.UpdateTag(editor, id, tag.Version, &models.UpdateTagRequest{Name: &name})
+		require.NoError(t, err)
+		assert.Equal(t, "time loop", updated.Name)
+		assert.True(t, updated.Curated)
+		assert.Equal(t, int64(2), updated.Version)
+		assertUpdatedBy(t, tag.Audit, updated.Audit, editor)
+
+		_, err = repo.UpdateTag(editor, id, tag.Version, &models.UpdateTagRequest{Name: &name})
+		assert.Equal(t, errs.Conflict, err)
+		taken := "heist"
+		_, err = repo.UpdateTag(editor, id, updated.Version, &models.UpdateTagRequest{Name: &taken})
+		assert.Equal(t, errs.AlreadyExists, err)
+		_, err = repo.UpdateTag(editor, models.NewID(), 1, &models.UpdateTagRequest{Name: &name})
+		assert.Equal(t, errs.NotFound, err)
+	})
+
+	t.Run("MovieTags", func(t *testing.T) {
+		repo := newRepo(t)
+		tagID, err := repo.CreateTag(actor, &models.Tag{Name: "heist"})
+		require.NoError(t, err)
+
+		id, err := repo.CreateMovieTag(actor, &models.MovieTag{MovieID: movieID, TagID: &tagID, Status: models.SuggestionApproved})
+		require.NoError(t, err)
+		_, err = repo.CreateMovieTag(actor, &models.MovieTag{MovieID: movieID, TagID: &tagID})
+		assert.Equal(t, errs.AlreadyExists, err)
+		_, err = repo.CreateMovieTag(actor, &models.MovieTag{MovieID: otherID, TagID: &tagID})
+		require.NoError(t, err)
+		// Suggested new tags have no tag yet and never collide.
+		_, err = repo.CreateMovieTag(actor, &models.MovieTag{MovieID: movieID, Name: "noir"})
+		require.NoError(t, err)
+		_, err = repo.CreateMovieTag(actor, &models.MovieTag{MovieID: movieID, Name: "neo-noir"})
+		require.NoError(t, err)
+
+		mt, err := repo.GetMovieTag(id)
+		require.NoError(t, err)
+		assert.Equal(t, movieID, mt.MovieID)
+		require.NotNil(t, mt.TagID)
+		assert.Equal(t, tagID, *mt.TagID)
+		assert.Equal(t, models.SuggestionApproved, mt.Status)
+		assertCreatedBy(t, mt.Audit, actor)
+
+		assert.Len(t, movieTags(t, repo, movieID), 3)
+		pending := listAll(t, repo.ListMovieTags, models.ListOptions{
+			Filters: []models.Filter{{Field: "status", Op: models.OpEq, Value: string(models.SuggestionPending)}},
+			Limit:   2,
+		})
+		assert.Len(t, pending, 3)
+		withTag := listAll(t, repo.ListMovieTags, models.ListOptions{Filters: []models.Filter{{Field: "tagId", Op: models.OpEq, Value: tagID}}, Limit: 1})
+		assert.Len(t, withTag, 2)
+
+		require.NoError(t, repo.DeleteMovieTag(movieID, tagID))
+		assert.Equal(t, errs.NotFound, repo.DeleteMovieTag(movieID, tagID))
+		assert.Len(t, movieTags(t, repo, movieID), 2)
+
+		require.NoError(t, repo.DeleteMovieTags(movieID))
+		assert.Empty(t, movieTags(t, repo, movieID))
+		assert.Len(t, movieTags(t, repo, otherID), 1)
+	})
+
+	t.Run("Resolve", func(t *testing.T) {
+		repo := newRepo(t)
+		editor := models.NewID()
+		tagID, err := repo.CreateTag(actor, &models.Tag{Name: "noir"})
+		require.NoError(t, err)
+		_, err = repo.CreateMovieTag(actor, &models.MovieTag{MovieID: movieID, TagID: &tagID, Status: models.SuggestionApproved})
+		require.NoError(t, err)
+
+		id, err := repo.CreateMovieTag(actor, &models.MovieTag{MovieID: movieID, Name: "heist"})
+		require.NoError(t, err)
+		suggested, err := repo.GetMovieTag(id)
+		require.NoError(t, err)
+		assert.Equal(t, models.SuggestionPending, suggested.Status)
+		assert.Nil(t, suggested.TagID)
+
+		_, err = repo.ResolveMovieTag(editor, id, suggested.Version, models.SuggestionApproved, "", &tagID)
+		assert.Equal(t, errs.AlreadyExists, err)
+
+		newTagID, err := repo.CreateTag(editor, &models.Tag{Name: "heist"})
+		require.NoError(t, err)
+		time.Sleep(2 * time.Millisecond)
+		resolved, err := repo.ResolveMovieTag(editor, id, suggested.Version, models.SuggestionApproved, "", &newTagID)
+		require.NoError(t, err)
+		assert.Equal(t, models.SuggestionApproved, resolved.Status)
+		require.NotNil(t, resolved.TagID)
+		assert.Equal(t, newTagID, *resolved.TagID)
+		assert.Equal(t, int64(2), resolved.Version)
+		assertUpdatedBy(t, suggested.Audit, resolved.Audit, editor)
+
+		_, err = repo.ResolveMovieTag(editor, id, suggested.Version, models.SuggestionRejected, "stale", nil)
+		assert.Equal(t, errs.Conflict, err)
+		_, err = repo.ResolveMovieTag(editor, models.NewID(), 1, models.SuggestionRejected, "", nil)
+		assert.Equal(t, errs.NotFound, err)
+
+		rejectedID, err := repo.CreateMovieTag(actor, &models.MovieTag{MovieID: otherID, TagID: &tagID})
+		require.NoError(t, err)
+		rejected, err := repo.ResolveMovieTag(editor, rejectedID, 1, models.SuggestionRejected, "not a noir", nil)
+		require.NoError(t, err)
+		assert.Equal(t, models.SuggestionRejected, rejected.Status)
+		assert.Equal(t, "not a noir", rejected.Reason)
+		require.NotNil(t, rejected.TagID)
+		assert.Equal(t, tagID, *rejected.TagID)
+	})
+
+	t.Run("Move", func(t *testing.T) {
+		repo := newRepo(t)
+		shared, err := repo.CreateTag(actor, &models.Tag{Name: "heist"})
+		require.NoError(t, err)
+		own, err := repo.CreateTag(actor, &models.Tag{Name: "noir"})
+		require.NoError(t, err)
+
+		for _, tagID := range []models.ID{shared, own} {
+			_, err := repo.CreateMovieTag(actor, &models.MovieTag{MovieID: movieID, TagID: &tagID, Status: models.SuggestionApproved})
+			require.NoError(t, err)
+		}
+		_, err = repo.CreateMovieTag(actor, &models.MovieTag{MovieID: movieID, Name: "caper"})
+		require.NoError(t, err)
+		_, err = repo.CreateMovieTag(actor, &models.MovieTag{MovieID: otherID, TagID: &shared, Status: models.SuggestionApproved})
+		require.NoError(t, err)
+
+		require.NoError(t, repo.MoveMovieTags(actor, movieID, otherID))
+		assert.Empty(t, movieTags(t, repo, movieID))
+		moved := movieTags(t, repo, otherID)
+		require.Len(t, moved, 3)
+		var tagged []models.ID
+		for _, mt := range moved {
+			if mt.TagID != nil {
+				tagged = append(tagged, *mt.TagID)
+			}
+		}
+		assert.ElementsMatch(t, []models.ID{shared, own}, tagged)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepo(t)
+		tagID, err := repo.CreateTag(actor, &models.Tag{Name: "heist"})
+		require.NoError(t, err)
+		otherTagID, err := repo.CreateTag(actor, &models.Tag{Name: "noir"})
+		require.NoError(t, err)
+		for _, id := range []models.ID{tagID, otherTagID} {
+			_, err := repo.CreateMovieTag(actor, &models.MovieTag{MovieID: movieID, TagID: &id, Status: models.SuggestionApproved})
+			require.NoError(t, err)
+		}
+
+		require.NoError(t, repo.DeleteTag(tagID))
+		assert.Equal(t, errs.NotFound, repo.DeleteTag(tagID))
+		_, err = repo.GetTag(tagID)
+		assert.Equal(t, errs.NotFound, err)
+
+		left := movieTags(t, repo, movieID)
+		require.Len(t, left, 1)
+		assert.Equal(t, otherTagID, *left[0].TagID)
+	})
+}
+
+func testReleaseRepo(t *testing.T, newRepo func(t *testing.T) ReleaseRepo) {
+	movieID, otherID := models.NewID(), models.NewID()
+	newRequest := func(country, date string, releaseType models.ReleaseType) *models.CreateReleaseRequest {
+		return &models.CreateReleaseRequest{Country: country, Date: date, Type: releaseType, Certification: "R"}
+	}
+	releases := func(t *testing.T, repo ReleaseRepo, movieID models.ID) []*models.Release {
+		t.Helper()
+		return listAll(t, repo.ListReleases, models.ListOptions{Filters: []models.Filter{{Field: "movieId", Op: models.OpEq, Value: movieID}}, Limit: 10})
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, err := repo.CreateRelease(actor, movieID, newRequest("US", "1999-03-31", models.ReleaseTheatrical))
+		require.NoError(t, err)
+		release, err := repo.GetRelease(id)
+		require.NoError(t, err)
+		assert.Equal(t, &models.Release{
+			ID:            id,
+			MovieID:       movieID,
+			Country:       "US",
+			Date:          "1999-03-31",
+			Type:          models.ReleaseTheatrical,
+			Certification: "R",
+			Version:       1,
+			Audit:         release.Audit,
+		}, release)
+		assertCreatedBy(t, release.Audit, actor)
+
+		_, err = repo.CreateRelease(actor, movieID, newRequest("US", "1999-09-21", models.ReleaseTheatrical))
+		assert.ErrorIs(t, err, errs.AlreadyExists)
+		_, err = repo.CreateRelease(actor, movieID, newRequest("US", "1999-09-21", models.ReleaseStreaming))
+		assert.NoError(t, err)
+		_, err = repo.CreateRelease(actor, otherID, newRequest("US", "1999-03-31", models.ReleaseTheatrical))
+		assert.NoError(t, err)
+
+		_, err = repo.GetRelease(models.NewID())
+		assert.ErrorIs(t, err, errs.NotFound)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		repo := newRepo(t)
+
+		for _, req := range []*models.CreateReleaseRequest{
+			newRequest("US", "1999-03-31", models.ReleaseTheatrical),
+			newRequest("GB", "1999-06-11", models.ReleaseTheatrical),
+			newRequest("JP", "1999-09-11", models.ReleaseTheatrical),
+			newRequest("US", "1999-09-21", models.ReleaseStreaming),
+		} {
+			_, err := repo.CreateRelease(actor, movieID, req)
+			require.NoError(t, err)
+		}
+
+		byDate := listAll(t, repo.ListReleases, models.ListOptions{Sort: "date", Limit: 1})
+		require.Len(t, byDate, 4)
+		assert.Equal(t, []string{"1999-03-31", "1999-06-11", "1999-09-11", "1999-09-21"},
+			[]string{byDate[0].Date, byDate[1].Date, byDate[2].Date, byDate[3].Date})
+
+		us := listAll(t, repo.ListReleases, models.ListOptions{Filters: []models.Filter{
+			{Field: "country", Op: models.OpEq, Value: "US"},
+			{Field: "date", Op: models.OpLte, Value: "1999-06-30"},
+		}, Limit: 10})
+		require.Len(t, us, 1)
+		assert.Equal(t, models.ReleaseTheatrical, us[0].Type)
+
+		streaming := listAll(t, repo.ListReleases, models.ListOptions{Filters: []models.Filter{{Field: "type", Op: models.OpEq, Value: string(models.ReleaseStreaming)}}, Limit: 10})
+		require.Len(t, streaming, 1)
+		assert.Equal(t, "1999-09-21", streaming[0].Date)
+
+		_, err := repo.CreateRelease(actor, otherID, newRequest("US", "2003-05-15", models.ReleaseTheatrical))
+		require.NoError(t, err)
+		_, err = repo.CreateRelease(actor, models.NewID(), newRequest("US", "2003-11-05", models.ReleaseTheatrical))
+		require.NoError(t, err)
+		both := listAll(t, repo.ListReleases, models.ListOptions{Filters: []models.Filter{
+			{Field: "movieId", Op: models.OpIn, Value: []any{movieID, otherID}},
+			{Field: "country", Op: models.OpEq, Value: "US"},
+		}, Sort: "date", Limit: 2})
+		require.Len(t, both, 3)
+		assert.Equal(t, []string{"1999-03-31", "1999-09-21", "2003-05-15"}, []string{both[0].Date, both[1].Date, both[2].Date})
+		none := listAll(t, repo.ListReleases, models.ListOptions{Filters: []models.Filter{{Field: "movieId", Op: models.OpIn, Value: []any{}}}, Limit: 10})
+		assert.Empty(t, none)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		repo := newRepo(t)
+		editor := models.NewID()
+
+		id, err := repo.CreateRelease(actor, movieID, newRequest("US", "1999-03-31", models.ReleaseTheatrical))
+		require.NoError(t, err)
+		created, err := repo.GetRelease(id)
+		require.NoError(t, err)
+
+		time.Sleep(2 * time.Millisecond)
+		certification := "PG-13"
+		updated, err := repo.UpdateRelease(editor, id, 1, &models.UpdateReleaseRequest{Certification: &certification})
+		require.NoError(t, err)
+		assert.Equal(t, "PG-13", updated.Certification)
+		assert.Equal(t, "1999-03-31", updated.Date)
+		assert.Equal(t, int64(2), updated.Version)
+		assertUpdatedBy(t, created.Audit, updated.Audit, editor)
+
+		stored, err := repo.GetRelease(id)
+		require.NoError(t, err)
+		assert.Equal(t, updated, stored)
+
+		date := "1999-04-02"
+		_, err = repo.UpdateRelease(editor, id, 1, &models.UpdateReleaseRequest{Date: &date})
+		assert.ErrorIs(t, err, errs.Conflict)
+		_, err = repo.UpdateRelease(editor, models.NewID(), 1, &models.UpdateReleaseRequest{Date: &date})
+		assert.ErrorIs(t, err, errs.NotFound)
+	})
+
+	t.Run("Move", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.CreateRelease(actor, movieID, newRequest("US", "1999-03-31", models.ReleaseTheatrical))
+		require.NoError(t, err)
+		gb, err := repo.CreateRelease(actor, movieID, newRequest("GB", "1999-06-11", models.ReleaseTheatrical))
+		require.NoError(t, err)
+		kept, err := repo.CreateRelease(actor, otherID, newRequest("US", "1999-04-02", models.ReleaseTheatrical))
+		require.NoError(t, err)
+
+		require.NoError(t, repo.MoveReleases(actor, movieID, otherID))
+		assert.Empty(t, releases(t, repo, movieID))
+		moved := releases(t, repo, otherID)
+		require.Len(t, moved, 2)
+		var ids []models.ID
+		for _, release := range moved {
+			ids = append(ids, release.ID)
+		}
+		assert.ElementsMatch(t, []models.ID{gb, kept}, ids)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, err := repo.CreateRelease(actor, movieID, newRequest("US", "1999-03-31", models.ReleaseTheatrical))
+		require.NoError(t, err)
+		_, err = repo.CreateRelease(actor, movieID, newRequest("GB", "1999-06-11", models.ReleaseTheatrical))
+		require.NoError(t, err)
+		_, err = repo.CreateRelease(actor, otherID, newRequest("GB", "1999-06-11", models.ReleaseTheatrical))
+		require.NoError(t, err)
+
+		require.NoError(t, repo.DeleteRelease(id))
+		assert.ErrorIs(t, repo.DeleteRelease(id), errs.NotFound)
+		assert.Len(t, releases(t, repo, movieID), 1)
+
+		require.NoError(t, repo.DeleteReleasesByMovieID(movieID))
+		assert.Empty(t, releases(t, repo, movieID))
+		assert.Len(t, releases(t, repo, otherID), 1)
+	})
+}
+
+func testWatchlistRepo(t *testing.T, newRepo func(t *testing.T) WatchlistRepo) {
+	userID, otherID := models.NewID(), models.NewID()
+	movieID, otherMovieID := models.NewID(), models.NewID()
+	watchlist := func(t *testing.T, repo WatchlistRepo, userID models.ID) []*models.WatchlistEntry {
+		t.Helper()
+		return listAll(t, repo.ListWatchlistEntries, models.ListOptions{Filters: []models.Filter{{Field: "userId", Op: models.OpEq, Value: userID}}, Limit: 10})
+	}
+
+	t.Run("Entries", func(t *testing.T) {
+		repo := newRepo(t)
+
+		id, err := repo.AddToWatchlist(userID, movieID)
+		require.NoError(t, err)
+		_, err = repo.AddToWatchlist(userID, movieID)
+		assert.ErrorIs(t, err, errs.AlreadyExists)
+		_, err = repo.AddToWatchlist(userID, otherMovieID)
+		require.NoError(t, err)
+		_, err = repo.AddToWatchlist(otherID, movieID)
+		require.NoError(t, err)
+
+		entries := listAll(t, repo.ListWatchlistEntries, models.ListOptions{
+			Filters: []models.Filter{{Field: "userId", Op: models.OpEq, Value: userID}},
+			Sort:    models.SortCreatedAt,
+			Limit:   1,
+		})
+		require.Len(t, entries, 2)
+		assert.Equal(t, id, entries[0].ID)
+		assert.Equal(t, movieID, entries[0].MovieID)
+		assertCreatedBy(t, entries[0].Audit, userID)
+		onMovie := listAll(t, repo.ListWatchlistEntries, models.ListOptions{Filters: []models.Filter{{Field: "movieId", Op: models.OpEq, Value: movieID}}, Limit: 10})
+		assert.Len(t, onMovie, 2)
+
+		require.NoError(t, repo.RemoveFromWatchlist(userID, movieID))
+		assert.ErrorIs(t, repo.RemoveFromWatchlist(userID, movieID), errs.NotFound)
+		assert.Len(t, watchlist(t, repo, userID), 1)
+
+		require.NoError(t, repo.DeleteWatchlistEntriesByMovieID(movieID))
+		assert.Empty(t, watchlist(t, repo, otherID))
+		assert.Len(t, watchlist(t, repo, userID), 1)
+	})
+
+	t.Run("Move", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.AddToWatchlist(userID, movieID)
+		require.NoError(t, err)
+		_, err = repo.AddToWatchlist(userID, otherMovieID)
+		require.NoError(t, err)
+		_, err = repo.AddToWatchlist(otherID, movieID)
+		require.NoError(t, err)
+
+		require.NoError(t, repo.MoveWatchlistEntries(actor, movieID, otherMovieID))
+		for _, user := range []models.ID{userID, otherID} {
+			entries := watchlist(t, repo, user)
+			require.Len(t, entries, 1)
+			assert.Equal(t, otherMovieID, entries[0].MovieID)
+		}
+	})
+
+	t.Run("CalendarTokens", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.GetCalendarToken(userID)
+		assert.ErrorIs(t, err, errs.NotFound)
+		require.NoError(t, repo.SetCalendarToken(userID, "first"))
+		token, err := repo.GetCalendarToken(userID)
+		require.NoError(t, err)
+		assert.Equal(t, "first", token)
+		assert.ErrorIs(t, repo.SetCalendarToken(otherID, "first"), errs.AlreadyExists)
+
+		require.NoError(t, repo.SetCalendarToken(userID, "second"))
+		_, err = repo.GetCalendarTokenUser("first")
+		assert.ErrorIs(t, err, errs.NotFound, "a replaced token stops working")
+		owner, err := repo.GetCalendarTokenUser("second")
+		require.NoError(t, err)
+		assert.Equal(t, userID, owner)
+	})
+
+	t.Run("DeleteWatchlist", func(t *testing.T) {
+		repo := newRepo(t)
+
+		_, err := repo.AddToWatchlist(userID, movieID)
+		require.NoError(t, err)
+		_, err = repo.AddToWatchlist(otherID, movieID)
+		require.NoError(t, err)
+		require.NoError(t, repo.SetCalendarToken(userID, "secret"))
+
+		require.NoError(t, repo.DeleteWatchlist(userID))
+		assert.Empty(t, watchlist(t, repo, userID))
+		assert.Len(t, watchlist(t, repo, otherID), 1)
+		_, err = repo.GetCalendarToken(userID)
+		assert.ErrorIs(t, err, errs.NotFound)
+	})
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// CreditRepo stores the cast and crew credits of movies. Credits stay in
+// place while their movie is in the trash and go when it is purged.
+type CreditRepo interface {
+	// ListMovieCredits returns the credits of a movie in billing order.
+	ListMovieCredits(movieID models.ID) ([]*models.Credit, error)
+	// ListPersonCredits returns the credits of a person, in no particular
+	// order.
+	ListPersonCredits(personID models.ID) ([]*models.Credit, error)
+	GetCredit(id models.ID) (*models.Credit, error)
+	CreateCredit(actorID, movieID models.ID, req *models.CreateCreditRequest) (models.ID, error)
+	UpdateCredit(actorID, id models.ID, version int64, req *models.UpdateCreditRequest) (*models.Credit, error)
+	DeleteCredit(id models.ID) error
+	DeleteCreditsByMovieID(movieID models.ID) error
+	// MoveCredits moves the credits of a movie to another movie.
+	MoveCredits(actorID, from, to models.ID) error
+}
+
+const creditsCollection = "credits"
+
+type creditRepo struct {
+	ctx        context.Context
+	collection *mongo.Collection
+}
+
+func NewCreditRepo(log *zap.Logger, collNames map[string]int, db *mongo.Database) CreditRepo {
+	var collectionName = creditsCollection
+
+	if _, exists := collNames[collectionName]; !exists {
+		if err := db.CreateCollection(context.TODO(), collectionName); err != nil {
+			log.Fatal("couldn't initialize repository: ", zap.Error(err))
+		}
+	}
+
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "movieId", Value: 1}, {Key: "order", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "personId", Value: 1}}},
+	}
+	if _, err := db.Collection(collectionName).Indexes().CreateMany(context.TODO(), indexes); err != nil {
+		log.Fatal("couldn't initialize repository: ", zap.Error(err))
+	}
+
+	return &creditRepo{
+		ctx:        context.TODO(),
+		collection: db.Collection(collectionName),
+	}
+}
+
+func (r *creditRepo) ListMovieCredits(movieID models.ID) ([]*models.Credit, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "order", Value: 1}, {Key: "_id", Value: 1}})
+	return r.find(bson.M{"movieId": movieID}, opts)
+}
+
+func (r *creditRepo) ListPersonCredits(personID models.ID) ([]*models.Credit, error) {
+	return r.find(bson.M{"personId": personID}, options.Find())
+}
+
+func (r *creditRepo) find(filter bson.M, opts *options.FindOptions) ([]*models.Credit, error) {
+	cur, err := r.collection.Find(r.ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	credits := []*models.Credit{}
+	if err := cur.All(r.ctx, &credits); err != nil {
+		return nil, err
+	}
+	return credits, nil
+}
+
+func (r *creditRepo) GetCredit(id models.ID) (*models.Credit, error) {
+	var credit models.Credit
+	if err := r.collection.FindOne(r.ctx, bson.M{"_id": id}).Decode(&credit); err != nil {
+		return nil, mongoErr(err)
+	}
+	return &credit, nil
+}
+
+func (r *creditRepo) CreateCredit(actorID, movieID models.ID, req *models.CreateCreditRequest) (models.ID, error) {
+	credit := newCredit(actorID, movieID, req)
+	if _, err := r.collection.InsertOne(r.ctx, credit); err != nil {
+		return models.NilID, mongoErr(err)
+	}
+	return credit.ID, nil
+}
+
+func (r *creditRepo) UpdateCredit(actorID, id models.ID, version int64, req *models.UpdateCreditRequest) (*models.Credit, error) {
+	update := mongoTouch(bson.M{"$set": creditUpdateFields(req), "$inc": bson.M{"version": 1}}, actorID)
+
+	var credit models.Credit
+	filter := bson.M{"_id": id, "version": versionFilter(version)}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(r.ctx, filter, update, opts).Decode(&credit)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, mongoStale(r.ctx, r.collection, id)
+	}
+	if err != nil {
+		return nil, mongoErr(err)
+	}
+	return &credit, nil
+}
+
+func (r *creditRepo) DeleteCredit(id models.ID) error {
+	res, err := r.collection.DeleteOne(r.ctx, bson.M{"_id": id})
+	if err != nil {
+		return mongoErr(err)
+	}
+	if res.DeletedCount == 0 {
+		return errs.NotFound
+	}
+	return nil
+}
+
+func (r *creditRepo) DeleteCreditsByMovieID(movieID models.ID) error {
+	_, err := r.collection.DeleteMany(r.ctx, bson.M{"movieId": movieID})
+	return mongoErr(err)
+}
+
+func (r *creditRepo) MoveCredits(actorID, from, to models.ID) error {
+	update := mongoTouch(bson.M{"$set": bson.M{"movieId": to}, "$inc": bson.M{"version": 1}}, actorID)
+	_, err := r.collection.UpdateMany(r.ctx, bson.M{"movieId": from}, update)
+	return mongoErr(err)
+}
+
+func newCredit(actorID, movieID models.ID, req *models.CreateCreditRequest) *models.Credit {
+	return &models.Credit{
+		ID:        models.NewID(),
+		MovieID:   movieID,
+		PersonID:  *req.PersonID,
+		Role:      req.Role,
+		Character: req.Character,
+		Order:     req.Order,
+		Version:   1,
+		Audit:     newAudit(actorID),
+	}
+}
+
+// creditUpdateFields returns the stored field names of every field set in
+// req.
+func creditUpdateFields(req *models.UpdateCreditRequest) bson.M {
+	fields := bson.M{}
+	if req.Role != nil {
+		fields["role"] = *req.Role
+	}
+	if req.Character != nil {
+		fields["character"] = *req.Character
+	}
+	if req.Order != nil {
+		fields["order"] = *req.Order
+	}
+	return fields
+}
+
+// applyCreditUpdate is the in-process equivalent of creditUpdateFields.
+func applyCreditUpdate(credit *models.Credit, req *models.UpdateCreditRequest) *models.Credit {
+	if req.Role != nil {
+		credit.Role = *req.Role
+	}
+	if req.Character != nil {
+		credit.Character = *req.Character
+	}
+	if req.Order != nil {
+		credit.Order = *req.Order
+	}
+	return credit
+}
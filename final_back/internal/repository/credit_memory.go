@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type memoryCreditRepo struct {
+	mu      sync.RWMutex
+	credits map[models.ID]*models.Credit
+}
+
+// NewMemoryCreditRepo returns a CreditRepo that keeps credits in process
+// memory. It is safe for concurrent use and behaves like the Mongo
+// implementation.
+func NewMemoryCreditRepo() CreditRepo {
+	return &memoryCreditRepo{
+		credits: make(map[models.ID]*models.Credit),
+	}
+}
+
+func (r *memoryCreditRepo) ListMovieCredits(movieID models.ID) ([]*models.Credit, error) {
+	credits := r.filter(func(credit *models.Credit) bool { return credit.MovieID == movieID })
+	slices.SortFunc(credits, func(a, b *models.Credit) int {
+		if o := cmp.Compare(a.Order, b.Order); o != 0 {
+			return o
+		}
+		return a.ID.Compare(b.ID)
+	})
+	return credits, nil
+}
+
+func (r *memoryCreditRepo) ListPersonCredits(personID models.ID) ([]*models.Credit, error) {
+	return r.filter(func(credit *models.Credit) bool { return credit.PersonID == personID }), nil
+}
+
+func (r *memoryCreditRepo) filter(keep func(credit *models.Credit) bool) []*models.Credit {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	credits := []*models.Credit{}
+	for _, credit := range r.credits {
+		if keep(credit) {
+			credits = append(credits, cloneCredit(credit))
+		}
+	}
+	return credits
+}
+
+func (r *memoryCreditRepo) GetCredit(id models.ID) (*models.Credit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	credit, ok := r.credits[id]
+	if !ok {
+		return nil, errs.NotFound
+	}
+	return cloneCredit(credit), nil
+}
+
+func (r *memoryCreditRepo) CreateCredit(actorID, movieID models.ID, req *models.CreateCreditRequest) (models.ID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credit := newCredit(actorID, movieID, req)
+	r.credits[credit.ID] = credit
+	return credit.ID, nil
+}
+
+func (r *memoryCreditRepo) UpdateCredit(actorID, id models.ID, version int64, req *models.UpdateCreditRequest) (*models.Credit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	credit, ok := r.credits[id]
+	if !ok {
+		return nil, errs.NotFound
+	}
+	if credit.Version != version {
+		return nil, errs.Conflict
+	}
+
+	updated := applyCreditUpdate(cloneCredit(credit), req)
+	updated.Version++
+	updated.Audit = touch(updated.Audit, actorID)
+	r.credits[id] = updated
+	return cloneCredit(updated), nil
+}
+
+func (r *memoryCreditRepo) DeleteCredit(id models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.credits[id]; !ok {
+		return errs.NotFound
+	}
+	delete(r.credits, id)
+	return nil
+}
+
+func (r *memoryCreditRepo) DeleteCreditsByMovieID(movieID models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, credit := range r.credits {
+		if credit.MovieID == movieID {
+			delete(r.credits, id)
+		}
+	}
+	return nil
+}
+
+func (r *memoryCreditRepo) MoveCredits(actorID, from, to models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, credit := range r.credits {
+		if credit.MovieID != from {
+			continue
+		}
+		credit = cloneCredit(credit)
+		credit.MovieID = to
+		credit.Version++
+		credit.Audit = touch(credit.Audit, actorID)
+		r.credits[id] = credit
+	}
+	return nil
+}
+
+func cloneCredit(credit *models.Credit) *models.Credit {
+	clone := *credit
+	clone.Audit = cloneAudit(credit.Audit)
+	return &clone
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type sqlCreditRepo struct {
+	sqlConn
+}
+
+// NewSQLCreditRepo returns a CreditRepo backed by the credits table of a
+// Postgres or SQLite database opened with db.OpenSQL.
+func NewSQLCreditRepo(db *sql.DB) CreditRepo {
+	return &sqlCreditRepo{
+		sqlConn: sqlConn{db: db},
+	}
+}
+
+// The billing order is stored in billing_order, as ORDER is a keyword.
+const selectCredit = `SELECT id, movie_id, person_id, role, character_name, billing_order, version, ` + auditColumns + ` FROM credits`
+
+func (r *sqlCreditRepo) ListMovieCredits(movieID models.ID) ([]*models.Credit, error) {
+	return r.query(selectCredit+` WHERE movie_id = $1 ORDER BY billing_order, id`, movieID)
+}
+
+func (r *sqlCreditRepo) ListPersonCredits(personID models.ID) ([]*models.Credit, error) {
+	return r.query(selectCredit+` WHERE person_id = $1`, personID)
+}
+
+func (r *sqlCreditRepo) query(query string, args ...any) ([]*models.Credit, error) {
+	rows, err := r.q().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	credits := []*models.Credit{}
+	for rows.Next() {
+		credit, err := scanCredit(rows)
+		if err != nil {
+			return nil, err
+		}
+		credits = append(credits, credit)
+	}
+	return credits, rows.Err()
+}
+
+func (r *sqlCreditRepo) GetCredit(id models.ID) (*models.Credit, error) {
+	credit, err := scanCredit(r.q().QueryRow(selectCredit+` WHERE id = $1`, id))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	return credit, nil
+}
+
+func (r *sqlCreditRepo) CreateCredit(actorID, movieID models.ID, req *models.CreateCreditRequest) (models.ID, error) {
+	credit := newCredit(actorID, movieID, req)
+
+	args := append([]any{credit.ID, credit.MovieID, credit.PersonID, credit.Role, credit.Character, credit.Order, credit.Version}, auditArgs(credit.Audit)...)
+	_, err := r.q().Exec(`INSERT INTO credits (id, movie_id, person_id, role, character_name, billing_order, version, `+auditColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		args...)
+	if err != nil {
+		return models.NilID, sqlErr(err)
+	}
+	return credit.ID, nil
+}
+
+func (r *sqlCreditRepo) UpdateCredit(actorID, id models.ID, version int64, req *models.UpdateCreditRequest) (*models.Credit, error) {
+	credit, err := r.GetCredit(id)
+	if err != nil {
+		return nil, err
+	}
+	credit = applyCreditUpdate(credit, req)
+	credit.Audit = touch(credit.Audit, actorID)
+
+	res, err := r.q().Exec(`UPDATE credits SET role = $2, character_name = $3, billing_order = $4, updated_at = $6, updated_by = $7, version = version + 1 WHERE id = $1 AND version = $5`,
+		id, credit.Role, credit.Character, credit.Order, version, sqlTime(credit.UpdatedAt), sqlID(credit.UpdatedBy))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, errs.Conflict
+	}
+	credit.Version = version + 1
+	return credit, nil
+}
+
+func (r *sqlCreditRepo) DeleteCredit(id models.ID) error {
+	res, err := r.q().Exec(`DELETE FROM credits WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return errs.NotFound
+	}
+	return nil
+}
+
+func (r *sqlCreditRepo) DeleteCreditsByMovieID(movieID models.ID) error {
+	_, err := r.q().Exec(`DELETE FROM credits WHERE movie_id = $1`, movieID)
+	return err
+}
+
+func (r *sqlCreditRepo) MoveCredits(actorID, from, to models.ID) error {
+	audit := touch(models.Audit{}, actorID)
+	_, err := r.q().Exec(`UPDATE credits SET movie_id = $2, updated_at = $3, updated_by = $4, version = version + 1 WHERE movie_id = $1`,
+		from, to, sqlTime(audit.UpdatedAt), sqlID(audit.UpdatedBy))
+	return sqlErr(err)
+}
+
+func scanCredit(row rowScanner) (*models.Credit, error) {
+	var (
+		credit models.Credit
+		audit  auditScan
+	)
+	dest := append([]any{&credit.ID, &credit.MovieID, &credit.PersonID, &credit.Role, &credit.Character, &credit.Order, &credit.Version}, audit.dest()...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	credit.Audit = audit.audit()
+	return &credit, nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// mongoErr maps driver errors onto the storage-independent errors in errs so
+// that every UserRepo, MovieRepo and ReviewRepo implementation reports
+// missing and conflicting documents the same way.
+func mongoErr(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return errs.NotFound
+	case mongo.IsDuplicateKeyError(err):
+		return errs.AlreadyExists
+	}
+	return err
+}
+
+// sqlErr is the mongoErr counterpart for the Postgres and SQLite drivers.
+func sqlErr(err error) error {
+	var pgErr *pgconn.PgError
+	var liteErr *sqlite.Error
+
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, sql.ErrNoRows):
+		return errs.NotFound
+	case errors.As(err, &pgErr) && pgErr.Code == "23505":
+		return errs.AlreadyExists
+	case errors.As(err, &liteErr) && (liteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || liteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY):
+		return errs.AlreadyExists
+	}
+	return err
+}
+
+// versionFilter matches documents at version. Documents written before
+// versioning was introduced have no version field and count as version 0.
+func versionFilter(version int64) interface{} {
+	if version == 0 {
+		return bson.M{"$in": bson.A{0, nil}}
+	}
+	return version
+}
+
+// mongoStale is called after a conditional write on id matched nothing and
+// tells a stale version apart from a missing document.
+func mongoStale(ctx context.Context, collection *mongo.Collection, id models.ID) error {
+	n, err := collection.CountDocuments(ctx, live(bson.M{"_id": id}))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errs.NotFound
+	}
+	return errs.Conflict
+}
+
+// sqlStale is the mongoStale counterpart for the SQL repositories.
+func sqlStale(q querier, table string, id models.ID) error {
+	var n int
+	if err := q.QueryRow(`SELECT COUNT(*) FROM `+table+` WHERE id = $1 AND deleted IS NULL`, id).Scan(&n); err != nil {
+		return err
+	}
+	if n == 0 {
+		return errs.NotFound
+	}
+	return errs.Conflict
+}
@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"slices"
+
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MovieFacetCounts are the numbers of movies by genre ID, by the first year
+// of their decade, by the whole part of their rating score and by the ID of
+// the tags approved for them. Movies without a genre or year, and movies
+// nobody rated, are not counted in those facets.
+type MovieFacetCounts struct {
+	Genres  map[models.ID]int
+	Decades map[int]int
+	Ratings map[int]int
+	Tags    map[models.ID]int
+}
+
+func newMovieFacetCounts() MovieFacetCounts {
+	return MovieFacetCounts{
+		Genres:  map[models.ID]int{},
+		Decades: map[int]int{},
+		Ratings: map[int]int{},
+		Tags:    map[models.ID]int{},
+	}
+}
+
+// FacetCounter counts live movies by facet where they are stored, so that
+// the movies themselves need not be read.
+type FacetCounter interface {
+	// CountMovieFacets counts the movies MovieRepo.ListMovies would list for
+	// the filters and IDs of opts, on every page. Only the facets asked for
+	// are counted; the others are left empty.
+	CountMovieFacets(opts models.ListOptions, facets []string) (MovieFacetCounts, error)
+}
+
+// ratingFacet is the facet value of a rating score: its whole part, with a
+// perfect score counted with the scores just below it.
+func ratingFacet(score float64) int {
+	return min(max(int(score), models.MinRating), models.MaxRating-1)
+}
+
+type mongoFacetCounter struct {
+	ctx        context.Context
+	collection *mongo.Collection
+}
+
+// NewMongoFacetCounter returns a FacetCounter that groups the movies
+// collection, and the movie tags collection for tags, in one aggregation.
+func NewMongoFacetCounter(db *mongo.Database) FacetCounter {
+	return &mongoFacetCounter{
+		ctx:        context.TODO(),
+		collection: db.Collection(moviesCollection),
+	}
+}
+
+// facetGroup is a value of a facet and its count, as $group returns it.
+type facetGroup[K any] struct {
+	Value K   `bson:"_id"`
+	Count int `bson:"count"`
+}
+
+func (f *mongoFacetCounter) CountMovieFacets(opts models.ListOptions, facets []string) (MovieFacetCounts, error) {
+	filter, _, err := mongoList(live(bson.M{}), models.ListOptions{Filters: opts.Filters, IDs: opts.IDs}, models.MovieListFields)
+	if err != nil {
+		return MovieFacetCounts{}, err
+	}
+
+	group := func(value any) bson.M {
+		return bson.M{"$group": bson.M{"_id": value, "count": bson.M{"$sum": 1}}}
+	}
+	pipelines := map[string]bson.A{
+		models.FacetGenre: {
+			bson.M{"$match": bson.M{"genreId": bson.M{"$ne": nil}}},
+			group("$genreId"),
+		},
+		models.FacetDecade: {
+			bson.M{"$match": bson.M{"year": bson.M{"$gt": 0}}},
+			group(bson.M{"$multiply": bson.A{bson.M{"$floor": bson.M{"$divide": bson.A{"$year", 10}}}, 10}}),
+		},
+		models.FacetRating: {
+			bson.M{"$match": bson.M{"ratingCount": bson.M{"$gt": 0}}},
+			group(bson.M{"$min": bson.A{bson.M{"$max": bson.A{bson.M{"$floor": "$rating"}, models.MinRating}}, models.MaxRating - 1}}),
+		},
+		models.FacetTag: {
+			bson.M{"$project": bson.M{"_id": 1}},
+			bson.M{"$lookup": bson.M{"from": movieTagsCollection, "localField": "_id", "foreignField": "movieId", "as": "tag"}},
+			bson.M{"$unwind": "$tag"},
+			bson.M{"$match": bson.M{"tag.status": models.SuggestionApproved, "tag.tagId": bson.M{"$ne": nil}}},
+			group("$tag.tagId"),
+		},
+	}
+	facet := bson.M{}
+	for name, pipeline := range pipelines {
+		if slices.Contains(facets, name) {
+			facet[name] = pipeline
+		}
+	}
+	counts := newMovieFacetCounts()
+	if len(facet) == 0 {
+		return counts, nil
+	}
+
+	cur, err := f.collection.Aggregate(f.ctx, bson.A{bson.M{"$match": filter}, bson.M{"$facet": facet}})
+	if err != nil {
+		return MovieFacetCounts{}, err
+	}
+	var results []struct {
+		Genres  []facetGroup[models.ID] `bson:"genre"`
+		Decades []facetGroup[float64]   `bson:"decade"`
+		Ratings []facetGroup[float64]   `bson:"rating"`
+		Tags    []facetGroup[models.ID] `bson:"tag"`
+	}
+	if err := cur.All(f.ctx, &results); err != nil {
+		return MovieFacetCounts{}, err
+	}
+
+	for _, result := range results {
+		for _, g := range result.Genres {
+			counts.Genres[g.Value] = g.Count
+		}
+		for _, g := range result.Decades {
+			counts.Decades[int(g.Value)] = g.Count
+		}
+		for _, g := range result.Ratings {
+			counts.Ratings[int(g.Value)] = g.Count
+		}
+		for _, g := range result.Tags {
+			counts.Tags[g.Value] = g.Count
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"slices"
+
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+// facetPageSize is how many movies the memory FacetCounter reads at a time.
+const facetPageSize = 500
+
+type memoryFacetCounter struct {
+	movies MovieRepo
+	tags   TagRepo
+}
+
+// NewMemoryFacetCounter returns a FacetCounter that reads the listed movies
+// and their approved tags from movies and tags and counts them in process,
+// which is all the memory repositories allow.
+func NewMemoryFacetCounter(movies MovieRepo, tags TagRepo) FacetCounter {
+	return &memoryFacetCounter{movies: movies, tags: tags}
+}
+
+func (f *memoryFacetCounter) CountMovieFacets(opts models.ListOptions, facets []string) (MovieFacetCounts, error) {
+	opts = models.ListOptions{Filters: opts.Filters, IDs: opts.IDs, Limit: facetPageSize}
+
+	counts := newMovieFacetCounts()
+	listed := map[models.ID]bool{}
+	for {
+		page, err := f.movies.ListMovies(opts)
+		if err != nil {
+			return MovieFacetCounts{}, err
+		}
+		for _, movie := range page.Items {
+			listed[movie.ID] = true
+			if movie.GenreID != nil && slices.Contains(facets, models.FacetGenre) {
+				counts.Genres[*movie.GenreID]++
+			}
+			if movie.Year > 0 && slices.Contains(facets, models.FacetDecade) {
+				counts.Decades[movie.Year/10*10]++
+			}
+			if movie.Ratings.Count > 0 && slices.Contains(facets, models.FacetRating) {
+				counts.Ratings[ratingFacet(movie.Ratings.Score)]++
+			}
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+
+	if slices.Contains(facets, models.FacetTag) {
+		movieTags, err := f.tags.ListMovieTags(models.ListOptions{Filters: []models.Filter{
+			{Field: "status", Op: models.OpEq, Value: string(models.SuggestionApproved)},
+		}})
+		if err != nil {
+			return MovieFacetCounts{}, err
+		}
+		for _, t := range movieTags.Items {
+			if t.TagID != nil && listed[t.MovieID] {
+				counts.Tags[*t.TagID]++
+			}
+		}
+	}
+	return counts, nil
+}
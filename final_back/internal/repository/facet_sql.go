@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"database/sql"
+	"slices"
+	"strconv"
+
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type sqlFacetCounter struct {
+	sqlConn
+}
+
+// NewSQLFacetCounter returns a FacetCounter that groups the movies table,
+// joined with the movie_tags table for tags, of a Postgres or SQLite database
+// opened with db.OpenSQL.
+func NewSQLFacetCounter(db *sql.DB) FacetCounter {
+	return &sqlFacetCounter{
+		sqlConn: sqlConn{db: db},
+	}
+}
+
+// sqlRatingFacet computes ratingFacet of the rating column. Postgres rounds
+// a cast to an integer where SQLite truncates, so the buckets are spelled
+// out.
+func sqlRatingFacet() string {
+	expr := `CASE`
+	for bucket := models.MaxRating - 1; bucket > models.MinRating; bucket-- {
+		expr += ` WHEN rating >= ` + strconv.Itoa(bucket) + ` THEN ` + strconv.Itoa(bucket)
+	}
+	return expr + ` ELSE ` + strconv.Itoa(models.MinRating) + ` END`
+}
+
+func (f *sqlFacetCounter) CountMovieFacets(opts models.ListOptions, facets []string) (MovieFacetCounts, error) {
+	listed, args, err := sqlList(`SELECT id, genre_id, year, rating, rating_count FROM movies WHERE deleted IS NULL`, nil,
+		models.ListOptions{Filters: opts.Filters, IDs: opts.IDs}, models.MovieListFields)
+	if err != nil {
+		return MovieFacetCounts{}, err
+	}
+	from := ` FROM (` + listed + `) m `
+
+	counts := newMovieFacetCounts()
+	if slices.Contains(facets, models.FacetGenre) {
+		err := f.group(`SELECT genre_id, COUNT(*)`+from+`WHERE genre_id IS NOT NULL GROUP BY genre_id`, args, func(rows *sql.Rows) error {
+			var genreID models.ID
+			var count int
+			if err := rows.Scan(&genreID, &count); err != nil {
+				return err
+			}
+			counts.Genres[genreID] = count
+			return nil
+		})
+		if err != nil {
+			return MovieFacetCounts{}, err
+		}
+	}
+	if slices.Contains(facets, models.FacetDecade) {
+		err := f.group(`SELECT year / 10 * 10, COUNT(*)`+from+`WHERE year > 0 GROUP BY year / 10 * 10`, args, func(rows *sql.Rows) error {
+			var decade, count int
+			if err := rows.Scan(&decade, &count); err != nil {
+				return err
+			}
+			counts.Decades[decade] = count
+			return nil
+		})
+		if err != nil {
+			return MovieFacetCounts{}, err
+		}
+	}
+	if slices.Contains(facets, models.FacetRating) {
+		bucket := sqlRatingFacet()
+		err := f.group(`SELECT `+bucket+`, COUNT(*)`+from+`WHERE rating_count > 0 GROUP BY `+bucket, args, func(rows *sql.Rows) error {
+			var rating, count int
+			if err := rows.Scan(&rating, &count); err != nil {
+				return err
+			}
+			counts.Ratings[rating] = count
+			return nil
+		})
+		if err != nil {
+			return MovieFacetCounts{}, err
+		}
+	}
+	if slices.Contains(facets, models.FacetTag) {
+		tagArgs := append(slices.Clone(args), string(models.SuggestionApproved))
+		query := `SELECT t.tag_id, COUNT(*) FROM movie_tags t JOIN (` + listed + `) m ON m.id = t.movie_id ` +
+			`WHERE t.status = $` + strconv.Itoa(len(tagArgs)) + ` AND t.tag_id IS NOT NULL GROUP BY t.tag_id`
+		err := f.group(query, tagArgs, func(rows *sql.Rows) error {
+			var tagID models.ID
+			var count int
+			if err := rows.Scan(&tagID, &count); err != nil {
+				return err
+			}
+			counts.Tags[tagID] = count
+			return nil
+		})
+		if err != nil {
+			return MovieFacetCounts{}, err
+		}
+	}
+	return counts, nil
+}
+
+// group runs a GROUP BY query and hands each of its rows to scan.
+func (f *sqlFacetCounter) group(query string, args []any, scan func(rows *sql.Rows) error) error {
+	rows, err := f.q().Query(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
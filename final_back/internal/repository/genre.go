@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// GenreRepo stores genres, whose names are unique. Like people, genres have
+// no trash.
+type GenreRepo interface {
+	ListGenres(opts models.ListOptions) (models.Page[*models.Genre], error)
+	GetGenre(id models.ID) (*models.Genre, error)
+	// GetGenres returns the genres among ids that exist, in no particular
+	// order.
+	GetGenres(ids []models.ID) ([]*models.Genre, error)
+	CreateGenre(actorID models.ID, req *models.CreateGenreRequest) (models.ID, error)
+	UpdateGenre(actorID, id models.ID, version int64, req *models.UpdateGenreRequest) (*models.Genre, error)
+	DeleteGenre(id models.ID) error
+}
+
+const genresCollection = "genres"
+
+type genreRepo struct {
+	ctx        context.Context
+	collection *mongo.Collection
+}
+
+func NewGenreRepo(log *zap.Logger, collNames map[string]int, db *mongo.Database) GenreRepo {
+	var collectionName = genresCollection
+
+	if _, exists := collNames[collectionName]; !exists {
+		if err := db.CreateCollection(context.TODO(), collectionName); err != nil {
+			log.Fatal("couldn't initialize repository: ", zap.Error(err))
+		}
+	}
+
+	indexes := append(listIndexes(nil, models.SortCreatedAt, models.SortUpdatedAt), mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if _, err := db.Collection(collectionName).Indexes().CreateMany(context.TODO(), indexes); err != nil {
+		log.Fatal("couldn't initialize repository: ", zap.Error(err))
+	}
+
+	return &genreRepo{
+		ctx:        context.TODO(),
+		collection: db.Collection(collectionName),
+	}
+}
+
+func (r *genreRepo) ListGenres(opts models.ListOptions) (models.Page[*models.Genre], error) {
+	filter, findOpts, err := mongoList(bson.M{}, opts, models.GenreListFields)
+	if err != nil {
+		return models.Page[*models.Genre]{}, err
+	}
+	genres, err := r.find(filter, findOpts)
+	if err != nil {
+		return models.Page[*models.Genre]{}, err
+	}
+	return page(genres, opts), nil
+}
+
+func (r *genreRepo) GetGenre(id models.ID) (*models.Genre, error) {
+	var genre models.Genre
+	if err := r.collection.FindOne(r.ctx, bson.M{"_id": id}).Decode(&genre); err != nil {
+		return nil, mongoErr(err)
+	}
+	return &genre, nil
+}
+
+func (r *genreRepo) GetGenres(ids []models.ID) ([]*models.Genre, error) {
+	return r.find(bson.M{"_id": bson.M{"$in": ids}}, options.Find())
+}
+
+func (r *genreRepo) find(filter bson.M, opts *options.FindOptions) ([]*models.Genre, error) {
+	cur, err := r.collection.Find(r.ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	genres := []*models.Genre{}
+	if err := cur.All(r.ctx, &genres); err != nil {
+		return nil, err
+	}
+	return genres, nil
+}
+
+func (r *genreRepo) CreateGenre(actorID models.ID, req *models.CreateGenreRequest) (models.ID, error) {
+	genre := newGenre(actorID, req)
+	if _, err := r.collection.InsertOne(r.ctx, genre); err != nil {
+		return models.NilID, mongoErr(err)
+	}
+	return genre.ID, nil
+}
+
+func (r *genreRepo) UpdateGenre(actorID, id models.ID, version int64, req *models.UpdateGenreRequest) (*models.Genre, error) {
+	set := bson.M{}
+	if req.Name != nil {
+		set["name"] = *req.Name
+	}
+	update := mongoTouch(bson.M{"$set": set, "$inc": bson.M{"version": 1}}, actorID)
+
+	var genre models.Genre
+	filter := bson.M{"_id": id, "version": versionFilter(version)}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(r.ctx, filter, update, opts).Decode(&genre)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, mongoStale(r.ctx, r.collection, id)
+	}
+	if err != nil {
+		return nil, mongoErr(err)
+	}
+	return &genre, nil
+}
+
+func (r *genreRepo) DeleteGenre(id models.ID) error {
+	res, err := r.collection.DeleteOne(r.ctx, bson.M{"_id": id})
+	if err != nil {
+		return mongoErr(err)
+	}
+	if res.DeletedCount == 0 {
+		return errs.NotFound
+	}
+	return nil
+}
+
+func newGenre(actorID models.ID, req *models.CreateGenreRequest) *models.Genre {
+	return &models.Genre{
+		ID:      models.NewID(),
+		Name:    req.Name,
+		Version: 1,
+		Audit:   newAudit(actorID),
+	}
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"sync"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type memoryGenreRepo struct {
+	mu     sync.RWMutex
+	genres map[models.ID]*models.Genre
+}
+
+// NewMemoryGenreRepo returns a GenreRepo that keeps genres in process
+// memory. It is safe for concurrent use and behaves like the Mongo
+// implementation.
+func NewMemoryGenreRepo() GenreRepo {
+	return &memoryGenreRepo{
+		genres: make(map[models.ID]*models.Genre),
+	}
+}
+
+func (r *memoryGenreRepo) ListGenres(opts models.ListOptions) (models.Page[*models.Genre], error) {
+	r.mu.RLock()
+	genres := make([]*models.Genre, 0, len(r.genres))
+	for _, genre := range r.genres {
+		genres = append(genres, cloneGenre(genre))
+	}
+	r.mu.RUnlock()
+
+	return memoryList(genres, opts, models.GenreListFields)
+}
+
+func (r *memoryGenreRepo) GetGenre(id models.ID) (*models.Genre, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	genre, ok := r.genres[id]
+	if !ok {
+		return nil, errs.NotFound
+	}
+	return cloneGenre(genre), nil
+}
+
+func (r *memoryGenreRepo) GetGenres(ids []models.ID) ([]*models.Genre, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	genres := []*models.Genre{}
+	for _, id := range ids {
+		if genre, ok := r.genres[id]; ok {
+			genres = append(genres, cloneGenre(genre))
+		}
+	}
+	return genres, nil
+}
+
+func (r *memoryGenreRepo) CreateGenre(actorID models.ID, req *models.CreateGenreRequest) (models.ID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nameTaken(req.Name, models.NilID) {
+		return models.NilID, errs.AlreadyExists
+	}
+	genre := newGenre(actorID, req)
+	r.genres[genre.ID] = genre
+	return genre.ID, nil
+}
+
+func (r *memoryGenreRepo) UpdateGenre(actorID, id models.ID, version int64, req *models.UpdateGenreRequest) (*models.Genre, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	genre, ok := r.genres[id]
+	if !ok {
+		return nil, errs.NotFound
+	}
+	if genre.Version != version {
+		return nil, errs.Conflict
+	}
+
+	updated := cloneGenre(genre)
+	if req.Name != nil {
+		if r.nameTaken(*req.Name, id) {
+			return nil, errs.AlreadyExists
+		}
+		updated.Name = *req.Name
+	}
+	updated.Version++
+	updated.Audit = touch(updated.Audit, actorID)
+	r.genres[id] = updated
+	return cloneGenre(updated), nil
+}
+
+func (r *memoryGenreRepo) DeleteGenre(id models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.genres[id]; !ok {
+		return errs.NotFound
+	}
+	delete(r.genres, id)
+	return nil
+}
+
+// nameTaken reports whether a genre other than except is called name.
+func (r *memoryGenreRepo) nameTaken(name string, except models.ID) bool {
+	for id, genre := range r.genres {
+		if genre.Name == name && id != except {
+			return true
+		}
+	}
+	return false
+}
+
+func cloneGenre(genre *models.Genre) *models.Genre {
+	clone := *genre
+	clone.Audit = cloneAudit(genre.Audit)
+	return &clone
+}
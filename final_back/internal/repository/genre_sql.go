@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type sqlGenreRepo struct {
+	sqlConn
+}
+
+// NewSQLGenreRepo returns a GenreRepo backed by the genres table of a
+// Postgres or SQLite database opened with db.OpenSQL.
+func NewSQLGenreRepo(db *sql.DB) GenreRepo {
+	return &sqlGenreRepo{
+		sqlConn: sqlConn{db: db},
+	}
+}
+
+const selectGenre = `SELECT id, name, version, ` + auditColumns + ` FROM genres`
+
+func (r *sqlGenreRepo) ListGenres(opts models.ListOptions) (models.Page[*models.Genre], error) {
+	query, args, err := sqlList(selectGenre+` WHERE TRUE`, nil, opts, models.GenreListFields)
+	if err != nil {
+		return models.Page[*models.Genre]{}, err
+	}
+	genres, err := r.query(query, args...)
+	if err != nil {
+		return models.Page[*models.Genre]{}, err
+	}
+	return page(genres, opts), nil
+}
+
+func (r *sqlGenreRepo) GetGenre(id models.ID) (*models.Genre, error) {
+	genre, err := scanGenre(r.q().QueryRow(selectGenre+` WHERE id = $1`, id))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	return genre, nil
+}
+
+func (r *sqlGenreRepo) GetGenres(ids []models.ID) ([]*models.Genre, error) {
+	if len(ids) == 0 {
+		return []*models.Genre{}, nil
+	}
+	query, args := sqlIn(selectGenre+` WHERE id IN `, ids)
+	return r.query(query, args...)
+}
+
+func (r *sqlGenreRepo) query(query string, args ...any) ([]*models.Genre, error) {
+	rows, err := r.q().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	genres := []*models.Genre{}
+	for rows.Next() {
+		genre, err := scanGenre(rows)
+		if err != nil {
+			return nil, err
+		}
+		genres = append(genres, genre)
+	}
+	return genres, rows.Err()
+}
+
+func (r *sqlGenreRepo) CreateGenre(actorID models.ID, req *models.CreateGenreRequest) (models.ID, error) {
+	genre := newGenre(actorID, req)
+
+	args := append([]any{genre.ID, genre.Name, genre.Version}, auditArgs(genre.Audit)...)
+	_, err := r.q().Exec(`INSERT INTO genres (id, name, version, `+auditColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		args...)
+	if err != nil {
+		return models.NilID, sqlErr(err)
+	}
+	return genre.ID, nil
+}
+
+func (r *sqlGenreRepo) UpdateGenre(actorID, id models.ID, version int64, req *models.UpdateGenreRequest) (*models.Genre, error) {
+	genre, err := r.GetGenre(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		genre.Name = *req.Name
+	}
+	genre.Audit = touch(genre.Audit, actorID)
+
+	res, err := r.q().Exec(`UPDATE genres SET name = $2, updated_at = $4, updated_by = $5, version = version + 1 WHERE id = $1 AND version = $3`,
+		id, genre.Name, version, sqlTime(genre.UpdatedAt), sqlID(genre.UpdatedBy))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, errs.Conflict
+	}
+	genre.Version = version + 1
+	return genre, nil
+}
+
+func (r *sqlGenreRepo) DeleteGenre(id models.ID) error {
+	res, err := r.q().Exec(`DELETE FROM genres WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return errs.NotFound
+	}
+	return nil
+}
+
+func scanGenre(row rowScanner) (*models.Genre, error) {
+	var (
+		genre models.Genre
+		audit auditScan
+	)
+	dest := append([]any{&genre.ID, &genre.Name, &genre.Version}, audit.dest()...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	genre.Audit = audit.audit()
+	return &genre, nil
+}
@@ -0,0 +1,329 @@
+package repository
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// All three implementations order lists the same way: by the sort field,
+// with records that lack it first in ascending order, and then by ID. Pages
+// are fetched with room for one more item than the limit, which tells
+// whether there is a next page.
+
+// page cuts items down to a page of opts.Limit items and issues the cursor
+// of the page after it.
+func page[T models.Listable](items []T, opts models.ListOptions) models.Page[T] {
+	if opts.Limit == 0 || len(items) <= opts.Limit {
+		return models.Page[T]{Items: items}
+	}
+	items = items[:opts.Limit]
+	return models.Page[T]{Items: items, NextCursor: opts.NextCursor(items[len(items)-1])}
+}
+
+var mongoOps = map[models.Op]string{
+	models.OpEq:  "$eq",
+	models.OpGt:  "$gt",
+	models.OpGte: "$gte",
+	models.OpLt:  "$lt",
+	models.OpLte: "$lte",
+	models.OpIn:  "$in",
+}
+
+func mongoValue(v any) any {
+	switch v := v.(type) {
+	case time.Time:
+		return primitive.NewDateTimeFromTime(v)
+	case []any:
+		values := make(bson.A, len(v))
+		for i, value := range v {
+			values[i] = mongoValue(value)
+		}
+		return values
+	}
+	return v
+}
+
+// mongoList adds the filters and cursor of opts to filter and returns the
+// find options that sort and limit the result. List fields are stored under
+// their JSON names.
+func mongoList(filter bson.M, opts models.ListOptions, fields models.ListFields) (bson.M, *options.FindOptions, error) {
+	cursor, err := opts.ParseCursor(fields)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conds := bson.A{}
+	for _, f := range opts.Filters {
+		conds = append(conds, bson.M{f.Field: bson.M{mongoOps[f.Op]: mongoValue(f.Value)}})
+	}
+	if opts.IDs != nil {
+		conds = append(conds, bson.M{"_id": bson.M{"$in": opts.IDs}})
+	}
+
+	field, desc := opts.SortField()
+	next, dir := "$gt", 1
+	if desc {
+		next, dir = "$lt", -1
+	}
+	if cursor != nil {
+		conds = append(conds, mongoAfter(field, next, desc, cursor))
+	}
+	if len(conds) > 0 {
+		filter["$and"] = conds
+	}
+
+	sort := bson.D{}
+	if field != "" {
+		sort = append(sort, bson.E{Key: field, Value: dir})
+	}
+	sort = append(sort, bson.E{Key: "_id", Value: dir})
+	findOpts := options.Find().SetSort(sort)
+	if opts.Limit > 0 {
+		findOpts.SetLimit(int64(opts.Limit) + 1)
+	}
+	return filter, findOpts, nil
+}
+
+// mongoAfter matches the records that come after cursor in the sort order.
+func mongoAfter(field, next string, desc bool, cursor *models.Cursor) bson.M {
+	afterID := bson.M{"_id": bson.M{next: cursor.ID}}
+	switch {
+	case field == "":
+		return afterID
+	case cursor.Value == nil && desc:
+		return bson.M{field: nil, "_id": bson.M{next: cursor.ID}}
+	case cursor.Value == nil:
+		return bson.M{"$or": bson.A{bson.M{field: bson.M{"$ne": nil}}, afterID}}
+	}
+
+	v := mongoValue(cursor.Value)
+	or := bson.A{
+		bson.M{field: bson.M{next: v}},
+		bson.M{field: v, "_id": bson.M{next: cursor.ID}},
+	}
+	if desc {
+		or = append(or, bson.M{field: nil})
+	}
+	return bson.M{"$or": or}
+}
+
+// listIndexes returns the indexes that serve pages sorted by each of fields
+// among the records matching an equality prefix, such as a movie's reviews.
+func listIndexes(prefix bson.D, fields ...string) []mongo.IndexModel {
+	indexes := []mongo.IndexModel{}
+	if len(prefix) > 0 {
+		indexes = append(indexes, mongo.IndexModel{Keys: append(slices.Clone(prefix), bson.E{Key: "_id", Value: 1})})
+	}
+	for _, field := range fields {
+		keys := append(slices.Clone(prefix), bson.E{Key: field, Value: 1}, bson.E{Key: "_id", Value: 1})
+		indexes = append(indexes, mongo.IndexModel{Keys: keys})
+	}
+	return indexes
+}
+
+// sqlColumn returns the column a list field is stored in.
+func sqlColumn(field string) string {
+	var b strings.Builder
+	for _, r := range field {
+		if unicode.IsUpper(r) {
+			b.WriteByte('_')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func sqlValue(v any) any {
+	if t, ok := v.(time.Time); ok {
+		return t.UTC()
+	}
+	return v
+}
+
+// sqlList appends the filters, cursor, ordering and limit of opts to a query
+// ending in a WHERE clause that already uses the placeholders for args.
+func sqlList(query string, args []any, opts models.ListOptions, fields models.ListFields) (string, []any, error) {
+	cursor, err := opts.ParseCursor(fields)
+	if err != nil {
+		return "", nil, err
+	}
+	arg := func(v any) string {
+		args = append(args, sqlValue(v))
+		return `$` + strconv.Itoa(len(args))
+	}
+
+	for _, f := range opts.Filters {
+		if f.Op != models.OpIn {
+			query += ` AND ` + sqlColumn(f.Field) + ` ` + string(f.Op) + ` ` + arg(f.Value)
+			continue
+		}
+		values := f.Value.([]any)
+		if len(values) == 0 {
+			query += ` AND FALSE`
+			continue
+		}
+		placeholders := make([]string, len(values))
+		for i, v := range values {
+			placeholders[i] = arg(v)
+		}
+		query += ` AND ` + sqlColumn(f.Field) + ` IN (` + strings.Join(placeholders, `, `) + `)`
+	}
+	if opts.IDs != nil {
+		if len(opts.IDs) == 0 {
+			query += ` AND FALSE`
+		} else {
+			placeholders := make([]string, len(opts.IDs))
+			for i, id := range opts.IDs {
+				placeholders[i] = arg(id)
+			}
+			query += ` AND id IN (` + strings.Join(placeholders, `, `) + `)`
+		}
+	}
+
+	field, desc := opts.SortField()
+	column := sqlColumn(field)
+	next, dir, nulls := ">", "ASC", "NULLS FIRST"
+	if desc {
+		next, dir, nulls = "<", "DESC", "NULLS LAST"
+	}
+	if cursor != nil {
+		id := arg(cursor.ID)
+		switch {
+		case field == "":
+			query += ` AND id ` + next + ` ` + id
+		case cursor.Value == nil && desc:
+			query += ` AND ` + column + ` IS NULL AND id < ` + id
+		case cursor.Value == nil:
+			query += ` AND (` + column + ` IS NOT NULL OR id > ` + id + `)`
+		default:
+			v := arg(cursor.Value)
+			cond := column + ` ` + next + ` ` + v + ` OR (` + column + ` = ` + v + ` AND id ` + next + ` ` + id + `)`
+			if desc {
+				cond += ` OR ` + column + ` IS NULL`
+			}
+			query += ` AND (` + cond + `)`
+		}
+	}
+
+	if field != "" {
+		query += ` ORDER BY ` + column + ` ` + dir + ` ` + nulls + `, id ` + dir
+	} else {
+		query += ` ORDER BY id ` + dir
+	}
+	if opts.Limit > 0 {
+		query += ` LIMIT ` + arg(opts.Limit+1)
+	}
+	return query, args, nil
+}
+
+// memoryList filters, orders and pages records the way mongoList and
+// sqlList do.
+func memoryList[T models.Listable](records []T, opts models.ListOptions, fields models.ListFields) (models.Page[T], error) {
+	cursor, err := opts.ParseCursor(fields)
+	if err != nil {
+		return models.Page[T]{}, err
+	}
+
+	field, desc := opts.SortField()
+	order := func(aValue any, aID models.ID, bValue any, bID models.ID) int {
+		o := compareValues(aValue, bValue)
+		if o == 0 {
+			o = aID.Compare(bID)
+		}
+		if desc {
+			return -o
+		}
+		return o
+	}
+
+	listed := []T{}
+	for _, record := range records {
+		if !matchesFilters(record, opts.Filters) {
+			continue
+		}
+		if opts.IDs != nil && !slices.Contains(opts.IDs, record.ListID()) {
+			continue
+		}
+		if cursor != nil && order(record.ListValue(field), record.ListID(), cursor.Value, cursor.ID) <= 0 {
+			continue
+		}
+		listed = append(listed, record)
+	}
+	slices.SortFunc(listed, func(a, b T) int {
+		return order(a.ListValue(field), a.ListID(), b.ListValue(field), b.ListID())
+	})
+	return page(listed, opts), nil
+}
+
+func matchesFilters(record models.Listable, filters []models.Filter) bool {
+	for _, f := range filters {
+		v := record.ListValue(f.Field)
+		if v == nil {
+			return false
+		}
+
+		if f.Op == models.OpIn {
+			if !slices.ContainsFunc(f.Value.([]any), func(value any) bool { return compareValues(v, value) == 0 }) {
+				return false
+			}
+			continue
+		}
+
+		c := compareValues(v, f.Value)
+		var ok bool
+		switch f.Op {
+		case models.OpEq:
+			ok = c == 0
+		case models.OpGt:
+			ok = c > 0
+		case models.OpGte:
+			ok = c >= 0
+		case models.OpLt:
+			ok = c < 0
+		case models.OpLte:
+			ok = c <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// compareValues compares two values of the same list field, ordering
+// missing values first.
+func compareValues(a, b any) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	switch a := a.(type) {
+	case int64:
+		return cmp.Compare(a, b.(int64))
+	case float64:
+		return cmp.Compare(a, b.(float64))
+	case string:
+		return strings.Compare(a, b.(string))
+	case time.Time:
+		return a.Compare(b.(time.Time))
+	case models.ID:
+		return a.Compare(b.(models.ID))
+	}
+	return 0
+}
@@ -0,0 +1,105 @@
+package repository
+
+import "testing"
+
+func TestMemoryUserRepo(t *testing.T) {
+	testUserRepo(t, func(t *testing.T) UserRepo {
+		return NewMemoryUserRepo()
+	})
+}
+
+func TestMemoryMovieRepo(t *testing.T) {
+	testMovieRepo(t, func(t *testing.T) MovieRepo {
+		return NewMemoryMovieRepo()
+	})
+}
+
+func TestMemoryReviewRepo(t *testing.T) {
+	testReviewRepo(t, func(t *testing.T) ReviewRepo {
+		return NewMemoryReviewRepo()
+	})
+}
+
+func TestMemoryPersonRepo(t *testing.T) {
+	testPersonRepo(t, func(t *testing.T) PersonRepo {
+		return NewMemoryPersonRepo()
+	})
+}
+
+func TestMemoryGenreRepo(t *testing.T) {
+	testGenreRepo(t, func(t *testing.T) GenreRepo {
+		return NewMemoryGenreRepo()
+	})
+}
+
+func TestMemoryCreditRepo(t *testing.T) {
+	testCreditRepo(t, func(t *testing.T) CreditRepo {
+		return NewMemoryCreditRepo()
+	})
+}
+
+func TestMemoryRevisionRepo(t *testing.T) {
+	testRevisionRepo(t, func(t *testing.T) RevisionRepo {
+		return NewMemoryRevisionRepo()
+	})
+}
+
+func TestMemorySuggestionRepo(t *testing.T) {
+	testSuggestionRepo(t, func(t *testing.T) SuggestionRepo {
+		return NewMemorySuggestionRepo()
+	})
+}
+
+func TestMemoryTranslationRepo(t *testing.T) {
+	testTranslationRepo(t, func(t *testing.T) TranslationRepo {
+		return NewMemoryTranslationRepo()
+	})
+}
+
+func TestMemoryTagRepo(t *testing.T) {
+	testTagRepo(t, func(t *testing.T) TagRepo {
+		return NewMemoryTagRepo()
+	})
+}
+
+func TestMemoryReleaseRepo(t *testing.T) {
+	testReleaseRepo(t, func(t *testing.T) ReleaseRepo {
+		return NewMemoryReleaseRepo()
+	})
+}
+
+func TestMemoryWatchlistRepo(t *testing.T) {
+	testWatchlistRepo(t, func(t *testing.T) WatchlistRepo {
+		return NewMemoryWatchlistRepo()
+	})
+}
+
+func TestMemoryUnitOfWork(t *testing.T) {
+	testUnitOfWork(t, func(t *testing.T) (Repos, UnitOfWork) {
+		repos := Repos{
+			Users:        NewMemoryUserRepo(),
+			Movies:       NewMemoryMovieRepo(),
+			Reviews:      NewMemoryReviewRepo(),
+			Credits:      NewMemoryCreditRepo(),
+			Revisions:    NewMemoryRevisionRepo(),
+			Translations: NewMemoryTranslationRepo(),
+			Tags:         NewMemoryTagRepo(),
+			Releases:     NewMemoryReleaseRepo(),
+			Watchlists:   NewMemoryWatchlistRepo(),
+		}
+		return repos, NewMemoryUnitOfWork(repos)
+	})
+}
+
+func TestMemorySearchIndex(t *testing.T) {
+	testSearchIndex(t, func(t *testing.T) (MovieRepo, SearchIndex) {
+		return NewMemoryMovieRepo(), NewMemorySearchIndex()
+	})
+}
+
+func TestMemoryFacetCounter(t *testing.T) {
+	testFacetCounter(t, func(t *testing.T) (MovieRepo, TagRepo, FacetCounter) {
+		movies, tags := NewMemoryMovieRepo(), NewMemoryTagRepo()
+		return movies, tags, NewMemoryFacetCounter(movies, tags)
+	})
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// newTestMongoDatabase connects to the server in TEST_MONGO_URI and returns a
+// throwaway database that is dropped when the test finishes. The test is
+// skipped when the variable is not set.
+func newTestMongoDatabase(t *testing.T) *mongo.Database {
+	t.Helper()
+
+	uri := os.Getenv("TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("TEST_MONGO_URI is not set")
+	}
+
+	client, err := mongo.Connect(context.TODO(), options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("couldn't connect to mongodb: %v", err)
+	}
+
+	db := client.Database("conformance_" + models.NewID().String())
+	t.Cleanup(func() {
+		_ = db.Drop(context.TODO())
+		_ = client.Disconnect(context.TODO())
+	})
+	return db
+}
+
+func TestMongoUserRepo(t *testing.T) {
+	testUserRepo(t, func(t *testing.T) UserRepo {
+		return NewUserRepo(zap.NewNop(), map[string]int{}, newTestMongoDatabase(t))
+	})
+}
+
+func TestMongoMovieRepo(t *testing.T) {
+	testMovieRepo(t, func(t *testing.T) MovieRepo {
+		return NewMovieRepo(zap.NewNop(), map[string]int{}, newTestMongoDatabase(t))
+	})
+}
+
+func TestMongoReviewRepo(t *testing.T) {
+	testReviewRepo(t, func(t *testing.T) ReviewRepo {
+		return NewReviewRepo(zap.NewNop(), map[string]int{}, newTestMongoDatabase(t))
+	})
+}
+
+func TestMongoPersonRepo(t *testing.T) {
+	testPersonRepo(t, func(t *testing.T) PersonRepo {
+		return NewPersonRepo(zap.NewNop(), map[string]int{}, newTestMongoDatabase(t))
+	})
+}
+
+func TestMongoGenreRepo(t *testing.T) {
+	testGenreRepo(t, func(t *testing.T) GenreRepo {
+		return NewGenreRepo(zap.NewNop(), map[string]int{}, newTestMongoDatabase(t))
+	})
+}
+
+func TestMongoCreditRepo(t *testing.T) {
+	testCreditRepo(t, func(t *testing.T) CreditRepo {
+		return NewCreditRepo(zap.NewNop(), map[string]int{}, newTestMongoDatabase(t))
+	})
+}
+
+func TestMongoRevisionRepo(t *testing.T) {
+	testRevisionRepo(t, func(t *testing.T) RevisionRepo {
+		return NewRevisionRepo(zap.NewNop(), map[string]int{}, newTestMongoDatabase(t))
+	})
+}
+
+func TestMongoSuggestionRepo(t *testing.T) {
+	testSuggestionRepo(t, func(t *testing.T) SuggestionRepo {
+		return NewSuggestionRepo(zap.NewNop(), map[string]int{}, newTestMongoDatabase(t))
+	})
+}
+
+func TestMongoTranslationRepo(t *testing.T) {
+	testTranslationRepo(t, func(t *testing.T) TranslationRepo {
+		return NewTranslationRepo(zap.NewNop(), map[string]int{}, newTestMongoDatabase(t))
+	})
+}
+
+func TestMongoTagRepo(t *testing.T) {
+	testTagRepo(t, func(t *testing.T) TagRepo {
+		return NewTagRepo(zap.NewNop(), map[string]int{}, newTestMongoDatabase(t))
+	})
+}
+
+func TestMongoReleaseRepo(t *testing.T) {
+	testReleaseRepo(t, func(t *testing.T) ReleaseRepo {
+		return NewReleaseRepo(zap.NewNop(), map[string]int{}, newTestMongoDatabase(t))
+	})
+}
+
+func TestMongoWatchlistRepo(t *testing.T) {
+	testWatchlistRepo(t, func(t *testing.T) WatchlistRepo {
+		return NewWatchlistRepo(zap.NewNop(), map[string]int{}, newTestMongoDatabase(t))
+	})
+}
+
+func TestMongoUnitOfWork(t *testing.T) {
+	testUnitOfWork(t, func(t *testing.T) (Repos, UnitOfWork) {
+		db := newTestMongoDatabase(t)
+		repos := Repos{
+			Users:        NewUserRepo(zap.NewNop(), map[string]int{}, db),
+			Movies:       NewMovieRepo(zap.NewNop(), map[string]int{}, db),
+			Reviews:      NewReviewRepo(zap.NewNop(), map[string]int{}, db),
+			Credits:      NewCreditRepo(zap.NewNop(), map[string]int{}, db),
+			Revisions:    NewRevisionRepo(zap.NewNop(), map[string]int{}, db),
+			Translations: NewTranslationRepo(zap.NewNop(), map[string]int{}, db),
+			Tags:         NewTagRepo(zap.NewNop(), map[string]int{}, db),
+			Releases:     NewReleaseRepo(zap.NewNop(), map[string]int{}, db),
+			Watchlists:   NewWatchlistRepo(zap.NewNop(), map[string]int{}, db),
+		}
+		return repos, NewMongoUnitOfWork(db)
+	})
+}
+
+func TestMongoFacetCounter(t *testing.T) {
+	testFacetCounter(t, func(t *testing.T) (MovieRepo, TagRepo, FacetCounter) {
+		db := newTestMongoDatabase(t)
+		return NewMovieRepo(zap.NewNop(), map[string]int{}, db), NewTagRepo(zap.NewNop(), map[string]int{}, db), NewMongoFacetCounter(db)
+	})
+}
+
+func TestMongoSearchIndex(t *testing.T) {
+	testSearchIndex(t, func(t *testing.T) (MovieRepo, SearchIndex) {
+		db := newTestMongoDatabase(t)
+		return NewMovieRepo(zap.NewNop(), map[string]int{}, db), NewMongoSearchIndex(zap.NewNop(), db)
+	})
+}
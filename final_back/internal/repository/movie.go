@@ -2,28 +2,44 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"slices"
 
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
 	"github.com/kakimnsnv/ios_final_back/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 )
 
 type MovieRepo interface {
-	ListMovies() ([]*models.Movie, error)
-	GetMovie(id *primitive.ObjectID) (*models.Movie, error)
-	CreateMovie(movie *models.CreateMovieRequest) (*primitive.ObjectID, error)
-	UpdateMovie(id *primitive.ObjectID, movie *models.UpdateMovieRequest) (*models.Movie, error)
-	DeleteMovie(id *primitive.ObjectID) error
+	ListMovies(opts models.ListOptions) (models.Page[*models.Movie], error)
+	GetMovie(id models.ID) (*models.Movie, error)
+	// GetMovieByExternalID finds a live movie by the ID another catalogue
+	// knows it by. External IDs are unique among all movies, trashed or not.
+	GetMovieByExternalID(externalID string) (*models.Movie, error)
+	CreateMovie(actorID models.ID, movie *models.CreateMovieRequest) (models.ID, error)
+	UpdateMovie(actorID, id models.ID, version int64, movie *models.UpdateMovieRequest) (*models.Movie, error)
+	DeleteMovie(actorID, id models.ID) error
+	ListDeletedMovies() ([]*models.Movie, error)
+	RestoreMovie(actorID, id models.ID) (*models.Movie, error)
+	PurgeMovie(id models.ID) error
+	// UpdateRatings moves one review rating out of and one into the rating
+	// aggregate of a movie, live or trashed. Either rating may be 0 for none.
+	// Updating the ratings of a movie that does not exist is not an error.
+	UpdateRatings(movieID models.ID, remove, add int) error
 }
 
+const moviesCollection = "movies"
+
 type movieRepo struct {
+	ctx        context.Context
 	collection *mongo.Collection
 }
 
 func NewMovieRepo(log *zap.Logger, collNames map[string]int, db *mongo.Database) MovieRepo {
-	var collectionName = "movies"
+	var collectionName = moviesCollection
 
 	if _, exists := collNames[collectionName]; !exists {
 		if err := db.CreateCollection(context.TODO(), collectionName); err != nil {
@@ -31,55 +47,253 @@ func NewMovieRepo(log *zap.Logger, collNames map[string]int, db *mongo.Database)
 		}
 	}
 
+	indexes := append(listIndexes(nil, "title", "year", "rating", "ratingMean", "ratingCount", "runtime", models.SortCreatedAt, models.SortUpdatedAt),
+		mongo.IndexModel{Keys: bson.D{{Key: "directorId", Value: 1}}},
+		mongo.IndexModel{Keys: bson.D{{Key: "genreId", Value: 1}}},
+		mongo.IndexModel{Keys: bson.D{{Key: "originalLanguage", Value: 1}}},
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "externalId", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"externalId": bson.M{"$type": "string"}}),
+		},
+	)
+	if _, err := db.Collection(collectionName).Indexes().CreateMany(context.TODO(), indexes); err != nil {
+		log.Fatal("couldn't initialize repository: ", zap.Error(err))
+	}
+
+	if err := backfillRatings(context.TODO(), db); err != nil {
+		log.Fatal("couldn't initialize repository: ", zap.Error(err))
+	}
+	if err := backfillListFields(context.TODO(), db.Collection(collectionName)); err != nil {
+		log.Fatal("couldn't initialize repository: ", zap.Error(err))
+	}
+
 	return &movieRepo{
+		ctx:        context.TODO(),
 		collection: db.Collection(collectionName),
 	}
 }
 
-func (r *movieRepo) ListMovies() ([]*models.Movie, error) {
-	cur, err := r.collection.Find(context.TODO(), bson.M{})
+// backfillListFields stores the zero value of the list fields that movies
+// written before they were always stored left out.
+func backfillListFields(ctx context.Context, movies *mongo.Collection) error {
+	for field, zero := range map[string]any{"title": "", "year": 0, "runtime": 0, "originalLanguage": ""} {
+		filter := bson.M{field: bson.M{"$exists": false}}
+		if _, err := movies.UpdateMany(ctx, filter, bson.M{"$set": bson.M{field: zero}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *movieRepo) ListMovies(opts models.ListOptions) (models.Page[*models.Movie], error) {
+	return r.find(live(bson.M{}), opts)
+}
+
+func (r *movieRepo) ListDeletedMovies() ([]*models.Movie, error) {
+	movies, err := r.find(trashed(bson.M{}), models.ListOptions{})
+	return movies.Items, err
+}
+
+func (r *movieRepo) find(filter bson.M, opts models.ListOptions) (models.Page[*models.Movie], error) {
+	filter, findOpts, err := mongoList(filter, opts, models.MovieListFields)
+	if err != nil {
+		return models.Page[*models.Movie]{}, err
+	}
+	cur, err := r.collection.Find(r.ctx, filter, findOpts)
 	if err != nil {
-		return nil, err
+		return models.Page[*models.Movie]{}, err
 	}
 
-	var movies []*models.Movie
-	if err := cur.All(context.TODO(), &movies); err != nil {
-		return nil, err
+	movies := []*models.Movie{}
+	if err := cur.All(r.ctx, &movies); err != nil {
+		return models.Page[*models.Movie]{}, err
 	}
-	return movies, nil
+	return page(movies, opts), nil
 }
 
-func (r *movieRepo) GetMovie(id *primitive.ObjectID) (*models.Movie, error) {
+func (r *movieRepo) GetMovie(id models.ID) (*models.Movie, error) {
 	var movie models.Movie
-	err := r.collection.FindOne(context.TODO(), bson.M{"_id": id}).Decode(&movie)
+	err := r.collection.FindOne(r.ctx, live(bson.M{"_id": id})).Decode(&movie)
 	if err != nil {
-		return nil, err
+		return nil, mongoErr(err)
 	}
 	return &movie, nil
 }
 
-func (r *movieRepo) CreateMovie(movie *models.CreateMovieRequest) (*primitive.ObjectID, error) {
-	res, err := r.collection.InsertOne(context.TODO(), movie)
+func (r *movieRepo) GetMovieByExternalID(externalID string) (*models.Movie, error) {
+	var movie models.Movie
+	err := r.collection.FindOne(r.ctx, live(bson.M{"externalId": externalID})).Decode(&movie)
 	if err != nil {
-		return nil, err
+		return nil, mongoErr(err)
 	}
+	return &movie, nil
+}
 
-	return res.InsertedID.(*primitive.ObjectID), nil
+func (r *movieRepo) CreateMovie(actorID models.ID, movie *models.CreateMovieRequest) (models.ID, error) {
+	newMovie := newMovie(actorID, movie)
+	if _, err := r.collection.InsertOne(r.ctx, storedMovie{Movie: *newMovie, TitleTerms: searchTokens(newMovie.Title)}); err != nil {
+		return models.NilID, mongoErr(err)
+	}
+	return newMovie.ID, nil
 }
 
-func (r *movieRepo) UpdateMovie(id *primitive.ObjectID, movie *models.UpdateMovieRequest) (*models.Movie, error) {
+// UpdateMovie applies movie only if the stored movie is still at version, and
+// returns errs.Conflict otherwise. Every successful update bumps the version.
+func (r *movieRepo) UpdateMovie(actorID, id models.ID, version int64, movie *models.UpdateMovieRequest) (*models.Movie, error) {
+	update := mongoTouch(bson.M{"$set": movieUpdateFields(movie), "$inc": bson.M{"version": 1}}, actorID)
+
 	var updatedMovie models.Movie
-	err := r.collection.FindOneAndUpdate(context.TODO(), bson.M{"_id": id}, bson.M{"$set": movie}).Decode(&updatedMovie)
+	filter := live(bson.M{"_id": id, "version": versionFilter(version)})
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(r.ctx, filter, update, opts).Decode(&updatedMovie)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, mongoStale(r.ctx, r.collection, id)
+	}
 	if err != nil {
-		return nil, err
+		return nil, mongoErr(err)
 	}
 	return &updatedMovie, nil
 }
 
-func (r *movieRepo) DeleteMovie(id *primitive.ObjectID) error {
-	_, err := r.collection.DeleteOne(context.TODO(), bson.M{"_id": id})
+// DeleteMovie moves the movie to the trash. Its reviews are left alone so
+// that restoring the movie brings them back too.
+func (r *movieRepo) DeleteMovie(actorID, id models.ID) error {
+	update := mongoTouch(bson.M{"$set": bson.M{"deleted": timestamp()}, "$inc": bson.M{"version": 1}}, actorID)
+	res, err := r.collection.UpdateOne(r.ctx, live(bson.M{"_id": id}), update)
 	if err != nil {
-		return err
+		return mongoErr(err)
+	}
+	if res.MatchedCount == 0 {
+		return errs.NotFound
 	}
 	return nil
 }
+
+func (r *movieRepo) RestoreMovie(actorID, id models.ID) (*models.Movie, error) {
+	var movie models.Movie
+	update := mongoTouch(bson.M{"$unset": bson.M{"deleted": ""}, "$inc": bson.M{"version": 1}}, actorID)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(r.ctx, trashed(bson.M{"_id": id}), update, opts).Decode(&movie)
+	if err != nil {
+		return nil, mongoErr(err)
+	}
+	return &movie, nil
+}
+
+// PurgeMovie removes a trashed movie for good.
+func (r *movieRepo) PurgeMovie(id models.ID) error {
+	res, err := r.collection.DeleteOne(r.ctx, trashed(bson.M{"_id": id}))
+	if err != nil {
+		return mongoErr(err)
+	}
+	if res.DeletedCount == 0 {
+		return errs.NotFound
+	}
+	return nil
+}
+
+func (r *movieRepo) UpdateRatings(movieID models.ID, remove, add int) error {
+	if !models.ValidRating(remove) && !models.ValidRating(add) {
+		return nil
+	}
+	_, err := r.collection.UpdateOne(r.ctx, bson.M{"_id": movieID}, mongoRatingsUpdate(remove, add))
+	return mongoErr(err)
+}
+
+func newMovie(actorID models.ID, req *models.CreateMovieRequest) *models.Movie {
+	return &models.Movie{
+		ID:         models.NewID(),
+		ExternalID: req.ExternalID,
+		Title:      req.Title,
+		Year:       req.Year,
+		DirectorID: req.DirectorID,
+		GenreID:    req.GenreID,
+		Ratings:    models.NewRatingStats(),
+		ImageURL:   req.ImageURL,
+		Version:    1,
+
+		Runtime:          req.Runtime,
+		OriginalLanguage: req.OriginalLanguage,
+		Synopsis:         req.Synopsis,
+		Audit:            newAudit(actorID),
+	}
+}
+
+// storedMovie is a movie as the movies collection keeps it, with the words of
+// its title folded the way searchTokens folds them so that title prefixes can
+// be looked up in an index.
+type storedMovie struct {
+	models.Movie `bson:",inline"`
+	TitleTerms   []string `bson:"titleTerms"`
+}
+
+// movieUpdateFields returns the stored field names of every field set in req,
+// so that a partial update only touches what the client sent.
+func movieUpdateFields(req *models.UpdateMovieRequest) bson.M {
+	fields := bson.M{}
+	if req.Title != nil {
+		fields["title"] = *req.Title
+		fields["titleTerms"] = searchTokens(*req.Title)
+	}
+	if req.Year != nil {
+		fields["year"] = *req.Year
+	}
+	if req.DirectorID != nil {
+		fields["directorId"] = req.DirectorID
+	}
+	if req.GenreID != nil {
+		fields["genreId"] = req.GenreID
+	}
+	if req.ImageURL != nil {
+		fields["imageURL"] = *req.ImageURL
+	}
+	if req.Poster != nil {
+		fields["poster"] = req.Poster
+	}
+	if req.Runtime != nil {
+		fields["runtime"] = *req.Runtime
+	}
+	if req.OriginalLanguage != nil {
+		fields["originalLanguage"] = *req.OriginalLanguage
+	}
+	if req.Synopsis != nil {
+		fields["synopsis"] = *req.Synopsis
+	}
+	return fields
+}
+
+// applyMovieUpdate is the in-process equivalent of movieUpdateFields.
+func applyMovieUpdate(movie *models.Movie, req *models.UpdateMovieRequest) *models.Movie {
+	if req.Title != nil {
+		movie.Title = *req.Title
+	}
+	if req.Year != nil {
+		movie.Year = *req.Year
+	}
+	if req.DirectorID != nil {
+		directorID := *req.DirectorID
+		movie.DirectorID = &directorID
+	}
+	if req.GenreID != nil {
+		genreID := *req.GenreID
+		movie.GenreID = &genreID
+	}
+	if req.ImageURL != nil {
+		movie.ImageURL = *req.ImageURL
+	}
+	if req.Poster != nil {
+		poster := *req.Poster
+		poster.Variants = slices.Clone(poster.Variants)
+		movie.Poster = &poster
+	}
+	if req.Runtime != nil {
+		movie.Runtime = *req.Runtime
+	}
+	if req.OriginalLanguage != nil {
+		movie.OriginalLanguage = *req.OriginalLanguage
+	}
+	if req.Synopsis != nil {
+		movie.Synopsis = *req.Synopsis
+	}
+	return movie
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type memoryMovieRepo struct {
+	mu     sync.RWMutex
+	movies map[models.ID]*models.Movie
+}
+
+// NewMemoryMovieRepo returns a MovieRepo that keeps movies in process memory.
+// It is safe for concurrent use and behaves like the Mongo implementation.
+func NewMemoryMovieRepo() MovieRepo {
+	return &memoryMovieRepo{
+		movies: make(map[models.ID]*models.Movie),
+	}
+}
+
+func (r *memoryMovieRepo) ListMovies(opts models.ListOptions) (models.Page[*models.Movie], error) {
+	movies := r.filter(func(movie *models.Movie) bool {
+		return movie.Deleted == nil
+	})
+	return memoryList(movies, opts, models.MovieListFields)
+}
+
+func (r *memoryMovieRepo) ListDeletedMovies() ([]*models.Movie, error) {
+	return r.filter(func(movie *models.Movie) bool {
+		return movie.Deleted != nil
+	}), nil
+}
+
+func (r *memoryMovieRepo) filter(match func(movie *models.Movie) bool) []*models.Movie {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	movies := []*models.Movie{}
+	for _, movie := range r.movies {
+		if match(movie) {
+			movies = append(movies, cloneMovie(movie))
+		}
+	}
+	sort.Slice(movies, func(i, j int) bool {
+		return movies[i].ID.Compare(movies[j].ID) < 0
+	})
+	return movies
+}
+
+func (r *memoryMovieRepo) GetMovie(id models.ID) (*models.Movie, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	movie, ok := r.movies[id]
+	if !ok || movie.Deleted != nil {
+		return nil, errs.NotFound
+	}
+	return cloneMovie(movie), nil
+}
+
+func (r *memoryMovieRepo) GetMovieByExternalID(externalID string) (*models.Movie, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, movie := range r.movies {
+		if movie.ExternalID == externalID && movie.Deleted == nil {
+			return cloneMovie(movie), nil
+		}
+	}
+	return nil, errs.NotFound
+}
+
+func (r *memoryMovieRepo) CreateMovie(actorID models.ID, req *models.CreateMovieRequest) (models.ID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if req.ExternalID != "" {
+		for _, movie := range r.movies {
+			if movie.ExternalID == req.ExternalID {
+				return models.NilID, errs.AlreadyExists
+			}
+		}
+	}
+
+	movie := cloneMovie(newMovie(actorID, req))
+	r.movies[movie.ID] = movie
+	return movie.ID, nil
+}
+
+func (r *memoryMovieRepo) UpdateMovie(actorID, id models.ID, version int64, req *models.UpdateMovieRequest) (*models.Movie, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	movie, ok := r.movies[id]
+	if !ok || movie.Deleted != nil {
+		return nil, errs.NotFound
+	}
+	if movie.Version != version {
+		return nil, errs.Conflict
+	}
+
+	updated := applyMovieUpdate(cloneMovie(movie), req)
+	updated.Version++
+	updated.Audit = touch(updated.Audit, actorID)
+	r.movies[id] = updated
+	return cloneMovie(updated), nil
+}
+
+func (r *memoryMovieRepo) DeleteMovie(actorID, id models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	movie, ok := r.movies[id]
+	if !ok || movie.Deleted != nil {
+		return errs.NotFound
+	}
+
+	deleted := cloneMovie(movie)
+	now := timestamp()
+	deleted.Deleted = &now
+	deleted.Version++
+	deleted.Audit = touch(deleted.Audit, actorID)
+	r.movies[id] = deleted
+	return nil
+}
+
+func (r *memoryMovieRepo) RestoreMovie(actorID, id models.ID) (*models.Movie, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	movie, ok := r.movies[id]
+	if !ok || movie.Deleted == nil {
+		return nil, errs.NotFound
+	}
+
+	restored := cloneMovie(movie)
+	restored.Deleted = nil
+	restored.Version++
+	restored.Audit = touch(restored.Audit, actorID)
+	r.movies[id] = restored
+	return cloneMovie(restored), nil
+}
+
+func (r *memoryMovieRepo) PurgeMovie(id models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	movie, ok := r.movies[id]
+	if !ok || movie.Deleted == nil {
+		return errs.NotFound
+	}
+	delete(r.movies, id)
+	return nil
+}
+
+func (r *memoryMovieRepo) UpdateRatings(movieID models.ID, remove, add int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	movie, ok := r.movies[movieID]
+	if !ok {
+		return nil
+	}
+	updated := cloneMovie(movie)
+	updated.Ratings = updated.Ratings.Adjust(remove, add)
+	r.movies[movieID] = updated
+	return nil
+}
+
+func cloneMovie(movie *models.Movie) *models.Movie {
+	clone := *movie
+	if movie.DirectorID != nil {
+		directorID := *movie.DirectorID
+		clone.DirectorID = &directorID
+	}
+	if movie.GenreID != nil {
+		genreID := *movie.GenreID
+		clone.GenreID = &genreID
+	}
+	if movie.Poster != nil {
+		poster := *movie.Poster
+		poster.Variants = slices.Clone(poster.Variants)
+		clone.Poster = &poster
+	}
+	if movie.Deleted != nil {
+		deleted := *movie.Deleted
+		clone.Deleted = &deleted
+	}
+	clone.Audit = cloneAudit(movie.Audit)
+	return &clone
+}
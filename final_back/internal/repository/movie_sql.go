@@ -0,0 +1,256 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type sqlMovieRepo struct {
+	sqlConn
+}
+
+// NewSQLMovieRepo returns a MovieRepo backed by the movies table of a
+// Postgres or SQLite database opened with db.OpenSQL.
+func NewSQLMovieRepo(db *sql.DB) MovieRepo {
+	return &sqlMovieRepo{
+		sqlConn: sqlConn{db: db},
+	}
+}
+
+const selectMovie = `SELECT id, external_id, title, year, director_id, genre_id, image_url, runtime, original_language, synopsis, deleted, version, ` + ratingColumns + `, ` + posterColumns + `, ` + auditColumns + ` FROM movies`
+
+// ratingColumns are the columns of models.RatingStats, in the order
+// scanMovie reads them.
+const ratingColumns = `rating, rating_mean, rating_count, rating_sum, rating_1, rating_2, rating_3, rating_4, rating_5, rating_6, rating_7, rating_8, rating_9, rating_10`
+
+// posterColumns are the columns of models.Poster, in the order posterScan
+// uses. A movie without a poster has them all NULL. The variants of a poster
+// are kept together as a JSON array.
+const posterColumns = `poster_key, poster_url, poster_content_type, poster_size, poster_width, poster_height, poster_variants, poster_blur_hash, poster_dominant_color`
+
+func (r *sqlMovieRepo) ListMovies(opts models.ListOptions) (models.Page[*models.Movie], error) {
+	movies, err := r.list(`WHERE deleted IS NULL`, opts)
+	if err != nil {
+		return models.Page[*models.Movie]{}, err
+	}
+	return page(movies, opts), nil
+}
+
+func (r *sqlMovieRepo) ListDeletedMovies() ([]*models.Movie, error) {
+	return r.list(`WHERE deleted IS NOT NULL`, models.ListOptions{})
+}
+
+func (r *sqlMovieRepo) list(where string, opts models.ListOptions) ([]*models.Movie, error) {
+	query, args, err := sqlList(selectMovie+` `+where, nil, opts, models.MovieListFields)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.q().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movies := []*models.Movie{}
+	for rows.Next() {
+		movie, err := scanMovie(rows)
+		if err != nil {
+			return nil, err
+		}
+		movies = append(movies, movie)
+	}
+	return movies, rows.Err()
+}
+
+func (r *sqlMovieRepo) GetMovie(id models.ID) (*models.Movie, error) {
+	movie, err := scanMovie(r.q().QueryRow(selectMovie+` WHERE id = $1 AND deleted IS NULL`, id))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	return movie, nil
+}
+
+func (r *sqlMovieRepo) GetMovieByExternalID(externalID string) (*models.Movie, error) {
+	movie, err := scanMovie(r.q().QueryRow(selectMovie+` WHERE external_id = $1 AND deleted IS NULL`, externalID))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	return movie, nil
+}
+
+func (r *sqlMovieRepo) CreateMovie(actorID models.ID, req *models.CreateMovieRequest) (models.ID, error) {
+	movie := newMovie(actorID, req)
+
+	var externalID *string
+	if movie.ExternalID != "" {
+		externalID = &movie.ExternalID
+	}
+	args := append([]any{movie.ID, movie.Title, movie.Year, movie.DirectorID, movie.GenreID, movie.Ratings.Score, movie.ImageURL, movie.Version}, auditArgs(movie.Audit)...)
+	args = append(args, sqlString(externalID), movie.Runtime, movie.OriginalLanguage, movie.Synopsis)
+	_, err := r.q().Exec(`INSERT INTO movies (id, title, year, director_id, genre_id, rating, image_url, version, `+auditColumns+`, external_id, runtime, original_language, synopsis) `+
+		`VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`,
+		args...)
+	if err != nil {
+		return models.NilID, sqlErr(err)
+	}
+	return movie.ID, nil
+}
+
+func (r *sqlMovieRepo) UpdateMovie(actorID, id models.ID, version int64, req *models.UpdateMovieRequest) (*models.Movie, error) {
+	movie, err := r.GetMovie(id)
+	if err != nil {
+		return nil, err
+	}
+	movie = applyMovieUpdate(movie, req)
+	movie.Audit = touch(movie.Audit, actorID)
+
+	poster, err := posterArgs(movie.Poster)
+	if err != nil {
+		return nil, err
+	}
+	args := append([]any{id, movie.Title, movie.Year, movie.DirectorID, movie.GenreID, movie.ImageURL, version, sqlTime(movie.UpdatedAt), sqlID(movie.UpdatedBy)}, poster...)
+	args = append(args, movie.Runtime, movie.OriginalLanguage, movie.Synopsis)
+	res, err := r.q().Exec(`UPDATE movies SET title = $2, year = $3, director_id = $4, genre_id = $5, image_url = $6, updated_at = $8, updated_by = $9, `+
+		`poster_key = $10, poster_url = $11, poster_content_type = $12, poster_size = $13, poster_width = $14, poster_height = $15, `+
+		`poster_variants = $16, poster_blur_hash = $17, poster_dominant_color = $18, `+
+		`runtime = $19, original_language = $20, synopsis = $21, `+
+		`version = version + 1 WHERE id = $1 AND version = $7 AND deleted IS NULL`,
+		args...)
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, sqlStale(r.q(), "movies", id)
+	}
+	movie.Version = version + 1
+	return movie, nil
+}
+
+func (r *sqlMovieRepo) DeleteMovie(actorID, id models.ID) error {
+	audit := touch(models.Audit{}, actorID)
+	res, err := r.q().Exec(`UPDATE movies SET deleted = $2, updated_at = $2, updated_by = $3, version = version + 1 WHERE id = $1 AND deleted IS NULL`,
+		id, sqlTime(audit.UpdatedAt), sqlID(audit.UpdatedBy))
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return errs.NotFound
+	}
+	return nil
+}
+
+func (r *sqlMovieRepo) RestoreMovie(actorID, id models.ID) (*models.Movie, error) {
+	audit := touch(models.Audit{}, actorID)
+	res, err := r.q().Exec(`UPDATE movies SET deleted = NULL, updated_at = $2, updated_by = $3, version = version + 1 WHERE id = $1 AND deleted IS NOT NULL`,
+		id, sqlTime(audit.UpdatedAt), sqlID(audit.UpdatedBy))
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, errs.NotFound
+	}
+	return r.GetMovie(id)
+}
+
+func (r *sqlMovieRepo) PurgeMovie(id models.ID) error {
+	res, err := r.q().Exec(`DELETE FROM movies WHERE id = $1 AND deleted IS NOT NULL`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return errs.NotFound
+	}
+	return nil
+}
+
+func (r *sqlMovieRepo) UpdateRatings(movieID models.ID, remove, add int) error {
+	if !models.ValidRating(remove) && !models.ValidRating(add) {
+		return nil
+	}
+	query, args := sqlRatingsUpdate(remove, add)
+	_, err := r.q().Exec(query, append([]any{movieID}, args...)...)
+	return err
+}
+
+func scanMovie(row rowScanner) (*models.Movie, error) {
+	var (
+		movie   models.Movie
+		deleted sql.NullTime
+		poster  posterScan
+		audit   auditScan
+		extID   sql.NullString
+	)
+	dest := []any{&movie.ID, &extID, &movie.Title, &movie.Year, &movie.DirectorID, &movie.GenreID, &movie.ImageURL,
+		&movie.Runtime, &movie.OriginalLanguage, &movie.Synopsis, &deleted, &movie.Version,
+		&movie.Ratings.Score, &movie.Ratings.Mean, &movie.Ratings.Count, &movie.Ratings.Sum}
+	for i := range movie.Ratings.Histogram {
+		dest = append(dest, &movie.Ratings.Histogram[i])
+	}
+	dest = append(dest, poster.dest()...)
+	dest = append(dest, audit.dest()...)
+	err := row.Scan(dest...)
+	if err != nil {
+		return nil, err
+	}
+	movie.ExternalID = extID.String
+	movie.Deleted = scanNullTime(deleted)
+	if movie.Poster, err = poster.poster(); err != nil {
+		return nil, err
+	}
+	movie.Audit = audit.audit()
+	return &movie, nil
+}
+
+func posterArgs(poster *models.Poster) ([]any, error) {
+	if poster == nil {
+		return []any{nil, nil, nil, nil, nil, nil, nil, nil, nil}, nil
+	}
+	variants, err := json.Marshal(poster.Variants)
+	if err != nil {
+		return nil, err
+	}
+	return []any{poster.Key, poster.URL, poster.ContentType, poster.Size, poster.Width, poster.Height, string(variants), poster.BlurHash, poster.DominantColor}, nil
+}
+
+// posterScan holds the scan destinations of the poster columns.
+type posterScan struct {
+	key, url, contentType, variants, blurHash, dominantColor sql.NullString
+	size, width, height                                      sql.NullInt64
+}
+
+func (p *posterScan) dest() []any {
+	return []any{&p.key, &p.url, &p.contentType, &p.size, &p.width, &p.height, &p.variants, &p.blurHash, &p.dominantColor}
+}
+
+func (p *posterScan) poster() (*models.Poster, error) {
+	if !p.key.Valid {
+		return nil, nil
+	}
+	poster := &models.Poster{
+		Key:           p.key.String,
+		URL:           p.url.String,
+		ContentType:   p.contentType.String,
+		Size:          p.size.Int64,
+		Width:         int(p.width.Int64),
+		Height:        int(p.height.Int64),
+		BlurHash:      p.blurHash.String,
+		DominantColor: p.dominantColor.String,
+	}
+	if p.variants.Valid {
+		if err := json.Unmarshal([]byte(p.variants.String), &poster.Variants); err != nil {
+			return nil, err
+		}
+	}
+	return poster, nil
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// PersonRepo stores people. Unlike users, movies and reviews, people have no
+// trash: the service refuses to delete a person movies still refer to, and
+// DeletePerson removes the person for good.
+type PersonRepo interface {
+	ListPeople(opts models.ListOptions) (models.Page[*models.Person], error)
+	GetPerson(id models.ID) (*models.Person, error)
+	// GetPersonByExternalID finds a person by the ID another catalogue knows
+	// them by. External IDs are unique among people.
+	GetPersonByExternalID(externalID string) (*models.Person, error)
+	// GetPeople returns the people among ids that exist, in no particular
+	// order.
+	GetPeople(ids []models.ID) ([]*models.Person, error)
+	CreatePerson(actorID models.ID, req *models.CreatePersonRequest) (models.ID, error)
+	UpdatePerson(actorID, id models.ID, version int64, req *models.UpdatePersonRequest) (*models.Person, error)
+	DeletePerson(id models.ID) error
+}
+
+const peopleCollection = "people"
+
+type personRepo struct {
+	ctx        context.Context
+	collection *mongo.Collection
+}
+
+func NewPersonRepo(log *zap.Logger, collNames map[string]int, db *mongo.Database) PersonRepo {
+	var collectionName = peopleCollection
+
+	if _, exists := collNames[collectionName]; !exists {
+		if err := db.CreateCollection(context.TODO(), collectionName); err != nil {
+			log.Fatal("couldn't initialize repository: ", zap.Error(err))
+		}
+	}
+
+	indexes := append(listIndexes(nil, "name", "birthYear", models.SortCreatedAt, models.SortUpdatedAt),
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "externalId", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"externalId": bson.M{"$type": "string"}}),
+		},
+	)
+	if _, err := db.Collection(collectionName).Indexes().CreateMany(context.TODO(), indexes); err != nil {
+		log.Fatal("couldn't initialize repository: ", zap.Error(err))
+	}
+
+	return &personRepo{
+		ctx:        context.TODO(),
+		collection: db.Collection(collectionName),
+	}
+}
+
+func (r *personRepo) ListPeople(opts models.ListOptions) (models.Page[*models.Person], error) {
+	filter, findOpts, err := mongoList(bson.M{}, opts, models.PersonListFields)
+	if err != nil {
+		return models.Page[*models.Person]{}, err
+	}
+	people, err := r.find(filter, findOpts)
+	if err != nil {
+		return models.Page[*models.Person]{}, err
+	}
+	return page(people, opts), nil
+}
+
+func (r *personRepo) GetPerson(id models.ID) (*models.Person, error) {
+	var person models.Person
+	if err := r.collection.FindOne(r.ctx, bson.M{"_id": id}).Decode(&person); err != nil {
+		return nil, mongoErr(err)
+	}
+	return &person, nil
+}
+
+func (r *personRepo) GetPersonByExternalID(externalID string) (*models.Person, error) {
+	var person models.Person
+	if err := r.collection.FindOne(r.ctx, bson.M{"externalId": externalID}).Decode(&person); err != nil {
+		return nil, mongoErr(err)
+	}
+	return &person, nil
+}
+
+func (r *personRepo) GetPeople(ids []models.ID) ([]*models.Person, error) {
+	return r.find(bson.M{"_id": bson.M{"$in": ids}}, options.Find())
+}
+
+func (r *personRepo) find(filter bson.M, opts *options.FindOptions) ([]*models.Person, error) {
+	cur, err := r.collection.Find(r.ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	people := []*models.Person{}
+	if err := cur.All(r.ctx, &people); err != nil {
+		return nil, err
+	}
+	return people, nil
+}
+
+func (r *personRepo) CreatePerson(actorID models.ID, req *models.CreatePersonRequest) (models.ID, error) {
+	person := newPerson(actorID, req)
+	if _, err := r.collection.InsertOne(r.ctx, person); err != nil {
+		return models.NilID, mongoErr(err)
+	}
+	return person.ID, nil
+}
+
+func (r *personRepo) UpdatePerson(actorID, id models.ID, version int64, req *models.UpdatePersonRequest) (*models.Person, error) {
+	update := mongoTouch(bson.M{"$set": personUpdateFields(req), "$inc": bson.M{"version": 1}}, actorID)
+
+	var person models.Person
+	filter := bson.M{"_id": id, "version": versionFilter(version)}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(r.ctx, filter, update, opts).Decode(&person)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, mongoStale(r.ctx, r.collection, id)
+	}
+	if err != nil {
+		return nil, mongoErr(err)
+	}
+	return &person, nil
+}
+
+func (r *personRepo) DeletePerson(id models.ID) error {
+	res, err := r.collection.DeleteOne(r.ctx, bson.M{"_id": id})
+	if err != nil {
+		return mongoErr(err)
+	}
+	if res.DeletedCount == 0 {
+		return errs.NotFound
+	}
+	return nil
+}
+
+func newPerson(actorID models.ID, req *models.CreatePersonRequest) *models.Person {
+	return &models.Person{
+		ID:         models.NewID(),
+		ExternalID: req.ExternalID,
+		Name:       req.Name,
+		BirthYear:  req.BirthYear,
+		Bio:        req.Bio,
+		ImageURL:   req.ImageURL,
+		Version:    1,
+		Audit:      newAudit(actorID),
+	}
+}
+
+// personUpdateFields returns the stored field names of every field set in
+// req.
+func personUpdateFields(req *models.UpdatePersonRequest) bson.M {
+	fields := bson.M{}
+	if req.Name != nil {
+		fields["name"] = *req.Name
+	}
+	if req.BirthYear != nil {
+		fields["birthYear"] = *req.BirthYear
+	}
+	if req.Bio != nil {
+		fields["bio"] = *req.Bio
+	}
+	if req.ImageURL != nil {
+		fields["imageURL"] = *req.ImageURL
+	}
+	return fields
+}
+
+// applyPersonUpdate is the in-process equivalent of personUpdateFields.
+func applyPersonUpdate(person *models.Person, req *models.UpdatePersonRequest) *models.Person {
+	if req.Name != nil {
+		person.Name = *req.Name
+	}
+	if req.BirthYear != nil {
+		birthYear := *req.BirthYear
+		person.BirthYear = &birthYear
+	}
+	if req.Bio != nil {
+		person.Bio = *req.Bio
+	}
+	if req.ImageURL != nil {
+		person.ImageURL = *req.ImageURL
+	}
+	return person
+}
@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"sync"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type memoryPersonRepo struct {
+	mu     sync.RWMutex
+	people map[models.ID]*models.Person
+}
+
+// NewMemoryPersonRepo returns a PersonRepo that keeps people in process
+// memory. It is safe for concurrent use and behaves like the Mongo
+// implementation.
+func NewMemoryPersonRepo() PersonRepo {
+	return &memoryPersonRepo{
+		people: make(map[models.ID]*models.Person),
+	}
+}
+
+func (r *memoryPersonRepo) ListPeople(opts models.ListOptions) (models.Page[*models.Person], error) {
+	r.mu.RLock()
+	people := make([]*models.Person, 0, len(r.people))
+	for _, person := range r.people {
+		people = append(people, clonePerson(person))
+	}
+	r.mu.RUnlock()
+
+	return memoryList(people, opts, models.PersonListFields)
+}
+
+func (r *memoryPersonRepo) GetPerson(id models.ID) (*models.Person, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	person, ok := r.people[id]
+	if !ok {
+		return nil, errs.NotFound
+	}
+	return clonePerson(person), nil
+}
+
+func (r *memoryPersonRepo) GetPersonByExternalID(externalID string) (*models.Person, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, person := range r.people {
+		if person.ExternalID == externalID {
+			return clonePerson(person), nil
+		}
+	}
+	return nil, errs.NotFound
+}
+
+func (r *memoryPersonRepo) GetPeople(ids []models.ID) ([]*models.Person, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	people := []*models.Person{}
+	for _, id := range ids {
+		if person, ok := r.people[id]; ok {
+			people = append(people, clonePerson(person))
+		}
+	}
+	return people, nil
+}
+
+func (r *memoryPersonRepo) CreatePerson(actorID models.ID, req *models.CreatePersonRequest) (models.ID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if req.ExternalID != "" {
+		for _, person := range r.people {
+			if person.ExternalID == req.ExternalID {
+				return models.NilID, errs.AlreadyExists
+			}
+		}
+	}
+
+	person := clonePerson(newPerson(actorID, req))
+	r.people[person.ID] = person
+	return person.ID, nil
+}
+
+func (r *memoryPersonRepo) UpdatePerson(actorID, id models.ID, version int64, req *models.UpdatePersonRequest) (*models.Person, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	person, ok := r.people[id]
+	if !ok {
+		return nil, errs.NotFound
+	}
+	if person.Version != version {
+		return nil, errs.Conflict
+	}
+
+	updated := applyPersonUpdate(clonePerson(person), req)
+	updated.Version++
+	updated.Audit = touch(updated.Audit, actorID)
+	r.people[id] = updated
+	return clonePerson(updated), nil
+}
+
+func (r *memoryPersonRepo) DeletePerson(id models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.people[id]; !ok {
+		return errs.NotFound
+	}
+	delete(r.people, id)
+	return nil
+}
+
+func clonePerson(person *models.Person) *models.Person {
+	clone := *person
+	if person.BirthYear != nil {
+		birthYear := *person.BirthYear
+		clone.BirthYear = &birthYear
+	}
+	clone.Audit = cloneAudit(person.Audit)
+	return &clone
+}
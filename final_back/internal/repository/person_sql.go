@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type sqlPersonRepo struct {
+	sqlConn
+}
+
+// NewSQLPersonRepo returns a PersonRepo backed by the people table of a
+// Postgres or SQLite database opened with db.OpenSQL.
+func NewSQLPersonRepo(db *sql.DB) PersonRepo {
+	return &sqlPersonRepo{
+		sqlConn: sqlConn{db: db},
+	}
+}
+
+const selectPerson = `SELECT id, external_id, name, birth_year, bio, image_url, version, ` + auditColumns + ` FROM people`
+
+func (r *sqlPersonRepo) ListPeople(opts models.ListOptions) (models.Page[*models.Person], error) {
+	query, args, err := sqlList(selectPerson+` WHERE TRUE`, nil, opts, models.PersonListFields)
+	if err != nil {
+		return models.Page[*models.Person]{}, err
+	}
+	people, err := r.query(query, args...)
+	if err != nil {
+		return models.Page[*models.Person]{}, err
+	}
+	return page(people, opts), nil
+}
+
+func (r *sqlPersonRepo) GetPerson(id models.ID) (*models.Person, error) {
+	person, err := scanPerson(r.q().QueryRow(selectPerson+` WHERE id = $1`, id))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	return person, nil
+}
+
+func (r *sqlPersonRepo) GetPersonByExternalID(externalID string) (*models.Person, error) {
+	person, err := scanPerson(r.q().QueryRow(selectPerson+` WHERE external_id = $1`, externalID))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	return person, nil
+}
+
+func (r *sqlPersonRepo) GetPeople(ids []models.ID) ([]*models.Person, error) {
+	if len(ids) == 0 {
+		return []*models.Person{}, nil
+	}
+	query, args := sqlIn(selectPerson+` WHERE id IN `, ids)
+	return r.query(query, args...)
+}
+
+func (r *sqlPersonRepo) query(query string, args ...any) ([]*models.Person, error) {
+	rows, err := r.q().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	people := []*models.Person{}
+	for rows.Next() {
+		person, err := scanPerson(rows)
+		if err != nil {
+			return nil, err
+		}
+		people = append(people, person)
+	}
+	return people, rows.Err()
+}
+
+func (r *sqlPersonRepo) CreatePerson(actorID models.ID, req *models.CreatePersonRequest) (models.ID, error) {
+	person := newPerson(actorID, req)
+
+	var externalID *string
+	if person.ExternalID != "" {
+		externalID = &person.ExternalID
+	}
+	args := append([]any{person.ID, person.Name, sqlInt(person.BirthYear), person.Bio, person.ImageURL, person.Version}, auditArgs(person.Audit)...)
+	args = append(args, sqlString(externalID))
+	_, err := r.q().Exec(`INSERT INTO people (id, name, birth_year, bio, image_url, version, `+auditColumns+`, external_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		args...)
+	if err != nil {
+		return models.NilID, sqlErr(err)
+	}
+	return person.ID, nil
+}
+
+func (r *sqlPersonRepo) UpdatePerson(actorID, id models.ID, version int64, req *models.UpdatePersonRequest) (*models.Person, error) {
+	person, err := r.GetPerson(id)
+	if err != nil {
+		return nil, err
+	}
+	person = applyPersonUpdate(person, req)
+	person.Audit = touch(person.Audit, actorID)
+
+	res, err := r.q().Exec(`UPDATE people SET name = $2, birth_year = $3, bio = $4, image_url = $5, updated_at = $7, updated_by = $8, version = version + 1 WHERE id = $1 AND version = $6`,
+		id, person.Name, sqlInt(person.BirthYear), person.Bio, person.ImageURL, version, sqlTime(person.UpdatedAt), sqlID(person.UpdatedBy))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, errs.Conflict
+	}
+	person.Version = version + 1
+	return person, nil
+}
+
+func (r *sqlPersonRepo) DeletePerson(id models.ID) error {
+	res, err := r.q().Exec(`DELETE FROM people WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return errs.NotFound
+	}
+	return nil
+}
+
+func scanPerson(row rowScanner) (*models.Person, error) {
+	var (
+		person    models.Person
+		birthYear sql.NullInt64
+		audit     auditScan
+		extID     sql.NullString
+	)
+	dest := append([]any{&person.ID, &extID, &person.Name, &birthYear, &person.Bio, &person.ImageURL, &person.Version}, audit.dest()...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	person.ExternalID = extID.String
+	person.BirthYear = scanNullInt(birthYear)
+	person.Audit = audit.audit()
+	return &person, nil
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// A movie's rating aggregate is adjusted in place, one review at a time, by
+// MovieRepo.UpdateRatings. Mongo and SQL do the arithmetic of
+// models.RatingStats.Adjust in the database so that concurrent adjustments
+// cannot overwrite one another.
+
+// mongoRatingsUpdate is the update pipeline equivalent of
+// models.RatingStats.Adjust.
+func mongoRatingsUpdate(remove, add int) bson.A {
+	delta := func(rating int) int {
+		if models.ValidRating(rating) {
+			return 1
+		}
+		return 0
+	}
+	value := func(rating int) int {
+		if models.ValidRating(rating) {
+			return rating
+		}
+		return 0
+	}
+	current := func(field string) bson.M {
+		return bson.M{"$ifNull": bson.A{"$" + field, 0}}
+	}
+
+	counts := bson.M{"$map": bson.M{
+		"input": bson.M{"$range": bson.A{0, models.MaxRating}},
+		"as":    "i",
+		"in": bson.M{"$add": bson.A{
+			bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$ratingHistogram", "$$i"}}, 0}},
+			bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$$i", add - 1}}, 1, 0}},
+			bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$$i", remove - 1}}, -1, 0}},
+		}},
+	}}
+
+	return bson.A{
+		bson.M{"$set": bson.M{
+			"ratingCount":     bson.M{"$add": bson.A{current("ratingCount"), delta(add) - delta(remove)}},
+			"ratingSum":       bson.M{"$add": bson.A{current("ratingSum"), value(add) - value(remove)}},
+			"ratingHistogram": counts,
+		}},
+		bson.M{"$set": bson.M{
+			"ratingMean": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$ratingCount", 0}},
+				bson.M{"$divide": bson.A{"$ratingSum", "$ratingCount"}},
+				0,
+			}},
+			"rating": bson.M{"$divide": bson.A{
+				bson.M{"$add": bson.A{models.RatingPriorMean * models.RatingPriorCount, "$ratingSum"}},
+				bson.M{"$add": bson.A{models.RatingPriorCount, "$ratingCount"}},
+			}},
+		}},
+	}
+}
+
+// sqlRatingsUpdate returns the UPDATE statement equivalent of
+// models.RatingStats.Adjust. The histogram is kept in the columns rating_1
+// to rating_10.
+func sqlRatingsUpdate(remove, add int) (string, []any) {
+	var (
+		count, sum int
+		histogram  string
+	)
+	if models.ValidRating(remove) {
+		count--
+		sum -= remove
+		column := `rating_` + strconv.Itoa(remove)
+		histogram += `, ` + column + ` = ` + column + ` - 1`
+	}
+	if models.ValidRating(add) {
+		count++
+		sum += add
+		column := `rating_` + strconv.Itoa(add)
+		histogram += `, ` + column + ` = ` + column + ` + 1`
+	}
+
+	// Every expression in SET sees the row as it was before the update.
+	priorSum := strconv.FormatFloat(models.RatingPriorMean*models.RatingPriorCount, 'f', -1, 64)
+	priorCount := strconv.Itoa(models.RatingPriorCount)
+	query := `UPDATE movies SET rating_count = rating_count + $2, rating_sum = rating_sum + $3` + histogram + `,
+		rating_mean = CASE WHEN rating_count + $2 > 0 THEN (rating_sum + $3) * 1.0 / (rating_count + $2) ELSE 0 END,
+		rating = (` + priorSum + ` + rating_sum + $3) * 1.0 / (` + priorCount + ` + rating_count + $2)
+		WHERE id = $1`
+	return query, []any{count, sum}
+}
+
+// backfillRatings computes the rating aggregate of the movies stored before
+// there was one, whose rating was typed in by their creator.
+func backfillRatings(ctx context.Context, db *mongo.Database) error {
+	movies := db.Collection(moviesCollection)
+	reviews := db.Collection(reviewsCollection)
+
+	cur, err := movies.Find(ctx, bson.M{"ratingCount": bson.M{"$exists": false}})
+	if err != nil {
+		return err
+	}
+	var stale []models.Movie
+	if err := cur.All(ctx, &stale); err != nil {
+		return err
+	}
+
+	for _, movie := range stale {
+		cur, err := reviews.Find(ctx, live(bson.M{"movieId": movie.ID, "isPrivate": false}))
+		if err != nil {
+			return err
+		}
+		var movieReviews []*models.Review
+		if err := cur.All(ctx, &movieReviews); err != nil {
+			return err
+		}
+
+		stats := models.NewRatingStats()
+		for _, review := range movieReviews {
+			stats = stats.Adjust(0, models.CountedRating(review))
+		}
+		if _, err := movies.UpdateOne(ctx, bson.M{"_id": movie.ID}, bson.M{"$set": stats}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// ReleaseRepo stores the releases of movies in each country. Releases stay
+// in place while their movie is in the trash and go when it is purged.
+type ReleaseRepo interface {
+	ListReleases(opts models.ListOptions) (models.Page[*models.Release], error)
+	GetRelease(id models.ID) (*models.Release, error)
+	// CreateRelease returns errs.AlreadyExists if the movie already has a
+	// release of the type in the country.
+	CreateRelease(actorID, movieID models.ID, req *models.CreateReleaseRequest) (models.ID, error)
+	UpdateRelease(actorID, id models.ID, version int64, req *models.UpdateReleaseRequest) (*models.Release, error)
+	DeleteRelease(id models.ID) error
+	DeleteReleasesByMovieID(movieID models.ID) error
+	// MoveReleases moves the releases of a movie to another movie, dropping
+	// the ones whose type and country it already has a release of.
+	MoveReleases(actorID, from, to models.ID) error
+}
+
+const releasesCollection = "releases"
+
+type releaseRepo struct {
+	ctx        context.Context
+	collection *mongo.Collection
+}
+
+func NewReleaseRepo(log *zap.Logger, collNames map[string]int, db *mongo.Database) ReleaseRepo {
+	var collectionName = releasesCollection
+
+	if _, exists := collNames[collectionName]; !exists {
+		if err := db.CreateCollection(context.TODO(), collectionName); err != nil {
+			log.Fatal("couldn't initialize repository: ", zap.Error(err))
+		}
+	}
+
+	indexes := append(listIndexes(nil, "date", models.SortCreatedAt, models.SortUpdatedAt),
+		mongo.IndexModel{Keys: bson.D{{Key: "country", Value: 1}, {Key: "date", Value: 1}, {Key: "_id", Value: 1}}},
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "movieId", Value: 1}, {Key: "country", Value: 1}, {Key: "type", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	)
+	if _, err := db.Collection(collectionName).Indexes().CreateMany(context.TODO(), indexes); err != nil {
+		log.Fatal("couldn't initialize repository: ", zap.Error(err))
+	}
+
+	return &releaseRepo{
+		ctx:        context.TODO(),
+		collection: db.Collection(collectionName),
+	}
+}
+
+func (r *releaseRepo) ListReleases(opts models.ListOptions) (models.Page[*models.Release], error) {
+	filter, findOpts, err := mongoList(bson.M{}, opts, models.ReleaseListFields)
+	if err != nil {
+		return models.Page[*models.Release]{}, err
+	}
+	cur, err := r.collection.Find(r.ctx, filter, findOpts)
+	if err != nil {
+		return models.Page[*models.Release]{}, err
+	}
+
+	releases := []*models.Release{}
+	if err := cur.All(r.ctx, &releases); err != nil {
+		return models.Page[*models.Release]{}, err
+	}
+	return page(releases, opts), nil
+}
+
+func (r *releaseRepo) GetRelease(id models.ID) (*models.Release, error) {
+	var release models.Release
+	if err := r.collection.FindOne(r.ctx, bson.M{"_id": id}).Decode(&release); err != nil {
+		return nil, mongoErr(err)
+	}
+	return &release, nil
+}
+
+func (r *releaseRepo) CreateRelease(actorID, movieID models.ID, req *models.CreateReleaseRequest) (models.ID, error) {
+	release := newRelease(actorID, movieID, req)
+	if _, err := r.collection.InsertOne(r.ctx, release); err != nil {
+		return models.NilID, mongoErr(err)
+	}
+	return release.ID, nil
+}
+
+func (r *releaseRepo) UpdateRelease(actorID, id models.ID, version int64, req *models.UpdateReleaseRequest) (*models.Release, error) {
+	update := mongoTouch(bson.M{"$set": releaseUpdateFields(req), "$inc": bson.M{"version": 1}}, actorID)
+
+	var release models.Release
+	filter := bson.M{"_id": id, "version": versionFilter(version)}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(r.ctx, filter, update, opts).Decode(&release)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, mongoStale(r.ctx, r.collection, id)
+	}
+	if err != nil {
+		return nil, mongoErr(err)
+	}
+	return &release, nil
+}
+
+func (r *releaseRepo) DeleteRelease(id models.ID) error {
+	res, err := r.collection.DeleteOne(r.ctx, bson.M{"_id": id})
+	if err != nil {
+		return mongoErr(err)
+	}
+	if res.DeletedCount == 0 {
+		return errs.NotFound
+	}
+	return nil
+}
+
+func (r *releaseRepo) DeleteReleasesByMovieID(movieID models.ID) error {
+	_, err := r.collection.DeleteMany(r.ctx, bson.M{"movieId": movieID})
+	return mongoErr(err)
+}
+
+func (r *releaseRepo) MoveReleases(actorID, from, to models.ID) error {
+	var kept []*models.Release
+	cur, err := r.collection.Find(r.ctx, bson.M{"movieId": to})
+	if err != nil {
+		return err
+	}
+	if err := cur.All(r.ctx, &kept); err != nil {
+		return err
+	}
+	for _, release := range kept {
+		_, err := r.collection.DeleteMany(r.ctx, bson.M{"movieId": from, "country": release.Country, "type": release.Type})
+		if err != nil {
+			return mongoErr(err)
+		}
+	}
+
+	update := mongoTouch(bson.M{"$set": bson.M{"movieId": to}, "$inc": bson.M{"version": 1}}, actorID)
+	_, err = r.collection.UpdateMany(r.ctx, bson.M{"movieId": from}, update)
+	return mongoErr(err)
+}
+
+func newRelease(actorID, movieID models.ID, req *models.CreateReleaseRequest) *models.Release {
+	return &models.Release{
+		ID:            models.NewID(),
+		MovieID:       movieID,
+		Country:       req.Country,
+		Date:          req.Date,
+		Type:          req.Type,
+		Certification: req.Certification,
+		Version:       1,
+		Audit:         newAudit(actorID),
+	}
+}
+
+// releaseUpdateFields returns the stored field names of every field set in
+// req.
+func releaseUpdateFields(req *models.UpdateReleaseRequest) bson.M {
+	fields := bson.M{}
+	if req.Date != nil {
+		fields["date"] = *req.Date
+	}
+	if req.Certification != nil {
+		fields["certification"] = *req.Certification
+	}
+	return fields
+}
+
+// applyReleaseUpdate is the in-process equivalent of releaseUpdateFields.
+func applyReleaseUpdate(release *models.Release, req *models.UpdateReleaseRequest) *models.Release {
+	if req.Date != nil {
+		release.Date = *req.Date
+	}
+	if req.Certification != nil {
+		release.Certification = *req.Certification
+	}
+	return release
+}
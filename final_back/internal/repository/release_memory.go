@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"sync"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type memoryReleaseRepo struct {
+	mu       sync.RWMutex
+	releases map[models.ID]*models.Release
+}
+
+// NewMemoryReleaseRepo returns a ReleaseRepo that keeps releases in process
+// memory. It is safe for concurrent use and behaves like the Mongo
+// implementation.
+func NewMemoryReleaseRepo() ReleaseRepo {
+	return &memoryReleaseRepo{
+		releases: make(map[models.ID]*models.Release),
+	}
+}
+
+func (r *memoryReleaseRepo) ListReleases(opts models.ListOptions) (models.Page[*models.Release], error) {
+	r.mu.RLock()
+	releases := make([]*models.Release, 0, len(r.releases))
+	for _, release := range r.releases {
+		releases = append(releases, cloneRelease(release))
+	}
+	r.mu.RUnlock()
+
+	return memoryList(releases, opts, models.ReleaseListFields)
+}
+
+func (r *memoryReleaseRepo) GetRelease(id models.ID) (*models.Release, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	release, ok := r.releases[id]
+	if !ok {
+		return nil, errs.NotFound
+	}
+	return cloneRelease(release), nil
+}
+
+func (r *memoryReleaseRepo) CreateRelease(actorID, movieID models.ID, req *models.CreateReleaseRequest) (models.ID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.released(movieID, req.Country, req.Type) {
+		return models.NilID, errs.AlreadyExists
+	}
+	release := newRelease(actorID, movieID, req)
+	r.releases[release.ID] = release
+	return release.ID, nil
+}
+
+func (r *memoryReleaseRepo) UpdateRelease(actorID, id models.ID, version int64, req *models.UpdateReleaseRequest) (*models.Release, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	release, ok := r.releases[id]
+	if !ok {
+		return nil, errs.NotFound
+	}
+	if release.Version != version {
+		return nil, errs.Conflict
+	}
+
+	updated := applyReleaseUpdate(cloneRelease(release), req)
+	updated.Version++
+	updated.Audit = touch(updated.Audit, actorID)
+	r.releases[id] = updated
+	return cloneRelease(updated), nil
+}
+
+func (r *memoryReleaseRepo) DeleteRelease(id models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.releases[id]; !ok {
+		return errs.NotFound
+	}
+	delete(r.releases, id)
+	return nil
+}
+
+func (r *memoryReleaseRepo) DeleteReleasesByMovieID(movieID models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, release := range r.releases {
+		if release.MovieID == movieID {
+			delete(r.releases, id)
+		}
+	}
+	return nil
+}
+
+func (r *memoryReleaseRepo) MoveReleases(actorID, from, to models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, release := range r.releases {
+		if release.MovieID != from {
+			continue
+		}
+		if r.released(to, release.Country, release.Type) {
+			delete(r.releases, id)
+			continue
+		}
+		moved := cloneRelease(release)
+		moved.MovieID = to
+		moved.Version++
+		moved.Audit = touch(moved.Audit, actorID)
+		r.releases[id] = moved
+	}
+	return nil
+}
+
+// released reports whether movieID has a release of releaseType in
+// country.
+func (r *memoryReleaseRepo) released(movieID models.ID, country string, releaseType models.ReleaseType) bool {
+	for _, release := range r.releases {
+		if release.MovieID == movieID && release.Country == country && release.Type == releaseType {
+			return true
+		}
+	}
+	return false
+}
+
+func cloneRelease(release *models.Release) *models.Release {
+	clone := *release
+	clone.Audit = cloneAudit(release.Audit)
+	return &clone
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type sqlReleaseRepo struct {
+	sqlConn
+}
+
+// NewSQLReleaseRepo returns a ReleaseRepo backed by the releases table of a
+// Postgres or SQLite database opened with db.OpenSQL.
+func NewSQLReleaseRepo(db *sql.DB) ReleaseRepo {
+	return &sqlReleaseRepo{
+		sqlConn: sqlConn{db: db},
+	}
+}
+
+const selectRelease = `SELECT id, movie_id, country, date, type, certification, version, ` + auditColumns + ` FROM releases`
+
+func (r *sqlReleaseRepo) ListReleases(opts models.ListOptions) (models.Page[*models.Release], error) {
+	query, args, err := sqlList(selectRelease+` WHERE TRUE`, nil, opts, models.ReleaseListFields)
+	if err != nil {
+		return models.Page[*models.Release]{}, err
+	}
+	rows, err := r.q().Query(query, args...)
+	if err != nil {
+		return models.Page[*models.Release]{}, err
+	}
+	defer rows.Close()
+
+	releases := []*models.Release{}
+	for rows.Next() {
+		release, err := scanRelease(rows)
+		if err != nil {
+			return models.Page[*models.Release]{}, err
+		}
+		releases = append(releases, release)
+	}
+	if err := rows.Err(); err != nil {
+		return models.Page[*models.Release]{}, err
+	}
+	return page(releases, opts), nil
+}
+
+func (r *sqlReleaseRepo) GetRelease(id models.ID) (*models.Release, error) {
+	release, err := scanRelease(r.q().QueryRow(selectRelease+` WHERE id = $1`, id))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	return release, nil
+}
+
+func (r *sqlReleaseRepo) CreateRelease(actorID, movieID models.ID, req *models.CreateReleaseRequest) (models.ID, error) {
+	release := newRelease(actorID, movieID, req)
+
+	args := append([]any{release.ID, release.MovieID, release.Country, release.Date, string(release.Type), release.Certification, release.Version}, auditArgs(release.Audit)...)
+	_, err := r.q().Exec(`INSERT INTO releases (id, movie_id, country, date, type, certification, version, `+auditColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		args...)
+	if err != nil {
+		return models.NilID, sqlErr(err)
+	}
+	return release.ID, nil
+}
+
+func (r *sqlReleaseRepo) UpdateRelease(actorID, id models.ID, version int64, req *models.UpdateReleaseRequest) (*models.Release, error) {
+	release, err := r.GetRelease(id)
+	if err != nil {
+		return nil, err
+	}
+	release = applyReleaseUpdate(release, req)
+	release.Audit = touch(release.Audit, actorID)
+
+	res, err := r.q().Exec(`UPDATE releases SET date = $2, certification = $3, updated_at = $5, updated_by = $6, version = version + 1 WHERE id = $1 AND version = $4`,
+		id, release.Date, release.Certification, version, sqlTime(release.UpdatedAt), sqlID(release.UpdatedBy))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, errs.Conflict
+	}
+	release.Version = version + 1
+	return release, nil
+}
+
+func (r *sqlReleaseRepo) DeleteRelease(id models.ID) error {
+	res, err := r.q().Exec(`DELETE FROM releases WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return errs.NotFound
+	}
+	return nil
+}
+
+func (r *sqlReleaseRepo) DeleteReleasesByMovieID(movieID models.ID) error {
+	_, err := r.q().Exec(`DELETE FROM releases WHERE movie_id = $1`, movieID)
+	return err
+}
+
+func (r *sqlReleaseRepo) MoveReleases(actorID, from, to models.ID) error {
+	audit := touch(models.Audit{}, actorID)
+	return r.inTx(func(tx querier) error {
+		_, err := tx.Exec(`DELETE FROM releases WHERE movie_id = $1 AND EXISTS `+
+			`(SELECT 1 FROM releases kept WHERE kept.movie_id = $2 AND kept.country = releases.country AND kept.type = releases.type)`, from, to)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE releases SET movie_id = $2, updated_at = $3, updated_by = $4, version = version + 1 WHERE movie_id = $1`,
+			from, to, sqlTime(audit.UpdatedAt), sqlID(audit.UpdatedBy))
+		return sqlErr(err)
+	})
+}
+
+func scanRelease(row rowScanner) (*models.Release, error) {
+	var (
+		release     models.Release
+		releaseType string
+		audit       auditScan
+	)
+	dest := append([]any{&release.ID, &release.MovieID, &release.Country, &release.Date, &releaseType, &release.Certification, &release.Version}, audit.dest()...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	release.Type = models.ReleaseType(releaseType)
+	release.Audit = audit.audit()
+	return &release, nil
+}
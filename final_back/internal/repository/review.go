@@ -2,33 +2,80 @@ package repository
 
 import (
 	"context"
+	"errors"
 
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
 	"github.com/kakimnsnv/ios_final_back/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 )
 
 type ReviewRepo interface {
-	ListReviewsByMovieID(actorID *primitive.ObjectID, movieID *primitive.ObjectID) ([]*models.Review, error)
-	ListOwnReviewsByMovieID(actorID *primitive.ObjectID, movieID *primitive.ObjectID) ([]*models.Review, error)
-	ListMyReviews(actorID *primitive.ObjectID) ([]*models.Review, error)
-	ListReviewCategories() ([]*models.ReviewCategory, error)
-	UpdateReview(reviewID *primitive.ObjectID, review *models.Review) (*models.Review, error)
-	GetReviewByID(reviewID *primitive.ObjectID) (*models.Review, error)
-	CreateReview(review *models.Review) (*primitive.ObjectID, error)
-	DeleteReview(reviewID *primitive.ObjectID) error
+	ListReviewsByMovieID(actorID models.ID, movieID models.ID, opts models.ListOptions) (models.Page[*models.Review], error)
+	ListOwnReviewsByMovieID(actorID models.ID, movieID models.ID) ([]*models.Review, error)
+	ListMyReviews(actorID models.ID, opts models.ListOptions) (models.Page[*models.Review], error)
+	// ListReviewCategories returns the review categories by their order,
+	// archived ones only if includeArchived.
+	ListReviewCategories(includeArchived bool) ([]*models.ReviewCategory, error)
+	// GetReviewCategory returns a category whether it is archived or not.
+	GetReviewCategory(id models.ID) (*models.ReviewCategory, error)
+	CreateReviewCategory(actorID models.ID, req *models.CreateReviewCategoryRequest) (models.ID, error)
+	UpdateReviewCategory(actorID, id models.ID, version int64, req *models.UpdateReviewCategoryRequest) (*models.ReviewCategory, error)
+	// ArchiveReviewCategory archives a category, or brings it back if
+	// archived is false. A category already in that state is left alone.
+	ArchiveReviewCategory(actorID, id models.ID, archived bool) (*models.ReviewCategory, error)
+	// CountReviewsByCategory returns the number of live reviews in each
+	// category that has any.
+	CountReviewsByCategory() (map[models.ID]int, error)
+	UpdateReview(actorID, reviewID models.ID, version int64, review *models.Review) (*models.Review, error)
+	GetReviewByID(reviewID models.ID) (*models.Review, error)
+	CreateReview(actorID models.ID, review *models.Review) (models.ID, error)
+	// DeleteReview moves the review to the trash if it is still at version,
+	// and returns errs.Conflict otherwise.
+	DeleteReview(actorID, reviewID models.ID, version int64) error
+	ListDeletedReviews() ([]*models.Review, error)
+	RestoreReview(actorID, reviewID models.ID) (*models.Review, error)
+	PurgeReview(reviewID models.ID) error
+	DeleteReviewsByMovieID(movieID models.ID) error
+	// MoveReviews moves the reviews of a movie, trashed ones included, to
+	// another movie and returns them as moved.
+	MoveReviews(actorID, from, to models.ID) ([]*models.Review, error)
+	// TrashReviewsWithMovie hides the reviews of a movie that is moving to
+	// the trash, or shows them again if trashed is false.
+	TrashReviewsWithMovie(movieID models.ID, trashed bool) error
+	// TrashReviewsWithOwner hides the reviews of a user who is moving to the
+	// trash, or shows them again if trashed is false, and returns the
+	// reviews it changed as changed.
+	TrashReviewsWithOwner(ownerID models.ID, trashed bool) ([]*models.Review, error)
+	// AnonymizeReviews returns the public reviews it shows again because
+	// their owner was in the trash, as anonymized.
+	AnonymizeReviews(ownerID models.ID) ([]*models.Review, error)
 }
 
+// defaultReviewCategories are seeded into an empty store by every ReviewRepo
+// implementation.
+var defaultReviewCategories = []string{
+	"Хочу пересмотеть",
+	"Советую другим",
+	"Только на один раз",
+}
+
+const (
+	reviewsCollection          = "reviews"
+	reviewCategoriesCollection = "reviewCategories"
+)
+
 type reviewRepo struct {
+	ctx                      context.Context
 	collection               *mongo.Collection
 	reviewCategoryCollection *mongo.Collection
 }
 
 func NewReviewRepo(log *zap.Logger, collNames map[string]int, db *mongo.Database) ReviewRepo {
-	var collectionName = "reviews"
-	var reviewCategoryCollectionName = "reviewCategories"
+	var collectionName = reviewsCollection
+	var reviewCategoryCollectionName = reviewCategoriesCollection
 
 	if _, exists := collNames[collectionName]; !exists {
 		if err := db.CreateCollection(context.TODO(), collectionName); err != nil {
@@ -40,107 +87,366 @@ func NewReviewRepo(log *zap.Logger, collNames map[string]int, db *mongo.Database
 		if err := db.CreateCollection(context.TODO(), reviewCategoryCollectionName); err != nil {
 			log.Fatal("couldn't initialize repository: ", zap.Error(err))
 		}
-		db.Collection(reviewCategoryCollectionName).InsertMany(context.TODO(), []interface{}{
-			models.ReviewCategory{
-				Name: "Хочу пересмотеть",
-			},
-			models.ReviewCategory{
-				Name: "Советую другим",
-			},
-			models.ReviewCategory{
-				Name: "Только на один раз",
-			},
-		})
+		categories := make([]interface{}, 0, len(defaultReviewCategories))
+		for i, name := range defaultReviewCategories {
+			categories = append(categories, newReviewCategory(models.NilID, &models.CreateReviewCategoryRequest{Name: name, Order: i}))
+		}
+		if _, err := db.Collection(reviewCategoryCollectionName).InsertMany(context.TODO(), categories); err != nil {
+			log.Fatal("couldn't initialize repository: ", zap.Error(err))
+		}
+	}
+
+	categoryIndex := mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	if _, err := db.Collection(reviewCategoryCollectionName).Indexes().CreateOne(context.TODO(), categoryIndex); err != nil {
+		log.Fatal("couldn't initialize repository: ", zap.Error(err))
+	}
 
+	sortFields := []string{"rating", models.SortCreatedAt, models.SortUpdatedAt}
+	indexes := append(listIndexes(bson.D{{Key: "movieId", Value: 1}}, sortFields...),
+		listIndexes(bson.D{{Key: "ownerId", Value: 1}}, sortFields...)...)
+	if _, err := db.Collection(collectionName).Indexes().CreateMany(context.TODO(), indexes); err != nil {
+		log.Fatal("couldn't initialize repository: ", zap.Error(err))
 	}
 
 	return &reviewRepo{
+		ctx:                      context.TODO(),
 		collection:               db.Collection(collectionName),
 		reviewCategoryCollection: db.Collection(reviewCategoryCollectionName),
 	}
 }
 
-func (r *reviewRepo) ListReviewsByMovieID(actorID *primitive.ObjectID, movieID *primitive.ObjectID) ([]*models.Review, error) {
-	cur, err := r.collection.Find(context.TODO(), bson.M{"movieID": movieID})
+// ListReviewsByMovieID returns the public reviews of the movie together with
+// the actor's own private ones.
+func (r *reviewRepo) ListReviewsByMovieID(actorID models.ID, movieID models.ID, opts models.ListOptions) (models.Page[*models.Review], error) {
+	return r.find(liveReview(bson.M{
+		"movieId": movieID,
+		"$or": bson.A{
+			bson.M{"isPrivate": false},
+			bson.M{"ownerId": actorID},
+		},
+	}), opts)
+}
+
+func (r *reviewRepo) ListOwnReviewsByMovieID(actorID models.ID, movieID models.ID) ([]*models.Review, error) {
+	reviews, err := r.find(liveReview(bson.M{"movieId": movieID, "ownerId": actorID}), models.ListOptions{})
+	return reviews.Items, err
+}
+
+func (r *reviewRepo) ListMyReviews(actorID models.ID, opts models.ListOptions) (models.Page[*models.Review], error) {
+	return r.find(liveReview(bson.M{"ownerId": actorID}), opts)
+}
+
+func (r *reviewRepo) find(filter bson.M, opts models.ListOptions) (models.Page[*models.Review], error) {
+	filter, findOpts, err := mongoList(filter, opts, models.ReviewListFields)
 	if err != nil {
-		return nil, err
+		return models.Page[*models.Review]{}, err
+	}
+	cur, err := r.collection.Find(r.ctx, filter, findOpts)
+	if err != nil {
+		return models.Page[*models.Review]{}, err
 	}
 
-	var reviews []*models.Review
-	if err := cur.All(context.TODO(), &reviews); err != nil {
-		return nil, err
+	reviews := []*models.Review{}
+	if err := cur.All(r.ctx, &reviews); err != nil {
+		return models.Page[*models.Review]{}, err
 	}
-	return reviews, nil
+	return page(reviews, opts), nil
 }
 
-func (r *reviewRepo) ListOwnReviewsByMovieID(actorID *primitive.ObjectID, movieID *primitive.ObjectID) ([]*models.Review, error) {
-	cur, err := r.collection.Find(context.TODO(), bson.M{"movieID": movieID, "userID": actorID})
+func (r *reviewRepo) ListReviewCategories(includeArchived bool) ([]*models.ReviewCategory, error) {
+	filter := bson.M{}
+	if !includeArchived {
+		filter["archived"] = nil
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "order", Value: 1}, {Key: "_id", Value: 1}})
+	cur, err := r.reviewCategoryCollection.Find(r.ctx, filter, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	var reviews []*models.Review
-	if err := cur.All(context.TODO(), &reviews); err != nil {
+	categories := []*models.ReviewCategory{}
+	if err := cur.All(r.ctx, &categories); err != nil {
 		return nil, err
 	}
-	return reviews, nil
+	return categories, nil
+}
+
+func (r *reviewRepo) GetReviewCategory(id models.ID) (*models.ReviewCategory, error) {
+	var category models.ReviewCategory
+	if err := r.reviewCategoryCollection.FindOne(r.ctx, bson.M{"_id": id}).Decode(&category); err != nil {
+		return nil, mongoErr(err)
+	}
+	return &category, nil
+}
+
+func (r *reviewRepo) CreateReviewCategory(actorID models.ID, req *models.CreateReviewCategoryRequest) (models.ID, error) {
+	category := newReviewCategory(actorID, req)
+	if _, err := r.reviewCategoryCollection.InsertOne(r.ctx, category); err != nil {
+		return models.NilID, mongoErr(err)
+	}
+	return category.ID, nil
 }
 
-func (r *reviewRepo) ListMyReviews(actorID *primitive.ObjectID) ([]*models.Review, error) {
-	cur, err := r.collection.Find(context.TODO(), bson.M{"userID": actorID})
+func (r *reviewRepo) UpdateReviewCategory(actorID, id models.ID, version int64, req *models.UpdateReviewCategoryRequest) (*models.ReviewCategory, error) {
+	set := bson.M{}
+	if req.Name != nil {
+		set["name"] = *req.Name
+	}
+	if req.Order != nil {
+		set["order"] = *req.Order
+	}
+	update := mongoTouch(bson.M{"$set": set, "$inc": bson.M{"version": 1}}, actorID)
+
+	var category models.ReviewCategory
+	filter := bson.M{"_id": id, "version": versionFilter(version)}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.reviewCategoryCollection.FindOneAndUpdate(r.ctx, filter, update, opts).Decode(&category)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, mongoStale(r.ctx, r.reviewCategoryCollection, id)
+	}
 	if err != nil {
-		return nil, err
+		return nil, mongoErr(err)
 	}
+	return &category, nil
+}
 
-	var reviews []*models.Review
-	if err := cur.All(context.TODO(), &reviews); err != nil {
-		return nil, err
+func (r *reviewRepo) ArchiveReviewCategory(actorID, id models.ID, archived bool) (*models.ReviewCategory, error) {
+	filter := bson.M{"_id": id, "archived": nil}
+	update := bson.M{"$set": bson.M{"archived": timestamp()}, "$inc": bson.M{"version": 1}}
+	if !archived {
+		filter["archived"] = bson.M{"$ne": nil}
+		update = bson.M{"$unset": bson.M{"archived": ""}, "$inc": bson.M{"version": 1}}
+	}
+
+	var category models.ReviewCategory
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.reviewCategoryCollection.FindOneAndUpdate(r.ctx, filter, mongoTouch(update, actorID), opts).Decode(&category)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return r.GetReviewCategory(id)
 	}
-	return reviews, nil
+	if err != nil {
+		return nil, mongoErr(err)
+	}
+	return &category, nil
 }
 
-func (r *reviewRepo) ListReviewCategories() ([]*models.ReviewCategory, error) {
-	cur, err := r.reviewCategoryCollection.Find(context.TODO(), bson.M{})
+func (r *reviewRepo) CountReviewsByCategory() (map[models.ID]int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: liveReview(bson.M{})}},
+		{{Key: "$group", Value: bson.M{"_id": "$reviewCategoryId", "count": bson.M{"$sum": 1}}}},
+	}
+	cur, err := r.collection.Aggregate(r.ctx, pipeline)
 	if err != nil {
 		return nil, err
 	}
 
-	var categories []*models.ReviewCategory
-	if err := cur.All(context.TODO(), &categories); err != nil {
+	var groups []struct {
+		ID    models.ID `bson:"_id"`
+		Count int       `bson:"count"`
+	}
+	if err := cur.All(r.ctx, &groups); err != nil {
 		return nil, err
 	}
-	return categories, nil
+	counts := make(map[models.ID]int, len(groups))
+	for _, group := range groups {
+		if !group.ID.IsZero() {
+			counts[group.ID] = group.Count
+		}
+	}
+	return counts, nil
 }
 
-func (r *reviewRepo) UpdateReview(reviewID *primitive.ObjectID, review *models.Review) (*models.Review, error) {
-	_, err := r.collection.UpdateOne(context.TODO(), bson.M{"_id": reviewID}, bson.M{"$set": review})
+// UpdateReview replaces the stored review with review if it is still at
+// version, and returns errs.Conflict otherwise. The ID and the creation
+// stamps are kept.
+func (r *reviewRepo) UpdateReview(actorID, reviewID models.ID, version int64, review *models.Review) (*models.Review, error) {
+	stored, err := r.GetReviewByID(reviewID)
 	if err != nil {
 		return nil, err
 	}
-	return review, nil
+
+	replacement := *review
+	replacement.ID = reviewID
+	replacement.Version = version + 1
+	replacement.Audit = touch(stored.Audit, actorID)
+
+	filter := liveReview(bson.M{"_id": reviewID, "version": versionFilter(version)})
+	res, err := r.collection.ReplaceOne(r.ctx, filter, &replacement)
+	if err != nil {
+		return nil, mongoErr(err)
+	}
+	if res.MatchedCount == 0 {
+		return nil, mongoStale(r.ctx, r.collection, reviewID)
+	}
+	return &replacement, nil
 }
 
-func (r *reviewRepo) GetReviewByID(reviewID *primitive.ObjectID) (*models.Review, error) {
+func (r *reviewRepo) GetReviewByID(reviewID models.ID) (*models.Review, error) {
 	var review models.Review
-	err := r.collection.FindOne(context.TODO(), bson.M{"_id": reviewID}).Decode(&review)
+	err := r.collection.FindOne(r.ctx, liveReview(bson.M{"_id": reviewID})).Decode(&review)
 	if err != nil {
-		return nil, err
+		return nil, mongoErr(err)
 	}
 	return &review, nil
 }
 
-func (r *reviewRepo) CreateReview(review *models.Review) (*primitive.ObjectID, error) {
-	res, err := r.collection.InsertOne(context.TODO(), review)
+func (r *reviewRepo) CreateReview(actorID models.ID, review *models.Review) (models.ID, error) {
+	stored := *review
+	if stored.ID.IsZero() {
+		stored.ID = models.NewID()
+	}
+	stored.Version = 1
+	stored.Audit = newAudit(actorID)
+
+	if _, err := r.collection.InsertOne(r.ctx, &stored); err != nil {
+		return models.NilID, mongoErr(err)
+	}
+	return stored.ID, nil
+}
+
+func (r *reviewRepo) DeleteReview(actorID, reviewID models.ID, version int64) error {
+	update := mongoTouch(bson.M{"$set": bson.M{"deleted": timestamp()}, "$inc": bson.M{"version": 1}}, actorID)
+	res, err := r.collection.UpdateOne(r.ctx, liveReview(bson.M{"_id": reviewID, "version": versionFilter(version)}), update)
 	if err != nil {
-		return nil, err
+		return mongoErr(err)
+	}
+	if res.MatchedCount == 0 {
+		return mongoStale(r.ctx, r.collection, reviewID)
+	}
+	return nil
+}
+
+func (r *reviewRepo) ListDeletedReviews() ([]*models.Review, error) {
+	reviews, err := r.find(trashed(bson.M{}), models.ListOptions{})
+	return reviews.Items, err
+}
+
+func (r *reviewRepo) RestoreReview(actorID, reviewID models.ID) (*models.Review, error) {
+	var review models.Review
+	update := mongoTouch(bson.M{"$unset": bson.M{"deleted": ""}, "$inc": bson.M{"version": 1}}, actorID)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(r.ctx, trashed(bson.M{"_id": reviewID}), update, opts).Decode(&review)
+	if err != nil {
+		return nil, mongoErr(err)
 	}
-	return res.InsertedID.(*primitive.ObjectID), nil
+	return &review, nil
 }
 
-func (r *reviewRepo) DeleteReview(reviewID *primitive.ObjectID) error {
-	_, err := r.collection.DeleteOne(context.TODO(), bson.M{"_id": reviewID})
+// PurgeReview removes a trashed review for good.
+func (r *reviewRepo) PurgeReview(reviewID models.ID) error {
+	res, err := r.collection.DeleteOne(r.ctx, trashed(bson.M{"_id": reviewID}))
 	if err != nil {
-		return err
+		return mongoErr(err)
+	}
+	if res.DeletedCount == 0 {
+		return errs.NotFound
 	}
 	return nil
 }
+
+func (r *reviewRepo) DeleteReviewsByMovieID(movieID models.ID) error {
+	_, err := r.collection.DeleteMany(r.ctx, bson.M{"movieId": movieID})
+	return mongoErr(err)
+}
+
+func (r *reviewRepo) MoveReviews(actorID, from, to models.ID) ([]*models.Review, error) {
+	reviews, err := r.find(bson.M{"movieId": from}, models.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	update := mongoTouch(bson.M{"$set": bson.M{"movieId": to}, "$inc": bson.M{"version": 1}}, actorID)
+	if _, err := r.collection.UpdateMany(r.ctx, bson.M{"movieId": from}, update); err != nil {
+		return nil, mongoErr(err)
+	}
+	for _, review := range reviews.Items {
+		review.MovieID = to
+		review.Version++
+		review.Audit = touch(review.Audit, actorID)
+	}
+	return reviews.Items, nil
+}
+
+func (r *reviewRepo) TrashReviewsWithMovie(movieID models.ID, trashed bool) error {
+	_, err := r.collection.UpdateMany(r.ctx, bson.M{"movieId": movieID}, trashFlag("movieTrashed", trashed))
+	return mongoErr(err)
+}
+
+func (r *reviewRepo) TrashReviewsWithOwner(ownerID models.ID, trashed bool) ([]*models.Review, error) {
+	filter := bson.M{"ownerId": ownerID, "ownerTrashed": bson.M{"$ne": true}}
+	if !trashed {
+		filter["ownerTrashed"] = true
+	}
+	reviews, err := r.find(filter, models.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := r.collection.UpdateMany(r.ctx, filter, trashFlag("ownerTrashed", trashed)); err != nil {
+		return nil, mongoErr(err)
+	}
+	for _, review := range reviews.Items {
+		review.OwnerTrashed = trashed
+	}
+	return reviews.Items, nil
+}
+
+// AnonymizeReviews detaches the owner's public reviews from them, audit
+// stamps included, and removes the private ones, which nobody else can read
+// anyway.
+func (r *reviewRepo) AnonymizeReviews(ownerID models.ID) ([]*models.Review, error) {
+	shown, err := r.find(bson.M{"ownerId": ownerID, "isPrivate": false, "ownerTrashed": true}, models.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.collection.DeleteMany(r.ctx, bson.M{"ownerId": ownerID, "isPrivate": true}); err != nil {
+		return nil, mongoErr(err)
+	}
+
+	now := timestamp()
+	update := bson.M{
+		"$set":   bson.M{"ownerId": models.NilID, "updatedAt": now},
+		"$unset": bson.M{"createdBy": "", "updatedBy": "", "ownerTrashed": ""},
+		"$inc":   bson.M{"version": 1},
+	}
+	if _, err := r.collection.UpdateMany(r.ctx, bson.M{"ownerId": ownerID}, update); err != nil {
+		return nil, mongoErr(err)
+	}
+	for _, review := range shown.Items {
+		review.OwnerID = models.NilID
+		review.OwnerTrashed = false
+		review.Version++
+		review.Audit = models.Audit{CreatedAt: review.CreatedAt, UpdatedAt: &now}
+	}
+	return shown.Items, nil
+}
+
+// liveReview is live for reviews, which are also hidden while their movie
+// or their owner is in the trash.
+func liveReview(filter bson.M) bson.M {
+	filter["movieTrashed"] = bson.M{"$ne": true}
+	filter["ownerTrashed"] = bson.M{"$ne": true}
+	return live(filter)
+}
+
+// trashFlag is the update that sets or clears one of the trash flags of
+// reviews.
+func trashFlag(field string, trashed bool) bson.M {
+	if trashed {
+		return bson.M{"$set": bson.M{field: true}}
+	}
+	return bson.M{"$unset": bson.M{field: ""}}
+}
+
+func newReviewCategory(actorID models.ID, req *models.CreateReviewCategoryRequest) *models.ReviewCategory {
+	return &models.ReviewCategory{
+		ID:      models.NewID(),
+		Name:    req.Name,
+		Order:   req.Order,
+		Version: 1,
+		Audit:   newAudit(actorID),
+	}
+}
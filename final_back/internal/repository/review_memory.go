@@ -0,0 +1,413 @@
+package repository
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type memoryReviewRepo struct {
+	mu         sync.RWMutex
+	reviews    map[models.ID]*models.Review
+	categories map[models.ID]*models.ReviewCategory
+}
+
+// NewMemoryReviewRepo returns a ReviewRepo that keeps reviews in process
+// memory, seeded with the default review categories. It is safe for
+// concurrent use and behaves like the Mongo implementation.
+func NewMemoryReviewRepo() ReviewRepo {
+	categories := make(map[models.ID]*models.ReviewCategory, len(defaultReviewCategories))
+	for i, name := range defaultReviewCategories {
+		category := newReviewCategory(models.NilID, &models.CreateReviewCategoryRequest{Name: name, Order: i})
+		categories[category.ID] = category
+	}
+
+	return &memoryReviewRepo{
+		reviews:    make(map[models.ID]*models.Review),
+		categories: categories,
+	}
+}
+
+func (r *memoryReviewRepo) ListReviewsByMovieID(actorID models.ID, movieID models.ID, opts models.ListOptions) (models.Page[*models.Review], error) {
+	reviews := r.filter(func(review *models.Review) bool {
+		return isLiveReview(review) && review.MovieID == movieID && (!review.IsPrivate || review.OwnerID == actorID)
+	})
+	return memoryList(reviews, opts, models.ReviewListFields)
+}
+
+func (r *memoryReviewRepo) ListOwnReviewsByMovieID(actorID models.ID, movieID models.ID) ([]*models.Review, error) {
+	return r.filter(func(review *models.Review) bool {
+		return isLiveReview(review) && review.MovieID == movieID && review.OwnerID == actorID
+	}), nil
+}
+
+func (r *memoryReviewRepo) ListMyReviews(actorID models.ID, opts models.ListOptions) (models.Page[*models.Review], error) {
+	reviews := r.filter(func(review *models.Review) bool {
+		return isLiveReview(review) && review.OwnerID == actorID
+	})
+	return memoryList(reviews, opts, models.ReviewListFields)
+}
+
+func (r *memoryReviewRepo) ListDeletedReviews() ([]*models.Review, error) {
+	return r.filter(func(review *models.Review) bool {
+		return review.Deleted != nil
+	}), nil
+}
+
+func (r *memoryReviewRepo) filter(match func(review *models.Review) bool) []*models.Review {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	reviews := []*models.Review{}
+	for _, review := range r.reviews {
+		if match(review) {
+			reviews = append(reviews, cloneReview(review))
+		}
+	}
+	sort.Slice(reviews, func(i, j int) bool {
+		return reviews[i].ID.Compare(reviews[j].ID) < 0
+	})
+	return reviews
+}
+
+func (r *memoryReviewRepo) ListReviewCategories(includeArchived bool) ([]*models.ReviewCategory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	categories := make([]*models.ReviewCategory, 0, len(r.categories))
+	for _, category := range r.categories {
+		if includeArchived || category.Archived == nil {
+			categories = append(categories, cloneReviewCategory(category))
+		}
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		if categories[i].Order != categories[j].Order {
+			return categories[i].Order < categories[j].Order
+		}
+		return categories[i].ID.Compare(categories[j].ID) < 0
+	})
+	return categories, nil
+}
+
+func (r *memoryReviewRepo) GetReviewCategory(id models.ID) (*models.ReviewCategory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	category, ok := r.categories[id]
+	if !ok {
+		return nil, errs.NotFound
+	}
+	return cloneReviewCategory(category), nil
+}
+
+func (r *memoryReviewRepo) CreateReviewCategory(actorID models.ID, req *models.CreateReviewCategoryRequest) (models.ID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.categoryNameTaken(req.Name, models.NilID) {
+		return models.NilID, errs.AlreadyExists
+	}
+	category := newReviewCategory(actorID, req)
+	r.categories[category.ID] = category
+	return category.ID, nil
+}
+
+func (r *memoryReviewRepo) UpdateReviewCategory(actorID, id models.ID, version int64, req *models.UpdateReviewCategoryRequest) (*models.ReviewCategory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	category, ok := r.categories[id]
+	if !ok {
+		return nil, errs.NotFound
+	}
+	if category.Version != version {
+		return nil, errs.Conflict
+	}
+
+	updated := cloneReviewCategory(category)
+	if req.Name != nil {
+		if r.categoryNameTaken(*req.Name, id) {
+			return nil, errs.AlreadyExists
+		}
+		updated.Name = *req.Name
+	}
+	if req.Order != nil {
+		updated.Order = *req.Order
+	}
+	updated.Version++
+	updated.Audit = touch(updated.Audit, actorID)
+	r.categories[id] = updated
+	return cloneReviewCategory(updated), nil
+}
+
+func (r *memoryReviewRepo) ArchiveReviewCategory(actorID, id models.ID, archived bool) (*models.ReviewCategory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	category, ok := r.categories[id]
+	if !ok {
+		return nil, errs.NotFound
+	}
+	if (category.Archived != nil) == archived {
+		return cloneReviewCategory(category), nil
+	}
+
+	updated := cloneReviewCategory(category)
+	updated.Archived = nil
+	if archived {
+		now := timestamp()
+		updated.Archived = &now
+	}
+	updated.Version++
+	updated.Audit = touch(updated.Audit, actorID)
+	r.categories[id] = updated
+	return cloneReviewCategory(updated), nil
+}
+
+func (r *memoryReviewRepo) CountReviewsByCategory() (map[models.ID]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := map[models.ID]int{}
+	for _, review := range r.reviews {
+		if isLiveReview(review) && !review.ReviewCategoryID.IsZero() {
+			counts[review.ReviewCategoryID]++
+		}
+	}
+	return counts, nil
+}
+
+// categoryNameTaken reports whether a category other than except is called
+// name.
+func (r *memoryReviewRepo) categoryNameTaken(name string, except models.ID) bool {
+	for id, category := range r.categories {
+		if category.Name == name && id != except {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryReviewRepo) UpdateReview(actorID, reviewID models.ID, version int64, review *models.Review) (*models.Review, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.reviews[reviewID]
+	if !ok || !isLiveReview(stored) {
+		return nil, errs.NotFound
+	}
+	if stored.Version != version {
+		return nil, errs.Conflict
+	}
+
+	replacement := cloneReview(review)
+	replacement.ID = reviewID
+	replacement.Version = version + 1
+	replacement.Audit = touch(cloneAudit(stored.Audit), actorID)
+	r.reviews[reviewID] = replacement
+	return cloneReview(replacement), nil
+}
+
+func (r *memoryReviewRepo) GetReviewByID(reviewID models.ID) (*models.Review, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	review, ok := r.reviews[reviewID]
+	if !ok || !isLiveReview(review) {
+		return nil, errs.NotFound
+	}
+	return cloneReview(review), nil
+}
+
+func (r *memoryReviewRepo) CreateReview(actorID models.ID, review *models.Review) (models.ID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := cloneReview(review)
+	if stored.ID.IsZero() {
+		stored.ID = models.NewID()
+	}
+	stored.Version = 1
+	stored.Audit = newAudit(actorID)
+	if _, exists := r.reviews[stored.ID]; exists {
+		return models.NilID, errs.AlreadyExists
+	}
+
+	r.reviews[stored.ID] = stored
+	return stored.ID, nil
+}
+
+func (r *memoryReviewRepo) DeleteReview(actorID, reviewID models.ID, version int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	review, ok := r.reviews[reviewID]
+	if !ok || !isLiveReview(review) {
+		return errs.NotFound
+	}
+	if review.Version != version {
+		return errs.Conflict
+	}
+
+	deleted := cloneReview(review)
+	now := timestamp()
+	deleted.Deleted = &now
+	deleted.Version++
+	deleted.Audit = touch(deleted.Audit, actorID)
+	r.reviews[reviewID] = deleted
+	return nil
+}
+
+func (r *memoryReviewRepo) RestoreReview(actorID, reviewID models.ID) (*models.Review, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	review, ok := r.reviews[reviewID]
+	if !ok || review.Deleted == nil {
+		return nil, errs.NotFound
+	}
+
+	restored := cloneReview(review)
+	restored.Deleted = nil
+	restored.Version++
+	restored.Audit = touch(restored.Audit, actorID)
+	r.reviews[reviewID] = restored
+	return cloneReview(restored), nil
+}
+
+func (r *memoryReviewRepo) PurgeReview(reviewID models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	review, ok := r.reviews[reviewID]
+	if !ok || review.Deleted == nil {
+		return errs.NotFound
+	}
+	delete(r.reviews, reviewID)
+	return nil
+}
+
+func (r *memoryReviewRepo) DeleteReviewsByMovieID(movieID models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, review := range r.reviews {
+		if review.MovieID == movieID {
+			delete(r.reviews, id)
+		}
+	}
+	return nil
+}
+
+func (r *memoryReviewRepo) MoveReviews(actorID, from, to models.ID) ([]*models.Review, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	moved := []*models.Review{}
+	for id, review := range r.reviews {
+		if review.MovieID != from {
+			continue
+		}
+		review = cloneReview(review)
+		review.MovieID = to
+		review.Version++
+		review.Audit = touch(review.Audit, actorID)
+		r.reviews[id] = review
+		moved = append(moved, cloneReview(review))
+	}
+	return moved, nil
+}
+
+func (r *memoryReviewRepo) TrashReviewsWithMovie(movieID models.ID, trashed bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, review := range r.reviews {
+		if review.MovieID == movieID && review.MovieTrashed != trashed {
+			review = cloneReview(review)
+			review.MovieTrashed = trashed
+			r.reviews[id] = review
+		}
+	}
+	return nil
+}
+
+func (r *memoryReviewRepo) TrashReviewsWithOwner(ownerID models.ID, trashed bool) ([]*models.Review, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	changed := []*models.Review{}
+	for id, review := range r.reviews {
+		if review.OwnerID != ownerID || review.OwnerTrashed == trashed {
+			continue
+		}
+		review = cloneReview(review)
+		review.OwnerTrashed = trashed
+		r.reviews[id] = review
+		changed = append(changed, cloneReview(review))
+	}
+	return changed, nil
+}
+
+func (r *memoryReviewRepo) AnonymizeReviews(ownerID models.ID) ([]*models.Review, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	shown := []*models.Review{}
+	for id, review := range r.reviews {
+		if review.OwnerID != ownerID {
+			continue
+		}
+		if review.IsPrivate {
+			delete(r.reviews, id)
+			continue
+		}
+
+		anonymized := cloneReview(review)
+		anonymized.OwnerID = models.NilID
+		anonymized.OwnerTrashed = false
+		anonymized.Version++
+		anonymized.Audit = touch(models.Audit{CreatedAt: anonymized.CreatedAt}, models.NilID)
+		r.reviews[id] = anonymized
+		if review.OwnerTrashed {
+			shown = append(shown, cloneReview(anonymized))
+		}
+	}
+	return shown, nil
+}
+
+// isLiveReview reports whether a review is neither in the trash nor hidden
+// because its movie or its owner is.
+func isLiveReview(review *models.Review) bool {
+	return review.Deleted == nil && !review.MovieTrashed && !review.OwnerTrashed
+}
+
+func cloneReview(review *models.Review) *models.Review {
+	clone := *review
+	if review.Content != nil {
+		content := *review.Content
+		clone.Content = &content
+	}
+	if review.Deleted != nil {
+		deleted := *review.Deleted
+		clone.Deleted = &deleted
+	}
+	clone.Audit = cloneAudit(review.Audit)
+	return &clone
+}
+
+func cloneReviewCategory(category *models.ReviewCategory) *models.ReviewCategory {
+	clone := *category
+	if category.Archived != nil {
+		archived := *category.Archived
+		clone.Archived = &archived
+	}
+	if category.Usage != nil {
+		usage := *category.Usage
+		clone.Usage = &usage
+	}
+	clone.Audit = cloneAudit(category.Audit)
+	return &clone
+}
@@ -0,0 +1,390 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.uber.org/zap"
+)
+
+type sqlReviewRepo struct {
+	sqlConn
+}
+
+// NewSQLReviewRepo returns a ReviewRepo backed by the reviews and
+// review_categories tables of a Postgres or SQLite database opened with
+// db.OpenSQL. The default review categories are seeded into an empty table.
+func NewSQLReviewRepo(log *zap.Logger, db *sql.DB) ReviewRepo {
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM review_categories`).Scan(&count); err != nil {
+		log.Fatal("couldn't initialize repository: ", zap.Error(err))
+	}
+
+	repo := &sqlReviewRepo{
+		sqlConn: sqlConn{db: db},
+	}
+	if count == 0 {
+		for i, name := range defaultReviewCategories {
+			if _, err := repo.CreateReviewCategory(models.NilID, &models.CreateReviewCategoryRequest{Name: name, Order: i}); err != nil {
+				log.Fatal("couldn't initialize repository: ", zap.Error(err))
+			}
+		}
+	}
+	return repo
+}
+
+const selectReviewCategory = `SELECT id, name, sort_order, archived, version, ` + auditColumns + ` FROM review_categories`
+
+const selectReview = `SELECT id, movie_id, owner_id, review_category_id, rating, content, deleted, is_private, version, movie_trashed, owner_trashed, ` + auditColumns + ` FROM reviews`
+
+// liveReviewSQL is the condition of reviews that are neither in the trash
+// nor hidden because their movie or their owner is.
+const liveReviewSQL = `deleted IS NULL AND movie_trashed = FALSE AND owner_trashed = FALSE`
+
+func (r *sqlReviewRepo) ListReviewsByMovieID(actorID models.ID, movieID models.ID, opts models.ListOptions) (models.Page[*models.Review], error) {
+	return r.findPage(`WHERE `+liveReviewSQL+` AND movie_id = $1 AND (is_private = FALSE OR owner_id = $2)`, opts, movieID, actorID)
+}
+
+func (r *sqlReviewRepo) ListOwnReviewsByMovieID(actorID models.ID, movieID models.ID) ([]*models.Review, error) {
+	return r.find(`WHERE `+liveReviewSQL+` AND movie_id = $1 AND owner_id = $2`, models.ListOptions{}, movieID, actorID)
+}
+
+func (r *sqlReviewRepo) ListMyReviews(actorID models.ID, opts models.ListOptions) (models.Page[*models.Review], error) {
+	return r.findPage(`WHERE `+liveReviewSQL+` AND owner_id = $1`, opts, actorID)
+}
+
+func (r *sqlReviewRepo) ListDeletedReviews() ([]*models.Review, error) {
+	return r.find(`WHERE deleted IS NOT NULL`, models.ListOptions{})
+}
+
+func (r *sqlReviewRepo) findPage(where string, opts models.ListOptions, args ...any) (models.Page[*models.Review], error) {
+	reviews, err := r.find(where, opts, args...)
+	if err != nil {
+		return models.Page[*models.Review]{}, err
+	}
+	return page(reviews, opts), nil
+}
+
+func (r *sqlReviewRepo) find(where string, opts models.ListOptions, args ...any) ([]*models.Review, error) {
+	query, args, err := sqlList(selectReview+` `+where, args, opts, models.ReviewListFields)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.q().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := []*models.Review{}
+	for rows.Next() {
+		review, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, review)
+	}
+	return reviews, rows.Err()
+}
+
+func (r *sqlReviewRepo) ListReviewCategories(includeArchived bool) ([]*models.ReviewCategory, error) {
+	query := selectReviewCategory + ` WHERE archived IS NULL ORDER BY sort_order, id`
+	if includeArchived {
+		query = selectReviewCategory + ` ORDER BY sort_order, id`
+	}
+	rows, err := r.q().Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*models.ReviewCategory{}
+	for rows.Next() {
+		category, err := scanReviewCategory(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, category)
+	}
+	return categories, rows.Err()
+}
+
+func (r *sqlReviewRepo) GetReviewCategory(id models.ID) (*models.ReviewCategory, error) {
+	category, err := scanReviewCategory(r.q().QueryRow(selectReviewCategory+` WHERE id = $1`, id))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	return category, nil
+}
+
+func (r *sqlReviewRepo) CreateReviewCategory(actorID models.ID, req *models.CreateReviewCategoryRequest) (models.ID, error) {
+	category := newReviewCategory(actorID, req)
+
+	args := append([]any{category.ID, category.Name, category.Order, category.Version}, auditArgs(category.Audit)...)
+	_, err := r.q().Exec(`INSERT INTO review_categories (id, name, sort_order, version, `+auditColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		args...)
+	if err != nil {
+		return models.NilID, sqlErr(err)
+	}
+	return category.ID, nil
+}
+
+func (r *sqlReviewRepo) UpdateReviewCategory(actorID, id models.ID, version int64, req *models.UpdateReviewCategoryRequest) (*models.ReviewCategory, error) {
+	category, err := r.GetReviewCategory(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		category.Name = *req.Name
+	}
+	if req.Order != nil {
+		category.Order = *req.Order
+	}
+	category.Audit = touch(category.Audit, actorID)
+
+	res, err := r.q().Exec(`UPDATE review_categories SET name = $2, sort_order = $3, updated_at = $5, updated_by = $6, version = version + 1 WHERE id = $1 AND version = $4`,
+		id, category.Name, category.Order, version, sqlTime(category.UpdatedAt), sqlID(category.UpdatedBy))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, errs.Conflict
+	}
+	category.Version = version + 1
+	return category, nil
+}
+
+func (r *sqlReviewRepo) ArchiveReviewCategory(actorID, id models.ID, archived bool) (*models.ReviewCategory, error) {
+	audit := touch(models.Audit{}, actorID)
+	query := `UPDATE review_categories SET archived = $2, updated_at = $2, updated_by = $3, version = version + 1 WHERE id = $1 AND archived IS NULL`
+	args := []any{id, sqlTime(audit.UpdatedAt), sqlID(audit.UpdatedBy)}
+	if !archived {
+		query = `UPDATE review_categories SET archived = NULL, updated_at = $2, updated_by = $3, version = version + 1 WHERE id = $1 AND archived IS NOT NULL`
+	}
+	if _, err := r.q().Exec(query, args...); err != nil {
+		return nil, sqlErr(err)
+	}
+	return r.GetReviewCategory(id)
+}
+
+func (r *sqlReviewRepo) CountReviewsByCategory() (map[models.ID]int, error) {
+	rows, err := r.q().Query(`SELECT review_category_id, COUNT(*) FROM reviews WHERE `+liveReviewSQL+` AND review_category_id <> $1 GROUP BY review_category_id`, models.NilID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[models.ID]int{}
+	for rows.Next() {
+		var (
+			id    models.ID
+			count int
+		)
+		if err := rows.Scan(&id, &count); err != nil {
+			return nil, err
+		}
+		counts[id] = count
+	}
+	return counts, rows.Err()
+}
+
+func (r *sqlReviewRepo) UpdateReview(actorID, reviewID models.ID, version int64, review *models.Review) (*models.Review, error) {
+	stored, err := r.GetReviewByID(reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	replacement := *review
+	replacement.ID = reviewID
+	replacement.Version = version + 1
+	replacement.Audit = touch(stored.Audit, actorID)
+
+	res, err := r.q().Exec(`UPDATE reviews SET movie_id = $2, owner_id = $3, review_category_id = $4, rating = $5, content = $6, deleted = $7, is_private = $8, version = $9, updated_at = $11, updated_by = $12 WHERE id = $1 AND version = $10 AND `+liveReviewSQL,
+		reviewID, replacement.MovieID, replacement.OwnerID, replacement.ReviewCategoryID, replacement.Rating,
+		sqlString(replacement.Content), sqlTime(replacement.Deleted), replacement.IsPrivate,
+		replacement.Version, version, sqlTime(replacement.UpdatedAt), sqlID(replacement.UpdatedBy))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, sqlStale(r.q(), "reviews", reviewID)
+	}
+	return &replacement, nil
+}
+
+func (r *sqlReviewRepo) GetReviewByID(reviewID models.ID) (*models.Review, error) {
+	review, err := scanReview(r.q().QueryRow(selectReview+` WHERE id = $1 AND `+liveReviewSQL, reviewID))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	return review, nil
+}
+
+func (r *sqlReviewRepo) CreateReview(actorID models.ID, review *models.Review) (models.ID, error) {
+	id := review.ID
+	if id.IsZero() {
+		id = models.NewID()
+	}
+
+	args := append([]any{id, review.MovieID, review.OwnerID, review.ReviewCategoryID, review.Rating,
+		sqlString(review.Content), sqlTime(review.Deleted), review.IsPrivate}, auditArgs(newAudit(actorID))...)
+	_, err := r.q().Exec(`INSERT INTO reviews (id, movie_id, owner_id, review_category_id, rating, content, deleted, is_private, version, `+auditColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 1, $9, $10, $11, $12)`,
+		args...)
+	if err != nil {
+		return models.NilID, sqlErr(err)
+	}
+	return id, nil
+}
+
+func (r *sqlReviewRepo) DeleteReview(actorID, reviewID models.ID, version int64) error {
+	audit := touch(models.Audit{}, actorID)
+	res, err := r.q().Exec(`UPDATE reviews SET deleted = $2, updated_at = $2, updated_by = $3, version = version + 1 WHERE id = $1 AND version = $4 AND `+liveReviewSQL,
+		reviewID, sqlTime(audit.UpdatedAt), sqlID(audit.UpdatedBy), version)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return sqlStale(r.q(), "reviews", reviewID)
+	}
+	return nil
+}
+
+func (r *sqlReviewRepo) RestoreReview(actorID, reviewID models.ID) (*models.Review, error) {
+	audit := touch(models.Audit{}, actorID)
+	res, err := r.q().Exec(`UPDATE reviews SET deleted = NULL, updated_at = $2, updated_by = $3, version = version + 1 WHERE id = $1 AND deleted IS NOT NULL`,
+		reviewID, sqlTime(audit.UpdatedAt), sqlID(audit.UpdatedBy))
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, errs.NotFound
+	}
+	return r.GetReviewByID(reviewID)
+}
+
+func (r *sqlReviewRepo) PurgeReview(reviewID models.ID) error {
+	res, err := r.q().Exec(`DELETE FROM reviews WHERE id = $1 AND deleted IS NOT NULL`, reviewID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return errs.NotFound
+	}
+	return nil
+}
+
+func (r *sqlReviewRepo) DeleteReviewsByMovieID(movieID models.ID) error {
+	_, err := r.q().Exec(`DELETE FROM reviews WHERE movie_id = $1`, movieID)
+	return err
+}
+
+func (r *sqlReviewRepo) MoveReviews(actorID, from, to models.ID) ([]*models.Review, error) {
+	reviews, err := r.find(`WHERE movie_id = $1`, models.ListOptions{}, from)
+	if err != nil {
+		return nil, err
+	}
+
+	audit := touch(models.Audit{}, actorID)
+	_, err = r.q().Exec(`UPDATE reviews SET movie_id = $2, updated_at = $3, updated_by = $4, version = version + 1 WHERE movie_id = $1`,
+		from, to, sqlTime(audit.UpdatedAt), sqlID(audit.UpdatedBy))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	for _, review := range reviews {
+		review.MovieID = to
+		review.Version++
+		review.Audit.UpdatedAt, review.Audit.UpdatedBy = audit.UpdatedAt, audit.UpdatedBy
+	}
+	return reviews, nil
+}
+
+func (r *sqlReviewRepo) TrashReviewsWithMovie(movieID models.ID, trashed bool) error {
+	_, err := r.q().Exec(`UPDATE reviews SET movie_trashed = $2 WHERE movie_id = $1`, movieID, trashed)
+	return err
+}
+
+func (r *sqlReviewRepo) TrashReviewsWithOwner(ownerID models.ID, trashed bool) ([]*models.Review, error) {
+	reviews, err := r.find(`WHERE owner_id = $1 AND owner_trashed <> $2`, models.ListOptions{}, ownerID, trashed)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := r.q().Exec(`UPDATE reviews SET owner_trashed = $2 WHERE owner_id = $1`, ownerID, trashed); err != nil {
+		return nil, err
+	}
+	for _, review := range reviews {
+		review.OwnerTrashed = trashed
+	}
+	return reviews, nil
+}
+
+func (r *sqlReviewRepo) AnonymizeReviews(ownerID models.ID) ([]*models.Review, error) {
+	shown, err := r.find(`WHERE owner_id = $1 AND is_private = FALSE AND owner_trashed = TRUE`, models.ListOptions{}, ownerID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := timestamp()
+	err = r.inTx(func(tx querier) error {
+		if _, err := tx.Exec(`DELETE FROM reviews WHERE owner_id = $1 AND is_private = TRUE`, ownerID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`UPDATE reviews SET owner_id = $2, owner_trashed = FALSE, updated_at = $3, created_by = NULL, updated_by = NULL, version = version + 1 WHERE owner_id = $1`,
+			ownerID, models.NilID, sqlTime(&now))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, review := range shown {
+		review.OwnerID = models.NilID
+		review.OwnerTrashed = false
+		review.Version++
+		review.Audit = models.Audit{CreatedAt: review.CreatedAt, UpdatedAt: &now}
+	}
+	return shown, nil
+}
+
+func scanReview(row rowScanner) (*models.Review, error) {
+	var (
+		review  models.Review
+		content sql.NullString
+		deleted sql.NullTime
+		audit   auditScan
+	)
+	dest := append([]any{&review.ID, &review.MovieID, &review.OwnerID, &review.ReviewCategoryID, &review.Rating, &content, &deleted, &review.IsPrivate, &review.Version, &review.MovieTrashed, &review.OwnerTrashed}, audit.dest()...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	review.Content = scanNullString(content)
+	review.Deleted = scanNullTime(deleted)
+	review.Audit = audit.audit()
+	return &review, nil
+}
+
+func scanReviewCategory(row rowScanner) (*models.ReviewCategory, error) {
+	var (
+		category models.ReviewCategory
+		archived sql.NullTime
+		audit    auditScan
+	)
+	dest := append([]any{&category.ID, &category.Name, &category.Order, &archived, &category.Version}, audit.dest()...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	category.Archived = scanNullTime(archived)
+	category.Audit = audit.audit()
+	return &category, nil
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// RevisionRepo stores the history of movies. Revisions are never changed
+// once written; they stay while their movie is in the trash and go when it
+// is purged.
+type RevisionRepo interface {
+	ListMovieRevisions(movieID models.ID, opts models.ListOptions) (models.Page[*models.MovieRevision], error)
+	// GetMovieRevision returns the revision that produced version of a
+	// movie.
+	GetMovieRevision(movieID models.ID, version int64) (*models.MovieRevision, error)
+	// CreateMovieRevision stores rev as made by actorID now, filling in its
+	// ID and stamps.
+	CreateMovieRevision(actorID models.ID, rev *models.MovieRevision) (models.ID, error)
+	DeleteMovieRevisions(movieID models.ID) error
+}
+
+const revisionsCollection = "movieRevisions"
+
+type revisionRepo struct {
+	ctx        context.Context
+	collection *mongo.Collection
+}
+
+func NewRevisionRepo(log *zap.Logger, collNames map[string]int, db *mongo.Database) RevisionRepo {
+	var collectionName = revisionsCollection
+
+	if _, exists := collNames[collectionName]; !exists {
+		if err := db.CreateCollection(context.TODO(), collectionName); err != nil {
+			log.Fatal("couldn't initialize repository: ", zap.Error(err))
+		}
+	}
+
+	indexes := listIndexes(bson.D{{Key: "movieId", Value: 1}}, "version")
+	if _, err := db.Collection(collectionName).Indexes().CreateMany(context.TODO(), indexes); err != nil {
+		log.Fatal("couldn't initialize repository: ", zap.Error(err))
+	}
+
+	return &revisionRepo{
+		ctx:        context.TODO(),
+		collection: db.Collection(collectionName),
+	}
+}
+
+func (r *revisionRepo) ListMovieRevisions(movieID models.ID, opts models.ListOptions) (models.Page[*models.MovieRevision], error) {
+	filter, findOpts, err := mongoList(bson.M{"movieId": movieID}, opts, models.RevisionListFields)
+	if err != nil {
+		return models.Page[*models.MovieRevision]{}, err
+	}
+	cur, err := r.collection.Find(r.ctx, filter, findOpts)
+	if err != nil {
+		return models.Page[*models.MovieRevision]{}, err
+	}
+
+	revisions := []*models.MovieRevision{}
+	if err := cur.All(r.ctx, &revisions); err != nil {
+		return models.Page[*models.MovieRevision]{}, err
+	}
+	return page(revisions, opts), nil
+}
+
+func (r *revisionRepo) GetMovieRevision(movieID models.ID, version int64) (*models.MovieRevision, error) {
+	var rev models.MovieRevision
+	if err := r.collection.FindOne(r.ctx, bson.M{"movieId": movieID, "version": version}).Decode(&rev); err != nil {
+		return nil, mongoErr(err)
+	}
+	return &rev, nil
+}
+
+func (r *revisionRepo) CreateMovieRevision(actorID models.ID, rev *models.MovieRevision) (models.ID, error) {
+	rev = newRevision(actorID, rev)
+	if _, err := r.collection.InsertOne(r.ctx, rev); err != nil {
+		return models.NilID, mongoErr(err)
+	}
+	return rev.ID, nil
+}
+
+func (r *revisionRepo) DeleteMovieRevisions(movieID models.ID) error {
+	_, err := r.collection.DeleteMany(r.ctx, bson.M{"movieId": movieID})
+	return mongoErr(err)
+}
+
+// newRevision returns a copy of rev stamped as made by actorID now.
+func newRevision(actorID models.ID, rev *models.MovieRevision) *models.MovieRevision {
+	stamped := *rev
+	stamped.ID = models.NewID()
+	stamped.CreatedAt = timestamp()
+	stamped.CreatedBy = nil
+	if !actorID.IsZero() {
+		createdBy := actorID
+		stamped.CreatedBy = &createdBy
+	}
+	return &stamped
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type memoryRevisionRepo struct {
+	mu        sync.RWMutex
+	revisions map[models.ID]*models.MovieRevision
+}
+
+// NewMemoryRevisionRepo returns a RevisionRepo that keeps revisions in
+// process memory. It is safe for concurrent use and behaves like the Mongo
+// implementation.
+func NewMemoryRevisionRepo() RevisionRepo {
+	return &memoryRevisionRepo{
+		revisions: make(map[models.ID]*models.MovieRevision),
+	}
+}
+
+func (r *memoryRevisionRepo) ListMovieRevisions(movieID models.ID, opts models.ListOptions) (models.Page[*models.MovieRevision], error) {
+	r.mu.RLock()
+	revisions := []*models.MovieRevision{}
+	for _, rev := range r.revisions {
+		if rev.MovieID == movieID {
+			revisions = append(revisions, cloneRevision(rev))
+		}
+	}
+	r.mu.RUnlock()
+
+	return memoryList(revisions, opts, models.RevisionListFields)
+}
+
+func (r *memoryRevisionRepo) GetMovieRevision(movieID models.ID, version int64) (*models.MovieRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, rev := range r.revisions {
+		if rev.MovieID == movieID && rev.Version == version {
+			return cloneRevision(rev), nil
+		}
+	}
+	return nil, errs.NotFound
+}
+
+func (r *memoryRevisionRepo) CreateMovieRevision(actorID models.ID, rev *models.MovieRevision) (models.ID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := cloneRevision(newRevision(actorID, rev))
+	r.revisions[stored.ID] = stored
+	return stored.ID, nil
+}
+
+func (r *memoryRevisionRepo) DeleteMovieRevisions(movieID models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, rev := range r.revisions {
+		if rev.MovieID == movieID {
+			delete(r.revisions, id)
+		}
+	}
+	return nil
+}
+
+func cloneRevision(rev *models.MovieRevision) *models.MovieRevision {
+	clone := *rev
+	clone.Before = cloneMovieFields(rev.Before)
+	clone.After = cloneMovieFields(rev.After)
+	if rev.RevertedTo != nil {
+		revertedTo := *rev.RevertedTo
+		clone.RevertedTo = &revertedTo
+	}
+	if rev.CreatedBy != nil {
+		createdBy := *rev.CreatedBy
+		clone.CreatedBy = &createdBy
+	}
+	return &clone
+}
+
+func cloneMovieFields(fields models.MovieFields) models.MovieFields {
+	clone := fields
+	if fields.Title != nil {
+		title := *fields.Title
+		clone.Title = &title
+	}
+	if fields.Year != nil {
+		year := *fields.Year
+		clone.Year = &year
+	}
+	if fields.DirectorID != nil {
+		directorID := *fields.DirectorID
+		clone.DirectorID = &directorID
+	}
+	if fields.GenreID != nil {
+		genreID := *fields.GenreID
+		clone.GenreID = &genreID
+	}
+	if fields.ImageURL != nil {
+		imageURL := *fields.ImageURL
+		clone.ImageURL = &imageURL
+	}
+	if fields.Poster != nil {
+		poster := *fields.Poster
+		poster.Variants = slices.Clone(poster.Variants)
+		clone.Poster = &poster
+	}
+	return clone
+}
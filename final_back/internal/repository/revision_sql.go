@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type sqlRevisionRepo struct {
+	sqlConn
+}
+
+// NewSQLRevisionRepo returns a RevisionRepo backed by the movie_revisions
+// table of a Postgres or SQLite database opened with db.OpenSQL. The fields
+// before and after a revision are kept as JSON objects.
+func NewSQLRevisionRepo(db *sql.DB) RevisionRepo {
+	return &sqlRevisionRepo{
+		sqlConn: sqlConn{db: db},
+	}
+}
+
+const selectRevision = `SELECT id, movie_id, version, action, old_fields, new_fields, reverted_to, created_by, created_at FROM movie_revisions`
+
+func (r *sqlRevisionRepo) ListMovieRevisions(movieID models.ID, opts models.ListOptions) (models.Page[*models.MovieRevision], error) {
+	query, args, err := sqlList(selectRevision+` WHERE movie_id = $1`, []any{movieID}, opts, models.RevisionListFields)
+	if err != nil {
+		return models.Page[*models.MovieRevision]{}, err
+	}
+	rows, err := r.q().Query(query, args...)
+	if err != nil {
+		return models.Page[*models.MovieRevision]{}, err
+	}
+	defer rows.Close()
+
+	revisions := []*models.MovieRevision{}
+	for rows.Next() {
+		rev, err := scanRevision(rows)
+		if err != nil {
+			return models.Page[*models.MovieRevision]{}, err
+		}
+		revisions = append(revisions, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return models.Page[*models.MovieRevision]{}, err
+	}
+	return page(revisions, opts), nil
+}
+
+func (r *sqlRevisionRepo) GetMovieRevision(movieID models.ID, version int64) (*models.MovieRevision, error) {
+	rev, err := scanRevision(r.q().QueryRow(selectRevision+` WHERE movie_id = $1 AND version = $2`, movieID, version))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	return rev, nil
+}
+
+func (r *sqlRevisionRepo) CreateMovieRevision(actorID models.ID, rev *models.MovieRevision) (models.ID, error) {
+	rev = newRevision(actorID, rev)
+
+	before, err := json.Marshal(rev.Before)
+	if err != nil {
+		return models.NilID, err
+	}
+	after, err := json.Marshal(rev.After)
+	if err != nil {
+		return models.NilID, err
+	}
+	var revertedTo any
+	if rev.RevertedTo != nil {
+		revertedTo = *rev.RevertedTo
+	}
+	_, err = r.q().Exec(`INSERT INTO movie_revisions (id, movie_id, version, action, old_fields, new_fields, reverted_to, created_by, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		rev.ID, rev.MovieID, rev.Version, string(rev.Action), string(before), string(after), revertedTo, sqlID(rev.CreatedBy), sqlTime(&rev.CreatedAt))
+	if err != nil {
+		return models.NilID, sqlErr(err)
+	}
+	return rev.ID, nil
+}
+
+func (r *sqlRevisionRepo) DeleteMovieRevisions(movieID models.ID) error {
+	_, err := r.q().Exec(`DELETE FROM movie_revisions WHERE movie_id = $1`, movieID)
+	return err
+}
+
+func scanRevision(row rowScanner) (*models.MovieRevision, error) {
+	var (
+		rev           models.MovieRevision
+		action        string
+		before, after string
+		revertedTo    sql.NullInt64
+		createdAt     sql.NullTime
+	)
+	if err := row.Scan(&rev.ID, &rev.MovieID, &rev.Version, &action, &before, &after, &revertedTo, &rev.CreatedBy, &createdAt); err != nil {
+		return nil, err
+	}
+	rev.Action = models.RevisionAction(action)
+	if err := json.Unmarshal([]byte(before), &rev.Before); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(after), &rev.After); err != nil {
+		return nil, err
+	}
+	if revertedTo.Valid {
+		rev.RevertedTo = &revertedTo.Int64
+	}
+	if t := scanNullTime(createdAt); t != nil {
+		rev.CreatedAt = *t
+	}
+	return &rev, nil
+}
@@ -0,0 +1,246 @@
+package repository
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// SearchIndex finds live movies by free text. Implementations differ in how
+// they find candidate movies but rank them the same way, by how well their
+// title (and year) match the query, then by popularity and recency.
+type SearchIndex interface {
+	// IndexMovie adds or replaces a movie in the index.
+	IndexMovie(movie *models.Movie) error
+	// RemoveMovie drops a movie from the index. Removing a movie that is not
+	// indexed is not an error.
+	RemoveMovie(id models.ID) error
+	// SearchMovies returns up to limit hits for query, best first.
+	SearchMovies(query string, limit int) ([]models.SearchHit, error)
+}
+
+// IndexMovies adds every live movie in movies to index, which is how indexes
+// that are not kept by the database are built at startup.
+func IndexMovies(index SearchIndex, movies MovieRepo) error {
+	page, err := movies.ListMovies(models.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, movie := range page.Items {
+		if err := index.IndexMovie(movie); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Weights of the ranking signals. A title match dominates; popularity and
+// recency mostly order movies that match equally well.
+const (
+	titleWeight       = 10.0
+	exactTitleBonus   = 5.0
+	titlePrefixBonus  = 2.0
+	popularityWeight  = 2.0
+	recencyWeight     = 1.0
+	exactTermScore    = 1.0
+	prefixTermScore   = 0.5
+	typoTermScore     = 0.6
+	typoPenalty       = 0.15
+	searchCandidates  = 500
+	earliestMovieYear = 1888
+)
+
+var folder = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// searchTokens splits text into lower-case words without diacritics, so that
+// "Amélie" and "amelie" are the same word.
+func searchTokens(text string) []string {
+	folded, _, err := transform.String(folder, text)
+	if err != nil {
+		folded = text
+	}
+	return strings.FieldsFunc(strings.ToLower(folded), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// NormalizeTitle reduces a movie title to the words that tell it apart, so
+// that "The Matrix (1999)" and "Matrix" normalize the same for a 1999 movie.
+// Case, diacritics, punctuation, a leading article and a trailing release
+// year are dropped.
+func NormalizeTitle(title string, year int) string {
+	tokens := searchTokens(title)
+	if len(tokens) > 1 && year != 0 && tokens[len(tokens)-1] == strconv.Itoa(year) {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if len(tokens) > 1 && slices.Contains([]string{"the", "a", "an"}, tokens[0]) {
+		tokens = tokens[1:]
+	}
+	return strings.Join(tokens, " ")
+}
+
+func isNumber(token string) bool {
+	return strings.IndexFunc(token, func(r rune) bool { return !unicode.IsDigit(r) }) < 0
+}
+
+// maxTypos is how many edits a query word may be away from a word it
+// matches. Short words and numbers must match exactly.
+func maxTypos(token string) int {
+	switch n := len([]rune(token)); {
+	case isNumber(token) || n < 4:
+		return 0
+	case n < 8:
+		return 1
+	}
+	return 2
+}
+
+// searchDoc is what the ranking knows about a movie.
+type searchDoc struct {
+	id         models.ID
+	title      []string
+	year       int
+	popularity float64
+}
+
+func newSearchDoc(movie *models.Movie) searchDoc {
+	return searchDoc{
+		id:         movie.ID,
+		title:      searchTokens(movie.Title),
+		year:       movie.Year,
+		popularity: movie.Ratings.Score / models.MaxRating,
+	}
+}
+
+// terms are the words a query can match: the title's and the year.
+func (d searchDoc) terms() []string {
+	if d.year == 0 {
+		return d.title
+	}
+	return append(slices.Clone(d.title), strconv.Itoa(d.year))
+}
+
+// termScore rates how well a query word matches a word of a movie: exactly,
+// as its prefix, or with a few typos. Zero means it does not match.
+func termScore(query, term string) float64 {
+	if query == term {
+		return exactTermScore
+	}
+	if !isNumber(query) && strings.HasPrefix(term, query) {
+		return prefixTermScore * (1 + float64(len(query))/float64(len(term)))
+	}
+	limit := maxTypos(query)
+	if limit == 0 {
+		return 0
+	}
+	if d := editDistance(query, term, limit); d <= limit {
+		return typoTermScore - typoPenalty*float64(d-1)
+	}
+	return 0
+}
+
+// score ranks doc against the words of a query. ok is false unless every
+// word matches.
+func (d searchDoc) score(query []string) (score float64, ok bool) {
+	terms := d.terms()
+	match := 0.0
+	for _, q := range query {
+		best := 0.0
+		for _, term := range terms {
+			best = max(best, termScore(q, term))
+		}
+		if best == 0 {
+			return 0, false
+		}
+		match += best
+	}
+	score = titleWeight * match / float64(len(query))
+
+	title, phrase := strings.Join(d.title, " "), strings.Join(query, " ")
+	switch {
+	case title == phrase:
+		score += exactTitleBonus
+	case strings.HasPrefix(title, phrase):
+		score += titlePrefixBonus
+	}
+
+	score += popularityWeight * min(max(d.popularity, 0), 1)
+	if d.year > earliestMovieYear {
+		score += recencyWeight * min(float64(d.year-earliestMovieYear)/150, 1)
+	}
+	return score, true
+}
+
+// rankSearchDocs scores docs against query and returns the best limit hits,
+// breaking ties by ID.
+func rankSearchDocs(docs []searchDoc, query []string, limit int) []models.SearchHit {
+	hits := []models.SearchHit{}
+	if len(query) == 0 {
+		return hits
+	}
+	for _, doc := range docs {
+		if score, ok := doc.score(query); ok {
+			hits = append(hits, models.SearchHit{ID: doc.id, Score: score})
+		}
+	}
+	slices.SortFunc(hits, func(a, b models.SearchHit) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		return a.ID.Compare(b.ID)
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// editDistance returns the Damerau-Levenshtein (optimal string alignment)
+// distance between a and b, or limit+1 once it is known to exceed limit.
+func editDistance(a, b string, limit int) int {
+	s, t := []rune(a), []rune(b)
+	if abs(len(s)-len(t)) > limit {
+		return limit + 1
+	}
+
+	prev2 := make([]int, len(t)+1)
+	prev := make([]int, len(t)+1)
+	cur := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return min(prev[len(t)], limit+1)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type memorySearchIndex struct {
+	mu       sync.RWMutex
+	docs     map[models.ID]searchDoc
+	postings map[string]map[models.ID]struct{}
+	// terms is the sorted vocabulary, which prefix lookups binary search.
+	terms []string
+}
+
+// NewMemorySearchIndex returns a SearchIndex that keeps an inverted index of
+// movie titles in process memory. It is safe for concurrent use and is empty
+// until movies are added with IndexMovie or IndexMovies. Nothing keeps it in
+// sync with writes made by other processes, so a store it indexes must only
+// be written to by the process that holds it.
+func NewMemorySearchIndex() SearchIndex {
+	return &memorySearchIndex{
+		docs:     make(map[models.ID]searchDoc),
+		postings: make(map[string]map[models.ID]struct{}),
+	}
+}
+
+func (idx *memorySearchIndex) IndexMovie(movie *models.Movie) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(movie.ID)
+	doc := newSearchDoc(movie)
+	idx.docs[doc.id] = doc
+	for _, term := range doc.terms() {
+		ids, ok := idx.postings[term]
+		if !ok {
+			ids = make(map[models.ID]struct{})
+			idx.postings[term] = ids
+			i, _ := slices.BinarySearch(idx.terms, term)
+			idx.terms = slices.Insert(idx.terms, i, term)
+		}
+		ids[doc.id] = struct{}{}
+	}
+	return nil
+}
+
+func (idx *memorySearchIndex) RemoveMovie(id models.ID) error {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+	return nil
+}
+
+func (idx *memorySearchIndex) remove(id models.ID) {
+	doc, ok := idx.docs[id]
+	if !ok {
+		return
+	}
+	delete(idx.docs, id)
+	for _, term := range doc.terms() {
+		ids := idx.postings[term]
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(idx.postings, term)
+			if i, found := slices.BinarySearch(idx.terms, term); found {
+				idx.terms = slices.Delete(idx.terms, i, i+1)
+			}
+		}
+	}
+}
+
+func (idx *memorySearchIndex) SearchMovies(query string, limit int) ([]models.SearchHit, error) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	tokens := searchTokens(query)
+	candidates := make(map[models.ID]struct{})
+	for _, token := range tokens {
+		for _, term := range idx.matchingTerms(token) {
+			for id := range idx.postings[term] {
+				candidates[id] = struct{}{}
+			}
+		}
+	}
+
+	docs := make([]searchDoc, 0, len(candidates))
+	for id := range candidates {
+		docs = append(docs, idx.docs[id])
+	}
+	return rankSearchDocs(docs, tokens, limit), nil
+}
+
+// matchingTerms returns the words of the vocabulary token matches exactly,
+// as a prefix or with typos.
+func (idx *memorySearchIndex) matchingTerms(token string) []string {
+	var terms []string
+	if !isNumber(token) {
+		i, _ := slices.BinarySearch(idx.terms, token)
+		for ; i < len(idx.terms) && strings.HasPrefix(idx.terms[i], token); i++ {
+			terms = append(terms, idx.terms[i])
+		}
+	} else if _, ok := idx.postings[token]; ok {
+		terms = append(terms, token)
+	}
+
+	if limit := maxTypos(token); limit > 0 {
+		for _, term := range idx.terms {
+			if !strings.HasPrefix(term, token) && editDistance(token, term, limit) <= limit {
+				terms = append(terms, term)
+			}
+		}
+	}
+	return terms
+}
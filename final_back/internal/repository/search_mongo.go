@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+type mongoSearchIndex struct {
+	ctx        context.Context
+	collection *mongo.Collection
+}
+
+// NewMongoSearchIndex returns a SearchIndex over the movies collection,
+// backed by a text index on the title and an index on its folded words
+// (titleTerms), which the movie repository keeps up to date. IndexMovie and
+// RemoveMovie are therefore no-ops.
+func NewMongoSearchIndex(log *zap.Logger, db *mongo.Database) SearchIndex {
+	indexes := []mongo.IndexModel{
+		{Keys: bson.D{{Key: "title", Value: "text"}}},
+		{Keys: bson.D{{Key: "titleTerms", Value: 1}}},
+	}
+	if _, err := db.Collection(moviesCollection).Indexes().CreateMany(context.TODO(), indexes); err != nil {
+		log.Fatal("couldn't initialize search index: ", zap.Error(err))
+	}
+	if err := backfillTitleTerms(context.TODO(), db.Collection(moviesCollection)); err != nil {
+		log.Fatal("couldn't initialize search index: ", zap.Error(err))
+	}
+
+	return &mongoSearchIndex{
+		ctx:        context.TODO(),
+		collection: db.Collection(moviesCollection),
+	}
+}
+
+func (idx *mongoSearchIndex) IndexMovie(movie *models.Movie) error {
+	return nil
+}
+
+func (idx *mongoSearchIndex) RemoveMovie(id models.ID) error {
+	return nil
+}
+
+// backfillTitleTerms stores the folded title words of movies written before
+// the movie repository kept them.
+func backfillTitleTerms(ctx context.Context, movies *mongo.Collection) error {
+	cur, err := movies.Find(ctx, bson.M{"titleTerms": bson.M{"$exists": false}})
+	if err != nil {
+		return err
+	}
+	var stale []models.Movie
+	if err := cur.All(ctx, &stale); err != nil {
+		return err
+	}
+
+	for _, movie := range stale {
+		update := bson.M{"$set": bson.M{"titleTerms": searchTokens(movie.Title)}}
+		if _, err := movies.UpdateOne(ctx, bson.M{"_id": movie.ID}, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SearchMovies collects candidates with the text index, which matches whole
+// (stemmed) words, and with anchored regexes on the folded title words, which
+// catch prefixes and most typos and can use the titleTerms index. The
+// candidates are then ranked like the other implementations rank them.
+func (idx *mongoSearchIndex) SearchMovies(query string, limit int) ([]models.SearchHit, error) {
+	tokens := searchTokens(query)
+	if len(tokens) == 0 {
+		return []models.SearchHit{}, nil
+	}
+
+	candidates := map[models.ID]*models.Movie{}
+	find := func(filter bson.M, opts *options.FindOptions) error {
+		cur, err := idx.collection.Find(idx.ctx, live(filter), opts.SetLimit(searchCandidates))
+		if err != nil {
+			return err
+		}
+		movies := []*models.Movie{}
+		if err := cur.All(idx.ctx, &movies); err != nil {
+			return err
+		}
+		for _, movie := range movies {
+			candidates[movie.ID] = movie
+		}
+		return nil
+	}
+
+	// The best text matches are kept when there are more than can be
+	// ranked.
+	textScore := bson.M{"$meta": "textScore"}
+	opts := options.Find().SetProjection(bson.M{"score": textScore}).SetSort(bson.D{{Key: "score", Value: textScore}})
+	if err := find(bson.M{"$text": bson.M{"$search": query}}, opts); err != nil {
+		return nil, err
+	}
+	or := bson.A{}
+	for _, token := range tokens {
+		if year, err := strconv.Atoi(token); err == nil && isNumber(token) {
+			or = append(or, bson.M{"year": year})
+			continue
+		}
+		prefix := []rune(token)
+		if maxTypos(token) > 0 {
+			prefix = prefix[:2]
+		}
+		or = append(or, bson.M{"titleTerms": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(string(prefix))}})
+	}
+	if err := find(bson.M{"$or": or}, options.Find()); err != nil {
+		return nil, err
+	}
+
+	docs := make([]searchDoc, 0, len(candidates))
+	for _, movie := range candidates {
+		docs = append(docs, newSearchDoc(movie))
+	}
+	return rankSearchDocs(docs, tokens, limit), nil
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchTokens(t *testing.T) {
+	assert.Equal(t, []string{"amelie", "2001"}, searchTokens("Amélie (2001)"))
+	assert.Equal(t, []string{"wall", "e"}, searchTokens("WALL·E"))
+	assert.Empty(t, searchTokens(" -- "))
+}
+
+func TestNormalizeTitle(t *testing.T) {
+	assert.Equal(t, "matrix", NormalizeTitle("The Matrix (1999)", 1999))
+	assert.Equal(t, "amelie", NormalizeTitle("Amélie", 2001))
+	assert.Equal(t, "blade runner 2049", NormalizeTitle("Blade Runner 2049", 2017))
+	assert.Equal(t, "1917", NormalizeTitle("1917", 1917), "a title is never emptied")
+	assert.Equal(t, "the", NormalizeTitle("The", 0))
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{"matrix", "matrix", 2, 0},
+		{"matirx", "matrix", 2, 1},
+		{"matrx", "matrix", 2, 1},
+		{"matrixx", "matrix", 2, 1},
+		{"natrix", "matrix", 2, 1},
+		{"mtarxi", "matrix", 2, 2},
+		{"godfather", "matrix", 2, 3},
+		{"mat", "matrix", 1, 2},
+	} {
+		assert.Equal(t, tc.want, editDistance(tc.a, tc.b, tc.limit), "%s/%s", tc.a, tc.b)
+	}
+}
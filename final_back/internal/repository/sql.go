@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// sqlConn is embedded by the SQL repositories. tx is set when a repository is
+// bound to a unit of work, and every statement then runs inside it.
+type sqlConn struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+func (c sqlConn) q() querier {
+	if c.tx != nil {
+		return c.tx
+	}
+	return c.db
+}
+
+// inTx runs fn inside the unit of work's transaction, or inside a transaction
+// of its own when the repository is not bound to one.
+func (c sqlConn) inTx(fn func(tx querier) error) error {
+	if c.tx != nil {
+		return fn(c.tx)
+	}
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer rollback(tx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func sqlTime(t *primitive.DateTime) any {
+	if t == nil {
+		return nil
+	}
+	return t.Time().UTC()
+}
+
+func scanNullTime(t sql.NullTime) *primitive.DateTime {
+	if !t.Valid {
+		return nil
+	}
+	dt := primitive.NewDateTimeFromTime(t.Time)
+	return &dt
+}
+
+func sqlString(s *string) any {
+	if s == nil {
+		return nil
+	}
+	return *s
+}
+
+func scanNullString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func sqlInt(n *int) any {
+	if n == nil {
+		return nil
+	}
+	return *n
+}
+
+func scanNullInt(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int64)
+	return &v
+}
+
+func sqlID(id *models.ID) any {
+	if id == nil {
+		return nil
+	}
+	return *id
+}
+
+// sqlIn appends a parenthesized list of placeholders for ids to query.
+func sqlIn(query string, ids []models.ID) (string, []any) {
+	placeholders := make([]string, len(ids))
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		placeholders[i] = `$` + strconv.Itoa(i+1)
+		args[i] = id
+	}
+	return query + `(` + strings.Join(placeholders, `, `) + `)`, args
+}
+
+// auditColumns are the columns of models.Audit, in the order auditArgs and
+// auditScan use.
+const auditColumns = `created_at, updated_at, created_by, updated_by`
+
+func auditArgs(audit models.Audit) []any {
+	return []any{sqlTime(audit.CreatedAt), sqlTime(audit.UpdatedAt), sqlID(audit.CreatedBy), sqlID(audit.UpdatedBy)}
+}
+
+// auditScan holds the scan destinations of the audit columns.
+type auditScan struct {
+	createdAt, updatedAt sql.NullTime
+	createdBy, updatedBy *models.ID
+}
+
+func (a *auditScan) dest() []any {
+	return []any{&a.createdAt, &a.updatedAt, &a.createdBy, &a.updatedBy}
+}
+
+func (a *auditScan) audit() models.Audit {
+	return models.Audit{
+		CreatedAt: scanNullTime(a.createdAt),
+		UpdatedAt: scanNullTime(a.updatedAt),
+		CreatedBy: a.createdBy,
+		UpdatedBy: a.updatedBy,
+	}
+}
+
+// rollback is deferred right after a transaction starts; it is a no-op once
+// the transaction has been committed.
+func rollback(tx *sql.Tx) {
+	_ = tx.Rollback()
+}
@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"database/sql"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kakimnsnv/ios_final_back/internal/db"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.uber.org/zap"
+)
+
+// newTestSQLite returns a freshly migrated SQLite database in a temporary
+// directory.
+func newTestSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+
+	sqlDB, err := db.OpenSQL(db.DialectSQLite, filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("couldn't open sqlite: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return sqlDB
+}
+
+// newTestPostgres creates a throwaway schema on the server in
+// TEST_POSTGRES_DSN and returns a migrated connection pool scoped to it. The
+// test is skipped when the variable is not set.
+func newTestPostgres(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN is not set")
+	}
+
+	admin, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatalf("couldn't connect to postgres: %v", err)
+	}
+	schema := "conformance_" + models.NewID().String()
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		t.Fatalf("couldn't create schema: %v", err)
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		t.Fatalf("TEST_POSTGRES_DSN must be a URL: %v", err)
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	sqlDB, err := db.OpenSQL(db.DialectPostgres, u.String())
+	if err != nil {
+		t.Fatalf("couldn't open postgres: %v", err)
+	}
+	t.Cleanup(func() {
+		sqlDB.Close()
+		_, _ = admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`)
+		admin.Close()
+	})
+	return sqlDB
+}
+
+func TestSQLiteUserRepo(t *testing.T) {
+	testUserRepo(t, func(t *testing.T) UserRepo {
+		return NewSQLUserRepo(newTestSQLite(t))
+	})
+}
+
+func TestSQLiteMovieRepo(t *testing.T) {
+	testMovieRepo(t, func(t *testing.T) MovieRepo {
+		return NewSQLMovieRepo(newTestSQLite(t))
+	})
+}
+
+func TestSQLiteReviewRepo(t *testing.T) {
+	testReviewRepo(t, func(t *testing.T) ReviewRepo {
+		return NewSQLReviewRepo(zap.NewNop(), newTestSQLite(t))
+	})
+}
+
+func TestSQLitePersonRepo(t *testing.T) {
+	testPersonRepo(t, func(t *testing.T) PersonRepo {
+		return NewSQLPersonRepo(newTestSQLite(t))
+	})
+}
+
+func TestSQLiteGenreRepo(t *testing.T) {
+	testGenreRepo(t, func(t *testing.T) GenreRepo {
+		return NewSQLGenreRepo(newTestSQLite(t))
+	})
+}
+
+func TestSQLiteCreditRepo(t *testing.T) {
+	testCreditRepo(t, func(t *testing.T) CreditRepo {
+		return NewSQLCreditRepo(newTestSQLite(t))
+	})
+}
+
+func TestSQLiteRevisionRepo(t *testing.T) {
+	testRevisionRepo(t, func(t *testing.T) RevisionRepo {
+		return NewSQLRevisionRepo(newTestSQLite(t))
+	})
+}
+
+func TestSQLiteSuggestionRepo(t *testing.T) {
+	testSuggestionRepo(t, func(t *testing.T) SuggestionRepo {
+		return NewSQLSuggestionRepo(newTestSQLite(t))
+	})
+}
+
+func TestSQLiteTranslationRepo(t *testing.T) {
+	testTranslationRepo(t, func(t *testing.T) TranslationRepo {
+		return NewSQLTranslationRepo(newTestSQLite(t))
+	})
+}
+
+func TestSQLiteTagRepo(t *testing.T) {
+	testTagRepo(t, func(t *testing.T) TagRepo {
+		return NewSQLTagRepo(newTestSQLite(t))
+	})
+}
+
+func TestSQLiteReleaseRepo(t *testing.T) {
+	testReleaseRepo(t, func(t *testing.T) ReleaseRepo {
+		return NewSQLReleaseRepo(newTestSQLite(t))
+	})
+}
+
+func TestSQLiteWatchlistRepo(t *testing.T) {
+	testWatchlistRepo(t, func(t *testing.T) WatchlistRepo {
+		return NewSQLWatchlistRepo(newTestSQLite(t))
+	})
+}
+
+func TestPostgresUserRepo(t *testing.T) {
+	testUserRepo(t, func(t *testing.T) UserRepo {
+		return NewSQLUserRepo(newTestPostgres(t))
+	})
+}
+
+func TestPostgresMovieRepo(t *testing.T) {
+	testMovieRepo(t, func(t *testing.T) MovieRepo {
+		return NewSQLMovieRepo(newTestPostgres(t))
+	})
+}
+
+func TestPostgresReviewRepo(t *testing.T) {
+	testReviewRepo(t, func(t *testing.T) ReviewRepo {
+		return NewSQLReviewRepo(zap.NewNop(), newTestPostgres(t))
+	})
+}
+
+func TestPostgresPersonRepo(t *testing.T) {
+	testPersonRepo(t, func(t *testing.T) PersonRepo {
+		return NewSQLPersonRepo(newTestPostgres(t))
+	})
+}
+
+func TestPostgresGenreRepo(t *testing.T) {
+	testGenreRepo(t, func(t *testing.T) GenreRepo {
+		return NewSQLGenreRepo(newTestPostgres(t))
+	})
+}
+
+func TestPostgresCreditRepo(t *testing.T) {
+	testCreditRepo(t, func(t *testing.T) CreditRepo {
+		return NewSQLCreditRepo(newTestPostgres(t))
+	})
+}
+
+func TestPostgresRevisionRepo(t *testing.T) {
+	testRevisionRepo(t, func(t *testing.T) RevisionRepo {
+		return NewSQLRevisionRepo(newTestPostgres(t))
+	})
+}
+
+func TestPostgresSuggestionRepo(t *testing.T) {
+	testSuggestionRepo(t, func(t *testing.T) SuggestionRepo {
+		return NewSQLSuggestionRepo(newTestPostgres(t))
+	})
+}
+
+func TestPostgresTranslationRepo(t *testing.T) {
+	testTranslationRepo(t, func(t *testing.T) TranslationRepo {
+		return NewSQLTranslationRepo(newTestPostgres(t))
+	})
+}
+
+func TestPostgresTagRepo(t *testing.T) {
+	testTagRepo(t, func(t *testing.T) TagRepo {
+		return NewSQLTagRepo(newTestPostgres(t))
+	})
+}
+
+func TestPostgresReleaseRepo(t *testing.T) {
+	testReleaseRepo(t, func(t *testing.T) ReleaseRepo {
+		return NewSQLReleaseRepo(newTestPostgres(t))
+	})
+}
+
+func TestPostgresWatchlistRepo(t *testing.T) {
+	testWatchlistRepo(t, func(t *testing.T) WatchlistRepo {
+		return NewSQLWatchlistRepo(newTestPostgres(t))
+	})
+}
+
+func TestSQLiteUnitOfWork(t *testing.T) {
+	testUnitOfWork(t, func(t *testing.T) (Repos, UnitOfWork) {
+		return newTestSQLStore(newTestSQLite(t))
+	})
+}
+
+func TestPostgresUnitOfWork(t *testing.T) {
+	testUnitOfWork(t, func(t *testing.T) (Repos, UnitOfWork) {
+		return newTestSQLStore(newTestPostgres(t))
+	})
+}
+
+func TestSQLiteFacetCounter(t *testing.T) {
+	testFacetCounter(t, func(t *testing.T) (MovieRepo, TagRepo, FacetCounter) {
+		sqlDB := newTestSQLite(t)
+		return NewSQLMovieRepo(sqlDB), NewSQLTagRepo(sqlDB), NewSQLFacetCounter(sqlDB)
+	})
+}
+
+func TestPostgresFacetCounter(t *testing.T) {
+	testFacetCounter(t, func(t *testing.T) (MovieRepo, TagRepo, FacetCounter) {
+		sqlDB := newTestPostgres(t)
+		return NewSQLMovieRepo(sqlDB), NewSQLTagRepo(sqlDB), NewSQLFacetCounter(sqlDB)
+	})
+}
+
+func newTestSQLStore(sqlDB *sql.DB) (Repos, UnitOfWork) {
+	repos := Repos{
+		Users:        NewSQLUserRepo(sqlDB),
+		Movies:       NewSQLMovieRepo(sqlDB),
+		Reviews:      NewSQLReviewRepo(zap.NewNop(), sqlDB),
+		Credits:      NewSQLCreditRepo(sqlDB),
+		Revisions:    NewSQLRevisionRepo(sqlDB),
+		Translations: NewSQLTranslationRepo(sqlDB),
+		Tags:         NewSQLTagRepo(sqlDB),
+		Releases:     NewSQLReleaseRepo(sqlDB),
+		Watchlists:   NewSQLWatchlistRepo(sqlDB),
+	}
+	return repos, NewSQLUnitOfWork(sqlDB)
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// SuggestionRepo stores the moderation queue of changes proposed by users.
+// Suggestions are kept once resolved, as a record of what was decided.
+type SuggestionRepo interface {
+	ListSuggestions(opts models.ListOptions) (models.Page[*models.Suggestion], error)
+	GetSuggestion(id models.ID) (*models.Suggestion, error)
+	// CreateSuggestion stores s as a pending suggestion made by actorID.
+	CreateSuggestion(actorID models.ID, s *models.Suggestion) (models.ID, error)
+	// ResolveSuggestion sets the status of a suggestion still at version,
+	// along with the reason for a rejection or the movie an approved new
+	// movie became.
+	ResolveSuggestion(actorID, id models.ID, version int64, status models.SuggestionStatus, reason string, movieID *models.ID) (*models.Suggestion, error)
+}
+
+const suggestionsCollection = "suggestions"
+
+type suggestionRepo struct {
+	ctx        context.Context
+	collection *mongo.Collection
+}
+
+func NewSuggestionRepo(log *zap.Logger, collNames map[string]int, db *mongo.Database) SuggestionRepo {
+	var collectionName = suggestionsCollection
+
+	if _, exists := collNames[collectionName]; !exists {
+		if err := db.CreateCollection(context.TODO(), collectionName); err != nil {
+			log.Fatal("couldn't initialize repository: ", zap.Error(err))
+		}
+	}
+
+	indexes := listIndexes(bson.D{{Key: "status", Value: 1}}, models.SortCreatedAt, models.SortUpdatedAt)
+	if _, err := db.Collection(collectionName).Indexes().CreateMany(context.TODO(), indexes); err != nil {
+		log.Fatal("couldn't initialize repository: ", zap.Error(err))
+	}
+
+	return &suggestionRepo{
+		ctx:        context.TODO(),
+		collection: db.Collection(collectionName),
+	}
+}
+
+func (r *suggestionRepo) ListSuggestions(opts models.ListOptions) (models.Page[*models.Suggestion], error) {
+	filter, findOpts, err := mongoList(bson.M{}, opts, models.SuggestionListFields)
+	if err != nil {
+		return models.Page[*models.Suggestion]{}, err
+	}
+	cur, err := r.collection.Find(r.ctx, filter, findOpts)
+	if err != nil {
+		return models.Page[*models.Suggestion]{}, err
+	}
+
+	suggestions := []*models.Suggestion{}
+	if err := cur.All(r.ctx, &suggestions); err != nil {
+		return models.Page[*models.Suggestion]{}, err
+	}
+	return page(suggestions, opts), nil
+}
+
+func (r *suggestionRepo) GetSuggestion(id models.ID) (*models.Suggestion, error) {
+	var s models.Suggestion
+	if err := r.collection.FindOne(r.ctx, bson.M{"_id": id}).Decode(&s); err != nil {
+		return nil, mongoErr(err)
+	}
+	return &s, nil
+}
+
+func (r *suggestionRepo) CreateSuggestion(actorID models.ID, s *models.Suggestion) (models.ID, error) {
+	s = newSuggestion(actorID, s)
+	if _, err := r.collection.InsertOne(r.ctx, s); err != nil {
+		return models.NilID, mongoErr(err)
+	}
+	return s.ID, nil
+}
+
+func (r *suggestionRepo) ResolveSuggestion(actorID, id models.ID, version int64, status models.SuggestionStatus, reason string, movieID *models.ID) (*models.Suggestion, error) {
+	set := bson.M{"status": status}
+	if reason != "" {
+		set["reason"] = reason
+	}
+	if movieID != nil {
+		set["movieId"] = *movieID
+	}
+	update := mongoTouch(bson.M{"$set": set, "$inc": bson.M{"version": 1}}, actorID)
+
+	var s models.Suggestion
+	filter := bson.M{"_id": id, "version": version}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(r.ctx, filter, update, opts).Decode(&s)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, mongoStale(r.ctx, r.collection, id)
+	}
+	if err != nil {
+		return nil, mongoErr(err)
+	}
+	return &s, nil
+}
+
+// newSuggestion returns a copy of s as a pending suggestion made by actorID
+// now.
+func newSuggestion(actorID models.ID, s *models.Suggestion) *models.Suggestion {
+	created := *s
+	created.ID = models.NewID()
+	created.Status = models.SuggestionPending
+	created.Reason = ""
+	created.Version = 1
+	created.Audit = newAudit(actorID)
+	return &created
+}
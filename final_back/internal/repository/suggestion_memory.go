@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"sync"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type memorySuggestionRepo struct {
+	mu          sync.RWMutex
+	suggestions map[models.ID]*models.Suggestion
+}
+
+// NewMemorySuggestionRepo returns a SuggestionRepo that keeps suggestions in
+// process memory. It is safe for concurrent use and behaves like the Mongo
+// implementation.
+func NewMemorySuggestionRepo() SuggestionRepo {
+	return &memorySuggestionRepo{
+		suggestions: make(map[models.ID]*models.Suggestion),
+	}
+}
+
+func (r *memorySuggestionRepo) ListSuggestions(opts models.ListOptions) (models.Page[*models.Suggestion], error) {
+	r.mu.RLock()
+	suggestions := make([]*models.Suggestion, 0, len(r.suggestions))
+	for _, s := range r.suggestions {
+		suggestions = append(suggestions, cloneSuggestion(s))
+	}
+	r.mu.RUnlock()
+
+	return memoryList(suggestions, opts, models.SuggestionListFields)
+}
+
+func (r *memorySuggestionRepo) GetSuggestion(id models.ID) (*models.Suggestion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.suggestions[id]
+	if !ok {
+		return nil, errs.NotFound
+	}
+	return cloneSuggestion(s), nil
+}
+
+func (r *memorySuggestionRepo) CreateSuggestion(actorID models.ID, s *models.Suggestion) (models.ID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	created := cloneSuggestion(newSuggestion(actorID, s))
+	r.suggestions[created.ID] = created
+	return created.ID, nil
+}
+
+func (r *memorySuggestionRepo) ResolveSuggestion(actorID, id models.ID, version int64, status models.SuggestionStatus, reason string, movieID *models.ID) (*models.Suggestion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.suggestions[id]
+	if !ok {
+		return nil, errs.NotFound
+	}
+	if s.Version != version {
+		return nil, errs.Conflict
+	}
+
+	resolved := cloneSuggestion(s)
+	resolved.Status = status
+	if reason != "" {
+		resolved.Reason = reason
+	}
+	if movieID != nil {
+		id := *movieID
+		resolved.MovieID = &id
+	}
+	resolved.Version++
+	resolved.Audit = touch(resolved.Audit, actorID)
+	r.suggestions[resolved.ID] = resolved
+	return cloneSuggestion(resolved), nil
+}
+
+func cloneSuggestion(s *models.Suggestion) *models.Suggestion {
+	clone := *s
+	if s.MovieID != nil {
+		movieID := *s.MovieID
+		clone.MovieID = &movieID
+	}
+	if s.Title != nil {
+		title := *s.Title
+		clone.Title = &title
+	}
+	if s.Year != nil {
+		year := *s.Year
+		clone.Year = &year
+	}
+	if s.DirectorID != nil {
+		directorID := *s.DirectorID
+		clone.DirectorID = &directorID
+	}
+	if s.GenreID != nil {
+		genreID := *s.GenreID
+		clone.GenreID = &genreID
+	}
+	if s.ImageURL != nil {
+		imageURL := *s.ImageURL
+		clone.ImageURL = &imageURL
+	}
+	clone.Audit = cloneAudit(s.Audit)
+	return &clone
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type sqlSuggestionRepo struct {
+	sqlConn
+}
+
+// NewSQLSuggestionRepo returns a SuggestionRepo backed by the suggestions
+// table of a Postgres or SQLite database opened with db.OpenSQL.
+func NewSQLSuggestionRepo(db *sql.DB) SuggestionRepo {
+	return &sqlSuggestionRepo{
+		sqlConn: sqlConn{db: db},
+	}
+}
+
+const selectSuggestion = `SELECT id, movie_id, movie_version, title, year, director_id, genre_id, image_url, status, reason, version, ` + auditColumns + ` FROM suggestions`
+
+func (r *sqlSuggestionRepo) ListSuggestions(opts models.ListOptions) (models.Page[*models.Suggestion], error) {
+	query, args, err := sqlList(selectSuggestion+` WHERE TRUE`, nil, opts, models.SuggestionListFields)
+	if err != nil {
+		return models.Page[*models.Suggestion]{}, err
+	}
+	rows, err := r.q().Query(query, args...)
+	if err != nil {
+		return models.Page[*models.Suggestion]{}, err
+	}
+	defer rows.Close()
+
+	suggestions := []*models.Suggestion{}
+	for rows.Next() {
+		s, err := scanSuggestion(rows)
+		if err != nil {
+			return models.Page[*models.Suggestion]{}, err
+		}
+		suggestions = append(suggestions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return models.Page[*models.Suggestion]{}, err
+	}
+	return page(suggestions, opts), nil
+}
+
+func (r *sqlSuggestionRepo) GetSuggestion(id models.ID) (*models.Suggestion, error) {
+	s, err := scanSuggestion(r.q().QueryRow(selectSuggestion+` WHERE id = $1`, id))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	return s, nil
+}
+
+func (r *sqlSuggestionRepo) CreateSuggestion(actorID models.ID, s *models.Suggestion) (models.ID, error) {
+	s = newSuggestion(actorID, s)
+
+	args := append([]any{s.ID, sqlID(s.MovieID), s.MovieVersion, sqlString(s.Title), sqlInt(s.Year), sqlID(s.DirectorID), sqlID(s.GenreID), sqlString(s.ImageURL), string(s.Status), s.Reason, s.Version}, auditArgs(s.Audit)...)
+	_, err := r.q().Exec(`INSERT INTO suggestions (id, movie_id, movie_version, title, year, director_id, genre_id, image_url, status, reason, version, `+auditColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`,
+		args...)
+	if err != nil {
+		return models.NilID, sqlErr(err)
+	}
+	return s.ID, nil
+}
+
+func (r *sqlSuggestionRepo) ResolveSuggestion(actorID, id models.ID, version int64, status models.SuggestionStatus, reason string, movieID *models.ID) (*models.Suggestion, error) {
+	s, err := r.GetSuggestion(id)
+	if err != nil {
+		return nil, err
+	}
+	s.Status = status
+	if reason != "" {
+		s.Reason = reason
+	}
+	if movieID != nil {
+		s.MovieID = movieID
+	}
+	s.Audit = touch(s.Audit, actorID)
+
+	res, err := r.q().Exec(`UPDATE suggestions SET status = $2, reason = $3, movie_id = $4, updated_at = $6, updated_by = $7, version = version + 1 WHERE id = $1 AND version = $5`,
+		id, string(s.Status), s.Reason, sqlID(s.MovieID), version, sqlTime(s.UpdatedAt), sqlID(s.UpdatedBy))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, errs.Conflict
+	}
+	s.Version = version + 1
+	return s, nil
+}
+
+func scanSuggestion(row rowScanner) (*models.Suggestion, error) {
+	var (
+		s        models.Suggestion
+		status   string
+		title    sql.NullString
+		year     sql.NullInt64
+		imageURL sql.NullString
+		audit    auditScan
+	)
+	dest := append([]any{&s.ID, &s.MovieID, &s.MovieVersion, &title, &year, &s.DirectorID, &s.GenreID, &imageURL, &status, &s.Reason, &s.Version}, audit.dest()...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	s.Status = models.SuggestionStatus(status)
+	s.Title = scanNullString(title)
+	s.Year = scanNullInt(year)
+	s.ImageURL = scanNullString(imageURL)
+	s.Audit = audit.audit()
+	return &s, nil
+}
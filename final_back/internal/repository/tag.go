@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// TagRepo stores tags, whose names are unique, and the tags put on movies
+// together with the ones suggested for them. A movie carries a tag at most
+// once, whatever became of it. Movie tags stay in place while their movie
+// is in the trash and go when it is purged.
+type TagRepo interface {
+	ListTags(opts models.ListOptions) (models.Page[*models.Tag], error)
+	GetTag(id models.ID) (*models.Tag, error)
+	GetTagByName(name string) (*models.Tag, error)
+	// GetTags returns the tags among ids that exist, in no particular order.
+	GetTags(ids []models.ID) ([]*models.Tag, error)
+	CreateTag(actorID models.ID, tag *models.Tag) (models.ID, error)
+	UpdateTag(actorID, id models.ID, version int64, req *models.UpdateTagRequest) (*models.Tag, error)
+	// DeleteTag removes a tag from every movie as well.
+	DeleteTag(id models.ID) error
+
+	ListMovieTags(opts models.ListOptions) (models.Page[*models.MovieTag], error)
+	GetMovieTag(id models.ID) (*models.MovieTag, error)
+	// CreateMovieTag stores t, approved or pending as its Status says.
+	CreateMovieTag(actorID models.ID, t *models.MovieTag) (models.ID, error)
+	// ResolveMovieTag sets the status of a movie tag still at version, along
+	// with the reason for a rejection or the tag a suggested new tag became.
+	ResolveMovieTag(actorID, id models.ID, version int64, status models.SuggestionStatus, reason string, tagID *models.ID) (*models.MovieTag, error)
+	// DeleteMovieTag takes a tag off a movie.
+	DeleteMovieTag(movieID, tagID models.ID) error
+	DeleteMovieTags(movieID models.ID) error
+	// MoveMovieTags moves the tags of a movie to another movie, dropping the
+	// ones it already has.
+	MoveMovieTags(actorID, from, to models.ID) error
+}
+
+const (
+	tagsCollection      = "tags"
+	movieTagsCollection = "movieTags"
+)
+
+type tagRepo struct {
+	ctx                context.Context
+	collection         *mongo.Collection
+	movieTagCollection *mongo.Collection
+}
+
+func NewTagRepo(log *zap.Logger, collNames map[string]int, db *mongo.Database) TagRepo {
+	for _, collectionName := range []string{tagsCollection, movieTagsCollection} {
+		if _, exists := collNames[collectionName]; !exists {
+			if err := db.CreateCollection(context.TODO(), collectionName); err != nil {
+				log.Fatal("couldn't initialize repository: ", zap.Error(err))
+			}
+		}
+	}
+
+	indexes := append(listIndexes(nil, models.SortCreatedAt, models.SortUpdatedAt), mongo.IndexModel{
+		Keys:    bson.D{{Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if _, err := db.Collection(tagsCollection).Indexes().CreateMany(context.TODO(), indexes); err != nil {
+		log.Fatal("couldn't initialize repository: ", zap.Error(err))
+	}
+
+	movieTagIndexes := append(listIndexes(bson.D{{Key: "status", Value: 1}}, models.SortCreatedAt, models.SortUpdatedAt),
+		mongo.IndexModel{Keys: bson.D{{Key: "tagId", Value: 1}}},
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "movieId", Value: 1}, {Key: "tagId", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{"tagId": bson.M{"$exists": true}}),
+		},
+	)
+	if _, err := db.Collection(movieTagsCollection).Indexes().CreateMany(context.TODO(), movieTagIndexes); err != nil {
+		log.Fatal("couldn't initialize repository: ", zap.Error(err))
+	}
+
+	return &tagRepo{
+		ctx:                context.TODO(),
+		collection:         db.Collection(tagsCollection),
+		movieTagCollection: db.Collection(movieTagsCollection),
+	}
+}
+
+func (r *tagRepo) ListTags(opts models.ListOptions) (models.Page[*models.Tag], error) {
+	filter, findOpts, err := mongoList(bson.M{}, opts, models.TagListFields)
+	if err != nil {
+		return models.Page[*models.Tag]{}, err
+	}
+	tags, err := r.find(filter, findOpts)
+	if err != nil {
+		return models.Page[*models.Tag]{}, err
+	}
+	return page(tags, opts), nil
+}
+
+func (r *tagRepo) GetTag(id models.ID) (*models.Tag, error) {
+	return r.findOne(bson.M{"_id": id})
+}
+
+func (r *tagRepo) GetTagByName(name string) (*models.Tag, error) {
+	return r.findOne(bson.M{"name": name})
+}
+
+func (r *tagRepo) GetTags(ids []models.ID) ([]*models.Tag, error) {
+	return r.find(bson.M{"_id": bson.M{"$in": ids}}, options.Find())
+}
+
+func (r *tagRepo) findOne(filter bson.M) (*models.Tag, error) {
+	var tag models.Tag
+	if err := r.collection.FindOne(r.ctx, filter).Decode(&tag); err != nil {
+		return nil, mongoErr(err)
+	}
+	return &tag, nil
+}
+
+func (r *tagRepo) find(filter bson.M, opts *options.FindOptions) ([]*models.Tag, error) {
+	cur, err := r.collection.Find(r.ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := []*models.Tag{}
+	if err := cur.All(r.ctx, &tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (r *tagRepo) CreateTag(actorID models.ID, tag *models.Tag) (models.ID, error) {
+	tag = newTag(actorID, tag)
+	if _, err := r.collection.InsertOne(r.ctx, tag); err != nil {
+		return models.NilID, mongoErr(err)
+	}
+	return tag.ID, nil
+}
+
+func (r *tagRepo) UpdateTag(actorID, id models.ID, version int64, req *models.UpdateTagRequest) (*models.Tag, error) {
+	set := bson.M{}
+	if req.Name != nil {
+		set["name"] = *req.Name
+	}
+	if req.Curated != nil {
+		set["curated"] = *req.Curated
+	}
+	update := mongoTouch(bson.M{"$set": set, "$inc": bson.M{"version": 1}}, actorID)
+
+	var tag models.Tag
+	filter := bson.M{"_id": id, "version": versionFilter(version)}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(r.ctx, filter, update, opts).Decode(&tag)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, mongoStale(r.ctx, r.collection, id)
+	}
+	if err != nil {
+		return nil, mongoErr(err)
+	}
+	return &tag, nil
+}
+
+func (r *tagRepo) DeleteTag(id models.ID) error {
+	res, err := r.collection.DeleteOne(r.ctx, bson.M{"_id": id})
+	if err != nil {
+		return mongoErr(err)
+	}
+	if res.DeletedCount == 0 {
+		return errs.NotFound
+	}
+	_, err = r.movieTagCollection.DeleteMany(r.ctx, bson.M{"tagId": id})
+	return mongoErr(err)
+}
+
+func (r *tagRepo) ListMovieTags(opts models.ListOptions) (models.Page[*models.MovieTag], error) {
+	filter, findOpts, err := mongoList(bson.M{}, opts, models.MovieTagListFields)
+	if err != nil {
+		return models.Page[*models.MovieTag]{}, err
+	}
+	cur, err := r.movieTagCollection.Find(r.ctx, filter, findOpts)
+	if err != nil {
+		return models.Page[*models.MovieTag]{}, err
+	}
+
+	movieTags := []*models.MovieTag{}
+	if err := cur.All(r.ctx, &movieTags); err != nil {
+		return models.Page[*models.MovieTag]{}, err
+	}
+	return page(movieTags, opts), nil
+}
+
+func (r *tagRepo) GetMovieTag(id models.ID) (*models.MovieTag, error) {
+	var t models.MovieTag
+	if err := r.movieTagCollection.FindOne(r.ctx, bson.M{"_id": id}).Decode(&t); err != nil {
+		return nil, mongoErr(err)
+	}
+	return &t, nil
+}
+
+func (r *tagRepo) CreateMovieTag(actorID models.ID, t *models.MovieTag) (models.ID, error) {
+	t = newMovieTag(actorID, t)
+	if _, err := r.movieTagCollection.InsertOne(r.ctx, t); err != nil {
+		return models.NilID, mongoErr(err)
+	}
+	return t.ID, nil
+}
+
+func (r *tagRepo) ResolveMovieTag(actorID, id models.ID, version int64, status models.SuggestionStatus, reason string, tagID *models.ID) (*models.MovieTag, error) {
+	set := bson.M{"status": status}
+	if reason != "" {
+		set["reason"] = reason
+	}
+	if tagID != nil {
+		set["tagId"] = *tagID
+	}
+	update := mongoTouch(bson.M{"$set": set, "$inc": bson.M{"version": 1}}, actorID)
+
+	var t models.MovieTag
+	filter := bson.M{"_id": id, "version": version}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.movieTagCollection.FindOneAndUpdate(r.ctx, filter, update, opts).Decode(&t)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, mongoStale(r.ctx, r.movieTagCollection, id)
+	}
+	if err != nil {
+		return nil, mongoErr(err)
+	}
+	return &t, nil
+}
+
+func (r *tagRepo) DeleteMovieTag(movieID, tagID models.ID) error {
+	res, err := r.movieTagCollection.DeleteOne(r.ctx, bson.M{"movieId": movieID, "tagId": tagID})
+	if err != nil {
+		return mongoErr(err)
+	}
+	if res.DeletedCount == 0 {
+		return errs.NotFound
+	}
+	return nil
+}
+
+func (r *tagRepo) DeleteMovieTags(movieID models.ID) error {
+	_, err := r.movieTagCollection.DeleteMany(r.ctx, bson.M{"movieId": movieID})
+	return mongoErr(err)
+}
+
+func (r *tagRepo) MoveMovieTags(actorID, from, to models.ID) error {
+	shared, err := r.movieTagCollection.Distinct(r.ctx, "tagId", bson.M{"movieId": to, "tagId": bson.M{"$exists": true}})
+	if err != nil {
+		return err
+	}
+	if _, err := r.movieTagCollection.DeleteMany(r.ctx, bson.M{"movieId": from, "tagId": bson.M{"$in": shared}}); err != nil {
+		return mongoErr(err)
+	}
+
+	update := mongoTouch(bson.M{"$set": bson.M{"movieId": to}, "$inc": bson.M{"version": 1}}, actorID)
+	_, err = r.movieTagCollection.UpdateMany(r.ctx, bson.M{"movieId": from}, update)
+	return mongoErr(err)
+}
+
+// newTag returns a copy of tag as created by actorID now.
+func newTag(actorID models.ID, tag *models.Tag) *models.Tag {
+	created := *tag
+	created.ID = models.NewID()
+	created.Version = 1
+	created.Audit = newAudit(actorID)
+	return &created
+}
+
+// newMovieTag returns a copy of t as created by actorID now. A movie tag
+// without a status is pending.
+func newMovieTag(actorID models.ID, t *models.MovieTag) *models.MovieTag {
+	created := *t
+	created.ID = models.NewID()
+	if created.Status == "" {
+		created.Status = models.SuggestionPending
+	}
+	created.Reason = ""
+	created.Version = 1
+	created.Audit = newAudit(actorID)
+	return &created
+}
@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"sync"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type memoryTagRepo struct {
+	mu        sync.RWMutex
+	tags      map[models.ID]*models.Tag
+	movieTags map[models.ID]*models.MovieTag
+}
+
+// NewMemoryTagRepo returns a TagRepo that keeps tags in process memory. It
+// is safe for concurrent use and behaves like the Mongo implementation.
+func NewMemoryTagRepo() TagRepo {
+	return &memoryTagRepo{
+		tags:      make(map[models.ID]*models.Tag),
+		movieTags: make(map[models.ID]*models.MovieTag),
+	}
+}
+
+func (r *memoryTagRepo) ListTags(opts models.ListOptions) (models.Page[*models.Tag], error) {
+	r.mu.RLock()
+	tags := make([]*models.Tag, 0, len(r.tags))
+	for _, tag := range r.tags {
+		tags = append(tags, cloneTag(tag))
+	}
+	r.mu.RUnlock()
+
+	return memoryList(tags, opts, models.TagListFields)
+}
+
+func (r *memoryTagRepo) GetTag(id models.ID) (*models.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tag, ok := r.tags[id]
+	if !ok {
+		return nil, errs.NotFound
+	}
+	return cloneTag(tag), nil
+}
+
+func (r *memoryTagRepo) GetTagByName(name string) (*models.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, tag := range r.tags {
+		if tag.Name == name {
+			return cloneTag(tag), nil
+		}
+	}
+	return nil, errs.NotFound
+}
+
+func (r *memoryTagRepo) GetTags(ids []models.ID) ([]*models.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tags := []*models.Tag{}
+	for _, id := range ids {
+		if tag, ok := r.tags[id]; ok {
+			tags = append(tags, cloneTag(tag))
+		}
+	}
+	return tags, nil
+}
+
+func (r *memoryTagRepo) CreateTag(actorID models.ID, tag *models.Tag) (models.ID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.nameTaken(tag.Name, models.NilID) {
+		return models.NilID, errs.AlreadyExists
+	}
+	created := newTag(actorID, tag)
+	r.tags[created.ID] = created
+	return created.ID, nil
+}
+
+func (r *memoryTagRepo) UpdateTag(actorID, id models.ID, version int64, req *models.UpdateTagRequest) (*models.Tag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tag, ok := r.tags[id]
+	if !ok {
+		return nil, errs.NotFound
+	}
+	if tag.Version != version {
+		return nil, errs.Conflict
+	}
+
+	updated := cloneTag(tag)
+	if req.Name != nil {
+		if r.nameTaken(*req.Name, id) {
+			return nil, errs.AlreadyExists
+		}
+		updated.Name = *req.Name
+	}
+	if req.Curated != nil {
+		updated.Curated = *req.Curated
+	}
+	updated.Version++
+	updated.Audit = touch(updated.Audit, actorID)
+	r.tags[id] = updated
+	return cloneTag(updated), nil
+}
+
+func (r *memoryTagRepo) DeleteTag(id models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tags[id]; !ok {
+		return errs.NotFound
+	}
+	delete(r.tags, id)
+	for movieTagID, t := range r.movieTags {
+		if t.TagID != nil && *t.TagID == id {
+			delete(r.movieTags, movieTagID)
+		}
+	}
+	return nil
+}
+
+// nameTaken reports whether a tag other than except is called name.
+func (r *memoryTagRepo) nameTaken(name string, except models.ID) bool {
+	for id, tag := range r.tags {
+		if tag.Name == name && id != except {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memoryTagRepo) ListMovieTags(opts models.ListOptions) (models.Page[*models.MovieTag], error) {
+	r.mu.RLock()
+	movieTags := make([]*models.MovieTag, 0, len(r.movieTags))
+	for _, t := range r.movieTags {
+		movieTags = append(movieTags, cloneMovieTag(t))
+	}
+	r.mu.RUnlock()
+
+	return memoryList(movieTags, opts, models.MovieTagListFields)
+}
+
+func (r *memoryTagRepo) GetMovieTag(id models.ID) (*models.MovieTag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.movieTags[id]
+	if !ok {
+		return nil, errs.NotFound
+	}
+	return cloneMovieTag(t), nil
+}
+
+func (r *memoryTagRepo) CreateMovieTag(actorID models.ID, t *models.MovieTag) (models.ID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if t.TagID != nil && r.tagged(t.MovieID, *t.TagID, models.NilID) {
+		return models.NilID, errs.AlreadyExists
+	}
+	created := cloneMovieTag(newMovieTag(actorID, t))
+	r.movieTags[created.ID] = created
+	return created.ID, nil
+}
+
+func (r *memoryTagRepo) ResolveMovieTag(actorID, id models.ID, version int64, status models.SuggestionStatus, reason string, tagID *models.ID) (*models.MovieTag, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, ok := r.movieTags[id]
+	if !ok {
+		return nil, errs.NotFound
+	}
+	if t.Version != version {
+		return nil, errs.Conflict
+	}
+
+	resolved := cloneMovieTag(t)
+	resolved.Status = status
+	if reason != "" {
+		resolved.Reason = reason
+	}
+	if tagID != nil {
+		if r.tagged(t.MovieID, *tagID, id) {
+			return nil, errs.AlreadyExists
+		}
+		id := *tagID
+		resolved.TagID = &id
+	}
+	resolved.Version++
+	resolved.Audit = touch(resolved.Audit, actorID)
+	r.movieTags[resolved.ID] = resolved
+	return cloneMovieTag(resolved), nil
+}
+
+func (r *memoryTagRepo) DeleteMovieTag(movieID, tagID models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, t := range r.movieTags {
+		if t.MovieID == movieID && t.TagID != nil && *t.TagID == tagID {
+			delete(r.movieTags, id)
+			return nil
+		}
+	}
+	return errs.NotFound
+}
+
+func (r *memoryTagRepo) DeleteMovieTags(movieID models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, t := range r.movieTags {
+		if t.MovieID == movieID {
+			delete(r.movieTags, id)
+		}
+	}
+	return nil
+}
+
+func (r *memoryTagRepo) MoveMovieTags(actorID, from, to models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, t := range r.movieTags {
+		if t.MovieID != from {
+			continue
+		}
+		if t.TagID != nil && r.tagged(to, *t.TagID, models.NilID) {
+			delete(r.movieTags, id)
+			continue
+		}
+		moved := cloneMovieTag(t)
+		moved.MovieID = to
+		moved.Version++
+		moved.Audit = touch(moved.Audit, actorID)
+		r.movieTags[id] = moved
+	}
+	return nil
+}
+
+// tagged reports whether a movie tag other than except puts tagID on
+// movieID.
+func (r *memoryTagRepo) tagged(movieID, tagID, except models.ID) bool {
+	for id, t := range r.movieTags {
+		if t.MovieID == movieID && t.TagID != nil && *t.TagID == tagID && id != except {
+			return true
+		}
+	}
+	return false
+}
+
+func cloneTag(tag *models.Tag) *models.Tag {
+	clone := *tag
+	clone.Audit = cloneAudit(tag.Audit)
+	return &clone
+}
+
+func cloneMovieTag(t *models.MovieTag) *models.MovieTag {
+	clone := *t
+	if t.TagID != nil {
+		tagID := *t.TagID
+		clone.TagID = &tagID
+	}
+	clone.Audit = cloneAudit(t.Audit)
+	return &clone
+}
@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type sqlTagRepo struct {
+	sqlConn
+}
+
+// NewSQLTagRepo returns a TagRepo backed by the tags and movie_tags tables
+// of a Postgres or SQLite database opened with db.OpenSQL.
+func NewSQLTagRepo(db *sql.DB) TagRepo {
+	return &sqlTagRepo{
+		sqlConn: sqlConn{db: db},
+	}
+}
+
+const (
+	selectTag      = `SELECT id, name, curated, version, ` + auditColumns + ` FROM tags`
+	selectMovieTag = `SELECT id, movie_id, tag_id, name, status, reason, version, ` + auditColumns + ` FROM movie_tags`
+)
+
+func (r *sqlTagRepo) ListTags(opts models.ListOptions) (models.Page[*models.Tag], error) {
+	query, args, err := sqlList(selectTag+` WHERE TRUE`, nil, opts, models.TagListFields)
+	if err != nil {
+		return models.Page[*models.Tag]{}, err
+	}
+	tags, err := r.query(query, args...)
+	if err != nil {
+		return models.Page[*models.Tag]{}, err
+	}
+	return page(tags, opts), nil
+}
+
+func (r *sqlTagRepo) GetTag(id models.ID) (*models.Tag, error) {
+	tag, err := scanTag(r.q().QueryRow(selectTag+` WHERE id = $1`, id))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	return tag, nil
+}
+
+func (r *sqlTagRepo) GetTagByName(name string) (*models.Tag, error) {
+	tag, err := scanTag(r.q().QueryRow(selectTag+` WHERE name = $1`, name))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	return tag, nil
+}
+
+func (r *sqlTagRepo) GetTags(ids []models.ID) ([]*models.Tag, error) {
+	if len(ids) == 0 {
+		return []*models.Tag{}, nil
+	}
+	query, args := sqlIn(selectTag+` WHERE id IN `, ids)
+	return r.query(query, args...)
+}
+
+func (r *sqlTagRepo) query(query string, args ...any) ([]*models.Tag, error) {
+	rows, err := r.q().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []*models.Tag{}
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+func (r *sqlTagRepo) CreateTag(actorID models.ID, tag *models.Tag) (models.ID, error) {
+	tag = newTag(actorID, tag)
+
+	args := append([]any{tag.ID, tag.Name, tag.Curated, tag.Version}, auditArgs(tag.Audit)...)
+	_, err := r.q().Exec(`INSERT INTO tags (id, name, curated, version, `+auditColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		args...)
+	if err != nil {
+		return models.NilID, sqlErr(err)
+	}
+	return tag.ID, nil
+}
+
+func (r *sqlTagRepo) UpdateTag(actorID, id models.ID, version int64, req *models.UpdateTagRequest) (*models.Tag, error) {
+	tag, err := r.GetTag(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name != nil {
+		tag.Name = *req.Name
+	}
+	if req.Curated != nil {
+		tag.Curated = *req.Curated
+	}
+	tag.Audit = touch(tag.Audit, actorID)
+
+	res, err := r.q().Exec(`UPDATE tags SET name = $2, curated = $3, updated_at = $5, updated_by = $6, version = version + 1 WHERE id = $1 AND version = $4`,
+		id, tag.Name, tag.Curated, version, sqlTime(tag.UpdatedAt), sqlID(tag.UpdatedBy))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, errs.Conflict
+	}
+	tag.Version = version + 1
+	return tag, nil
+}
+
+func (r *sqlTagRepo) DeleteTag(id models.ID) error {
+	return r.inTx(func(tx querier) error {
+		res, err := tx.Exec(`DELETE FROM tags WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return errs.NotFound
+		}
+		_, err = tx.Exec(`DELETE FROM movie_tags WHERE tag_id = $1`, id)
+		return err
+	})
+}
+
+func (r *sqlTagRepo) ListMovieTags(opts models.ListOptions) (models.Page[*models.MovieTag], error) {
+	query, args, err := sqlList(selectMovieTag+` WHERE TRUE`, nil, opts, models.MovieTagListFields)
+	if err != nil {
+		return models.Page[*models.MovieTag]{}, err
+	}
+	rows, err := r.q().Query(query, args...)
+	if err != nil {
+		return models.Page[*models.MovieTag]{}, err
+	}
+	defer rows.Close()
+
+	movieTags := []*models.MovieTag{}
+	for rows.Next() {
+		t, err := scanMovieTag(rows)
+		if err != nil {
+			return models.Page[*models.MovieTag]{}, err
+		}
+		movieTags = append(movieTags, t)
+	}
+	if err := rows.Err(); err != nil {
+		return models.Page[*models.MovieTag]{}, err
+	}
+	return page(movieTags, opts), nil
+}
+
+func (r *sqlTagRepo) GetMovieTag(id models.ID) (*models.MovieTag, error) {
+	t, err := scanMovieTag(r.q().QueryRow(selectMovieTag+` WHERE id = $1`, id))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	return t, nil
+}
+
+func (r *sqlTagRepo) CreateMovieTag(actorID models.ID, t *models.MovieTag) (models.ID, error) {
+	t = newMovieTag(actorID, t)
+
+	args := append([]any{t.ID, t.MovieID, sqlID(t.TagID), t.Name, string(t.Status), t.Reason, t.Version}, auditArgs(t.Audit)...)
+	_, err := r.q().Exec(`INSERT INTO movie_tags (id, movie_id, tag_id, name, status, reason, version, `+auditColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		args...)
+	if err != nil {
+		return models.NilID, sqlErr(err)
+	}
+	return t.ID, nil
+}
+
+func (r *sqlTagRepo) ResolveMovieTag(actorID, id models.ID, version int64, status models.SuggestionStatus, reason string, tagID *models.ID) (*models.MovieTag, error) {
+	t, err := r.GetMovieTag(id)
+	if err != nil {
+		return nil, err
+	}
+	t.Status = status
+	if reason != "" {
+		t.Reason = reason
+	}
+	if tagID != nil {
+		t.TagID = tagID
+	}
+	t.Audit = touch(t.Audit, actorID)
+
+	res, err := r.q().Exec(`UPDATE movie_tags SET status = $2, reason = $3, tag_id = $4, updated_at = $6, updated_by = $7, version = version + 1 WHERE id = $1 AND version = $5`,
+		id, string(t.Status), t.Reason, sqlID(t.TagID), version, sqlTime(t.UpdatedAt), sqlID(t.UpdatedBy))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, errs.Conflict
+	}
+	t.Version = version + 1
+	return t, nil
+}
+
+func (r *sqlTagRepo) DeleteMovieTag(movieID, tagID models.ID) error {
+	res, err := r.q().Exec(`DELETE FROM movie_tags WHERE movie_id = $1 AND tag_id = $2`, movieID, tagID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return errs.NotFound
+	}
+	return nil
+}
+
+func (r *sqlTagRepo) DeleteMovieTags(movieID models.ID) error {
+	_, err := r.q().Exec(`DELETE FROM movie_tags WHERE movie_id = $1`, movieID)
+	return err
+}
+
+func (r *sqlTagRepo) MoveMovieTags(actorID, from, to models.ID) error {
+	audit := touch(models.Audit{}, actorID)
+	return r.inTx(func(tx querier) error {
+		_, err := tx.Exec(`DELETE FROM movie_tags WHERE movie_id = $1 AND tag_id IN (SELECT tag_id FROM movie_tags WHERE movie_id = $2)`, from, to)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE movie_tags SET movie_id = $2, updated_at = $3, updated_by = $4, version = version + 1 WHERE movie_id = $1`,
+			from, to, sqlTime(audit.UpdatedAt), sqlID(audit.UpdatedBy))
+		return sqlErr(err)
+	})
+}
+
+func scanTag(row rowScanner) (*models.Tag, error) {
+	var (
+		tag   models.Tag
+		audit auditScan
+	)
+	dest := append([]any{&tag.ID, &tag.Name, &tag.Curated, &tag.Version}, audit.dest()...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	tag.Audit = audit.audit()
+	return &tag, nil
+}
+
+func scanMovieTag(row rowScanner) (*models.MovieTag, error) {
+	var (
+		t      models.MovieTag
+		status string
+		audit  auditScan
+	)
+	dest := append([]any{&t.ID, &t.MovieID, &t.TagID, &t.Name, &status, &t.Reason, &t.Version}, audit.dest()...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	t.Status = models.SuggestionStatus(status)
+	t.Audit = audit.audit()
+	return &t, nil
+}
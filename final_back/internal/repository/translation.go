@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// TranslationRepo stores the translations of records into other locales.
+type TranslationRepo interface {
+	// ListTranslations returns the translations of the records of kind in
+	// recordIDs, ordered by record and then by locale.
+	ListTranslations(kind models.TranslationKind, recordIDs []models.ID) ([]*models.Translation, error)
+	// PutTranslation stores t as the translation of its record into its
+	// locale, replacing any there was.
+	PutTranslation(actorID models.ID, t *models.Translation) (*models.Translation, error)
+	DeleteTranslation(kind models.TranslationKind, recordID models.ID, locale string) error
+	DeleteTranslations(kind models.TranslationKind, recordID models.ID) error
+}
+
+const translationsCollection = "translations"
+
+type translationRepo struct {
+	ctx        context.Context
+	collection *mongo.Collection
+}
+
+func NewTranslationRepo(log *zap.Logger, collNames map[string]int, db *mongo.Database) TranslationRepo {
+	var collectionName = translationsCollection
+
+	if _, exists := collNames[collectionName]; !exists {
+		if err := db.CreateCollection(context.TODO(), collectionName); err != nil {
+			log.Fatal("couldn't initialize repository: ", zap.Error(err))
+		}
+	}
+
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "kind", Value: 1}, {Key: "recordId", Value: 1}, {Key: "locale", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}
+	if _, err := db.Collection(collectionName).Indexes().CreateOne(context.TODO(), index); err != nil {
+		log.Fatal("couldn't initialize repository: ", zap.Error(err))
+	}
+
+	return &translationRepo{
+		ctx:        context.TODO(),
+		collection: db.Collection(collectionName),
+	}
+}
+
+func (r *translationRepo) ListTranslations(kind models.TranslationKind, recordIDs []models.ID) ([]*models.Translation, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "recordId", Value: 1}, {Key: "locale", Value: 1}})
+	cur, err := r.collection.Find(r.ctx, bson.M{"kind": kind, "recordId": bson.M{"$in": recordIDs}}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	translations := []*models.Translation{}
+	if err := cur.All(r.ctx, &translations); err != nil {
+		return nil, err
+	}
+	return translations, nil
+}
+
+func (r *translationRepo) PutTranslation(actorID models.ID, t *models.Translation) (*models.Translation, error) {
+	filter := bson.M{"kind": t.Kind, "recordId": t.RecordID, "locale": t.Locale}
+	var existing models.Translation
+	err := r.collection.FindOne(r.ctx, filter).Decode(&existing)
+	if err == mongo.ErrNoDocuments {
+		t = newTranslation(actorID, t, nil)
+		if _, err := r.collection.InsertOne(r.ctx, t); err != nil {
+			return nil, mongoErr(err)
+		}
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t = newTranslation(actorID, t, &existing)
+	res, err := r.collection.ReplaceOne(r.ctx, bson.M{"_id": existing.ID, "version": existing.Version}, t)
+	if err != nil {
+		return nil, mongoErr(err)
+	}
+	if res.MatchedCount == 0 {
+		return nil, errs.Conflict
+	}
+	return t, nil
+}
+
+func (r *translationRepo) DeleteTranslation(kind models.TranslationKind, recordID models.ID, locale string) error {
+	res, err := r.collection.DeleteOne(r.ctx, bson.M{"kind": kind, "recordId": recordID, "locale": locale})
+	if err != nil {
+		return mongoErr(err)
+	}
+	if res.DeletedCount == 0 {
+		return errs.NotFound
+	}
+	return nil
+}
+
+func (r *translationRepo) DeleteTranslations(kind models.TranslationKind, recordID models.ID) error {
+	_, err := r.collection.DeleteMany(r.ctx, bson.M{"kind": kind, "recordId": recordID})
+	return mongoErr(err)
+}
+
+// newTranslation returns a copy of t stamped as written by actorID now,
+// over existing if it replaces a stored translation.
+func newTranslation(actorID models.ID, t *models.Translation, existing *models.Translation) *models.Translation {
+	stamped := *t
+	if existing == nil {
+		stamped.ID = models.NewID()
+		stamped.Version = 1
+		stamped.Audit = newAudit(actorID)
+		return &stamped
+	}
+	stamped.ID = existing.ID
+	stamped.Version = existing.Version + 1
+	stamped.Audit = touch(cloneAudit(existing.Audit), actorID)
+	return &stamped
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+// translationKey is what makes a translation unique.
+type translationKey struct {
+	kind     models.TranslationKind
+	recordID models.ID
+	locale   string
+}
+
+type memoryTranslationRepo struct {
+	mu           sync.RWMutex
+	translations map[translationKey]*models.Translation
+}
+
+// NewMemoryTranslationRepo returns a TranslationRepo that keeps translations
+// in process memory. It is safe for concurrent use and behaves like the
+// Mongo implementation.
+func NewMemoryTranslationRepo() TranslationRepo {
+	return &memoryTranslationRepo{
+		translations: make(map[translationKey]*models.Translation),
+	}
+}
+
+func (r *memoryTranslationRepo) ListTranslations(kind models.TranslationKind, recordIDs []models.ID) ([]*models.Translation, error) {
+	r.mu.RLock()
+	translations := []*models.Translation{}
+	for key, t := range r.translations {
+		if key.kind == kind && slices.Contains(recordIDs, key.recordID) {
+			translations = append(translations, cloneTranslation(t))
+		}
+	}
+	r.mu.RUnlock()
+
+	slices.SortFunc(translations, func(a, b *models.Translation) int {
+		if c := a.RecordID.Compare(b.RecordID); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Locale, b.Locale)
+	})
+	return translations, nil
+}
+
+func (r *memoryTranslationRepo) PutTranslation(actorID models.ID, t *models.Translation) (*models.Translation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := translationKey{t.Kind, t.RecordID, t.Locale}
+	stored := cloneTranslation(newTranslation(actorID, t, r.translations[key]))
+	r.translations[key] = stored
+	return cloneTranslation(stored), nil
+}
+
+func (r *memoryTranslationRepo) DeleteTranslation(kind models.TranslationKind, recordID models.ID, locale string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := translationKey{kind, recordID, locale}
+	if _, ok := r.translations[key]; !ok {
+		return errs.NotFound
+	}
+	delete(r.translations, key)
+	return nil
+}
+
+func (r *memoryTranslationRepo) DeleteTranslations(kind models.TranslationKind, recordID models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for key := range r.translations {
+		if key.kind == kind && key.recordID == recordID {
+			delete(r.translations, key)
+		}
+	}
+	return nil
+}
+
+func cloneTranslation(t *models.Translation) *models.Translation {
+	clone := *t
+	clone.Fields = maps.Clone(t.Fields)
+	clone.Audit = cloneAudit(t.Audit)
+	return &clone
+}
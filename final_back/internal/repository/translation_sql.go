@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"strconv"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type sqlTranslationRepo struct {
+	sqlConn
+}
+
+// NewSQLTranslationRepo returns a TranslationRepo backed by the translations
+// table of a Postgres or SQLite database opened with db.OpenSQL.
+func NewSQLTranslationRepo(db *sql.DB) TranslationRepo {
+	return &sqlTranslationRepo{
+		sqlConn: sqlConn{db: db},
+	}
+}
+
+const selectTranslation = `SELECT id, kind, record_id, locale, fields, version, ` + auditColumns + ` FROM translations`
+
+func (r *sqlTranslationRepo) ListTranslations(kind models.TranslationKind, recordIDs []models.ID) ([]*models.Translation, error) {
+	if len(recordIDs) == 0 {
+		return []*models.Translation{}, nil
+	}
+	query, args := sqlIn(selectTranslation+` WHERE record_id IN `, recordIDs)
+	query += ` AND kind = $` + strconv.Itoa(len(args)+1) + ` ORDER BY record_id, locale`
+	rows, err := r.q().Query(query, append(args, string(kind))...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	translations := []*models.Translation{}
+	for rows.Next() {
+		t, err := scanTranslation(rows)
+		if err != nil {
+			return nil, err
+		}
+		translations = append(translations, t)
+	}
+	return translations, rows.Err()
+}
+
+func (r *sqlTranslationRepo) PutTranslation(actorID models.ID, t *models.Translation) (*models.Translation, error) {
+	fields, err := json.Marshal(t.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := scanTranslation(r.q().QueryRow(selectTranslation+` WHERE kind = $1 AND record_id = $2 AND locale = $3`, string(t.Kind), t.RecordID, t.Locale))
+	if err == sql.ErrNoRows {
+		t = newTranslation(actorID, t, nil)
+		args := append([]any{t.ID, string(t.Kind), t.RecordID, t.Locale, string(fields), t.Version}, auditArgs(t.Audit)...)
+		_, err := r.q().Exec(`INSERT INTO translations (id, kind, record_id, locale, fields, version, `+auditColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			args...)
+		if err != nil {
+			return nil, sqlErr(err)
+		}
+		return t, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	t = newTranslation(actorID, t, existing)
+	res, err := r.q().Exec(`UPDATE translations SET fields = $2, updated_at = $4, updated_by = $5, version = version + 1 WHERE id = $1 AND version = $3`,
+		existing.ID, string(fields), existing.Version, sqlTime(t.UpdatedAt), sqlID(t.UpdatedBy))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, errs.Conflict
+	}
+	return t, nil
+}
+
+func (r *sqlTranslationRepo) DeleteTranslation(kind models.TranslationKind, recordID models.ID, locale string) error {
+	res, err := r.q().Exec(`DELETE FROM translations WHERE kind = $1 AND record_id = $2 AND locale = $3`, string(kind), recordID, locale)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return errs.NotFound
+	}
+	return nil
+}
+
+func (r *sqlTranslationRepo) DeleteTranslations(kind models.TranslationKind, recordID models.ID) error {
+	_, err := r.q().Exec(`DELETE FROM translations WHERE kind = $1 AND record_id = $2`, string(kind), recordID)
+	return err
+}
+
+func scanTranslation(row rowScanner) (*models.Translation, error) {
+	var (
+		t      models.Translation
+		kind   string
+		fields string
+		audit  auditScan
+	)
+	dest := append([]any{&t.ID, &kind, &t.RecordID, &t.Locale, &fields, &t.Version}, audit.dest()...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	t.Kind = models.TranslationKind(kind)
+	if err := json.Unmarshal([]byte(fields), &t.Fields); err != nil {
+		return nil, err
+	}
+	t.Audit = audit.audit()
+	return &t, nil
+}
@@ -0,0 +1,21 @@
+package repository
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// Deleting a user, movie or review only stamps it as deleted. Every read and
+// write other than the trash operations (ListDeleted*, Restore*, Purge*) acts
+// on live records only, and a trashed record stays in the trash until it is
+// restored or purged. Reviews are also hidden, though not trashed, while
+// their movie or their owner is in the trash.
+
+// live restricts a Mongo filter to documents that are not in the trash.
+func live(filter bson.M) bson.M {
+	filter["deleted"] = nil
+	return filter
+}
+
+// trashed restricts a Mongo filter to documents that are in the trash.
+func trashed(filter bson.M) bson.M {
+	filter["deleted"] = bson.M{"$ne": nil}
+	return filter
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Repos are the repositories a UnitOfWork hands to its function.
+type Repos struct {
+	Users        UserRepo
+	Movies       MovieRepo
+	Reviews      ReviewRepo
+	Credits      CreditRepo
+	Revisions    RevisionRepo
+	Translations TranslationRepo
+	Tags         TagRepo
+	Releases     ReleaseRepo
+	Watchlists   WatchlistRepo
+	Suggestions  SuggestionRepo
+}
+
+// UnitOfWork groups writes that span several repositories, such as the
+// review and credit cascades of deleting a movie or a user, so that they
+// either all happen or none do.
+type UnitOfWork interface {
+	// Do calls fn with repositories bound to a new transaction. The
+	// transaction is committed when fn returns nil and rolled back otherwise.
+	// fn may be called again if the store retries a transient conflict.
+	Do(fn func(repos Repos) error) error
+}
+
+type mongoUnitOfWork struct {
+	db *mongo.Database
+}
+
+// NewMongoUnitOfWork returns a UnitOfWork backed by multi-document
+// transactions, which require the server to run as a replica set.
+func NewMongoUnitOfWork(db *mongo.Database) UnitOfWork {
+	return &mongoUnitOfWork{
+		db: db,
+	}
+}
+
+func (u *mongoUnitOfWork) Do(fn func(repos Repos) error) error {
+	session, err := u.db.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(context.TODO())
+
+	_, err = session.WithTransaction(context.TODO(), func(ctx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(Repos{
+			Users: &userRepo{
+				ctx:        ctx,
+				collection: u.db.Collection(usersCollection),
+			},
+			Movies: &movieRepo{
+				ctx:        ctx,
+				collection: u.db.Collection(moviesCollection),
+			},
+			Reviews: &reviewRepo{
+				ctx:                      ctx,
+				collection:               u.db.Collection(reviewsCollection),
+				reviewCategoryCollection: u.db.Collection(reviewCategoriesCollection),
+			},
+			Credits: &creditRepo{
+				ctx:        ctx,
+				collection: u.db.Collection(creditsCollection),
+			},
+			Revisions: &revisionRepo{
+				ctx:        ctx,
+				collection: u.db.Collection(revisionsCollection),
+			},
+			Translations: &translationRepo{
+				ctx:        ctx,
+				collection: u.db.Collection(translationsCollection),
+			},
+			Tags: &tagRepo{
+				ctx:                ctx,
+				collection:         u.db.Collection(tagsCollection),
+				movieTagCollection: u.db.Collection(movieTagsCollection),
+			},
+			Releases: &releaseRepo{
+				ctx:        ctx,
+				collection: u.db.Collection(releasesCollection),
+			},
+			Watchlists: &watchlistRepo{
+				ctx:             ctx,
+				collection:      u.db.Collection(watchlistCollection),
+				tokenCollection: u.db.Collection(calendarTokensCollection),
+			},
+			Suggestions: &suggestionRepo{
+				ctx:        ctx,
+				collection: u.db.Collection(suggestionsCollection),
+			},
+		})
+	})
+	return err
+}
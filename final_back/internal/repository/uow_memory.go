@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"maps"
+	"sync"
+)
+
+// memorySnapshotter is implemented by the in-memory repositories. snapshot
+// returns a function that puts the repository back into its current state.
+type memorySnapshotter interface {
+	snapshot() (restore func())
+}
+
+type memoryUnitOfWork struct {
+	mu    sync.Mutex
+	repos Repos
+}
+
+// NewMemoryUnitOfWork returns a UnitOfWork over the in-memory repositories in
+// repos. Units of work run one at a time and are rolled back by restoring a
+// snapshot, so they are atomic but not isolated from writes made outside a
+// unit of work.
+func NewMemoryUnitOfWork(repos Repos) UnitOfWork {
+	return &memoryUnitOfWork{
+		repos: repos,
+	}
+}
+
+func (u *memoryUnitOfWork) Do(fn func(repos Repos) error) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	var restores []func()
+	for _, repo := range []any{u.repos.Users, u.repos.Movies, u.repos.Reviews, u.repos.Credits, u.repos.Revisions, u.repos.Translations, u.repos.Tags, u.repos.Releases, u.repos.Watchlists, u.repos.Suggestions} {
+		if s, ok := repo.(memorySnapshotter); ok {
+			restores = append(restores, s.snapshot())
+		}
+	}
+
+	if err := fn(u.repos); err != nil {
+		for _, restore := range restores {
+			restore()
+		}
+		return err
+	}
+	return nil
+}
+
+// Stored values are never modified in place, so a shallow copy of a
+// repository's map is a consistent snapshot of it.
+
+func (r *memoryUserRepo) snapshot() func() {
+	r.mu.RLock()
+	users := maps.Clone(r.users)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		r.users = users
+		r.mu.Unlock()
+	}
+}
+
+func (r *memoryMovieRepo) snapshot() func() {
+	r.mu.RLock()
+	movies := maps.Clone(r.movies)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		r.movies = movies
+		r.mu.Unlock()
+	}
+}
+
+func (r *memoryReviewRepo) snapshot() func() {
+	r.mu.RLock()
+	reviews := maps.Clone(r.reviews)
+	categories := maps.Clone(r.categories)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		r.reviews = reviews
+		r.categories = categories
+		r.mu.Unlock()
+	}
+}
+
+func (r *memoryCreditRepo) snapshot() func() {
+	r.mu.RLock()
+	credits := maps.Clone(r.credits)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		r.credits = credits
+		r.mu.Unlock()
+	}
+}
+
+func (r *memoryRevisionRepo) snapshot() func() {
+	r.mu.RLock()
+	revisions := maps.Clone(r.revisions)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		r.revisions = revisions
+		r.mu.Unlock()
+	}
+}
+
+func (r *memoryTranslationRepo) snapshot() func() {
+	r.mu.RLock()
+	translations := maps.Clone(r.translations)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		r.translations = translations
+		r.mu.Unlock()
+	}
+}
+
+func (r *memoryTagRepo) snapshot() func() {
+	r.mu.RLock()
+	tags := maps.Clone(r.tags)
+	movieTags := maps.Clone(r.movieTags)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		r.tags = tags
+		r.movieTags = movieTags
+		r.mu.Unlock()
+	}
+}
+
+func (r *memoryReleaseRepo) snapshot() func() {
+	r.mu.RLock()
+	releases := maps.Clone(r.releases)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		r.releases = releases
+		r.mu.Unlock()
+	}
+}
+
+func (r *memoryWatchlistRepo) snapshot() func() {
+	r.mu.RLock()
+	entries := maps.Clone(r.entries)
+	tokens := maps.Clone(r.tokens)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		r.entries = entries
+		r.tokens = tokens
+		r.mu.Unlock()
+	}
+}
+
+func (r *memorySuggestionRepo) snapshot() func() {
+	r.mu.RLock()
+	suggestions := maps.Clone(r.suggestions)
+	r.mu.RUnlock()
+
+	return func() {
+		r.mu.Lock()
+		r.suggestions = suggestions
+		r.mu.Unlock()
+	}
+}
@@ -0,0 +1,41 @@
+package repository
+
+import "database/sql"
+
+type sqlUnitOfWork struct {
+	db *sql.DB
+}
+
+// NewSQLUnitOfWork returns a UnitOfWork that runs fn in a single database
+// transaction.
+func NewSQLUnitOfWork(db *sql.DB) UnitOfWork {
+	return &sqlUnitOfWork{
+		db: db,
+	}
+}
+
+func (u *sqlUnitOfWork) Do(fn func(repos Repos) error) error {
+	tx, err := u.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer rollback(tx)
+
+	conn := sqlConn{db: u.db, tx: tx}
+	err = fn(Repos{
+		Users:        &sqlUserRepo{sqlConn: conn},
+		Movies:       &sqlMovieRepo{sqlConn: conn},
+		Reviews:      &sqlReviewRepo{sqlConn: conn},
+		Credits:      &sqlCreditRepo{sqlConn: conn},
+		Revisions:    &sqlRevisionRepo{sqlConn: conn},
+		Translations: &sqlTranslationRepo{sqlConn: conn},
+		Tags:         &sqlTagRepo{sqlConn: conn},
+		Releases:     &sqlReleaseRepo{sqlConn: conn},
+		Watchlists:   &sqlWatchlistRepo{sqlConn: conn},
+		Suggestions:  &sqlSuggestionRepo{sqlConn: conn},
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -2,29 +2,38 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"slices"
 
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
 	"github.com/kakimnsnv/ios_final_back/internal/models"
 	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.uber.org/zap"
 )
 
 type UserRepo interface {
-	GetUserByID(userID *primitive.ObjectID) (*models.User, error)
+	GetUserByID(userID models.ID) (*models.User, error)
 	GetUserByUsername(username string) (*models.User, error)
-	CreateUser(req *models.User) (primitive.ObjectID, error)
-	UpdateUser(id *primitive.ObjectID, req *models.User) (*models.User, error)
-	DeleteUser(id *primitive.ObjectID) error
-	ListUsers() ([]*models.User, error)
+	CreateUser(actorID models.ID, req *models.User) (models.ID, error)
+	UpdateUser(actorID, id models.ID, version int64, req *models.User) (*models.User, error)
+	DeleteUser(actorID, id models.ID) error
+	ListUsers(opts models.ListOptions) (models.Page[*models.User], error)
+	ListDeletedUsers() ([]*models.User, error)
+	RestoreUser(actorID, id models.ID) (*models.User, error)
+	PurgeUser(id models.ID) error
 }
 
+const usersCollection = "users"
+
 type userRepo struct {
+	ctx        context.Context
 	collection *mongo.Collection
 }
 
 func NewUserRepo(log *zap.Logger, collNames map[string]int, db *mongo.Database) UserRepo {
-	var collectionName = "users"
+	var collectionName = usersCollection
 
 	if _, exists := collNames[collectionName]; !exists {
 		if err := db.CreateCollection(context.TODO(), collectionName); err != nil {
@@ -32,63 +41,170 @@ func NewUserRepo(log *zap.Logger, collNames map[string]int, db *mongo.Database)
 		}
 	}
 
+	indexes := append(listIndexes(nil, models.SortCreatedAt, models.SortUpdatedAt), mongo.IndexModel{
+		Keys:    bson.D{{Key: "username", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if _, err := db.Collection(collectionName).Indexes().CreateMany(context.TODO(), indexes); err != nil {
+		log.Fatal("couldn't initialize repository: ", zap.Error(err))
+	}
+
 	return &userRepo{
+		ctx:        context.TODO(),
 		collection: db.Collection(collectionName),
 	}
 }
 
-func (r *userRepo) GetUserByID(userID *primitive.ObjectID) (*models.User, error) {
+func (r *userRepo) GetUserByID(userID models.ID) (*models.User, error) {
 	var user models.User
-	err := r.collection.FindOne(context.TODO(), bson.M{"_id": userID}).Decode(&user)
+	err := r.collection.FindOne(r.ctx, live(bson.M{"_id": userID})).Decode(&user)
 	if err != nil {
-		return nil, err
+		return nil, mongoErr(err)
 	}
 	return &user, nil
 }
 
 func (r *userRepo) GetUserByUsername(username string) (*models.User, error) {
 	var user models.User
-	err := r.collection.FindOne(context.TODO(), bson.M{"username": username}).Decode(&user)
+	err := r.collection.FindOne(r.ctx, live(bson.M{"username": username})).Decode(&user)
 	if err != nil {
-		return nil, err
+		return nil, mongoErr(err)
 	}
 	return &user, nil
 }
 
-func (r *userRepo) CreateUser(req *models.User) (primitive.ObjectID, error) {
-	res, err := r.collection.InsertOne(context.TODO(), req)
-	if err != nil {
-		return primitive.NilObjectID, err
+func (r *userRepo) CreateUser(actorID models.ID, req *models.User) (models.ID, error) {
+	user := *req
+	if user.ID.IsZero() {
+		user.ID = models.NewID()
+	}
+	user.Version = 1
+	user.Audit = newAudit(actorID)
+
+	if _, err := r.collection.InsertOne(r.ctx, &user); err != nil {
+		return models.NilID, mongoErr(err)
 	}
-	return res.InsertedID.(primitive.ObjectID), nil
+	return user.ID, nil
 }
 
-func (r *userRepo) UpdateUser(id *primitive.ObjectID, req *models.User) (*models.User, error) {
+// UpdateUser applies req to the user only if it is still at version, and
+// returns errs.Conflict otherwise.
+func (r *userRepo) UpdateUser(actorID, id models.ID, version int64, req *models.User) (*models.User, error) {
 	var user models.User
-	err := r.collection.FindOneAndUpdate(context.TODO(), bson.M{"_id": id}, bson.M{"$set": req}).Decode(&user)
+	filter := live(bson.M{"_id": id, "version": versionFilter(version)})
+	update := mongoTouch(bson.M{"$set": userUpdateFields(req), "$inc": bson.M{"version": 1}}, actorID)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(r.ctx, filter, update, opts).Decode(&user)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, mongoStale(r.ctx, r.collection, id)
+	}
 	if err != nil {
-		return nil, err
+		return nil, mongoErr(err)
 	}
 	return &user, nil
 }
 
-func (r *userRepo) DeleteUser(id *primitive.ObjectID) error {
-	_, err := r.collection.DeleteOne(context.TODO(), bson.M{"_id": id})
+// DeleteUser moves the user to the trash. The username stays taken until the
+// user is purged.
+func (r *userRepo) DeleteUser(actorID, id models.ID) error {
+	update := mongoTouch(bson.M{"$set": bson.M{"deleted": timestamp()}, "$inc": bson.M{"version": 1}}, actorID)
+	res, err := r.collection.UpdateOne(r.ctx, live(bson.M{"_id": id}), update)
 	if err != nil {
-		return err
+		return mongoErr(err)
+	}
+	if res.MatchedCount == 0 {
+		return errs.NotFound
 	}
 	return nil
 }
 
-func (r *userRepo) ListUsers() ([]*models.User, error) {
-	cur, err := r.collection.Find(context.TODO(), bson.M{})
+func (r *userRepo) ListUsers(opts models.ListOptions) (models.Page[*models.User], error) {
+	return r.find(live(bson.M{}), opts)
+}
+
+func (r *userRepo) ListDeletedUsers() ([]*models.User, error) {
+	users, err := r.find(trashed(bson.M{}), models.ListOptions{})
+	return users.Items, err
+}
+
+func (r *userRepo) find(filter bson.M, opts models.ListOptions) (models.Page[*models.User], error) {
+	filter, findOpts, err := mongoList(filter, opts, models.UserListFields)
+	if err != nil {
+		return models.Page[*models.User]{}, err
+	}
+	cur, err := r.collection.Find(r.ctx, filter, findOpts)
+	if err != nil {
+		return models.Page[*models.User]{}, err
+	}
+
+	users := []*models.User{}
+	if err := cur.All(r.ctx, &users); err != nil {
+		return models.Page[*models.User]{}, err
+	}
+	return page(users, opts), nil
+}
+
+func (r *userRepo) RestoreUser(actorID, id models.ID) (*models.User, error) {
+	var user models.User
+	update := mongoTouch(bson.M{"$unset": bson.M{"deleted": ""}, "$inc": bson.M{"version": 1}}, actorID)
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(r.ctx, trashed(bson.M{"_id": id}), update, opts).Decode(&user)
+	if err != nil {
+		return nil, mongoErr(err)
+	}
+	return &user, nil
+}
+
+// PurgeUser removes a trashed user for good.
+func (r *userRepo) PurgeUser(id models.ID) error {
+	res, err := r.collection.DeleteOne(r.ctx, trashed(bson.M{"_id": id}))
 	if err != nil {
-		return nil, err
+		return mongoErr(err)
+	}
+	if res.DeletedCount == 0 {
+		return errs.NotFound
 	}
+	return nil
+}
 
-	var users []*models.User
-	if err := cur.All(context.TODO(), &users); err != nil {
-		return nil, err
+// userUpdateFields returns the stored fields a UpdateUser call with req sets.
+func userUpdateFields(req *models.User) bson.M {
+	fields := bson.M{"roles": req.Roles}
+	if req.Username != "" {
+		fields["username"] = req.Username
+	}
+	if len(req.PasswordHash) > 0 {
+		fields["passwordHash"] = req.PasswordHash
+	}
+	if req.Email != "" {
+		fields["email"] = req.Email
+	}
+	if req.Avatar != nil {
+		fields["avatarKey"] = req.Avatar.Key
+		fields["avatar"] = req.Avatar
+	}
+	return fields
+}
+
+// applyUserUpdate is the in-process equivalent of userUpdateFields: empty
+// optional fields are left untouched while roles are always overwritten.
+// The avatar is only ever replaced, never cleared.
+func applyUserUpdate(user *models.User, req *models.User) *models.User {
+	if req.Username != "" {
+		user.Username = req.Username
+	}
+	if len(req.PasswordHash) > 0 {
+		user.PasswordHash = append([]byte(nil), req.PasswordHash...)
+	}
+	if req.Email != "" {
+		user.Email = req.Email
+	}
+	if req.Avatar != nil {
+		avatar := *req.Avatar
+		avatar.Variants = slices.Clone(avatar.Variants)
+		user.AvatarKey = avatar.Key
+		user.Avatar = &avatar
 	}
-	return users, nil
+	user.Roles = append([]models.Role(nil), req.Roles...)
+	return user
 }
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type memoryUserRepo struct {
+	mu    sync.RWMutex
+	users map[models.ID]*models.User
+}
+
+// NewMemoryUserRepo returns a UserRepo that keeps users in process memory.
+// It is safe for concurrent use and behaves like the Mongo implementation.
+func NewMemoryUserRepo() UserRepo {
+	return &memoryUserRepo{
+		users: make(map[models.ID]*models.User),
+	}
+}
+
+func (r *memoryUserRepo) GetUserByID(userID models.ID) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[userID]
+	if !ok || user.Deleted != nil {
+		return nil, errs.NotFound
+	}
+	return cloneUser(user), nil
+}
+
+func (r *memoryUserRepo) GetUserByUsername(username string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if user := r.findByUsername(username); user != nil && user.Deleted == nil {
+		return cloneUser(user), nil
+	}
+	return nil, errs.NotFound
+}
+
+func (r *memoryUserRepo) CreateUser(actorID models.ID, req *models.User) (models.ID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.findByUsername(req.Username) != nil {
+		return models.NilID, errs.AlreadyExists
+	}
+
+	user := cloneUser(req)
+	if user.ID.IsZero() {
+		user.ID = models.NewID()
+	}
+	user.Version = 1
+	user.Audit = newAudit(actorID)
+	if _, exists := r.users[user.ID]; exists {
+		return models.NilID, errs.AlreadyExists
+	}
+
+	r.users[user.ID] = user
+	return user.ID, nil
+}
+
+func (r *memoryUserRepo) UpdateUser(actorID, id models.ID, version int64, req *models.User) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.Deleted != nil {
+		return nil, errs.NotFound
+	}
+	if user.Version != version {
+		return nil, errs.Conflict
+	}
+
+	if req.Username != "" && req.Username != user.Username {
+		if r.findByUsername(req.Username) != nil {
+			return nil, errs.AlreadyExists
+		}
+	}
+
+	updated := applyUserUpdate(cloneUser(user), req)
+	updated.Version++
+	updated.Audit = touch(updated.Audit, actorID)
+	r.users[id] = updated
+	return cloneUser(updated), nil
+}
+
+func (r *memoryUserRepo) DeleteUser(actorID, id models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.Deleted != nil {
+		return errs.NotFound
+	}
+
+	deleted := cloneUser(user)
+	now := timestamp()
+	deleted.Deleted = &now
+	deleted.Version++
+	deleted.Audit = touch(deleted.Audit, actorID)
+	r.users[id] = deleted
+	return nil
+}
+
+func (r *memoryUserRepo) ListUsers(opts models.ListOptions) (models.Page[*models.User], error) {
+	users := r.filter(func(user *models.User) bool {
+		return user.Deleted == nil
+	})
+	return memoryList(users, opts, models.UserListFields)
+}
+
+func (r *memoryUserRepo) ListDeletedUsers() ([]*models.User, error) {
+	return r.filter(func(user *models.User) bool {
+		return user.Deleted != nil
+	}), nil
+}
+
+func (r *memoryUserRepo) filter(match func(user *models.User) bool) []*models.User {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := []*models.User{}
+	for _, user := range r.users {
+		if match(user) {
+			users = append(users, cloneUser(user))
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID.Compare(users[j].ID) < 0
+	})
+	return users
+}
+
+func (r *memoryUserRepo) RestoreUser(actorID, id models.ID) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.Deleted == nil {
+		return nil, errs.NotFound
+	}
+
+	restored := cloneUser(user)
+	restored.Deleted = nil
+	restored.Version++
+	restored.Audit = touch(restored.Audit, actorID)
+	r.users[id] = restored
+	return cloneUser(restored), nil
+}
+
+func (r *memoryUserRepo) PurgeUser(id models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok || user.Deleted == nil {
+		return errs.NotFound
+	}
+	delete(r.users, id)
+	return nil
+}
+
+// findByUsername also finds trashed users, whose usernames stay taken. It
+// must be called with r.mu held.
+func (r *memoryUserRepo) findByUsername(username string) *models.User {
+	for _, user := range r.users {
+		if user.Username == username {
+			return user
+		}
+	}
+	return nil
+}
+
+func cloneUser(user *models.User) *models.User {
+	clone := *user
+	if user.PasswordHash != nil {
+		clone.PasswordHash = append([]byte(nil), user.PasswordHash...)
+	}
+	if user.Roles != nil {
+		clone.Roles = append([]models.Role(nil), user.Roles...)
+	}
+	if user.Avatar != nil {
+		avatar := *user.Avatar
+		avatar.Variants = slices.Clone(avatar.Variants)
+		clone.Avatar = &avatar
+	}
+	if user.Deleted != nil {
+		deleted := *user.Deleted
+		clone.Deleted = &deleted
+	}
+	clone.Audit = cloneAudit(user.Audit)
+	return &clone
+}
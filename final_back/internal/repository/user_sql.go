@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type sqlUserRepo struct {
+	sqlConn
+}
+
+// NewSQLUserRepo returns a UserRepo backed by the users and user_roles tables
+// of a Postgres or SQLite database opened with db.OpenSQL.
+func NewSQLUserRepo(db *sql.DB) UserRepo {
+	return &sqlUserRepo{
+		sqlConn: sqlConn{db: db},
+	}
+}
+
+const selectUser = `SELECT id, username, password_hash, email, deleted, version, ` + avatarColumns + `, ` + auditColumns + ` FROM users`
+
+// avatarColumns are the posterColumns of a user's avatar.
+const avatarColumns = `avatar_key, avatar_url, avatar_content_type, avatar_size, avatar_width, avatar_height, avatar_variants, avatar_blur_hash, avatar_dominant_color`
+
+func (r *sqlUserRepo) GetUserByID(userID models.ID) (*models.User, error) {
+	return r.getUser(selectUser+` WHERE id = $1 AND deleted IS NULL`, userID)
+}
+
+func (r *sqlUserRepo) GetUserByUsername(username string) (*models.User, error) {
+	return r.getUser(selectUser+` WHERE username = $1 AND deleted IS NULL`, username)
+}
+
+func (r *sqlUserRepo) getUser(query string, args ...any) (*models.User, error) {
+	user, err := scanUser(r.q().QueryRow(query, args...))
+	if err != nil {
+		return nil, sqlErr(err)
+	}
+
+	roles, err := r.listRoles(`WHERE user_id = $1`, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	user.Roles = roles[user.ID]
+	return user, nil
+}
+
+func (r *sqlUserRepo) CreateUser(actorID models.ID, req *models.User) (models.ID, error) {
+	id := req.ID
+	if id.IsZero() {
+		id = models.NewID()
+	}
+
+	err := r.inTx(func(tx querier) error {
+		args := append([]any{id, req.Username, req.PasswordHash, req.Email}, auditArgs(newAudit(actorID))...)
+		_, err := tx.Exec(`INSERT INTO users (id, username, password_hash, email, version, `+auditColumns+`) VALUES ($1, $2, $3, $4, 1, $5, $6, $7, $8)`,
+			args...)
+		if err != nil {
+			return sqlErr(err)
+		}
+		return insertRoles(tx, id, req.Roles)
+	})
+	if err != nil {
+		return models.NilID, err
+	}
+	return id, nil
+}
+
+func (r *sqlUserRepo) UpdateUser(actorID, id models.ID, version int64, req *models.User) (*models.User, error) {
+	user, err := r.GetUserByID(id)
+	if err != nil {
+		return nil, err
+	}
+	user = applyUserUpdate(user, req)
+	user.Audit = touch(user.Audit, actorID)
+
+	avatar, err := posterArgs(user.Avatar)
+	if err != nil {
+		return nil, err
+	}
+	args := append([]any{id, user.Username, user.PasswordHash, user.Email, version, sqlTime(user.UpdatedAt), sqlID(user.UpdatedBy)}, avatar...)
+	err = r.inTx(func(tx querier) error {
+		res, err := tx.Exec(`UPDATE users SET username = $2, password_hash = $3, email = $4, updated_at = $6, updated_by = $7, `+
+			`avatar_key = $8, avatar_url = $9, avatar_content_type = $10, avatar_size = $11, avatar_width = $12, avatar_height = $13, `+
+			`avatar_variants = $14, avatar_blur_hash = $15, avatar_dominant_color = $16, `+
+			`version = version + 1 WHERE id = $1 AND version = $5 AND deleted IS NULL`,
+			args...)
+		if err != nil {
+			return sqlErr(err)
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return sqlStale(tx, "users", id)
+		}
+
+		if _, err := tx.Exec(`DELETE FROM user_roles WHERE user_id = $1`, id); err != nil {
+			return err
+		}
+		return insertRoles(tx, id, user.Roles)
+	})
+	if err != nil {
+		return nil, err
+	}
+	user.Version = version + 1
+	return user, nil
+}
+
+func (r *sqlUserRepo) DeleteUser(actorID, id models.ID) error {
+	audit := touch(models.Audit{}, actorID)
+	res, err := r.q().Exec(`UPDATE users SET deleted = $2, updated_at = $2, updated_by = $3, version = version + 1 WHERE id = $1 AND deleted IS NULL`,
+		id, sqlTime(audit.UpdatedAt), sqlID(audit.UpdatedBy))
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return errs.NotFound
+	}
+	return nil
+}
+
+func (r *sqlUserRepo) RestoreUser(actorID, id models.ID) (*models.User, error) {
+	audit := touch(models.Audit{}, actorID)
+	res, err := r.q().Exec(`UPDATE users SET deleted = NULL, updated_at = $2, updated_by = $3, version = version + 1 WHERE id = $1 AND deleted IS NOT NULL`,
+		id, sqlTime(audit.UpdatedAt), sqlID(audit.UpdatedBy))
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 0 {
+		return nil, errs.NotFound
+	}
+	return r.GetUserByID(id)
+}
+
+func (r *sqlUserRepo) PurgeUser(id models.ID) error {
+	return r.inTx(func(tx querier) error {
+		res, err := tx.Exec(`DELETE FROM users WHERE id = $1 AND deleted IS NOT NULL`, id)
+		if err != nil {
+			return err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return errs.NotFound
+		}
+		_, err = tx.Exec(`DELETE FROM user_roles WHERE user_id = $1`, id)
+		return err
+	})
+}
+
+func (r *sqlUserRepo) ListUsers(opts models.ListOptions) (models.Page[*models.User], error) {
+	users, err := r.list(`WHERE deleted IS NULL`, opts)
+	if err != nil {
+		return models.Page[*models.User]{}, err
+	}
+	return page(users, opts), nil
+}
+
+func (r *sqlUserRepo) ListDeletedUsers() ([]*models.User, error) {
+	return r.list(`WHERE deleted IS NOT NULL`, models.ListOptions{})
+}
+
+func (r *sqlUserRepo) list(where string, opts models.ListOptions) ([]*models.User, error) {
+	query, args, err := sqlList(selectUser+` `+where, nil, opts, models.UserListFields)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := r.q().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []*models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	roles, err := r.listRoles("")
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		user.Roles = roles[user.ID]
+	}
+	return users, nil
+}
+
+// listRoles returns the roles of every user matched by where, in the order
+// they were assigned.
+func (r *sqlUserRepo) listRoles(where string, args ...any) (map[models.ID][]models.Role, error) {
+	rows, err := r.q().Query(`SELECT user_id, role FROM user_roles `+where+` ORDER BY user_id, position`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := make(map[models.ID][]models.Role)
+	for rows.Next() {
+		var userID models.ID
+		var role models.Role
+		if err := rows.Scan(&userID, &role); err != nil {
+			return nil, err
+		}
+		roles[userID] = append(roles[userID], role)
+	}
+	return roles, rows.Err()
+}
+
+func insertRoles(tx querier, userID models.ID, roles []models.Role) error {
+	for position, role := range roles {
+		_, err := tx.Exec(`INSERT INTO user_roles (user_id, position, role) VALUES ($1, $2, $3)`,
+			userID, position, role)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scanUser(row rowScanner) (*models.User, error) {
+	var (
+		user    models.User
+		deleted sql.NullTime
+		avatar  posterScan
+		audit   auditScan
+	)
+	dest := append([]any{&user.ID, &user.Username, &user.PasswordHash, &user.Email, &deleted, &user.Version}, avatar.dest()...)
+	dest = append(dest, audit.dest()...)
+	err := row.Scan(dest...)
+	if err != nil {
+		return nil, err
+	}
+	user.Deleted = scanNullTime(deleted)
+	if user.Avatar, err = avatar.poster(); err != nil {
+		return nil, err
+	}
+	if user.Avatar != nil {
+		user.AvatarKey = user.Avatar.Key
+	}
+	user.Audit = audit.audit()
+	return &user, nil
+}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+// WatchlistRepo stores the movies users keep on their watchlists and the
+// secret tokens of their watchlist calendars. Entries stay in place while
+// their movie or user is in the trash and go when it is purged.
+type WatchlistRepo interface {
+	ListWatchlistEntries(opts models.ListOptions) (models.Page[*models.WatchlistEntry], error)
+	// AddToWatchlist returns errs.AlreadyExists if the movie is on the
+	// user's watchlist already.
+	AddToWatchlist(userID, movieID models.ID) (models.ID, error)
+	RemoveFromWatchlist(userID, movieID models.ID) error
+	DeleteWatchlistEntriesByMovieID(movieID models.ID) error
+	// DeleteWatchlist removes the watchlist of a user along with their
+	// calendar token.
+	DeleteWatchlist(userID models.ID) error
+	// MoveWatchlistEntries moves a movie on watchlists to another movie,
+	// dropping the entries of users who have both.
+	MoveWatchlistEntries(actorID, from, to models.ID) error
+
+	// GetCalendarToken returns errs.NotFound if the user has no token yet.
+	GetCalendarToken(userID models.ID) (string, error)
+	// SetCalendarToken gives a user a new token, replacing any they had.
+	SetCalendarToken(userID models.ID, token string) error
+	// GetCalendarTokenUser returns the user a token belongs to, or
+	// errs.NotFound.
+	GetCalendarTokenUser(token string) (models.ID, error)
+}
+
+const (
+	watchlistCollection      = "watchlist"
+	calendarTokensCollection = "calendarTokens"
+)
+
+type watchlistRepo struct {
+	ctx             context.Context
+	collection      *mongo.Collection
+	tokenCollection *mongo.Collection
+}
+
+// calendarToken is how calendar tokens are stored, one document per user.
+type calendarToken struct {
+	UserID models.ID `bson:"_id"`
+	Token  string    `bson:"token"`
+}
+
+func NewWatchlistRepo(log *zap.Logger, collNames map[string]int, db *mongo.Database) WatchlistRepo {
+	for _, collectionName := range []string{watchlistCollection, calendarTokensCollection} {
+		if _, exists := collNames[collectionName]; !exists {
+			if err := db.CreateCollection(context.TODO(), collectionName); err != nil {
+				log.Fatal("couldn't initialize repository: ", zap.Error(err))
+			}
+		}
+	}
+
+	indexes := append(listIndexes(nil, models.SortCreatedAt, models.SortUpdatedAt),
+		mongo.IndexModel{
+			Keys:    bson.D{{Key: "userId", Value: 1}, {Key: "movieId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		mongo.IndexModel{Keys: bson.D{{Key: "movieId", Value: 1}}},
+	)
+	if _, err := db.Collection(watchlistCollection).Indexes().CreateMany(context.TODO(), indexes); err != nil {
+		log.Fatal("couldn't initialize repository: ", zap.Error(err))
+	}
+	tokenIndex := mongo.IndexModel{Keys: bson.D{{Key: "token", Value: 1}}, Options: options.Index().SetUnique(true)}
+	if _, err := db.Collection(calendarTokensCollection).Indexes().CreateOne(context.TODO(), tokenIndex); err != nil {
+		log.Fatal("couldn't initialize repository: ", zap.Error(err))
+	}
+
+	return &watchlistRepo{
+		ctx:             context.TODO(),
+		collection:      db.Collection(watchlistCollection),
+		tokenCollection: db.Collection(calendarTokensCollection),
+	}
+}
+
+func (r *watchlistRepo) ListWatchlistEntries(opts models.ListOptions) (models.Page[*models.WatchlistEntry], error) {
+	filter, findOpts, err := mongoList(bson.M{}, opts, models.WatchlistListFields)
+	if err != nil {
+		return models.Page[*models.WatchlistEntry]{}, err
+	}
+	cur, err := r.collection.Find(r.ctx, filter, findOpts)
+	if err != nil {
+		return models.Page[*models.WatchlistEntry]{}, err
+	}
+
+	entries := []*models.WatchlistEntry{}
+	if err := cur.All(r.ctx, &entries); err != nil {
+		return models.Page[*models.WatchlistEntry]{}, err
+	}
+	return page(entries, opts), nil
+}
+
+func (r *watchlistRepo) AddToWatchlist(userID, movieID models.ID) (models.ID, error) {
+	entry := newWatchlistEntry(userID, movieID)
+	if _, err := r.collection.InsertOne(r.ctx, entry); err != nil {
+		return models.NilID, mongoErr(err)
+	}
+	return entry.ID, nil
+}
+
+func (r *watchlistRepo) RemoveFromWatchlist(userID, movieID models.ID) error {
+	res, err := r.collection.DeleteOne(r.ctx, bson.M{"userId": userID, "movieId": movieID})
+	if err != nil {
+		return mongoErr(err)
+	}
+	if res.DeletedCount == 0 {
+		return errs.NotFound
+	}
+	return nil
+}
+
+func (r *watchlistRepo) DeleteWatchlistEntriesByMovieID(movieID models.ID) error {
+	_, err := r.collection.DeleteMany(r.ctx, bson.M{"movieId": movieID})
+	return mongoErr(err)
+}
+
+func (r *watchlistRepo) DeleteWatchlist(userID models.ID) error {
+	if _, err := r.collection.DeleteMany(r.ctx, bson.M{"userId": userID}); err != nil {
+		return mongoErr(err)
+	}
+	_, err := r.tokenCollection.DeleteOne(r.ctx, bson.M{"_id": userID})
+	return mongoErr(err)
+}
+
+func (r *watchlistRepo) MoveWatchlistEntries(actorID, from, to models.ID) error {
+	var kept []*models.WatchlistEntry
+	cur, err := r.collection.Find(r.ctx, bson.M{"movieId": to})
+	if err != nil {
+		return err
+	}
+	if err := cur.All(r.ctx, &kept); err != nil {
+		return err
+	}
+	users := []models.ID{}
+	for _, entry := range kept {
+		users = append(users, entry.UserID)
+	}
+	if _, err := r.collection.DeleteMany(r.ctx, bson.M{"movieId": from, "userId": bson.M{"$in": users}}); err != nil {
+		return mongoErr(err)
+	}
+
+	update := mongoTouch(bson.M{"$set": bson.M{"movieId": to}}, actorID)
+	_, err = r.collection.UpdateMany(r.ctx, bson.M{"movieId": from}, update)
+	return mongoErr(err)
+}
+
+func (r *watchlistRepo) GetCalendarToken(userID models.ID) (string, error) {
+	var token calendarToken
+	if err := r.tokenCollection.FindOne(r.ctx, bson.M{"_id": userID}).Decode(&token); err != nil {
+		return "", mongoErr(err)
+	}
+	return token.Token, nil
+}
+
+func (r *watchlistRepo) SetCalendarToken(userID models.ID, token string) error {
+	opts := options.Replace().SetUpsert(true)
+	_, err := r.tokenCollection.ReplaceOne(r.ctx, bson.M{"_id": userID}, calendarToken{UserID: userID, Token: token}, opts)
+	return mongoErr(err)
+}
+
+func (r *watchlistRepo) GetCalendarTokenUser(token string) (models.ID, error) {
+	var stored calendarToken
+	if err := r.tokenCollection.FindOne(r.ctx, bson.M{"token": token}).Decode(&stored); err != nil {
+		return models.NilID, mongoErr(err)
+	}
+	return stored.UserID, nil
+}
+
+func newWatchlistEntry(userID, movieID models.ID) *models.WatchlistEntry {
+	return &models.WatchlistEntry{
+		ID:      models.NewID(),
+		UserID:  userID,
+		MovieID: movieID,
+		Audit:   newAudit(userID),
+	}
+}
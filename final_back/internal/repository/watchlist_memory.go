@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"sync"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type memoryWatchlistRepo struct {
+	mu      sync.RWMutex
+	entries map[models.ID]*models.WatchlistEntry
+	// tokens maps users to their calendar tokens.
+	tokens map[models.ID]string
+}
+
+// NewMemoryWatchlistRepo returns a WatchlistRepo that keeps watchlists in
+// process memory. It is safe for concurrent use and behaves like the Mongo
+// implementation.
+func NewMemoryWatchlistRepo() WatchlistRepo {
+	return &memoryWatchlistRepo{
+		entries: make(map[models.ID]*models.WatchlistEntry),
+		tokens:  make(map[models.ID]string),
+	}
+}
+
+func (r *memoryWatchlistRepo) ListWatchlistEntries(opts models.ListOptions) (models.Page[*models.WatchlistEntry], error) {
+	r.mu.RLock()
+	entries := make([]*models.WatchlistEntry, 0, len(r.entries))
+	for _, entry := range r.entries {
+		entries = append(entries, cloneWatchlistEntry(entry))
+	}
+	r.mu.RUnlock()
+
+	return memoryList(entries, opts, models.WatchlistListFields)
+}
+
+func (r *memoryWatchlistRepo) AddToWatchlist(userID, movieID models.ID) (models.ID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.find(userID, movieID) != nil {
+		return models.NilID, errs.AlreadyExists
+	}
+	entry := newWatchlistEntry(userID, movieID)
+	r.entries[entry.ID] = entry
+	return entry.ID, nil
+}
+
+func (r *memoryWatchlistRepo) RemoveFromWatchlist(userID, movieID models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry := r.find(userID, movieID)
+	if entry == nil {
+		return errs.NotFound
+	}
+	delete(r.entries, entry.ID)
+	return nil
+}
+
+func (r *memoryWatchlistRepo) DeleteWatchlistEntriesByMovieID(movieID models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, entry := range r.entries {
+		if entry.MovieID == movieID {
+			delete(r.entries, id)
+		}
+	}
+	return nil
+}
+
+func (r *memoryWatchlistRepo) DeleteWatchlist(userID models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, entry := range r.entries {
+		if entry.UserID == userID {
+			delete(r.entries, id)
+		}
+	}
+	delete(r.tokens, userID)
+	return nil
+}
+
+func (r *memoryWatchlistRepo) MoveWatchlistEntries(actorID, from, to models.ID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, entry := range r.entries {
+		if entry.MovieID != from {
+			continue
+		}
+		if r.find(entry.UserID, to) != nil {
+			delete(r.entries, id)
+			continue
+		}
+		moved := cloneWatchlistEntry(entry)
+		moved.MovieID = to
+		moved.Audit = touch(moved.Audit, actorID)
+		r.entries[id] = moved
+	}
+	return nil
+}
+
+func (r *memoryWatchlistRepo) GetCalendarToken(userID models.ID) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	token, ok := r.tokens[userID]
+	if !ok {
+		return "", errs.NotFound
+	}
+	return token, nil
+}
+
+func (r *memoryWatchlistRepo) SetCalendarToken(userID models.ID, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for other, t := range r.tokens {
+		if t == token && other != userID {
+			return errs.AlreadyExists
+		}
+	}
+	r.tokens[userID] = token
+	return nil
+}
+
+func (r *memoryWatchlistRepo) GetCalendarTokenUser(token string) (models.ID, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for userID, t := range r.tokens {
+		if t == token {
+			return userID, nil
+		}
+	}
+	return models.NilID, errs.NotFound
+}
+
+// find returns the entry that puts movieID on the watchlist of userID, or
+// nil.
+func (r *memoryWatchlistRepo) find(userID, movieID models.ID) *models.WatchlistEntry {
+	for _, entry := range r.entries {
+		if entry.UserID == userID && entry.MovieID == movieID {
+			return entry
+		}
+	}
+	return nil
+}
+
+func cloneWatchlistEntry(entry *models.WatchlistEntry) *models.WatchlistEntry {
+	clone := *entry
+	clone.Audit = cloneAudit(entry.Audit)
+	return &clone
+}
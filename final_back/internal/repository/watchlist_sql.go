@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+type sqlWatchlistRepo struct {
+	sqlConn
+}
+
+// NewSQLWatchlistRepo returns a WatchlistRepo backed by the
+// watchlist_entries and calendar_tokens tables of a Postgres or SQLite
+// database opened with db.OpenSQL.
+func NewSQLWatchlistRepo(db *sql.DB) WatchlistRepo {
+	return &sqlWatchlistRepo{
+		sqlConn: sqlConn{db: db},
+	}
+}
+
+const selectWatchlistEntry = `SELECT id, user_id, movie_id, ` + auditColumns + ` FROM watchlist_entries`
+
+func (r *sqlWatchlistRepo) ListWatchlistEntries(opts models.ListOptions) (models.Page[*models.WatchlistEntry], error) {
+	query, args, err := sqlList(selectWatchlistEntry+` WHERE TRUE`, nil, opts, models.WatchlistListFields)
+	if err != nil {
+		return models.Page[*models.WatchlistEntry]{}, err
+	}
+	rows, err := r.q().Query(query, args...)
+	if err != nil {
+		return models.Page[*models.WatchlistEntry]{}, err
+	}
+	defer rows.Close()
+
+	entries := []*models.WatchlistEntry{}
+	for rows.Next() {
+		var (
+			entry models.WatchlistEntry
+			audit auditScan
+		)
+		dest := append([]any{&entry.ID, &entry.UserID, &entry.MovieID}, audit.dest()...)
+		if err := rows.Scan(dest...); err != nil {
+			return models.Page[*models.WatchlistEntry]{}, err
+		}
+		entry.Audit = audit.audit()
+		entries = append(entries, &entry)
+	}
+	if err := rows.Err(); err != nil {
+		return models.Page[*models.WatchlistEntry]{}, err
+	}
+	return page(entries, opts), nil
+}
+
+func (r *sqlWatchlistRepo) AddToWatchlist(userID, movieID models.ID) (models.ID, error) {
+	entry := newWatchlistEntry(userID, movieID)
+
+	args := append([]any{entry.ID, entry.UserID, entry.MovieID}, auditArgs(entry.Audit)...)
+	_, err := r.q().Exec(`INSERT INTO watchlist_entries (id, user_id, movie_id, `+auditColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		args...)
+	if err != nil {
+		return models.NilID, sqlErr(err)
+	}
+	return entry.ID, nil
+}
+
+func (r *sqlWatchlistRepo) RemoveFromWatchlist(userID, movieID models.ID) error {
+	res, err := r.q().Exec(`DELETE FROM watchlist_entries WHERE user_id = $1 AND movie_id = $2`, userID, movieID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return errs.NotFound
+	}
+	return nil
+}
+
+func (r *sqlWatchlistRepo) DeleteWatchlistEntriesByMovieID(movieID models.ID) error {
+	_, err := r.q().Exec(`DELETE FROM watchlist_entries WHERE movie_id = $1`, movieID)
+	return err
+}
+
+func (r *sqlWatchlistRepo) DeleteWatchlist(userID models.ID) error {
+	return r.inTx(func(tx querier) error {
+		if _, err := tx.Exec(`DELETE FROM watchlist_entries WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`DELETE FROM calendar_tokens WHERE user_id = $1`, userID)
+		return err
+	})
+}
+
+func (r *sqlWatchlistRepo) MoveWatchlistEntries(actorID, from, to models.ID) error {
+	audit := touch(models.Audit{}, actorID)
+	return r.inTx(func(tx querier) error {
+		_, err := tx.Exec(`DELETE FROM watchlist_entries WHERE movie_id = $1 AND user_id IN `+
+			`(SELECT user_id FROM watchlist_entries WHERE movie_id = $2)`, from, to)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE watchlist_entries SET movie_id = $2, updated_at = $3, updated_by = $4 WHERE movie_id = $1`,
+			from, to, sqlTime(audit.UpdatedAt), sqlID(audit.UpdatedBy))
+		return sqlErr(err)
+	})
+}
+
+func (r *sqlWatchlistRepo) GetCalendarToken(userID models.ID) (string, error) {
+	var token string
+	if err := r.q().QueryRow(`SELECT token FROM calendar_tokens WHERE user_id = $1`, userID).Scan(&token); err != nil {
+		return "", sqlErr(err)
+	}
+	return token, nil
+}
+
+func (r *sqlWatchlistRepo) SetCalendarToken(userID models.ID, token string) error {
+	_, err := r.q().Exec(`INSERT INTO calendar_tokens (user_id, token) VALUES ($1, $2) `+
+		`ON CONFLICT (user_id) DO UPDATE SET token = excluded.token`, userID, token)
+	return sqlErr(err)
+}
+
+func (r *sqlWatchlistRepo) GetCalendarTokenUser(token string) (models.ID, error) {
+	var userID models.ID
+	if err := r.q().QueryRow(`SELECT user_id FROM calendar_tokens WHERE token = $1`, token).Scan(&userID); err != nil {
+		return models.NilID, sqlErr(err)
+	}
+	return userID, nil
+}
@@ -29,7 +29,15 @@ func HasPermission(userWR *models.User, resource models.ResourceType, action mod
 				return true
 			}
 		case UserCheck:
-			if resource, ok := data.(models.User); ok && p(userWR, resource) {
+			if target, ok := asUser(data); ok && p(userWR, target) {
+				return true
+			}
+		case ReviewCheck:
+			if target, ok := asReview(data); ok && p(userWR, target) {
+				return true
+			}
+		case SuggestionCheck:
+			if target, ok := asSuggestion(data); ok && p(userWR, target) {
 				return true
 			}
 		}
@@ -38,6 +46,46 @@ func HasPermission(userWR *models.User, resource models.ResourceType, action mod
 	return false
 }
 
+// asUser accepts the target of a UserCheck by value or by pointer.
+func asUser(data interface{}) (models.User, bool) {
+	switch target := data.(type) {
+	case models.User:
+		return target, true
+	case *models.User:
+		if target != nil {
+			return *target, true
+		}
+	}
+	return models.User{}, false
+}
+
+// asReview accepts the target of a ReviewCheck by value or by pointer.
+func asReview(data interface{}) (models.Review, bool) {
+	switch target := data.(type) {
+	case models.Review:
+		return target, true
+	case *models.Review:
+		if target != nil {
+			return *target, true
+		}
+	}
+	return models.Review{}, false
+}
+
+// asSuggestion accepts the target of a SuggestionCheck by value or by
+// pointer.
+func asSuggestion(data interface{}) (models.Suggestion, bool) {
+	switch target := data.(type) {
+	case models.Suggestion:
+		return target, true
+	case *models.Suggestion:
+		if target != nil {
+			return *target, true
+		}
+	}
+	return models.Suggestion{}, false
+}
+
 const (
 	RoleAdmin     models.Role = "admin"
 	RoleModerator models.Role = "moderator"
@@ -48,19 +96,33 @@ const (
 	ResourceUser   models.ResourceType = "user"
 	ResourceMovie  models.ResourceType = "movie"
 	ResourceReview models.ResourceType = "review"
+	ResourcePerson models.ResourceType = "person"
+	ResourceGenre  models.ResourceType = "genre"
+
+	ResourceSuggestion     models.ResourceType = "suggestion"
+	ResourceTranslation    models.ResourceType = "translation"
+	ResourceReviewCategory models.ResourceType = "reviewCategory" // deleting one archives it
+	ResourceTag            models.ResourceType = "tag"            // moderating approves tags users suggest
 )
 
 const (
-	ActionView   models.ActionType = "view"
-	ActionCreate models.ActionType = "create"
-	ActionUpdate models.ActionType = "update"
-	ActionDelete models.ActionType = "delete"
+	ActionView    models.ActionType = "view"
+	ActionCreate  models.ActionType = "create"
+	ActionUpdate  models.ActionType = "update"
+	ActionDelete  models.ActionType = "delete"
+	ActionRestore models.ActionType = "restore" // browse the trash and restore from it
+	ActionImport  models.ActionType = "import"  // bulk create and update
+	ActionRevert  models.ActionType = "revert"  // go back to an earlier revision
+	ActionMerge   models.ActionType = "merge"   // fold a duplicate into another record
+
+	ActionModerate models.ActionType = "moderate" // approve or reject
 )
 
 // BooleanCheck is a simple boolean permission check
 type BooleanCheck bool
 type UserCheck func(user *models.User, target models.User) bool
 type ReviewCheck func(user *models.User, target models.Review) bool
+type SuggestionCheck func(user *models.User, target models.Suggestion) bool
 
 // ROLES defines the permission matrix for all roles
 var ROLES = initRoles()
@@ -69,40 +131,114 @@ func initRoles() models.RolePermissions {
 	return models.RolePermissions{
 		RoleAdmin: {
 			ResourceUser: {
+				ActionCreate:  BooleanCheck(true),
+				ActionView:    BooleanCheck(true),
+				ActionUpdate:  BooleanCheck(true),
+				ActionDelete:  BooleanCheck(true),
+				ActionRestore: BooleanCheck(true),
+			},
+			ResourceMovie: {
+				ActionCreate:  BooleanCheck(true),
+				ActionView:    BooleanCheck(true),
+				ActionUpdate:  BooleanCheck(true),
+				ActionDelete:  BooleanCheck(true),
+				ActionRestore: BooleanCheck(true),
+				ActionImport:  BooleanCheck(true),
+				ActionRevert:  BooleanCheck(true),
+				ActionMerge:   BooleanCheck(true),
+			},
+			ResourceReview: {
+				ActionCreate:  BooleanCheck(true),
+				ActionView:    BooleanCheck(true),
+				ActionUpdate:  BooleanCheck(true),
+				ActionDelete:  BooleanCheck(true),
+				ActionRestore: BooleanCheck(true),
+			},
+			ResourcePerson: {
 				ActionCreate: BooleanCheck(true),
 				ActionView:   BooleanCheck(true),
 				ActionUpdate: BooleanCheck(true),
 				ActionDelete: BooleanCheck(true),
 			},
-			ResourceMovie: {
+			ResourceGenre: {
 				ActionCreate: BooleanCheck(true),
 				ActionView:   BooleanCheck(true),
 				ActionUpdate: BooleanCheck(true),
 				ActionDelete: BooleanCheck(true),
 			},
-			ResourceReview: {
+			ResourceSuggestion: {
+				ActionCreate:   BooleanCheck(true),
+				ActionView:     BooleanCheck(true),
+				ActionModerate: BooleanCheck(true),
+			},
+			ResourceTranslation: {
+				ActionUpdate: BooleanCheck(true),
+				ActionDelete: BooleanCheck(true),
+			},
+			ResourceReviewCategory: {
 				ActionCreate: BooleanCheck(true),
 				ActionView:   BooleanCheck(true),
 				ActionUpdate: BooleanCheck(true),
 				ActionDelete: BooleanCheck(true),
 			},
+			ResourceTag: {
+				ActionCreate:   BooleanCheck(true),
+				ActionView:     BooleanCheck(true),
+				ActionUpdate:   BooleanCheck(true),
+				ActionDelete:   BooleanCheck(true),
+				ActionModerate: BooleanCheck(true),
+			},
 		},
 		RoleModerator: {
 			ResourceUser: {
+				ActionView:    BooleanCheck(true),
+				ActionUpdate:  BooleanCheck(true),
+				ActionDelete:  BooleanCheck(true),
+				ActionRestore: BooleanCheck(true),
+			},
+			ResourceMovie: {
+				ActionCreate:  BooleanCheck(true),
+				ActionView:    BooleanCheck(true),
+				ActionUpdate:  BooleanCheck(true),
+				ActionDelete:  BooleanCheck(true),
+				ActionRestore: BooleanCheck(true),
+				ActionRevert:  BooleanCheck(true),
+			},
+			ResourceReview: {
+				ActionView:    BooleanCheck(true),
+				ActionDelete:  BooleanCheck(true),
+				ActionRestore: BooleanCheck(true),
+			},
+			ResourcePerson: {
+				ActionCreate: BooleanCheck(true),
 				ActionView:   BooleanCheck(true),
 				ActionUpdate: BooleanCheck(true),
 				ActionDelete: BooleanCheck(true),
 			},
-			ResourceMovie: {
+			ResourceGenre: {
 				ActionCreate: BooleanCheck(true),
 				ActionView:   BooleanCheck(true),
 				ActionUpdate: BooleanCheck(true),
 				ActionDelete: BooleanCheck(true),
 			},
-			ResourceReview: {
+			ResourceSuggestion: {
+				ActionCreate:   BooleanCheck(true),
+				ActionView:     BooleanCheck(true),
+				ActionModerate: BooleanCheck(true),
+			},
+			ResourceReviewCategory: {
+				ActionCreate: BooleanCheck(true),
 				ActionView:   BooleanCheck(true),
+				ActionUpdate: BooleanCheck(true),
 				ActionDelete: BooleanCheck(true),
 			},
+			ResourceTag: {
+				ActionCreate:   BooleanCheck(true),
+				ActionView:     BooleanCheck(true),
+				ActionUpdate:   BooleanCheck(true),
+				ActionDelete:   BooleanCheck(true),
+				ActionModerate: BooleanCheck(true),
+			},
 		},
 		RoleUser: {
 			ResourceUser: {
@@ -118,16 +254,31 @@ func initRoles() models.RolePermissions {
 			ResourceMovie: {
 				ActionView: BooleanCheck(true),
 			},
+			ResourcePerson: {
+				ActionView: BooleanCheck(true),
+			},
+			ResourceGenre: {
+				ActionView: BooleanCheck(true),
+			},
+			ResourceTag: {
+				ActionView: BooleanCheck(true),
+			},
 			ResourceReview: {
 				ActionCreate: BooleanCheck(true),
 				ActionView: ReviewCheck(func(user *models.User, target models.Review) bool {
-					return !target.IsPrivate || target.OwnerID == *user.ID
+					return !target.IsPrivate || target.OwnerID == user.ID
 				}),
 				ActionUpdate: ReviewCheck(func(user *models.User, target models.Review) bool {
-					return target.OwnerID == *user.ID
+					return target.OwnerID == user.ID
 				}),
 				ActionDelete: ReviewCheck(func(user *models.User, target models.Review) bool {
-					return target.OwnerID == *user.ID
+					return target.OwnerID == user.ID
+				}),
+			},
+			ResourceSuggestion: {
+				ActionCreate: BooleanCheck(true),
+				ActionView: SuggestionCheck(func(user *models.User, target models.Suggestion) bool {
+					return target.CreatedBy != nil && *target.CreatedBy == user.ID
 				}),
 			},
 		},
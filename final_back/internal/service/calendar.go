@@ -0,0 +1,312 @@
+package service
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"go.uber.org/zap"
+)
+
+// calendarLookback is how many days back calendars reach, so that a release
+// stays in subscribed calendars for a while after it has come out instead
+// of vanishing the next day.
+const calendarLookback = 30
+
+// calendarPageSize is how many releases or watchlist entries a calendar
+// reads at a time.
+const calendarPageSize = 500
+
+// calendarDomain makes the UIDs of calendar events unique beyond this
+// service, as RFC 5545 asks.
+const calendarDomain = "ios_final_back"
+
+// CalendarService writes movie releases as iCalendar (RFC 5545) feeds that
+// calendar apps can subscribe to. Each release is an all-day event whose
+// UID stays the same for as long as the release exists, and whose SEQUENCE
+// goes up whenever the release changes, so that apps update the event they
+// already have instead of adding another.
+type CalendarService interface {
+	// ReleaseCalendar writes the releases in country, or every country if
+	// it is empty, of releaseType, or of every type if it is empty. Movie
+	// titles are translated into the first of locales they have a
+	// translation in.
+	ReleaseCalendar(w io.Writer, country string, releaseType models.ReleaseType, locales []string) error
+	// WatchlistCalendar writes the releases of the movies on the watchlist
+	// of the user token belongs to, like ReleaseCalendar. It returns
+	// errs.NotFound if token is no user's calendar token.
+	WatchlistCalendar(w io.Writer, token string, country string, releaseType models.ReleaseType, locales []string) error
+}
+
+type calendarSvc struct {
+	log            *zap.Logger
+	releaseRepo    repository.ReleaseRepo
+	movieRepo      repository.MovieRepo
+	watchlistRepo  repository.WatchlistRepo
+	userRepo       repository.UserRepo
+	translationSvc TranslationService
+}
+
+func NewCalendarService(log *zap.Logger, releaseRepo repository.ReleaseRepo, movieRepo repository.MovieRepo, watchlistRepo repository.WatchlistRepo, userRepo repository.UserRepo, translationSvc TranslationService) CalendarService {
+	return &calendarSvc{
+		log:            log,
+		releaseRepo:    releaseRepo,
+		movieRepo:      movieRepo,
+		watchlistRepo:  watchlistRepo,
+		userRepo:       userRepo,
+		translationSvc: translationSvc,
+	}
+}
+
+// ReleaseCalendar reads everything it writes before writing any of it, so
+// that w is left untouched when reading fails.
+func (s *calendarSvc) ReleaseCalendar(w io.Writer, country string, releaseType models.ReleaseType, locales []string) error {
+	country, err := parseCalendarCountry(country)
+	if err != nil {
+		return err
+	}
+	releases, err := s.releases(calendarFilters(country, releaseType))
+	if err != nil {
+		return err
+	}
+
+	name := "Movie releases"
+	if country != "" {
+		name += " in " + country
+	}
+	return s.write(w, name, releases, locales)
+}
+
+func (s *calendarSvc) WatchlistCalendar(w io.Writer, token string, country string, releaseType models.ReleaseType, locales []string) error {
+	country, err := parseCalendarCountry(country)
+	if err != nil {
+		return err
+	}
+	filters := calendarFilters(country, releaseType)
+	userID, err := s.watchlistRepo.GetCalendarTokenUser(token)
+	if err != nil {
+		return err
+	}
+	// Tokens of users in the trash stop working until they are restored.
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	movieIDs := []any{}
+	opts := models.ListOptions{
+		Filters: []models.Filter{{Field: "userId", Op: models.OpEq, Value: userID}},
+		Limit:   calendarPageSize,
+	}
+	for {
+		entries, err := s.watchlistRepo.ListWatchlistEntries(opts)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries.Items {
+			movieIDs = append(movieIDs, entry.MovieID)
+		}
+		if entries.NextCursor == "" {
+			break
+		}
+		opts.Cursor = entries.NextCursor
+	}
+
+	// The releases of every watchlisted movie are read at once.
+	releases, err := s.releases(append(filters, models.Filter{Field: "movieId", Op: models.OpIn, Value: movieIDs}))
+	if err != nil {
+		return err
+	}
+	return s.write(w, user.Username+"'s watchlist", releases, locales)
+}
+
+// parseCalendarCountry is ParseCountry for a country that may be left
+// empty.
+func parseCalendarCountry(country string) (string, error) {
+	if country == "" {
+		return "", nil
+	}
+	return models.ParseCountry(country)
+}
+
+// calendarFilters returns the release filters of a calendar: releases from
+// calendarLookback days ago on, in country and of releaseType if they are
+// set.
+func calendarFilters(country string, releaseType models.ReleaseType) []models.Filter {
+	from := time.Now().UTC().AddDate(0, 0, -calendarLookback).Format(models.ReleaseDateLayout)
+	filters := []models.Filter{{Field: "date", Op: models.OpGte, Value: from}}
+	if country != "" {
+		filters = append(filters, models.Filter{Field: "country", Op: models.OpEq, Value: country})
+	}
+	if releaseType != "" {
+		filters = append(filters, models.Filter{Field: "type", Op: models.OpEq, Value: string(releaseType)})
+	}
+	return filters
+}
+
+// releases returns every release that passes filters.
+func (s *calendarSvc) releases(filters []models.Filter) ([]*models.Release, error) {
+	var releases []*models.Release
+	opts := models.ListOptions{Filters: filters, Sort: "date", Limit: calendarPageSize}
+	for {
+		page, err := s.releaseRepo.ListReleases(opts)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, page.Items...)
+		if page.NextCursor == "" {
+			return releases, nil
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+// write fills in the movies of releases, leaving out those in the trash,
+// and writes them as a calendar called name, soonest first.
+func (s *calendarSvc) write(w io.Writer, name string, releases []*models.Release, locales []string) error {
+	ids := []models.ID{}
+	for _, release := range releases {
+		if !slices.Contains(ids, release.MovieID) {
+			ids = append(ids, release.MovieID)
+		}
+	}
+	byID := make(map[models.ID]*models.Movie, len(ids))
+	for chunk := range slices.Chunk(ids, calendarPageSize) {
+		movies, err := s.movieRepo.ListMovies(models.ListOptions{IDs: chunk, Limit: calendarPageSize})
+		if err != nil {
+			return err
+		}
+		if err := s.translationSvc.LocalizeMovies(movies.Items, locales); err != nil {
+			return err
+		}
+		for _, movie := range movies.Items {
+			byID[movie.ID] = movie
+		}
+	}
+
+	events := []*models.Release{}
+	for _, release := range releases {
+		if release.Movie = byID[release.MovieID]; release.Movie != nil {
+			events = append(events, release)
+		}
+	}
+	slices.SortFunc(events, func(a, b *models.Release) int {
+		if o := cmp.Compare(a.Date, b.Date); o != 0 {
+			return o
+		}
+		if o := cmp.Compare(a.Country, b.Country); o != 0 {
+			return o
+		}
+		return a.ID.Compare(b.ID)
+	})
+
+	ics := &icsWriter{w: w}
+	ics.line("BEGIN", "VCALENDAR")
+	ics.line("VERSION", "2.0")
+	ics.line("PRODID", "-//"+calendarDomain+"//Releases//EN")
+	ics.line("CALSCALE", "GREGORIAN")
+	ics.line("METHOD", "PUBLISH")
+	ics.line("NAME", icsText(name))
+	ics.line("X-WR-CALNAME", icsText(name))
+	ics.line("REFRESH-INTERVAL;VALUE=DURATION", "PT12H")
+	ics.line("X-PUBLISHED-TTL", "PT12H")
+	for _, release := range events {
+		if err := ics.event(release); err != nil {
+			return err
+		}
+	}
+	ics.line("END", "VCALENDAR")
+	return ics.err
+}
+
+// icsWriter writes iCalendar content lines. Once a write fails it writes
+// nothing more and keeps the error.
+type icsWriter struct {
+	w   io.Writer
+	err error
+}
+
+// event writes a release as an all-day event. Dates carry no time zone, so
+// the event falls on the release date wherever the calendar is viewed,
+// while timestamps are in UTC.
+func (w *icsWriter) event(release *models.Release) error {
+	date, err := time.Parse(models.ReleaseDateLayout, release.Date)
+	if err != nil {
+		return fmt.Errorf("release %s: %w", release.ID, err)
+	}
+	stamp := time.Now()
+	if release.UpdatedAt != nil {
+		stamp = release.UpdatedAt.Time()
+	}
+
+	summary := fmt.Sprintf("%s (%s, %s)", release.Movie.Title, release.Country, release.Type)
+	var description []string
+	if release.Certification != "" {
+		description = append(description, "Rated "+release.Certification)
+	}
+	if release.Movie.Runtime > 0 {
+		description = append(description, strconv.Itoa(release.Movie.Runtime)+" min")
+	}
+	if release.Movie.Synopsis != "" {
+		description = append(description, release.Movie.Synopsis)
+	}
+
+	w.line("BEGIN", "VEVENT")
+	w.line("UID", "release-"+release.ID.String()+"@"+calendarDomain)
+	w.line("DTSTAMP", icsTime(stamp))
+	w.line("LAST-MODIFIED", icsTime(stamp))
+	w.line("SEQUENCE", strconv.FormatInt(max(release.Version-1, 0), 10))
+	w.line("DTSTART;VALUE=DATE", date.Format("20060102"))
+	w.line("DTEND;VALUE=DATE", date.AddDate(0, 0, 1).Format("20060102"))
+	w.line("SUMMARY", icsText(summary))
+	if len(description) > 0 {
+		w.line("DESCRIPTION", icsText(strings.Join(description, "\n")))
+	}
+	w.line("CATEGORIES", icsText(strings.ToUpper(string(release.Type))))
+	w.line("TRANSP", "TRANSPARENT")
+	w.line("END", "VEVENT")
+	return w.err
+}
+
+// line writes a content line, folded so that no line is longer than 75
+// octets and no UTF-8 sequence is split, and ended with CRLF.
+func (w *icsWriter) line(name, value string) {
+	if w.err != nil {
+		return
+	}
+	line := name + ":" + value
+
+	var b strings.Builder
+	width := 0
+	for len(line) > 0 {
+		_, size := utf8.DecodeRuneInString(line)
+		if width+size > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteString(line[:size])
+		width += size
+		line = line[size:]
+	}
+	b.WriteString("\r\n")
+	_, w.err = io.WriteString(w.w, b.String())
+}
+
+// icsTextEscaper escapes the characters RFC 5545 gives a meaning in TEXT
+// values.
+var icsTextEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+func icsText(s string) string {
+	return icsTextEscaper.Replace(s)
+}
+
+func icsTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCalendars(t *testing.T) {
+	users := repository.NewMemoryUserRepo()
+	movies := repository.NewMemoryMovieRepo()
+	reviews := repository.NewMemoryReviewRepo()
+	releases := repository.NewMemoryReleaseRepo()
+	watchlists := repository.NewMemoryWatchlistRepo()
+	translationSvc := NewTranslationService(zap.NewNop(), repository.NewMemoryTranslationRepo(), movies, reviews, users)
+	releaseSvc := NewReleaseService(zap.NewNop(), releases, movies, users)
+	watchlistSvc := NewWatchlistService(zap.NewNop(), watchlists, movies, users)
+	listed := &countingReleaseRepo{ReleaseRepo: releases}
+	calendarSvc := NewCalendarService(zap.NewNop(), listed, movies, watchlists, users, translationSvc)
+
+	adminID, err := users.CreateUser(models.NilID, &models.User{Username: "admin", Roles: []models.Role{RoleAdmin}})
+	require.NoError(t, err)
+	userID, err := users.CreateUser(models.NilID, &models.User{Username: "neo", Roles: []models.Role{RoleUser}})
+	require.NoError(t, err)
+	matrix, err := movies.CreateMovie(adminID, &models.CreateMovieRequest{
+		Title:    "The Matrix",
+		Year:     1999,
+		Runtime:  136,
+		Synopsis: "A hacker learns that the world, as he knows it, is a simulation; he joins the resistance against the machines that built it.",
+	})
+	require.NoError(t, err)
+	dune, err := movies.CreateMovie(adminID, &models.CreateMovieRequest{Title: "Dune: Part Three", Year: 2026})
+	require.NoError(t, err)
+	trashed, err := movies.CreateMovie(adminID, &models.CreateMovieRequest{Title: "Unreleased", Year: 2026})
+	require.NoError(t, err)
+	_, err = translationSvc.PutTranslation(adminID, models.TranslationMovie, matrix, "ru", map[string]string{"title": "Матрица"})
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	old := now.AddDate(0, 0, -calendarLookback-1).Format(models.ReleaseDateLayout)
+	soon := now.AddDate(0, 0, 7)
+	later := now.AddDate(0, 1, 0).Format(models.ReleaseDateLayout)
+
+	create := func(movieID models.ID, country, date string, releaseType models.ReleaseType, certification string) models.ID {
+		t.Helper()
+		id, err := releaseSvc.CreateRelease(adminID, movieID, &models.CreateReleaseRequest{Country: country, Date: date, Type: releaseType, Certification: certification})
+		require.NoError(t, err)
+		return id
+	}
+	matrixUS := create(matrix, "US", soon.Format(models.ReleaseDateLayout), models.ReleaseTheatrical, "R")
+	create(matrix, "KZ", later, models.ReleaseStreaming, "")
+	create(matrix, "GB", old, models.ReleaseTheatrical, "")
+	create(dune, "US", later, models.ReleaseTheatrical, "")
+	create(trashed, "US", later, models.ReleaseTheatrical, "")
+	require.NoError(t, movies.DeleteMovie(adminID, trashed))
+
+	calendar := func(write func(*bytes.Buffer) error) string {
+		t.Helper()
+		var buf bytes.Buffer
+		require.NoError(t, write(&buf))
+		return buf.String()
+	}
+
+	t.Run("Format", func(t *testing.T) {
+		ics := calendar(func(buf *bytes.Buffer) error { return calendarSvc.ReleaseCalendar(buf, "", "", nil) })
+		assert.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+		assert.True(t, strings.HasSuffix(ics, "END:VCALENDAR\r\n"))
+		assert.Equal(t, 3, strings.Count(ics, "BEGIN:VEVENT"), "old releases and trashed movies are left out")
+		assert.NotContains(t, ics, "Unreleased")
+
+		lines := strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n")
+		for _, line := range lines {
+			assert.LessOrEqual(t, len(line), 75)
+			assert.NotContains(t, line, "\n")
+		}
+
+		// Unfold the lines to read the properties back.
+		unfolded := strings.ReplaceAll(ics, "\r\n ", "")
+		assert.Contains(t, unfolded, "UID:release-"+matrixUS.String()+"@"+calendarDomain+"\r\n")
+		assert.Contains(t, unfolded, "SEQUENCE:0\r\n")
+		assert.Contains(t, unfolded, "DTSTART;VALUE=DATE:"+soon.Format("20060102")+"\r\n")
+		assert.Contains(t, unfolded, "DTEND;VALUE=DATE:"+soon.AddDate(0, 0, 1).Format("20060102")+"\r\n")
+		assert.Contains(t, unfolded, "SUMMARY:The Matrix (US\\, theatrical)\r\n")
+		assert.Contains(t, unfolded, "SUMMARY:Dune: Part Three (US\\, theatrical)\r\n")
+		assert.Contains(t, unfolded, `DESCRIPTION:Rated R\n136 min\nA hacker learns that the world\, as he knows it\, is a simulation\; he joins`)
+		assert.Less(t, strings.Index(unfolded, "(US\\, theatrical)"), strings.Index(unfolded, "(KZ\\, streaming)"), "events are in date order")
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		certification := "PG-13"
+		_, err := releaseSvc.UpdateRelease(adminID, matrix, matrixUS, 1, &models.UpdateReleaseRequest{Certification: &certification})
+		require.NoError(t, err)
+
+		ics := calendar(func(buf *bytes.Buffer) error {
+			return calendarSvc.ReleaseCalendar(buf, "us", models.ReleaseTheatrical, nil)
+		})
+		assert.Equal(t, 2, strings.Count(ics, "BEGIN:VEVENT"))
+		assert.Contains(t, ics, "UID:release-"+matrixUS.String()+"@"+calendarDomain+"\r\n", "the event keeps its UID")
+		assert.Contains(t, ics, "SEQUENCE:1\r\n")
+		assert.Contains(t, ics, "Rated PG-13")
+		assert.Contains(t, ics, "X-WR-CALNAME:Movie releases in US\r\n")
+	})
+
+	t.Run("Localized", func(t *testing.T) {
+		ics := calendar(func(buf *bytes.Buffer) error { return calendarSvc.ReleaseCalendar(buf, "KZ", "", []string{"ru-KZ"}) })
+		assert.Contains(t, ics, "SUMMARY:Матрица (KZ\\, streaming)\r\n")
+
+		var buf bytes.Buffer
+		assert.ErrorIs(t, calendarSvc.ReleaseCalendar(&buf, "Kazakhstan", "", nil), models.ErrInvalidCountry)
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("Watchlist", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.ErrorIs(t, calendarSvc.WatchlistCalendar(&buf, "unknown", "", "", nil), errs.NotFound)
+
+		token, err := watchlistSvc.CalendarToken(userID)
+		require.NoError(t, err)
+		ics := calendar(func(buf *bytes.Buffer) error { return calendarSvc.WatchlistCalendar(buf, token, "", "", nil) })
+		assert.Equal(t, 0, strings.Count(ics, "BEGIN:VEVENT"))
+		assert.Contains(t, ics, "X-WR-CALNAME:neo's watchlist\r\n")
+
+		_, err = watchlistSvc.AddToWatchlist(userID, dune)
+		require.NoError(t, err)
+		ics = calendar(func(buf *bytes.Buffer) error { return calendarSvc.WatchlistCalendar(buf, token, "", "", nil) })
+		assert.Equal(t, 1, strings.Count(ics, "BEGIN:VEVENT"))
+		assert.Contains(t, ics, "Dune: Part Three")
+
+		_, err = watchlistSvc.AddToWatchlist(userID, matrix)
+		require.NoError(t, err)
+		listed.lists = 0
+		ics = calendar(func(buf *bytes.Buffer) error { return calendarSvc.WatchlistCalendar(buf, token, "", "", nil) })
+		assert.Equal(t, 3, strings.Count(ics, "BEGIN:VEVENT"))
+		assert.Equal(t, 1, listed.lists, "the releases of the whole watchlist are read at once")
+
+		reset, err := watchlistSvc.ResetCalendarToken(userID)
+		require.NoError(t, err)
+		assert.ErrorIs(t, calendarSvc.WatchlistCalendar(&buf, token, "", "", nil), errs.NotFound, "the old address stops working")
+
+		require.NoError(t, users.DeleteUser(adminID, userID))
+		assert.ErrorIs(t, calendarSvc.WatchlistCalendar(&buf, reset, "", "", nil), errs.NotFound)
+	})
+}
+
+// countingReleaseRepo counts the ListReleases calls made through it.
+type countingReleaseRepo struct {
+	repository.ReleaseRepo
+	lists int
+}
+
+func (r *countingReleaseRepo) ListReleases(opts models.ListOptions) (models.Page[*models.Release], error) {
+	r.lists++
+	return r.ReleaseRepo.ListReleases(opts)
+}
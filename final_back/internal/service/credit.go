@@ -0,0 +1,183 @@
+package service
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"go.uber.org/zap"
+)
+
+// CreditService manages the cast and crew of movies. Credits are part of a
+// movie, so changing them takes the permission to update the movie.
+type CreditService interface {
+	ListMovieCredits(movieID models.ID) ([]*models.Credit, error)
+	GetCredit(movieID models.ID, id models.ID) (*models.Credit, error)
+	CreateCredit(actorID models.ID, movieID models.ID, req *models.CreateCreditRequest) (models.ID, error)
+	UpdateCredit(actorID models.ID, movieID models.ID, id models.ID, version int64, req *models.UpdateCreditRequest) (*models.Credit, error)
+	DeleteCredit(actorID models.ID, movieID models.ID, id models.ID) error
+	Filmography(personID models.ID) ([]*models.Credit, error)
+}
+
+type creditSvc struct {
+	log        *zap.Logger
+	repo       repository.CreditRepo
+	movieRepo  repository.MovieRepo
+	personRepo repository.PersonRepo
+	userRepo   repository.UserRepo
+}
+
+func NewCreditService(log *zap.Logger, repo repository.CreditRepo, movieRepo repository.MovieRepo, personRepo repository.PersonRepo, userRepo repository.UserRepo) CreditService {
+	return &creditSvc{
+		log:        log,
+		repo:       repo,
+		movieRepo:  movieRepo,
+		personRepo: personRepo,
+		userRepo:   userRepo,
+	}
+}
+
+// ListMovieCredits returns the credits of a live movie in billing order, each
+// with its person filled in.
+func (s *creditSvc) ListMovieCredits(movieID models.ID) ([]*models.Credit, error) {
+	if _, err := s.movieRepo.GetMovie(movieID); err != nil {
+		return nil, err
+	}
+
+	credits, err := s.repo.ListMovieCredits(movieID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []models.ID{}
+	for _, credit := range credits {
+		ids = append(ids, credit.PersonID)
+	}
+	people, err := s.personRepo.GetPeople(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[models.ID]*models.Person, len(people))
+	for _, person := range people {
+		byID[person.ID] = person
+	}
+	for _, credit := range credits {
+		credit.Person = byID[credit.PersonID]
+	}
+	return credits, nil
+}
+
+// GetCredit returns errs.NotFound unless the credit belongs to the movie.
+func (s *creditSvc) GetCredit(movieID models.ID, id models.ID) (*models.Credit, error) {
+	credit, err := s.repo.GetCredit(id)
+	if err != nil {
+		return nil, err
+	}
+	if credit.MovieID != movieID {
+		return nil, errs.NotFound
+	}
+	return credit, nil
+}
+
+// CreateCredit returns errs.InvalidReference if the person does not exist.
+func (s *creditSvc) CreateCredit(actorID models.ID, movieID models.ID, req *models.CreateCreditRequest) (models.ID, error) {
+	if err := s.authorize(actorID); err != nil {
+		return models.NilID, err
+	}
+
+	if _, err := s.movieRepo.GetMovie(movieID); err != nil {
+		return models.NilID, err
+	}
+	if _, err := s.personRepo.GetPerson(*req.PersonID); err == errs.NotFound {
+		return models.NilID, errs.InvalidReference
+	} else if err != nil {
+		return models.NilID, err
+	}
+
+	return s.repo.CreateCredit(actorID, movieID, req)
+}
+
+func (s *creditSvc) UpdateCredit(actorID models.ID, movieID models.ID, id models.ID, version int64, req *models.UpdateCreditRequest) (*models.Credit, error) {
+	if err := s.authorize(actorID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.GetCredit(movieID, id); err != nil {
+		return nil, err
+	}
+
+	return s.repo.UpdateCredit(actorID, id, version, req)
+}
+
+func (s *creditSvc) DeleteCredit(actorID models.ID, movieID models.ID, id models.ID) error {
+	if err := s.authorize(actorID); err != nil {
+		return err
+	}
+
+	if _, err := s.GetCredit(movieID, id); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteCredit(id)
+}
+
+// Filmography returns the credits of a person in live movies, each with its
+// movie filled in, newest movie first.
+func (s *creditSvc) Filmography(personID models.ID) ([]*models.Credit, error) {
+	if _, err := s.personRepo.GetPerson(personID); err != nil {
+		return nil, err
+	}
+
+	credits, err := s.repo.ListPersonCredits(personID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []models.ID{}
+	for _, credit := range credits {
+		ids = append(ids, credit.MovieID)
+	}
+	movies, err := s.movieRepo.ListMovies(models.ListOptions{IDs: ids})
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[models.ID]*models.Movie, len(movies.Items))
+	for _, movie := range movies.Items {
+		byID[movie.ID] = movie
+	}
+
+	filmography := []*models.Credit{}
+	for _, credit := range credits {
+		// Movies in the trash are left out.
+		if credit.Movie = byID[credit.MovieID]; credit.Movie != nil {
+			filmography = append(filmography, credit)
+		}
+	}
+	slices.SortFunc(filmography, func(a, b *models.Credit) int {
+		if o := cmp.Compare(b.Movie.Year, a.Movie.Year); o != 0 {
+			return o
+		}
+		if o := cmp.Compare(a.Movie.Title, b.Movie.Title); o != 0 {
+			return o
+		}
+		if o := cmp.Compare(a.Order, b.Order); o != 0 {
+			return o
+		}
+		return a.ID.Compare(b.ID)
+	})
+	return filmography, nil
+}
+
+func (s *creditSvc) authorize(actorID models.ID) error {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return err
+	}
+
+	if !HasPermission(actor, ResourceMovie, ActionUpdate, nil) {
+		return errs.Forbidden
+	}
+	return nil
+}
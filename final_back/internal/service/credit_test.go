@@ -0,0 +1,95 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCredits(t *testing.T) {
+	users := repository.NewMemoryUserRepo()
+	movies := repository.NewMemoryMovieRepo()
+	people := repository.NewMemoryPersonRepo()
+	credits := repository.NewMemoryCreditRepo()
+	revisions := repository.NewMemoryRevisionRepo()
+	uow := repository.NewMemoryUnitOfWork(repository.Repos{Users: users, Movies: movies, Reviews: repository.NewMemoryReviewRepo(), Credits: credits, Revisions: revisions})
+	movieSvc := NewMovieService(zap.NewNop(), movies, users, people, repository.NewMemoryGenreRepo(), credits, repository.NewMemoryTagRepo(), repository.NewMemoryReleaseRepo(), revisions, repository.NewMemorySearchIndex(), repository.NewMemoryFacetCounter(movies, repository.NewMemoryTagRepo()), uow)
+	personSvc := NewPersonService(zap.NewNop(), people, movies, credits, users)
+	creditSvc := NewCreditService(zap.NewNop(), credits, movies, people, users)
+
+	adminID, err := users.CreateUser(models.NilID, &models.User{Username: "admin", Roles: []models.Role{RoleAdmin}})
+	require.NoError(t, err)
+	userID, err := users.CreateUser(models.NilID, &models.User{Username: "neo", Roles: []models.Role{RoleUser}})
+	require.NoError(t, err)
+
+	keanu, err := people.CreatePerson(adminID, &models.CreatePersonRequest{Name: "Keanu Reeves"})
+	require.NoError(t, err)
+	carrie, err := people.CreatePerson(adminID, &models.CreatePersonRequest{Name: "Carrie-Anne Moss"})
+	require.NoError(t, err)
+	matrix, err := movies.CreateMovie(adminID, &models.CreateMovieRequest{Title: "The Matrix", Year: 1999})
+	require.NoError(t, err)
+	wick, err := movies.CreateMovie(adminID, &models.CreateMovieRequest{Title: "John Wick", Year: 2014})
+	require.NoError(t, err)
+
+	credit := func(movieID, personID models.ID, character string, order int) models.ID {
+		id, err := creditSvc.CreateCredit(adminID, movieID, &models.CreateCreditRequest{PersonID: &personID, Role: models.CreditActor, Character: character, Order: order})
+		require.NoError(t, err)
+		return id
+	}
+	credit(matrix, carrie, "Trinity", 1)
+	neo := credit(matrix, keanu, "Neo", 0)
+	credit(wick, keanu, "John Wick", 0)
+
+	unknown := models.NewID()
+	_, err = creditSvc.CreateCredit(adminID, matrix, &models.CreateCreditRequest{PersonID: &unknown, Role: models.CreditActor})
+	assert.ErrorIs(t, err, errs.InvalidReference)
+	_, err = creditSvc.CreateCredit(adminID, unknown, &models.CreateCreditRequest{PersonID: &keanu, Role: models.CreditActor})
+	assert.ErrorIs(t, err, errs.NotFound)
+	_, err = creditSvc.CreateCredit(userID, matrix, &models.CreateCreditRequest{PersonID: &keanu, Role: models.CreditActor})
+	assert.ErrorIs(t, err, errs.Forbidden)
+
+	cast, err := creditSvc.ListMovieCredits(matrix)
+	require.NoError(t, err)
+	require.Len(t, cast, 2)
+	assert.Equal(t, "Neo", cast[0].Character)
+	assert.Equal(t, "Keanu Reeves", cast[0].Person.Name)
+	assert.Equal(t, "Trinity", cast[1].Character)
+
+	_, err = creditSvc.UpdateCredit(adminID, wick, neo, 1, &models.UpdateCreditRequest{})
+	assert.ErrorIs(t, err, errs.NotFound, "a credit is only reachable through its own movie")
+
+	filmography, err := creditSvc.Filmography(keanu)
+	require.NoError(t, err)
+	require.Len(t, filmography, 2)
+	assert.Equal(t, "John Wick", filmography[0].Movie.Title)
+	assert.Equal(t, "The Matrix", filmography[1].Movie.Title)
+
+	page, err := movieSvc.ListMovies(models.ListOptions{Filters: []models.Filter{{Field: models.FilterCastID, Op: models.OpEq, Value: keanu}}, Sort: "year"})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, matrix, page.Items[0].ID)
+	page, err = movieSvc.ListMovies(models.ListOptions{Filters: []models.Filter{
+		{Field: models.FilterCastID, Op: models.OpEq, Value: keanu},
+		{Field: models.FilterCastID, Op: models.OpEq, Value: carrie},
+	}})
+	require.NoError(t, err)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, matrix, page.Items[0].ID)
+	page, err = movieSvc.ListMovies(models.ListOptions{Filters: []models.Filter{{Field: models.FilterCastID, Op: models.OpEq, Value: unknown}}})
+	require.NoError(t, err)
+	assert.Empty(t, page.Items)
+
+	require.NoError(t, movies.DeleteMovie(adminID, wick))
+	filmography, err = creditSvc.Filmography(keanu)
+	require.NoError(t, err)
+	require.Len(t, filmography, 1, "movies in the trash are left out")
+	assert.ErrorIs(t, personSvc.DeletePerson(adminID, keanu), errs.InUse)
+
+	require.NoError(t, creditSvc.DeleteCredit(adminID, matrix, neo))
+	assert.ErrorIs(t, creditSvc.DeleteCredit(adminID, matrix, neo), errs.NotFound)
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"go.uber.org/zap"
+)
+
+// Weights of the evidence that two movies with the same normalized title
+// are one film. Evidence that is missing on either side counts for half;
+// evidence against them rules the pair out.
+const (
+	duplicateTitleScore    = 0.5
+	duplicateYearScore     = 0.3
+	duplicateDirectorScore = 0.2
+	duplicatePageSize      = 500
+)
+
+func (s *movieSvc) FindDuplicates(actorID models.ID) ([]*models.Duplicate, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !HasPermission(actor, ResourceMovie, ActionMerge, nil) {
+		return nil, errs.Forbidden
+	}
+
+	// Movies come in ID order, so every group lists its oldest movie first.
+	groups := map[string][]*models.Movie{}
+	opts := models.ListOptions{Limit: duplicatePageSize}
+	for {
+		page, err := s.repo.ListMovies(opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, movie := range page.Items {
+			if key := repository.NormalizeTitle(movie.Title, movie.Year); key != "" {
+				groups[key] = append(groups[key], movie)
+			}
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+
+	duplicates := []*models.Duplicate{}
+	for _, movies := range groups {
+		for i, movie := range movies {
+			for _, other := range movies[i+1:] {
+				if score, ok := duplicateScore(movie, other); ok {
+					duplicates = append(duplicates, &models.Duplicate{Movie: movie, Duplicate: other, Score: score})
+				}
+			}
+		}
+	}
+	slices.SortFunc(duplicates, func(a, b *models.Duplicate) int {
+		if c := cmp.Compare(b.Score, a.Score); c != 0 {
+			return c
+		}
+		if c := a.Movie.ID.Compare(b.Movie.ID); c != 0 {
+			return c
+		}
+		return a.Duplicate.ID.Compare(b.Duplicate.ID)
+	})
+	return duplicates, nil
+}
+
+// duplicateScore rates how likely two movies with the same normalized title
+// are to be one film. Years more than one apart, which is as far as
+// release dates tend to disagree, or different directors rule it out.
+func duplicateScore(a, b *models.Movie) (float64, bool) {
+	score := duplicateTitleScore
+
+	switch {
+	case a.Year == 0 || b.Year == 0:
+		score += duplicateYearScore / 2
+	case a.Year == b.Year:
+		score += duplicateYearScore
+	case a.Year-b.Year == 1 || b.Year-a.Year == 1:
+		score += duplicateYearScore / 2
+	default:
+		return 0, false
+	}
+
+	switch {
+	case a.DirectorID == nil || b.DirectorID == nil:
+		score += duplicateDirectorScore / 2
+	case *a.DirectorID == *b.DirectorID:
+		score += duplicateDirectorScore
+	default:
+		return 0, false
+	}
+	return math.Round(score*100) / 100, true
+}
+
+// MergeMovies keeps the credits and tags of the movie id over the same ones
+// of the duplicate. Ratings follow the reviews they come from.
+func (s *movieSvc) MergeMovies(actorID models.ID, id models.ID, duplicateID models.ID) (*models.Movie, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !HasPermission(actor, ResourceMovie, ActionMerge, nil) {
+		return nil, errs.Forbidden
+	}
+	if id == duplicateID {
+		return nil, models.ErrInvalidMerge
+	}
+
+	var merged *models.Movie
+	err = s.movies.uow.Do(func(repos repository.Repos) error {
+		if _, err := repos.Movies.GetMovie(id); err != nil {
+			return err
+		}
+		if _, err := repos.Movies.GetMovie(duplicateID); err == errs.NotFound {
+			return errs.InvalidReference
+		} else if err != nil {
+			return err
+		}
+
+		reviews, err := repos.Reviews.MoveReviews(actorID, duplicateID, id)
+		if err != nil {
+			return err
+		}
+		for _, review := range reviews {
+			rating := models.CountedRating(review)
+			if err := repos.Movies.UpdateRatings(duplicateID, rating, 0); err != nil {
+				return err
+			}
+			if err := repos.Movies.UpdateRatings(id, 0, rating); err != nil {
+				return err
+			}
+		}
+
+		if err := dropSharedCredits(repos.Credits, id, duplicateID); err != nil {
+			return err
+		}
+		if err := repos.Credits.MoveCredits(actorID, duplicateID, id); err != nil {
+			return err
+		}
+		if err := repos.Tags.MoveMovieTags(actorID, duplicateID, id); err != nil {
+			return err
+		}
+		if err := repos.Releases.MoveReleases(actorID, duplicateID, id); err != nil {
+			return err
+		}
+		if err := repos.Watchlists.MoveWatchlistEntries(actorID, duplicateID, id); err != nil {
+			return err
+		}
+
+		if err := deleteMovie(repos, actorID, duplicateID); err != nil {
+			return err
+		}
+		merged, err = repos.Movies.GetMovie(id)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.search.RemoveMovie(duplicateID); err != nil {
+		s.log.Error("failed to remove movie from search index", zap.Error(err))
+	}
+	s.index(merged)
+	return merged, nil
+}
+
+// dropSharedCredits deletes the credits of the duplicate that credit a
+// person with a role they already have on the movie id.
+func dropSharedCredits(credits repository.CreditRepo, id, duplicateID models.ID) error {
+	kept, err := credits.ListMovieCredits(id)
+	if err != nil {
+		return err
+	}
+	type credited struct {
+		person models.ID
+		role   models.CreditRole
+	}
+	shared := map[credited]bool{}
+	for _, credit := range kept {
+		shared[credited{credit.PersonID, credit.Role}] = true
+	}
+
+	dropped, err := credits.ListMovieCredits(duplicateID)
+	if err != nil {
+		return err
+	}
+	for _, credit := range dropped {
+		if shared[credited{credit.PersonID, credit.Role}] {
+			if err := credits.DeleteCredit(credit.ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestDuplicates(t *testing.T) {
+	repos := repository.Repos{
+		Users:      repository.NewMemoryUserRepo(),
+		Movies:     repository.NewMemoryMovieRepo(),
+		Reviews:    repository.NewMemoryReviewRepo(),
+		Credits:    repository.NewMemoryCreditRepo(),
+		Revisions:  repository.NewMemoryRevisionRepo(),
+		Tags:       repository.NewMemoryTagRepo(),
+		Releases:   repository.NewMemoryReleaseRepo(),
+		Watchlists: repository.NewMemoryWatchlistRepo(),
+	}
+	people := repository.NewMemoryPersonRepo()
+	search := repository.NewMemorySearchIndex()
+	uow := repository.NewMemoryUnitOfWork(repos)
+	movieSvc := NewMovieService(zap.NewNop(), repos.Movies, repos.Users, people, repository.NewMemoryGenreRepo(), repos.Credits, repos.Tags, repos.Releases, repos.Revisions, search, repository.NewMemoryFacetCounter(repos.Movies, repos.Tags), uow)
+	reviewSvc := NewReviewService(zap.NewNop(), repos.Reviews, repos.Users, repos.Movies, uow, search)
+
+	adminID, err := repos.Users.CreateUser(models.NilID, &models.User{Username: "admin", Roles: []models.Role{RoleAdmin}})
+	require.NoError(t, err)
+	moderatorID, err := repos.Users.CreateUser(models.NilID, &models.User{Username: "moderator", Roles: []models.Role{RoleModerator}})
+	require.NoError(t, err)
+	userID, err := repos.Users.CreateUser(models.NilID, &models.User{Username: "neo", Roles: []models.Role{RoleUser}})
+	require.NoError(t, err)
+	lana, err := people.CreatePerson(adminID, &models.CreatePersonRequest{Name: "Lana Wachowski"})
+	require.NoError(t, err)
+	other, err := people.CreatePerson(adminID, &models.CreatePersonRequest{Name: "Someone Else"})
+	require.NoError(t, err)
+
+	create := func(title string, year int, directorID *models.ID) models.ID {
+		t.Helper()
+		id, err := movieSvc.CreateMovie(adminID, &models.CreateMovieRequest{Title: title, Year: year, DirectorID: directorID})
+		require.NoError(t, err)
+		return id
+	}
+	matrix := create("The Matrix", 1999, &lana)
+	copied := create("Matrix (1999)", 1999, &lana)
+	create("the matrix", 0, nil)
+	create("The Matrix", 2021, &lana)
+	create("Matrix", 1999, &other)
+	create("Amélie", 2001, nil)
+	create("Amelie", 2002, nil)
+
+	t.Run("Find", func(t *testing.T) {
+		_, err := movieSvc.FindDuplicates(moderatorID)
+		assert.ErrorIs(t, err, errs.Forbidden)
+
+		duplicates, err := movieSvc.FindDuplicates(adminID)
+		require.NoError(t, err)
+		type pair struct {
+			movie, duplicate string
+			score            float64
+		}
+		pairs := []pair{}
+		for _, d := range duplicates {
+			pairs = append(pairs, pair{d.Movie.Title, d.Duplicate.Title, d.Score})
+		}
+		assert.Equal(t, []pair{
+			{"The Matrix", "Matrix (1999)", 1},
+			{"The Matrix", "the matrix", 0.75},
+			{"Matrix (1999)", "the matrix", 0.75},
+			{"the matrix", "The Matrix", 0.75},
+			{"the matrix", "Matrix", 0.75},
+			{"Amélie", "Amelie", 0.75},
+		}, pairs)
+	})
+
+	t.Run("Merge", func(t *testing.T) {
+		_, err := reviewSvc.CreateReview(userID, &models.CreateReviewRequest{MovieID: matrix, Rating: 9})
+		require.NoError(t, err)
+		_, err = reviewSvc.CreateReview(userID, &models.CreateReviewRequest{MovieID: copied, Rating: 7})
+		require.NoError(t, err)
+		_, err = reviewSvc.CreateReview(adminID, &models.CreateReviewRequest{MovieID: copied, Rating: 3, IsPrivate: true})
+		require.NoError(t, err)
+		neo, err := people.CreatePerson(adminID, &models.CreatePersonRequest{Name: "Keanu Reeves"})
+		require.NoError(t, err)
+		_, err = repos.Credits.CreateCredit(adminID, matrix, &models.CreateCreditRequest{PersonID: &lana, Role: models.CreditDirector})
+		require.NoError(t, err)
+		_, err = repos.Credits.CreateCredit(adminID, copied, &models.CreateCreditRequest{PersonID: &lana, Role: models.CreditDirector})
+		require.NoError(t, err)
+		_, err = repos.Credits.CreateCredit(adminID, copied, &models.CreateCreditRequest{PersonID: &neo, Role: models.CreditActor, Character: "Neo", Order: 1})
+		require.NoError(t, err)
+		for _, movieID := range []models.ID{matrix, copied} {
+			_, err = repos.Releases.CreateRelease(adminID, movieID, &models.CreateReleaseRequest{Country: "US", Date: "1999-03-31", Type: models.ReleaseTheatrical})
+			require.NoError(t, err)
+		}
+		_, err = repos.Releases.CreateRelease(adminID, copied, &models.CreateReleaseRequest{Country: "GB", Date: "1999-06-11", Type: models.ReleaseTheatrical})
+		require.NoError(t, err)
+
+		_, err = movieSvc.MergeMovies(moderatorID, matrix, copied)
+		assert.ErrorIs(t, err, errs.Forbidden)
+		_, err = movieSvc.MergeMovies(adminID, matrix, matrix)
+		assert.ErrorIs(t, err, models.ErrInvalidMerge)
+		_, err = movieSvc.MergeMovies(adminID, matrix, models.NewID())
+		assert.ErrorIs(t, err, errs.InvalidReference)
+		_, err = movieSvc.MergeMovies(adminID, models.NewID(), copied)
+		assert.ErrorIs(t, err, errs.NotFound)
+
+		merged, err := movieSvc.MergeMovies(adminID, matrix, copied)
+		require.NoError(t, err)
+		assert.Equal(t, 2, merged.Ratings.Count)
+
+		reviews, err := repos.Reviews.ListReviewsByMovieID(adminID, matrix, models.ListOptions{})
+		require.NoError(t, err)
+		assert.Len(t, reviews.Items, 3)
+		credits, err := repos.Credits.ListMovieCredits(matrix)
+		require.NoError(t, err)
+		require.Len(t, credits, 2, "the director is credited once")
+		assert.Equal(t, neo, credits[1].PersonID)
+		releases, err := repos.Releases.ListReleases(models.ListOptions{Filters: []models.Filter{{Field: "movieId", Op: models.OpEq, Value: matrix}}})
+		require.NoError(t, err)
+		assert.Len(t, releases.Items, 2, "the US release is kept once")
+
+		_, err = movieSvc.GetMovie(copied)
+		assert.ErrorIs(t, err, errs.NotFound)
+		deleted, err := repos.Movies.ListDeletedMovies()
+		require.NoError(t, err)
+		require.Len(t, deleted, 1)
+		assert.Equal(t, copied, deleted[0].ID)
+		assert.Zero(t, deleted[0].Ratings.Count)
+		hits, err := search.SearchMovies("matrix", 10)
+		require.NoError(t, err)
+		for _, hit := range hits {
+			assert.NotEqual(t, copied, hit.ID)
+		}
+
+		duplicates, err := movieSvc.FindDuplicates(adminID)
+		require.NoError(t, err)
+		assert.Len(t, duplicates, 4)
+	})
+}
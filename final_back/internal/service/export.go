@@ -0,0 +1,263 @@
+package service
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// exportPageSize is how many movies an export reads, resolves and writes at a
+// time, so that the catalogue is never held in memory whole.
+const exportPageSize = 500
+
+// flusher is implemented by writers that buffer, like HTTP responses, which
+// an export flushes after every page.
+type flusher interface {
+	Flush()
+}
+
+// movieEncoder writes movies in one export format. flush hands whatever it
+// buffers on to the underlying writer.
+type movieEncoder interface {
+	begin() error
+	encode(movie *models.Movie) error
+	end() error
+	flush() error
+}
+
+// ExportMovies writes every live movie to w in format, in ID order, with
+// their director and genre resolved. Nothing is written if format is not
+// one of the export formats.
+func (s *movieSvc) ExportMovies(format string, w io.Writer) error {
+	var enc movieEncoder
+	switch format {
+	case models.ExportCSV:
+		enc = &csvMovieEncoder{w: csv.NewWriter(w)}
+	case models.ExportNDJSON:
+		enc = &ndjsonMovieEncoder{enc: json.NewEncoder(w)}
+	case models.ExportJSONLD:
+		enc = &jsonLDMovieEncoder{w: w}
+	default:
+		return fmt.Errorf("%w: unknown format %q", models.ErrInvalidExport, format)
+	}
+
+	if err := enc.begin(); err != nil {
+		return err
+	}
+	opts := models.ListOptions{Limit: exportPageSize}
+	for {
+		page, err := s.repo.ListMovies(opts)
+		if err != nil {
+			return err
+		}
+		if err := s.ExpandMovies(page.Items, []string{models.ExpandDirector, models.ExpandGenre}); err != nil {
+			return err
+		}
+		for _, movie := range page.Items {
+			if err := enc.encode(movie); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		if err := flush(enc, w); err != nil {
+			return err
+		}
+		opts.Cursor = page.NextCursor
+	}
+	if err := enc.end(); err != nil {
+		return err
+	}
+	return flush(enc, w)
+}
+
+func flush(enc movieEncoder, w io.Writer) error {
+	if err := enc.flush(); err != nil {
+		return err
+	}
+	if f, ok := w.(flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+type csvMovieEncoder struct {
+	w *csv.Writer
+}
+
+func (e *csvMovieEncoder) begin() error {
+	return e.w.Write(models.ExportColumns)
+}
+
+func (e *csvMovieEncoder) encode(movie *models.Movie) error {
+	var directorID, director, genreID, genre string
+	if movie.DirectorID != nil {
+		directorID = movie.DirectorID.String()
+	}
+	if movie.Director != nil {
+		director = movie.Director.Name
+	}
+	if movie.GenreID != nil {
+		genreID = movie.GenreID.String()
+	}
+	if movie.Genre != nil {
+		genre = movie.Genre.Name
+	}
+	return e.w.Write([]string{
+		movie.ID.String(),
+		movie.ExternalID,
+		movie.Title,
+		strconv.Itoa(movie.Year),
+		directorID,
+		director,
+		genreID,
+		genre,
+		movie.ImageURL,
+		strconv.FormatFloat(movie.Ratings.Score, 'f', -1, 64),
+		strconv.FormatFloat(movie.Ratings.Mean, 'f', -1, 64),
+		strconv.Itoa(movie.Ratings.Count),
+		exportTime(movie.CreatedAt),
+		exportTime(movie.UpdatedAt),
+	})
+}
+
+func (e *csvMovieEncoder) end() error { return nil }
+
+func (e *csvMovieEncoder) flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonMovieEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonMovieEncoder) begin() error { return nil }
+
+func (e *ndjsonMovieEncoder) encode(movie *models.Movie) error {
+	return e.enc.Encode(movie)
+}
+
+func (e *ndjsonMovieEncoder) end() error { return nil }
+
+func (e *ndjsonMovieEncoder) flush() error { return nil }
+
+// jsonLDMovieEncoder writes a JSON-LD document whose graph holds the movies
+// as schema.org Movie nodes. The graph is written one node at a time.
+type jsonLDMovieEncoder struct {
+	w     io.Writer
+	count int
+}
+
+type jsonLDMovie struct {
+	Type            string                `json:"@type"`
+	Identifier      []jsonLDPropertyValue `json:"identifier"`
+	Name            string                `json:"name"`
+	DatePublished   string                `json:"datePublished,omitempty"`
+	Director        *jsonLDThing          `json:"director,omitempty"`
+	Genre           string                `json:"genre,omitempty"`
+	Image           string                `json:"image,omitempty"`
+	AggregateRating *jsonLDRating         `json:"aggregateRating,omitempty"`
+	DateCreated     string                `json:"dateCreated,omitempty"`
+	DateModified    string                `json:"dateModified,omitempty"`
+}
+
+type jsonLDPropertyValue struct {
+	Type       string `json:"@type"`
+	PropertyID string `json:"propertyID"`
+	Value      string `json:"value"`
+}
+
+type jsonLDThing struct {
+	Type       string                `json:"@type"`
+	Identifier []jsonLDPropertyValue `json:"identifier"`
+	Name       string                `json:"name"`
+}
+
+type jsonLDRating struct {
+	Type        string  `json:"@type"`
+	RatingValue float64 `json:"ratingValue"`
+	RatingCount int     `json:"ratingCount"`
+	BestRating  int     `json:"bestRating"`
+	WorstRating int     `json:"worstRating"`
+}
+
+func (e *jsonLDMovieEncoder) begin() error {
+	_, err := io.WriteString(e.w, `{"@context":"https://schema.org","@graph":[`)
+	return err
+}
+
+func (e *jsonLDMovieEncoder) encode(movie *models.Movie) error {
+	node := jsonLDMovie{
+		Type:         "Movie",
+		Identifier:   jsonLDIdentifier(movie.ID, movie.ExternalID),
+		Name:         movie.Title,
+		Image:        movie.ImageURL,
+		DateCreated:  exportTime(movie.CreatedAt),
+		DateModified: exportTime(movie.UpdatedAt),
+	}
+	if movie.Year != 0 {
+		node.DatePublished = fmt.Sprintf("%04d", movie.Year)
+	}
+	if movie.Director != nil {
+		node.Director = &jsonLDThing{Type: "Person", Identifier: jsonLDIdentifier(movie.Director.ID, ""), Name: movie.Director.Name}
+	}
+	if movie.Genre != nil {
+		node.Genre = movie.Genre.Name
+	}
+	if movie.Ratings.Count > 0 {
+		node.AggregateRating = &jsonLDRating{
+			Type:        "AggregateRating",
+			RatingValue: movie.Ratings.Mean,
+			RatingCount: movie.Ratings.Count,
+			BestRating:  models.MaxRating,
+			WorstRating: models.MinRating,
+		}
+	}
+
+	data, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if e.count == 0 {
+		sep = "\n"
+	}
+	e.count++
+	if _, err := io.WriteString(e.w, sep); err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+func (e *jsonLDMovieEncoder) end() error {
+	_, err := io.WriteString(e.w, "\n]}\n")
+	return err
+}
+
+func (e *jsonLDMovieEncoder) flush() error { return nil }
+
+// jsonLDIdentifier lists the ID of a record and, if it has one, the ID it
+// was imported under.
+func jsonLDIdentifier(id models.ID, externalID string) []jsonLDPropertyValue {
+	ids := []jsonLDPropertyValue{{Type: "PropertyValue", PropertyID: "id", Value: id.String()}}
+	if externalID != "" {
+		ids = append(ids, jsonLDPropertyValue{Type: "PropertyValue", PropertyID: "externalId", Value: externalID})
+	}
+	return ids
+}
+
+func exportTime(t *primitive.DateTime) string {
+	if t == nil {
+		return ""
+	}
+	return t.Time().UTC().Format(time.RFC3339)
+}
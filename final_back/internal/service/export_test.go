@@ -0,0 +1,122 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestExportMovies(t *testing.T) {
+	users := repository.NewMemoryUserRepo()
+	movies := repository.NewMemoryMovieRepo()
+	people := repository.NewMemoryPersonRepo()
+	genres := repository.NewMemoryGenreRepo()
+	revisions := repository.NewMemoryRevisionRepo()
+	uow := repository.NewMemoryUnitOfWork(repository.Repos{Users: users, Movies: movies, Revisions: revisions})
+	movieSvc := NewMovieService(zap.NewNop(), movies, users, people, genres, repository.NewMemoryCreditRepo(), repository.NewMemoryTagRepo(), repository.NewMemoryReleaseRepo(), revisions, repository.NewMemorySearchIndex(), repository.NewMemoryFacetCounter(movies, repository.NewMemoryTagRepo()), uow)
+
+	adminID, err := users.CreateUser(models.NilID, &models.User{Username: "admin", Roles: []models.Role{RoleAdmin}})
+	require.NoError(t, err)
+	directorID, err := people.CreatePerson(adminID, &models.CreatePersonRequest{Name: "Lana Wachowski"})
+	require.NoError(t, err)
+	genreID, err := genres.CreateGenre(adminID, &models.CreateGenreRequest{Name: "Science Fiction"})
+	require.NoError(t, err)
+
+	// One more than a page, so that the export has to carry on past it.
+	total := exportPageSize + 1
+	for i := range total {
+		_, err := movies.CreateMovie(adminID, &models.CreateMovieRequest{
+			ExternalID: fmt.Sprintf("tt%07d", i),
+			Title:      fmt.Sprintf("Movie %d", i),
+			Year:       1999,
+			DirectorID: &directorID,
+			GenreID:    &genreID,
+		})
+		require.NoError(t, err)
+	}
+	require.NoError(t, movies.UpdateRatings(mustFirstMovie(t, movies).ID, 0, 8))
+
+	t.Run("CSV", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, movieSvc.ExportMovies(models.ExportCSV, &buf))
+		records, err := csv.NewReader(&buf).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, total+1)
+		assert.Equal(t, models.ExportColumns, records[0])
+
+		row := map[string]string{}
+		for i, name := range records[0] {
+			row[name] = records[1][i]
+		}
+		assert.Equal(t, "tt0000000", row["externalId"])
+		assert.Equal(t, "Lana Wachowski", row["director"])
+		assert.Equal(t, "Science Fiction", row["genre"])
+		assert.Equal(t, "1", row["ratingCount"])
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, movieSvc.ExportMovies(models.ExportNDJSON, &buf))
+		lines := 0
+		scanner := bufio.NewScanner(&buf)
+		for scanner.Scan() {
+			var movie models.Movie
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &movie))
+			require.NotNil(t, movie.Director)
+			assert.Equal(t, "Lana Wachowski", movie.Director.Name)
+			lines++
+		}
+		assert.Equal(t, total, lines)
+	})
+
+	t.Run("JSONLD", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, movieSvc.ExportMovies(models.ExportJSONLD, &buf))
+		var doc struct {
+			Context string           `json:"@context"`
+			Graph   []map[string]any `json:"@graph"`
+		}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+		assert.Equal(t, "https://schema.org", doc.Context)
+		require.Len(t, doc.Graph, total)
+
+		node := doc.Graph[0]
+		assert.Equal(t, "Movie", node["@type"])
+		assert.Equal(t, "Movie 0", node["name"])
+		assert.Equal(t, "1999", node["datePublished"])
+		assert.Equal(t, "Science Fiction", node["genre"])
+		assert.Equal(t, "Lana Wachowski", node["director"].(map[string]any)["name"])
+		assert.Equal(t, float64(8), node["aggregateRating"].(map[string]any)["ratingValue"])
+		assert.NotContains(t, doc.Graph[1], "aggregateRating")
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		noMovies, noTags := repository.NewMemoryMovieRepo(), repository.NewMemoryTagRepo()
+		empty := NewMovieService(zap.NewNop(), noMovies, users, people, genres, repository.NewMemoryCreditRepo(), noTags, repository.NewMemoryReleaseRepo(), revisions, repository.NewMemorySearchIndex(), repository.NewMemoryFacetCounter(noMovies, noTags), uow)
+		var buf bytes.Buffer
+		require.NoError(t, empty.ExportMovies(models.ExportJSONLD, &buf))
+		var doc map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+		assert.Empty(t, doc["@graph"])
+	})
+
+	var buf bytes.Buffer
+	assert.ErrorIs(t, movieSvc.ExportMovies("xml", &buf), models.ErrInvalidExport)
+	assert.Zero(t, buf.Len())
+}
+
+func mustFirstMovie(t *testing.T, movies repository.MovieRepo) *models.Movie {
+	page, err := movies.ListMovies(models.ListOptions{Limit: 1})
+	require.NoError(t, err)
+	require.NotEmpty(t, page.Items)
+	return page.Items[0]
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+)
+
+func (s *movieSvc) ListMovieFacets(opts models.ListOptions, facets []string) (models.MovieFacets, error) {
+	opts, err := s.resolveFilters(opts)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.facets.CountMovieFacets(opts, facets)
+	if err != nil {
+		return nil, err
+	}
+
+	result := models.MovieFacets{}
+	for _, facet := range facets {
+		switch facet {
+		case models.FacetGenre:
+			result[facet], err = s.genreFacet(counts.Genres)
+		case models.FacetDecade:
+			result[facet] = countFacet(counts.Decades, strconv.Itoa, nil)
+		case models.FacetRating:
+			result[facet] = countFacet(counts.Ratings, strconv.Itoa, nil)
+		case models.FacetTag:
+			result[facet], err = s.tagFacet(counts.Tags)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (s *movieSvc) genreFacet(counts map[models.ID]int) ([]models.FacetCount, error) {
+	ids := make([]models.ID, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	genres, err := s.genreRepo.GetGenres(ids)
+	if err != nil {
+		return nil, err
+	}
+	names := map[models.ID]string{}
+	for _, genre := range genres {
+		names[genre.ID] = genre.Name
+	}
+	return namedFacet(counts, names), nil
+}
+
+// tagFacet names the tags of counts.
+func (s *movieSvc) tagFacet(counts map[models.ID]int) ([]models.FacetCount, error) {
+	ids := make([]models.ID, 0, len(counts))
+	for id := range counts {
+		ids = append(ids, id)
+	}
+	tags, err := s.tagRepo.GetTags(ids)
+	if err != nil {
+		return nil, err
+	}
+	names := map[models.ID]string{}
+	for _, tag := range tags {
+		names[tag.ID] = tag.Name
+	}
+	return namedFacet(counts, names), nil
+}
+
+func namedFacet(counts map[models.ID]int, names map[models.ID]string) []models.FacetCount {
+	return countFacet(counts, models.ID.String, func(id models.ID) string { return names[id] })
+}
+
+// countFacet turns counts into a facet, largest count first and ties in
+// order of value. name may be nil.
+func countFacet[K comparable](counts map[K]int, value, name func(K) string) []models.FacetCount {
+	facet := make([]models.FacetCount, 0, len(counts))
+	for key, count := range counts {
+		fc := models.FacetCount{Value: value(key), Count: count}
+		if name != nil {
+			fc.Name = name(key)
+		}
+		facet = append(facet, fc)
+	}
+	slices.SortFunc(facet, func(a, b models.FacetCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Value, b.Value)
+	})
+	return facet
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"go.uber.org/zap"
+)
+
+type GenreService interface {
+	ListGenres(opts models.ListOptions) (models.Page[*models.Genre], error)
+	GetGenre(id models.ID) (*models.Genre, error)
+	CreateGenre(actorID models.ID, req *models.CreateGenreRequest) (models.ID, error)
+	UpdateGenre(actorID models.ID, id models.ID, version int64, req *models.UpdateGenreRequest) (*models.Genre, error)
+	DeleteGenre(actorID models.ID, id models.ID) error
+}
+
+type genreSvc struct {
+	log       *zap.Logger
+	repo      repository.GenreRepo
+	movieRepo repository.MovieRepo
+	userRepo  repository.UserRepo
+}
+
+func NewGenreService(log *zap.Logger, repo repository.GenreRepo, movieRepo repository.MovieRepo, userRepo repository.UserRepo) GenreService {
+	return &genreSvc{
+		log:       log,
+		repo:      repo,
+		movieRepo: movieRepo,
+		userRepo:  userRepo,
+	}
+}
+
+func (s *genreSvc) ListGenres(opts models.ListOptions) (models.Page[*models.Genre], error) {
+	return s.repo.ListGenres(opts)
+}
+
+func (s *genreSvc) GetGenre(id models.ID) (*models.Genre, error) {
+	return s.repo.GetGenre(id)
+}
+
+func (s *genreSvc) CreateGenre(actorID models.ID, req *models.CreateGenreRequest) (models.ID, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return models.NilID, err
+	}
+
+	if !HasPermission(actor, ResourceGenre, ActionCreate, nil) {
+		return models.NilID, errs.Forbidden
+	}
+
+	return s.repo.CreateGenre(actorID, req)
+}
+
+func (s *genreSvc) UpdateGenre(actorID models.ID, id models.ID, version int64, req *models.UpdateGenreRequest) (*models.Genre, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !HasPermission(actor, ResourceGenre, ActionUpdate, nil) {
+		return nil, errs.Forbidden
+	}
+
+	return s.repo.UpdateGenre(actorID, id, version, req)
+}
+
+// DeleteGenre refuses with errs.InUse to delete a genre a movie still has.
+func (s *genreSvc) DeleteGenre(actorID models.ID, id models.ID) error {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return err
+	}
+
+	if !HasPermission(actor, ResourceGenre, ActionDelete, nil) {
+		return errs.Forbidden
+	}
+
+	used, err := referencedByMovies(s.movieRepo, "genreId", id)
+	if err != nil {
+		s.log.Error("failed to check movies of genre", zap.Error(err))
+		return err
+	}
+	if used {
+		return errs.InUse
+	}
+
+	return s.repo.DeleteGenre(id)
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"strings"
+)
+
+// toRGBA copies img into an RGBA image with its origin at 0,0, which the
+// functions below read pixels from directly.
+func toRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba
+}
+
+// resizeToWidth scales src down to width, keeping its aspect ratio. Every
+// output pixel is the average of the source pixels it covers, which keeps
+// thin lines and text from aliasing the way nearest-neighbour sampling
+// would.
+func resizeToWidth(src *image.RGBA, width int) *image.RGBA {
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	height := max(1, int(math.Round(float64(sh)*float64(width)/float64(sw))))
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for dy := range height {
+		y0, y1 := dy*sh/height, max((dy+1)*sh/height, dy*sh/height+1)
+		for dx := range width {
+			x0, x1 := dx*sw/width, max((dx+1)*sw/width, dx*sw/width+1)
+
+			var r, g, b, a, n int
+			for y := y0; y < y1; y++ {
+				row := src.Pix[y*src.Stride:]
+				for x := x0; x < x1; x++ {
+					p := row[x*4 : x*4+4]
+					r += int(p[0])
+					g += int(p[1])
+					b += int(p[2])
+					a += int(p[3])
+					n++
+				}
+			}
+			o := dst.PixOffset(dx, dy)
+			dst.Pix[o] = uint8(r / n)
+			dst.Pix[o+1] = uint8(g / n)
+			dst.Pix[o+2] = uint8(b / n)
+			dst.Pix[o+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// encodeVariant encodes a scaled image as a JPEG, or as a PNG if it has
+// transparency that a JPEG would lose.
+func encodeVariant(img *image.RGBA) (data []byte, contentType, ext string, err error) {
+	var buf bytes.Buffer
+	if img.Opaque() {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+		return buf.Bytes(), "image/jpeg", ".jpg", err
+	}
+	err = png.Encode(&buf, img)
+	return buf.Bytes(), "image/png", ".png", err
+}
+
+// dominantColor returns the most common colour of img as #rrggbb. Colours
+// are counted in coarse buckets so that noise and gradients do not split
+// one visible colour into many; the result is the average of the largest
+// bucket. Transparent pixels are not counted.
+func dominantColor(img *image.RGBA) string {
+	type bucket struct{ r, g, b, n int }
+	buckets := map[int]*bucket{}
+	var top *bucket
+	for i := 0; i+3 < len(img.Pix); i += 4 {
+		p := img.Pix[i : i+4]
+		if p[3] < 128 {
+			continue
+		}
+		k := int(p[0]>>4)<<8 | int(p[1]>>4)<<4 | int(p[2]>>4)
+		bk, ok := buckets[k]
+		if !ok {
+			bk = &bucket{}
+			buckets[k] = bk
+		}
+		bk.r += int(p[0])
+		bk.g += int(p[1])
+		bk.b += int(p[2])
+		bk.n++
+		if top == nil || bk.n > top.n {
+			top = bk
+		}
+	}
+	if top == nil {
+		return ""
+	}
+	return fmt.Sprintf("#%02x%02x%02x", top.r/top.n, top.g/top.n, top.b/top.n)
+}
+
+const base83 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// blurHash encodes img with xComponents by yComponents cosine components
+// as described at https://blurha.sh. Clients decode the hash into a blurry
+// placeholder of the image.
+func blurHash(img *image.RGBA, xComponents, yComponents int) string {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := range yComponents {
+		for i := range xComponents {
+			norm := 2.0
+			if i == 0 && j == 0 {
+				norm = 1
+			}
+			var f [3]float64
+			for y := range h {
+				by := math.Cos(math.Pi * float64(j) * float64(y) / float64(h))
+				for x := range w {
+					basis := by * math.Cos(math.Pi*float64(i)*float64(x)/float64(w))
+					p := img.Pix[img.PixOffset(x, y):]
+					f[0] += basis * srgbToLinear(p[0])
+					f[1] += basis * srgbToLinear(p[1])
+					f[2] += basis * srgbToLinear(p[2])
+				}
+			}
+			scale := norm / float64(w*h)
+			factors = append(factors, [3]float64{f[0] * scale, f[1] * scale, f[2] * scale})
+		}
+	}
+
+	var hash strings.Builder
+	hash.WriteString(encode83((xComponents-1)+(yComponents-1)*9, 1))
+
+	dc, ac := factors[0], factors[1:]
+	maxValue := 1.0
+	if len(ac) > 0 {
+		actualMax := 0.0
+		for _, f := range ac {
+			actualMax = max(actualMax, math.Abs(f[0]), math.Abs(f[1]), math.Abs(f[2]))
+		}
+		quantisedMax := int(max(0, min(82, math.Floor(actualMax*166-0.5))))
+		maxValue = float64(quantisedMax+1) / 166
+		hash.WriteString(encode83(quantisedMax, 1))
+	} else {
+		hash.WriteString(encode83(0, 1))
+	}
+
+	hash.WriteString(encode83(linearToSRGB(dc[0])<<16|linearToSRGB(dc[1])<<8|linearToSRGB(dc[2]), 4))
+	for _, f := range ac {
+		quant := func(v float64) int {
+			return int(max(0, min(18, math.Floor(signPow(v/maxValue, 0.5)*9+9.5))))
+		}
+		hash.WriteString(encode83(quant(f[0])*19*19+quant(f[1])*19+quant(f[2]), 2))
+	}
+	return hash.String()
+}
+
+func encode83(value, length int) string {
+	b := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		b[i] = base83[value%83]
+		value /= 83
+	}
+	return string(b)
+}
+
+func srgbToLinear(v uint8) float64 {
+	c := float64(v) / 255
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) int {
+	c := max(0, min(1, v))
+	if c <= 0.0031308 {
+		return int(c*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(c, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(v, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), exp), v)
+}
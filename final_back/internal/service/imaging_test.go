@@ -0,0 +1,57 @@
+package service
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fill(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+func TestResizeToWidth(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 40, 60))
+	fill(src, image.Rect(0, 0, 20, 60), color.RGBA{255, 0, 0, 255})
+	fill(src, image.Rect(20, 0, 40, 60), color.RGBA{0, 0, 255, 255})
+
+	dst := resizeToWidth(src, 4)
+	assert.Equal(t, image.Rect(0, 0, 4, 6), dst.Rect)
+	assert.Equal(t, color.RGBA{255, 0, 0, 255}, dst.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{0, 0, 255, 255}, dst.RGBAAt(3, 5))
+
+	// A pixel straddling both halves averages the pixels it covers: 7 red
+	// and 6 blue ones.
+	dst = resizeToWidth(src, 3)
+	assert.Equal(t, image.Rect(0, 0, 3, 5), dst.Rect)
+	assert.Equal(t, color.RGBA{137, 0, 117, 255}, dst.RGBAAt(1, 0))
+}
+
+func TestBlurHash(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 6))
+	fill(img, img.Rect, color.RGBA{255, 0, 0, 255})
+
+	flat := blurHash(img, 4, 3)
+	assert.Len(t, flat, 28)
+	assert.Equal(t, "L", flat[:1], "4x3 components")
+	assert.Equal(t, "TI:j", flat[2:6], "the average colour is pure red")
+	assert.Equal(t, "T", blurHash(img, 3, 4)[:1], "3x4 components")
+
+	fill(img, image.Rect(0, 0, 4, 6), color.RGBA{0, 0, 255, 255})
+	assert.NotEqual(t, flat, blurHash(img, 4, 3))
+}
+
+func TestDominantColor(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	fill(img, img.Rect, color.RGBA{200, 30, 30, 255})
+	fill(img, image.Rect(0, 0, 3, 10), color.RGBA{0, 0, 250, 255})
+	assert.Equal(t, "#c81e1e", dominantColor(img))
+
+	assert.Equal(t, "", dominantColor(image.NewRGBA(image.Rect(0, 0, 2, 2))), "transparent images have none")
+}
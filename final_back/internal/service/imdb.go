@@ -0,0 +1,489 @@
+package service
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"go.uber.org/zap"
+)
+
+// Phases of an IMDb import that write, in the order they run. Reading the
+// ratings and crew only builds up what these phases need.
+const (
+	imdbPhaseTitles = "titles"
+	imdbPhaseNames  = "names"
+)
+
+var imdbPhases = []string{imdbPhaseTitles, imdbPhaseNames}
+
+// imdbCheckpointEvery is how many rows an IMDb import writes between
+// checkpoints.
+const imdbCheckpointEvery = 1000
+
+// imdbNull is how IMDb dumps spell a missing value.
+const imdbNull = `\N`
+
+// imdbImport is the state of one run of RunIMDbImport. The sets it keeps are
+// rebuilt from the dump on every run, including resumed ones; only the
+// writes before the checkpoint are skipped.
+type imdbImport struct {
+	s          *importSvc
+	actorID    models.ID
+	dump       fs.FS
+	checkpoint string
+	state      models.IMDbCheckpoint
+	resume     models.IMDbCheckpoint
+	unsaved    int
+
+	rated    map[string]bool
+	titles   map[string]bool
+	directs  map[string][]string
+	directed map[string]bool
+	hasCrew  bool
+	genres   map[string]models.ID
+}
+
+func (s *importSvc) RunIMDbImport(actorID models.ID, dump fs.FS, checkpoint string, opts models.IMDbOptions) (*models.IMDbReport, error) {
+	if len(opts.TitleTypes) == 0 {
+		opts.TitleTypes = []string{"movie"}
+	}
+	imp := &imdbImport{
+		s:          s,
+		actorID:    actorID,
+		dump:       dump,
+		checkpoint: checkpoint,
+		state:      models.IMDbCheckpoint{Options: opts, Phase: imdbPhaseTitles, Report: models.IMDbReport{Errors: []models.ImportError{}}},
+		rated:      map[string]bool{},
+		titles:     map[string]bool{},
+		directs:    map[string][]string{},
+		directed:   map[string]bool{},
+		genres:     map[string]models.ID{},
+	}
+	if err := imp.loadCheckpoint(); err != nil {
+		return nil, err
+	}
+
+	if err := imp.run(); err != nil {
+		if saveErr := imp.saveCheckpoint(); saveErr != nil {
+			s.log.Error("failed to save IMDb import checkpoint", zap.Error(saveErr))
+		}
+		return nil, err
+	}
+	if err := os.Remove(checkpoint); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return &imp.state.Report, nil
+}
+
+// loadCheckpoint carries on from the checkpoint of an earlier run, if there
+// is one. A checkpoint written with other options is refused rather than
+// mixed with this run.
+func (imp *imdbImport) loadCheckpoint() error {
+	imp.resume = imp.state
+	data, err := os.ReadFile(imp.checkpoint)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var saved models.IMDbCheckpoint
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("checkpoint %s: %w", imp.checkpoint, err)
+	}
+	opts := imp.state.Options
+	if !slices.Equal(saved.Options.TitleTypes, opts.TitleTypes) || saved.Options.MinVotes != opts.MinVotes {
+		return fmt.Errorf("checkpoint %s was written with other options; remove it to start over", imp.checkpoint)
+	}
+	if saved.Report.Errors == nil {
+		saved.Report.Errors = []models.ImportError{}
+	}
+	saved.Report.Resumed = true
+	imp.state = saved
+	imp.resume = saved
+	return nil
+}
+
+func (imp *imdbImport) saveCheckpoint() error {
+	data, err := json.Marshal(imp.state)
+	if err != nil {
+		return err
+	}
+	tmp := imp.checkpoint + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, imp.checkpoint)
+}
+
+// writes tells whether line of phase still has to be written, that is
+// whether it comes after the checkpoint this run resumed from.
+func (imp *imdbImport) writes(phase string, line int) bool {
+	at, resumeAt := slices.Index(imdbPhases, phase), slices.Index(imdbPhases, imp.resume.Phase)
+	return at > resumeAt || at == resumeAt && line > imp.resume.Line
+}
+
+// wrote moves the checkpoint past line of the phase being written, saving
+// it every imdbCheckpointEvery rows.
+func (imp *imdbImport) wrote(line int) error {
+	imp.state.Line = line
+	if imp.unsaved++; imp.unsaved < imdbCheckpointEvery {
+		return nil
+	}
+	imp.unsaved = 0
+	return imp.saveCheckpoint()
+}
+
+// finishPhase moves the checkpoint on from phase to next, unless this run
+// resumed past phase.
+func (imp *imdbImport) finishPhase(phase, next string) error {
+	if imp.state.Phase != phase {
+		return nil
+	}
+	imp.s.log.Info("IMDb import phase finished", zap.String("phase", phase))
+	imp.state.Phase, imp.state.Line, imp.unsaved = next, 0, 0
+	return imp.saveCheckpoint()
+}
+
+func (imp *imdbImport) run() error {
+	report := &imp.state.Report
+
+	n, err := imp.readTSV(models.IMDbTitleRatings, true, []string{"tconst", "numVotes"}, func(line int, row []string) error {
+		votes, err := strconv.Atoi(row[1])
+		if err == nil && votes >= imp.state.Options.MinVotes {
+			imp.rated[row[0]] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	report.RatingsRead = n
+
+	if err := imp.loadGenres(); err != nil {
+		return err
+	}
+
+	n, err = imp.readTSV(models.IMDbTitleBasics, true, []string{"tconst", "titleType", "primaryTitle", "isAdult", "startYear", "genres"}, func(line int, row []string) error {
+		tconst := row[0]
+		if !slices.Contains(imp.state.Options.TitleTypes, row[1]) || row[3] == "1" {
+			return nil
+		}
+		if imp.state.Options.MinVotes > 0 && !imp.rated[tconst] {
+			return nil
+		}
+		imp.titles[tconst] = true
+		if !imp.writes(imdbPhaseTitles, line) {
+			return nil
+		}
+		if err := imp.importTitle(line, row); err != nil {
+			return err
+		}
+		return imp.wrote(line)
+	})
+	if err != nil {
+		return err
+	}
+	report.TitlesRead = n
+	// The ratings were only needed to pick the titles.
+	imp.rated = nil
+	if err := imp.finishPhase(imdbPhaseTitles, imdbPhaseNames); err != nil {
+		return err
+	}
+
+	n, err = imp.readTSV(models.IMDbTitleCrew, false, []string{"tconst", "directors"}, func(line int, row []string) error {
+		imp.hasCrew = true
+		if tconst := row[0]; imp.titles[tconst] && row[1] != "" {
+			director, _, _ := strings.Cut(row[1], ",")
+			imp.directs[director] = append(imp.directs[director], tconst)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	report.CrewRead = n
+
+	n, err = imp.readTSV(models.IMDbNameBasics, true, []string{"nconst", "primaryName", "birthYear", "primaryProfession", "knownForTitles"}, func(line int, row []string) error {
+		titles := imp.directedBy(row)
+		if len(titles) == 0 || !imp.writes(imdbPhaseNames, line) {
+			return nil
+		}
+		if err := imp.importDirector(line, row, titles); err != nil {
+			return err
+		}
+		return imp.wrote(line)
+	})
+	if err != nil {
+		return err
+	}
+	report.NamesRead = n
+	imp.s.log.Info("IMDb import finished", zap.Int("movies", len(imp.titles)), zap.Int("failed", report.Failed))
+	return nil
+}
+
+// directedBy returns the imported titles the person of a name.basics row
+// directed. Without the crew file, a person who works as a director directs
+// the titles they are known for that nobody directs yet.
+func (imp *imdbImport) directedBy(row []string) []string {
+	if imp.hasCrew {
+		return imp.directs[row[0]]
+	}
+	if !slices.Contains(strings.Split(row[3], ","), "director") {
+		return nil
+	}
+	titles := []string{}
+	for _, tconst := range strings.Split(row[4], ",") {
+		if imp.titles[tconst] && !imp.directed[tconst] {
+			imp.directed[tconst] = true
+			titles = append(titles, tconst)
+		}
+	}
+	return titles
+}
+
+func (imp *imdbImport) fail(file string, line int, externalID string, err error) {
+	report := &imp.state.Report
+	report.Failed++
+	if len(report.Errors) < maxImportErrors {
+		report.Errors = append(report.Errors, models.ImportError{File: file, Line: line, ExternalID: externalID, Error: err.Error()})
+	}
+}
+
+// readTSV streams a gzipped file of the dump to fn, one line at a time,
+// with the values of columns in that order and missing values empty. It
+// returns the number of lines after the header. An optional file that is
+// not in the dump reads as empty.
+func (imp *imdbImport) readTSV(name string, required bool, columns []string, fn func(line int, row []string) error) (int, error) {
+	f, err := imp.dump.Open(name)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s: %v", models.ErrInvalidIMDbDump, name, err)
+	}
+	defer gz.Close()
+
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return 0, err
+		}
+		return 0, fmt.Errorf("%w: %s: missing header", models.ErrInvalidIMDbDump, name)
+	}
+	header := strings.Split(scanner.Text(), "\t")
+	indexes := make([]int, len(columns))
+	for i, column := range columns {
+		if indexes[i] = slices.Index(header, column); indexes[i] < 0 {
+			return 0, fmt.Errorf("%w: %s: missing column %q", models.ErrInvalidIMDbDump, name, column)
+		}
+	}
+
+	line := 1
+	row := make([]string, len(columns))
+	for scanner.Scan() {
+		line++
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) != len(header) {
+			return 0, fmt.Errorf("%w: %s:%d: %d fields, want %d", models.ErrInvalidIMDbDump, name, line, len(fields), len(header))
+		}
+		for i, index := range indexes {
+			if row[i] = fields[index]; row[i] == imdbNull {
+				row[i] = ""
+			}
+		}
+		if err := fn(line, row); err != nil {
+			return 0, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("%w: %s: %v", models.ErrInvalidIMDbDump, name, err)
+	}
+	return line - 1, nil
+}
+
+// loadGenres reads the genres already in the catalogue, which titles are
+// matched with by name.
+func (imp *imdbImport) loadGenres() error {
+	genres, err := imp.s.genreRepo.ListGenres(models.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, genre := range genres.Items {
+		imp.genres[genre.Name] = genre.ID
+	}
+	return nil
+}
+
+// genre returns the ID of the genre called name, creating it if need be.
+func (imp *imdbImport) genre(name string) (models.ID, error) {
+	if id, ok := imp.genres[name]; ok {
+		return id, nil
+	}
+	id, err := imp.s.genreRepo.CreateGenre(imp.actorID, &models.CreateGenreRequest{Name: name})
+	if err != nil {
+		return models.NilID, err
+	}
+	imp.genres[name] = id
+	imp.state.Report.GenresCreated++
+	return id, nil
+}
+
+// importTitle creates or updates the movie of a title.basics row. Movies
+// have one genre, so a title takes the first of its genres. Only errors
+// that should stop the import are returned; row errors go to the report.
+func (imp *imdbImport) importTitle(line int, row []string) error {
+	report := &imp.state.Report
+	tconst, title := row[0], row[2]
+
+	year := 0
+	if row[4] != "" {
+		var err error
+		if year, err = strconv.Atoi(row[4]); err != nil {
+			imp.fail(models.IMDbTitleBasics, line, tconst, errors.New("invalid startYear"))
+			return nil
+		}
+	}
+	var genreID *models.ID
+	if name, _, _ := strings.Cut(row[5], ","); name != "" {
+		id, err := imp.genre(name)
+		if err != nil {
+			return err
+		}
+		genreID = &id
+	}
+
+	existing, err := imp.s.movieRepo.GetMovieByExternalID(tconst)
+	if err == errs.NotFound {
+		created, err := imp.s.movies.create(imp.actorID, &models.CreateMovieRequest{ExternalID: tconst, Title: title, Year: year, GenreID: genreID})
+		if err == errs.AlreadyExists {
+			imp.fail(models.IMDbTitleBasics, line, tconst, errors.New("external ID belongs to a movie in the trash"))
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		report.MoviesCreated++
+		imp.s.index(created)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	req := &models.UpdateMovieRequest{}
+	if existing.Title != title {
+		req.Title = &title
+	}
+	if existing.Year != year {
+		req.Year = &year
+	}
+	if genreID != nil && (existing.GenreID == nil || *existing.GenreID != *genreID) {
+		req.GenreID = genreID
+	}
+	if *req == (models.UpdateMovieRequest{}) {
+		report.MoviesUnchanged++
+		return nil
+	}
+	updated, err := imp.s.movies.update(imp.actorID, existing.ID, existing.Version, req, nil)
+	if err == errs.Conflict {
+		imp.fail(models.IMDbTitleBasics, line, tconst, errors.New("movie was modified during the import"))
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	report.MoviesUpdated++
+	imp.s.index(updated)
+	return nil
+}
+
+// importDirector creates or updates the person of a name.basics row and
+// makes them the director of titles.
+func (imp *imdbImport) importDirector(line int, row []string, titles []string) error {
+	report := &imp.state.Report
+	nconst, name := row[0], row[1]
+
+	var birthYear *int
+	if row[2] != "" {
+		year, err := strconv.Atoi(row[2])
+		if err != nil {
+			imp.fail(models.IMDbNameBasics, line, nconst, errors.New("invalid birthYear"))
+			return nil
+		}
+		birthYear = &year
+	}
+
+	person, err := imp.s.personRepo.GetPersonByExternalID(nconst)
+	if err == errs.NotFound {
+		id, err := imp.s.personRepo.CreatePerson(imp.actorID, &models.CreatePersonRequest{ExternalID: nconst, Name: name, BirthYear: birthYear})
+		if err != nil {
+			return err
+		}
+		report.PeopleCreated++
+		person = &models.Person{ID: id}
+	} else if err != nil {
+		return err
+	} else {
+		req := &models.UpdatePersonRequest{}
+		if person.Name != name {
+			req.Name = &name
+		}
+		if birthYear != nil && (person.BirthYear == nil || *person.BirthYear != *birthYear) {
+			req.BirthYear = birthYear
+		}
+		if *req == (models.UpdatePersonRequest{}) {
+			report.PeopleUnchanged++
+		} else if _, err := imp.s.personRepo.UpdatePerson(imp.actorID, person.ID, person.Version, req); err == errs.Conflict {
+			imp.fail(models.IMDbNameBasics, line, nconst, errors.New("person was modified during the import"))
+			return nil
+		} else if err != nil {
+			return err
+		} else {
+			report.PeopleUpdated++
+		}
+	}
+
+	for _, tconst := range titles {
+		movie, err := imp.s.movieRepo.GetMovieByExternalID(tconst)
+		if err == errs.NotFound {
+			// The title failed to import or its movie was trashed since.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if movie.DirectorID != nil && *movie.DirectorID == person.ID {
+			continue
+		}
+		updated, err := imp.s.movies.update(imp.actorID, movie.ID, movie.Version, &models.UpdateMovieRequest{DirectorID: &person.ID}, nil)
+		if err == errs.Conflict {
+			imp.fail(models.IMDbNameBasics, line, tconst, errors.New("movie was modified during the import"))
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		report.DirectorsLinked++
+		imp.s.index(updated)
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func gzipTSV(t *testing.T, lines ...string) *fstest.MapFile {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte(strings.Join(lines, "\n") + "\n"))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return &fstest.MapFile{Data: buf.Bytes()}
+}
+
+func imdbDump(t *testing.T, withCrew bool) fstest.MapFS {
+	dump := fstest.MapFS{
+		models.IMDbTitleBasics: gzipTSV(t,
+			"tconst\ttitleType\tprimaryTitle\toriginalTitle\tisAdult\tstartYear\tendYear\truntimeMinutes\tgenres",
+			"tt0133093\tmovie\tThe Matrix\tThe Matrix\t0\t1999\t\\N\t136\tAction,Sci-Fi",
+			"tt0234215\tmovie\tThe Matrix Reloaded\tThe Matrix Reloaded\t0\t2003\t\\N\t138\tAction,Sci-Fi",
+			"tt0000001\tmovie\tObscure\tObscure\t0\t1900\t\\N\t\\N\tDrama",
+			"tt0000002\tmovie\tAdult\tAdult\t1\t2000\t\\N\t\\N\tDrama",
+			"tt0903747\ttvSeries\tBreaking Bad\tBreaking Bad\t0\t2008\t2013\t49\tCrime,Drama",
+			"tt0113277\tmovie\tHeat\tHeat\t0\t1995\t\\N\t170\t\\N",
+		),
+		models.IMDbTitleRatings: gzipTSV(t,
+			"tconst\taverageRating\tnumVotes",
+			"tt0133093\t8.7\t2000000",
+			"tt0234215\t7.2\t600000",
+			"tt0000001\t5.0\t3",
+			"tt0000002\t5.0\t5000",
+			"tt0903747\t9.5\t2000000",
+			"tt0113277\t8.3\t700000",
+		),
+		models.IMDbNameBasics: gzipTSV(t,
+			"nconst\tprimaryName\tbirthYear\tdeathYear\tprimaryProfession\tknownForTitles",
+			"nm0905154\tLana Wachowski\t1965\t\\N\tdirector,writer,producer\ttt0133093,tt0234215",
+			"nm0000110\tKeanu Reeves\t1964\t\\N\tactor\ttt0133093",
+			"nm0000520\tMichael Mann\t1943\t\\N\tproducer,director\ttt0113277",
+		),
+	}
+	if withCrew {
+		dump[models.IMDbTitleCrew] = gzipTSV(t,
+			"tconst\tdirectors\twriters",
+			"tt0133093\tnm0905154,nm0905152\tnm0905152",
+			"tt0234215\tnm0905154,nm0905152\tnm0905152",
+			"tt0113277\tnm0000520\tnm0000520",
+		)
+	}
+	return dump
+}
+
+func TestIMDbImport(t *testing.T) {
+	newService := func() (ImportService, repository.MovieRepo, repository.PersonRepo) {
+		users := repository.NewMemoryUserRepo()
+		movies := repository.NewMemoryMovieRepo()
+		people := repository.NewMemoryPersonRepo()
+		genres := repository.NewMemoryGenreRepo()
+		uow := repository.NewMemoryUnitOfWork(repository.Repos{Users: users, Movies: movies, Revisions: repository.NewMemoryRevisionRepo()})
+		return NewImportService(zap.NewNop(), movies, people, genres, users, repository.NewMemorySearchIndex(), uow), movies, people
+	}
+	opts := models.IMDbOptions{MinVotes: 1000}
+
+	for _, withCrew := range []bool{true, false} {
+		name := "Crew"
+		if !withCrew {
+			name = "KnownFor"
+		}
+		t.Run(name, func(t *testing.T) {
+			svc, movies, people := newService()
+			checkpoint := filepath.Join(t.TempDir(), "checkpoint.json")
+
+			report, err := svc.RunIMDbImport(models.NilID, imdbDump(t, withCrew), checkpoint, opts)
+			require.NoError(t, err)
+			assert.Equal(t, 6, report.TitlesRead)
+			assert.Equal(t, 3, report.MoviesCreated, "the obscure, adult and TV titles are left out")
+			assert.Equal(t, 1, report.GenresCreated, "movies take the first genre of a title")
+			assert.Equal(t, 2, report.PeopleCreated, "only directors are imported")
+			assert.Equal(t, 3, report.DirectorsLinked)
+			assert.Zero(t, report.Failed)
+			assert.NoFileExists(t, checkpoint)
+
+			matrix, err := movies.GetMovieByExternalID("tt0133093")
+			require.NoError(t, err)
+			assert.Equal(t, "The Matrix", matrix.Title)
+			assert.Equal(t, 1999, matrix.Year)
+			require.NotNil(t, matrix.GenreID)
+			require.NotNil(t, matrix.DirectorID)
+			lana, err := people.GetPersonByExternalID("nm0905154")
+			require.NoError(t, err)
+			assert.Equal(t, lana.ID, *matrix.DirectorID)
+			assert.Equal(t, 1965, *lana.BirthYear)
+
+			heat, err := movies.GetMovieByExternalID("tt0113277")
+			require.NoError(t, err)
+			assert.Nil(t, heat.GenreID)
+			_, err = movies.GetMovieByExternalID("tt0903747")
+			assert.ErrorIs(t, err, errs.NotFound)
+
+			report, err = svc.RunIMDbImport(models.NilID, imdbDump(t, withCrew), checkpoint, opts)
+			require.NoError(t, err)
+			assert.Zero(t, report.MoviesCreated)
+			assert.Equal(t, 3, report.MoviesUnchanged)
+			assert.Zero(t, report.PeopleCreated)
+			assert.Equal(t, 2, report.PeopleUnchanged)
+			assert.Zero(t, report.DirectorsLinked)
+		})
+	}
+
+	t.Run("Resume", func(t *testing.T) {
+		svc, movies, _ := newService()
+		checkpoint := filepath.Join(t.TempDir(), "checkpoint.json")
+		saved := models.IMDbCheckpoint{Options: models.IMDbOptions{TitleTypes: []string{"movie"}, MinVotes: 1000}, Phase: imdbPhaseTitles, Line: 2, Report: models.IMDbReport{MoviesCreated: 1}}
+		data, err := json.Marshal(saved)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(checkpoint, data, 0o644))
+
+		report, err := svc.RunIMDbImport(models.NilID, imdbDump(t, true), checkpoint, opts)
+		require.NoError(t, err)
+		assert.True(t, report.Resumed)
+		assert.Equal(t, 3, report.MoviesCreated, "counts carry on from the checkpoint")
+		_, err = movies.GetMovieByExternalID("tt0133093")
+		assert.ErrorIs(t, err, errs.NotFound, "lines up to the checkpoint are not written again")
+		_, err = movies.GetMovieByExternalID("tt0234215")
+		assert.NoError(t, err)
+		assert.NoFileExists(t, checkpoint)
+
+		require.NoError(t, os.WriteFile(checkpoint, data, 0o644))
+		_, err = svc.RunIMDbImport(models.NilID, imdbDump(t, true), checkpoint, models.IMDbOptions{MinVotes: 10})
+		assert.ErrorContains(t, err, "other options")
+	})
+
+	t.Run("InvalidDump", func(t *testing.T) {
+		svc, _, _ := newService()
+		checkpoint := filepath.Join(t.TempDir(), "checkpoint.json")
+
+		dump := imdbDump(t, false)
+		dump[models.IMDbTitleBasics] = gzipTSV(t, "tconst\ttitleType")
+		_, err := svc.RunIMDbImport(models.NilID, dump, checkpoint, opts)
+		assert.ErrorIs(t, err, models.ErrInvalidIMDbDump)
+
+		dump[models.IMDbTitleBasics] = &fstest.MapFile{Data: []byte("not gzip")}
+		_, err = svc.RunIMDbImport(models.NilID, dump, checkpoint, opts)
+		assert.ErrorIs(t, err, models.ErrInvalidIMDbDump)
+
+		delete(dump, models.IMDbNameBasics)
+		dump[models.IMDbTitleBasics] = imdbDump(t, false)[models.IMDbTitleBasics]
+		_, err = svc.RunIMDbImport(models.NilID, dump, checkpoint, opts)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
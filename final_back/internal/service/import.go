@@ -0,0 +1,425 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"go.uber.org/zap"
+)
+
+// maxImportErrors bounds the error report of a job; rows failing beyond it
+// are still counted.
+const maxImportErrors = 1000
+
+type ImportService interface {
+	// StartImport checks the input is well-formed and imports its rows in
+	// the background. The returned job reports progress through GetImport.
+	StartImport(actorID models.ID, format string, data []byte) (*models.ImportJob, error)
+	GetImport(actorID models.ID, id models.ID) (*models.ImportJob, error)
+	// RunImport imports r before returning, without checking permissions. It
+	// is meant for the command line, which acts with the server's own access.
+	RunImport(actorID models.ID, format string, r io.Reader) (*models.ImportJob, error)
+	// RunIMDbImport imports the movies of an IMDb dataset dump, and their
+	// genres and directors, before returning. Like RunImport it is meant for
+	// the command line. Progress is kept in the checkpoint file, which a
+	// later run with the same options carries on from; it is removed once
+	// the import is done.
+	RunIMDbImport(actorID models.ID, dump fs.FS, checkpoint string, opts models.IMDbOptions) (*models.IMDbReport, error)
+}
+
+type importSvc struct {
+	log        *zap.Logger
+	movieRepo  repository.MovieRepo
+	personRepo repository.PersonRepo
+	genreRepo  repository.GenreRepo
+	userRepo   repository.UserRepo
+	search     repository.SearchIndex
+	validate   *validator.Validate
+	movies     movieWriter
+
+	// Jobs live in memory and are forgotten on restart.
+	mu   sync.RWMutex
+	jobs map[models.ID]*models.ImportJob
+}
+
+func NewImportService(log *zap.Logger, movieRepo repository.MovieRepo, personRepo repository.PersonRepo, genreRepo repository.GenreRepo, userRepo repository.UserRepo, search repository.SearchIndex, uow repository.UnitOfWork) ImportService {
+	validate := validator.New()
+	// Apply the same rules gin applies to request bodies, and name fields
+	// the way clients know them.
+	validate.SetTagName("binding")
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return strings.Split(field.Tag.Get("json"), ",")[0]
+	})
+	return &importSvc{
+		log:        log,
+		movieRepo:  movieRepo,
+		personRepo: personRepo,
+		genreRepo:  genreRepo,
+		userRepo:   userRepo,
+		search:     search,
+		validate:   validate,
+		movies:     movieWriter{uow: uow},
+		jobs:       map[models.ID]*models.ImportJob{},
+	}
+}
+
+// importRow is one parsed row of the input, or the reason it could not be
+// parsed.
+type importRow struct {
+	line int
+	req  models.CreateMovieRequest
+	err  error
+}
+
+func (s *importSvc) StartImport(actorID models.ID, format string, data []byte) (*models.ImportJob, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !HasPermission(actor, ResourceMovie, ActionImport, nil) {
+		return nil, errs.Forbidden
+	}
+
+	rows, err := parseImport(format, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	job := s.newJob(actorID, format, len(rows))
+	go s.run(job.ID, actorID, rows)
+	return job, nil
+}
+
+func (s *importSvc) RunImport(actorID models.ID, format string, r io.Reader) (*models.ImportJob, error) {
+	rows, err := parseImport(format, r)
+	if err != nil {
+		return nil, err
+	}
+
+	job := s.newJob(actorID, format, len(rows))
+	s.run(job.ID, actorID, rows)
+	return s.job(job.ID), nil
+}
+
+func (s *importSvc) GetImport(actorID models.ID, id models.ID) (*models.ImportJob, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !HasPermission(actor, ResourceMovie, ActionImport, nil) {
+		return nil, errs.Forbidden
+	}
+
+	job := s.job(id)
+	if job == nil {
+		return nil, errs.NotFound
+	}
+	return job, nil
+}
+
+func (s *importSvc) newJob(actorID models.ID, format string, total int) *models.ImportJob {
+	job := &models.ImportJob{
+		ID:        models.NewID(),
+		Format:    format,
+		Status:    models.ImportQueued,
+		Total:     total,
+		Errors:    []models.ImportError{},
+		CreatedBy: actorID,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job
+	return cloneJob(job)
+}
+
+// job returns a copy of a job that is safe to read while the job runs.
+func (s *importSvc) job(id models.ID) *models.ImportJob {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil
+	}
+	return cloneJob(job)
+}
+
+func (s *importSvc) update(id models.ID, change func(job *models.ImportJob)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	change(s.jobs[id])
+}
+
+func (s *importSvc) run(id models.ID, actorID models.ID, rows []importRow) {
+	s.update(id, func(job *models.ImportJob) { job.Status = models.ImportRunning })
+
+	for _, row := range rows {
+		created, err := s.importRow(actorID, row)
+		s.update(id, func(job *models.ImportJob) {
+			job.Processed++
+			switch {
+			case err != nil:
+				job.Failed++
+				if len(job.Errors) < maxImportErrors {
+					job.Errors = append(job.Errors, models.ImportError{Line: row.line, ExternalID: row.req.ExternalID, Error: err.Error()})
+				}
+			case created:
+				job.Created++
+			default:
+				job.Updated++
+			}
+		})
+	}
+
+	s.update(id, func(job *models.ImportJob) {
+		finished := time.Now().UTC()
+		job.Status = models.ImportDone
+		job.FinishedAt = &finished
+	})
+	s.log.Info("movie import finished", zap.Stringer("job", id))
+}
+
+// importRow creates or updates the movie of one row and reports which. The
+// errors it returns are meant for the job's report.
+func (s *importSvc) importRow(actorID models.ID, row importRow) (bool, error) {
+	if row.err != nil {
+		return false, row.err
+	}
+	req := row.req
+	if err := s.validate.Struct(&req); err != nil {
+		return false, validationError(err)
+	}
+	if err := checkMovieReferences(s.personRepo, s.genreRepo, req.DirectorID, req.GenreID); err == errs.InvalidReference {
+		return false, errors.New("unknown director or genre")
+	} else if err != nil {
+		return false, err
+	}
+
+	if req.ExternalID != "" {
+		existing, err := s.movieRepo.GetMovieByExternalID(req.ExternalID)
+		if err == nil {
+			update := &models.UpdateMovieRequest{
+				Title:      &req.Title,
+				Year:       &req.Year,
+				DirectorID: req.DirectorID,
+				GenreID:    req.GenreID,
+				ImageURL:   &req.ImageURL,
+			}
+			// The optional fields are left as they are by rows without them,
+			// so that files made before they existed do not clear them.
+			if req.Runtime != 0 {
+				update.Runtime = &req.Runtime
+			}
+			if req.OriginalLanguage != "" {
+				update.OriginalLanguage = &req.OriginalLanguage
+			}
+			if req.Synopsis != "" {
+				update.Synopsis = &req.Synopsis
+			}
+			updated, err := s.movies.update(actorID, existing.ID, existing.Version, update, nil)
+			if err == errs.Conflict {
+				return false, errors.New("movie was modified during the import")
+			}
+			if err != nil {
+				return false, err
+			}
+			s.index(updated)
+			return false, nil
+		}
+		if err != errs.NotFound {
+			return false, err
+		}
+	}
+
+	created, err := s.movies.create(actorID, &req)
+	if err == errs.AlreadyExists {
+		return false, errors.New("external ID belongs to a movie in the trash")
+	}
+	if err != nil {
+		return false, err
+	}
+	s.index(created)
+	return true, nil
+}
+
+func (s *importSvc) index(movie *models.Movie) {
+	if err := s.search.IndexMovie(movie); err != nil {
+		s.log.Error("failed to index movie", zap.Error(err))
+	}
+}
+
+func cloneJob(job *models.ImportJob) *models.ImportJob {
+	clone := *job
+	clone.Errors = slices.Clone(job.Errors)
+	if job.FinishedAt != nil {
+		finished := *job.FinishedAt
+		clone.FinishedAt = &finished
+	}
+	return &clone
+}
+
+// validationError describes the fields of a row that break the rules of
+// CreateMovieRequest, by their JSON names.
+func validationError(err error) error {
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+	msgs := make([]string, len(fieldErrs))
+	for i, fe := range fieldErrs {
+		switch {
+		case fe.Tag() == "required":
+			msgs[i] = fe.Field() + " is required"
+		case fe.Param() != "":
+			msgs[i] = fmt.Sprintf("%s must be %s=%s", fe.Field(), fe.Tag(), fe.Param())
+		default:
+			msgs[i] = fmt.Sprintf("%s must be %s", fe.Field(), fe.Tag())
+		}
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+// parseImport reads every row of r. Rows that cannot be parsed are kept
+// with their error; only input that is not in format at all fails.
+func parseImport(format string, r io.Reader) ([]importRow, error) {
+	switch format {
+	case models.ImportCSV:
+		return parseCSV(r)
+	case models.ImportNDJSON:
+		return parseNDJSON(r)
+	}
+	return nil, fmt.Errorf("%w: unknown format %q", models.ErrInvalidImport, format)
+}
+
+func parseCSV(r io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: missing header", models.ErrInvalidImport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidImport, err)
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		if !slices.Contains(models.ImportColumns, name) {
+			return nil, fmt.Errorf("%w: unknown column %q", models.ErrInvalidImport, name)
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"title", "year"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: missing column %q", models.ErrInvalidImport, name)
+		}
+	}
+
+	rows := []importRow{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rows = append(rows, importRow{line: parseErr.StartLine, err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, csvRow(line, record, columns))
+	}
+}
+
+func csvRow(line int, record []string, columns map[string]int) importRow {
+	row := importRow{line: line}
+	field := func(name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	id := func(name string) (*models.ID, error) {
+		value := field(name)
+		if value == "" {
+			return nil, nil
+		}
+		id, err := models.ParseID(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not a valid id", name)
+		}
+		return &id, nil
+	}
+
+	row.req.ExternalID = field("externalId")
+	row.req.Title = field("title")
+	row.req.ImageURL = field("imageURL")
+	row.req.OriginalLanguage = field("originalLanguage")
+	row.req.Synopsis = field("synopsis")
+	if year := field("year"); year != "" {
+		if row.req.Year, row.err = strconv.Atoi(year); row.err != nil {
+			row.err = errors.New("year is not a number")
+			return row
+		}
+	}
+	if runtime := field("runtime"); runtime != "" {
+		if row.req.Runtime, row.err = strconv.Atoi(runtime); row.err != nil {
+			row.err = errors.New("runtime is not a number")
+			return row
+		}
+	}
+	if row.req.DirectorID, row.err = id("directorId"); row.err != nil {
+		return row
+	}
+	row.req.GenreID, row.err = id("genreId")
+	return row
+}
+
+func parseNDJSON(r io.Reader) ([]importRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	rows := []importRow{}
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		row := importRow{line: line}
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&row.req); err != nil {
+			row.err = fmt.Errorf("invalid JSON: %v", err)
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidImport, err)
+	}
+	return rows, nil
+}
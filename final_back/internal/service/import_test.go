@@ -0,0 +1,142 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestImportMovies(t *testing.T) {
+	users := repository.NewMemoryUserRepo()
+	movies := repository.NewMemoryMovieRepo()
+	people := repository.NewMemoryPersonRepo()
+	genres := repository.NewMemoryGenreRepo()
+	uow := repository.NewMemoryUnitOfWork(repository.Repos{Users: users, Movies: movies, Revisions: repository.NewMemoryRevisionRepo()})
+	importSvc := NewImportService(zap.NewNop(), movies, people, genres, users, repository.NewMemorySearchIndex(), uow)
+
+	adminID, err := users.CreateUser(models.NilID, &models.User{Username: "admin", Roles: []models.Role{RoleAdmin}})
+	require.NoError(t, err)
+	moderatorID, err := users.CreateUser(models.NilID, &models.User{Username: "mod", Roles: []models.Role{RoleModerator}})
+	require.NoError(t, err)
+	director, err := people.CreatePerson(adminID, &models.CreatePersonRequest{Name: "Lana Wachowski"})
+	require.NoError(t, err)
+	genre, err := genres.CreateGenre(adminID, &models.CreateGenreRequest{Name: "Sci-Fi"})
+	require.NoError(t, err)
+
+	csv := "externalId,title,year,directorId,genreId,imageURL\n" +
+		"tt0133093,The Matrix,1999," + director.String() + "," + genre.String() + ",https://example.com/matrix.jpg\n" +
+		"tt0234215,The Matrix Reloaded,2003," + director.String() + "," + genre.String() + ",https://example.com/reloaded.jpg\n" +
+		",,2003," + director.String() + "," + genre.String() + ",https://example.com/untitled.jpg\n" +
+		"tt0242653,The Matrix Revolutions,soon," + director.String() + "," + genre.String() + ",https://example.com/revolutions.jpg\n" +
+		"tt0000001,Unknown Director,2000," + models.NewID().String() + "," + genre.String() + ",https://example.com/x.jpg\n" +
+		"too,few\n"
+	job, err := importSvc.RunImport(adminID, models.ImportCSV, strings.NewReader(csv))
+	require.NoError(t, err)
+	assert.Equal(t, models.ImportDone, job.Status)
+	assert.Equal(t, 6, job.Total)
+	assert.Equal(t, 6, job.Processed)
+	assert.Equal(t, 2, job.Created)
+	assert.Equal(t, 4, job.Failed)
+	require.Len(t, job.Errors, 4)
+	assert.Equal(t, models.ImportError{Line: 4, Error: "title is required"}, job.Errors[0])
+	assert.Equal(t, models.ImportError{Line: 5, ExternalID: "tt0242653", Error: "year is not a number"}, job.Errors[1])
+	assert.Equal(t, models.ImportError{Line: 6, ExternalID: "tt0000001", Error: "unknown director or genre"}, job.Errors[2])
+	assert.Equal(t, 7, job.Errors[3].Line)
+
+	matrix, err := movies.GetMovieByExternalID("tt0133093")
+	require.NoError(t, err)
+	assert.Equal(t, "The Matrix", matrix.Title)
+	assert.Equal(t, &director, matrix.DirectorID)
+
+	// Importing again updates movies by external ID and creates the rest.
+	ndjson := `{"externalId":"tt0133093","title":"The Matrix","year":1999,"directorId":"` + director.String() + `","genreId":"` + genre.String() + `","imageURL":"https://example.com/matrix-4k.jpg"}
+
+{"title":"Heat","year":1995,"directorId":"` + director.String() + `","genreId":"` + genre.String() + `","imageURL":"https://example.com/heat.jpg"}
+{"title":"Typo","yaer":1995}
+`
+	job, err = importSvc.StartImport(adminID, models.ImportNDJSON, []byte(ndjson))
+	require.NoError(t, err)
+	assert.Equal(t, 3, job.Total)
+	require.Eventually(t, func() bool {
+		job, err = importSvc.GetImport(adminID, job.ID)
+		require.NoError(t, err)
+		return job.Status == models.ImportDone
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 1, job.Created)
+	assert.Equal(t, 1, job.Updated)
+	assert.Equal(t, 1, job.Failed)
+	require.Len(t, job.Errors, 1)
+	assert.Equal(t, 4, job.Errors[0].Line)
+	assert.Contains(t, job.Errors[0].Error, "yaer")
+
+	matrix, err = movies.GetMovieByExternalID("tt0133093")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/matrix-4k.jpg", matrix.ImageURL)
+	assert.Equal(t, int64(2), matrix.Version)
+
+	_, err = importSvc.StartImport(adminID, models.ImportCSV, []byte("title,year,rating\n"))
+	assert.ErrorIs(t, err, models.ErrInvalidImport)
+	_, err = importSvc.StartImport(adminID, models.ImportCSV, []byte("externalId,title\n"))
+	assert.ErrorIs(t, err, models.ErrInvalidImport)
+	_, err = importSvc.StartImport(adminID, "xml", []byte("<movies/>"))
+	assert.ErrorIs(t, err, models.ErrInvalidImport)
+	_, err = importSvc.StartImport(moderatorID, models.ImportCSV, []byte(csv))
+	assert.ErrorIs(t, err, errs.Forbidden)
+	_, err = importSvc.GetImport(moderatorID, job.ID)
+	assert.ErrorIs(t, err, errs.Forbidden)
+	_, err = importSvc.GetImport(adminID, models.NewID())
+	assert.ErrorIs(t, err, errs.NotFound)
+}
+
+func TestImportMovieDetails(t *testing.T) {
+	users := repository.NewMemoryUserRepo()
+	movies := repository.NewMemoryMovieRepo()
+	people := repository.NewMemoryPersonRepo()
+	genres := repository.NewMemoryGenreRepo()
+	uow := repository.NewMemoryUnitOfWork(repository.Repos{Users: users, Movies: movies, Revisions: repository.NewMemoryRevisionRepo()})
+	importSvc := NewImportService(zap.NewNop(), movies, people, genres, users, repository.NewMemorySearchIndex(), uow)
+
+	adminID, err := users.CreateUser(models.NilID, &models.User{Username: "admin", Roles: []models.Role{RoleAdmin}})
+	require.NoError(t, err)
+	director, err := people.CreatePerson(adminID, &models.CreatePersonRequest{Name: "Lana Wachowski"})
+	require.NoError(t, err)
+	genre, err := genres.CreateGenre(adminID, &models.CreateGenreRequest{Name: "Sci-Fi"})
+	require.NoError(t, err)
+	refs := director.String() + "," + genre.String()
+
+	csv := "externalId,title,year,directorId,genreId,imageURL,runtime,originalLanguage,synopsis\n" +
+		"tt0133093,The Matrix,1999," + refs + ",https://example.com/matrix.jpg,136,EN-us,A hacker learns the truth.\n" +
+		"tt0234215,The Matrix Reloaded,2003," + refs + ",https://example.com/reloaded.jpg,long,en,\n" +
+		"tt0242653,The Matrix Revolutions,2003," + refs + ",https://example.com/revolutions.jpg,129,not a language,\n"
+	job, err := importSvc.RunImport(adminID, models.ImportCSV, strings.NewReader(csv))
+	require.NoError(t, err)
+	assert.Equal(t, 1, job.Created)
+	require.Len(t, job.Errors, 2)
+	assert.Equal(t, "runtime is not a number", job.Errors[0].Error)
+	assert.Equal(t, models.ErrInvalidLocale.Error(), job.Errors[1].Error)
+
+	matrix, err := movies.GetMovieByExternalID("tt0133093")
+	require.NoError(t, err)
+	assert.Equal(t, 136, matrix.Runtime)
+	assert.Equal(t, "en-US", matrix.OriginalLanguage, "languages are stored in canonical form")
+	assert.Equal(t, "A hacker learns the truth.", matrix.Synopsis)
+
+	ndjson := `{"externalId":"tt0133093","title":"The Matrix","year":1999,"directorId":"` + director.String() + `","genreId":"` + genre.String() + `","imageURL":"https://example.com/matrix.jpg","runtime":138,"originalLanguage":"EN-gb"}
+`
+	job, err = importSvc.RunImport(adminID, models.ImportNDJSON, strings.NewReader(ndjson))
+	require.NoError(t, err)
+	assert.Equal(t, 1, job.Updated)
+
+	matrix, err = movies.GetMovieByExternalID("tt0133093")
+	require.NoError(t, err)
+	assert.Equal(t, 138, matrix.Runtime)
+	assert.Equal(t, "en-GB", matrix.OriginalLanguage)
+	assert.Equal(t, "A hacker learns the truth.", matrix.Synopsis, "rows without a field leave it as it is")
+}
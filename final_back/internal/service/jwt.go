@@ -1,17 +1,17 @@
 package service
 
 import (
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/kakimnsnv/ios_final_back/internal/models"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type JWTService interface {
-	CreateJWT(userID primitive.ObjectID, roles []models.Role) (string, string, error)
+	CreateJWT(userID models.ID, roles []models.Role) (string, string, error)
 	RefreshToken(tokenString string) (string, string, error)
-	ParseJWT(tokenString string) (*primitive.ObjectID, []models.Role, error)
+	ParseJWT(tokenString string) (models.ID, []models.Role, error)
 }
 
 type jwtService struct {
@@ -29,17 +29,17 @@ func NewJWTService(secretKey string, tokenDuration time.Duration, refreshTokenDu
 
 }
 
-func (s *jwtService) CreateJWT(userID primitive.ObjectID, roles []models.Role) (string, string, error) {
+func (s *jwtService) CreateJWT(userID models.ID, roles []models.Role) (string, string, error) {
 	claims := jwt.MapClaims{
 		"iss":    "ios_final_back",
-		"userID": userID,
+		"userID": userID.String(),
 		"roles":  roles,
 		"exp":    time.Now().Add(s.tokenDuration).Unix(),
 		"iat":    time.Now().Unix(),
 	}
 	refreshClaims := jwt.MapClaims{
 		"iss":    "ios_final_back",
-		"userID": userID,
+		"userID": userID.String(),
 		"roles":  roles,
 		"exp":    time.Now().Add(s.refreshTokenDuration).Unix(),
 		"iat":    time.Now().Unix(),
@@ -61,24 +61,15 @@ func (s *jwtService) CreateJWT(userID primitive.ObjectID, roles []models.Role) (
 }
 
 func (s *jwtService) RefreshToken(tokenString string) (string, string, error) {
-	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return []byte(s.secretKey), nil
-	})
+	userID, roles, err := s.ParseJWT(tokenString)
 	if err != nil {
 		return "", "", err
 	}
-
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return s.CreateJWT(claims["userID"].(primitive.ObjectID), claims["roles"].([]models.Role))
-	}
-
-	return "", "", jwt.ErrTokenInvalidClaims
+	return s.CreateJWT(userID, roles)
 }
 
-func (s *jwtService) ParseJWT(tokenString string) (*primitive.ObjectID, []models.Role, error) {
+func (s *jwtService) ParseJWT(tokenString string) (models.ID, []models.Role, error) {
+	tokenString = strings.TrimPrefix(tokenString, "Bearer ")
 	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, jwt.ErrSignatureInvalid
@@ -86,17 +77,34 @@ func (s *jwtService) ParseJWT(tokenString string) (*primitive.ObjectID, []models
 		return []byte(s.secretKey), nil
 	})
 	if err != nil {
-		return nil, nil, err
+		return models.NilID, nil, err
 	}
 
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID, err := primitive.ObjectIDFromHex(claims["userID"].(string))
-		if err != nil {
-			return nil, nil, err
-		}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return models.NilID, nil, jwt.ErrTokenInvalidClaims
+	}
+
+	// Claims are decoded as generic JSON values: the user ID is its hex
+	// string and the roles a list of strings.
+	rawUserID, ok := claims["userID"].(string)
+	if !ok {
+		return models.NilID, nil, jwt.ErrTokenInvalidClaims
+	}
+	userID, err := models.ParseID(rawUserID)
+	if err != nil {
+		return models.NilID, nil, err
+	}
 
-		return &userID, claims["roles"].([]models.Role), nil
+	rawRoles, _ := claims["roles"].([]interface{})
+	roles := make([]models.Role, 0, len(rawRoles))
+	for _, rawRole := range rawRoles {
+		role, ok := rawRole.(string)
+		if !ok {
+			return models.NilID, nil, jwt.ErrTokenInvalidClaims
+		}
+		roles = append(roles, models.Role(role))
 	}
 
-	return nil, nil, jwt.ErrTokenInvalidClaims
+	return userID, roles, nil
 }
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJWTRoundTrip(t *testing.T) {
+	svc := NewJWTService("secret", time.Minute, time.Hour)
+	userID := models.NewID()
+	roles := []models.Role{RoleUser, RoleModerator}
+
+	token, refreshToken, err := svc.CreateJWT(userID, roles)
+	require.NoError(t, err)
+
+	parsedID, parsedRoles, err := svc.ParseJWT("Bearer " + token)
+	require.NoError(t, err)
+	assert.Equal(t, userID, parsedID)
+	assert.Equal(t, roles, parsedRoles)
+
+	token, _, err = svc.RefreshToken(refreshToken)
+	require.NoError(t, err)
+	parsedID, parsedRoles, err = svc.ParseJWT(token)
+	require.NoError(t, err)
+	assert.Equal(t, userID, parsedID)
+	assert.Equal(t, roles, parsedRoles)
+
+	_, _, err = NewJWTService("other", time.Minute, time.Hour).ParseJWT(token)
+	assert.Error(t, err)
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"go.uber.org/zap"
+)
+
+// MediaURLPrefix is the path blobs are served under.
+const MediaURLPrefix = "/media/"
+
+// maxImagePixels bounds the size of images decoded in memory, whatever
+// their file size, since a small file can hold a huge image. An image this
+// large takes 64MB once converted to RGBA.
+const maxImagePixels = 16_000_000
+
+// maxConcurrentDecodes bounds how many uploaded images are decoded and
+// resized at once, and so the memory uploads can take together.
+const maxConcurrentDecodes = 2
+
+type MediaService interface {
+	UploadPoster(actorID models.ID, movieID models.ID, version int64, data []byte) (*models.Movie, error)
+	UploadAvatar(actorID models.ID, version int64, data []byte) (*models.User, error)
+	OpenMedia(key string) (*repository.Blob, error)
+}
+
+type mediaSvc struct {
+	log           *zap.Logger
+	blobs         repository.BlobStore
+	movieRepo     repository.MovieRepo
+	userRepo      repository.UserRepo
+	maxPosterSize int64
+	movies        movieWriter
+	decodes       chan struct{}
+}
+
+func NewMediaService(log *zap.Logger, blobs repository.BlobStore, movieRepo repository.MovieRepo, userRepo repository.UserRepo, uow repository.UnitOfWork, maxPosterSize int64) MediaService {
+	return &mediaSvc{
+		log:           log,
+		blobs:         blobs,
+		movieRepo:     movieRepo,
+		userRepo:      userRepo,
+		maxPosterSize: maxPosterSize,
+		movies:        movieWriter{uow: uow},
+		decodes:       make(chan struct{}, maxConcurrentDecodes),
+	}
+}
+
+// UploadPoster stores data as the poster of a movie still at version, along
+// with its variants, and points the movie's image URL at it.
+func (s *mediaSvc) UploadPoster(actorID models.ID, movieID models.ID, version int64, data []byte) (*models.Movie, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !HasPermission(actor, ResourceMovie, ActionUpdate, nil) {
+		return nil, errs.Forbidden
+	}
+
+	poster, err := s.checkImage(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.movieRepo.GetMovie(movieID); err != nil {
+		return nil, err
+	}
+
+	if err := s.storeImage(poster, data); err != nil {
+		return nil, err
+	}
+	return s.movies.update(actorID, movieID, version, &models.UpdateMovieRequest{ImageURL: &poster.URL, Poster: poster}, nil)
+}
+
+// UploadAvatar stores data as the avatar of the actor, who must still be at
+// version, along with its variants. Avatars are checked like posters.
+func (s *mediaSvc) UploadAvatar(actorID models.ID, version int64, data []byte) (*models.User, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+	// UpdateUser always overwrites roles, so they must be the ones of the
+	// version being replaced.
+	if actor.Version != version {
+		return nil, errs.Conflict
+	}
+
+	avatar, err := s.checkImage(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storeImage(avatar, data); err != nil {
+		return nil, err
+	}
+	return s.userRepo.UpdateUser(actorID, actorID, version, &models.User{Roles: actor.Roles, Avatar: avatar})
+}
+
+// checkImage describes data if it is an image that may be uploaded. The
+// image type is told from its content, not from what the client claims it
+// is.
+func (s *mediaSvc) checkImage(data []byte) (*models.Poster, error) {
+	if int64(len(data)) > s.maxPosterSize {
+		return nil, models.ErrImageTooLarge
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := models.PosterTypes[contentType]
+	if !ok {
+		return nil, models.ErrUnsupportedImage
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, models.ErrUnsupportedImage
+	}
+	if config.Width*config.Height > maxImagePixels {
+		return nil, models.ErrImageTooLarge
+	}
+
+	key := repository.BlobKey(data, ext)
+	return &models.Poster{
+		Key:         key,
+		URL:         MediaURLPrefix + key,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Width:       config.Width,
+		Height:      config.Height,
+	}, nil
+}
+
+// storeImage stores data, described by checkImage as poster, and its
+// variants. It waits while too many other images are being decoded.
+func (s *mediaSvc) storeImage(poster *models.Poster, data []byte) error {
+	s.decodes <- struct{}{}
+	defer func() { <-s.decodes }()
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return models.ErrUnsupportedImage
+	}
+	if err := s.blobs.PutBlob(poster.Key, data); err != nil {
+		return err
+	}
+	return s.addVariants(poster, toRGBA(img))
+}
+
+// addVariants stores the variants of an image described by poster and
+// fills in its placeholders, which are computed from the smallest variant.
+func (s *mediaSvc) addVariants(poster *models.Poster, img *image.RGBA) error {
+	smallest := img
+	for _, v := range models.ImageVariants {
+		variant := models.ImageVariant{
+			Name:        v.Name,
+			Key:         poster.Key,
+			URL:         poster.URL,
+			ContentType: poster.ContentType,
+			Size:        poster.Size,
+			Width:       poster.Width,
+			Height:      poster.Height,
+		}
+		if poster.Width > v.Width {
+			scaled := resizeToWidth(img, v.Width)
+			data, contentType, ext, err := encodeVariant(scaled)
+			if err != nil {
+				return err
+			}
+			key := repository.BlobKey(data, ext)
+			if err := s.blobs.PutBlob(key, data); err != nil {
+				return err
+			}
+			variant = models.ImageVariant{
+				Name:        v.Name,
+				Key:         key,
+				URL:         MediaURLPrefix + key,
+				ContentType: contentType,
+				Size:        int64(len(data)),
+				Width:       scaled.Rect.Dx(),
+				Height:      scaled.Rect.Dy(),
+			}
+			if scaled.Rect.Dx() < smallest.Rect.Dx() {
+				smallest = scaled
+			}
+		}
+		poster.Variants = append(poster.Variants, variant)
+	}
+
+	xComponents, yComponents := 4, 3
+	if poster.Height > poster.Width {
+		xComponents, yComponents = 3, 4
+	}
+	poster.BlurHash = blurHash(smallest, xComponents, yComponents)
+	poster.DominantColor = dominantColor(smallest)
+	return nil
+}
+
+func (s *mediaSvc) OpenMedia(key string) (*repository.Blob, error) {
+	return s.blobs.OpenBlob(key)
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestUploadPoster(t *testing.T) {
+	users := repository.NewMemoryUserRepo()
+	movies := repository.NewMemoryMovieRepo()
+	uow := repository.NewMemoryUnitOfWork(repository.Repos{Users: users, Movies: movies, Revisions: repository.NewMemoryRevisionRepo()})
+	mediaSvc := NewMediaService(zap.NewNop(), repository.NewS3BlobStore(repository.NewMemoryS3Client(), "media"), movies, users, uow, 64*1024)
+
+	adminID, err := users.CreateUser(models.NilID, &models.User{Username: "admin", Roles: []models.Role{RoleAdmin}})
+	require.NoError(t, err)
+	userID, err := users.CreateUser(models.NilID, &models.User{Username: "neo", Roles: []models.Role{RoleUser}})
+	require.NoError(t, err)
+	movieID, err := movies.CreateMovie(adminID, &models.CreateMovieRequest{Title: "The Matrix", Year: 1999, ImageURL: "https://example.com/matrix.jpg"})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 20, 30))))
+	poster := buf.Bytes()
+
+	_, err = mediaSvc.UploadPoster(userID, movieID, 1, poster)
+	assert.ErrorIs(t, err, errs.Forbidden)
+	_, err = mediaSvc.UploadPoster(adminID, movieID, 1, []byte("GIF89a"))
+	assert.ErrorIs(t, err, models.ErrUnsupportedImage)
+	_, err = mediaSvc.UploadPoster(adminID, movieID, 1, []byte("<html>not an image</html>"))
+	assert.ErrorIs(t, err, models.ErrUnsupportedImage)
+	_, err = mediaSvc.UploadPoster(adminID, movieID, 1, make([]byte, 64*1024+1))
+	assert.ErrorIs(t, err, models.ErrImageTooLarge)
+	_, err = mediaSvc.UploadPoster(adminID, models.NewID(), 1, poster)
+	assert.ErrorIs(t, err, errs.NotFound)
+
+	movie, err := mediaSvc.UploadPoster(adminID, movieID, 1, poster)
+	require.NoError(t, err)
+	require.NotNil(t, movie.Poster)
+	assert.Equal(t, repository.BlobKey(poster, ".png"), movie.Poster.Key)
+	assert.Equal(t, "image/png", movie.Poster.ContentType)
+	assert.Equal(t, 20, movie.Poster.Width)
+	assert.Equal(t, 30, movie.Poster.Height)
+	assert.Equal(t, movie.Poster.URL, movie.ImageURL)
+	assert.Equal(t, int64(2), movie.Version)
+
+	_, err = mediaSvc.UploadPoster(adminID, movieID, 1, poster)
+	assert.ErrorIs(t, err, errs.Conflict)
+
+	blob, err := mediaSvc.OpenMedia(movie.Poster.Key)
+	require.NoError(t, err)
+	defer blob.Close()
+	stored, err := io.ReadAll(blob)
+	require.NoError(t, err)
+	assert.Equal(t, poster, stored)
+
+	// A poster smaller than every variant stands in for all of them.
+	require.Len(t, movie.Poster.Variants, len(models.ImageVariants))
+	for _, variant := range movie.Poster.Variants {
+		assert.Equal(t, movie.Poster.Key, variant.Key, variant.Name)
+	}
+	assert.NotEmpty(t, movie.Poster.BlurHash)
+	assert.Equal(t, "#000000", movie.Poster.DominantColor)
+
+	buf.Reset()
+	large := image.NewRGBA(image.Rect(0, 0, 1200, 1800))
+	for i := range large.Pix {
+		large.Pix[i] = 0xc0
+		if i%4 == 3 {
+			large.Pix[i] = 0xff
+		}
+	}
+	require.NoError(t, png.Encode(&buf, large))
+	movie, err = mediaSvc.UploadPoster(adminID, movieID, 2, buf.Bytes())
+	require.NoError(t, err)
+
+	sizes := map[string][2]int{}
+	for _, variant := range movie.Poster.Variants {
+		sizes[variant.Name] = [2]int{variant.Width, variant.Height}
+		assert.Equal(t, "image/jpeg", variant.ContentType, "opaque variants are JPEGs")
+
+		blob, err := mediaSvc.OpenMedia(variant.Key)
+		require.NoError(t, err)
+		img, _, err := image.Decode(blob)
+		blob.Close()
+		require.NoError(t, err)
+		assert.Equal(t, variant.Width, img.Bounds().Dx())
+	}
+	assert.Equal(t, map[string][2]int{
+		models.VariantThumbnail: {160, 240},
+		models.VariantMedium:    {480, 720},
+		models.VariantLarge:     {1080, 1620},
+	}, sizes)
+	assert.Equal(t, "#c0c0c0", movie.Poster.DominantColor)
+}
+
+func TestUploadAvatar(t *testing.T) {
+	users := repository.NewMemoryUserRepo()
+	movies := repository.NewMemoryMovieRepo()
+	uow := repository.NewMemoryUnitOfWork(repository.Repos{Users: users, Movies: movies, Revisions: repository.NewMemoryRevisionRepo()})
+	mediaSvc := NewMediaService(zap.NewNop(), repository.NewS3BlobStore(repository.NewMemoryS3Client(), "media"), movies, users, uow, 64*1024)
+
+	userID, err := users.CreateUser(models.NilID, &models.User{Username: "neo", Roles: []models.Role{RoleUser}})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 400, 400))))
+	avatar := buf.Bytes()
+
+	_, err = mediaSvc.UploadAvatar(userID, 1, []byte("<html>not an image</html>"))
+	assert.ErrorIs(t, err, models.ErrUnsupportedImage)
+	_, err = mediaSvc.UploadAvatar(userID, 1, make([]byte, 64*1024+1))
+	assert.ErrorIs(t, err, models.ErrImageTooLarge)
+	_, err = mediaSvc.UploadAvatar(models.NewID(), 1, avatar)
+	assert.ErrorIs(t, err, errs.NotFound)
+
+	user, err := mediaSvc.UploadAvatar(userID, 1, avatar)
+	require.NoError(t, err)
+	require.NotNil(t, user.Avatar)
+	assert.Equal(t, repository.BlobKey(avatar, ".png"), user.AvatarKey)
+	assert.Equal(t, user.AvatarKey, user.Avatar.Key)
+	assert.Equal(t, 400, user.Avatar.Width)
+	assert.Equal(t, []models.Role{RoleUser}, user.Roles, "roles are kept")
+	assert.Equal(t, int64(2), user.Version)
+
+	_, err = mediaSvc.UploadAvatar(userID, 1, avatar)
+	assert.ErrorIs(t, err, errs.Conflict)
+
+	sizes := map[string]int{}
+	for _, variant := range user.Avatar.Variants {
+		sizes[variant.Name] = variant.Width
+		blob, err := mediaSvc.OpenMedia(variant.Key)
+		require.NoError(t, err, variant.Name)
+		blob.Close()
+	}
+	assert.Equal(t, map[string]int{
+		models.VariantThumbnail: 160,
+		models.VariantMedium:    400,
+		models.VariantLarge:     400,
+	}, sizes)
+	assert.NotEmpty(t, user.Avatar.BlurHash)
+	assert.Equal(t, "#000000", user.Avatar.DominantColor)
+}
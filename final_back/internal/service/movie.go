@@ -2,44 +2,150 @@ package service
 
 import (
 	"errors"
+	"io"
+	"slices"
 
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
 	"github.com/kakimnsnv/ios_final_back/internal/models"
 	"github.com/kakimnsnv/ios_final_back/internal/repository"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 )
 
 type MovieService interface {
-	ListMovies() ([]*models.Movie, error)
-	GetMovie(id *primitive.ObjectID) (*models.Movie, error)
-	CreateMovie(actorID *primitive.ObjectID, movie *models.CreateMovieRequest) (*primitive.ObjectID, error)
-	UpdateMovie(actorID *primitive.ObjectID, id *primitive.ObjectID, movie *models.UpdateMovieRequest) (*models.Movie, error)
-	DeleteMovie(actorID *primitive.ObjectID, id *primitive.ObjectID) error
+	ListMovies(opts models.ListOptions) (models.Page[*models.Movie], error)
+	GetMovie(id models.ID) (*models.Movie, error)
+	CreateMovie(actorID models.ID, movie *models.CreateMovieRequest) (models.ID, error)
+	UpdateMovie(actorID models.ID, id models.ID, version int64, movie *models.UpdateMovieRequest) (*models.Movie, error)
+	DeleteMovie(actorID models.ID, id models.ID) error
+	ListDeletedMovies(actorID models.ID) ([]*models.Movie, error)
+	RestoreMovie(actorID models.ID, id models.ID) (*models.Movie, error)
+	SearchMovies(query string, limit int) ([]*models.MovieSearchResult, error)
+	ExpandMovies(movies []*models.Movie, expand []string) error
+	// ListMovieFacets counts the movies ListMovies would list for opts, page
+	// limit aside, by each of the facets asked for.
+	ListMovieFacets(opts models.ListOptions, facets []string) (models.MovieFacets, error)
+	ExportMovies(format string, w io.Writer) error
+	// ListMovieHistory lists the revisions of a movie that is not in the
+	// trash.
+	ListMovieHistory(movieID models.ID, opts models.ListOptions) (models.Page[*models.MovieRevision], error)
+	// RevertMovie sets the fields of a movie still at version back to their
+	// values at an earlier version, recording the revert as a new revision.
+	RevertMovie(actorID models.ID, id models.ID, version int64, toVersion int64) (*models.Movie, error)
+	// FindDuplicates reports the pairs of live movies that look like the
+	// same film, most likely first.
+	FindDuplicates(actorID models.ID) ([]*models.Duplicate, error)
+	// MergeMovies moves the reviews, credits, tags, releases and watchlist
+	// entries of a duplicate to the movie id and moves the duplicate to the
+	// trash, all at once.
+	MergeMovies(actorID models.ID, id models.ID, duplicateID models.ID) (*models.Movie, error)
 }
 
 type movieSvc struct {
-	log      *zap.Logger
-	repo     repository.MovieRepo
-	userRepo repository.UserRepo
+	log         *zap.Logger
+	repo        repository.MovieRepo
+	userRepo    repository.UserRepo
+	personRepo  repository.PersonRepo
+	genreRepo   repository.GenreRepo
+	creditRepo  repository.CreditRepo
+	tagRepo     repository.TagRepo
+	releaseRepo repository.ReleaseRepo
+	search      repository.SearchIndex
+	facets      repository.FacetCounter
+
+	revisionRepo repository.RevisionRepo
+	movies       movieWriter
 }
 
-func NewMovieService(log *zap.Logger, repo repository.MovieRepo, userRepo repository.UserRepo) MovieService {
+func NewMovieService(log *zap.Logger, repo repository.MovieRepo, userRepo repository.UserRepo, personRepo repository.PersonRepo, genreRepo repository.GenreRepo, creditRepo repository.CreditRepo, tagRepo repository.TagRepo, releaseRepo repository.ReleaseRepo, revisionRepo repository.RevisionRepo, search repository.SearchIndex, facets repository.FacetCounter, uow repository.UnitOfWork) MovieService {
 	return &movieSvc{
-		log:      log,
-		repo:     repo,
-		userRepo: userRepo,
+		log:          log,
+		repo:         repo,
+		userRepo:     userRepo,
+		personRepo:   personRepo,
+		genreRepo:    genreRepo,
+		creditRepo:   creditRepo,
+		tagRepo:      tagRepo,
+		releaseRepo:  releaseRepo,
+		search:       search,
+		facets:       facets,
+		revisionRepo: revisionRepo,
+		movies:       movieWriter{uow: uow},
 	}
 }
 
-func (s *movieSvc) ListMovies() ([]*models.Movie, error) {
-	movies, err := s.repo.ListMovies()
+func (s *movieSvc) ListMovies(opts models.ListOptions) (models.Page[*models.Movie], error) {
+	opts, err := s.resolveFilters(opts)
 	if err != nil {
-		return nil, err
+		return models.Page[*models.Movie]{}, err
 	}
-	return movies, nil
+	return s.repo.ListMovies(opts)
 }
 
-func (s *movieSvc) GetMovie(id *primitive.ObjectID) (*models.Movie, error) {
+// resolveFilters replaces the castId, tagId and releasedIn filters of opts,
+// which movies do not store, with the IDs of the movies each person is
+// credited in, each tag is approved for or that have come out in each
+// country by today. Several such filters keep the movies they all share.
+func (s *movieSvc) resolveFilters(opts models.ListOptions) (models.ListOptions, error) {
+	filters := []models.Filter{}
+	for _, filter := range opts.Filters {
+		if filter.Field != models.FilterCastID && filter.Field != models.FilterTagID && filter.Field != models.FilterReleasedIn {
+			filters = append(filters, filter)
+			continue
+		}
+		if filter.Op != models.OpEq {
+			return opts, models.ErrInvalidFilter
+		}
+
+		var movieIDs []models.ID
+		if filter.Field == models.FilterReleasedIn {
+			country, err := models.ParseCountry(filter.Value.(string))
+			if err != nil {
+				return opts, models.ErrInvalidFilter
+			}
+			releases, err := s.releaseRepo.ListReleases(models.ListOptions{Filters: []models.Filter{
+				{Field: "country", Op: models.OpEq, Value: country},
+				{Field: "date", Op: models.OpLte, Value: today()},
+			}})
+			if err != nil {
+				return opts, err
+			}
+			for _, release := range releases.Items {
+				movieIDs = append(movieIDs, release.MovieID)
+			}
+		} else if filter.Field == models.FilterCastID {
+			credits, err := s.creditRepo.ListPersonCredits(filter.Value.(models.ID))
+			if err != nil {
+				return opts, err
+			}
+			for _, credit := range credits {
+				movieIDs = append(movieIDs, credit.MovieID)
+			}
+		} else {
+			movieTags, err := s.tagRepo.ListMovieTags(models.ListOptions{Filters: []models.Filter{
+				{Field: "tagId", Op: models.OpEq, Value: filter.Value},
+				{Field: "status", Op: models.OpEq, Value: string(models.SuggestionApproved)},
+			}})
+			if err != nil {
+				return opts, err
+			}
+			for _, t := range movieTags.Items {
+				movieIDs = append(movieIDs, t.MovieID)
+			}
+		}
+
+		ids := []models.ID{}
+		for _, id := range movieIDs {
+			if !slices.Contains(ids, id) && (opts.IDs == nil || slices.Contains(opts.IDs, id)) {
+				ids = append(ids, id)
+			}
+		}
+		opts.IDs = ids
+	}
+	opts.Filters = filters
+	return opts, nil
+}
+
+func (s *movieSvc) GetMovie(id models.ID) (*models.Movie, error) {
 	movie, err := s.repo.GetMovie(id)
 	if err != nil {
 		return nil, err
@@ -47,20 +153,30 @@ func (s *movieSvc) GetMovie(id *primitive.ObjectID) (*models.Movie, error) {
 	return movie, nil
 }
 
-func (s *movieSvc) CreateMovie(actorID *primitive.ObjectID, movie *models.CreateMovieRequest) (*primitive.ObjectID, error) {
+func (s *movieSvc) CreateMovie(actorID models.ID, movie *models.CreateMovieRequest) (models.ID, error) {
 	actor, err := s.userRepo.GetUserByID(actorID)
 	if err != nil {
-		return nil, err
+		return models.NilID, err
 	}
 
 	if !HasPermission(actor, ResourceMovie, ActionCreate, nil) {
-		return nil, errors.New("unauthorized")
+		return models.NilID, errors.New("unauthorized")
 	}
 
-	return s.repo.CreateMovie(movie)
+	if err := checkMovieReferences(s.personRepo, s.genreRepo, movie.DirectorID, movie.GenreID); err != nil {
+		return models.NilID, err
+	}
+
+	created, err := s.movies.create(actorID, movie)
+	if err != nil {
+		return models.NilID, err
+	}
+
+	s.index(created)
+	return created.ID, nil
 }
 
-func (s *movieSvc) UpdateMovie(actorID *primitive.ObjectID, id *primitive.ObjectID, movie *models.UpdateMovieRequest) (*models.Movie, error) {
+func (s *movieSvc) UpdateMovie(actorID models.ID, id models.ID, version int64, movie *models.UpdateMovieRequest) (*models.Movie, error) {
 	actor, err := s.userRepo.GetUserByID(actorID)
 	if err != nil {
 		return nil, err
@@ -70,10 +186,20 @@ func (s *movieSvc) UpdateMovie(actorID *primitive.ObjectID, id *primitive.Object
 		return nil, errors.New("unauthorized")
 	}
 
-	return s.repo.UpdateMovie(id, movie)
+	if err := checkMovieReferences(s.personRepo, s.genreRepo, movie.DirectorID, movie.GenreID); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.movies.update(actorID, id, version, movie, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	s.index(updated)
+	return updated, nil
 }
 
-func (s *movieSvc) DeleteMovie(actorID *primitive.ObjectID, id *primitive.ObjectID) error {
+func (s *movieSvc) DeleteMovie(actorID models.ID, id models.ID) error {
 	actor, err := s.userRepo.GetUserByID(actorID)
 	if err != nil {
 		return err
@@ -83,5 +209,148 @@ func (s *movieSvc) DeleteMovie(actorID *primitive.ObjectID, id *primitive.Object
 		return errors.New("unauthorized")
 	}
 
-	return s.repo.DeleteMovie(id)
+	if err := s.movies.delete(actorID, id); err != nil {
+		return err
+	}
+
+	if err := s.search.RemoveMovie(id); err != nil {
+		s.log.Error("failed to remove movie from search index", zap.Error(err))
+	}
+	return nil
+}
+
+func (s *movieSvc) ListDeletedMovies(actorID models.ID) ([]*models.Movie, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !HasPermission(actor, ResourceMovie, ActionRestore, nil) {
+		return nil, errs.Forbidden
+	}
+
+	return s.repo.ListDeletedMovies()
+}
+
+func (s *movieSvc) RestoreMovie(actorID models.ID, id models.ID) (*models.Movie, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !HasPermission(actor, ResourceMovie, ActionRestore, nil) {
+		return nil, errs.Forbidden
+	}
+
+	restored, err := s.movies.restore(actorID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.index(restored)
+	return restored, nil
+}
+
+func (s *movieSvc) SearchMovies(query string, limit int) ([]*models.MovieSearchResult, error) {
+	hits, err := s.search.SearchMovies(query, limit)
+	if err != nil {
+		s.log.Error("failed to search movies", zap.Error(err))
+		return nil, err
+	}
+
+	results := make([]*models.MovieSearchResult, 0, len(hits))
+	for _, hit := range hits {
+		movie, err := s.repo.GetMovie(hit.ID)
+		if err == errs.NotFound {
+			// Deleted since it was indexed.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, &models.MovieSearchResult{Movie: movie, Score: hit.Score})
+	}
+	return results, nil
+}
+
+// checkMovieReferences returns errs.InvalidReference unless the director and
+// genre a movie is given, if any, exist.
+func checkMovieReferences(personRepo repository.PersonRepo, genreRepo repository.GenreRepo, directorID, genreID *models.ID) error {
+	if directorID != nil {
+		if _, err := personRepo.GetPerson(*directorID); err == errs.NotFound {
+			return errs.InvalidReference
+		} else if err != nil {
+			return err
+		}
+	}
+	if genreID != nil {
+		if _, err := genreRepo.GetGenre(*genreID); err == errs.NotFound {
+			return errs.InvalidReference
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExpandMovies fills in the references of movies named in expand, fetching
+// each kind of reference in one batch. References to records that no longer
+// exist are left empty.
+func (s *movieSvc) ExpandMovies(movies []*models.Movie, expand []string) error {
+	for _, name := range expand {
+		switch name {
+		case models.ExpandDirector:
+			ids := referencedIDs(movies, func(m *models.Movie) *models.ID { return m.DirectorID })
+			people, err := s.personRepo.GetPeople(ids)
+			if err != nil {
+				return err
+			}
+			byID := make(map[models.ID]*models.Person, len(people))
+			for _, person := range people {
+				byID[person.ID] = person
+			}
+			for _, movie := range movies {
+				if movie.DirectorID != nil {
+					movie.Director = byID[*movie.DirectorID]
+				}
+			}
+		case models.ExpandGenre:
+			ids := referencedIDs(movies, func(m *models.Movie) *models.ID { return m.GenreID })
+			genres, err := s.genreRepo.GetGenres(ids)
+			if err != nil {
+				return err
+			}
+			byID := make(map[models.ID]*models.Genre, len(genres))
+			for _, genre := range genres {
+				byID[genre.ID] = genre
+			}
+			for _, movie := range movies {
+				if movie.GenreID != nil {
+					movie.Genre = byID[*movie.GenreID]
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// referencedIDs returns the distinct IDs ref picks out of movies.
+func referencedIDs(movies []*models.Movie, ref func(*models.Movie) *models.ID) []models.ID {
+	seen := map[models.ID]bool{}
+	ids := []models.ID{}
+	for _, movie := range movies {
+		if id := ref(movie); id != nil && !seen[*id] {
+			seen[*id] = true
+			ids = append(ids, *id)
+		}
+	}
+	return ids
+}
+
+// index keeps the search index in step with a movie write. The write has
+// already succeeded, so a failure is only logged.
+func (s *movieSvc) index(movie *models.Movie) {
+	if err := s.search.IndexMovie(movie); err != nil {
+		s.log.Error("failed to index movie", zap.Error(err))
+	}
 }
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMovieReferences(t *testing.T) {
+	users := repository.NewMemoryUserRepo()
+	movies := repository.NewMemoryMovieRepo()
+	people := repository.NewMemoryPersonRepo()
+	genres := repository.NewMemoryGenreRepo()
+	credits := repository.NewMemoryCreditRepo()
+	revisions := repository.NewMemoryRevisionRepo()
+	uow := repository.NewMemoryUnitOfWork(repository.Repos{Users: users, Movies: movies, Reviews: repository.NewMemoryReviewRepo(), Credits: credits, Revisions: revisions})
+	movieSvc := NewMovieService(zap.NewNop(), movies, users, people, genres, credits, repository.NewMemoryTagRepo(), repository.NewMemoryReleaseRepo(), revisions, repository.NewMemorySearchIndex(), repository.NewMemoryFacetCounter(movies, repository.NewMemoryTagRepo()), uow)
+	personSvc := NewPersonService(zap.NewNop(), people, movies, credits, users)
+	genreSvc := NewGenreService(zap.NewNop(), genres, movies, users)
+
+	adminID, err := users.CreateUser(models.NilID, &models.User{Username: "admin", Roles: []models.Role{RoleAdmin}})
+	require.NoError(t, err)
+	directorID, err := people.CreatePerson(adminID, &models.CreatePersonRequest{Name: "Lana Wachowski"})
+	require.NoError(t, err)
+	genreID, err := genres.CreateGenre(adminID, &models.CreateGenreRequest{Name: "Science Fiction"})
+	require.NoError(t, err)
+
+	unknown := models.NewID()
+	_, err = movieSvc.CreateMovie(adminID, &models.CreateMovieRequest{Title: "matrix", DirectorID: &unknown, GenreID: &genreID})
+	assert.ErrorIs(t, err, errs.InvalidReference)
+	_, err = movieSvc.CreateMovie(adminID, &models.CreateMovieRequest{Title: "matrix", DirectorID: &directorID, GenreID: &unknown})
+	assert.ErrorIs(t, err, errs.InvalidReference)
+
+	movieID, err := movieSvc.CreateMovie(adminID, &models.CreateMovieRequest{Title: "matrix", DirectorID: &directorID, GenreID: &genreID})
+	require.NoError(t, err)
+	_, err = movieSvc.UpdateMovie(adminID, movieID, 1, &models.UpdateMovieRequest{DirectorID: &unknown})
+	assert.ErrorIs(t, err, errs.InvalidReference)
+
+	movie, err := movieSvc.GetMovie(movieID)
+	require.NoError(t, err)
+	require.NoError(t, movieSvc.ExpandMovies([]*models.Movie{movie}, []string{models.ExpandDirector, models.ExpandGenre}))
+	require.NotNil(t, movie.Director)
+	assert.Equal(t, "Lana Wachowski", movie.Director.Name)
+	require.NotNil(t, movie.Genre)
+	assert.Equal(t, "Science Fiction", movie.Genre.Name)
+
+	assert.ErrorIs(t, personSvc.DeletePerson(adminID, directorID), errs.InUse)
+	assert.ErrorIs(t, genreSvc.DeleteGenre(adminID, genreID), errs.InUse)
+
+	require.NoError(t, movieSvc.DeleteMovie(adminID, movieID))
+	assert.NoError(t, personSvc.DeletePerson(adminID, directorID), "a deleted movie no longer holds on to its director")
+	assert.NoError(t, genreSvc.DeleteGenre(adminID, genreID))
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"go.uber.org/zap"
+)
+
+type PersonService interface {
+	ListPeople(opts models.ListOptions) (models.Page[*models.Person], error)
+	GetPerson(id models.ID) (*models.Person, error)
+	CreatePerson(actorID models.ID, req *models.CreatePersonRequest) (models.ID, error)
+	UpdatePerson(actorID models.ID, id models.ID, version int64, req *models.UpdatePersonRequest) (*models.Person, error)
+	DeletePerson(actorID models.ID, id models.ID) error
+}
+
+type personSvc struct {
+	log        *zap.Logger
+	repo       repository.PersonRepo
+	movieRepo  repository.MovieRepo
+	creditRepo repository.CreditRepo
+	userRepo   repository.UserRepo
+}
+
+func NewPersonService(log *zap.Logger, repo repository.PersonRepo, movieRepo repository.MovieRepo, creditRepo repository.CreditRepo, userRepo repository.UserRepo) PersonService {
+	return &personSvc{
+		log:        log,
+		repo:       repo,
+		movieRepo:  movieRepo,
+		creditRepo: creditRepo,
+		userRepo:   userRepo,
+	}
+}
+
+func (s *personSvc) ListPeople(opts models.ListOptions) (models.Page[*models.Person], error) {
+	return s.repo.ListPeople(opts)
+}
+
+func (s *personSvc) GetPerson(id models.ID) (*models.Person, error) {
+	return s.repo.GetPerson(id)
+}
+
+func (s *personSvc) CreatePerson(actorID models.ID, req *models.CreatePersonRequest) (models.ID, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return models.NilID, err
+	}
+
+	if !HasPermission(actor, ResourcePerson, ActionCreate, nil) {
+		return models.NilID, errs.Forbidden
+	}
+
+	return s.repo.CreatePerson(actorID, req)
+}
+
+func (s *personSvc) UpdatePerson(actorID models.ID, id models.ID, version int64, req *models.UpdatePersonRequest) (*models.Person, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !HasPermission(actor, ResourcePerson, ActionUpdate, nil) {
+		return nil, errs.Forbidden
+	}
+
+	return s.repo.UpdatePerson(actorID, id, version, req)
+}
+
+// DeletePerson refuses with errs.InUse to delete someone who still directs a
+// movie or has credits, even in movies in the trash.
+func (s *personSvc) DeletePerson(actorID models.ID, id models.ID) error {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return err
+	}
+
+	if !HasPermission(actor, ResourcePerson, ActionDelete, nil) {
+		return errs.Forbidden
+	}
+
+	used, err := referencedByMovies(s.movieRepo, "directorId", id)
+	if err != nil {
+		s.log.Error("failed to check movies of person", zap.Error(err))
+		return err
+	}
+	if used {
+		return errs.InUse
+	}
+
+	credits, err := s.creditRepo.ListPersonCredits(id)
+	if err != nil {
+		s.log.Error("failed to check credits of person", zap.Error(err))
+		return err
+	}
+	if len(credits) > 0 {
+		return errs.InUse
+	}
+
+	return s.repo.DeletePerson(id)
+}
+
+// referencedByMovies reports whether a live movie refers to id in field.
+func referencedByMovies(movies repository.MovieRepo, field string, id models.ID) (bool, error) {
+	page, err := movies.ListMovies(models.ListOptions{
+		Filters: []models.Filter{{Field: field, Op: models.OpEq, Value: id}},
+		Limit:   1,
+	})
+	if err != nil {
+		return false, err
+	}
+	return len(page.Items) > 0, nil
+}
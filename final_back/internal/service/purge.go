@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// PurgeService empties the trash of everything that has been in it for longer
+// than the retention period.
+type PurgeService interface {
+	Purge() error
+	// Run purges every interval until ctx is done.
+	Run(ctx context.Context, interval time.Duration)
+}
+
+type purgeSvc struct {
+	log       *zap.Logger
+	uow       repository.UnitOfWork
+	retention time.Duration
+}
+
+func NewPurgeService(log *zap.Logger, uow repository.UnitOfWork, retention time.Duration) PurgeService {
+	return &purgeSvc{
+		log:       log,
+		uow:       uow,
+		retention: retention,
+	}
+}
+
+func (s *purgeSvc) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Purge(); err != nil {
+			s.log.Error("failed to purge trash", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge hard-deletes expired reviews, movies and users in one unit of work.
+// A purged movie takes all of its reviews, credits, history, translations
+// and tags with it, and a purged user's reviews are anonymized.
+func (s *purgeSvc) Purge() error {
+	cutoff := time.Now().Add(-s.retention)
+	expired := func(deleted *primitive.DateTime) bool {
+		return deleted != nil && deleted.Time().Before(cutoff)
+	}
+
+	var reviewCount, movieCount, userCount int
+	err := s.uow.Do(func(repos repository.Repos) error {
+		reviewCount, movieCount, userCount = 0, 0, 0
+
+		reviews, err := repos.Reviews.ListDeletedReviews()
+		if err != nil {
+			return err
+		}
+		for _, review := range reviews {
+			if !expired(review.Deleted) {
+				continue
+			}
+			if err := repos.Reviews.PurgeReview(review.ID); err != nil {
+				return err
+			}
+			reviewCount++
+		}
+
+		movies, err := repos.Movies.ListDeletedMovies()
+		if err != nil {
+			return err
+		}
+		for _, movie := range movies {
+			if !expired(movie.Deleted) {
+				continue
+			}
+			if err := repos.Reviews.DeleteReviewsByMovieID(movie.ID); err != nil {
+				return err
+			}
+			if err := repos.Credits.DeleteCreditsByMovieID(movie.ID); err != nil {
+				return err
+			}
+			if err := repos.Revisions.DeleteMovieRevisions(movie.ID); err != nil {
+				return err
+			}
+			if err := repos.Translations.DeleteTranslations(models.TranslationMovie, movie.ID); err != nil {
+				return err
+			}
+			if err := repos.Tags.DeleteMovieTags(movie.ID); err != nil {
+				return err
+			}
+			if err := repos.Releases.DeleteReleasesByMovieID(movie.ID); err != nil {
+				return err
+			}
+			if err := repos.Watchlists.DeleteWatchlistEntriesByMovieID(movie.ID); err != nil {
+				return err
+			}
+			if err := repos.Movies.PurgeMovie(movie.ID); err != nil {
+				return err
+			}
+			movieCount++
+		}
+
+		users, err := repos.Users.ListDeletedUsers()
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			if !expired(user.Deleted) {
+				continue
+			}
+			// The public reviews hidden with their owner count again now
+			// that they are anonymous.
+			shown, err := repos.Reviews.AnonymizeReviews(user.ID)
+			if err != nil {
+				return err
+			}
+			for _, review := range shown {
+				if err := repos.Movies.UpdateRatings(review.MovieID, 0, models.CountedRating(review)); err != nil {
+					return err
+				}
+			}
+			if err := repos.Watchlists.DeleteWatchlist(user.ID); err != nil {
+				return err
+			}
+			if err := repos.Users.PurgeUser(user.ID); err != nil {
+				return err
+			}
+			userCount++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if reviewCount+movieCount+userCount > 0 {
+		s.log.Info("purged trash",
+			zap.Int("reviews", reviewCount),
+			zap.Int("movies", movieCount),
+			zap.Int("users", userCount))
+	}
+	return nil
+}
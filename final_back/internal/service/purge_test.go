@@ -0,0 +1,187 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestPurge(t *testing.T) {
+	repos := repository.Repos{
+		Users:        repository.NewMemoryUserRepo(),
+		Movies:       repository.NewMemoryMovieRepo(),
+		Reviews:      repository.NewMemoryReviewRepo(),
+		Credits:      repository.NewMemoryCreditRepo(),
+		Revisions:    repository.NewMemoryRevisionRepo(),
+		Translations: repository.NewMemoryTranslationRepo(),
+		Tags:         repository.NewMemoryTagRepo(),
+		Releases:     repository.NewMemoryReleaseRepo(),
+		Watchlists:   repository.NewMemoryWatchlistRepo(),
+	}
+	uow := repository.NewMemoryUnitOfWork(repos)
+
+	userID, err := repos.Users.CreateUser(models.NilID, &models.User{Username: "neo"})
+	require.NoError(t, err)
+	movieID, err := repos.Movies.CreateMovie(models.NilID, &models.CreateMovieRequest{Title: "matrix"})
+	require.NoError(t, err)
+	otherMovieID, err := repos.Movies.CreateMovie(models.NilID, &models.CreateMovieRequest{Title: "reloaded"})
+	require.NoError(t, err)
+	movieReview, err := repos.Reviews.CreateReview(models.NilID, &models.Review{MovieID: movieID, OwnerID: models.NewID(), Rating: 8})
+	require.NoError(t, err)
+	userReview, err := repos.Reviews.CreateReview(models.NilID, &models.Review{MovieID: otherMovieID, OwnerID: userID, Rating: 9})
+	require.NoError(t, err)
+	_, err = repos.Revisions.CreateMovieRevision(models.NilID, &models.MovieRevision{MovieID: movieID, Version: 1, Action: models.RevisionCreated})
+	require.NoError(t, err)
+	_, err = repos.Translations.PutTranslation(models.NilID, &models.Translation{Kind: models.TranslationMovie, RecordID: movieID, Locale: "ru", Fields: map[string]string{"title": "Матрица"}})
+	require.NoError(t, err)
+	_, err = repos.Watchlists.AddToWatchlist(userID, otherMovieID)
+	require.NoError(t, err)
+	_, err = repos.Watchlists.AddToWatchlist(models.NewID(), movieID)
+	require.NoError(t, err)
+	release, err := repos.Releases.CreateRelease(models.NilID, movieID, &models.CreateReleaseRequest{Country: "US", Date: "1999-03-31", Type: models.ReleaseTheatrical})
+	require.NoError(t, err)
+
+	require.NoError(t, repos.Users.DeleteUser(models.NilID, userID))
+	require.NoError(t, repos.Movies.DeleteMovie(models.NilID, movieID))
+
+	// Nothing has been in the trash for an hour yet.
+	require.NoError(t, NewPurgeService(zap.NewNop(), uow, time.Hour).Purge())
+	_, err = repos.Users.RestoreUser(models.NilID, userID)
+	require.NoError(t, err)
+	require.NoError(t, repos.Users.DeleteUser(models.NilID, userID))
+
+	require.NoError(t, NewPurgeService(zap.NewNop(), uow, 0).Purge())
+
+	users, err := repos.Users.ListDeletedUsers()
+	require.NoError(t, err)
+	assert.Empty(t, users)
+	movies, err := repos.Movies.ListDeletedMovies()
+	require.NoError(t, err)
+	assert.Empty(t, movies)
+
+	_, err = repos.Reviews.GetReviewByID(movieReview)
+	assert.ErrorIs(t, err, errs.NotFound, "reviews of a purged movie are removed")
+	_, err = repos.Revisions.GetMovieRevision(movieID, 1)
+	assert.ErrorIs(t, err, errs.NotFound, "history of a purged movie is removed")
+	translations, err := repos.Translations.ListTranslations(models.TranslationMovie, []models.ID{movieID})
+	require.NoError(t, err)
+	assert.Empty(t, translations, "translations of a purged movie are removed")
+	_, err = repos.Releases.GetRelease(release)
+	assert.ErrorIs(t, err, errs.NotFound, "releases of a purged movie are removed")
+	watchlists, err := repos.Watchlists.ListWatchlistEntries(models.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, watchlists.Items, "watchlists of purged movies and users are removed")
+	review, err := repos.Reviews.GetReviewByID(userReview)
+	require.NoError(t, err)
+	assert.True(t, review.OwnerID.IsZero(), "reviews of a purged user are anonymized")
+}
+
+func TestTrashCascades(t *testing.T) {
+	repos := repository.Repos{
+		Users:        repository.NewMemoryUserRepo(),
+		Movies:       repository.NewMemoryMovieRepo(),
+		Reviews:      repository.NewMemoryReviewRepo(),
+		Credits:      repository.NewMemoryCreditRepo(),
+		Revisions:    repository.NewMemoryRevisionRepo(),
+		Translations: repository.NewMemoryTranslationRepo(),
+		Tags:         repository.NewMemoryTagRepo(),
+		Releases:     repository.NewMemoryReleaseRepo(),
+		Watchlists:   repository.NewMemoryWatchlistRepo(),
+	}
+	uow := repository.NewMemoryUnitOfWork(repos)
+	search := repository.NewMemorySearchIndex()
+	userSvc := NewUserService(zap.NewNop(), repos.Users, repos.Movies, nil, uow, search)
+	movieSvc := NewMovieService(zap.NewNop(), repos.Movies, repos.Users, repository.NewMemoryPersonRepo(), repository.NewMemoryGenreRepo(), repos.Credits, repos.Tags, repos.Releases, repos.Revisions, search, repository.NewMemoryFacetCounter(repos.Movies, repos.Tags), uow)
+	reviewSvc := NewReviewService(zap.NewNop(), repos.Reviews, repos.Users, repos.Movies, uow, search)
+
+	adminID, err := repos.Users.CreateUser(models.NilID, &models.User{Username: "admin", Roles: []models.Role{RoleAdmin}})
+	require.NoError(t, err)
+	userID, err := repos.Users.CreateUser(models.NilID, &models.User{Username: "neo", Roles: []models.Role{RoleUser}})
+	require.NoError(t, err)
+	otherID, err := repos.Users.CreateUser(models.NilID, &models.User{Username: "trinity", Roles: []models.Role{RoleUser}})
+	require.NoError(t, err)
+	movieID, err := movieSvc.CreateMovie(adminID, &models.CreateMovieRequest{Title: "The Matrix", Year: 1999})
+	require.NoError(t, err)
+
+	public, err := reviewSvc.CreateReview(userID, &models.CreateReviewRequest{MovieID: movieID, Rating: 9})
+	require.NoError(t, err)
+	private, err := reviewSvc.CreateReview(userID, &models.CreateReviewRequest{MovieID: movieID, Rating: 3, IsPrivate: true})
+	require.NoError(t, err)
+	others, err := reviewSvc.CreateReview(otherID, &models.CreateReviewRequest{MovieID: movieID, Rating: 7})
+	require.NoError(t, err)
+
+	visible := func() []models.ID {
+		t.Helper()
+		_, page, err := reviewSvc.ListReviewsByMovieID(otherID, movieID, models.ListOptions{})
+		require.NoError(t, err)
+		return reviewIDs(page.Items)
+	}
+	ratings := func() models.RatingStats {
+		t.Helper()
+		movie, err := repos.Movies.GetMovie(movieID)
+		require.NoError(t, err)
+		return movie.Ratings
+	}
+	counted := models.NewRatingStats().Adjust(0, 9).Adjust(0, 7)
+
+	t.Run("Movie", func(t *testing.T) {
+		require.NoError(t, movieSvc.DeleteMovie(adminID, movieID))
+		assert.Empty(t, visible())
+		mine, err := reviewSvc.ListMyReviews(userID, models.ListOptions{})
+		require.NoError(t, err)
+		assert.Empty(t, mine.Items)
+		_, err = reviewSvc.GetReview(userID, public)
+		assert.ErrorIs(t, err, errs.NotFound)
+		trash, err := repos.Movies.ListDeletedMovies()
+		require.NoError(t, err)
+		require.Len(t, trash, 1)
+		assert.Equal(t, counted, trash[0].Ratings, "the movie keeps its aggregate in the trash")
+
+		_, err = movieSvc.RestoreMovie(adminID, movieID)
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{public, others}, visible())
+		assert.Equal(t, counted, ratings())
+	})
+
+	t.Run("User", func(t *testing.T) {
+		require.NoError(t, userSvc.DeleteUser(adminID, userID))
+		assert.Equal(t, []models.ID{others}, visible())
+		assert.Equal(t, models.NewRatingStats().Adjust(0, 7), ratings(), "ratings of trashed users are not counted")
+
+		_, err := userSvc.RestoreUser(adminID, userID)
+		require.NoError(t, err)
+		assert.Equal(t, []models.ID{public, others}, visible())
+		assert.Equal(t, counted, ratings())
+		mine, err := reviewSvc.ListMyReviews(userID, models.ListOptions{})
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []models.ID{public, private}, reviewIDs(mine.Items))
+	})
+
+	t.Run("Purge", func(t *testing.T) {
+		require.NoError(t, userSvc.DeleteMe(userID))
+		assert.Equal(t, []models.ID{others}, visible())
+
+		require.NoError(t, NewPurgeService(zap.NewNop(), uow, 0).Purge())
+		assert.Equal(t, []models.ID{public, others}, visible(), "public reviews come back anonymized")
+		assert.Equal(t, counted, ratings())
+		review, err := repos.Reviews.GetReviewByID(public)
+		require.NoError(t, err)
+		assert.True(t, review.OwnerID.IsZero())
+		_, err = repos.Reviews.GetReviewByID(private)
+		assert.ErrorIs(t, err, errs.NotFound)
+	})
+}
+
+func reviewIDs(reviews []*models.Review) []models.ID {
+	ids := make([]models.ID, 0, len(reviews))
+	for _, review := range reviews {
+		ids = append(ids, review.ID)
+	}
+	return ids
+}
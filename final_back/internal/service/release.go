@@ -0,0 +1,177 @@
+package service
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"go.uber.org/zap"
+)
+
+// ReleaseService manages when movies come out in each country. Like
+// credits, releases are part of a movie, so changing them takes the
+// permission to update the movie.
+type ReleaseService interface {
+	ListMovieReleases(movieID models.ID) ([]*models.Release, error)
+	CreateRelease(actorID models.ID, movieID models.ID, req *models.CreateReleaseRequest) (models.ID, error)
+	UpdateRelease(actorID models.ID, movieID models.ID, id models.ID, version int64, req *models.UpdateReleaseRequest) (*models.Release, error)
+	DeleteRelease(actorID models.ID, movieID models.ID, id models.ID) error
+	// UpcomingReleases lists the releases from today on, soonest first, each
+	// with its movie filled in. country and releaseType may be empty to list
+	// every country or type.
+	UpcomingReleases(country string, releaseType models.ReleaseType, opts models.ListOptions) (models.Page[*models.Release], error)
+}
+
+type releaseSvc struct {
+	log       *zap.Logger
+	repo      repository.ReleaseRepo
+	movieRepo repository.MovieRepo
+	userRepo  repository.UserRepo
+}
+
+func NewReleaseService(log *zap.Logger, repo repository.ReleaseRepo, movieRepo repository.MovieRepo, userRepo repository.UserRepo) ReleaseService {
+	return &releaseSvc{
+		log:       log,
+		repo:      repo,
+		movieRepo: movieRepo,
+		userRepo:  userRepo,
+	}
+}
+
+// ListMovieReleases returns the releases of a live movie by country and
+// then date.
+func (s *releaseSvc) ListMovieReleases(movieID models.ID) ([]*models.Release, error) {
+	if _, err := s.movieRepo.GetMovie(movieID); err != nil {
+		return nil, err
+	}
+
+	releases, err := s.repo.ListReleases(models.ListOptions{Filters: []models.Filter{{Field: "movieId", Op: models.OpEq, Value: movieID}}})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(releases.Items, func(a, b *models.Release) int {
+		if o := cmp.Compare(a.Country, b.Country); o != 0 {
+			return o
+		}
+		return cmp.Compare(a.Date, b.Date)
+	})
+	return releases.Items, nil
+}
+
+// CreateRelease returns models.ErrInvalidCountry for a country ParseCountry
+// does not accept.
+func (s *releaseSvc) CreateRelease(actorID models.ID, movieID models.ID, req *models.CreateReleaseRequest) (models.ID, error) {
+	if err := s.authorize(actorID); err != nil {
+		return models.NilID, err
+	}
+
+	country, err := models.ParseCountry(req.Country)
+	if err != nil {
+		return models.NilID, err
+	}
+	req.Country = country
+
+	if _, err := s.movieRepo.GetMovie(movieID); err != nil {
+		return models.NilID, err
+	}
+	return s.repo.CreateRelease(actorID, movieID, req)
+}
+
+func (s *releaseSvc) UpdateRelease(actorID models.ID, movieID models.ID, id models.ID, version int64, req *models.UpdateReleaseRequest) (*models.Release, error) {
+	if err := s.authorize(actorID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.getRelease(movieID, id); err != nil {
+		return nil, err
+	}
+	return s.repo.UpdateRelease(actorID, id, version, req)
+}
+
+func (s *releaseSvc) DeleteRelease(actorID models.ID, movieID models.ID, id models.ID) error {
+	if err := s.authorize(actorID); err != nil {
+		return err
+	}
+
+	if _, err := s.getRelease(movieID, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteRelease(id)
+}
+
+// UpcomingReleases leaves out the releases of movies in the trash, so a page
+// may hold fewer than opts.Limit releases even when more follow.
+func (s *releaseSvc) UpcomingReleases(country string, releaseType models.ReleaseType, opts models.ListOptions) (models.Page[*models.Release], error) {
+	opts.Filters = append(opts.Filters, models.Filter{Field: "date", Op: models.OpGte, Value: today()})
+	if country != "" {
+		country, err := models.ParseCountry(country)
+		if err != nil {
+			return models.Page[*models.Release]{}, err
+		}
+		opts.Filters = append(opts.Filters, models.Filter{Field: "country", Op: models.OpEq, Value: country})
+	}
+	if releaseType != "" {
+		opts.Filters = append(opts.Filters, models.Filter{Field: "type", Op: models.OpEq, Value: string(releaseType)})
+	}
+	opts.Sort = "date"
+
+	releases, err := s.repo.ListReleases(opts)
+	if err != nil {
+		return models.Page[*models.Release]{}, err
+	}
+
+	ids := []models.ID{}
+	for _, release := range releases.Items {
+		ids = append(ids, release.MovieID)
+	}
+	movies, err := s.movieRepo.ListMovies(models.ListOptions{IDs: ids})
+	if err != nil {
+		return models.Page[*models.Release]{}, err
+	}
+	byID := make(map[models.ID]*models.Movie, len(movies.Items))
+	for _, movie := range movies.Items {
+		byID[movie.ID] = movie
+	}
+
+	upcoming := []*models.Release{}
+	for _, release := range releases.Items {
+		if release.Movie = byID[release.MovieID]; release.Movie != nil {
+			upcoming = append(upcoming, release)
+		}
+	}
+	releases.Items = upcoming
+	return releases, nil
+}
+
+// getRelease returns errs.NotFound unless the release belongs to the movie.
+func (s *releaseSvc) getRelease(movieID models.ID, id models.ID) (*models.Release, error) {
+	release, err := s.repo.GetRelease(id)
+	if err != nil {
+		return nil, err
+	}
+	if release.MovieID != movieID {
+		return nil, errs.NotFound
+	}
+	return release, nil
+}
+
+func (s *releaseSvc) authorize(actorID models.ID) error {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return err
+	}
+
+	if !HasPermission(actor, ResourceMovie, ActionUpdate, nil) {
+		return errs.Forbidden
+	}
+	return nil
+}
+
+// today is the date releases from today on count as upcoming from, and
+// earlier ones as out.
+func today() string {
+	return time.Now().UTC().Format(models.ReleaseDateLayout)
+}
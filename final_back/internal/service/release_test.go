@@ -0,0 +1,142 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestReleases(t *testing.T) {
+	users := repository.NewMemoryUserRepo()
+	movies := repository.NewMemoryMovieRepo()
+	releases := repository.NewMemoryReleaseRepo()
+	revisions := repository.NewMemoryRevisionRepo()
+	uow := repository.NewMemoryUnitOfWork(repository.Repos{Users: users, Movies: movies, Reviews: repository.NewMemoryReviewRepo(), Revisions: revisions, Releases: releases})
+	movieSvc := NewMovieService(zap.NewNop(), movies, users, repository.NewMemoryPersonRepo(), repository.NewMemoryGenreRepo(), repository.NewMemoryCreditRepo(), repository.NewMemoryTagRepo(), releases, revisions, repository.NewMemorySearchIndex(), repository.NewMemoryFacetCounter(movies, repository.NewMemoryTagRepo()), uow)
+	releaseSvc := NewReleaseService(zap.NewNop(), releases, movies, users)
+
+	moderatorID, err := users.CreateUser(models.NilID, &models.User{Username: "moderator", Roles: []models.Role{RoleModerator}})
+	require.NoError(t, err)
+	userID, err := users.CreateUser(models.NilID, &models.User{Username: "neo", Roles: []models.Role{RoleUser}})
+	require.NoError(t, err)
+	matrix, err := movieSvc.CreateMovie(moderatorID, &models.CreateMovieRequest{Title: "The Matrix", Year: 1999, Runtime: 136, OriginalLanguage: "EN-us"})
+	require.NoError(t, err)
+	dune, err := movieSvc.CreateMovie(moderatorID, &models.CreateMovieRequest{Title: "Dune: Part Three", Year: 2026})
+	require.NoError(t, err)
+	trashed, err := movieSvc.CreateMovie(moderatorID, &models.CreateMovieRequest{Title: "Unreleased", Year: 2026})
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	past := now.AddDate(0, -1, 0).Format(models.ReleaseDateLayout)
+	soon := now.AddDate(0, 1, 0).Format(models.ReleaseDateLayout)
+	later := now.AddDate(0, 2, 0).Format(models.ReleaseDateLayout)
+
+	t.Run("Details", func(t *testing.T) {
+		movie, err := movieSvc.GetMovie(matrix)
+		require.NoError(t, err)
+		assert.Equal(t, "en-US", movie.OriginalLanguage)
+		assert.Equal(t, 136, movie.Runtime)
+
+		_, err = movieSvc.CreateMovie(moderatorID, &models.CreateMovieRequest{Title: "Primer", Year: 2004, OriginalLanguage: "not a language"})
+		assert.ErrorIs(t, err, models.ErrInvalidLocale)
+
+		runtime, synopsis := 150, "A hacker learns the truth."
+		updated, err := movieSvc.UpdateMovie(moderatorID, matrix, movie.Version, &models.UpdateMovieRequest{Runtime: &runtime, Synopsis: &synopsis})
+		require.NoError(t, err)
+		reverted, err := movieSvc.RevertMovie(moderatorID, matrix, updated.Version, movie.Version)
+		require.NoError(t, err)
+		assert.Equal(t, 136, reverted.Runtime)
+		assert.Equal(t, synopsis, reverted.Synopsis, "an empty field is not put back")
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		_, err := releaseSvc.CreateRelease(userID, matrix, &models.CreateReleaseRequest{Country: "US", Date: past, Type: models.ReleaseTheatrical})
+		assert.ErrorIs(t, err, errs.Forbidden)
+		_, err = releaseSvc.CreateRelease(moderatorID, matrix, &models.CreateReleaseRequest{Country: "ZZ", Date: past, Type: models.ReleaseTheatrical})
+		assert.ErrorIs(t, err, models.ErrInvalidCountry)
+		_, err = releaseSvc.CreateRelease(moderatorID, models.NewID(), &models.CreateReleaseRequest{Country: "US", Date: past, Type: models.ReleaseTheatrical})
+		assert.ErrorIs(t, err, errs.NotFound)
+
+		for _, release := range []struct {
+			movieID models.ID
+			req     models.CreateReleaseRequest
+		}{
+			{matrix, models.CreateReleaseRequest{Country: "us", Date: past, Type: models.ReleaseTheatrical, Certification: "R"}},
+			{matrix, models.CreateReleaseRequest{Country: "UK", Date: soon, Type: models.ReleaseStreaming, Certification: "15"}},
+			{dune, models.CreateReleaseRequest{Country: "US", Date: later, Type: models.ReleaseTheatrical}},
+			{dune, models.CreateReleaseRequest{Country: "GB", Date: later, Type: models.ReleaseTheatrical}},
+			{trashed, models.CreateReleaseRequest{Country: "US", Date: soon, Type: models.ReleaseTheatrical}},
+		} {
+			_, err := releaseSvc.CreateRelease(moderatorID, release.movieID, &release.req)
+			require.NoError(t, err)
+		}
+		_, err = releaseSvc.CreateRelease(moderatorID, matrix, &models.CreateReleaseRequest{Country: "US", Date: soon, Type: models.ReleaseTheatrical})
+		assert.ErrorIs(t, err, errs.AlreadyExists)
+
+		list, err := releaseSvc.ListMovieReleases(matrix)
+		require.NoError(t, err)
+		require.Len(t, list, 2)
+		assert.Equal(t, "GB", list[0].Country, "UK is stored as GB")
+		assert.Equal(t, "US", list[1].Country)
+		require.NoError(t, movieSvc.DeleteMovie(moderatorID, trashed))
+	})
+
+	t.Run("ReleasedIn", func(t *testing.T) {
+		page, err := movieSvc.ListMovies(models.ListOptions{Filters: []models.Filter{{Field: models.FilterReleasedIn, Op: models.OpEq, Value: "us"}}})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, matrix, page.Items[0].ID)
+
+		page, err = movieSvc.ListMovies(models.ListOptions{Filters: []models.Filter{{Field: models.FilterReleasedIn, Op: models.OpEq, Value: "GB"}}})
+		require.NoError(t, err)
+		assert.Empty(t, page.Items, "nothing has come out in GB yet")
+
+		_, err = movieSvc.ListMovies(models.ListOptions{Filters: []models.Filter{{Field: models.FilterReleasedIn, Op: models.OpEq, Value: ""}}})
+		assert.ErrorIs(t, err, models.ErrInvalidFilter)
+	})
+
+	t.Run("Upcoming", func(t *testing.T) {
+		page, err := releaseSvc.UpcomingReleases("", "", models.ListOptions{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 3, "past releases and trashed movies are left out")
+		assert.Equal(t, soon, page.Items[0].Date)
+		require.NotNil(t, page.Items[0].Movie)
+		assert.Equal(t, "The Matrix", page.Items[0].Movie.Title)
+
+		page, err = releaseSvc.UpcomingReleases("us", models.ReleaseTheatrical, models.ListOptions{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, dune, page.Items[0].MovieID)
+
+		page, err = releaseSvc.UpcomingReleases("GB", models.ReleaseStreaming, models.ListOptions{Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		assert.Equal(t, matrix, page.Items[0].MovieID)
+
+		_, err = releaseSvc.UpcomingReleases("Narnia", "", models.ListOptions{Limit: 10})
+		assert.ErrorIs(t, err, models.ErrInvalidCountry)
+	})
+
+	t.Run("UpdateAndDelete", func(t *testing.T) {
+		list, err := releaseSvc.ListMovieReleases(matrix)
+		require.NoError(t, err)
+		us := list[1]
+
+		_, err = releaseSvc.UpdateRelease(moderatorID, dune, us.ID, us.Version, &models.UpdateReleaseRequest{})
+		assert.ErrorIs(t, err, errs.NotFound, "the release belongs to another movie")
+		certification := "PG-13"
+		updated, err := releaseSvc.UpdateRelease(moderatorID, matrix, us.ID, us.Version, &models.UpdateReleaseRequest{Certification: &certification})
+		require.NoError(t, err)
+		assert.Equal(t, "PG-13", updated.Certification)
+
+		assert.ErrorIs(t, releaseSvc.DeleteRelease(userID, matrix, us.ID), errs.Forbidden)
+		require.NoError(t, releaseSvc.DeleteRelease(moderatorID, matrix, us.ID))
+		assert.ErrorIs(t, releaseSvc.DeleteRelease(moderatorID, matrix, us.ID), errs.NotFound)
+	})
+}
@@ -4,92 +4,236 @@ import (
 	"github.com/kakimnsnv/ios_final_back/internal/errs"
 	"github.com/kakimnsnv/ios_final_back/internal/models"
 	"github.com/kakimnsnv/ios_final_back/internal/repository"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 )
 
 type ReviewService interface {
-	ListReviewsByMovieID(actorID *primitive.ObjectID, movieID *primitive.ObjectID) ([]*models.Review, []*models.Review, error)
-	ListMyReviews(actorID *primitive.ObjectID) ([]*models.Review, error)
-	UpdateReview(actorID *primitive.ObjectID, reviewID *primitive.ObjectID, review *models.UpdateReviewRequest) error
+	ListReviewsByMovieID(actorID models.ID, movieID models.ID, opts models.ListOptions) ([]*models.Review, models.Page[*models.Review], error)
+	ListMyReviews(actorID models.ID, opts models.ListOptions) (models.Page[*models.Review], error)
+	GetReview(actorID models.ID, reviewID models.ID) (*models.Review, error)
+	UpdateReview(actorID models.ID, reviewID models.ID, version int64, review *models.UpdateReviewRequest) (*models.Review, error)
 	ListReviewCategories() ([]*models.ReviewCategory, error)
-	CreateReview(actorID *primitive.ObjectID, review *models.CreateReviewRequest) (*primitive.ObjectID, error)
-	DeleteReview(actorID *primitive.ObjectID, reviewID *primitive.ObjectID) error
+	ListAllReviewCategories(actorID models.ID) ([]*models.ReviewCategory, error)
+	GetReviewCategory(id models.ID) (*models.ReviewCategory, error)
+	CreateReviewCategory(actorID models.ID, req *models.CreateReviewCategoryRequest) (models.ID, error)
+	UpdateReviewCategory(actorID models.ID, id models.ID, version int64, req *models.UpdateReviewCategoryRequest) (*models.ReviewCategory, error)
+	ArchiveReviewCategory(actorID models.ID, id models.ID, archived bool) (*models.ReviewCategory, error)
+	CreateReview(actorID models.ID, review *models.CreateReviewRequest) (models.ID, error)
+	DeleteReview(actorID models.ID, reviewID models.ID) error
+	ListDeletedReviews(actorID models.ID) ([]*models.Review, error)
+	RestoreReview(actorID models.ID, reviewID models.ID) (*models.Review, error)
 }
 
+// reviewSvc keeps the rating aggregates of movies in step with their
+// reviews: every review write adjusts the aggregate of its movie in the same
+// unit of work.
 type reviewSvc struct {
-	log      *zap.Logger
-	repo     repository.ReviewRepo
-	userRepo repository.UserRepo
+	log       *zap.Logger
+	repo      repository.ReviewRepo
+	userRepo  repository.UserRepo
+	movieRepo repository.MovieRepo
+	uow       repository.UnitOfWork
+	search    repository.SearchIndex
 }
 
-func NewReviewService(log *zap.Logger, repo repository.ReviewRepo, userRepo repository.UserRepo) ReviewService {
+func NewReviewService(log *zap.Logger, repo repository.ReviewRepo, userRepo repository.UserRepo, movieRepo repository.MovieRepo, uow repository.UnitOfWork, search repository.SearchIndex) ReviewService {
 	return &reviewSvc{
-		log:      log,
-		repo:     repo,
-		userRepo: userRepo,
+		log:       log,
+		repo:      repo,
+		userRepo:  userRepo,
+		movieRepo: movieRepo,
+		uow:       uow,
+		search:    search,
 	}
 }
 
-func (s *reviewSvc) ListReviewsByMovieID(actorID *primitive.ObjectID, movieID *primitive.ObjectID) ([]*models.Review, []*models.Review, error) {
-	reviews, err := s.repo.ListReviewsByMovieID(actorID, movieID)
+func (s *reviewSvc) ListReviewsByMovieID(actorID models.ID, movieID models.ID, opts models.ListOptions) ([]*models.Review, models.Page[*models.Review], error) {
+	reviews, err := s.repo.ListReviewsByMovieID(actorID, movieID, opts)
 	if err != nil {
-		return nil, nil, err
+		return nil, models.Page[*models.Review]{}, err
 	}
 
 	ownReviews, err := s.repo.ListOwnReviewsByMovieID(actorID, movieID)
 	if err != nil {
-		return nil, nil, err
+		return nil, models.Page[*models.Review]{}, err
 	}
 
 	return ownReviews, reviews, nil
 }
 
-func (s *reviewSvc) ListMyReviews(actorID *primitive.ObjectID) ([]*models.Review, error) {
-	reviews, err := s.repo.ListMyReviews(actorID)
+func (s *reviewSvc) ListMyReviews(actorID models.ID, opts models.ListOptions) (models.Page[*models.Review], error) {
+	return s.repo.ListMyReviews(actorID, opts)
+}
+
+func (s *reviewSvc) GetReview(actorID models.ID, reviewID models.ID) (*models.Review, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	review, err := s.repo.GetReviewByID(reviewID)
 	if err != nil {
 		return nil, err
 	}
-	return reviews, nil
+
+	if !HasPermission(actor, ResourceReview, ActionView, review) {
+		return nil, errs.Forbidden
+	}
+	return review, nil
 }
 
-func (s *reviewSvc) UpdateReview(actorID *primitive.ObjectID, reviewID *primitive.ObjectID, review *models.UpdateReviewRequest) error {
+func (s *reviewSvc) UpdateReview(actorID models.ID, reviewID models.ID, version int64, review *models.UpdateReviewRequest) (*models.Review, error) {
 	actor, err := s.userRepo.GetUserByID(actorID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	updatingReview, err := s.repo.GetReviewByID(reviewID)
+	// The review is read inside the unit of work, at the version the client
+	// saw, so the rating taken off the aggregate is the one that was on it.
+	var updated *models.Review
+	err = s.uow.Do(func(repos repository.Repos) error {
+		updatingReview, err := repos.Reviews.GetReviewByID(reviewID)
+		if err != nil {
+			return err
+		}
+		if !HasPermission(actor, ResourceReview, ActionUpdate, updatingReview) {
+			return errs.Forbidden
+		}
+		if updatingReview.Version != version {
+			return errs.Conflict
+		}
+		if err := checkReviewCategory(repos.Reviews, review.ReviewCategoryID, updatingReview.ReviewCategoryID); err != nil {
+			return err
+		}
+
+		before := models.CountedRating(updatingReview)
+		updatingReview.Content = review.Content
+		updatingReview.IsPrivate = review.IsPrivate
+		updatingReview.ReviewCategoryID = review.ReviewCategoryID
+		updatingReview.Rating = review.Rating
+
+		if updated, err = repos.Reviews.UpdateReview(actorID, reviewID, version, updatingReview); err != nil {
+			return err
+		}
+		return repos.Movies.UpdateRatings(updated.MovieID, before, models.CountedRating(updated))
+	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	s.reindex(updated.MovieID)
+	return updated, nil
+}
+
+// ListReviewCategories returns the categories reviewers can pick.
+func (s *reviewSvc) ListReviewCategories() ([]*models.ReviewCategory, error) {
+	categories, err := s.repo.ListReviewCategories(false)
+	if err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// ListAllReviewCategories returns the archived categories too, each with
+// its usage.
+func (s *reviewSvc) ListAllReviewCategories(actorID models.ID) ([]*models.ReviewCategory, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
 	}
 
-	if !HasPermission(actor, ResourceReview, ActionUpdate, updatingReview) {
-		return errs.Forbidden
+	if !HasPermission(actor, ResourceReviewCategory, ActionView, nil) {
+		return nil, errs.Forbidden
 	}
 
-	updatingReview.Content = review.Content
-	updatingReview.IsPrivate = review.IsPrivate
-	updatingReview.ReviewCategoryID = review.ReviewCategoryID
-	updatingReview.Rating = review.Rating
+	categories, err := s.repo.ListReviewCategories(true)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.repo.CountReviewsByCategory()
+	if err != nil {
+		return nil, err
+	}
+	for _, category := range categories {
+		usage := counts[category.ID]
+		category.Usage = &usage
+	}
+	return categories, nil
+}
 
-	if _, err = s.repo.UpdateReview(reviewID, updatingReview); err != nil {
-		return err
+func (s *reviewSvc) GetReviewCategory(id models.ID) (*models.ReviewCategory, error) {
+	return s.repo.GetReviewCategory(id)
+}
+
+func (s *reviewSvc) CreateReviewCategory(actorID models.ID, req *models.CreateReviewCategoryRequest) (models.ID, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return models.NilID, err
 	}
-	return nil
+
+	if !HasPermission(actor, ResourceReviewCategory, ActionCreate, nil) {
+		return models.NilID, errs.Forbidden
+	}
+
+	return s.repo.CreateReviewCategory(actorID, req)
 }
 
-func (s *reviewSvc) ListReviewCategories() ([]*models.ReviewCategory, error) {
-	categories, err := s.repo.ListReviewCategories()
+// UpdateReviewCategory renames or reorders a category.
+func (s *reviewSvc) UpdateReviewCategory(actorID models.ID, id models.ID, version int64, req *models.UpdateReviewCategoryRequest) (*models.ReviewCategory, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
 	if err != nil {
 		return nil, err
 	}
-	return categories, nil
+
+	if !HasPermission(actor, ResourceReviewCategory, ActionUpdate, nil) {
+		return nil, errs.Forbidden
+	}
+
+	return s.repo.UpdateReviewCategory(actorID, id, version, req)
 }
 
-func (s *reviewSvc) CreateReview(actorID *primitive.ObjectID, req *models.CreateReviewRequest) (*primitive.ObjectID, error) {
+// ArchiveReviewCategory archives a category, or brings it back if archived
+// is false. Reviews keep their archived category, but it cannot be picked
+// for new ones.
+func (s *reviewSvc) ArchiveReviewCategory(actorID models.ID, id models.ID, archived bool) (*models.ReviewCategory, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !HasPermission(actor, ResourceReviewCategory, ActionDelete, nil) {
+		return nil, errs.Forbidden
+	}
+
+	return s.repo.ArchiveReviewCategory(actorID, id, archived)
+}
+
+// checkReviewCategory returns errs.InvalidReference unless id is zero, the
+// category the review already has, or an active category.
+func checkReviewCategory(repo repository.ReviewRepo, id, current models.ID) error {
+	if id.IsZero() || id == current {
+		return nil
+	}
+	category, err := repo.GetReviewCategory(id)
+	if err == errs.NotFound {
+		return errs.InvalidReference
+	}
+	if err != nil {
+		return err
+	}
+	if category.Archived != nil {
+		return errs.InvalidReference
+	}
+	return nil
+}
+
+func (s *reviewSvc) CreateReview(actorID models.ID, req *models.CreateReviewRequest) (models.ID, error) {
+	if err := checkReviewCategory(s.repo, req.ReviewCategoryID, models.NilID); err != nil {
+		return models.NilID, err
+	}
+
 	review := models.Review{
-		OwnerID:          *actorID,
+		OwnerID:          actorID,
 		MovieID:          req.MovieID,
 		ReviewCategoryID: req.ReviewCategoryID,
 		Content:          req.Content,
@@ -97,30 +241,117 @@ func (s *reviewSvc) CreateReview(actorID *primitive.ObjectID, req *models.Create
 		Rating:           req.Rating,
 	}
 
-	id, err := s.repo.CreateReview(&review)
+	var id models.ID
+	err := s.uow.Do(func(repos repository.Repos) error {
+		// UpdateRatings ignores unknown movies and still counts into trashed
+		// ones, so the movie is checked here rather than left to it.
+		if _, err := repos.Movies.GetMovie(review.MovieID); err == errs.NotFound {
+			return errs.InvalidReference
+		} else if err != nil {
+			return err
+		}
+
+		var err error
+		if id, err = repos.Reviews.CreateReview(actorID, &review); err != nil {
+			return err
+		}
+		return repos.Movies.UpdateRatings(review.MovieID, 0, models.CountedRating(&review))
+	})
 	if err != nil {
-		return nil, err
+		return models.NilID, err
 	}
+
+	s.reindex(review.MovieID)
 	return id, nil
 }
 
-func (s *reviewSvc) DeleteReview(actorID *primitive.ObjectID, reviewID *primitive.ObjectID) error {
+func (s *reviewSvc) DeleteReview(actorID models.ID, reviewID models.ID) error {
 	actor, err := s.userRepo.GetUserByID(actorID)
 	if err != nil {
 		return err
 	}
 
-	review, err := s.repo.GetReviewByID(reviewID)
+	// The review is read inside the unit of work and deleted at the version
+	// read, so the rating taken off the aggregate is the one that was on it.
+	var review *models.Review
+	err = s.uow.Do(func(repos repository.Repos) error {
+		var err error
+		if review, err = repos.Reviews.GetReviewByID(reviewID); err != nil {
+			return err
+		}
+		if !HasPermission(actor, ResourceReview, ActionDelete, review) {
+			return errs.Forbidden
+		}
+		if err := repos.Reviews.DeleteReview(actorID, reviewID, review.Version); err != nil {
+			return err
+		}
+		return repos.Movies.UpdateRatings(review.MovieID, models.CountedRating(review), 0)
+	})
 	if err != nil {
 		return err
 	}
 
-	if !HasPermission(actor, ResourceReview, ActionDelete, review) {
-		return errs.Forbidden
+	s.reindex(review.MovieID)
+	return nil
+}
+
+func (s *reviewSvc) ListDeletedReviews(actorID models.ID) ([]*models.Review, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !HasPermission(actor, ResourceReview, ActionRestore, nil) {
+		return nil, errs.Forbidden
 	}
 
-	if err := s.repo.DeleteReview(reviewID); err != nil {
-		return err
+	return s.repo.ListDeletedReviews()
+}
+
+func (s *reviewSvc) RestoreReview(actorID models.ID, reviewID models.ID) (*models.Review, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !HasPermission(actor, ResourceReview, ActionRestore, nil) {
+		return nil, errs.Forbidden
+	}
+
+	var restored *models.Review
+	err = s.uow.Do(func(repos repository.Repos) error {
+		var err error
+		if restored, err = repos.Reviews.RestoreReview(actorID, reviewID); err != nil {
+			return err
+		}
+		return repos.Movies.UpdateRatings(restored.MovieID, 0, models.CountedRating(restored))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.reindex(restored.MovieID)
+	return restored, nil
+}
+
+// reindex refreshes a movie in the search index after its rating changed,
+// since ratings weigh in on ranking.
+func (s *reviewSvc) reindex(movieID models.ID) {
+	reindexMovie(s.log, s.movieRepo, s.search, movieID)
+}
+
+// reindexMovie refreshes a movie in the search index. Movies in the trash
+// are not indexed.
+func reindexMovie(log *zap.Logger, movieRepo repository.MovieRepo, search repository.SearchIndex, movieID models.ID) {
+	movie, err := movieRepo.GetMovie(movieID)
+	if err == errs.NotFound {
+		return
+	}
+	if err != nil {
+		log.Error("failed to get movie", zap.Error(err))
+		return
+	}
+	if err := search.IndexMovie(movie); err != nil {
+		log.Error("failed to index movie", zap.Error(err))
 	}
-	return nil
 }
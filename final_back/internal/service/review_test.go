@@ -0,0 +1,173 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestReviewRatings(t *testing.T) {
+	repos := repository.Repos{
+		Users:     repository.NewMemoryUserRepo(),
+		Movies:    repository.NewMemoryMovieRepo(),
+		Reviews:   repository.NewMemoryReviewRepo(),
+		Credits:   repository.NewMemoryCreditRepo(),
+		Revisions: repository.NewMemoryRevisionRepo(),
+	}
+	search := repository.NewMemorySearchIndex()
+	reviewSvc := NewReviewService(zap.NewNop(), repos.Reviews, repos.Users, repos.Movies, repository.NewMemoryUnitOfWork(repos), search)
+
+	adminID, err := repos.Users.CreateUser(models.NilID, &models.User{Username: "admin", Roles: []models.Role{RoleAdmin}})
+	require.NoError(t, err)
+	userID, err := repos.Users.CreateUser(models.NilID, &models.User{Username: "neo", Roles: []models.Role{RoleUser}})
+	require.NoError(t, err)
+	movieID, err := repos.Movies.CreateMovie(adminID, &models.CreateMovieRequest{Title: "The Matrix", Year: 1999})
+	require.NoError(t, err)
+
+	ratings := func() models.RatingStats {
+		t.Helper()
+		movie, err := repos.Movies.GetMovie(movieID)
+		require.NoError(t, err)
+		return movie.Ratings
+	}
+	want := func(ratings ...int) models.RatingStats {
+		stats := models.NewRatingStats()
+		for _, rating := range ratings {
+			stats = stats.Adjust(0, rating)
+		}
+		return stats
+	}
+
+	first, err := reviewSvc.CreateReview(userID, &models.CreateReviewRequest{MovieID: movieID, Rating: 9})
+	require.NoError(t, err)
+	_, err = reviewSvc.CreateReview(adminID, &models.CreateReviewRequest{MovieID: movieID, Rating: 7})
+	require.NoError(t, err)
+	_, err = reviewSvc.CreateReview(adminID, &models.CreateReviewRequest{MovieID: movieID, Rating: 2, IsPrivate: true})
+	require.NoError(t, err)
+	assert.Equal(t, want(9, 7), ratings(), "private reviews are not counted")
+
+	_, err = reviewSvc.UpdateReview(userID, first, 1, &models.UpdateReviewRequest{Rating: 10})
+	require.NoError(t, err)
+	assert.Equal(t, want(10, 7), ratings())
+
+	_, err = reviewSvc.UpdateReview(userID, first, 2, &models.UpdateReviewRequest{Rating: 10, IsPrivate: true})
+	require.NoError(t, err)
+	assert.Equal(t, want(7), ratings())
+
+	_, err = reviewSvc.UpdateReview(userID, first, 3, &models.UpdateReviewRequest{Rating: 8})
+	require.NoError(t, err)
+	assert.Equal(t, want(8, 7), ratings())
+
+	_, err = reviewSvc.UpdateReview(userID, first, 3, &models.UpdateReviewRequest{Rating: 1})
+	assert.ErrorIs(t, err, errs.Conflict)
+	assert.Equal(t, want(8, 7), ratings(), "stale updates leave the aggregate alone")
+
+	otherID, err := repos.Users.CreateUser(models.NilID, &models.User{Username: "smith", Roles: []models.Role{RoleUser}})
+	require.NoError(t, err)
+	assert.ErrorIs(t, reviewSvc.DeleteReview(otherID, first), errs.Forbidden)
+	assert.ErrorIs(t, reviewSvc.DeleteReview(userID, models.NewID()), errs.NotFound)
+	require.NoError(t, reviewSvc.DeleteReview(userID, first))
+	assert.Equal(t, want(7), ratings())
+
+	_, err = reviewSvc.RestoreReview(adminID, first)
+	require.NoError(t, err)
+	assert.Equal(t, want(7, 8), ratings())
+
+	hits, err := search.SearchMovies("matrix", 10)
+	require.NoError(t, err)
+	require.Len(t, hits, 1, "rating changes reindex the movie")
+}
+
+func TestReviewMovieReference(t *testing.T) {
+	repos := repository.Repos{
+		Users:     repository.NewMemoryUserRepo(),
+		Movies:    repository.NewMemoryMovieRepo(),
+		Reviews:   repository.NewMemoryReviewRepo(),
+		Credits:   repository.NewMemoryCreditRepo(),
+		Revisions: repository.NewMemoryRevisionRepo(),
+	}
+	reviewSvc := NewReviewService(zap.NewNop(), repos.Reviews, repos.Users, repos.Movies, repository.NewMemoryUnitOfWork(repos), repository.NewMemorySearchIndex())
+
+	adminID, err := repos.Users.CreateUser(models.NilID, &models.User{Username: "admin", Roles: []models.Role{RoleAdmin}})
+	require.NoError(t, err)
+	movieID, err := repos.Movies.CreateMovie(adminID, &models.CreateMovieRequest{Title: "The Matrix", Year: 1999})
+	require.NoError(t, err)
+
+	_, err = reviewSvc.CreateReview(adminID, &models.CreateReviewRequest{MovieID: models.NewID(), Rating: 9})
+	assert.ErrorIs(t, err, errs.InvalidReference, "unknown movie")
+
+	require.NoError(t, repos.Movies.DeleteMovie(adminID, movieID))
+	_, err = reviewSvc.CreateReview(adminID, &models.CreateReviewRequest{MovieID: movieID, Rating: 9})
+	assert.ErrorIs(t, err, errs.InvalidReference, "trashed movie")
+
+	trash, err := repos.Movies.ListDeletedMovies()
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, models.NewRatingStats(), trash[0].Ratings, "rejected reviews are not counted")
+	mine, err := repos.Reviews.ListMyReviews(adminID, models.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, mine.Items)
+}
+
+func TestReviewCategories(t *testing.T) {
+	repos := repository.Repos{
+		Users:     repository.NewMemoryUserRepo(),
+		Movies:    repository.NewMemoryMovieRepo(),
+		Reviews:   repository.NewMemoryReviewRepo(),
+		Credits:   repository.NewMemoryCreditRepo(),
+		Revisions: repository.NewMemoryRevisionRepo(),
+	}
+	reviewSvc := NewReviewService(zap.NewNop(), repos.Reviews, repos.Users, repos.Movies, repository.NewMemoryUnitOfWork(repos), repository.NewMemorySearchIndex())
+
+	moderatorID, err := repos.Users.CreateUser(models.NilID, &models.User{Username: "morpheus", Roles: []models.Role{RoleModerator}})
+	require.NoError(t, err)
+	userID, err := repos.Users.CreateUser(models.NilID, &models.User{Username: "neo", Roles: []models.Role{RoleUser}})
+	require.NoError(t, err)
+	movieID, err := repos.Movies.CreateMovie(moderatorID, &models.CreateMovieRequest{Title: "The Matrix", Year: 1999})
+	require.NoError(t, err)
+
+	_, err = reviewSvc.CreateReviewCategory(userID, &models.CreateReviewCategoryRequest{Name: "Classic"})
+	assert.ErrorIs(t, err, errs.Forbidden)
+	_, err = reviewSvc.ListAllReviewCategories(userID)
+	assert.ErrorIs(t, err, errs.Forbidden)
+
+	classic, err := reviewSvc.CreateReviewCategory(moderatorID, &models.CreateReviewCategoryRequest{Name: "Classic"})
+	require.NoError(t, err)
+
+	_, err = reviewSvc.CreateReview(userID, &models.CreateReviewRequest{MovieID: movieID, ReviewCategoryID: models.NewID(), Rating: 9})
+	assert.ErrorIs(t, err, errs.InvalidReference, "unknown category")
+	reviewID, err := reviewSvc.CreateReview(userID, &models.CreateReviewRequest{MovieID: movieID, ReviewCategoryID: classic, Rating: 9})
+	require.NoError(t, err)
+
+	_, err = reviewSvc.ArchiveReviewCategory(moderatorID, classic, true)
+	require.NoError(t, err)
+
+	_, err = reviewSvc.CreateReview(userID, &models.CreateReviewRequest{MovieID: movieID, ReviewCategoryID: classic, Rating: 8})
+	assert.ErrorIs(t, err, errs.InvalidReference, "archived category")
+	_, err = reviewSvc.UpdateReview(userID, reviewID, 1, &models.UpdateReviewRequest{ReviewCategoryID: classic, Rating: 10})
+	require.NoError(t, err, "a review keeps its archived category")
+
+	active, err := reviewSvc.ListReviewCategories()
+	require.NoError(t, err)
+	for _, category := range active {
+		assert.NotEqual(t, classic, category.ID)
+		assert.Nil(t, category.Usage)
+	}
+
+	all, err := reviewSvc.ListAllReviewCategories(moderatorID)
+	require.NoError(t, err)
+	require.Len(t, all, len(active)+1)
+	for _, category := range all {
+		require.NotNil(t, category.Usage)
+		if category.ID == classic {
+			assert.Equal(t, 1, *category.Usage)
+		} else {
+			assert.Zero(t, *category.Usage)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package service
+
+import (
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+)
+
+func (s *movieSvc) ListMovieHistory(movieID models.ID, opts models.ListOptions) (models.Page[*models.MovieRevision], error) {
+	if _, err := s.repo.GetMovie(movieID); err != nil {
+		return models.Page[*models.MovieRevision]{}, err
+	}
+	return s.revisionRepo.ListMovieRevisions(movieID, opts)
+}
+
+// RevertMovie works out the fields of the movie at toVersion by undoing
+// every later revision, newest first. Only fields that had a value then can
+// be put back; a field that was empty at toVersion keeps its current value.
+func (s *movieSvc) RevertMovie(actorID models.ID, id models.ID, version int64, toVersion int64) (*models.Movie, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !HasPermission(actor, ResourceMovie, ActionRevert, nil) {
+		return nil, errs.Forbidden
+	}
+
+	current, err := s.repo.GetMovie(id)
+	if err != nil {
+		return nil, err
+	}
+	if current.Version != version {
+		return nil, errs.Conflict
+	}
+	if toVersion >= current.Version {
+		return nil, models.ErrInvalidRevert
+	}
+	if _, err := s.revisionRepo.GetMovieRevision(id, toVersion); err != nil {
+		return nil, err
+	}
+
+	target := *current
+	opts := models.ListOptions{
+		Sort:    "-version",
+		Filters: []models.Filter{{Field: "version", Op: models.OpGt, Value: toVersion}},
+		Limit:   revisionPageSize,
+	}
+	for {
+		revisions, err := s.revisionRepo.ListMovieRevisions(id, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, rev := range revisions.Items {
+			undoRevision(&target, rev)
+		}
+		if revisions.NextCursor == "" {
+			break
+		}
+		opts.Cursor = revisions.NextCursor
+	}
+
+	_, fields := models.DiffMovie(current, &target)
+	req := &models.UpdateMovieRequest{
+		Title:            fields.Title,
+		Year:             fields.Year,
+		DirectorID:       fields.DirectorID,
+		GenreID:          fields.GenreID,
+		ImageURL:         fields.ImageURL,
+		Runtime:          fields.Runtime,
+		OriginalLanguage: fields.OriginalLanguage,
+		Synopsis:         fields.Synopsis,
+		Poster:           fields.Poster,
+	}
+	if err := checkMovieReferences(s.personRepo, s.genreRepo, req.DirectorID, req.GenreID); err != nil {
+		return nil, err
+	}
+
+	reverted, err := s.movies.update(actorID, id, version, req, &toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	s.index(reverted)
+	return reverted, nil
+}
+
+const revisionPageSize = 100
+
+// movieWriter makes the movie writes of every service that changes movies.
+// Each write records a revision of the movie in the same unit of work, so
+// the history of a movie is complete.
+type movieWriter struct {
+	uow repository.UnitOfWork
+}
+
+func (w movieWriter) create(actorID models.ID, req *models.CreateMovieRequest) (*models.Movie, error) {
+	var created *models.Movie
+	err := w.uow.Do(func(repos repository.Repos) error {
+		var err error
+		created, err = createMovie(repos, actorID, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// createMovie creates a movie within a unit of work that is already
+// running. Like every movie write it stores the original language in its
+// canonical form.
+func createMovie(repos repository.Repos, actorID models.ID, req *models.CreateMovieRequest) (*models.Movie, error) {
+	if req.OriginalLanguage != "" {
+		language, err := models.ParseLocale(req.OriginalLanguage)
+		if err != nil {
+			return nil, err
+		}
+		req.OriginalLanguage = language
+	}
+
+	id, err := repos.Movies.CreateMovie(actorID, req)
+	if err != nil {
+		return nil, err
+	}
+	created, err := repos.Movies.GetMovie(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordChange(repos, actorID, models.RevisionCreated, nil, created, nil); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// update applies req to version of a movie. revertedTo is set when the
+// update reverts the movie to an earlier version.
+func (w movieWriter) update(actorID, id models.ID, version int64, req *models.UpdateMovieRequest, revertedTo *int64) (*models.Movie, error) {
+	var updated *models.Movie
+	err := w.uow.Do(func(repos repository.Repos) error {
+		var err error
+		updated, err = updateMovie(repos, actorID, id, version, req, revertedTo)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// updateMovie is update within a unit of work that is already running.
+func updateMovie(repos repository.Repos, actorID, id models.ID, version int64, req *models.UpdateMovieRequest, revertedTo *int64) (*models.Movie, error) {
+	action := models.RevisionUpdated
+	if revertedTo != nil {
+		action = models.RevisionReverted
+	}
+	if req.OriginalLanguage != nil && *req.OriginalLanguage != "" {
+		language, err := models.ParseLocale(*req.OriginalLanguage)
+		if err != nil {
+			return nil, err
+		}
+		req.OriginalLanguage = &language
+	}
+
+	before, err := repos.Movies.GetMovie(id)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := repos.Movies.UpdateMovie(actorID, id, version, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordChange(repos, actorID, action, before, updated, revertedTo); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (w movieWriter) delete(actorID, id models.ID) error {
+	return w.uow.Do(func(repos repository.Repos) error {
+		return deleteMovie(repos, actorID, id)
+	})
+}
+
+// deleteMovie moves a movie to the trash within a unit of work that is
+// already running. Its reviews are hidden with it.
+func deleteMovie(repos repository.Repos, actorID, id models.ID) error {
+	movie, err := repos.Movies.GetMovie(id)
+	if err != nil {
+		return err
+	}
+	if err := repos.Movies.DeleteMovie(actorID, id); err != nil {
+		return err
+	}
+	if err := repos.Reviews.TrashReviewsWithMovie(id, true); err != nil {
+		return err
+	}
+	// Moving a movie to the trash takes it to its next version.
+	_, err = repos.Revisions.CreateMovieRevision(actorID, &models.MovieRevision{MovieID: id, Version: movie.Version + 1, Action: models.RevisionDeleted})
+	return err
+}
+
+func (w movieWriter) restore(actorID, id models.ID) (*models.Movie, error) {
+	var restored *models.Movie
+	err := w.uow.Do(func(repos repository.Repos) error {
+		var err error
+		if restored, err = repos.Movies.RestoreMovie(actorID, id); err != nil {
+			return err
+		}
+		if err := repos.Reviews.TrashReviewsWithMovie(id, false); err != nil {
+			return err
+		}
+		_, err = repos.Revisions.CreateMovieRevision(actorID, &models.MovieRevision{MovieID: id, Version: restored.Version, Action: models.RevisionRestored})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
+// recordChange records the change from before to after as a revision.
+// Updates that change none of the fields a revision keeps are not recorded.
+func recordChange(repos repository.Repos, actorID models.ID, action models.RevisionAction, before, after *models.Movie, revertedTo *int64) error {
+	old, new := models.DiffMovie(before, after)
+	if action == models.RevisionUpdated && old.IsZero() && new.IsZero() {
+		return nil
+	}
+	_, err := repos.Revisions.CreateMovieRevision(actorID, &models.MovieRevision{
+		MovieID:    after.ID,
+		Version:    after.Version,
+		Action:     action,
+		Before:     old,
+		After:      new,
+		RevertedTo: revertedTo,
+	})
+	return err
+}
+
+// undoRevision puts the fields of movie that rev changed back to their
+// values before it.
+func undoRevision(movie *models.Movie, rev *models.MovieRevision) {
+	before, after := rev.Before, rev.After
+	if before.Title != nil || after.Title != nil {
+		movie.Title = valueOf(before.Title)
+	}
+	if before.Year != nil || after.Year != nil {
+		movie.Year = valueOf(before.Year)
+	}
+	if before.DirectorID != nil || after.DirectorID != nil {
+		movie.DirectorID = before.DirectorID
+	}
+	if before.GenreID != nil || after.GenreID != nil {
+		movie.GenreID = before.GenreID
+	}
+	if before.ImageURL != nil || after.ImageURL != nil {
+		movie.ImageURL = valueOf(before.ImageURL)
+	}
+	if before.Poster != nil || after.Poster != nil {
+		movie.Poster = before.Poster
+	}
+	if before.Runtime != nil || after.Runtime != nil {
+		movie.Runtime = valueOf(before.Runtime)
+	}
+	if before.OriginalLanguage != nil || after.OriginalLanguage != nil {
+		movie.OriginalLanguage = valueOf(before.OriginalLanguage)
+	}
+	if before.Synopsis != nil || after.Synopsis != nil {
+		movie.Synopsis = valueOf(before.Synopsis)
+	}
+}
+
+func valueOf[T any](p *T) T {
+	var v T
+	if p != nil {
+		v = *p
+	}
+	return v
+}
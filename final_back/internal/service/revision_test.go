@@ -0,0 +1,102 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMovieHistory(t *testing.T) {
+	users := repository.NewMemoryUserRepo()
+	movies := repository.NewMemoryMovieRepo()
+	people := repository.NewMemoryPersonRepo()
+	genres := repository.NewMemoryGenreRepo()
+	revisions := repository.NewMemoryRevisionRepo()
+	uow := repository.NewMemoryUnitOfWork(repository.Repos{Users: users, Movies: movies, Reviews: repository.NewMemoryReviewRepo(), Revisions: revisions})
+	movieSvc := NewMovieService(zap.NewNop(), movies, users, people, genres, repository.NewMemoryCreditRepo(), repository.NewMemoryTagRepo(), repository.NewMemoryReleaseRepo(), revisions, repository.NewMemorySearchIndex(), repository.NewMemoryFacetCounter(movies, repository.NewMemoryTagRepo()), uow)
+
+	adminID, err := users.CreateUser(models.NilID, &models.User{Username: "admin", Roles: []models.Role{RoleAdmin}})
+	require.NoError(t, err)
+	moderatorID, err := users.CreateUser(models.NilID, &models.User{Username: "moderator", Roles: []models.Role{RoleModerator}})
+	require.NoError(t, err)
+	userID, err := users.CreateUser(models.NilID, &models.User{Username: "neo", Roles: []models.Role{RoleUser}})
+	require.NoError(t, err)
+	genreID, err := genres.CreateGenre(adminID, &models.CreateGenreRequest{Name: "Science Fiction"})
+	require.NoError(t, err)
+
+	title, year := "The Matrix", 2000
+	movieID, err := movieSvc.CreateMovie(adminID, &models.CreateMovieRequest{Title: "matrix", Year: 1999})
+	require.NoError(t, err)
+	_, err = movieSvc.UpdateMovie(adminID, movieID, 1, &models.UpdateMovieRequest{Title: &title, GenreID: &genreID})
+	require.NoError(t, err)
+	_, err = movieSvc.UpdateMovie(adminID, movieID, 2, &models.UpdateMovieRequest{Year: &year})
+	require.NoError(t, err)
+	_, err = movieSvc.UpdateMovie(adminID, movieID, 3, &models.UpdateMovieRequest{Year: &year})
+	require.NoError(t, err)
+
+	history, err := movieSvc.ListMovieHistory(movieID, models.ListOptions{Sort: "version"})
+	require.NoError(t, err)
+	require.Len(t, history.Items, 3, "an update that changes nothing is not recorded")
+	created, renamed := history.Items[0], history.Items[1]
+	assert.Equal(t, models.RevisionCreated, created.Action)
+	assert.Equal(t, "matrix", *created.After.Title)
+	assert.Nil(t, created.Before.Title)
+	assert.Equal(t, adminID, *created.CreatedBy)
+	assert.Equal(t, int64(2), renamed.Version)
+	assert.Equal(t, "matrix", *renamed.Before.Title)
+	assert.Equal(t, "The Matrix", *renamed.After.Title)
+	assert.Nil(t, renamed.Before.GenreID)
+	assert.Equal(t, genreID, *renamed.After.GenreID)
+	assert.Nil(t, renamed.After.Year)
+
+	t.Run("Revert", func(t *testing.T) {
+		_, err := movieSvc.RevertMovie(userID, movieID, 4, 2)
+		assert.ErrorIs(t, err, errs.Forbidden)
+		_, err = movieSvc.RevertMovie(moderatorID, movieID, 3, 2)
+		assert.ErrorIs(t, err, errs.Conflict)
+		_, err = movieSvc.RevertMovie(moderatorID, movieID, 4, 4)
+		assert.ErrorIs(t, err, models.ErrInvalidRevert)
+		_, err = movieSvc.RevertMovie(moderatorID, models.NewID(), 4, 1)
+		assert.ErrorIs(t, err, errs.NotFound)
+
+		reverted, err := movieSvc.RevertMovie(moderatorID, movieID, 4, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), reverted.Version)
+		assert.Equal(t, "matrix", reverted.Title)
+		assert.Equal(t, 1999, reverted.Year)
+		assert.Equal(t, genreID, *reverted.GenreID, "a field empty at the version keeps its value")
+
+		rev, err := revisions.GetMovieRevision(movieID, 5)
+		require.NoError(t, err)
+		assert.Equal(t, models.RevisionReverted, rev.Action)
+		assert.Equal(t, int64(1), *rev.RevertedTo)
+		assert.Equal(t, moderatorID, *rev.CreatedBy)
+
+		_, err = movieSvc.RevertMovie(moderatorID, movieID, 5, 4)
+		assert.ErrorIs(t, err, errs.NotFound, "version 4 changed nothing and was not recorded")
+		reverted, err = movieSvc.RevertMovie(moderatorID, movieID, 5, 3)
+		require.NoError(t, err)
+		assert.Equal(t, "The Matrix", reverted.Title, "a revert can itself be undone")
+		assert.Equal(t, 2000, reverted.Year)
+	})
+
+	t.Run("Trash", func(t *testing.T) {
+		require.NoError(t, movieSvc.DeleteMovie(adminID, movieID))
+		_, err := movieSvc.ListMovieHistory(movieID, models.ListOptions{})
+		assert.ErrorIs(t, err, errs.NotFound)
+
+		restored, err := movieSvc.RestoreMovie(adminID, movieID)
+		require.NoError(t, err)
+		history, err := movieSvc.ListMovieHistory(movieID, models.ListOptions{Sort: "-version", Limit: 2})
+		require.NoError(t, err)
+		require.Len(t, history.Items, 2)
+		assert.Equal(t, models.RevisionRestored, history.Items[0].Action)
+		assert.Equal(t, restored.Version, history.Items[0].Version)
+		assert.Equal(t, models.RevisionDeleted, history.Items[1].Action)
+	})
+}
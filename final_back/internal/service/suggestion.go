@@ -0,0 +1,245 @@
+package service
+
+import (
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"go.uber.org/zap"
+)
+
+// SuggestionService lets users propose new movies and corrections that
+// moderators then approve or reject. Approved changes are made as if the
+// moderator had made them, in the same unit of work that resolves the
+// suggestion.
+type SuggestionService interface {
+	// ListSuggestions lists every suggestion to moderators and only their
+	// own to everyone else.
+	ListSuggestions(actorID models.ID, opts models.ListOptions) (models.Page[*models.Suggestion], error)
+	GetSuggestion(actorID models.ID, id models.ID) (*models.Suggestion, error)
+	CreateSuggestion(actorID models.ID, req *models.CreateSuggestionRequest) (models.ID, error)
+	ApproveSuggestion(actorID models.ID, id models.ID) (*models.Suggestion, error)
+	RejectSuggestion(actorID models.ID, id models.ID, reason string) (*models.Suggestion, error)
+}
+
+type suggestionSvc struct {
+	log        *zap.Logger
+	repo       repository.SuggestionRepo
+	movieRepo  repository.MovieRepo
+	userRepo   repository.UserRepo
+	personRepo repository.PersonRepo
+	genreRepo  repository.GenreRepo
+	uow        repository.UnitOfWork
+	search     repository.SearchIndex
+}
+
+func NewSuggestionService(log *zap.Logger, repo repository.SuggestionRepo, movieRepo repository.MovieRepo, userRepo repository.UserRepo, personRepo repository.PersonRepo, genreRepo repository.GenreRepo, uow repository.UnitOfWork, search repository.SearchIndex) SuggestionService {
+	return &suggestionSvc{
+		log:        log,
+		repo:       repo,
+		movieRepo:  movieRepo,
+		userRepo:   userRepo,
+		personRepo: personRepo,
+		genreRepo:  genreRepo,
+		uow:        uow,
+		search:     search,
+	}
+}
+
+func (s *suggestionSvc) ListSuggestions(actorID models.ID, opts models.ListOptions) (models.Page[*models.Suggestion], error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return models.Page[*models.Suggestion]{}, err
+	}
+
+	if !HasPermission(actor, ResourceSuggestion, ActionView, nil) {
+		opts.Filters = append(opts.Filters, models.Filter{Field: "createdBy", Op: models.OpEq, Value: actorID})
+	}
+	return s.repo.ListSuggestions(opts)
+}
+
+func (s *suggestionSvc) GetSuggestion(actorID models.ID, id models.ID) (*models.Suggestion, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestion, err := s.repo.GetSuggestion(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !HasPermission(actor, ResourceSuggestion, ActionView, suggestion) {
+		return nil, errs.Forbidden
+	}
+	return suggestion, nil
+}
+
+// CreateSuggestion queues req for moderation. Corrections that would leave
+// a movie as it is are turned away, as are new movies missing any of the
+// fields a movie is created with.
+func (s *suggestionSvc) CreateSuggestion(actorID models.ID, req *models.CreateSuggestionRequest) (models.ID, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return models.NilID, err
+	}
+
+	if !HasPermission(actor, ResourceSuggestion, ActionCreate, nil) {
+		return models.NilID, errs.Forbidden
+	}
+
+	if err := checkMovieReferences(s.personRepo, s.genreRepo, req.DirectorID, req.GenreID); err != nil {
+		return models.NilID, err
+	}
+
+	suggestion := &models.Suggestion{Title: req.Title, Year: req.Year, DirectorID: req.DirectorID, GenreID: req.GenreID, ImageURL: req.ImageURL}
+	if req.MovieID == nil {
+		if !completeMovie(suggestion) {
+			return models.NilID, models.ErrInvalidSuggestion
+		}
+		return s.repo.CreateSuggestion(actorID, suggestion)
+	}
+
+	movie, err := s.movieRepo.GetMovie(*req.MovieID)
+	if err == errs.NotFound {
+		return models.NilID, errs.InvalidReference
+	}
+	if err != nil {
+		return models.NilID, err
+	}
+	if req.Title != nil && *req.Title == movie.Title {
+		suggestion.Title = nil
+	}
+	if req.Year != nil && *req.Year == movie.Year {
+		suggestion.Year = nil
+	}
+	if req.DirectorID != nil && movie.DirectorID != nil && *req.DirectorID == *movie.DirectorID {
+		suggestion.DirectorID = nil
+	}
+	if req.GenreID != nil && movie.GenreID != nil && *req.GenreID == *movie.GenreID {
+		suggestion.GenreID = nil
+	}
+	if req.ImageURL != nil && *req.ImageURL == movie.ImageURL {
+		suggestion.ImageURL = nil
+	}
+	if suggestion.Title == nil && suggestion.Year == nil && suggestion.DirectorID == nil && suggestion.GenreID == nil && suggestion.ImageURL == nil {
+		return models.NilID, models.ErrInvalidSuggestion
+	}
+	suggestion.MovieID = &movie.ID
+	suggestion.MovieVersion = movie.Version
+	return s.repo.CreateSuggestion(actorID, suggestion)
+}
+
+// ApproveSuggestion makes the change a suggestion proposes. Corrections
+// only apply to the movie as it was when they were made; errs.Conflict
+// means it has been changed since. The change and the resolution happen
+// together, so a suggestion approved twice at once changes the catalogue
+// only once.
+func (s *suggestionSvc) ApproveSuggestion(actorID models.ID, id models.ID) (*models.Suggestion, error) {
+	suggestion, err := s.pending(actorID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Suggestions made before new movies needed every field cannot be
+	// approved as they are.
+	if suggestion.MovieID == nil && !completeMovie(suggestion) {
+		return nil, models.ErrInvalidSuggestion
+	}
+	if err := checkMovieReferences(s.personRepo, s.genreRepo, suggestion.DirectorID, suggestion.GenreID); err != nil {
+		return nil, err
+	}
+
+	var movieID models.ID
+	var resolved *models.Suggestion
+	err = s.uow.Do(func(repos repository.Repos) error {
+		if suggestion.MovieID == nil {
+			created, err := createMovie(repos, actorID, &models.CreateMovieRequest{
+				Title:      *suggestion.Title,
+				Year:       *suggestion.Year,
+				DirectorID: suggestion.DirectorID,
+				GenreID:    suggestion.GenreID,
+				ImageURL:   *suggestion.ImageURL,
+			})
+			if err != nil {
+				return err
+			}
+			movieID = created.ID
+		} else {
+			movieID = *suggestion.MovieID
+			_, err := updateMovie(repos, actorID, movieID, suggestion.MovieVersion, &models.UpdateMovieRequest{
+				Title:      suggestion.Title,
+				Year:       suggestion.Year,
+				DirectorID: suggestion.DirectorID,
+				GenreID:    suggestion.GenreID,
+				ImageURL:   suggestion.ImageURL,
+			}, nil)
+			if err == errs.NotFound {
+				// The movie has been moved to the trash.
+				return errs.InvalidReference
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		var err error
+		resolved, err = resolve(repos.Suggestions, actorID, suggestion, models.SuggestionApproved, "", &movieID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reindexMovie(s.log, s.movieRepo, s.search, movieID)
+	return resolved, nil
+}
+
+func (s *suggestionSvc) RejectSuggestion(actorID models.ID, id models.ID, reason string) (*models.Suggestion, error) {
+	suggestion, err := s.pending(actorID, id)
+	if err != nil {
+		return nil, err
+	}
+	return resolve(s.repo, actorID, suggestion, models.SuggestionRejected, reason, nil)
+}
+
+// pending returns a suggestion that actorID may moderate and that is still
+// waiting for it.
+func (s *suggestionSvc) pending(actorID, id models.ID) (*models.Suggestion, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !HasPermission(actor, ResourceSuggestion, ActionModerate, nil) {
+		return nil, errs.Forbidden
+	}
+
+	suggestion, err := s.repo.GetSuggestion(id)
+	if err != nil {
+		return nil, err
+	}
+	if suggestion.Status != models.SuggestionPending {
+		return nil, models.ErrSuggestionResolved
+	}
+	return suggestion, nil
+}
+
+// resolve sets the status of a suggestion read by pending.
+func resolve(repo repository.SuggestionRepo, actorID models.ID, suggestion *models.Suggestion, status models.SuggestionStatus, reason string, movieID *models.ID) (*models.Suggestion, error) {
+	resolved, err := repo.ResolveSuggestion(actorID, suggestion.ID, suggestion.Version, status, reason, movieID)
+	if err == errs.Conflict {
+		// Another moderator got there first.
+		return nil, models.ErrSuggestionResolved
+	}
+	return resolved, err
+}
+
+// completeMovie reports whether a new movie suggestion has every field that
+// models.CreateMovieRequest requires, so that approving it makes a movie
+// that could have been created directly.
+func completeMovie(suggestion *models.Suggestion) bool {
+	return suggestion.Title != nil && *suggestion.Title != "" &&
+		suggestion.Year != nil && *suggestion.Year != 0 &&
+		suggestion.DirectorID != nil && suggestion.GenreID != nil &&
+		suggestion.ImageURL != nil && *suggestion.ImageURL != ""
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSuggestions(t *testing.T) {
+	users := repository.NewMemoryUserRepo()
+	movies := repository.NewMemoryMovieRepo()
+	revisions := repository.NewMemoryRevisionRepo()
+	suggestions := repository.NewMemorySuggestionRepo()
+	uow := &hookedUnitOfWork{UnitOfWork: repository.NewMemoryUnitOfWork(repository.Repos{Users: users, Movies: movies, Revisions: revisions, Suggestions: suggestions})}
+	people := repository.NewMemoryPersonRepo()
+	genres := repository.NewMemoryGenreRepo()
+	search := repository.NewMemorySearchIndex()
+	movieSvc := NewMovieService(zap.NewNop(), movies, users, people, genres, repository.NewMemoryCreditRepo(), repository.NewMemoryTagRepo(), repository.NewMemoryReleaseRepo(), revisions, search, repository.NewMemoryFacetCounter(movies, repository.NewMemoryTagRepo()), uow)
+	suggestionSvc := NewSuggestionService(zap.NewNop(), suggestions, movies, users, people, genres, uow, search)
+
+	moderatorID, err := users.CreateUser(models.NilID, &models.User{Username: "moderator", Roles: []models.Role{RoleModerator}})
+	require.NoError(t, err)
+	userID, err := users.CreateUser(models.NilID, &models.User{Username: "neo", Roles: []models.Role{RoleUser}})
+	require.NoError(t, err)
+	otherID, err := users.CreateUser(models.NilID, &models.User{Username: "trinity", Roles: []models.Role{RoleUser}})
+	require.NoError(t, err)
+	movieID, err := movieSvc.CreateMovie(moderatorID, &models.CreateMovieRequest{Title: "matrix", Year: 1999})
+	require.NoError(t, err)
+
+	title, year, oldYear, imageURL := "The Matrix", 2003, 1999, "https://example.com/matrix.jpg"
+	unknown := models.NewID()
+	directorID, err := people.CreatePerson(moderatorID, &models.CreatePersonRequest{Name: "Lana Wachowski"})
+	require.NoError(t, err)
+	genreID, err := genres.CreateGenre(moderatorID, &models.CreateGenreRequest{Name: "Sci-Fi"})
+	require.NoError(t, err)
+	newMovie := func() *models.CreateSuggestionRequest {
+		return &models.CreateSuggestionRequest{Title: &title, Year: &year, DirectorID: &directorID, GenreID: &genreID, ImageURL: &imageURL}
+	}
+
+	t.Run("Create", func(t *testing.T) {
+		_, err := suggestionSvc.CreateSuggestion(userID, &models.CreateSuggestionRequest{Title: &title})
+		assert.ErrorIs(t, err, models.ErrInvalidSuggestion, "a new movie needs a year")
+		_, err = suggestionSvc.CreateSuggestion(userID, &models.CreateSuggestionRequest{Title: &title, Year: &year, ImageURL: &imageURL})
+		assert.ErrorIs(t, err, models.ErrInvalidSuggestion, "a new movie needs a director and a genre")
+		unknownDirector := newMovie()
+		unknownDirector.DirectorID = &unknown
+		_, err = suggestionSvc.CreateSuggestion(userID, unknownDirector)
+		assert.ErrorIs(t, err, errs.InvalidReference, "unknown director")
+		_, err = suggestionSvc.CreateSuggestion(userID, &models.CreateSuggestionRequest{MovieID: &movieID, Year: &oldYear})
+		assert.ErrorIs(t, err, models.ErrInvalidSuggestion, "the movie already has that year")
+		_, err = suggestionSvc.CreateSuggestion(userID, &models.CreateSuggestionRequest{MovieID: &unknown, Title: &title})
+		assert.ErrorIs(t, err, errs.InvalidReference)
+
+		id, err := suggestionSvc.CreateSuggestion(userID, &models.CreateSuggestionRequest{MovieID: &movieID, Title: &title, Year: &oldYear})
+		require.NoError(t, err)
+		suggestion, err := suggestionSvc.GetSuggestion(userID, id)
+		require.NoError(t, err)
+		assert.Equal(t, models.SuggestionPending, suggestion.Status)
+		assert.Equal(t, int64(1), suggestion.MovieVersion)
+		assert.Equal(t, &title, suggestion.Title)
+		assert.Nil(t, suggestion.Year, "unchanged fields are left out")
+
+		_, err = suggestionSvc.GetSuggestion(otherID, id)
+		assert.ErrorIs(t, err, errs.Forbidden)
+		_, err = suggestionSvc.GetSuggestion(moderatorID, id)
+		assert.NoError(t, err)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		_, err := suggestionSvc.CreateSuggestion(otherID, newMovie())
+		require.NoError(t, err)
+
+		page, err := suggestionSvc.ListSuggestions(userID, models.ListOptions{})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1, "users only see their own suggestions")
+		assert.Equal(t, &userID, page.Items[0].CreatedBy)
+		page, err = suggestionSvc.ListSuggestions(moderatorID, models.ListOptions{})
+		require.NoError(t, err)
+		assert.Len(t, page.Items, 2)
+	})
+
+	t.Run("Approve", func(t *testing.T) {
+		correction, err := suggestionSvc.CreateSuggestion(userID, &models.CreateSuggestionRequest{MovieID: &movieID, Year: &year})
+		require.NoError(t, err)
+		created, err := suggestionSvc.CreateSuggestion(userID, newMovie())
+		require.NoError(t, err)
+
+		_, err = suggestionSvc.ApproveSuggestion(userID, correction)
+		assert.ErrorIs(t, err, errs.Forbidden)
+
+		approved, err := suggestionSvc.ApproveSuggestion(moderatorID, correction)
+		require.NoError(t, err)
+		assert.Equal(t, models.SuggestionApproved, approved.Status)
+		movie, err := movieSvc.GetMovie(movieID)
+		require.NoError(t, err)
+		assert.Equal(t, year, movie.Year)
+		assert.Equal(t, "matrix", movie.Title)
+		rev, err := revisions.GetMovieRevision(movieID, movie.Version)
+		require.NoError(t, err)
+		assert.Equal(t, &moderatorID, rev.CreatedBy, "the change is made as the moderator")
+
+		_, err = suggestionSvc.ApproveSuggestion(moderatorID, correction)
+		assert.ErrorIs(t, err, models.ErrSuggestionResolved)
+
+		approved, err = suggestionSvc.ApproveSuggestion(moderatorID, created)
+		require.NoError(t, err)
+		require.NotNil(t, approved.MovieID)
+		movie, err = movieSvc.GetMovie(*approved.MovieID)
+		require.NoError(t, err)
+		assert.Equal(t, title, movie.Title)
+		assert.Equal(t, year, movie.Year)
+		assert.Equal(t, &directorID, movie.DirectorID)
+		assert.Equal(t, &genreID, movie.GenreID)
+		assert.Equal(t, imageURL, movie.ImageURL)
+
+		legacy, err := suggestions.CreateSuggestion(userID, &models.Suggestion{Title: &title, Year: &year})
+		require.NoError(t, err)
+		_, err = suggestionSvc.ApproveSuggestion(moderatorID, legacy)
+		assert.ErrorIs(t, err, models.ErrInvalidSuggestion, "suggestions missing fields a movie needs are not approved")
+
+		hits, err := search.SearchMovies(title, 10)
+		require.NoError(t, err)
+		assert.Len(t, hits, 1, "approved movies are indexed")
+	})
+
+	t.Run("ApproveRace", func(t *testing.T) {
+		id, err := suggestionSvc.CreateSuggestion(userID, newMovie())
+		require.NoError(t, err)
+		before, err := movies.ListMovies(models.ListOptions{})
+		require.NoError(t, err)
+
+		// Another moderator resolves the suggestion after it was read but
+		// before it is approved.
+		uow.before = func() {
+			_, err := suggestionSvc.RejectSuggestion(moderatorID, id, "duplicate")
+			require.NoError(t, err)
+		}
+		defer func() { uow.before = nil }()
+		_, err = suggestionSvc.ApproveSuggestion(moderatorID, id)
+		assert.ErrorIs(t, err, models.ErrSuggestionResolved)
+
+		after, err := movies.ListMovies(models.ListOptions{})
+		require.NoError(t, err)
+		assert.Len(t, after.Items, len(before.Items), "the losing approval creates no movie")
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		page, err := suggestionSvc.ListSuggestions(moderatorID, models.ListOptions{
+			Filters: []models.Filter{{Field: "status", Op: models.OpEq, Value: string(models.SuggestionPending)}, {Field: "movieId", Op: models.OpEq, Value: movieID}},
+		})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1)
+		stale := page.Items[0].ID
+
+		_, err = suggestionSvc.ApproveSuggestion(moderatorID, stale)
+		assert.ErrorIs(t, err, errs.Conflict, "the movie has changed since the suggestion was made")
+
+		rejected, err := suggestionSvc.RejectSuggestion(moderatorID, stale, "outdated")
+		require.NoError(t, err)
+		assert.Equal(t, models.SuggestionRejected, rejected.Status)
+		assert.Equal(t, "outdated", rejected.Reason)
+		_, err = suggestionSvc.RejectSuggestion(moderatorID, stale, "outdated")
+		assert.ErrorIs(t, err, models.ErrSuggestionResolved)
+		_, err = suggestionSvc.RejectSuggestion(moderatorID, models.NewID(), "unknown")
+		assert.ErrorIs(t, err, errs.NotFound)
+	})
+}
+
+// hookedUnitOfWork calls before, if set, ahead of each unit of work, to let
+// tests make concurrent writes at the worst moment.
+type hookedUnitOfWork struct {
+	repository.UnitOfWork
+	before func()
+}
+
+func (u *hookedUnitOfWork) Do(fn func(repos repository.Repos) error) error {
+	if u.before != nil {
+		u.before()
+	}
+	return u.UnitOfWork.Do(fn)
+}
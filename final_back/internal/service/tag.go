@@ -0,0 +1,322 @@
+package service
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"go.uber.org/zap"
+)
+
+// TagService manages tags and puts them on movies. Moderators tag movies
+// directly, while users' tags are suggestions that wait for a moderator.
+type TagService interface {
+	ListTags(opts models.ListOptions) (models.Page[*models.Tag], error)
+	GetTag(id models.ID) (*models.Tag, error)
+	// CreateTag makes a curated tag.
+	CreateTag(actorID models.ID, req *models.CreateTagRequest) (models.ID, error)
+	UpdateTag(actorID models.ID, id models.ID, version int64, req *models.UpdateTagRequest) (*models.Tag, error)
+	DeleteTag(actorID models.ID, id models.ID) error
+
+	// ListMovieTags lists the approved tags of a movie that is not in the
+	// trash.
+	ListMovieTags(movieID models.ID) ([]*models.Tag, error)
+	// TagMovie puts a tag on a movie, or suggests it when actorID may not tag
+	// movies directly. A tag named that does not exist yet is made as well.
+	TagMovie(actorID models.ID, movieID models.ID, req *models.TagMovieRequest) (*models.MovieTag, error)
+	UntagMovie(actorID models.ID, movieID models.ID, tagID models.ID) error
+
+	// ListTagSuggestions lists every tag suggestion to moderators and only
+	// their own to everyone else.
+	ListTagSuggestions(actorID models.ID, opts models.ListOptions) (models.Page[*models.MovieTag], error)
+	ApproveTagSuggestion(actorID models.ID, id models.ID) (*models.MovieTag, error)
+	RejectTagSuggestion(actorID models.ID, id models.ID, reason string) (*models.MovieTag, error)
+}
+
+type tagSvc struct {
+	log       *zap.Logger
+	repo      repository.TagRepo
+	movieRepo repository.MovieRepo
+	userRepo  repository.UserRepo
+	uow       repository.UnitOfWork
+}
+
+func NewTagService(log *zap.Logger, repo repository.TagRepo, movieRepo repository.MovieRepo, userRepo repository.UserRepo, uow repository.UnitOfWork) TagService {
+	return &tagSvc{
+		log:       log,
+		repo:      repo,
+		movieRepo: movieRepo,
+		userRepo:  userRepo,
+		uow:       uow,
+	}
+}
+
+func (s *tagSvc) ListTags(opts models.ListOptions) (models.Page[*models.Tag], error) {
+	return s.repo.ListTags(opts)
+}
+
+func (s *tagSvc) GetTag(id models.ID) (*models.Tag, error) {
+	return s.repo.GetTag(id)
+}
+
+func (s *tagSvc) CreateTag(actorID models.ID, req *models.CreateTagRequest) (models.ID, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return models.NilID, err
+	}
+
+	if !HasPermission(actor, ResourceTag, ActionCreate, nil) {
+		return models.NilID, errs.Forbidden
+	}
+
+	name := models.NormalizeTagName(req.Name)
+	if name == "" {
+		return models.NilID, models.ErrInvalidTag
+	}
+	return s.repo.CreateTag(actorID, &models.Tag{Name: name, Curated: true})
+}
+
+func (s *tagSvc) UpdateTag(actorID models.ID, id models.ID, version int64, req *models.UpdateTagRequest) (*models.Tag, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !HasPermission(actor, ResourceTag, ActionUpdate, nil) {
+		return nil, errs.Forbidden
+	}
+
+	if req.Name != nil {
+		name := models.NormalizeTagName(*req.Name)
+		if name == "" {
+			return nil, models.ErrInvalidTag
+		}
+		req.Name = &name
+	}
+	return s.repo.UpdateTag(actorID, id, version, req)
+}
+
+func (s *tagSvc) DeleteTag(actorID models.ID, id models.ID) error {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return err
+	}
+
+	if !HasPermission(actor, ResourceTag, ActionDelete, nil) {
+		return errs.Forbidden
+	}
+	return s.repo.DeleteTag(id)
+}
+
+func (s *tagSvc) ListMovieTags(movieID models.ID) ([]*models.Tag, error) {
+	if _, err := s.movieRepo.GetMovie(movieID); err != nil {
+		return nil, err
+	}
+
+	movieTags, err := s.repo.ListMovieTags(models.ListOptions{Filters: []models.Filter{
+		{Field: "movieId", Op: models.OpEq, Value: movieID},
+		{Field: "status", Op: models.OpEq, Value: string(models.SuggestionApproved)},
+	}})
+	if err != nil {
+		return nil, err
+	}
+	ids := []models.ID{}
+	for _, t := range movieTags.Items {
+		ids = append(ids, *t.TagID)
+	}
+
+	tags, err := s.repo.GetTags(ids)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(tags, func(a, b *models.Tag) int { return strings.Compare(a.Name, b.Name) })
+	return tags, nil
+}
+
+// TagMovie turns away a request that names both an existing tag and a new
+// one, or neither. A new tag named like an existing one is that tag.
+func (s *tagSvc) TagMovie(actorID models.ID, movieID models.ID, req *models.TagMovieRequest) (*models.MovieTag, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	direct := HasPermission(actor, ResourceTag, ActionCreate, nil)
+	if !direct && !HasPermission(actor, ResourceSuggestion, ActionCreate, nil) {
+		return nil, errs.Forbidden
+	}
+	if (req.TagID == nil) == (req.Name == nil) {
+		return nil, models.ErrInvalidTag
+	}
+
+	if _, err := s.movieRepo.GetMovie(movieID); err == errs.NotFound {
+		return nil, errs.InvalidReference
+	} else if err != nil {
+		return nil, err
+	}
+
+	movieTag := &models.MovieTag{MovieID: movieID, TagID: req.TagID}
+	if req.Name != nil {
+		name := models.NormalizeTagName(*req.Name)
+		if name == "" {
+			return nil, models.ErrInvalidTag
+		}
+		tag, err := s.repo.GetTagByName(name)
+		if err == nil {
+			movieTag.TagID = &tag.ID
+		} else if err != errs.NotFound {
+			return nil, err
+		} else if taken, err := s.suggested(movieID, name); err != nil {
+			return nil, err
+		} else if taken {
+			return nil, errs.AlreadyExists
+		} else {
+			movieTag.Name = name
+		}
+	} else if _, err := s.repo.GetTag(*req.TagID); err == errs.NotFound {
+		return nil, errs.InvalidReference
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !direct {
+		id, err := s.repo.CreateMovieTag(actorID, movieTag)
+		if err != nil {
+			return nil, err
+		}
+		return s.repo.GetMovieTag(id)
+	}
+
+	movieTag.Status = models.SuggestionApproved
+	var id models.ID
+	err = s.uow.Do(func(repos repository.Repos) error {
+		if movieTag.TagID == nil {
+			tagID, err := repos.Tags.CreateTag(actorID, &models.Tag{Name: movieTag.Name})
+			if err != nil {
+				return err
+			}
+			movieTag.TagID, movieTag.Name = &tagID, ""
+		}
+		id, err = repos.Tags.CreateMovieTag(actorID, movieTag)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetMovieTag(id)
+}
+
+// suggested reports whether a new tag called name is already waiting to be
+// put on movieID.
+func (s *tagSvc) suggested(movieID models.ID, name string) (bool, error) {
+	pending, err := s.repo.ListMovieTags(models.ListOptions{Filters: []models.Filter{
+		{Field: "movieId", Op: models.OpEq, Value: movieID},
+		{Field: "status", Op: models.OpEq, Value: string(models.SuggestionPending)},
+	}})
+	if err != nil {
+		return false, err
+	}
+	for _, t := range pending.Items {
+		if t.TagID == nil && t.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *tagSvc) UntagMovie(actorID models.ID, movieID models.ID, tagID models.ID) error {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return err
+	}
+
+	if !HasPermission(actor, ResourceTag, ActionDelete, nil) {
+		return errs.Forbidden
+	}
+	return s.repo.DeleteMovieTag(movieID, tagID)
+}
+
+func (s *tagSvc) ListTagSuggestions(actorID models.ID, opts models.ListOptions) (models.Page[*models.MovieTag], error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return models.Page[*models.MovieTag]{}, err
+	}
+
+	if !HasPermission(actor, ResourceTag, ActionModerate, nil) {
+		opts.Filters = append(opts.Filters, models.Filter{Field: "createdBy", Op: models.OpEq, Value: actorID})
+	}
+	return s.repo.ListMovieTags(opts)
+}
+
+// ApproveTagSuggestion makes the new tag a suggestion names, unless a tag
+// of that name has been made since, in which case the suggestion becomes
+// that tag.
+func (s *tagSvc) ApproveTagSuggestion(actorID models.ID, id models.ID) (*models.MovieTag, error) {
+	suggestion, err := s.pending(actorID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var approved *models.MovieTag
+	err = s.uow.Do(func(repos repository.Repos) error {
+		tagID := suggestion.TagID
+		if tagID == nil {
+			tag, err := repos.Tags.GetTagByName(suggestion.Name)
+			if err == errs.NotFound {
+				created, err := repos.Tags.CreateTag(actorID, &models.Tag{Name: suggestion.Name})
+				if err != nil {
+					return err
+				}
+				tagID = &created
+			} else if err != nil {
+				return err
+			} else {
+				tagID = &tag.ID
+			}
+		}
+		approved, err = repos.Tags.ResolveMovieTag(actorID, suggestion.ID, suggestion.Version, models.SuggestionApproved, "", tagID)
+		return err
+	})
+	if err == errs.Conflict {
+		// Another moderator got there first.
+		return nil, models.ErrSuggestionResolved
+	}
+	return approved, err
+}
+
+func (s *tagSvc) RejectTagSuggestion(actorID models.ID, id models.ID, reason string) (*models.MovieTag, error) {
+	suggestion, err := s.pending(actorID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rejected, err := s.repo.ResolveMovieTag(actorID, suggestion.ID, suggestion.Version, models.SuggestionRejected, reason, nil)
+	if err == errs.Conflict {
+		return nil, models.ErrSuggestionResolved
+	}
+	return rejected, err
+}
+
+// pending returns a tag suggestion that actorID may moderate and that is
+// still waiting for it.
+func (s *tagSvc) pending(actorID, id models.ID) (*models.MovieTag, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !HasPermission(actor, ResourceTag, ActionModerate, nil) {
+		return nil, errs.Forbidden
+	}
+
+	suggestion, err := s.repo.GetMovieTag(id)
+	if err != nil {
+		return nil, err
+	}
+	if suggestion.Status != models.SuggestionPending {
+		return nil, models.ErrSuggestionResolved
+	}
+	return suggestion, nil
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTags(t *testing.T) {
+	users := repository.NewMemoryUserRepo()
+	movies := repository.NewMemoryMovieRepo()
+	genres := repository.NewMemoryGenreRepo()
+	tags := repository.NewMemoryTagRepo()
+	revisions := repository.NewMemoryRevisionRepo()
+	uow := repository.NewMemoryUnitOfWork(repository.Repos{Users: users, Movies: movies, Revisions: revisions, Tags: tags})
+	movieSvc := NewMovieService(zap.NewNop(), movies, users, repository.NewMemoryPersonRepo(), genres, repository.NewMemoryCreditRepo(), tags, repository.NewMemoryReleaseRepo(), revisions, repository.NewMemorySearchIndex(), repository.NewMemoryFacetCounter(movies, tags), uow)
+	tagSvc := NewTagService(zap.NewNop(), tags, movies, users, uow)
+
+	moderatorID, err := users.CreateUser(models.NilID, &models.User{Username: "moderator", Roles: []models.Role{RoleModerator}})
+	require.NoError(t, err)
+	userID, err := users.CreateUser(models.NilID, &models.User{Username: "neo", Roles: []models.Role{RoleUser}})
+	require.NoError(t, err)
+	otherID, err := users.CreateUser(models.NilID, &models.User{Username: "trinity", Roles: []models.Role{RoleUser}})
+	require.NoError(t, err)
+	scifi, err := genres.CreateGenre(moderatorID, &models.CreateGenreRequest{Name: "Science Fiction"})
+	require.NoError(t, err)
+	matrix, err := movieSvc.CreateMovie(moderatorID, &models.CreateMovieRequest{Title: "The Matrix", Year: 1999, GenreID: &scifi})
+	require.NoError(t, err)
+	primer, err := movieSvc.CreateMovie(moderatorID, &models.CreateMovieRequest{Title: "Primer", Year: 2004, GenreID: &scifi})
+	require.NoError(t, err)
+	heat, err := movieSvc.CreateMovie(moderatorID, &models.CreateMovieRequest{Title: "Heat", Year: 1995})
+	require.NoError(t, err)
+
+	name := func(name string) *string { return &name }
+	unknown := models.NewID()
+
+	var timeTravel models.ID
+	t.Run("Create", func(t *testing.T) {
+		_, err := tagSvc.CreateTag(userID, &models.CreateTagRequest{Name: "heist"})
+		assert.ErrorIs(t, err, errs.Forbidden)
+		_, err = tagSvc.CreateTag(moderatorID, &models.CreateTagRequest{Name: "   "})
+		assert.ErrorIs(t, err, models.ErrInvalidTag)
+
+		timeTravel, err = tagSvc.CreateTag(moderatorID, &models.CreateTagRequest{Name: " Time  Travel "})
+		require.NoError(t, err)
+		tag, err := tagSvc.GetTag(timeTravel)
+		require.NoError(t, err)
+		assert.Equal(t, "time travel", tag.Name)
+		assert.True(t, tag.Curated)
+
+		_, err = tagSvc.CreateTag(moderatorID, &models.CreateTagRequest{Name: "TIME TRAVEL"})
+		assert.ErrorIs(t, err, errs.AlreadyExists)
+	})
+
+	t.Run("TagMovie", func(t *testing.T) {
+		_, err := tagSvc.TagMovie(moderatorID, primer, &models.TagMovieRequest{})
+		assert.ErrorIs(t, err, models.ErrInvalidTag)
+		_, err = tagSvc.TagMovie(moderatorID, primer, &models.TagMovieRequest{TagID: &timeTravel, Name: name("heist")})
+		assert.ErrorIs(t, err, models.ErrInvalidTag)
+		_, err = tagSvc.TagMovie(moderatorID, unknown, &models.TagMovieRequest{TagID: &timeTravel})
+		assert.ErrorIs(t, err, errs.InvalidReference)
+		_, err = tagSvc.TagMovie(moderatorID, primer, &models.TagMovieRequest{TagID: &unknown})
+		assert.ErrorIs(t, err, errs.InvalidReference)
+
+		tagged, err := tagSvc.TagMovie(moderatorID, primer, &models.TagMovieRequest{TagID: &timeTravel})
+		require.NoError(t, err)
+		assert.Equal(t, models.SuggestionApproved, tagged.Status)
+		_, err = tagSvc.TagMovie(moderatorID, primer, &models.TagMovieRequest{Name: name("Time Travel")})
+		assert.ErrorIs(t, err, errs.AlreadyExists, "a name resolves to the existing tag")
+
+		tagged, err = tagSvc.TagMovie(moderatorID, heat, &models.TagMovieRequest{Name: name("Heist")})
+		require.NoError(t, err)
+		assert.Equal(t, models.SuggestionApproved, tagged.Status)
+		require.NotNil(t, tagged.TagID)
+		heist, err := tagSvc.GetTag(*tagged.TagID)
+		require.NoError(t, err)
+		assert.Equal(t, "heist", heist.Name)
+		assert.False(t, heist.Curated)
+
+		movieTags, err := tagSvc.ListMovieTags(primer)
+		require.NoError(t, err)
+		require.Len(t, movieTags, 1)
+		assert.Equal(t, timeTravel, movieTags[0].ID)
+	})
+
+	t.Run("Suggestions", func(t *testing.T) {
+		suggested, err := tagSvc.TagMovie(userID, matrix, &models.TagMovieRequest{Name: name("Simulated Reality")})
+		require.NoError(t, err)
+		assert.Equal(t, models.SuggestionPending, suggested.Status)
+		assert.Nil(t, suggested.TagID)
+		_, err = tagSvc.TagMovie(otherID, matrix, &models.TagMovieRequest{Name: name("simulated  reality")})
+		assert.ErrorIs(t, err, errs.AlreadyExists)
+		rejected, err := tagSvc.TagMovie(otherID, matrix, &models.TagMovieRequest{TagID: &timeTravel})
+		require.NoError(t, err)
+
+		movieTags, err := tagSvc.ListMovieTags(matrix)
+		require.NoError(t, err)
+		assert.Empty(t, movieTags, "pending tags are not shown")
+
+		mine, err := tagSvc.ListTagSuggestions(userID, models.ListOptions{})
+		require.NoError(t, err)
+		require.Len(t, mine.Items, 1)
+		assert.Equal(t, suggested.ID, mine.Items[0].ID)
+		all, err := tagSvc.ListTagSuggestions(moderatorID, models.ListOptions{Filters: []models.Filter{{Field: "status", Op: models.OpEq, Value: string(models.SuggestionPending)}}})
+		require.NoError(t, err)
+		assert.Len(t, all.Items, 2)
+
+		_, err = tagSvc.ApproveTagSuggestion(userID, suggested.ID)
+		assert.ErrorIs(t, err, errs.Forbidden)
+		approved, err := tagSvc.ApproveTagSuggestion(moderatorID, suggested.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.SuggestionApproved, approved.Status)
+		require.NotNil(t, approved.TagID)
+		_, err = tagSvc.ApproveTagSuggestion(moderatorID, suggested.ID)
+		assert.ErrorIs(t, err, models.ErrSuggestionResolved)
+
+		rejected, err = tagSvc.RejectTagSuggestion(moderatorID, rejected.ID, "not really")
+		require.NoError(t, err)
+		assert.Equal(t, models.SuggestionRejected, rejected.Status)
+		assert.Equal(t, "not really", rejected.Reason)
+
+		movieTags, err = tagSvc.ListMovieTags(matrix)
+		require.NoError(t, err)
+		require.Len(t, movieTags, 1)
+		assert.Equal(t, "simulated reality", movieTags[0].Name)
+		assert.False(t, movieTags[0].Curated)
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		page, err := movieSvc.ListMovies(models.ListOptions{Filters: []models.Filter{{Field: models.FilterTagID, Op: models.OpEq, Value: timeTravel}}})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1, "rejected tags do not count")
+		assert.Equal(t, primer, page.Items[0].ID)
+
+		_, err = movieSvc.ListMovies(models.ListOptions{Filters: []models.Filter{{Field: models.FilterTagID, Op: models.OpGt, Value: timeTravel}}})
+		assert.ErrorIs(t, err, models.ErrInvalidFilter)
+	})
+
+	t.Run("Facets", func(t *testing.T) {
+		require.NoError(t, movies.UpdateRatings(matrix, 0, 9))
+		require.NoError(t, movies.UpdateRatings(primer, 0, 10))
+
+		facets, err := movieSvc.ListMovieFacets(models.ListOptions{Limit: 1}, []string{models.FacetGenre, models.FacetDecade, models.FacetTag, models.FacetRating})
+		require.NoError(t, err)
+		assert.Equal(t, []models.FacetCount{{Value: scifi.String(), Name: "Science Fiction", Count: 2}}, facets[models.FacetGenre])
+		assert.Equal(t, []models.FacetCount{{Value: "1990", Count: 2}, {Value: "2000", Count: 1}}, facets[models.FacetDecade])
+		assert.Len(t, facets[models.FacetTag], 3)
+		assert.Len(t, facets[models.FacetRating], 1, "movies nobody rated are left out")
+
+		facets, err = movieSvc.ListMovieFacets(models.ListOptions{Filters: []models.Filter{{Field: models.FilterTagID, Op: models.OpEq, Value: timeTravel}}}, []string{models.FacetDecade, models.FacetTag})
+		require.NoError(t, err)
+		assert.Len(t, facets, 2)
+		assert.Equal(t, []models.FacetCount{{Value: "2000", Count: 1}}, facets[models.FacetDecade])
+		assert.Equal(t, []models.FacetCount{{Value: timeTravel.String(), Name: "time travel", Count: 1}}, facets[models.FacetTag])
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		assert.ErrorIs(t, tagSvc.UntagMovie(userID, primer, timeTravel), errs.Forbidden)
+		require.NoError(t, tagSvc.UntagMovie(moderatorID, primer, timeTravel))
+		assert.ErrorIs(t, tagSvc.UntagMovie(moderatorID, primer, timeTravel), errs.NotFound)
+
+		assert.ErrorIs(t, tagSvc.DeleteTag(userID, timeTravel), errs.Forbidden)
+		require.NoError(t, tagSvc.DeleteTag(moderatorID, timeTravel))
+		_, err := tagSvc.GetTag(timeTravel)
+		assert.ErrorIs(t, err, errs.NotFound)
+	})
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"go.uber.org/zap"
+)
+
+// TranslationService manages the translations of movie titles and review
+// category names, and puts them in place for clients that prefer another
+// language.
+type TranslationService interface {
+	ListTranslations(kind models.TranslationKind, recordID models.ID) ([]*models.Translation, error)
+	// PutTranslation sets the translation of a record into locale, which
+	// may be in any form ParseLocale accepts.
+	PutTranslation(actorID models.ID, kind models.TranslationKind, recordID models.ID, locale string, fields map[string]string) (*models.Translation, error)
+	DeleteTranslation(actorID models.ID, kind models.TranslationKind, recordID models.ID, locale string) error
+	// LocalizeMovies replaces the titles of movies with their best
+	// translation for locales, which are canonical and in order of
+	// preference. See localize for how translations are picked.
+	LocalizeMovies(movies []*models.Movie, locales []string) error
+	LocalizeReviewCategories(categories []*models.ReviewCategory, locales []string) error
+}
+
+type translationSvc struct {
+	log        *zap.Logger
+	repo       repository.TranslationRepo
+	movieRepo  repository.MovieRepo
+	reviewRepo repository.ReviewRepo
+	userRepo   repository.UserRepo
+}
+
+func NewTranslationService(log *zap.Logger, repo repository.TranslationRepo, movieRepo repository.MovieRepo, reviewRepo repository.ReviewRepo, userRepo repository.UserRepo) TranslationService {
+	return &translationSvc{
+		log:        log,
+		repo:       repo,
+		movieRepo:  movieRepo,
+		reviewRepo: reviewRepo,
+		userRepo:   userRepo,
+	}
+}
+
+func (s *translationSvc) ListTranslations(kind models.TranslationKind, recordID models.ID) ([]*models.Translation, error) {
+	if err := s.checkRecord(kind, recordID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListTranslations(kind, []models.ID{recordID})
+}
+
+func (s *translationSvc) PutTranslation(actorID models.ID, kind models.TranslationKind, recordID models.ID, locale string, fields map[string]string) (*models.Translation, error) {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !HasPermission(actor, ResourceTranslation, ActionUpdate, nil) {
+		return nil, errs.Forbidden
+	}
+
+	locale, err = models.ParseLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, models.ErrInvalidTranslation
+	}
+	for field, value := range fields {
+		if !slices.Contains(models.TranslatableFields[kind], field) || strings.TrimSpace(value) == "" {
+			return nil, models.ErrInvalidTranslation
+		}
+	}
+	if err := s.checkRecord(kind, recordID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.PutTranslation(actorID, &models.Translation{Kind: kind, RecordID: recordID, Locale: locale, Fields: fields})
+}
+
+func (s *translationSvc) DeleteTranslation(actorID models.ID, kind models.TranslationKind, recordID models.ID, locale string) error {
+	actor, err := s.userRepo.GetUserByID(actorID)
+	if err != nil {
+		return err
+	}
+
+	if !HasPermission(actor, ResourceTranslation, ActionDelete, nil) {
+		return errs.Forbidden
+	}
+
+	locale, err = models.ParseLocale(locale)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteTranslation(kind, recordID, locale)
+}
+
+// checkRecord returns errs.NotFound unless the record a translation is for
+// exists. Movies in the trash cannot be translated.
+func (s *translationSvc) checkRecord(kind models.TranslationKind, recordID models.ID) error {
+	switch kind {
+	case models.TranslationMovie:
+		_, err := s.movieRepo.GetMovie(recordID)
+		return err
+	case models.TranslationReviewCategory:
+		_, err := s.reviewRepo.GetReviewCategory(recordID)
+		return err
+	}
+	return errs.NotFound
+}
+
+func (s *translationSvc) LocalizeMovies(movies []*models.Movie, locales []string) error {
+	if len(locales) == 0 || len(movies) == 0 {
+		return nil
+	}
+
+	ids := make([]models.ID, len(movies))
+	for i, movie := range movies {
+		ids[i] = movie.ID
+	}
+	byRecord, err := s.translations(models.TranslationMovie, ids)
+	if err != nil {
+		return err
+	}
+	for _, movie := range movies {
+		if title, ok := localize(byRecord[movie.ID], locales, "title"); ok {
+			movie.Title = title
+		}
+		if synopsis, ok := localize(byRecord[movie.ID], locales, "synopsis"); ok {
+			movie.Synopsis = synopsis
+		}
+	}
+	return nil
+}
+
+func (s *translationSvc) LocalizeReviewCategories(categories []*models.ReviewCategory, locales []string) error {
+	if len(locales) == 0 || len(categories) == 0 {
+		return nil
+	}
+
+	ids := make([]models.ID, len(categories))
+	for i, category := range categories {
+		ids[i] = category.ID
+	}
+	byRecord, err := s.translations(models.TranslationReviewCategory, ids)
+	if err != nil {
+		return err
+	}
+	for _, category := range categories {
+		if name, ok := localize(byRecord[category.ID], locales, "name"); ok {
+			category.Name = name
+		}
+	}
+	return nil
+}
+
+func (s *translationSvc) translations(kind models.TranslationKind, ids []models.ID) (map[models.ID][]*models.Translation, error) {
+	translations, err := s.repo.ListTranslations(kind, ids)
+	if err != nil {
+		return nil, err
+	}
+	byRecord := map[models.ID][]*models.Translation{}
+	for _, t := range translations {
+		byRecord[t.RecordID] = append(byRecord[t.RecordID], t)
+	}
+	return byRecord, nil
+}
+
+// localize picks the value of field for the first of locales that one of
+// translations matches. A translation matches a locale if it is for that
+// locale, or failing that for its language alone ("pt" for "pt-BR"), or
+// failing that for another variant of its language ("pt-PT"). false means
+// the original value should stay.
+func localize(translations []*models.Translation, locales []string, field string) (string, bool) {
+	for _, locale := range locales {
+		lang, _, _ := strings.Cut(locale, "-")
+		best, rank := "", 0
+		for _, t := range translations {
+			value, ok := t.Fields[field]
+			if !ok {
+				continue
+			}
+			switch {
+			case t.Locale == locale:
+				return value, true
+			case t.Locale == lang && rank < 2:
+				best, rank = value, 2
+			case strings.HasPrefix(t.Locale, lang+"-") && rank < 1:
+				best, rank = value, 1
+			}
+		}
+		if rank > 0 {
+			return best, true
+		}
+	}
+	return "", false
+}
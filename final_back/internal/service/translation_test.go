@@ -0,0 +1,91 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTranslations(t *testing.T) {
+	users := repository.NewMemoryUserRepo()
+	movies := repository.NewMemoryMovieRepo()
+	reviews := repository.NewMemoryReviewRepo()
+	translationSvc := NewTranslationService(zap.NewNop(), repository.NewMemoryTranslationRepo(), movies, reviews, users)
+
+	adminID, err := users.CreateUser(models.NilID, &models.User{Username: "admin", Roles: []models.Role{RoleAdmin}})
+	require.NoError(t, err)
+	moderatorID, err := users.CreateUser(models.NilID, &models.User{Username: "moderator", Roles: []models.Role{RoleModerator}})
+	require.NoError(t, err)
+	movieID, err := movies.CreateMovie(adminID, &models.CreateMovieRequest{Title: "The Matrix", Year: 1999})
+	require.NoError(t, err)
+	categories, err := reviews.ListReviewCategories(false)
+	require.NoError(t, err)
+	category := categories[0]
+
+	put := func(locale, title string) {
+		t.Helper()
+		_, err := translationSvc.PutTranslation(adminID, models.TranslationMovie, movieID, locale, map[string]string{"title": title})
+		require.NoError(t, err)
+	}
+
+	t.Run("Put", func(t *testing.T) {
+		title := map[string]string{"title": "Матрица"}
+		_, err := translationSvc.PutTranslation(moderatorID, models.TranslationMovie, movieID, "ru", title)
+		assert.ErrorIs(t, err, errs.Forbidden)
+		_, err = translationSvc.PutTranslation(adminID, models.TranslationMovie, movieID, "not a locale", title)
+		assert.ErrorIs(t, err, models.ErrInvalidLocale)
+		_, err = translationSvc.PutTranslation(adminID, models.TranslationMovie, movieID, "ru", map[string]string{"name": "Матрица"})
+		assert.ErrorIs(t, err, models.ErrInvalidTranslation)
+		_, err = translationSvc.PutTranslation(adminID, models.TranslationMovie, models.NewID(), "ru", title)
+		assert.ErrorIs(t, err, errs.NotFound)
+
+		translation, err := translationSvc.PutTranslation(adminID, models.TranslationMovie, movieID, "pt_br", map[string]string{"title": "Matrix"})
+		require.NoError(t, err)
+		assert.Equal(t, "pt-BR", translation.Locale)
+		require.NoError(t, translationSvc.DeleteTranslation(adminID, models.TranslationMovie, movieID, "pt-br"))
+
+		_, err = translationSvc.PutTranslation(adminID, models.TranslationReviewCategory, category.ID, "en", map[string]string{"name": "Want to rewatch"})
+		require.NoError(t, err)
+		translations, err := translationSvc.ListTranslations(models.TranslationReviewCategory, category.ID)
+		require.NoError(t, err)
+		assert.Len(t, translations, 1)
+	})
+
+	t.Run("Localize", func(t *testing.T) {
+		put("ru", "Матрица")
+		put("pt-PT", "Matrix (PT)")
+		put("pt-BR", "Matrix (BR)")
+		put("es", "Matrix (ES)")
+		put("es-MX", "Matrix (MX)")
+
+		for _, tc := range []struct {
+			locales []string
+			want    string
+		}{
+			{nil, "The Matrix"},
+			{[]string{"de"}, "The Matrix"},
+			{[]string{"ru-RU"}, "Матрица"},
+			{[]string{"de", "ru"}, "Матрица"},
+			{[]string{"pt-BR"}, "Matrix (BR)"},
+			{[]string{"pt-AO"}, "Matrix (BR)"},
+			{[]string{"es-AR"}, "Matrix (ES)"},
+			{[]string{"es-MX", "ru"}, "Matrix (MX)"},
+		} {
+			movie, err := movies.GetMovie(movieID)
+			require.NoError(t, err)
+			require.NoError(t, translationSvc.LocalizeMovies([]*models.Movie{movie}, tc.locales))
+			assert.Equal(t, tc.want, movie.Title, "%v", tc.locales)
+		}
+
+		categories, err := reviews.ListReviewCategories(false)
+		require.NoError(t, err)
+		require.NoError(t, translationSvc.LocalizeReviewCategories(categories, []string{"en-GB"}))
+		assert.Equal(t, "Want to rewatch", categories[0].Name)
+		assert.Equal(t, category.ID, categories[0].ID)
+	})
+}
@@ -1,37 +1,49 @@
 package service
 
 import (
+	"slices"
+
 	"github.com/kakimnsnv/ios_final_back/internal/errs"
 	"github.com/kakimnsnv/ios_final_back/internal/models"
 	"github.com/kakimnsnv/ios_final_back/internal/repository"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService interface {
-	ListUsers() ([]*models.User, error)
+	ListUsers(opts models.ListOptions) (models.Page[*models.User], error)
 	CreateUser(req models.CreateUserRequest) (string, string, error)
 	LoginUser(req models.UserCredentials) (string, string, error)
-	GetUserByID(id *primitive.ObjectID) (*models.User, error)
-	UpdateMe(id *primitive.ObjectID, req models.UpdateMeRequest) (*models.User, error)
-	DeleteMe(id *primitive.ObjectID) error
+	GetUserByID(id models.ID) (*models.User, error)
+	UpdateMe(id models.ID, version int64, req models.UpdateMeRequest) (*models.User, error)
+	DeleteMe(id models.ID) error
 
-	UpdateUser(actorID *primitive.ObjectID, id *primitive.ObjectID, req models.UpdateUserRequest) (*models.User, error)
-	DeleteUser(actorID *primitive.ObjectID, id *primitive.ObjectID) error
+	UpdateUser(actorID models.ID, id models.ID, version int64, req models.UpdateUserRequest) (*models.User, error)
+	DeleteUser(actorID models.ID, id models.ID) error
+	ListDeletedUsers(actorID models.ID) ([]*models.User, error)
+	RestoreUser(actorID models.ID, id models.ID) (*models.User, error)
 }
 
+// userSvc hides the reviews of a user while they are in the trash, taking
+// their ratings out of the aggregates of the movies until the user is
+// restored.
 type userSvc struct {
-	log    *zap.Logger
-	repo   repository.UserRepo
-	jwtSvc JWTService
+	log       *zap.Logger
+	repo      repository.UserRepo
+	movieRepo repository.MovieRepo
+	jwtSvc    JWTService
+	uow       repository.UnitOfWork
+	search    repository.SearchIndex
 }
 
-func NewUserService(log *zap.Logger, repo repository.UserRepo, jwtSvc JWTService) UserService {
+func NewUserService(log *zap.Logger, repo repository.UserRepo, movieRepo repository.MovieRepo, jwtSvc JWTService, uow repository.UnitOfWork, search repository.SearchIndex) UserService {
 	return &userSvc{
-		log:    log,
-		repo:   repo,
-		jwtSvc: jwtSvc,
+		log:       log,
+		repo:      repo,
+		movieRepo: movieRepo,
+		jwtSvc:    jwtSvc,
+		uow:       uow,
+		search:    search,
 	}
 }
 
@@ -42,7 +54,9 @@ func (s *userSvc) CreateUser(req models.CreateUserRequest) (string, string, erro
 		return "", "", err
 	}
 
+	// Users sign themselves up, so the new user is also the actor.
 	user := &models.User{
+		ID:           models.NewID(),
 		Username:     req.Username,
 		PasswordHash: hash,
 		Email:        req.Email,
@@ -50,7 +64,7 @@ func (s *userSvc) CreateUser(req models.CreateUserRequest) (string, string, erro
 			RoleUser,
 		},
 	}
-	id, err := s.repo.CreateUser(user)
+	id, err := s.repo.CreateUser(user.ID, user)
 	if err != nil {
 		s.log.Error("failed to create user", zap.Error(err))
 		return "", "", err
@@ -72,10 +86,10 @@ func (s *userSvc) LoginUser(req models.UserCredentials) (string, string, error)
 		return "", "", err
 	}
 
-	return s.jwtSvc.CreateJWT(*user.ID, user.Roles)
+	return s.jwtSvc.CreateJWT(user.ID, user.Roles)
 }
 
-func (s *userSvc) GetUserByID(id *primitive.ObjectID) (*models.User, error) {
+func (s *userSvc) GetUserByID(id models.ID) (*models.User, error) {
 	user, err := s.repo.GetUserByID(id)
 	if err != nil {
 		s.log.Error("failed to get user by ID", zap.Error(err))
@@ -84,7 +98,7 @@ func (s *userSvc) GetUserByID(id *primitive.ObjectID) (*models.User, error) {
 	return user, nil
 }
 
-func (s *userSvc) UpdateMe(id *primitive.ObjectID, req models.UpdateMeRequest) (*models.User, error) {
+func (s *userSvc) UpdateMe(id models.ID, version int64, req models.UpdateMeRequest) (*models.User, error) {
 	user, err := s.repo.GetUserByID(id)
 	if err != nil {
 		s.log.Error("failed to get user by ID", zap.Error(err))
@@ -99,11 +113,11 @@ func (s *userSvc) UpdateMe(id *primitive.ObjectID, req models.UpdateMeRequest) (
 		user.Email = *req.Email
 	}
 
-	return s.repo.UpdateUser(id, user)
+	return s.repo.UpdateUser(id, id, version, user)
 }
 
-func (s *userSvc) DeleteMe(id *primitive.ObjectID) error {
-	err := s.repo.DeleteUser(id)
+func (s *userSvc) DeleteMe(id models.ID) error {
+	err := s.deleteUser(id, id)
 	if err != nil {
 		s.log.Error("failed to delete user", zap.Error(err))
 		return err
@@ -111,16 +125,16 @@ func (s *userSvc) DeleteMe(id *primitive.ObjectID) error {
 	return nil
 }
 
-func (s *userSvc) ListUsers() ([]*models.User, error) {
-	users, err := s.repo.ListUsers()
+func (s *userSvc) ListUsers(opts models.ListOptions) (models.Page[*models.User], error) {
+	users, err := s.repo.ListUsers(opts)
 	if err != nil {
 		s.log.Error("failed to list users", zap.Error(err))
-		return nil, err
+		return models.Page[*models.User]{}, err
 	}
 	return users, nil
 }
 
-func (s *userSvc) UpdateUser(actorID *primitive.ObjectID, id *primitive.ObjectID, req models.UpdateUserRequest) (*models.User, error) {
+func (s *userSvc) UpdateUser(actorID models.ID, id models.ID, version int64, req models.UpdateUserRequest) (*models.User, error) {
 	actor, err := s.repo.GetUserByID(actorID)
 	if err != nil {
 		s.log.Error("failed to get actor by ID", zap.Error(err))
@@ -138,10 +152,27 @@ func (s *userSvc) UpdateUser(actorID *primitive.ObjectID, id *primitive.ObjectID
 		return nil, errs.Forbidden
 	}
 
-	return s.repo.UpdateUser(id, user)
+	if req.Roles != nil && !slices.Contains(actor.Roles, RoleAdmin) {
+		s.log.Error("only admins can change roles")
+		return nil, errs.Forbidden
+	}
+
+	if req.Username != nil {
+		user.Username = *req.Username
+	}
+
+	if req.Email != "" {
+		user.Email = req.Email
+	}
+
+	if req.Roles != nil {
+		user.Roles = req.Roles
+	}
+
+	return s.repo.UpdateUser(actorID, id, version, user)
 }
 
-func (s *userSvc) DeleteUser(actorID *primitive.ObjectID, id *primitive.ObjectID) error {
+func (s *userSvc) DeleteUser(actorID models.ID, id models.ID) error {
 	actor, err := s.repo.GetUserByID(actorID)
 	if err != nil {
 		s.log.Error("failed to get actor by ID", zap.Error(err))
@@ -159,5 +190,106 @@ func (s *userSvc) DeleteUser(actorID *primitive.ObjectID, id *primitive.ObjectID
 		return errs.Forbidden
 	}
 
-	return s.repo.DeleteUser(id)
+	return s.deleteUser(actorID, id)
+}
+
+// deleteUser moves the user to the trash together with their reviews in
+// one unit of work.
+func (s *userSvc) deleteUser(actorID, id models.ID) error {
+	var reviews []*models.Review
+	err := s.uow.Do(func(repos repository.Repos) error {
+		if err := repos.Users.DeleteUser(actorID, id); err != nil {
+			return err
+		}
+		var err error
+		if reviews, err = repos.Reviews.TrashReviewsWithOwner(id, true); err != nil {
+			return err
+		}
+		return moveOwnerRatings(repos, reviews)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.reindex(reviews)
+	return nil
+}
+
+func (s *userSvc) ListDeletedUsers(actorID models.ID) ([]*models.User, error) {
+	actor, err := s.repo.GetUserByID(actorID)
+	if err != nil {
+		s.log.Error("failed to get actor by ID", zap.Error(err))
+		return nil, err
+	}
+
+	if !HasPermission(actor, ResourceUser, ActionRestore, nil) {
+		s.log.Error("user does not have permission to list deleted users")
+		return nil, errs.Forbidden
+	}
+
+	return s.repo.ListDeletedUsers()
+}
+
+func (s *userSvc) RestoreUser(actorID models.ID, id models.ID) (*models.User, error) {
+	actor, err := s.repo.GetUserByID(actorID)
+	if err != nil {
+		s.log.Error("failed to get actor by ID", zap.Error(err))
+		return nil, err
+	}
+
+	if !HasPermission(actor, ResourceUser, ActionRestore, nil) {
+		s.log.Error("user does not have permission to restore user")
+		return nil, errs.Forbidden
+	}
+
+	var (
+		restored *models.User
+		reviews  []*models.Review
+	)
+	err = s.uow.Do(func(repos repository.Repos) error {
+		var err error
+		if restored, err = repos.Users.RestoreUser(actorID, id); err != nil {
+			return err
+		}
+		if reviews, err = repos.Reviews.TrashReviewsWithOwner(id, false); err != nil {
+			return err
+		}
+		return moveOwnerRatings(repos, reviews)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.reindex(reviews)
+	return restored, nil
+}
+
+// moveOwnerRatings takes the ratings of reviews whose owner moved to the
+// trash out of the aggregates of their movies, or puts them back for an
+// owner who left it.
+func moveOwnerRatings(repos repository.Repos, reviews []*models.Review) error {
+	for _, review := range reviews {
+		counted := *review
+		counted.OwnerTrashed = false
+		remove, add := models.CountedRating(&counted), 0
+		if !review.OwnerTrashed {
+			remove, add = add, remove
+		}
+		if err := repos.Movies.UpdateRatings(review.MovieID, remove, add); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reindex refreshes the movies of reviews in the search index, since their
+// ratings weigh in on ranking.
+func (s *userSvc) reindex(reviews []*models.Review) {
+	reindexed := map[models.ID]bool{}
+	for _, review := range reviews {
+		if !reindexed[review.MovieID] {
+			reindexed[review.MovieID] = true
+			reindexMovie(s.log, s.movieRepo, s.search, review.MovieID)
+		}
+	}
 }
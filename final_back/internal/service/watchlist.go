@@ -0,0 +1,138 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"go.uber.org/zap"
+)
+
+// WatchlistService keeps the movies users want to see. Every user has their
+// own watchlist, which only they can see and change.
+type WatchlistService interface {
+	// ListWatchlist lists the entries of a user's watchlist whose movie is
+	// not in the trash, each with its movie filled in.
+	ListWatchlist(actorID models.ID, opts models.ListOptions) (models.Page[*models.WatchlistEntry], error)
+	AddToWatchlist(actorID models.ID, movieID models.ID) (models.ID, error)
+	RemoveFromWatchlist(actorID models.ID, movieID models.ID) error
+	// CalendarToken returns the secret token of a user's watchlist calendar,
+	// making one the first time it is asked for.
+	CalendarToken(actorID models.ID) (string, error)
+	// ResetCalendarToken gives a user a new calendar token, so that the
+	// address of the old one stops working.
+	ResetCalendarToken(actorID models.ID) (string, error)
+}
+
+type watchlistSvc struct {
+	log       *zap.Logger
+	repo      repository.WatchlistRepo
+	movieRepo repository.MovieRepo
+	userRepo  repository.UserRepo
+}
+
+func NewWatchlistService(log *zap.Logger, repo repository.WatchlistRepo, movieRepo repository.MovieRepo, userRepo repository.UserRepo) WatchlistService {
+	return &watchlistSvc{
+		log:       log,
+		repo:      repo,
+		movieRepo: movieRepo,
+		userRepo:  userRepo,
+	}
+}
+
+// ListWatchlist leaves out the entries of movies in the trash, so a page may
+// hold fewer than opts.Limit entries even when more follow.
+func (s *watchlistSvc) ListWatchlist(actorID models.ID, opts models.ListOptions) (models.Page[*models.WatchlistEntry], error) {
+	if _, err := s.userRepo.GetUserByID(actorID); err != nil {
+		return models.Page[*models.WatchlistEntry]{}, err
+	}
+
+	opts.Filters = append(opts.Filters, models.Filter{Field: "userId", Op: models.OpEq, Value: actorID})
+	entries, err := s.repo.ListWatchlistEntries(opts)
+	if err != nil {
+		return models.Page[*models.WatchlistEntry]{}, err
+	}
+
+	ids := []models.ID{}
+	for _, entry := range entries.Items {
+		ids = append(ids, entry.MovieID)
+	}
+	movies, err := s.movieRepo.ListMovies(models.ListOptions{IDs: ids})
+	if err != nil {
+		return models.Page[*models.WatchlistEntry]{}, err
+	}
+	byID := make(map[models.ID]*models.Movie, len(movies.Items))
+	for _, movie := range movies.Items {
+		byID[movie.ID] = movie
+	}
+
+	live := []*models.WatchlistEntry{}
+	for _, entry := range entries.Items {
+		if entry.Movie = byID[entry.MovieID]; entry.Movie != nil {
+			live = append(live, entry)
+		}
+	}
+	entries.Items = live
+	return entries, nil
+}
+
+// AddToWatchlist returns errs.InvalidReference if the movie does not exist
+// or is in the trash.
+func (s *watchlistSvc) AddToWatchlist(actorID models.ID, movieID models.ID) (models.ID, error) {
+	if _, err := s.userRepo.GetUserByID(actorID); err != nil {
+		return models.NilID, err
+	}
+
+	if _, err := s.movieRepo.GetMovie(movieID); err == errs.NotFound {
+		return models.NilID, errs.InvalidReference
+	} else if err != nil {
+		return models.NilID, err
+	}
+	return s.repo.AddToWatchlist(actorID, movieID)
+}
+
+func (s *watchlistSvc) RemoveFromWatchlist(actorID models.ID, movieID models.ID) error {
+	if _, err := s.userRepo.GetUserByID(actorID); err != nil {
+		return err
+	}
+	return s.repo.RemoveFromWatchlist(actorID, movieID)
+}
+
+func (s *watchlistSvc) CalendarToken(actorID models.ID) (string, error) {
+	if _, err := s.userRepo.GetUserByID(actorID); err != nil {
+		return "", err
+	}
+
+	token, err := s.repo.GetCalendarToken(actorID)
+	if err == errs.NotFound {
+		return s.ResetCalendarToken(actorID)
+	}
+	return token, err
+}
+
+func (s *watchlistSvc) ResetCalendarToken(actorID models.ID) (string, error) {
+	if _, err := s.userRepo.GetUserByID(actorID); err != nil {
+		return "", err
+	}
+
+	token, err := newCalendarToken()
+	if err != nil {
+		return "", err
+	}
+	if err := s.repo.SetCalendarToken(actorID, token); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// newCalendarToken returns 256 random bits in a form that fits in a URL
+// path.
+func newCalendarToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/kakimnsnv/ios_final_back/internal/errs"
+	"github.com/kakimnsnv/ios_final_back/internal/models"
+	"github.com/kakimnsnv/ios_final_back/internal/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestWatchlist(t *testing.T) {
+	users := repository.NewMemoryUserRepo()
+	movies := repository.NewMemoryMovieRepo()
+	watchlistSvc := NewWatchlistService(zap.NewNop(), repository.NewMemoryWatchlistRepo(), movies, users)
+
+	userID, err := users.CreateUser(models.NilID, &models.User{Username: "neo", Roles: []models.Role{RoleUser}})
+	require.NoError(t, err)
+	otherID, err := users.CreateUser(models.NilID, &models.User{Username: "trinity", Roles: []models.Role{RoleUser}})
+	require.NoError(t, err)
+	matrix, err := movies.CreateMovie(userID, &models.CreateMovieRequest{Title: "The Matrix", Year: 1999})
+	require.NoError(t, err)
+	primer, err := movies.CreateMovie(userID, &models.CreateMovieRequest{Title: "Primer", Year: 2004})
+	require.NoError(t, err)
+
+	t.Run("Add", func(t *testing.T) {
+		_, err := watchlistSvc.AddToWatchlist(userID, models.NewID())
+		assert.ErrorIs(t, err, errs.InvalidReference)
+
+		_, err = watchlistSvc.AddToWatchlist(userID, matrix)
+		require.NoError(t, err)
+		_, err = watchlistSvc.AddToWatchlist(userID, primer)
+		require.NoError(t, err)
+		_, err = watchlistSvc.AddToWatchlist(userID, matrix)
+		assert.ErrorIs(t, err, errs.AlreadyExists)
+		_, err = watchlistSvc.AddToWatchlist(otherID, matrix)
+		require.NoError(t, err)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		page, err := watchlistSvc.ListWatchlist(userID, models.ListOptions{})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 2, "only the user's own entries are listed")
+		for _, entry := range page.Items {
+			require.NotNil(t, entry.Movie)
+			assert.Equal(t, entry.MovieID, entry.Movie.ID)
+		}
+
+		require.NoError(t, movies.DeleteMovie(userID, primer))
+		page, err = watchlistSvc.ListWatchlist(userID, models.ListOptions{})
+		require.NoError(t, err)
+		require.Len(t, page.Items, 1, "movies in the trash are left out")
+		assert.Equal(t, matrix, page.Items[0].MovieID)
+
+		_, err = watchlistSvc.AddToWatchlist(otherID, primer)
+		assert.ErrorIs(t, err, errs.InvalidReference)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		require.NoError(t, watchlistSvc.RemoveFromWatchlist(userID, matrix))
+		assert.ErrorIs(t, watchlistSvc.RemoveFromWatchlist(userID, matrix), errs.NotFound)
+
+		page, err := watchlistSvc.ListWatchlist(otherID, models.ListOptions{})
+		require.NoError(t, err)
+		assert.Len(t, page.Items, 1, "other watchlists are left alone")
+	})
+
+	t.Run("CalendarToken", func(t *testing.T) {
+		token, err := watchlistSvc.CalendarToken(userID)
+		require.NoError(t, err)
+		assert.Len(t, token, 43)
+		again, err := watchlistSvc.CalendarToken(userID)
+		require.NoError(t, err)
+		assert.Equal(t, token, again)
+
+		other, err := watchlistSvc.CalendarToken(otherID)
+		require.NoError(t, err)
+		assert.NotEqual(t, token, other)
+
+		reset, err := watchlistSvc.ResetCalendarToken(userID)
+		require.NoError(t, err)
+		assert.NotEqual(t, token, reset)
+		again, err = watchlistSvc.CalendarToken(userID)
+		require.NoError(t, err)
+		assert.Equal(t, reset, again)
+	})
+}